@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// flagBenchmark runs every scanner and prints a timing table (--benchmark,
+// hidden).
+var flagBenchmark bool
+
+// benchmarkRow is one scanner's measurements in the --benchmark table.
+type benchmarkRow struct {
+	Label    string
+	Duration time.Duration
+	Bytes    int64
+	Items    int
+	Err      error
+}
+
+// runBenchmark runs all scanners registered with e and collects one row
+// per scanner, sorted by duration, slowest first. total is the whole
+// scan's wall-clock time. Nothing is deleted.
+func runBenchmark(e *engine.Engine) (rows []benchmarkRow, total time.Duration) {
+	events, done := e.ScanAll(context.Background(), nil)
+	for event := range events {
+		switch event.Type {
+		case engine.EventScannerDone:
+			row := benchmarkRow{Label: event.Label, Duration: event.Duration}
+			for _, cat := range event.Results {
+				row.Bytes += cat.TotalSize
+				row.Items += len(cat.Entries)
+			}
+			rows = append(rows, row)
+		case engine.EventScannerError:
+			rows = append(rows, benchmarkRow{Label: event.Label, Err: event.Err})
+		case engine.EventScanComplete:
+			total = event.Duration
+		}
+	}
+	<-done
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		return rows[i].Duration > rows[j].Duration
+	})
+	return rows, total
+}
+
+// printBenchmark prints the --benchmark profiling table with each
+// scanner's duration, bytes found and item count, and the throughput.
+func printBenchmark(w io.Writer, rows []benchmarkRow, total time.Duration) {
+	bold := color.New(color.Bold)
+	fmt.Fprintln(w)
+	_, _ = bold.Fprintln(w, "Scanner Benchmark")
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "  SCANNER\tDURATION\tSIZE\tITEMS\tRATE\t")
+	for _, row := range rows {
+		if row.Err != nil {
+			fmt.Fprintf(tw, "  %s\t-\t-\t-\terror: %v\t\n", row.Label, row.Err)
+			continue
+		}
+		rate := "-"
+		if secs := row.Duration.Seconds(); secs > 0 {
+			rate = scan.FormatSize(int64(float64(row.Bytes)/secs)) + "/s"
+		}
+		fmt.Fprintf(tw, "  %s\t%.3fs\t%s\t%d\t%s\t\n",
+			row.Label, row.Duration.Seconds(), scan.FormatSize(row.Bytes), row.Items, rate)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "  Total: %.3fs across %d scanners\n", total.Seconds(), len(rows))
+}
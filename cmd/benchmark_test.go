@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+func TestRunBenchmark_ReportsEachScanner(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	e := engine.New()
+	e.Register(engine.NewScanner(engine.ScannerInfo{ID: "fast", Name: "Fast Scanner"}, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{{
+			Category:  "fast-cat",
+			TotalSize: 2000,
+			Entries:   []scan.ScanEntry{{Path: "/tmp/a", Size: 1000}, {Path: "/tmp/b", Size: 1000}},
+		}}, nil
+	}))
+	e.Register(engine.NewScanner(engine.ScannerInfo{ID: "slow", Name: "Slow Scanner"}, func() ([]scan.CategoryResult, error) {
+		time.Sleep(20 * time.Millisecond)
+		return nil, nil
+	}))
+	e.Register(engine.NewScanner(engine.ScannerInfo{ID: "broken", Name: "Broken Scanner"}, func() ([]scan.CategoryResult, error) {
+		return nil, errors.New("boom")
+	}))
+
+	rows, total := runBenchmark(e)
+	if len(rows) != 3 {
+		t.Fatalf("expected 3 rows, got %d", len(rows))
+	}
+	if rows[0].Label != "Slow Scanner" {
+		t.Errorf("expected slowest scanner first, got %q", rows[0].Label)
+	}
+	for _, row := range rows {
+		if row.Duration < 0 {
+			t.Errorf("%s: negative duration %v", row.Label, row.Duration)
+		}
+		if row.Label == "Fast Scanner" && (row.Bytes != 2000 || row.Items != 2) {
+			t.Errorf("Fast Scanner row = %+v, want 2000 bytes and 2 items", row)
+		}
+	}
+	if total <= 0 {
+		t.Errorf("expected positive total duration, got %v", total)
+	}
+
+	var buf bytes.Buffer
+	printBenchmark(&buf, rows, total)
+	out := buf.String()
+	for _, want := range []string{"Scanner Benchmark", "Fast Scanner", "Slow Scanner", "Broken Scanner", "error: boom", "across 3 scanners"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "Slow Scanner") > strings.Index(out, "Fast Scanner") {
+		t.Errorf("table not sorted by duration:\n%s", out)
+	}
+}
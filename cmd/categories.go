@@ -1,5 +1,15 @@
 package cmd
 
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
 // categoryDef describes a single scannable category within a scanner group.
 type categoryDef struct {
 	FlagName    string // targeted scan flag name, e.g. "npm" (empty if no per-item flag)
@@ -7,6 +17,7 @@ type categoryDef struct {
 	Description string // human-readable, e.g. "npm cache"
 	SkipFlag    *bool  // pointer to skip flag variable (nil if no skip flag)
 	ScanFlag    *bool  // pointer to targeted scan flag variable (nil if no targeted flag)
+	TimeBased   bool   // entries carry ModTime; --keep-recent applies
 }
 
 // groupDef describes a scanner group containing multiple categories.
@@ -20,49 +31,82 @@ type groupDef struct {
 	Items       []categoryDef // categories produced by this scanner
 }
 
+// flagCategoriesFile names a file of category IDs to scan (--categories-file).
+var flagCategoriesFile string
+
+// Category ID patterns (--category-include, --category-exclude) and their
+// compiled forms, set by compileCategoryPatterns.
+var (
+	flagCategoryInclude string
+	flagCategoryExclude string
+	categoryInclude     *regexp.Regexp
+	categoryExclude     *regexp.Regexp
+)
+
 // Targeted scan flag variables — registered on the scan subcommand only.
 var (
-	flagScanQuicklook         bool
-	flagScanSafari            bool
-	flagScanChrome            bool
-	flagScanFirefox           bool
-	flagScanDerivedData       bool
-	flagScanNpm               bool
-	flagScanYarn              bool
-	flagScanHomebrew          bool
-	flagScanDocker            bool
-	flagScanSimulatorCaches   bool
-	flagScanSimulatorLogs     bool
-	flagScanXcodeDevSupport   bool
-	flagScanXcodeArchives     bool
-	flagScanPnpm              bool
-	flagScanCocoapods         bool
-	flagScanGradle            bool
-	flagScanPip               bool
-	flagScanOrphanedPrefs     bool
-	flagScanIosBackups        bool
-	flagScanOldDownloads      bool
-	flagScanAdobe             bool
-	flagScanAdobeMedia        bool
-	flagScanSketch            bool
-	flagScanFigma             bool
-	flagScanSlack             bool
-	flagScanDiscord           bool
-	flagScanTeams             bool
-	flagScanZoom              bool
-	flagScanPhotosCaches      bool
-	flagScanPhotosAnalysis    bool
-	flagScanPhotosIcloudCache bool
-	flagScanPhotosSyndication bool
-	flagScanSpotlight         bool
-	flagScanMail              bool
-	flagScanMailDownloads     bool
-	flagScanMessages          bool
-	flagScanIOSUpdates        bool
-	flagScanTimemachine       bool
-	flagScanVMParallels       bool
-	flagScanVMUTM             bool
-	flagScanVMVMware          bool
+	flagScanQuicklook               bool
+	flagScanSysdiagnose             bool
+	flagScanBrokenSymlinks          bool
+	flagScanInstallerLeftovers      bool
+	flagScanTmpCaches               bool
+	flagScanICloudDriveCache        bool
+	flagScanSafari                  bool
+	flagScanChrome                  bool
+	flagScanChromeStorage           bool
+	flagScanSafariFavicons          bool
+	flagScanSafariWebsiteData       bool
+	flagScanFirefox                 bool
+	flagScanDerivedData             bool
+	flagScanXcodeIndex              bool
+	flagScanNpm                     bool
+	flagScanYarn                    bool
+	flagScanHomebrew                bool
+	flagScanBrewAutoremove          bool
+	flagScanNodeModules             bool
+	flagScanPyEnvs                  bool
+	flagScanDocker                  bool
+	flagScanSimulatorCaches         bool
+	flagScanSimulatorLogs           bool
+	flagScanXcodeDevSupport         bool
+	flagScanXcodeArchives           bool
+	flagScanMobileDevice            bool
+	flagScanDockerVM                bool
+	flagScanPnpm                    bool
+	flagScanCocoapods               bool
+	flagScanGradle                  bool
+	flagScanPip                     bool
+	flagScanOrphanedPrefs           bool
+	flagScanOrphanedGroupContainers bool
+	flagScanIosBackups              bool
+	flagScanOldDownloads            bool
+	flagScanAdobe                   bool
+	flagScanAdobeMedia              bool
+	flagScanSketch                  bool
+	flagScanFigma                   bool
+	flagScanAdobeLogs               bool
+	flagScanFigmaProfile            bool
+	flagScanSlack                   bool
+	flagScanDiscord                 bool
+	flagScanTeams                   bool
+	flagScanZoom                    bool
+	flagScanZoomRecordings          bool
+	flagScanSlackDownloads          bool
+	flagScanPhotosCaches            bool
+	flagScanPhotosAnalysis          bool
+	flagScanPhotosIcloudCache       bool
+	flagScanPhotosSyndication       bool
+	flagScanSpotlight               bool
+	flagScanMail                    bool
+	flagScanMailEnvelope            bool
+	flagScanMailDownloads           bool
+	flagScanMessages                bool
+	flagScanIOSUpdates              bool
+	flagScanTimemachine             bool
+	flagScanUnifiedLogs             bool
+	flagScanVMParallels             bool
+	flagScanVMUTM                   bool
+	flagScanVMVMware                bool
 )
 
 // scanGroups is the central registry of all scanner groups and their
@@ -74,13 +118,18 @@ var scanGroups = []groupDef{
 		FlagName:    "system-caches",
 		ScannerID:   "system",
 		GroupName:   "System Caches",
-		Description: "user app caches, logs, and QuickLook thumbnails",
+		Description: "user app caches, logs, QuickLook thumbnails, diagnostic archives, broken symlinks, and installer leftovers",
 		ScanFlag:    &flagSystemCaches,
 		SkipFlag:    &flagSkipSystemCaches,
 		Items: []categoryDef{
 			{CategoryID: "system-caches", Description: "user app caches"},
 			{CategoryID: "system-logs", Description: "user logs"},
 			{FlagName: "quicklook", CategoryID: "quicklook", Description: "QuickLook thumbnails", SkipFlag: &flagSkipQuicklook, ScanFlag: &flagScanQuicklook},
+			{FlagName: "sysdiagnose", CategoryID: "system-sysdiagnose", Description: "sysdiagnose and spindump archives", SkipFlag: &flagSkipSysdiagnose, ScanFlag: &flagScanSysdiagnose},
+			{FlagName: "broken-symlinks", CategoryID: "system-broken-symlinks", Description: "broken symlinks in bin directories", SkipFlag: &flagSkipBrokenSymlinks, ScanFlag: &flagScanBrokenSymlinks},
+			{FlagName: "installer-leftovers", CategoryID: "system-installer-leftovers", Description: "installer receipts and partial App Store downloads", SkipFlag: &flagSkipInstallerLeftovers, ScanFlag: &flagScanInstallerLeftovers},
+			{FlagName: "tmp-caches", CategoryID: "system-tmp-caches", Description: "temporary app caches in /private/var/folders (opt-in)", SkipFlag: &flagSkipTmpCaches, ScanFlag: &flagScanTmpCaches},
+			{FlagName: "iclouddrive-cache", CategoryID: "system-iclouddrive-cache", Description: "iCloud Drive evictable cache (re-downloaded on demand)", SkipFlag: &flagSkipICloudDriveCache, ScanFlag: &flagScanICloudDriveCache},
 		},
 	},
 	{
@@ -92,7 +141,10 @@ var scanGroups = []groupDef{
 		SkipFlag:    &flagSkipBrowserData,
 		Items: []categoryDef{
 			{FlagName: "safari", CategoryID: "browser-safari", Description: "Safari cache", SkipFlag: &flagSkipSafari, ScanFlag: &flagScanSafari},
+			{FlagName: "safari-favicons", CategoryID: "browser-safari-favicons", Description: "Safari favicon and touch icon caches", SkipFlag: &flagSkipSafariFavicons, ScanFlag: &flagScanSafariFavicons},
+			{FlagName: "safari-website-data", CategoryID: "browser-safari-website-data", Description: "Safari local storage and databases (signs you out of sites)", SkipFlag: &flagSkipSafariWebsiteData, ScanFlag: &flagScanSafariWebsiteData},
 			{FlagName: "chrome", CategoryID: "browser-chrome", Description: "Chrome cache", SkipFlag: &flagSkipChrome, ScanFlag: &flagScanChrome},
+			{FlagName: "chrome-storage", CategoryID: "browser-chrome-storage", Description: "Chrome service worker and code caches", SkipFlag: &flagSkipChromeStorage, ScanFlag: &flagScanChromeStorage},
 			{FlagName: "firefox", CategoryID: "browser-firefox", Description: "Firefox cache", SkipFlag: &flagSkipFirefox, ScanFlag: &flagScanFirefox},
 		},
 	},
@@ -105,9 +157,11 @@ var scanGroups = []groupDef{
 		SkipFlag:    &flagSkipDevCaches,
 		Items: []categoryDef{
 			{FlagName: "derived-data", CategoryID: "dev-xcode", Description: "Xcode DerivedData", SkipFlag: &flagSkipDerivedData, ScanFlag: &flagScanDerivedData},
+			{FlagName: "xcode-index", CategoryID: "dev-xcode-index", Description: "Xcode index stores in DerivedData", SkipFlag: &flagSkipXcodeIndex, ScanFlag: &flagScanXcodeIndex},
 			{FlagName: "npm", CategoryID: "dev-npm", Description: "npm cache", SkipFlag: &flagSkipNpm, ScanFlag: &flagScanNpm},
 			{FlagName: "yarn", CategoryID: "dev-yarn", Description: "Yarn cache", SkipFlag: &flagSkipYarn, ScanFlag: &flagScanYarn},
 			{FlagName: "homebrew", CategoryID: "dev-homebrew", Description: "Homebrew cache", SkipFlag: &flagSkipHomebrew, ScanFlag: &flagScanHomebrew},
+			{FlagName: "brew-autoremove", CategoryID: "dev-brew-autoremove", Description: "unneeded Homebrew dependencies (brew autoremove)", SkipFlag: &flagSkipBrewAutoremove, ScanFlag: &flagScanBrewAutoremove},
 			{FlagName: "docker", CategoryID: "dev-docker", Description: "Docker reclaimable space", SkipFlag: &flagSkipDocker, ScanFlag: &flagScanDocker},
 			{FlagName: "pnpm", CategoryID: "dev-pnpm", Description: "pnpm store", SkipFlag: &flagSkipPnpm, ScanFlag: &flagScanPnpm},
 			{FlagName: "cocoapods", CategoryID: "dev-cocoapods", Description: "CocoaPods cache", SkipFlag: &flagSkipCocoapods, ScanFlag: &flagScanCocoapods},
@@ -117,6 +171,10 @@ var scanGroups = []groupDef{
 			{FlagName: "simulator-logs", CategoryID: "dev-simulator-logs", Description: "iOS Simulator logs", SkipFlag: &flagSkipSimulatorLogs, ScanFlag: &flagScanSimulatorLogs},
 			{FlagName: "xcode-device-support", CategoryID: "dev-xcode-device-support", Description: "Xcode Device Support files", SkipFlag: &flagSkipXcodeDevSupport, ScanFlag: &flagScanXcodeDevSupport},
 			{FlagName: "xcode-archives", CategoryID: "dev-xcode-archives", Description: "Xcode Archives", SkipFlag: &flagSkipXcodeArchives, ScanFlag: &flagScanXcodeArchives},
+			{FlagName: "mobiledevice", CategoryID: "dev-mobiledevice", Description: "Xcode Products and expired provisioning profiles", SkipFlag: &flagSkipMobileDevice, ScanFlag: &flagScanMobileDevice},
+			{FlagName: "docker-vm", CategoryID: "dev-docker-vm", Description: "Docker Desktop VM disk image", SkipFlag: &flagSkipDockerVM, ScanFlag: &flagScanDockerVM},
+			{FlagName: "node-modules", CategoryID: "dev-node-modules", Description: "stale node_modules under project roots (opt-in)", SkipFlag: &flagSkipNodeModules, ScanFlag: &flagScanNodeModules, TimeBased: true},
+			{FlagName: "pyenvs", CategoryID: "dev-pyenvs", Description: "stale Python virtualenvs and __pycache__ under project roots (opt-in)", SkipFlag: &flagSkipPyEnvs, ScanFlag: &flagScanPyEnvs, TimeBased: true},
 		},
 	},
 	{
@@ -128,8 +186,9 @@ var scanGroups = []groupDef{
 		SkipFlag:    &flagSkipAppLeftovers,
 		Items: []categoryDef{
 			{FlagName: "orphaned-prefs", CategoryID: "app-orphaned-prefs", Description: "orphaned preferences", SkipFlag: &flagSkipOrphanedPrefs, ScanFlag: &flagScanOrphanedPrefs},
-			{FlagName: "ios-backups", CategoryID: "app-ios-backups", Description: "iOS device backups", SkipFlag: &flagSkipIosBackups, ScanFlag: &flagScanIosBackups},
-			{FlagName: "old-downloads", CategoryID: "app-old-downloads", Description: "old Downloads files", SkipFlag: &flagSkipOldDownloads, ScanFlag: &flagScanOldDownloads},
+			{FlagName: "orphaned-group-containers", CategoryID: "app-orphaned-group-containers", Description: "orphaned Group Containers (report-only unless targeted)", SkipFlag: &flagSkipOrphanedGroupContainers, ScanFlag: &flagScanOrphanedGroupContainers},
+			{FlagName: "ios-backups", CategoryID: "app-ios-backups", Description: "iOS device backups", SkipFlag: &flagSkipIosBackups, ScanFlag: &flagScanIosBackups, TimeBased: true},
+			{FlagName: "old-downloads", CategoryID: "app-old-downloads", Description: "old Downloads files", SkipFlag: &flagSkipOldDownloads, ScanFlag: &flagScanOldDownloads, TimeBased: true},
 		},
 	},
 	{
@@ -144,13 +203,15 @@ var scanGroups = []groupDef{
 			{FlagName: "adobe-media", CategoryID: "creative-adobe-media", Description: "Adobe media caches", SkipFlag: &flagSkipAdobeMedia, ScanFlag: &flagScanAdobeMedia},
 			{FlagName: "sketch", CategoryID: "creative-sketch", Description: "Sketch cache", SkipFlag: &flagSkipSketch, ScanFlag: &flagScanSketch},
 			{FlagName: "figma", CategoryID: "creative-figma", Description: "Figma cache", SkipFlag: &flagSkipFigma, ScanFlag: &flagScanFigma},
+			{FlagName: "adobe-logs", CategoryID: "creative-adobe-logs", Description: "Adobe installer logs", SkipFlag: &flagSkipAdobeLogs, ScanFlag: &flagScanAdobeLogs},
+			{FlagName: "figma-profile", CategoryID: "creative-figma-profile", Description: "Figma desktop profile", SkipFlag: &flagSkipFigmaProfile, ScanFlag: &flagScanFigmaProfile},
 		},
 	},
 	{
 		FlagName:    "messaging-caches",
 		ScannerID:   "messaging",
 		GroupName:   "Messaging App Caches",
-		Description: "Slack, Discord, Teams, and Zoom caches",
+		Description: "Slack, Discord, Teams, and Zoom caches, recordings, and downloads",
 		ScanFlag:    &flagMessagingCaches,
 		SkipFlag:    &flagSkipMessagingCaches,
 		Items: []categoryDef{
@@ -158,6 +219,8 @@ var scanGroups = []groupDef{
 			{FlagName: "discord", CategoryID: "msg-discord", Description: "Discord cache", SkipFlag: &flagSkipDiscord, ScanFlag: &flagScanDiscord},
 			{FlagName: "teams", CategoryID: "msg-teams", Description: "Microsoft Teams cache", SkipFlag: &flagSkipTeams, ScanFlag: &flagScanTeams},
 			{FlagName: "zoom", CategoryID: "msg-zoom", Description: "Zoom cache", SkipFlag: &flagSkipZoom, ScanFlag: &flagScanZoom},
+			{FlagName: "zoom-recordings", CategoryID: "msg-zoom-recordings", Description: "Zoom local meeting recordings", SkipFlag: &flagSkipZoomRecordings, ScanFlag: &flagScanZoomRecordings},
+			{FlagName: "slack-downloads", CategoryID: "msg-slack-downloads", Description: "Slack downloaded files", SkipFlag: &flagSkipSlackDownloads, ScanFlag: &flagScanSlackDownloads},
 		},
 	},
 	{
@@ -195,10 +258,12 @@ var scanGroups = []groupDef{
 		Items: []categoryDef{
 			{FlagName: "spotlight", CategoryID: "sysdata-spotlight", Description: "CoreSpotlight metadata", SkipFlag: &flagSkipSpotlight, ScanFlag: &flagScanSpotlight},
 			{FlagName: "mail", CategoryID: "sysdata-mail", Description: "Mail database", SkipFlag: &flagSkipMail, ScanFlag: &flagScanMail},
+			{FlagName: "mail-envelope", CategoryID: "sysdata-mail-envelope", Description: "Mail Envelope Index (rebuilt by Mail)", SkipFlag: &flagSkipMailEnvelope, ScanFlag: &flagScanMailEnvelope},
 			{FlagName: "mail-downloads", CategoryID: "sysdata-mail-downloads", Description: "Mail attachment cache", SkipFlag: &flagSkipMailDownloads, ScanFlag: &flagScanMailDownloads},
 			{FlagName: "messages", CategoryID: "sysdata-messages", Description: "Messages attachments", SkipFlag: &flagSkipMessages, ScanFlag: &flagScanMessages},
 			{FlagName: "ios-updates", CategoryID: "sysdata-ios-updates", Description: "iOS software updates", SkipFlag: &flagSkipIOSUpdates, ScanFlag: &flagScanIOSUpdates},
 			{FlagName: "timemachine", CategoryID: "sysdata-timemachine", Description: "Time Machine local snapshots", SkipFlag: &flagSkipTimemachine, ScanFlag: &flagScanTimemachine},
+			{FlagName: "unified-logs", CategoryID: "sysdata-unified-logs", Description: "unified logs", SkipFlag: &flagSkipUnifiedLogs, ScanFlag: &flagScanUnifiedLogs},
 			{FlagName: "vm-parallels", CategoryID: "sysdata-vm-parallels", Description: "Parallels VMs", SkipFlag: &flagSkipVMParallels, ScanFlag: &flagScanVMParallels},
 			{FlagName: "vm-utm", CategoryID: "sysdata-vm-utm", Description: "UTM VMs", SkipFlag: &flagSkipVMUTM, ScanFlag: &flagScanVMUTM},
 			{FlagName: "vm-vmware", CategoryID: "sysdata-vm-vmware", Description: "VMware Fusion VMs", SkipFlag: &flagSkipVMVMware, ScanFlag: &flagScanVMVMware},
@@ -218,3 +283,160 @@ func groupForCategory(categoryID string) *groupDef {
 	}
 	return nil
 }
+
+// categoryOrder returns every category ID in canonical output order: by
+// group position in scanGroups, then by item position within the group.
+func categoryOrder() []string {
+	var ids []string
+	for _, g := range scanGroups {
+		for _, item := range g.Items {
+			ids = append(ids, item.CategoryID)
+		}
+	}
+	return ids
+}
+
+// isTimeBased reports whether a category ID is marked TimeBased in scanGroups.
+func isTimeBased(categoryID string) bool {
+	for _, g := range scanGroups {
+		for _, item := range g.Items {
+			if item.CategoryID == categoryID {
+				return item.TimeBased
+			}
+		}
+	}
+	return false
+}
+
+// categoryItem returns the categoryDef for a category ID, or nil if not
+// found.
+func categoryItem(categoryID string) *categoryDef {
+	for i := range scanGroups {
+		for j := range scanGroups[i].Items {
+			if scanGroups[i].Items[j].CategoryID == categoryID {
+				return &scanGroups[i].Items[j]
+			}
+		}
+	}
+	return nil
+}
+
+// readCategoriesFile reads newline-separated category IDs from path. Blank
+// lines and text after a "#" are ignored. Every ID must name a category in
+// scanGroups; unknown IDs are reported with their line number.
+func readCategoriesFile(path string) ([]string, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is supplied by the user on the command line
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseCategoryList(f, path)
+}
+
+// parseCategoryList parses a category list read from r. name labels errors.
+func parseCategoryList(r io.Reader, name string) ([]string, error) {
+	var ids []string
+	var errs []error
+	sc := bufio.NewScanner(r)
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		id := strings.TrimSpace(line)
+		if id == "" {
+			continue
+		}
+		if groupForCategory(id) == nil {
+			errs = append(errs, fmt.Errorf("%s:%d: unknown category %q", name, lineNo, id))
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return ids, nil
+}
+
+// compileCategoryPatterns compiles --category-include and
+// --category-exclude once, before anything is scanned. An include pattern
+// that matches no category ID is an error, since it would select nothing.
+func compileCategoryPatterns() error {
+	categoryInclude, categoryExclude = nil, nil
+	if flagCategoryInclude != "" {
+		re, err := regexp.Compile(flagCategoryInclude)
+		if err != nil {
+			return fmt.Errorf("--category-include: %w", err)
+		}
+		matched := false
+		for _, id := range categoryOrder() {
+			if re.MatchString(id) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return fmt.Errorf("--category-include: %q matches no category", flagCategoryInclude)
+		}
+		categoryInclude = re
+	}
+	if flagCategoryExclude != "" {
+		re, err := regexp.Compile(flagCategoryExclude)
+		if err != nil {
+			return fmt.Errorf("--category-exclude: %w", err)
+		}
+		categoryExclude = re
+	}
+	return nil
+}
+
+// patternExcluded reports whether the category patterns leave id out:
+// it matches --category-exclude, or an include pattern is set and it does
+// not match it. Exclude wins over include.
+func patternExcluded(id string) bool {
+	if categoryExclude != nil && categoryExclude.MatchString(id) {
+		return true
+	}
+	return categoryInclude != nil && !categoryInclude.MatchString(id)
+}
+
+// patternGroups returns the scanner IDs of the groups, not skipped as a
+// whole, with a category the patterns keep. It is the selection when
+// --category-include is the only scan flag.
+func patternGroups() map[string]bool {
+	groups := map[string]bool{}
+	for _, g := range scanGroups {
+		if g.SkipFlag != nil && *g.SkipFlag {
+			continue
+		}
+		for _, item := range g.Items {
+			if !patternExcluded(item.CategoryID) {
+				groups[g.ScannerID] = true
+			}
+		}
+	}
+	return groups
+}
+
+// selectCategories sets the targeted scan flag of each category ID, as if
+// it had been passed on the command line. A category without its own flag
+// selects its whole group.
+func selectCategories(ids []string) {
+	for _, id := range ids {
+		g := groupForCategory(id)
+		if g == nil {
+			continue
+		}
+		for _, item := range g.Items {
+			if item.CategoryID != id {
+				continue
+			}
+			if item.ScanFlag != nil {
+				*item.ScanFlag = true
+			} else {
+				*g.ScanFlag = true
+			}
+		}
+	}
+}
@@ -0,0 +1,234 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/confirm"
+	"github.com/sp3esu/mac-cleaner/internal/logging"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// flagPathsFile names a file of paths for the clean command to delete
+// (--paths-file).
+var flagPathsFile string
+
+var cleanCmd = &cobra.Command{
+	Use:   "clean --paths-file FILE",
+	Short: "delete an explicit list of paths with the usual safety checks",
+	Long: `Delete the paths listed in a file, one per line, without running any scanner.
+Blank lines and lines starting with # are ignored.
+
+Every path is checked against the safety blocklist and must be inside the home
+directory (but not the home directory itself); refused paths are reported as
+failed and missing paths as skipped. The usual confirmation prompt, --force,
+--dry-run, --report-only and --json apply.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		paths, err := readPathsFile(flagPathsFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --paths-file: %v\n", err)
+			os.Exit(1)
+		}
+		if flagJSON {
+			color.NoColor = true
+		}
+		runCleanPaths(os.Stdin, os.Stdout, paths)
+	},
+}
+
+func init() {
+	cleanCmd.Flags().StringVar(&flagPathsFile, "paths-file", "", "file of paths to delete, one per line (# starts a comment)")
+	cleanCmd.Flags().BoolVar(&flagJSON, "json", false, "output results as JSON")
+	cleanCmd.Flags().BoolVar(&flagForce, "force", false, "bypass confirmation prompt (for automation)")
+	_ = cleanCmd.MarkFlagRequired("paths-file")
+	rootCmd.AddCommand(cleanCmd)
+}
+
+// readPathsFile reads newline-separated paths from path. Blank lines and
+// lines starting with "#" are ignored; surrounding whitespace is trimmed.
+// A "#" elsewhere is kept, since it may be part of a file name.
+func readPathsFile(path string) ([]string, error) {
+	f, err := os.Open(path) // #nosec G304 -- path is supplied by the user on the command line
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var paths []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		paths = append(paths, line)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// runCleanPaths deletes paths for the clean command. In --dry-run mode it
+// reports what each path would come to without deleting; otherwise it
+// applies the same acknowledgement, cooldown and confirmation gates as
+// runCleanup before removing the paths that pass the safety checks, and
+// records the cleanup time for the next cooldown. It returns false when
+// nothing was attempted.
+func runCleanPaths(in io.Reader, w io.Writer, paths []string) bool {
+	if len(paths) == 0 {
+		fmt.Fprintln(w, "No paths to delete.")
+		return false
+	}
+	if flagDryRun {
+		printPathOutcomes(w, checkPaths(paths), true)
+		return false
+	}
+	if flagReportOnly {
+		printReportOnlyNotice(os.Stderr)
+		return false
+	}
+
+	reader := bufio.NewReader(in)
+	home := operatorHome()
+	if !ensureAcknowledged(reader, w, home) {
+		return false
+	}
+	if !ensureCooldown(home) {
+		return false
+	}
+	if !flagForce {
+		cat := scan.CategoryResult{Category: "paths-file", Description: "Paths from " + flagPathsFile}
+		for _, path := range paths {
+			if entry, outcome := cleanup.CheckPath(path); outcome == nil {
+				cat.Entries = append(cat.Entries, entry)
+				cat.TotalSize += entry.Size
+			}
+		}
+		if len(cat.Entries) > 0 && !confirm.PromptConfirmation(reader, w, []scan.CategoryResult{cat}) {
+			fmt.Fprintln(w, "Aborted.")
+			return false
+		}
+	}
+
+	outcomes := cleanup.RemovePaths(paths)
+	if err := confirm.RecordCleanup(home, clock()); err != nil {
+		logging.Warn("could not record cleanup time", "err", err)
+	}
+	if flagJSON {
+		printPathsJSON(w, outcomes)
+		return true
+	}
+	printPathOutcomes(w, outcomes, false)
+	return true
+}
+
+// checkPaths returns the outcome each path would have without deleting
+// anything: paths that pass the safety checks are reported as removed.
+func checkPaths(paths []string) []cleanup.PathOutcome {
+	outcomes := make([]cleanup.PathOutcome, 0, len(paths))
+	for _, path := range paths {
+		entry, outcome := cleanup.CheckPath(path)
+		if outcome != nil {
+			outcomes = append(outcomes, *outcome)
+			continue
+		}
+		outcomes = append(outcomes, cleanup.PathOutcome{Path: path, Status: cleanup.PathRemoved, Size: entry.Size})
+	}
+	return outcomes
+}
+
+// pathTotals counts outcomes by status and sums the bytes removed.
+func pathTotals(outcomes []cleanup.PathOutcome) (removed, failed, skipped int, freed int64) {
+	for _, o := range outcomes {
+		switch o.Status {
+		case cleanup.PathRemoved:
+			removed++
+			freed += o.Size
+		case cleanup.PathFailed:
+			failed++
+		case cleanup.PathSkipped:
+			skipped++
+		}
+	}
+	return removed, failed, skipped, freed
+}
+
+// printPathOutcomes prints one line per path and a summary. With dryRun,
+// removable paths are listed as "would remove" and the summary says so.
+func printPathOutcomes(w io.Writer, outcomes []cleanup.PathOutcome, dryRun bool) {
+	yellow := color.New(color.FgYellow)
+	fmt.Fprintln(w)
+	for _, o := range outcomes {
+		switch o.Status {
+		case cleanup.PathRemoved:
+			label := "removed"
+			if dryRun {
+				label = "would remove"
+			}
+			fmt.Fprintf(w, "  %-12s %s  (%s)\n", label, o.Path, scan.FormatSize(o.Size))
+		default:
+			_, _ = yellow.Fprintf(w, "  %-12s %s: %s\n", o.Status, o.Path, o.Reason)
+		}
+	}
+
+	removed, failed, skipped, freed := pathTotals(outcomes)
+	fmt.Fprintln(w)
+	if dryRun {
+		fmt.Fprintf(w, "Dry run: %d paths would be removed (%s); %d refused, %d skipped\n",
+			removed, scan.FormatSize(freed), failed, skipped)
+	} else {
+		greenBold := color.New(color.FgGreen, color.Bold)
+		_, _ = greenBold.Fprintf(w, "Cleanup complete: %d items removed, %s freed; %d failed, %d skipped\n",
+			removed, scan.FormatSize(freed), failed, skipped)
+	}
+	fmt.Fprintln(w)
+}
+
+// pathOutcomeJSON is one path's outcome in the clean command's --json
+// output.
+type pathOutcomeJSON struct {
+	Path   string `json:"path"`
+	Status string `json:"status"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// pathsCleanupJSON is the machine-readable outcome of the clean command.
+type pathsCleanupJSON struct {
+	Removed    int               `json:"removed"`
+	Failed     int               `json:"failed"`
+	Skipped    int               `json:"skipped"`
+	BytesFreed int64             `json:"bytes_freed"`
+	Paths      []pathOutcomeJSON `json:"paths"`
+}
+
+// printPathsJSON writes the clean command's outcome to w as a single JSON
+// object.
+func printPathsJSON(w io.Writer, outcomes []cleanup.PathOutcome) {
+	var out pathsCleanupJSON
+	out.Removed, out.Failed, out.Skipped, out.BytesFreed = pathTotals(outcomes)
+	out.Paths = make([]pathOutcomeJSON, 0, len(outcomes))
+	for _, o := range outcomes {
+		out.Paths = append(out.Paths, pathOutcomeJSON{
+			Path:   o.Path,
+			Status: string(o.Status),
+			Size:   o.Size,
+			Reason: o.Reason,
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+	}
+}
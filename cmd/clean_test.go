@@ -0,0 +1,195 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// writePathsFile writes lines to a paths file in a temp directory and
+// returns its path.
+func writePathsFile(t *testing.T, lines ...string) string {
+	t.Helper()
+	file := filepath.Join(t.TempDir(), "paths.txt")
+	if err := os.WriteFile(file, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return file
+}
+
+func TestReadPathsFile(t *testing.T) {
+	file := writePathsFile(t,
+		"# computed by a script",
+		"/Users/me/Library/Caches/foo",
+		"",
+		"   /Users/me/build#1   ",
+	)
+	got, err := readPathsFile(file)
+	if err != nil {
+		t.Fatalf("readPathsFile: %v", err)
+	}
+	want := []string{"/Users/me/Library/Caches/foo", "/Users/me/build#1"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("readPathsFile = %q, want %q", got, want)
+	}
+}
+
+func TestReadPathsFile_Missing(t *testing.T) {
+	if _, err := readPathsFile(filepath.Join(t.TempDir(), "nope.txt")); err == nil {
+		t.Error("expected error for missing paths file")
+	}
+}
+
+func TestRunCleanPaths_MixedOutcomes(t *testing.T) {
+	flagForce = true
+	defer func() { flagForce = false }()
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	home := acknowledgedHome(t)
+	target := filepath.Join(home, "scratch")
+	if err := os.MkdirAll(target, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(target, "data"), []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(home, "gone")
+	paths, err := readPathsFile(writePathsFile(t, target, home, missing))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if !runCleanPaths(strings.NewReader(""), &out, paths) {
+		t.Fatal("expected runCleanPaths to proceed with --force")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("expected %s to be removed, stat err: %v", target, err)
+	}
+	if _, err := os.Stat(home); err != nil {
+		t.Fatalf("home directory must survive: %v", err)
+	}
+	output := out.String()
+	for _, want := range []string{
+		"removed      " + target,
+		"failed       " + home + ": blocked: home directory",
+		"skipped      " + missing + ": does not exist",
+		"1 items removed, 4 B freed; 1 failed, 1 skipped",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+}
+
+func TestRunCleanPaths_JSON(t *testing.T) {
+	flagForce = true
+	flagJSON = true
+	defer func() {
+		flagForce = false
+		flagJSON = false
+	}()
+
+	home := acknowledgedHome(t)
+	target := filepath.Join(home, "old.log")
+	if err := os.WriteFile(target, []byte("log"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	runCleanPaths(strings.NewReader(""), &out, []string{target, home, filepath.Join(home, "gone")})
+
+	var got pathsCleanupJSON
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if got.Removed != 1 || got.Failed != 1 || got.Skipped != 1 || got.BytesFreed != 3 {
+		t.Errorf("unexpected totals: %+v", got)
+	}
+	statuses := []string{got.Paths[0].Status, got.Paths[1].Status, got.Paths[2].Status}
+	if strings.Join(statuses, ",") != "removed,failed,skipped" {
+		t.Errorf("statuses = %v, want removed, failed, skipped", statuses)
+	}
+}
+
+func TestRunCleanPaths_DryRunKeepsFiles(t *testing.T) {
+	flagDryRun = true
+	defer func() { flagDryRun = false }()
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	home := acknowledgedHome(t)
+	target := filepath.Join(home, "keep.txt")
+	if err := os.WriteFile(target, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if runCleanPaths(strings.NewReader(""), &out, []string{target}) {
+		t.Error("expected dry run to attempt nothing")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("dry run must not delete: %v", err)
+	}
+	if !strings.Contains(out.String(), "would remove") {
+		t.Errorf("expected dry-run listing, got:\n%s", out.String())
+	}
+}
+
+func TestRunCleanPaths_DeclinedPromptKeepsFiles(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	home := acknowledgedHome(t)
+	target := filepath.Join(home, "keep.txt")
+	if err := os.WriteFile(target, []byte("keep"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if runCleanPaths(strings.NewReader("no\n"), &out, []string{target}) {
+		t.Error("expected declined prompt to abort")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("declined prompt must not delete: %v", err)
+	}
+}
+
+func TestRunCleanPaths_CooldownBlocksQuickRerun(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	origClock := clock
+	defer func() { clock = origClock }()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock = func() time.Time { return now }
+
+	home := acknowledgedHome(t)
+	first := filepath.Join(home, "first.txt")
+	second := filepath.Join(home, "second.txt")
+	for _, p := range []string{first, second} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if !runCleanPaths(strings.NewReader("yes\n"), &out, []string{first}) {
+		t.Fatal("expected first cleanup to proceed")
+	}
+
+	// The first run is recorded, so a second one 10s later is refused.
+	now = now.Add(10 * time.Second)
+	if runCleanPaths(strings.NewReader("yes\n"), &out, []string{second}) {
+		t.Fatal("expected cleanup within the cooldown to be refused")
+	}
+	if _, err := os.Stat(second); err != nil {
+		t.Errorf("expected file kept during cooldown, stat err: %v", err)
+	}
+}
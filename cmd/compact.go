@@ -0,0 +1,250 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/confirm"
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// compactOptions configures the compaction the compact command runs: the
+// zero value runs the real tools and measures allocated blocks.
+var compactOptions cleanup.CompactOptions
+
+var compactCmd = &cobra.Command{
+	Use:   "compact [flags]",
+	Short: "shrink VM and Docker disk images in place instead of deleting them",
+	Long: `Find Parallels and UTM virtual machines and Docker Desktop's VM disk, and
+release the space their guests no longer use without deleting anything:
+
+  Parallels (.pvm, .hdd)   prl_disk_tool compact --hdd DISK
+  UTM (.utm, .qcow2)       qemu-img convert -O qcow2 DISK COPY, then COPY replaces DISK
+  Docker.raw               docker run --privileged --pid=host docker/desktop-reclaim-space
+
+A qcow2 disk with snapshots is refused, since the copy would drop them, as
+is a disk whose copy might not fit in the free space; the original is kept
+when the copy is not smaller. Docker pulls docker/desktop-reclaim-space from
+Docker Hub if it is not present.
+
+Shut the VMs down first; Docker Desktop must be running. The images and
+commands are listed and must be confirmed with "yes" unless --force is set.
+Each image's allocated size is measured before and after, and the
+difference is reported as reclaimed. With --dry-run the commands are listed
+and nothing is run; --report-only and --no-exec refuse compaction, and it
+waits out the cooldown after a cleanup like any cleanup.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if flagJSON {
+			color.NoColor = true
+		}
+		eng = engine.New()
+		engine.RegisterDefaults(eng)
+		eng.NoExec = flagNoExec
+		applyHome()
+		prepareHome(os.Stderr)
+
+		results, err := scanCompactable(context.Background(), eng)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		paths := compactablePaths(results, nil)
+		if len(paths) == 0 {
+			fmt.Fprintln(os.Stderr, "No VM or Docker disk images found.")
+			return
+		}
+		if flagDryRun {
+			printCompactPlan(os.Stdout, paths)
+			return
+		}
+		if !confirmCompact(os.Stdin, os.Stdout, paths) {
+			return
+		}
+		compacted, errs := runCompact(context.Background(), paths, compactOptions)
+		for _, err := range errs {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		}
+		if flagJSON {
+			printCompactJSON(os.Stdout, compacted)
+		} else {
+			printCompact(os.Stdout, compacted)
+		}
+		if len(errs) > 0 {
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	compactCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "list the images and the commands that would compact them without running anything")
+	compactCmd.Flags().BoolVar(&flagJSON, "json", false, "output results as JSON")
+	compactCmd.Flags().StringVar(&flagHome, "home", "", "look for images in this home directory instead of your own")
+	compactCmd.Flags().BoolVar(&flagForce, "force", false, "compact without asking for confirmation or waiting out the cooldown after a cleanup")
+	compactCmd.Flags().BoolVar(&flagNoExec, "no-exec", false, "run no external commands; compaction needs them, so it is refused")
+	rootCmd.AddCommand(compactCmd)
+}
+
+// scanCompactable runs the scanners that report the categories in
+// cleanup.CompactCategories and returns those categories only.
+func scanCompactable(ctx context.Context, e *engine.Engine) ([]scan.CategoryResult, error) {
+	wanted := map[string]bool{}
+	for _, id := range cleanup.CompactCategories {
+		wanted[id] = true
+	}
+	var results []scan.CategoryResult
+	for _, info := range e.Categories() {
+		relevant := false
+		for _, id := range info.CategoryIDs {
+			relevant = relevant || wanted[id]
+		}
+		if !relevant {
+			continue
+		}
+		crs, err := e.Run(ctx, info.ID)
+		if err != nil {
+			return nil, err
+		}
+		for _, cr := range crs {
+			if wanted[cr.Category] {
+				results = append(results, cr)
+			}
+		}
+	}
+	return results, nil
+}
+
+// compactablePaths returns the entries of results that are images
+// cleanup.Compact recognizes, skipping any outside the allowed roots.
+func compactablePaths(results []scan.CategoryResult, extraRoots []string) []string {
+	var paths []string
+	for _, cr := range results {
+		for _, entry := range cr.Entries {
+			if _, ok := cleanup.ImageKindOf(entry.Path); !ok {
+				continue
+			}
+			if ok, reason := safety.CheckAllowedRoot(entry.Path, extraRoots); !ok {
+				fmt.Fprintf(os.Stderr, "Warning: not compacting %s: %s\n", entry.Path, reason)
+				continue
+			}
+			paths = append(paths, entry.Path)
+		}
+	}
+	return paths
+}
+
+// confirmCompact reports whether the images in paths may be compacted.
+// Compaction is refused in --report-only mode, under --no-exec since
+// every image is compacted by an external tool, and within the cooldown
+// after a cleanup. Unless --force is set, the images and the commands
+// that compact them are listed and the user must type "yes".
+func confirmCompact(in io.Reader, w io.Writer, paths []string) bool {
+	if flagReportOnly {
+		printReportOnlyNotice(os.Stderr)
+		return false
+	}
+	if flagNoExec {
+		fmt.Fprintln(os.Stderr, "Compaction runs prl_disk_tool, qemu-img or docker; refusing it under --no-exec.")
+		return false
+	}
+	if !ensureCooldown(operatorHome()) {
+		return false
+	}
+	if flagForce {
+		return true
+	}
+	fmt.Fprintln(w, "The following disk images will be compacted in place:")
+	printCompactPlan(w, paths)
+	for _, p := range paths {
+		if kind, _ := cleanup.ImageKindOf(p); kind == cleanup.ImageDocker {
+			fmt.Fprintln(w, "Docker pulls the reclaim-space image from Docker Hub if it is not present.")
+			break
+		}
+	}
+	fmt.Fprintln(w, "Shut the VMs down first; Docker Desktop must be running.")
+	if !confirm.PromptYes(in, w, "Type 'yes' to proceed: ") {
+		fmt.Fprintln(w, "Aborted.")
+		return false
+	}
+	return true
+}
+
+// runCompact compacts each image in paths, continuing past failures, and
+// returns the results of those that succeeded and the errors of the rest.
+func runCompact(ctx context.Context, paths []string, opts cleanup.CompactOptions) ([]cleanup.CompactResult, []error) {
+	var results []cleanup.CompactResult
+	var errs []error
+	for _, p := range paths {
+		res, err := cleanup.Compact(ctx, p, opts)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		results = append(results, res)
+	}
+	return results, errs
+}
+
+// printCompactPlan lists each image with the commands compact would run.
+func printCompactPlan(w io.Writer, paths []string) {
+	for _, p := range paths {
+		fmt.Fprintln(w, p)
+		cmds, err := cleanup.CompactCommands(p)
+		if err != nil {
+			fmt.Fprintf(w, "  (%v)\n", err)
+			continue
+		}
+		for _, c := range cmds {
+			fmt.Fprintf(w, "  %s\n", strings.Join(c, " "))
+		}
+	}
+}
+
+// printCompact prints each image's size before and after compaction and
+// the total reclaimed.
+func printCompact(w io.Writer, results []cleanup.CompactResult) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	var total int64
+	for _, r := range results {
+		fmt.Fprintf(tw, "  %s\t%s -> %s\treclaimed %s\n", r.Path,
+			scan.FormatSize(r.Before), scan.FormatSize(r.After), scan.FormatSize(r.Reclaimed()))
+		total += r.Reclaimed()
+	}
+	_ = tw.Flush()
+	fmt.Fprintf(w, "\nCompacted %d image(s), reclaimed %s.\n", len(results), scan.FormatSize(total))
+}
+
+// compactJSON is the JSON form of a compact run.
+type compactJSON struct {
+	Images         []compactImageJSON `json:"images"`
+	TotalReclaimed int64              `json:"total_reclaimed"`
+}
+
+// compactImageJSON is one compacted image with its reclaimed bytes.
+type compactImageJSON struct {
+	cleanup.CompactResult
+	Reclaimed int64 `json:"reclaimed"`
+}
+
+// printCompactJSON prints the compaction results as JSON.
+func printCompactJSON(w io.Writer, results []cleanup.CompactResult) {
+	out := compactJSON{Images: []compactImageJSON{}}
+	for _, r := range results {
+		out.Images = append(out.Images, compactImageJSON{CompactResult: r, Reclaimed: r.Reclaimed()})
+		out.TotalReclaimed += r.Reclaimed()
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(out)
+}
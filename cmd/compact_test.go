@@ -0,0 +1,90 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/confirm"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+func TestCompactablePaths_OnlyImages(t *testing.T) {
+	home := t.TempDir()
+	results := []scan.CategoryResult{{
+		Category: "sysdata-vm-parallels",
+		Entries: []scan.ScanEntry{
+			{Path: home + "/Parallels/Windows 11.pvm"},
+			{Path: home + "/Parallels/readme.txt"},
+		},
+	}}
+	paths := compactablePaths(results, []string{home})
+	if len(paths) != 1 || !strings.HasSuffix(paths[0], "Windows 11.pvm") {
+		t.Errorf("compactablePaths = %v, want only the .pvm bundle", paths)
+	}
+}
+
+func TestPrintCompactJSON_TotalsReclaimed(t *testing.T) {
+	var out bytes.Buffer
+	printCompactJSON(&out, []cleanup.CompactResult{
+		{Path: "/a.pvm", Kind: cleanup.ImageParallels, Before: 1000, After: 400},
+		{Path: "/Docker.raw", Kind: cleanup.ImageDocker, Before: 500, After: 500},
+	})
+	var got compactJSON
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("unmarshal: %v\n%s", err, out.String())
+	}
+	if got.TotalReclaimed != 600 || len(got.Images) != 2 || got.Images[0].Reclaimed != 600 {
+		t.Errorf("unexpected JSON: %s", out.String())
+	}
+}
+
+func TestPrintCompactPlan_ListsCommands(t *testing.T) {
+	var out bytes.Buffer
+	printCompactPlan(&out, []string{"/vms/Docker.raw"})
+	if !strings.Contains(out.String(), "docker run --rm --privileged --pid=host docker/desktop-reclaim-space") {
+		t.Errorf("plan missing the Docker command:\n%s", out.String())
+	}
+}
+
+func TestConfirmCompact_Gate(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	safety.SetHome(home)
+	defer func() {
+		safety.SetHome("")
+		flagNoExec, flagForce = false, false
+	}()
+	paths := []string{home + "/Parallels/Windows 11.pvm"}
+
+	var out bytes.Buffer
+	if confirmCompact(strings.NewReader("no\n"), &out, paths) {
+		t.Error("compaction ran without a yes")
+	}
+	if !strings.Contains(out.String(), "Windows 11.pvm") {
+		t.Errorf("prompt does not list the image:\n%s", out.String())
+	}
+	if !confirmCompact(strings.NewReader("yes\n"), &out, paths) {
+		t.Error("compaction refused after yes")
+	}
+
+	if err := confirm.RecordCleanup(home, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if confirmCompact(strings.NewReader("yes\n"), &out, paths) {
+		t.Error("compaction ran within the cooldown after a cleanup")
+	}
+	flagForce = true
+	if !confirmCompact(strings.NewReader(""), &out, paths) {
+		t.Error("--force did not skip the prompt and cooldown")
+	}
+
+	flagNoExec = true
+	if confirmCompact(strings.NewReader("yes\n"), &out, paths) {
+		t.Error("compaction ran under --no-exec")
+	}
+}
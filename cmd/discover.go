@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// Flags for the discover command.
+var (
+	flagDiscoverDepth   int
+	flagDiscoverTop     int
+	flagDiscoverExclude []string
+)
+
+var discoverCmd = &cobra.Command{
+	Use:   "discover",
+	Short: "list the largest directories under home that no category covers",
+	Long: `Walk the home directory to a bounded depth and list the largest directories,
+largest first, to show where space goes beyond the cleanup categories.
+
+Directories a scanner already examines (such as ~/Library/Caches) are left out,
+as are directories given with --exclude. Sizes count every file below a
+directory, however deep; --depth only limits which directories are listed.
+The listing is informational: nothing is offered for deletion.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		home, err := safety.Home()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: cannot determine home directory: %v\n", err)
+			os.Exit(1)
+		}
+		if flagJSON {
+			color.NoColor = true
+		}
+		prepareHome(os.Stderr)
+
+		eng := engine.New()
+		engine.RegisterDefaults(eng)
+		exclude := append(categorizedPaths(eng, home), flagDiscoverExclude...)
+		dirs, err := scan.LargestDirs(home, flagDiscoverDepth, flagDiscoverTop, exclude)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if flagJSON {
+			printDiscoverJSON(os.Stdout, dirs)
+			return
+		}
+		printDiscover(os.Stdout, home, dirs)
+	},
+}
+
+func init() {
+	discoverCmd.Flags().IntVar(&flagDiscoverDepth, "depth", 3, "list directories at most N levels below home")
+	discoverCmd.Flags().IntVar(&flagDiscoverTop, "top", 20, "list the N largest directories (0 for all)")
+	discoverCmd.Flags().StringArrayVar(&flagDiscoverExclude, "exclude", nil, "leave DIR and everything under it out (repeatable)")
+	discoverCmd.Flags().BoolVar(&flagJSON, "json", false, "output results as JSON")
+	rootCmd.AddCommand(discoverCmd)
+}
+
+// categorizedPaths returns the paths under home that e's scanners examine,
+// so discover can leave them out. Paths outside home, and home itself, are
+// dropped.
+func categorizedPaths(e *engine.Engine, home string) []string {
+	home = filepath.Clean(home)
+	var paths []string
+	for _, info := range e.Categories() {
+		listed, _ := e.Paths(info.ID, home)
+		for _, p := range listed {
+			if strings.HasPrefix(filepath.Clean(p), home+string(filepath.Separator)) {
+				paths = append(paths, p)
+			}
+		}
+	}
+	return paths
+}
+
+// printDiscover prints the discover listing with sizes and paths relative
+// to home.
+func printDiscover(w io.Writer, home string, dirs []scan.DirUsage) {
+	bold := color.New(color.Bold)
+	fmt.Fprintln(w)
+	_, _ = bold.Fprintln(w, "Largest directories (informational, nothing is deleted)")
+	fmt.Fprintln(w)
+	if len(dirs) == 0 {
+		fmt.Fprintln(w, "  No uncategorized directories found.")
+		fmt.Fprintln(w)
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, d := range dirs {
+		rel, err := filepath.Rel(home, d.Path)
+		if err != nil {
+			rel = d.Path
+		}
+		fmt.Fprintf(tw, "  %s\t~/%s\t\n", scan.FormatSize(d.Size), rel)
+	}
+	_ = tw.Flush()
+	fmt.Fprintln(w)
+}
+
+// discoverJSON is the machine-readable output of the discover command.
+type discoverJSON struct {
+	Directories []scan.DirUsage `json:"directories"`
+}
+
+// printDiscoverJSON writes the discover listing to w as a single JSON
+// object.
+func printDiscoverJSON(w io.Writer, dirs []scan.DirUsage) {
+	out := discoverJSON{Directories: dirs}
+	if out.Directories == nil {
+		out.Directories = []scan.DirUsage{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+	}
+}
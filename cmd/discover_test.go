@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// discoverHome builds a home with a large categorized cache and smaller
+// uncategorized directories.
+func discoverHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	for path, size := range map[string]int{
+		"Library/Caches/com.example/blob": 90000,
+		"Movies/trip.mov":                 6000,
+		"Projects/app/build.bin":          4000,
+		"Documents/report.pdf":            500,
+	} {
+		full := filepath.Join(home, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(full, make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return home
+}
+
+func TestCategorizedPaths_ExcludedFromDiscover(t *testing.T) {
+	home := discoverHome(t)
+	eng := engine.New()
+	engine.RegisterDefaults(eng)
+
+	dirs, err := scan.LargestDirs(home, 2, 3, categorizedPaths(eng, home))
+	if err != nil {
+		t.Fatalf("LargestDirs: %v", err)
+	}
+	want := []string{"Movies", "Projects", "Projects/app"}
+	if len(dirs) != len(want) {
+		t.Fatalf("got %+v, want %v", dirs, want)
+	}
+	for i, d := range dirs {
+		if d.Path != filepath.Join(home, want[i]) {
+			t.Errorf("dirs[%d] = %s, want %s", i, d.Path, want[i])
+		}
+		if i > 0 && d.Size > dirs[i-1].Size {
+			t.Errorf("not in descending order: %+v", dirs)
+		}
+		if strings.Contains(d.Path, "Caches") {
+			t.Errorf("categorized path listed: %s", d.Path)
+		}
+	}
+}
+
+func TestCategorizedPaths_OnlyUnderHome(t *testing.T) {
+	eng := engine.New()
+	engine.RegisterDefaults(eng)
+	home := "/Users/me"
+	for _, p := range categorizedPaths(eng, home) {
+		if !strings.HasPrefix(p, home+"/") {
+			t.Errorf("path outside home: %s", p)
+		}
+	}
+}
+
+func TestPrintDiscover(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	printDiscover(&buf, "/Users/me", []scan.DirUsage{{Path: "/Users/me/Movies", Size: 6000}})
+	out := buf.String()
+	for _, want := range []string{"nothing is deleted", "6.0 kB", "~/Movies"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	printDiscoverJSON(&buf, nil)
+	var got discoverJSON
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, buf.String())
+	}
+	if got.Directories == nil || len(got.Directories) != 0 {
+		t.Errorf("expected empty directories array, got %s", buf.String())
+	}
+}
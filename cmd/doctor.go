@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "run every scanner and report which ones could check for data",
+	Long: `Run every scanner and report, per scanner, whether it found data, found nothing,
+or could not check: a command it needs is not installed, external commands are
+disabled, none of its directories exist, or permissions kept it out.
+
+Use it to tell "nothing to clean" apart from "could not look". Nothing is
+offered for deletion.`,
+	Args: cobra.NoArgs,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		eng = engine.New()
+		engine.RegisterDefaults(eng)
+		eng.AppDirs = flagAppDirs
+		eng.ScanTmpCaches = flagScanTmpCaches
+		eng.ScanNodeModules = flagScanNodeModules
+		eng.ScanPyEnvs = flagScanPyEnvs
+		eng.ProjectRoots = flagProjectRoots
+		eng.IncludeHidden = flagIncludeHidden
+		eng.NoExec = flagNoExec
+		eng.ScanTimeout = flagScanTimeout
+		applyHome()
+		prepareHome(os.Stderr)
+		if flagJSON {
+			color.NoColor = true
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		statuses, err := eng.Doctor(context.Background())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if flagJSON {
+			printDoctorJSON(os.Stdout, statuses)
+			return
+		}
+		printDoctor(os.Stdout, statuses)
+	},
+}
+
+func init() {
+	doctorCmd.Flags().BoolVar(&flagJSON, "json", false, "output results as JSON")
+	doctorCmd.Flags().StringSliceVar(&flagAppDirs, "app-dir", nil, "extra directory to search for unused applications (repeatable)")
+	doctorCmd.Flags().BoolVar(&flagScanTmpCaches, "tmp-caches", false, "also check temporary app caches in /private/var/folders (opt-in)")
+	doctorCmd.Flags().BoolVar(&flagScanNodeModules, "node-modules", false, "also check for stale node_modules (90+ days) under the project roots (opt-in)")
+	doctorCmd.Flags().BoolVar(&flagScanPyEnvs, "pyenvs", false, "also check for stale Python virtualenvs and __pycache__ (90+ days) under the project roots (opt-in)")
+	doctorCmd.Flags().StringSliceVar(&flagProjectRoots, "project-root", nil, "directory searched for stale node_modules and Python environments instead of ~/Developer, ~/Projects and ~/Documents/code (repeatable)")
+	doctorCmd.Flags().BoolVar(&flagIncludeHidden, "include-hidden", false, "also consider hidden (dot-prefixed) entries in ~/Downloads and hidden directories under the project roots")
+	doctorCmd.Flags().StringVar(&flagHome, "home", "", "check this home directory instead of your own")
+	doctorCmd.Flags().BoolVar(&flagNoExec, "no-exec", false, "run no external commands (docker, brew, tmutil, mdls, PlistBuddy); scanners that need them are reported as skipped")
+	rootCmd.AddCommand(doctorCmd)
+}
+
+// statusLabel returns the human-readable form of a scan status.
+func statusLabel(s scan.ScanStatus) string {
+	switch s {
+	case scan.StatusFound:
+		return "found data"
+	case scan.StatusNoData:
+		return "no data"
+	case scan.StatusToolMissing:
+		return "skipped: tool not installed"
+	case scan.StatusDirAbsent:
+		return "skipped: directory not found"
+	case scan.StatusDisabled:
+		return "skipped: external commands disabled"
+	case scan.StatusPermission:
+		return "permission issues"
+	case scan.StatusError:
+		return "error"
+	}
+	return string(s)
+}
+
+// doctorDetail returns the text shown after a scanner's status.
+func doctorDetail(st engine.ScannerStatus) string {
+	switch {
+	case st.Status == scan.StatusFound:
+		return fmt.Sprintf("%s in %d categories", scan.FormatSize(st.TotalSize), st.Categories)
+	case st.Status == scan.StatusPermission:
+		return fmt.Sprintf("%d paths unreadable", st.PermissionIssues)
+	case st.Detail != "":
+		return st.Detail
+	}
+	return ""
+}
+
+// printDoctor prints one line per scanner, any categories a scanner with
+// data could not check, and a summary.
+func printDoctor(w io.Writer, statuses []engine.ScannerStatus) {
+	bold := color.New(color.Bold)
+	yellow := color.New(color.FgYellow)
+	fmt.Fprintln(w)
+	_, _ = bold.Fprintln(w, "Scanner coverage (informational, nothing is deleted)")
+	fmt.Fprintln(w)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	found, empty, unchecked := 0, 0, 0
+	for _, st := range statuses {
+		switch st.Status {
+		case scan.StatusFound:
+			found++
+		case scan.StatusNoData:
+			empty++
+		default:
+			unchecked++
+		}
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t\n", st.Name, statusLabel(st.Status), doctorDetail(st))
+		if st.Status != scan.StatusFound {
+			continue
+		}
+		for _, sk := range st.Skipped {
+			fmt.Fprintf(tw, "  \t%s\t%s\t\n", yellow.Sprintf("%s: %s", sk.Category, statusLabel(sk.Status)), sk.Detail)
+		}
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintln(w)
+	fmt.Fprintf(w, "%d scanners found data, %d found nothing, %d could not check.\n", found, empty, unchecked)
+	fmt.Fprintln(w)
+}
+
+// doctorJSON is the machine-readable output of the doctor command.
+type doctorJSON struct {
+	Scanners []engine.ScannerStatus `json:"scanners"`
+}
+
+// printDoctorJSON writes the doctor report to w as a single JSON object.
+func printDoctorJSON(w io.Writer, statuses []engine.ScannerStatus) {
+	out := doctorJSON{Scanners: statuses}
+	if out.Scanners == nil {
+		out.Scanners = []engine.ScannerStatus{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+	}
+}
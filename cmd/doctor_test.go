@@ -0,0 +1,73 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+func TestPrintDoctor_MissingToolVersusEmptyDir(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	dir := t.TempDir()
+	eng := engine.New()
+	eng.Register(engine.NewStatusScanner(engine.ScannerInfo{ID: "brew", Name: "Homebrew"}, func(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+		report.Report("dev-brew-autoremove", scan.StatusToolMissing, "brew")
+		return nil, nil
+	}))
+	eng.Register(engine.NewScannerWithPaths(engine.ScannerInfo{ID: "caches", Name: "Caches"}, func() ([]scan.CategoryResult, error) {
+		return nil, nil
+	}, func(string) []string { return []string{dir} }))
+
+	statuses, err := eng.Doctor(context.Background())
+	if err != nil {
+		t.Fatalf("Doctor: %v", err)
+	}
+	var out bytes.Buffer
+	printDoctor(&out, statuses)
+
+	lines := map[string]string{}
+	for _, line := range strings.Split(out.String(), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			lines[fields[0]] = line
+		}
+	}
+	if got := lines["Homebrew"]; !strings.Contains(got, "skipped: tool not installed") || !strings.Contains(got, "brew") {
+		t.Errorf("expected missing tool reported as skipped, got %q\n%s", got, out.String())
+	}
+	if got := lines["Caches"]; !strings.Contains(got, "no data") || strings.Contains(got, "skipped") {
+		t.Errorf("expected empty directory reported as no data, got %q\n%s", got, out.String())
+	}
+	if !strings.Contains(out.String(), "0 scanners found data, 1 found nothing, 1 could not check.") {
+		t.Errorf("unexpected summary:\n%s", out.String())
+	}
+}
+
+func TestDoctorPreRun_BuildsEngineFromFlags(t *testing.T) {
+	home := t.TempDir()
+	flagHome, flagNoExec, flagScanNodeModules = home, true, true
+	flagScanTimeout = time.Minute
+	defer func() {
+		flagHome, flagNoExec, flagScanNodeModules = "", false, false
+		flagScanTimeout = 0
+		safety.SetHome("")
+		eng = nil
+	}()
+
+	doctorCmd.PreRun(doctorCmd, nil)
+
+	if eng.Home != home || !eng.NoExec || !eng.ScanNodeModules || eng.ScanTimeout != time.Minute {
+		t.Errorf("doctor engine ignores the flags: home=%q no-exec=%v node-modules=%v timeout=%v",
+			eng.Home, eng.NoExec, eng.ScanNodeModules, eng.ScanTimeout)
+	}
+}
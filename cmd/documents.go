@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/confirm"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// guardDocuments drops the entries of results that look like documents
+// (see cleanup.DocumentEntries) unless the user confirms each one. keep
+// reports whether an entry stays. Category totals are adjusted and
+// categories left empty are dropped.
+func guardDocuments(results []scan.CategoryResult, keep func(path string, d cleanup.DocumentLike) bool) []scan.CategoryResult {
+	found := cleanup.DocumentEntries(results)
+	if len(found) == 0 {
+		return results
+	}
+	out := make([]scan.CategoryResult, 0, len(results))
+	for _, cat := range results {
+		var kept []scan.ScanEntry
+		for _, e := range cat.Entries {
+			if d, ok := found[e.Path]; ok && !keep(e.Path, d) {
+				if e.Deletable {
+					cat.TotalSize -= e.Size
+				}
+				continue
+			}
+			kept = append(kept, e)
+		}
+		if len(kept) == 0 && len(cat.Entries) > 0 {
+			continue
+		}
+		cat.Entries = kept
+		out = append(out, cat)
+	}
+	return out
+}
+
+// confirmDocuments asks on w, reading answers from in, before deleting
+// each entry that looks like it holds documents, and drops the entries
+// the user does not confirm. The confirmed paths are returned for
+// cleanup.Options.AllowDocuments, since cleanup withholds them otherwise.
+func confirmDocuments(in io.Reader, w io.Writer, results []scan.CategoryResult) ([]scan.CategoryResult, []string) {
+	var confirmed []string
+	kept := guardDocuments(results, func(path string, d cleanup.DocumentLike) bool {
+		if !confirm.PromptDocuments(in, w, path, d.Docs, d.Sampled) {
+			return false
+		}
+		confirmed = append(confirmed, path)
+		return true
+	})
+	return kept, confirmed
+}
+
+// withholdDocuments drops every entry that looks like it holds documents,
+// for --force runs where nobody can confirm them, writing a note to w for
+// each.
+func withholdDocuments(w io.Writer, results []scan.CategoryResult) []scan.CategoryResult {
+	return guardDocuments(results, func(path string, d cleanup.DocumentLike) bool {
+		fmt.Fprintf(w, "Not deleting %s: this looks like it contains your documents (%d of %d sampled files); run without --force to confirm it.\n", path, d.Docs, d.Sampled)
+		return false
+	})
+}
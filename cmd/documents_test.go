@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+	"github.com/sp3esu/mac-cleaner/internal/spinner"
+)
+
+// documentTestResults creates, under home, a cache directory repurposed
+// for documents and a real cache, and returns them as one safe category.
+func documentTestResults(t *testing.T, home string) (docs, cache string, results []scan.CategoryResult) {
+	t.Helper()
+	docs = filepath.Join(home, "Library", "Caches", "com.example.notes")
+	cache = filepath.Join(home, "Library", "Caches", "com.example.browser")
+	files := map[string][]string{
+		docs:  {"contract.pdf", "invoice.pdf", "cv.docx", "budget.xlsx", "cover.psd", "IMG_0001.HEIC"},
+		cache: {"Cache.db", "Cache.db-wal", "fsCachedData/1A", "fsCachedData/2B", "fsCachedData/3C"},
+	}
+	for dir, names := range files {
+		for _, name := range names {
+			path := filepath.Join(dir, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	results = []scan.CategoryResult{{
+		Category:    "system-caches",
+		Description: "User App Caches",
+		Entries: []scan.ScanEntry{
+			{Path: docs, Description: "com.example.notes", Size: 24, IsDir: true, Deletable: true},
+			{Path: cache, Description: "com.example.browser", Size: 20, IsDir: true, Deletable: true},
+		},
+		TotalSize: 44,
+	}}
+	return docs, cache, results
+}
+
+func TestRunCleanup_ForceWithholdsDocuments(t *testing.T) {
+	flagForce = true
+	defer func() { flagForce = false }()
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	home := acknowledgedHome(t)
+	docs, cache, results := documentTestResults(t, home)
+
+	var out bytes.Buffer
+	var ran bool
+	stderr := captureStderr(t, func() {
+		ran = runCleanup(strings.NewReader(""), &out, spinner.New("", false), results)
+	})
+	if !ran {
+		t.Fatal("expected runCleanup to delete the remaining cache")
+	}
+	if _, err := os.Stat(docs); err != nil {
+		t.Errorf("expected documents directory to survive --force: %v", err)
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Errorf("expected cache directory to be removed, stat err: %v", err)
+	}
+	if !strings.Contains(stderr, "this looks like it contains your documents") {
+		t.Errorf("expected documents notice on stderr, got: %q", stderr)
+	}
+}
+
+func TestRunCleanup_DocumentsNeedConfirmation(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	home := acknowledgedHome(t)
+	docs, cache, results := documentTestResults(t, home)
+
+	// Keep the documents, then confirm the rest.
+	var out bytes.Buffer
+	if !runCleanup(strings.NewReader("\nyes\n"), &out, spinner.New("", false), results) {
+		t.Fatalf("expected runCleanup to proceed, output:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "looks like it contains your documents") {
+		t.Errorf("expected documents warning, got:\n%s", out.String())
+	}
+	if _, err := os.Stat(docs); err != nil {
+		t.Errorf("expected declined documents directory to survive: %v", err)
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Errorf("expected cache directory to be removed, stat err: %v", err)
+	}
+}
+
+func TestGuardDocumentsAdjustsTotals(t *testing.T) {
+	docs, _, results := documentTestResults(t, t.TempDir())
+
+	var asked []string
+	kept := guardDocuments(results, func(path string, _ cleanup.DocumentLike) bool {
+		asked = append(asked, path)
+		return false
+	})
+	if len(asked) != 1 || asked[0] != docs {
+		t.Fatalf("expected to be asked about %s only, got %v", docs, asked)
+	}
+	if len(kept) != 1 || len(kept[0].Entries) != 1 || kept[0].TotalSize != 20 {
+		t.Errorf("expected the cache alone with total 20, got %+v", kept)
+	}
+	if results[0].TotalSize != 44 || len(results[0].Entries) != 2 {
+		t.Errorf("expected the input to be left unchanged, got %+v", results[0])
+	}
+}
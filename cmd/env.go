@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// Environment variables that select and skip categories, for CI systems
+// that prefer configuration over long command lines. Both hold
+// comma-separated group flags (e.g. "dev-caches"), scanner IDs (e.g.
+// "developer") or item flags (e.g. "npm").
+const (
+	envScan = "MAC_CLEANER_SCAN"
+	envSkip = "MAC_CLEANER_SKIP"
+)
+
+// envTarget is a MAC_CLEANER_SCAN or MAC_CLEANER_SKIP token resolved
+// against scanGroups: the group or item's scan and skip flags.
+type envTarget struct {
+	scanName string
+	scanFlag *bool
+	skipName string
+	skipFlag *bool
+}
+
+// resolveEnvToken looks up tok as a group flag, scanner ID or item flag.
+func resolveEnvToken(tok string) (envTarget, bool) {
+	for _, g := range scanGroups {
+		if tok == g.FlagName || tok == g.ScannerID {
+			return envTarget{g.FlagName, g.ScanFlag, "skip-" + g.FlagName, g.SkipFlag}, true
+		}
+		for _, item := range g.Items {
+			if item.FlagName != "" && tok == item.FlagName {
+				return envTarget{item.FlagName, item.ScanFlag, "skip-" + item.FlagName, item.SkipFlag}, true
+			}
+		}
+	}
+	return envTarget{}, false
+}
+
+// applyEnvSelection merges MAC_CLEANER_SCAN and MAC_CLEANER_SKIP, read
+// through getenv, into the scan and skip flags registered on cmd. Flags
+// given on the command line win: a token is ignored when its flag was set
+// explicitly, and a skipped category passed as a scan flag is still
+// scanned. Unknown tokens, and tokens whose flag this command does not
+// have, are reported together and nothing is applied.
+func applyEnvSelection(cmd *cobra.Command, getenv func(string) string) error {
+	flags := cmd.Flags()
+	var scans, skips []envTarget
+	var errs []error
+	parse := func(name string, into *[]envTarget, skip bool) {
+		for _, tok := range strings.Split(getenv(name), ",") {
+			tok = strings.TrimSpace(tok)
+			if tok == "" {
+				continue
+			}
+			target, ok := resolveEnvToken(tok)
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: unknown category %q", name, tok))
+				continue
+			}
+			flag, ptr := target.scanName, target.scanFlag
+			if skip {
+				flag, ptr = target.skipName, target.skipFlag
+			}
+			if ptr == nil || flags.Lookup(flag) == nil {
+				errs = append(errs, fmt.Errorf("%s: %q is not supported by this command", name, tok))
+				continue
+			}
+			*into = append(*into, target)
+		}
+	}
+	parse(envScan, &scans, false)
+	parse(envSkip, &skips, true)
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	for _, t := range scans {
+		if !flags.Changed(t.scanName) {
+			*t.scanFlag = true
+		}
+	}
+	for _, t := range skips {
+		if flags.Changed(t.skipName) || flags.Changed(t.scanName) {
+			continue
+		}
+		*t.skipFlag = true
+	}
+	return nil
+}
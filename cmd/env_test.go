@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// envCommand returns a command with the scan and skip flags used by the
+// env tests, parsed from args.
+func envCommand(t *testing.T, args ...string) *cobra.Command {
+	t.Helper()
+	c := &cobra.Command{Use: "test"}
+	c.Flags().BoolVar(&flagDevCaches, "dev-caches", false, "")
+	c.Flags().BoolVar(&flagBrowserData, "browser-data", false, "")
+	c.Flags().BoolVar(&flagScanNpm, "npm", false, "")
+	c.Flags().BoolVar(&flagSkipDocker, "skip-docker", false, "")
+	c.Flags().BoolVar(&flagScanDocker, "docker", false, "")
+	c.Flags().BoolVar(&flagSkipNpm, "skip-npm", false, "")
+	if err := c.Flags().Parse(args); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		flagDevCaches, flagBrowserData, flagScanNpm = false, false, false
+		flagSkipDocker, flagScanDocker, flagSkipNpm = false, false, false
+	})
+	return c
+}
+
+func envFunc(vars map[string]string) func(string) string {
+	return func(key string) string { return vars[key] }
+}
+
+func TestApplyEnvSelection_ScanAndSkip(t *testing.T) {
+	c := envCommand(t)
+	err := applyEnvSelection(c, envFunc(map[string]string{
+		envScan: "developer, browser-data",
+		envSkip: "docker,npm",
+	}))
+	if err != nil {
+		t.Fatalf("applyEnvSelection: %v", err)
+	}
+	if !flagDevCaches || !flagBrowserData {
+		t.Error("expected developer and browser groups selected")
+	}
+	if !flagSkipDocker || !flagSkipNpm {
+		t.Error("expected docker and npm skipped")
+	}
+}
+
+func TestApplyEnvSelection_FlagsWin(t *testing.T) {
+	c := envCommand(t, "--docker", "--dev-caches=false")
+	err := applyEnvSelection(c, envFunc(map[string]string{
+		envScan: "dev-caches",
+		envSkip: "docker",
+	}))
+	if err != nil {
+		t.Fatalf("applyEnvSelection: %v", err)
+	}
+	if flagDevCaches {
+		t.Error("explicit --dev-caches=false should override MAC_CLEANER_SCAN")
+	}
+	if flagSkipDocker {
+		t.Error("explicit --docker should override MAC_CLEANER_SKIP")
+	}
+}
+
+func TestApplyEnvSelection_Unset(t *testing.T) {
+	c := envCommand(t)
+	if err := applyEnvSelection(c, envFunc(nil)); err != nil {
+		t.Fatalf("applyEnvSelection: %v", err)
+	}
+	if flagDevCaches || flagBrowserData || flagSkipDocker || flagSkipNpm {
+		t.Error("expected no flags changed without env vars")
+	}
+}
+
+func TestApplyEnvSelection_RejectsInvalidTokens(t *testing.T) {
+	c := envCommand(t)
+	err := applyEnvSelection(c, envFunc(map[string]string{
+		envScan: "developer,bogus",
+		envSkip: "safari",
+	}))
+	if err == nil {
+		t.Fatal("expected error for invalid tokens")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, `MAC_CLEANER_SCAN: unknown category "bogus"`) {
+		t.Errorf("expected unknown token reported, got: %s", msg)
+	}
+	if !strings.Contains(msg, `MAC_CLEANER_SKIP: "safari" is not supported`) {
+		t.Errorf("expected unregistered flag reported, got: %s", msg)
+	}
+	if flagDevCaches {
+		t.Error("nothing should be applied when a token is invalid")
+	}
+}
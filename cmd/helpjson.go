@@ -64,7 +64,32 @@ func buildHelpJSON() helpJSON {
 			"scan": {
 				Usage:       "mac-cleaner scan [flags]",
 				Description: "Scan specific categories or items",
-				Notes:       "Requires at least one scan flag",
+				Notes:       "Requires at least one scan flag, --categories-file or --category-include",
+			},
+			"clean": {
+				Usage:       "mac-cleaner clean --paths-file <file>",
+				Description: "Delete an explicit list of paths without scanning",
+				Notes:       "Each path is safety-checked; reports removed, failed or skipped per path",
+			},
+			"discover": {
+				Usage:       "mac-cleaner discover [--depth N] [--top N] [--exclude DIR]",
+				Description: "List the largest directories under home that no category covers",
+				Notes:       "Informational only; nothing is offered for deletion",
+			},
+			"doctor": {
+				Usage:       "mac-cleaner doctor [--json] [--no-exec] [--home DIR]",
+				Description: "Run every scanner and report which found data, found nothing, or could not check",
+				Notes:       "Informational only; nothing is offered for deletion",
+			},
+			"total": {
+				Usage:       "mac-cleaner total [--human] [--skip-<name>...]",
+				Description: "Print only the total reclaimable bytes, for status bars and shell prompts",
+				Notes:       "Prints a bare integer, or a formatted size with --human; nothing is deleted",
+			},
+			"compact": {
+				Usage:       "mac-cleaner compact [--dry-run] [--force] [--json] [--no-exec]",
+				Description: "Shrink Parallels, UTM and Docker Desktop disk images in place instead of deleting them",
+				Notes:       "Runs prl_disk_tool, qemu-img or Docker's reclaim-space container and reports the bytes reclaimed per image",
 			},
 			"serve": {
 				Usage:       "mac-cleaner serve --socket <path>",
@@ -73,16 +98,54 @@ func buildHelpJSON() helpJSON {
 		},
 		GlobalFlags: []helpFlag{
 			{Flag: "--dry-run", Description: "preview what would be removed without deleting"},
+			{Flag: "--report-only", Description: "scan and report only; refuse all cleanup (policy control)"},
+			{Flag: "--accept-risk", Description: "acknowledge that deletions are permanent (required with --force on first run)"},
+			{Flag: "--log-level", Description: "diagnostic log level on stderr: debug, info, warn or error"},
+			{Flag: "--log-json", Description: "write diagnostic logs as JSON lines"},
+			{Flag: "--locale", Description: "format sizes and dates for a locale: en, de, fr, pl, ru, uk, or auto to follow LANG"},
+			{Flag: "--on-disk-size", Description: "count the disk blocks files occupy (Finder's \"on disk\" size) instead of their logical size"},
+			{Flag: "--scan-timeout DUR", Description: "stop scanning after this long and report what was found so far (e.g. 2m; 0 disables)"},
+			{Flag: "--confirm-format FMT", Description: "how the confirmation before deletion is shown: rich (default) or plain, uncolored with one item per line"},
 		},
 		OutputFlags: []helpFlag{
 			{Flag: "--json", Description: "output results as JSON"},
 			{Flag: "--verbose", Description: "show detailed file listing"},
+			{Flag: "--compact", Description: "print one line per category (automatic on narrow terminals)"},
+			{Flag: "--manifest FILE", Description: "write a JSON manifest of every item found (category, path, size, mtime, risk, SHA-256 of the first 4KB) to FILE for review; nothing is deleted"},
+			{Flag: "--preview-risky", Description: "list only the risky entries a cleanup would include, with their total; never deletes"},
+			{Flag: "--no-spinner", Description: "print plain status lines instead of the animated spinner (automatic when output is not a terminal)"},
+			{Flag: "--keep-recent N", Description: "always keep the N newest items in time-based categories (old Downloads, iOS backups)"},
+			{Flag: "--keep-latest-devicesupport", Description: "never offer the newest Xcode iOS DeviceSupport version of each device family for deletion"},
+			{Flag: "--exclude-newer-than D", Description: "withhold items containing changes newer than this age (e.g. 1h) from deletion"},
+			{Flag: "--list-paths", Description: "list the paths each selected scanner examines, without scanning"},
+			{Flag: "--absolute-paths", Description: "show full paths instead of shortening the home directory to ~"},
+			{Flag: "--app-dir DIR", Description: "extra directory to search for unused applications (repeatable)"},
+			{Flag: "--tmp-caches", Description: "also scan temporary app caches in /private/var/folders (opt-in)"},
+			{Flag: "--node-modules", Description: "also scan stale node_modules (90+ days) under the project roots (opt-in)"},
+			{Flag: "--pyenvs", Description: "also scan stale Python virtualenvs and __pycache__ (90+ days) under the project roots (opt-in)"},
+			{Flag: "--home DIR", Description: "scan this home directory instead of your own, e.g. another account's for an audit; deletions stay inside it"},
+			{Flag: "--project-root DIR", Description: "directory searched for stale node_modules and Python environments instead of ~/Developer, ~/Projects and ~/Documents/code (repeatable)"},
+			{Flag: "--coalesce-under SIZE", Description: "group categories smaller than this size (e.g. 100MB) into one \"Other\" row in the summary"},
+			{Flag: "--include-hidden", Description: "also consider hidden (dot-prefixed) entries in ~/Downloads and hidden directories under the project roots"},
+			{Flag: "--expand-blobs", Description: "list the top-level entries of caches normally shown as one blob (yarn, pnpm, Mail, Messages, iOS updates) instead; VM bundles stay whole"},
+			{Flag: "--max-depth N", Description: "size cache entries only this many directory levels deep for a fast overview; deeper content is left out and marked (0, the default, sizes everything)"},
+			{Flag: "--no-exec", Description: "run no external commands (docker, brew, tmutil, mdls, PlistBuddy); categories that need them are skipped or sized from disk"},
+			{Flag: "--strict", Description: "stop at the first scanner error and exit with status 4 instead of reporting partial results (for CI)"},
+			{Flag: "--removal-timeout D", Description: "give up on an item whose removal takes longer than this (default 10m) and move on (0 waits)"},
+			{Flag: "--skip-network-paths", Description: "do not size or delete anything on a network-backed home directory"},
+			{Flag: "--if-below THRESHOLD", Description: "only run when free space on the home volume is below this percentage (e.g. 10%) or size (e.g. 20GB)"},
+			{Flag: "--resume", Description: "pre-fill walkthrough answers with the choices saved by the previous --resume run"},
+			{Flag: "--sudo", Description: "delete root-owned items with sudo, asking for the password once"},
+			{Flag: "--verify", Description: "after cleanup, compare the reported bytes freed with the measured change in free disk space"},
 			{Flag: "--force", Description: "bypass confirmation prompt (for automation)"},
 		},
 		Examples: []helpExample{
 			{Command: "mac-cleaner scan --npm --yarn --json", Description: "Scan only npm and yarn caches, output as JSON"},
 			{Command: "mac-cleaner scan --all --skip-docker --dry-run", Description: "Dry-run scan everything except Docker"},
 			{Command: "mac-cleaner scan --dev-caches --safari", Description: "Scan all developer caches plus Safari"},
+			{Command: "mac-cleaner scan --categories-file categories.txt --dry-run", Description: "Preview the categories listed in a file"},
+			{Command: "mac-cleaner scan --category-include '^dev-' --category-exclude dev-docker --dry-run", Description: "Preview every developer category except Docker"},
+			{Command: "mac-cleaner clean --paths-file paths.txt --force --json", Description: "Delete paths computed elsewhere, with safety checks"},
 			{Command: "mac-cleaner --all --dry-run", Description: "Preview all reclaimable space"},
 			{Command: "mac-cleaner", Description: "Interactive walkthrough mode"},
 		},
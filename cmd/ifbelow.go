@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// exitEnoughSpace is the exit status when --if-below finds free space at
+// or above the threshold and nothing is scanned or cleaned.
+const exitEnoughSpace = 3
+
+// flagIfBelow makes a run conditional on low free space (--if-below).
+var flagIfBelow spaceThreshold
+
+// volumeSpace reports the available and total bytes of the volume holding
+// a path. Tests replace it.
+var volumeSpace = scan.VolumeSpace
+
+// spaceThreshold is the --if-below value: a percentage of the volume
+// ("10%") or an absolute size ("20GB"). It implements pflag.Value.
+type spaceThreshold struct {
+	percent float64
+	bytes   int64
+	set     bool
+}
+
+func (t *spaceThreshold) String() string {
+	switch {
+	case !t.set:
+		return ""
+	case t.bytes > 0:
+		return scan.FormatSize(t.bytes)
+	}
+	return strconv.FormatFloat(t.percent, 'f', -1, 64) + "%"
+}
+
+func (t *spaceThreshold) Set(s string) error {
+	s = strings.TrimSpace(s)
+	if p, ok := strings.CutSuffix(s, "%"); ok {
+		pct, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil || pct <= 0 || pct > 100 {
+			return fmt.Errorf("invalid percentage %q: want a number between 0 and 100", s)
+		}
+		*t = spaceThreshold{percent: pct, set: true}
+		return nil
+	}
+	n, err := scan.ParseSize(s)
+	if err != nil {
+		return err
+	}
+	if n <= 0 {
+		return fmt.Errorf("invalid size %q: must be positive", s)
+	}
+	*t = spaceThreshold{bytes: n, set: true}
+	return nil
+}
+
+func (t *spaceThreshold) Type() string { return "percent|size" }
+
+// limit returns the threshold in bytes for a volume of total bytes.
+func (t *spaceThreshold) limit(total int64) int64 {
+	if t.bytes > 0 {
+		return t.bytes
+	}
+	return int64(float64(total) * t.percent / 100)
+}
+
+// enoughFreeSpace reports whether the volume holding home has at least
+// the --if-below threshold free, in which case the run should stop. The
+// decision is explained on w either way. An error reading the volume is
+// returned so scheduled runs do not clean blindly.
+func enoughFreeSpace(w io.Writer, home string) (bool, error) {
+	avail, total, err := volumeSpace(home)
+	if err != nil {
+		return false, fmt.Errorf("--if-below: cannot read free space: %w", err)
+	}
+	limit := flagIfBelow.limit(total)
+	if avail >= limit {
+		fmt.Fprintf(w, "%s free is not below %s (--if-below %s); nothing to do.\n",
+			scan.FormatSize(avail), scan.FormatSize(limit), flagIfBelow.String())
+		return true, nil
+	}
+	fmt.Fprintf(w, "%s free is below %s (--if-below %s); proceeding.\n",
+		scan.FormatSize(avail), scan.FormatSize(limit), flagIfBelow.String())
+	return false, nil
+}
+
+// applyIfBelow enforces --if-below before anything is scanned: it exits
+// with exitEnoughSpace when free space is not below the threshold, and
+// with status 1 when free space cannot be read.
+func applyIfBelow() {
+	if !flagIfBelow.set {
+		return
+	}
+	home, _ := safety.Home()
+	enough, err := enoughFreeSpace(os.Stderr, home)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if enough {
+		os.Exit(exitEnoughSpace)
+	}
+}
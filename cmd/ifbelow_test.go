@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeVolume replaces volumeSpace with fixed figures for the test.
+func fakeVolume(t *testing.T, avail, total int64, err error) {
+	t.Helper()
+	orig := volumeSpace
+	volumeSpace = func(string) (int64, int64, error) { return avail, total, err }
+	t.Cleanup(func() {
+		volumeSpace = orig
+		flagIfBelow = spaceThreshold{}
+	})
+}
+
+func TestSpaceThreshold_Set(t *testing.T) {
+	tests := []struct {
+		in      string
+		percent float64
+		bytes   int64
+		wantErr bool
+	}{
+		{"10%", 10, 0, false},
+		{"12.5 %", 12.5, 0, false},
+		{"20GB", 0, 20_000_000_000, false},
+		{"0%", 0, 0, true},
+		{"150%", 0, 0, true},
+		{"abc%", 0, 0, true},
+		{"lots", 0, 0, true},
+	}
+	for _, tt := range tests {
+		var th spaceThreshold
+		err := th.Set(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Set(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err == nil && (th.percent != tt.percent || th.bytes != tt.bytes) {
+			t.Errorf("Set(%q) = %+v, want percent %v bytes %d", tt.in, th, tt.percent, tt.bytes)
+		}
+	}
+}
+
+func TestEnoughFreeSpace_AboveThresholdStops(t *testing.T) {
+	fakeVolume(t, 30_000_000_000, 100_000_000_000, nil)
+	if err := flagIfBelow.Set("10%"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	enough, err := enoughFreeSpace(&buf, "/home")
+	if err != nil {
+		t.Fatalf("enoughFreeSpace: %v", err)
+	}
+	if !enough {
+		t.Error("expected early exit with 30% free and a 10% threshold")
+	}
+	if !strings.Contains(buf.String(), "nothing to do") {
+		t.Errorf("expected explanation, got: %s", buf.String())
+	}
+}
+
+func TestEnoughFreeSpace_BelowThresholdProceeds(t *testing.T) {
+	fakeVolume(t, 5_000_000_000, 100_000_000_000, nil)
+	if err := flagIfBelow.Set("20GB"); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	enough, err := enoughFreeSpace(&buf, "/home")
+	if err != nil {
+		t.Fatalf("enoughFreeSpace: %v", err)
+	}
+	if enough {
+		t.Error("expected to proceed with 5 GB free and a 20 GB threshold")
+	}
+	if !strings.Contains(buf.String(), "proceeding") {
+		t.Errorf("expected explanation, got: %s", buf.String())
+	}
+}
+
+func TestEnoughFreeSpace_ReadError(t *testing.T) {
+	fakeVolume(t, 0, 0, errors.New("statfs failed"))
+	if err := flagIfBelow.Set("10%"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := enoughFreeSpace(&bytes.Buffer{}, "/home"); err == nil {
+		t.Error("expected error when free space cannot be read")
+	}
+}
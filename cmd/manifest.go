@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// writeManifest writes the manifest of results (see scan.NewManifest) to
+// the file at path, replacing it, and notes on w what was written.
+func writeManifest(w io.Writer, path string, results []scan.CategoryResult) error {
+	m := scan.NewManifest(results)
+	m.GeneratedAt = time.Now().Truncate(time.Second)
+	m.ToolVersion = version
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("create manifest: %w", err)
+	}
+	if err := scan.WriteManifest(f, m); err != nil {
+		f.Close()
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("write manifest: %w", err)
+	}
+	fmt.Fprintf(w, "Wrote manifest of %d item(s), %s, to %s. Nothing was deleted.\n",
+		len(m.Entries), scan.FormatSize(m.TotalSize), path)
+	return nil
+}
+
+// mustWriteManifest writes the --manifest file for results, exiting with
+// status 1 if it cannot. Callers end the run afterwards: a manifest run
+// only reports.
+func mustWriteManifest(results []scan.CategoryResult) {
+	if err := writeManifest(os.Stderr, flagManifest, results); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// flagPreviewRisky lists only the risky entries of a scan, without
+// deleting anything (--preview-risky).
+var flagPreviewRisky bool
+
+// riskyOnly returns the risky entries of results, grouped by category.
+// An entry without a risk level takes its category's. Categories without
+// risky entries are dropped, and withheld entries and permission issues
+// are left out. The input slice is not modified.
+func riskyOnly(results []scan.CategoryResult) []scan.CategoryResult {
+	var out []scan.CategoryResult
+	for _, cat := range results {
+		kept := scan.CategoryResult{
+			Category:    cat.Category,
+			Description: cat.Description,
+			ReportOnly:  cat.ReportOnly,
+		}
+		for _, e := range cat.Entries {
+			if e.RiskLevel == "" {
+				e.RiskLevel = safety.RiskForCategory(cat.Category)
+			}
+			if e.RiskLevel != safety.RiskRisky {
+				continue
+			}
+			kept.Entries = append(kept.Entries, e)
+			kept.TotalSize += e.Size
+		}
+		if len(kept.Entries) > 0 {
+			out = append(out, kept)
+		}
+	}
+	return out
+}
+
+// printRiskyPreview lists the risky entries of results on w with their
+// sizes, the total they would free if included in a cleanup, and how to
+// leave each category out.
+func printRiskyPreview(w io.Writer, results []scan.CategoryResult) {
+	risky := riskyOnly(results)
+	home, _ := safety.Home()
+	bold := color.New(color.Bold)
+	cyan := color.New(color.FgCyan)
+	faint := color.New(color.Faint)
+	red := color.New(color.FgRed)
+
+	fmt.Fprintln(w)
+	_, _ = bold.Fprintln(w, "Risky items (preview only; nothing is deleted)")
+	if len(risky) == 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "  No risky items found.")
+		fmt.Fprintln(w)
+		return
+	}
+
+	var total int64
+	var count int
+	for _, cat := range risky {
+		fmt.Fprintln(w)
+		_, _ = bold.Fprintf(w, "  %s\n", cat.Description)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+		for _, e := range cat.Entries {
+			fmt.Fprintf(tw, "    %s\t  %s\t\n", scan.ValidUTF8(e.Description), cyan.Sprint(scan.FormatSize(e.Size)))
+			if flagVerbose {
+				fmt.Fprintf(tw, "      %s\t\t\n", displayPath(e.Path, home))
+			}
+		}
+		_ = tw.Flush()
+		if item := categoryItem(cat.Category); item != nil && item.SkipFlag != nil && item.FlagName != "" {
+			fmt.Fprintf(w, "    %s\n", faint.Sprintf("leave out with --skip-%s", item.FlagName))
+		}
+		total += cat.TotalSize
+		count += len(cat.Entries)
+	}
+
+	fmt.Fprintln(w)
+	_, _ = red.Fprintf(w, "  Reclaimable if risky items are included: %s (%d items)\n", scan.FormatSize(total), count)
+	fmt.Fprintln(w, "  Risky items may hold data that cannot be re-downloaded or rebuilt, such as")
+	fmt.Fprintln(w, "  backups, mail, messages, virtual machines and app settings. Review them")
+	fmt.Fprintln(w, "  before a cleanup that includes them.")
+	fmt.Fprintln(w)
+}
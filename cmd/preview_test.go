@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// previewSampleResults holds a risky category, a safe category, and a
+// moderate category with one entry marked risky by its scanner.
+func previewSampleResults() []scan.CategoryResult {
+	return []scan.CategoryResult{
+		{Category: "app-ios-backups", Description: "iOS Device Backups", TotalSize: 3000, Entries: []scan.ScanEntry{
+			{Path: "/u/Backup/a", Description: "iPhone", Size: 3000},
+		}},
+		{Category: "system-caches", Description: "User App Caches", TotalSize: 500, Entries: []scan.ScanEntry{
+			{Path: "/u/Library/Caches/x", Description: "x", Size: 500, RiskLevel: safety.RiskSafe},
+		}},
+		{Category: "app-old-downloads", Description: "Old Downloads (90+ days)", TotalSize: 1200, Entries: []scan.ScanEntry{
+			{Path: "/u/Downloads/photos", Description: "photos (includes iCloud-only files)", Size: 1000, RiskLevel: safety.RiskRisky},
+			{Path: "/u/Downloads/old.zip", Description: "old.zip", Size: 200},
+		}},
+	}
+}
+
+func TestRiskyOnly(t *testing.T) {
+	results := previewSampleResults()
+	got := riskyOnly(results)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 categories with risky entries, got %+v", got)
+	}
+	if got[0].Category != "app-ios-backups" || got[0].TotalSize != 3000 || got[0].Entries[0].RiskLevel != safety.RiskRisky {
+		t.Errorf("expected iOS backup kept with the category risk, got %+v", got[0])
+	}
+	if len(got[1].Entries) != 1 || got[1].Entries[0].Path != "/u/Downloads/photos" || got[1].TotalSize != 1000 {
+		t.Errorf("expected only the risky download kept, got %+v", got[1])
+	}
+	if results[0].Entries[0].RiskLevel != "" {
+		t.Error("riskyOnly modified its input")
+	}
+}
+
+func TestPrintRiskyPreview(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	printRiskyPreview(&buf, previewSampleResults())
+	out := buf.String()
+
+	for _, want := range []string{
+		"iPhone",
+		"photos (includes iCloud-only files)",
+		"leave out with --skip-ios-backups",
+		"Reclaimable if risky items are included: " + scan.FormatSize(4000) + " (2 items)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("preview missing %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"User App Caches", "old.zip"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("preview should not list %q:\n%s", unwanted, out)
+		}
+	}
+}
+
+func TestPrintRiskyPreview_NoneFound(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	printRiskyPreview(&buf, previewSampleResults()[1:2])
+	if !strings.Contains(buf.String(), "No risky items found.") {
+		t.Errorf("expected no-risky message, got:\n%s", buf.String())
+	}
+}
+
+func TestRiskyOnly_JSONTotals(t *testing.T) {
+	data, err := json.Marshal(riskyOnly(previewSampleResults()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "old.zip") || strings.Contains(string(data), "Caches/x") {
+		t.Errorf("expected only risky entries in JSON: %s", data)
+	}
+}
@@ -4,21 +4,26 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"text/tabwriter"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 
 	"github.com/sp3esu/mac-cleaner/internal/cleanup"
 	"github.com/sp3esu/mac-cleaner/internal/confirm"
 	"github.com/sp3esu/mac-cleaner/internal/engine"
 	"github.com/sp3esu/mac-cleaner/internal/interactive"
+	"github.com/sp3esu/mac-cleaner/internal/logging"
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 	"github.com/sp3esu/mac-cleaner/internal/spinner"
@@ -48,6 +53,36 @@ var (
 	flagVerbose      bool
 	flagForce        bool
 	flagHelpJSON     bool
+	flagReportOnly   bool
+	flagAbsolutePaths bool
+	flagAcceptRisk    bool
+	flagKeepRecent    int
+	flagKeepLatestDS  bool
+	flagExcludeNewer  time.Duration
+	flagListPaths     bool
+	flagCompact       bool
+	flagNoSpinner     bool
+	flagAppDirs       []string
+	flagProjectRoots  []string
+	flagIncludeHidden bool
+	flagExpandBlobs   bool
+	flagMaxDepth      int
+	flagVerify        bool
+	flagSkipNetwork   bool
+	flagNoExec        bool
+	flagRemovalTO     time.Duration
+	flagStrict        bool
+	flagHome          string
+	flagCoalesceUnder sizeValue
+	flagSudo          bool
+	flagResume        bool
+	flagLogLevel      string
+	flagLogJSON       bool
+	flagLocale        string
+	flagOnDiskSize    bool
+	flagConfirmFormat string
+	flagManifest      string
+	flagScanTimeout   time.Duration
 )
 
 // Category-level skip flags prevent entire scanner groups from running.
@@ -66,21 +101,36 @@ var (
 // Item-level skip flags filter specific categories from scan results.
 var (
 	flagSkipDerivedData   bool
+	flagSkipXcodeIndex    bool
 	flagSkipNpm           bool
 	flagSkipYarn          bool
 	flagSkipHomebrew      bool
+	flagSkipBrewAutoremove bool
+	flagSkipNodeModules    bool
+	flagSkipPyEnvs         bool
 	flagSkipDocker        bool
 	flagSkipSafari        bool
 	flagSkipChrome        bool
+	flagSkipChromeStorage bool
+	flagSkipSafariFavicons    bool
+	flagSkipSafariWebsiteData bool
 	flagSkipFirefox       bool
 	flagSkipQuicklook     bool
+	flagSkipSysdiagnose   bool
+	flagSkipBrokenSymlinks bool
+	flagSkipInstallerLeftovers bool
+	flagSkipTmpCaches     bool
+	flagSkipICloudDriveCache bool
 	flagSkipOrphanedPrefs bool
+	flagSkipOrphanedGroupContainers bool
 	flagSkipIosBackups    bool
 	flagSkipOldDownloads      bool
 	flagSkipSimulatorCaches   bool
 	flagSkipSimulatorLogs     bool
 	flagSkipXcodeDevSupport   bool
 	flagSkipXcodeArchives     bool
+	flagSkipMobileDevice      bool
+	flagSkipDockerVM          bool
 	flagSkipPnpm              bool
 	flagSkipCocoapods         bool
 	flagSkipGradle            bool
@@ -89,20 +139,26 @@ var (
 	flagSkipAdobeMedia        bool
 	flagSkipSketch            bool
 	flagSkipFigma             bool
+	flagSkipAdobeLogs         bool
+	flagSkipFigmaProfile      bool
 	flagSkipSlack             bool
 	flagSkipDiscord           bool
 	flagSkipTeams             bool
 	flagSkipZoom              bool
+	flagSkipZoomRecordings    bool
+	flagSkipSlackDownloads    bool
 	flagSkipPhotosCaches      bool
 	flagSkipPhotosAnalysis    bool
 	flagSkipPhotosIcloudCache bool
 	flagSkipPhotosSyndication bool
 	flagSkipSpotlight        bool
 	flagSkipMail             bool
+	flagSkipMailEnvelope     bool
 	flagSkipMailDownloads    bool
 	flagSkipMessages         bool
 	flagSkipIOSUpdates       bool
 	flagSkipTimemachine      bool
+	flagSkipUnifiedLogs      bool
 	flagSkipVMParallels      bool
 	flagSkipVMUTM            bool
 	flagSkipVMVMware         bool
@@ -136,7 +192,13 @@ Examples:
 			return
 		}
 
-		sp := spinner.New("Scanning...", !flagJSON)
+		if flagBenchmark {
+			rows, total := runBenchmark(eng)
+			printBenchmark(os.Stdout, rows, total)
+			return
+		}
+
+		sp := newSpinner("Scanning...")
 		ran := false
 		var allResults []scan.CategoryResult
 
@@ -151,9 +213,33 @@ Examples:
 			{&flagPhotos, "photos"},
 			{&flagSystemData, "systemdata"},
 		}
+		if flagListPaths {
+			var ids []string
+			for _, m := range flagScanners {
+				if *m.flag {
+					ids = append(ids, m.scannerID)
+				}
+			}
+			if len(ids) == 0 {
+				for _, info := range eng.Categories() {
+					ids = append(ids, info.ID)
+				}
+			}
+			home := eng.Home
+			if home == "" {
+				home, _ = safety.Home()
+			}
+			printScanPaths(os.Stdout, ids, home)
+			return
+		}
+
 		for _, m := range flagScanners {
 			if *m.flag {
-				allResults = append(allResults, runScannerByID(m.scannerID, sp)...)
+				results, err := runScannerByID(m.scannerID, sp)
+				if err != nil {
+					exitStrict(err)
+				}
+				allResults = append(allResults, results...)
 				ran = true
 			}
 		}
@@ -164,9 +250,21 @@ Examples:
 		}
 
 		if !ran {
-			allResults = scanAll(sp)
+			results, err := scanAll(sp)
+			if err != nil {
+				exitStrict(err)
+			}
+			allResults = results
 			// Apply item-level skip filtering in interactive mode.
 			allResults = engine.FilterSkipped(allResults, buildSkipSet())
+			if flagManifest != "" {
+				mustWriteManifest(allResults)
+				return
+			}
+			if flagPreviewRisky {
+				printRiskyPreview(os.Stdout, allResults)
+				return
+			}
 			printPermissionIssues(allResults)
 			printDryRunSummary(os.Stdout, allResults)
 			if len(allResults) == 0 {
@@ -174,8 +272,14 @@ Examples:
 				return
 			}
 
+			// Report-only mode never offers anything for deletion.
+			if flagReportOnly {
+				printReportOnlyNotice(os.Stderr)
+				return
+			}
+
 			reader := bufio.NewReader(os.Stdin)
-			marked := interactive.RunWalkthrough(reader, os.Stdout, allResults)
+			marked := runWalkthrough(reader, os.Stdout, allResults)
 			if marked == nil {
 				return
 			}
@@ -184,51 +288,50 @@ Examples:
 				return
 			}
 
-			if !flagForce {
-				if !confirm.PromptConfirmation(reader, os.Stdout, marked) {
-					fmt.Println("Aborted.")
-					return
-				}
-			}
-			sp.UpdateMessage("Cleaning up...")
-			sp.Start()
-			result := cleanup.Execute(marked, cleanupProgress(sp, os.Stderr))
-			sp.Stop()
-			printCleanupSummary(os.Stdout, result)
+			runCleanup(reader, os.Stdout, sp, marked)
 			return
 		}
 
-		// Apply item-level skip filtering.
+		// Apply item-level skip filtering and canonical ordering.
 		allResults = engine.FilterSkipped(allResults, buildSkipSet())
+		engine.SortCategories(allResults, eng.CategoryOrder)
+
+		if flagManifest != "" {
+			mustWriteManifest(allResults)
+			return
+		}
+
+		if flagPreviewRisky {
+			if flagJSON {
+				printJSON(riskyOnly(allResults))
+			} else {
+				printRiskyPreview(os.Stdout, allResults)
+			}
+			return
+		}
 
 		if !flagJSON {
 			printPermissionIssues(allResults)
 		}
 
 		if flagJSON {
-			printJSON(allResults)
 			if flagDryRun {
-				return
+				printJSON(allResults)
+			} else {
+				runJSONCleanup(os.Stdin, os.Stdout, sp, allResults)
 			}
+			return
 		}
 
-		if flagDryRun && !flagJSON {
+		if flagDryRun {
 			printDryRunSummary(os.Stdout, allResults)
 		}
 
 		// Deletion flow: only when not in dry-run mode and there are results.
 		if !flagDryRun && len(allResults) > 0 {
-			if !flagForce {
-				if !confirm.PromptConfirmation(os.Stdin, os.Stdout, allResults) {
-					fmt.Println("Aborted.")
-					return
-				}
+			if toClean := withholdReportOnly(os.Stderr, allResults); len(toClean) > 0 {
+				runCleanup(os.Stdin, os.Stdout, sp, toClean)
 			}
-			sp.UpdateMessage("Cleaning up...")
-			sp.Start()
-			result := cleanup.Execute(allResults, cleanupProgress(sp, os.Stderr))
-			sp.Stop()
-			printCleanupSummary(os.Stdout, result)
 		}
 	},
 }
@@ -237,18 +340,57 @@ func init() {
 	rootCmd.Version = version
 	rootCmd.SetVersionTemplate("{{.Version}}\n")
 	rootCmd.PersistentFlags().BoolVar(&flagDryRun, "dry-run", false, "preview what would be removed without deleting")
-	rootCmd.Flags().BoolVar(&flagSystemCaches, "system-caches", false, "scan user app caches, logs, and QuickLook thumbnails")
+	rootCmd.PersistentFlags().BoolVar(&flagReportOnly, "report-only", false, "scan and report only; refuse all cleanup (policy control)")
+	rootCmd.PersistentFlags().BoolVar(&flagAcceptRisk, "accept-risk", false, "acknowledge that deletions are permanent (required with --force on first run)")
+	rootCmd.PersistentFlags().StringVar(&flagLogLevel, "log-level", logging.DefaultLevel, "diagnostic log level on stderr: debug, info, warn or error")
+	rootCmd.PersistentFlags().BoolVar(&flagLogJSON, "log-json", false, "write diagnostic logs as JSON lines")
+	rootCmd.PersistentFlags().StringVar(&flagLocale, "locale", "", "format sizes and dates for a locale: en, de, fr, pl, ru, uk, or auto to follow LANG")
+	rootCmd.PersistentFlags().BoolVar(&flagOnDiskSize, "on-disk-size", false, "count the disk blocks files occupy (Finder's \"on disk\" size) instead of their logical size")
+	rootCmd.PersistentFlags().DurationVar(&flagScanTimeout, "scan-timeout", 0, "stop scanning after this long and report what was found so far (e.g. 2m; 0 disables)")
+	rootCmd.PersistentFlags().StringVar(&flagConfirmFormat, "confirm-format", string(confirm.FormatRich), "how the confirmation before deletion is shown: rich (default) or plain, uncolored with one item per line")
+	rootCmd.Flags().BoolVar(&flagSystemCaches, "system-caches", false, "scan user app caches, logs, QuickLook thumbnails, diagnostic archives, broken symlinks, and installer leftovers")
 	rootCmd.Flags().BoolVar(&flagBrowserData, "browser-data", false, "scan Safari, Chrome, and Firefox caches")
 	rootCmd.Flags().BoolVar(&flagDevCaches, "dev-caches", false, "scan Xcode, npm/yarn, Homebrew, and Docker caches")
 	rootCmd.Flags().BoolVar(&flagAppLeftovers, "app-leftovers", false, "scan orphaned preferences, iOS backups, and old Downloads")
 	rootCmd.Flags().BoolVar(&flagCreativeCaches, "creative-caches", false, "scan Adobe, Sketch, and Figma caches")
-	rootCmd.Flags().BoolVar(&flagMessagingCaches, "messaging-caches", false, "scan Slack, Discord, Teams, and Zoom caches")
+	rootCmd.Flags().BoolVar(&flagMessagingCaches, "messaging-caches", false, "scan Slack, Discord, Teams, and Zoom caches, recordings, and downloads")
 	rootCmd.Flags().BoolVar(&flagUnusedApps, "unused-apps", false, "scan applications not opened in 180+ days")
 	rootCmd.Flags().BoolVar(&flagPhotos, "photos", false, "scan Photos app caches and media analysis data")
 	rootCmd.Flags().BoolVar(&flagSystemData, "system-data", false, "scan Spotlight, Mail, Messages, iOS updates, Time Machine, and VMs")
 	rootCmd.Flags().BoolVar(&flagAll, "all", false, "scan all categories")
+	rootCmd.Flags().StringVar(&flagCategoryInclude, "category-include", "", "keep only the categories whose ID matches this regular expression")
+	rootCmd.Flags().StringVar(&flagCategoryExclude, "category-exclude", "", "drop the categories whose ID matches this regular expression (wins over --category-include)")
 	rootCmd.Flags().BoolVar(&flagJSON, "json", false, "output results as JSON")
 	rootCmd.Flags().BoolVar(&flagVerbose, "verbose", false, "show detailed file listing")
+	rootCmd.Flags().BoolVar(&flagCompact, "compact", false, "print one line per category (automatic on narrow terminals)")
+	rootCmd.Flags().StringVar(&flagManifest, "manifest", "", "write a JSON manifest of every item found (category, path, size, mtime, risk, SHA-256 of the first 4KB) to FILE for review; nothing is deleted")
+	rootCmd.Flags().BoolVar(&flagPreviewRisky, "preview-risky", false, "list only the risky entries a cleanup would include, with their total; never deletes")
+	rootCmd.Flags().BoolVar(&flagNoSpinner, "no-spinner", false, "print plain status lines instead of the animated spinner (automatic when output is not a terminal)")
+	rootCmd.Flags().IntVar(&flagKeepRecent, "keep-recent", 0, "always keep the N newest items in time-based categories (old Downloads, iOS backups)")
+	rootCmd.Flags().BoolVar(&flagKeepLatestDS, "keep-latest-devicesupport", false, "never offer the newest Xcode iOS DeviceSupport version of each device family for deletion")
+	rootCmd.Flags().DurationVar(&flagExcludeNewer, "exclude-newer-than", 0, "withhold items containing changes newer than this age (e.g. 1h) from deletion")
+	rootCmd.Flags().BoolVar(&flagAbsolutePaths, "absolute-paths", false, "show full paths instead of shortening the home directory to ~")
+	rootCmd.Flags().StringSliceVar(&flagAppDirs, "app-dir", nil, "extra directory to search for unused applications (repeatable)")
+	rootCmd.Flags().BoolVar(&flagScanTmpCaches, "tmp-caches", false, "also scan temporary app caches in /private/var/folders (opt-in)")
+	rootCmd.Flags().BoolVar(&flagScanNodeModules, "node-modules", false, "also scan stale node_modules (90+ days) under the project roots (opt-in)")
+	rootCmd.Flags().BoolVar(&flagScanPyEnvs, "pyenvs", false, "also scan stale Python virtualenvs and __pycache__ (90+ days) under the project roots (opt-in)")
+	rootCmd.Flags().StringVar(&flagHome, "home", "", "scan this home directory instead of your own, e.g. another account's for an audit; deletions stay inside it")
+	rootCmd.Flags().StringSliceVar(&flagProjectRoots, "project-root", nil, "directory searched for stale node_modules and Python environments instead of ~/Developer, ~/Projects and ~/Documents/code (repeatable)")
+	rootCmd.Flags().BoolVar(&flagIncludeHidden, "include-hidden", false, "also consider hidden (dot-prefixed) entries in ~/Downloads and hidden directories under the project roots")
+	rootCmd.Flags().BoolVar(&flagExpandBlobs, "expand-blobs", false, "list the top-level entries of caches normally shown as one blob (yarn, pnpm, Mail, Messages, iOS updates) instead; VM bundles stay whole")
+	rootCmd.Flags().IntVar(&flagMaxDepth, "max-depth", 0, "size cache entries only this many directory levels deep for a fast overview; deeper content is left out and marked (0, the default, sizes everything)")
+	rootCmd.Flags().BoolVar(&flagNoExec, "no-exec", false, "run no external commands (docker, brew, tmutil, mdls, PlistBuddy); categories that need them are skipped or sized from disk")
+	rootCmd.Flags().BoolVar(&flagStrict, "strict", false, "stop at the first scanner error and exit with status 4 instead of reporting partial results")
+	rootCmd.Flags().DurationVar(&flagRemovalTO, "removal-timeout", cleanup.DefaultEntryTimeout, "give up on an item whose removal takes longer than this and move on (0 waits)")
+	rootCmd.Flags().BoolVar(&flagListPaths, "list-paths", false, "list the paths each selected scanner examines, without scanning")
+	rootCmd.Flags().Var(&flagCoalesceUnder, "coalesce-under", "group categories smaller than this size (e.g. 100MB) into one \"Other\" row in the summary")
+	rootCmd.Flags().BoolVar(&flagSkipNetwork, "skip-network-paths", false, "do not size or delete anything on a network-backed home directory")
+	rootCmd.Flags().BoolVar(&flagBenchmark, "benchmark", false, "run every scanner and print a table of per-scanner timings (no deletion)")
+	_ = rootCmd.Flags().MarkHidden("benchmark")
+	rootCmd.Flags().Var(&flagIfBelow, "if-below", "only run when free space on the home volume is below this percentage (e.g. 10%) or size (e.g. 20GB)")
+	rootCmd.Flags().BoolVar(&flagSudo, "sudo", false, "delete root-owned items with sudo, asking for the password once")
+	rootCmd.Flags().BoolVar(&flagResume, "resume", false, "pre-fill walkthrough answers with the choices saved by the previous --resume run")
+	rootCmd.Flags().BoolVar(&flagVerify, "verify", false, "after cleanup, compare the reported bytes freed with the measured change in free disk space")
 	rootCmd.Flags().BoolVar(&flagForce, "force", false, "bypass confirmation prompt (for automation)")
 	rootCmd.Flags().BoolVar(&flagHelpJSON, "help-json", false, "output structured help as JSON for AI agents")
 
@@ -265,21 +407,36 @@ func init() {
 
 	// Item-level skip flags.
 	rootCmd.Flags().BoolVar(&flagSkipDerivedData, "skip-derived-data", false, "skip Xcode DerivedData")
+	rootCmd.Flags().BoolVar(&flagSkipXcodeIndex, "skip-xcode-index", false, "skip Xcode index stores in DerivedData")
 	rootCmd.Flags().BoolVar(&flagSkipNpm, "skip-npm", false, "skip npm cache")
 	rootCmd.Flags().BoolVar(&flagSkipYarn, "skip-yarn", false, "skip Yarn cache")
 	rootCmd.Flags().BoolVar(&flagSkipHomebrew, "skip-homebrew", false, "skip Homebrew cache")
+	rootCmd.Flags().BoolVar(&flagSkipBrewAutoremove, "skip-brew-autoremove", false, "skip unneeded Homebrew dependencies")
+	rootCmd.Flags().BoolVar(&flagSkipNodeModules, "skip-node-modules", false, "skip stale node_modules")
+	rootCmd.Flags().BoolVar(&flagSkipPyEnvs, "skip-pyenvs", false, "skip stale Python virtualenvs and __pycache__")
 	rootCmd.Flags().BoolVar(&flagSkipDocker, "skip-docker", false, "skip Docker reclaimable space")
 	rootCmd.Flags().BoolVar(&flagSkipSafari, "skip-safari", false, "skip Safari cache")
+	rootCmd.Flags().BoolVar(&flagSkipSafariFavicons, "skip-safari-favicons", false, "skip Safari favicon and touch icon caches")
+	rootCmd.Flags().BoolVar(&flagSkipSafariWebsiteData, "skip-safari-website-data", false, "skip Safari local storage and databases")
 	rootCmd.Flags().BoolVar(&flagSkipChrome, "skip-chrome", false, "skip Chrome cache")
+	rootCmd.Flags().BoolVar(&flagSkipChromeStorage, "skip-chrome-storage", false, "skip Chrome service worker and code caches")
 	rootCmd.Flags().BoolVar(&flagSkipFirefox, "skip-firefox", false, "skip Firefox cache")
 	rootCmd.Flags().BoolVar(&flagSkipQuicklook, "skip-quicklook", false, "skip QuickLook thumbnails")
+	rootCmd.Flags().BoolVar(&flagSkipSysdiagnose, "skip-sysdiagnose", false, "skip sysdiagnose and spindump archives")
+	rootCmd.Flags().BoolVar(&flagSkipBrokenSymlinks, "skip-broken-symlinks", false, "skip broken symlinks in bin directories")
+	rootCmd.Flags().BoolVar(&flagSkipInstallerLeftovers, "skip-installer-leftovers", false, "skip installer receipts and partial App Store downloads")
+	rootCmd.Flags().BoolVar(&flagSkipTmpCaches, "skip-tmp-caches", false, "skip temporary app caches in /private/var/folders")
+	rootCmd.Flags().BoolVar(&flagSkipICloudDriveCache, "skip-iclouddrive-cache", false, "skip iCloud Drive evictable cache")
 	rootCmd.Flags().BoolVar(&flagSkipOrphanedPrefs, "skip-orphaned-prefs", false, "skip orphaned preferences")
+	rootCmd.Flags().BoolVar(&flagSkipOrphanedGroupContainers, "skip-orphaned-group-containers", false, "skip orphaned Group Containers")
 	rootCmd.Flags().BoolVar(&flagSkipIosBackups, "skip-ios-backups", false, "skip iOS device backups")
 	rootCmd.Flags().BoolVar(&flagSkipOldDownloads, "skip-old-downloads", false, "skip old Downloads files")
 	rootCmd.Flags().BoolVar(&flagSkipSimulatorCaches, "skip-simulator-caches", false, "skip iOS Simulator caches")
 	rootCmd.Flags().BoolVar(&flagSkipSimulatorLogs, "skip-simulator-logs", false, "skip iOS Simulator logs")
 	rootCmd.Flags().BoolVar(&flagSkipXcodeDevSupport, "skip-xcode-device-support", false, "skip Xcode Device Support files")
 	rootCmd.Flags().BoolVar(&flagSkipXcodeArchives, "skip-xcode-archives", false, "skip Xcode Archives")
+	rootCmd.Flags().BoolVar(&flagSkipMobileDevice, "skip-mobiledevice", false, "skip Xcode Products and expired provisioning profiles")
+	rootCmd.Flags().BoolVar(&flagSkipDockerVM, "skip-docker-vm", false, "skip Docker Desktop VM disk image")
 	rootCmd.Flags().BoolVar(&flagSkipPnpm, "skip-pnpm", false, "skip pnpm store")
 	rootCmd.Flags().BoolVar(&flagSkipCocoapods, "skip-cocoapods", false, "skip CocoaPods cache")
 	rootCmd.Flags().BoolVar(&flagSkipGradle, "skip-gradle", false, "skip Gradle cache")
@@ -288,29 +445,78 @@ func init() {
 	rootCmd.Flags().BoolVar(&flagSkipAdobeMedia, "skip-adobe-media", false, "skip Adobe media caches")
 	rootCmd.Flags().BoolVar(&flagSkipSketch, "skip-sketch", false, "skip Sketch cache")
 	rootCmd.Flags().BoolVar(&flagSkipFigma, "skip-figma", false, "skip Figma cache")
+	rootCmd.Flags().BoolVar(&flagSkipAdobeLogs, "skip-adobe-logs", false, "skip Adobe installer logs")
+	rootCmd.Flags().BoolVar(&flagSkipFigmaProfile, "skip-figma-profile", false, "skip Figma desktop profile")
 	rootCmd.Flags().BoolVar(&flagSkipSlack, "skip-slack", false, "skip Slack cache")
 	rootCmd.Flags().BoolVar(&flagSkipDiscord, "skip-discord", false, "skip Discord cache")
 	rootCmd.Flags().BoolVar(&flagSkipTeams, "skip-teams", false, "skip Microsoft Teams cache")
 	rootCmd.Flags().BoolVar(&flagSkipZoom, "skip-zoom", false, "skip Zoom cache")
+	rootCmd.Flags().BoolVar(&flagSkipZoomRecordings, "skip-zoom-recordings", false, "skip Zoom local meeting recordings")
+	rootCmd.Flags().BoolVar(&flagSkipSlackDownloads, "skip-slack-downloads", false, "skip Slack downloaded files")
 	rootCmd.Flags().BoolVar(&flagSkipPhotosCaches, "skip-photos-caches", false, "skip Photos app caches")
 	rootCmd.Flags().BoolVar(&flagSkipPhotosAnalysis, "skip-photos-analysis", false, "skip Photos analysis caches")
 	rootCmd.Flags().BoolVar(&flagSkipPhotosIcloudCache, "skip-photos-icloud-cache", false, "skip iCloud Photos sync cache")
 	rootCmd.Flags().BoolVar(&flagSkipPhotosSyndication, "skip-photos-syndication", false, "skip Messages shared photos")
 	rootCmd.Flags().BoolVar(&flagSkipSpotlight, "skip-spotlight", false, "skip CoreSpotlight metadata")
 	rootCmd.Flags().BoolVar(&flagSkipMail, "skip-mail", false, "skip Mail database")
+	rootCmd.Flags().BoolVar(&flagSkipMailEnvelope, "skip-mail-envelope", false, "skip Mail Envelope Index")
 	rootCmd.Flags().BoolVar(&flagSkipMailDownloads, "skip-mail-downloads", false, "skip Mail attachment cache")
 	rootCmd.Flags().BoolVar(&flagSkipMessages, "skip-messages", false, "skip Messages attachments")
 	rootCmd.Flags().BoolVar(&flagSkipIOSUpdates, "skip-ios-updates", false, "skip iOS software updates")
 	rootCmd.Flags().BoolVar(&flagSkipTimemachine, "skip-timemachine", false, "skip Time Machine local snapshots")
+	rootCmd.Flags().BoolVar(&flagSkipUnifiedLogs, "skip-unified-logs", false, "skip unified logs")
 	rootCmd.Flags().BoolVar(&flagSkipVMParallels, "skip-vm-parallels", false, "skip Parallels VMs")
 	rootCmd.Flags().BoolVar(&flagSkipVMUTM, "skip-vm-utm", false, "skip UTM VMs")
 	rootCmd.Flags().BoolVar(&flagSkipVMVMware, "skip-vm-vmware", false, "skip VMware Fusion VMs")
 
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		if err := logging.Setup(os.Stderr, flagLogLevel, flagLogJSON); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --log-level: %v\n", err)
+			os.Exit(1)
+		}
+		l, err := resolveLocale(flagLocale)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --locale: %v\n", err)
+			os.Exit(1)
+		}
+		scan.SetLocale(l)
+		scan.SetOnDiskSize(flagOnDiskSize)
+		f, err := confirm.ParseFormat(flagConfirmFormat)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --confirm-format: %v\n", err)
+			os.Exit(1)
+		}
+		confirm.SetFormat(f)
+	}
+
 	rootCmd.PreRun = func(cmd *cobra.Command, args []string) {
 		// Initialize the engine.
 		eng = engine.New()
 		engine.RegisterDefaults(eng)
+		eng.AppDirs = flagAppDirs
+		eng.KeepLatestDeviceSupport = flagKeepLatestDS
+		eng.ProjectRoots = flagProjectRoots
+		eng.IncludeHidden = flagIncludeHidden
+		eng.CategoryOrder = categoryOrder()
+		eng.RootDeletable = flagSudo
+		eng.NoExec = flagNoExec
+		eng.Strict = flagStrict
+		eng.ScanTimeout = flagScanTimeout
+		scan.SetExpandBlobs(flagExpandBlobs)
+		eng.MaxDepth = flagMaxDepth
+		applyHome()
+		prepareHome(os.Stderr)
+		eng.Freshness = mustLoadFreshness()
+		applyIfBelow()
 
+		if err := applyEnvSelection(cmd, os.Getenv); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := compileCategoryPatterns(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		if flagAll {
 			flagSystemCaches = true
 			flagBrowserData = true
@@ -350,6 +556,7 @@ func init() {
 		if flagSkipSystemData {
 			flagSystemData = false
 		}
+		applyOptIns()
 		if flagJSON {
 			color.NoColor = true
 		}
@@ -364,6 +571,25 @@ func Execute() {
 	}
 }
 
+// runWalkthrough runs the interactive walkthrough. With --resume, the
+// choices saved by the previous --resume run are the defaults, and this
+// run's choices are saved for the next one.
+func runWalkthrough(in io.Reader, out io.Writer, results []scan.CategoryResult) []scan.CategoryResult {
+	if !flagResume {
+		return interactive.RunWalkthrough(in, out, results)
+	}
+	home := operatorHome()
+	defaults, err := interactive.LoadDecisions(home)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v; starting without saved choices\n", err)
+	}
+	marked, decisions := interactive.RunWalkthroughWithDefaults(in, out, results, defaults)
+	if err := interactive.SaveDecisions(home, decisions); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	return marked
+}
+
 // findScannerInfo looks up scanner metadata from the engine's registry.
 func findScannerInfo(scannerID string) engine.ScannerInfo {
 	for _, info := range eng.Categories() {
@@ -374,30 +600,144 @@ func findScannerInfo(scannerID string) engine.ScannerInfo {
 	return engine.ScannerInfo{ID: scannerID, Name: scannerID}
 }
 
-// runScannerByID runs a single scanner by ID using the engine and prints results.
-func runScannerByID(scannerID string, sp *spinner.Spinner) []scan.CategoryResult {
+// runScannerByID runs a single scanner by ID using the engine and prints
+// results. A scanner error is printed and yields no results, unless
+// --strict is set, in which case it is returned.
+func runScannerByID(scannerID string, sp *spinner.Spinner) ([]scan.CategoryResult, error) {
 	info := findScannerInfo(scannerID)
-	sp.UpdateMessage("Scanning " + strings.ToLower(info.Name) + "...")
-	sp.Start()
-	results, err := eng.Run(context.Background(), scannerID)
-	sp.Stop()
+	stop := startScanSpinner(sp, info.Name)
+	start := time.Now()
+	ctx, cancel := scanContext()
+	defer cancel()
+	results, err := eng.Run(ctx, scannerID)
+	elapsed := time.Since(start)
+	stop()
 	if err != nil {
+		if flagStrict {
+			return nil, err
+		}
 		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		return nil
+		return nil, nil
 	}
-	if !flagJSON {
+	results = applyEntryFilters(results)
+	if !flagJSON && !flagPreviewRisky {
 		printResults(results, flagDryRun, info.Name)
+		if flagVerbose {
+			printScanDuration(os.Stderr, info.Name, elapsed)
+		}
+	}
+	return results, nil
+}
+
+// scanStarted is when the first scanner of this run started, the start of
+// the --scan-timeout window.
+var scanStarted = sync.OnceValue(time.Now)
+
+// scanContext returns the context to run a scanner under: it expires
+// --scan-timeout after the first scanner started, so scanners run one by
+// one share a single deadline, and never when the flag is unset.
+func scanContext() (context.Context, context.CancelFunc) {
+	if flagScanTimeout <= 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithDeadline(context.Background(), scanStarted().Add(flagScanTimeout))
+}
+
+// exitScanFailed is the exit status when --strict stops a scan at a
+// failing scanner.
+const exitScanFailed = 4
+
+// osExit ends the process for exitStrict. Tests replace it.
+var osExit = os.Exit
+
+// exitStrict reports the scanner error that stopped a --strict scan and
+// exits with exitScanFailed.
+func exitStrict(err error) {
+	fmt.Fprintf(os.Stderr, "Error: %v (--strict)\n", err)
+	osExit(exitScanFailed)
+}
+
+// newSpinner creates the progress spinner on stderr. It is disabled with
+// --json, prints plain status lines with --no-spinner or when stdout or
+// stderr is not a terminal (CI logs, pipes), and animates otherwise.
+func newSpinner(message string) *spinner.Spinner {
+	if flagJSON {
+		return spinner.New(message, false)
+	}
+	if flagNoSpinner || !spinner.IsTerminal(os.Stdout) || !spinner.IsTerminal(os.Stderr) {
+		return spinner.NewPlain(os.Stderr, message)
+	}
+	return spinner.New(message, true)
+}
+
+// startScanSpinner starts sp with a "Scanning <label>..." message. While it
+// runs, directory walks longer than a second add the bytes sized so far,
+// and an ETA when the directory's earlier size is known, from this run or
+// a previous one. The returned func stops the spinner and the progress
+// reporting and saves the sizes measured for the next run.
+func startScanSpinner(sp *spinner.Spinner, label string) func() {
+	loadSizeEstimates()
+	prefix := "Scanning " + strings.ToLower(label) + "..."
+	sp.UpdateMessage(prefix)
+	sp.Start()
+	scan.SetSizeProgress(func(p scan.SizeProgress) {
+		sp.UpdateMessage(sizeProgressMessage(prefix, p))
+	})
+	return func() {
+		scan.SetSizeProgress(nil)
+		sp.Stop()
+		if path, err := sizeEstimatesPath(); err == nil {
+			if err := scan.SaveSizeEstimates(path); err != nil {
+				logging.Debug("could not save size estimates", "err", err)
+			}
+		}
+	}
+}
+
+// sizeEstimatesPath is where the sizes behind the progress ETA are kept
+// between runs, in the user's cache directory.
+func sizeEstimatesPath() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "mac-cleaner", "size-estimates.json"), nil
+}
+
+// loadSizeEstimates loads the sizes saved by earlier runs, once.
+var loadSizeEstimates = sync.OnceFunc(func() {
+	path, err := sizeEstimatesPath()
+	if err != nil {
+		return
+	}
+	if err := scan.LoadSizeEstimates(path); err != nil {
+		logging.Debug("could not load size estimates", "err", err)
 	}
-	return results
+})
+
+// sizeProgressMessage formats spinner text for a long directory walk, e.g.
+// "Scanning mail... 3.2 GB, ~5s remaining". The ETA is omitted when the
+// total size is unknown.
+func sizeProgressMessage(prefix string, p scan.SizeProgress) string {
+	msg := prefix + " " + scan.FormatSize(p.Bytes)
+	if eta, ok := scan.ETA(p.Elapsed, p.Bytes, p.Estimate); ok {
+		secs := int(eta.Round(time.Second) / time.Second)
+		if secs < 1 {
+			secs = 1
+		}
+		msg += fmt.Sprintf(", ~%ds remaining", secs)
+	}
+	return msg
 }
 
 // buildSkipSet collects category IDs that should be excluded from results
-// based on item-level skip flags. Uses scanGroups as the source of truth.
+// based on item-level skip flags and the --category-include and
+// --category-exclude patterns. Uses scanGroups as the source of truth.
 func buildSkipSet() map[string]bool {
 	skip := map[string]bool{}
 	for _, g := range scanGroups {
 		for _, item := range g.Items {
-			if item.SkipFlag != nil && *item.SkipFlag {
+			if (item.SkipFlag != nil && *item.SkipFlag) || patternExcluded(item.CategoryID) {
 				skip[item.CategoryID] = true
 			}
 		}
@@ -407,27 +747,453 @@ func buildSkipSet() map[string]bool {
 
 // scanAll runs all registered scanners via the engine's channel-based API
 // and returns aggregated results. Scanner errors are logged to stderr; partial
-// results are still returned. Results are printed with dryRun=true since
+// results are still returned, unless --strict stopped the scan, in which case
+// the error is returned instead. Results are printed with dryRun=true since
 // interactive mode handles deletion decisions separately.
-func scanAll(sp *spinner.Spinner) []scan.CategoryResult {
+func scanAll(sp *spinner.Spinner) ([]scan.CategoryResult, error) {
+	stop := func() {}
 	events, done := eng.ScanAll(context.Background(), nil)
 	for event := range events {
 		switch event.Type {
 		case engine.EventScannerStart:
-			sp.UpdateMessage("Scanning " + strings.ToLower(event.Label) + "...")
-			sp.Start()
+			stop = startScanSpinner(sp, event.Label)
 		case engine.EventScannerDone:
-			sp.Stop()
-			if len(event.Results) > 0 {
+			stop()
+			event.Results = applyEntryFilters(event.Results)
+			if len(event.Results) > 0 && !flagPreviewRisky {
 				printResults(event.Results, true, event.Label)
 			}
+			if flagVerbose {
+				printScanDuration(os.Stderr, event.Label, event.Duration)
+			}
 		case engine.EventScannerError:
-			sp.Stop()
-			fmt.Fprintf(os.Stderr, "Warning: %v\n", event.Err)
+			stop()
+			if !flagStrict {
+				fmt.Fprintf(os.Stderr, "Warning: %v\n", event.Err)
+			}
 		}
 	}
 	result := <-done
-	return result.Results
+	if result.Err != nil {
+		return nil, result.Err
+	}
+	return applyEntryFilters(result.Results), nil
+}
+
+// applyEntryFilters applies the --skip-network-paths, --exclude-newer-than
+// and --keep-recent guards to scan results before they are displayed or
+// offered for deletion.
+func applyEntryFilters(results []scan.CategoryResult) []scan.CategoryResult {
+	results = excludeNetworkPaths(results, networkRoots)
+	results = applyExcludeNewerThan(results, flagExcludeNewer, time.Now())
+	return applyKeepRecent(results, flagKeepRecent)
+}
+
+// homeInfo reports where the home directory lives, without running dscl
+// under --no-exec. Tests replace it.
+var homeInfo safety.HomeInfoProvider = func() (safety.HomeInfo, error) {
+	if flagNoExec {
+		return safety.DetectHomeNoExec()
+	}
+	return safety.DetectHome()
+}
+
+// networkRoots holds the network-backed path prefixes excluded by
+// --skip-network-paths, set by prepareHome.
+var networkRoots []string
+
+// prepareHome warns on w when the home directory is network-backed or
+// belongs to a mobile account. With --skip-network-paths, network-backed
+// paths are then neither sized nor offered for deletion.
+func prepareHome(w io.Writer) {
+	networkRoots = nil
+	info, err := homeInfo()
+	if err != nil {
+		return
+	}
+	yellow := color.New(color.FgYellow)
+	if info.Network {
+		if flagSkipNetwork {
+			_, _ = yellow.Fprintf(w, "Warning: home directory %s is on a network volume; skipping it (--skip-network-paths).\n", info.Path)
+			networkRoots = info.NetworkRoots
+		} else {
+			_, _ = yellow.Fprintf(w, "Warning: home directory %s is on a network volume; sizing may be slow and deletions change the server copy. Use --skip-network-paths to leave it alone.\n", info.Path)
+		}
+	}
+	if info.Mobile {
+		_, _ = yellow.Fprintf(w, "Warning: %s belongs to a mobile account; deletions sync back to the network home.\n", info.Path)
+	}
+	scan.SetSkippedRoots(networkRoots)
+}
+
+// resolveLocale returns the locale for a --locale value: English when it
+// is empty, the one named by the environment for "auto", and the named
+// locale otherwise.
+func resolveLocale(tag string) (scan.Locale, error) {
+	switch tag {
+	case "":
+		return scan.DefaultLocale, nil
+	case "auto":
+		return scan.DetectLocale(), nil
+	}
+	return scan.LookupLocale(tag)
+}
+
+// applyHome points the scanners and the deletion containment at --home,
+// exiting when it is not a valid home directory. Without --home both use
+// the current user's home.
+func applyHome() {
+	home, err := resolveHome(flagHome)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: --home: %v\n", err)
+		os.Exit(1)
+	}
+	eng.Home = home
+	safety.SetHome(home)
+}
+
+// applyOptIns passes the opt-in category selections to the engine. It
+// runs after --categories-file and MAC_CLEANER_SCAN, which can select
+// them too.
+func applyOptIns() {
+	eng.ScanTmpCaches = flagScanTmpCaches
+	eng.ScanNodeModules = flagScanNodeModules
+	eng.ScanPyEnvs = flagScanPyEnvs
+}
+
+// resolveHome validates a --home value and returns it as a clean absolute
+// path. An empty value is returned as is. The path must be an existing
+// directory below the top level, since a home directory is never /, /Users
+// or another top-level directory.
+func resolveHome(path string) (string, error) {
+	if path == "" {
+		return "", nil
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("%s is not a directory", abs)
+	}
+	if filepath.Dir(abs) == abs || filepath.Dir(abs) == "/" {
+		return "", fmt.Errorf("%s is not a home directory", abs)
+	}
+	return abs, nil
+}
+
+// excludeNetworkPaths drops every entry under one of roots, so nothing
+// network-backed is offered for deletion. Categories left empty are
+// removed. The input slice is not modified.
+func excludeNetworkPaths(results []scan.CategoryResult, roots []string) []scan.CategoryResult {
+	if len(roots) == 0 {
+		return results
+	}
+	out := make([]scan.CategoryResult, 0, len(results))
+	for _, cr := range results {
+		kept := cr
+		kept.Entries = nil
+		kept.TotalSize = 0
+		for _, e := range cr.Entries {
+			if underAnyRoot(e.Path, roots) {
+				continue
+			}
+			kept.Entries = append(kept.Entries, e)
+			kept.TotalSize += e.Size
+		}
+		if len(kept.Entries) == 0 && len(kept.RecentlyModified) == 0 && len(kept.PermissionIssues) == 0 {
+			continue
+		}
+		out = append(out, kept)
+	}
+	return out
+}
+
+// underAnyRoot reports whether path is equal to or inside one of roots.
+func underAnyRoot(path string, roots []string) bool {
+	path = filepath.Clean(path)
+	for _, r := range roots {
+		r = filepath.Clean(r)
+		if path == r || strings.HasPrefix(path, r+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// applyExcludeNewerThan withholds every entry containing anything modified
+// within age of now, moving it to the category's RecentlyModified list so
+// it is reported but never deleted. Categories keep their place even if all
+// entries were withheld. The input slice is not modified.
+func applyExcludeNewerThan(results []scan.CategoryResult, age time.Duration, now time.Time) []scan.CategoryResult {
+	if age <= 0 {
+		return results
+	}
+	cutoff := now.Add(-age)
+	out := make([]scan.CategoryResult, 0, len(results))
+	for _, cat := range results {
+		cat.RecentlyModified = append([]scan.ScanEntry(nil), cat.RecentlyModified...)
+		cat.ExcludeNewerThan(cutoff)
+		out = append(out, cat)
+	}
+	return out
+}
+
+// freshnessWindows loads the per-category freshness windows from
+// engine.FreshnessPath(home). Every category ID must name a category in
+// scanGroups.
+func freshnessWindows(home string) (map[string]time.Duration, error) {
+	windows, err := engine.LoadFreshness(home)
+	if err != nil {
+		return nil, err
+	}
+	var errs []error
+	for id := range windows {
+		if groupForCategory(id) == nil {
+			errs = append(errs, fmt.Errorf("%s: unknown category %q", engine.FreshnessPath(home), id))
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return windows, nil
+}
+
+// mustLoadFreshness returns the freshness windows of the home being
+// scanned, --home once applyHome has run, exiting when the file is invalid.
+func mustLoadFreshness() map[string]time.Duration {
+	home, err := safety.Home()
+	if err != nil {
+		return nil
+	}
+	windows, err := freshnessWindows(home)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return windows
+}
+
+// applyKeepRecent removes the n newest entries from every time-based
+// category (see categoryDef.TimeBased) so they are never offered for
+// deletion. Categories left with no entries are dropped. The input slice
+// is not modified.
+func applyKeepRecent(results []scan.CategoryResult, n int) []scan.CategoryResult {
+	if n <= 0 {
+		return results
+	}
+	out := make([]scan.CategoryResult, 0, len(results))
+	for _, cat := range results {
+		if isTimeBased(cat.Category) {
+			cat.KeepRecent(n)
+			if len(cat.Entries) == 0 && len(cat.PermissionIssues) == 0 {
+				continue
+			}
+		}
+		out = append(out, cat)
+	}
+	return out
+}
+
+// withholdReportOnly returns results without the ReportOnly categories
+// whose targeted scan flag was not given, writing a note to w for each
+// category withheld. Such categories stay in the listing but are only
+// deleted when selected explicitly, e.g. with scan --orphaned-group-containers
+// or --categories-file.
+func withholdReportOnly(w io.Writer, results []scan.CategoryResult) []scan.CategoryResult {
+	out := make([]scan.CategoryResult, 0, len(results))
+	for _, cat := range results {
+		if !cat.ReportOnly {
+			out = append(out, cat)
+			continue
+		}
+		item := categoryItem(cat.Category)
+		if item == nil || item.ScanFlag == nil {
+			fmt.Fprintf(w, "Not deleting %s: report-only.\n", cat.Description)
+			continue
+		}
+		if !*item.ScanFlag {
+			fmt.Fprintf(w, "Not deleting %s: report-only; target it with --%s to delete.\n", cat.Description, item.FlagName)
+			continue
+		}
+		out = append(out, cat)
+	}
+	return out
+}
+
+// runCleanup prompts for confirmation (unless --force) and removes the given
+// results, printing a summary to w. Safe directories that look like they
+// hold documents need their own confirmation first and are withheld under
+// --force. In --report-only mode it refuses before any prompt or deletion
+// and returns false. It also returns false when the user aborts at the
+// prompt or nothing is left to delete.
+func runCleanup(in io.Reader, w io.Writer, sp *spinner.Spinner, results []scan.CategoryResult) bool {
+	out, ok := executeCleanup(in, w, sp, results)
+	if !ok {
+		return false
+	}
+	if flagJSON {
+		printCleanupJSON(w, out.result, out.skipped, out.check)
+		return true
+	}
+	printCleanupSummary(w, out.result)
+	if out.check != nil {
+		printFreeSpaceCheck(w, *out.check)
+	}
+	return true
+}
+
+// cleanupOutcome is what a cleanup that ran reports: the deletion result,
+// the entries withheld as recently modified, and the --verify free-space
+// check, which is nil when not measured.
+type cleanupOutcome struct {
+	result  cleanup.CleanupResult
+	skipped int
+	check   *freeSpaceCheck
+}
+
+// executeCleanup is runCleanup without the final report: it passes the
+// gates, prompts on w and deletes. It returns false when nothing was
+// deleted.
+func executeCleanup(in io.Reader, w io.Writer, sp *spinner.Spinner, results []scan.CategoryResult) (cleanupOutcome, bool) {
+	if flagReportOnly {
+		printReportOnlyNotice(os.Stderr)
+		return cleanupOutcome{}, false
+	}
+	// Share one buffered reader between the acknowledgement and
+	// confirmation prompts so neither swallows the other's input.
+	reader := bufio.NewReader(in)
+	state := operatorHome()
+	if !ensureAcknowledged(reader, w, state) {
+		return cleanupOutcome{}, false
+	}
+	if !ensureCooldown(state) {
+		return cleanupOutcome{}, false
+	}
+	home, _ := safety.Home()
+	var allowDocs []string
+	if flagForce {
+		results = withholdDocuments(os.Stderr, results)
+	} else {
+		results, allowDocs = confirmDocuments(reader, w, results)
+	}
+	if len(results) == 0 {
+		fmt.Fprintln(w, "Nothing left to delete.")
+		return cleanupOutcome{}, false
+	}
+	if !flagForce {
+		if !confirm.PromptConfirmation(reader, w, results) {
+			fmt.Fprintln(w, "Aborted.")
+			return cleanupOutcome{}, false
+		}
+	}
+	var before int64
+	verify := flagVerify
+	if verify {
+		b, err := scan.AvailableBytes(home)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: --verify: cannot read free space: %v\n", err)
+			verify = false
+		}
+		before = b
+	}
+	opts := cleanup.Options{EntryTimeout: flagRemovalTO, ExtraRoots: flagAppDirs, AllowDocuments: allowDocs}
+	if flagSudo && cleanup.NeedsRoot(results) {
+		if err := cleanup.ValidateSudo(sudoRunner); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v; root-owned items will be removed without sudo\n", err)
+		} else {
+			opts.Sudo = sudoRunner
+		}
+	}
+	sp.UpdateMessage("Cleaning up...")
+	sp.Start()
+	result := cleanup.ExecuteWithOptions(results, cleanupProgress(sp, os.Stderr), opts)
+	sp.Stop()
+	if err := confirm.RecordCleanup(state, clock()); err != nil {
+		logging.Warn("could not record cleanup time", "err", err)
+	}
+	var check *freeSpaceCheck
+	if verify {
+		if after, err := scan.AvailableBytes(home); err == nil {
+			c := checkFreeSpace(result.BytesFreed, before, after)
+			check = &c
+		} else {
+			fmt.Fprintf(os.Stderr, "Warning: --verify: cannot read free space: %v\n", err)
+		}
+	}
+	return cleanupOutcome{result: result, skipped: countWithheld(results), check: check}, true
+}
+
+// ensureAcknowledged enforces the one-time first-run acknowledgement
+// before any deletion. Once ~/.config/mac-cleaner/ack exists it always
+// passes. Otherwise --accept-risk records the acknowledgement and proceeds,
+// --force alone is refused, and interactive users are prompted.
+func ensureAcknowledged(in io.Reader, w io.Writer, home string) bool {
+	if confirm.HasAcknowledged(home) {
+		return true
+	}
+	if !flagAcceptRisk {
+		if flagForce {
+			fmt.Fprintln(os.Stderr, "First run: --force cannot skip the one-time acknowledgement that deletions are permanent.")
+			fmt.Fprintln(os.Stderr, "Run once without --force, or add --accept-risk for headless use.")
+			return false
+		}
+		if !confirm.PromptAcknowledgement(in, w) {
+			fmt.Fprintln(w, "Aborted.")
+			return false
+		}
+	}
+	if err := confirm.RecordAcknowledgement(home); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not save acknowledgement: %v\n", err)
+	}
+	return true
+}
+
+// operatorHome returns the home directory of the user running
+// mac-cleaner, which holds its own state: the first-run acknowledgement,
+// the last-cleanup time and the --resume choices. Unlike safety.Home it
+// ignores --home, so auditing another account neither reads that state
+// from the audited home, where it could bypass the gates, nor writes it
+// there.
+func operatorHome() string {
+	home, _ := os.UserHomeDir()
+	return home
+}
+
+// sudoRunner runs sudo for --sudo deletions. Tests replace it.
+var sudoRunner cleanup.CmdRunner = cleanup.SudoRunner
+
+// clock returns the current time. Tests replace it.
+var clock = time.Now
+
+// ensureCooldown refuses a cleanup that starts within
+// confirm.CleanupCooldown of the previous one, unless --force is set.
+func ensureCooldown(home string) bool {
+	last, recent := confirm.RecentCleanup(home, confirm.CleanupCooldown, clock())
+	if !recent || flagForce {
+		return true
+	}
+	ago := clock().Sub(last).Round(time.Second)
+	fmt.Fprintf(os.Stderr, "A cleanup ran %s ago; refusing another within %s so recreated caches are not deleted while in use.\n", ago, confirm.CleanupCooldown)
+	fmt.Fprintln(os.Stderr, "Wait and run again, or add --force.")
+	return false
+}
+
+// printReportOnlyNotice tells the user that cleanup was skipped because
+// --report-only is in effect.
+func printReportOnlyNotice(w io.Writer) {
+	fmt.Fprintln(w, "Report-only mode: cleanup is disabled.")
+}
+
+// printScanDuration prints how long a scanner group took, e.g.
+// "Developer Caches scanned in 4.2s." It is used in verbose mode to help
+// identify slow scanners.
+func printScanDuration(w io.Writer, label string, d time.Duration) {
+	fmt.Fprintf(w, "%s scanned in %.1fs.\n", label, d.Seconds())
 }
 
 // printCleanupSummary displays the results of a cleanup operation.
@@ -436,6 +1202,11 @@ func printCleanupSummary(w io.Writer, result cleanup.CleanupResult) {
 	fmt.Fprintln(w)
 	_, _ = greenBold.Fprintf(w, "Cleanup complete: %d items removed, %s freed\n",
 		result.Removed, scan.FormatSize(result.BytesFreed))
+	if result.SudoRemoved > 0 {
+		fmt.Fprintf(w, "  %d of them (%s) removed with sudo, %d (%s) without\n",
+			result.SudoRemoved, scan.FormatSize(result.SudoBytesFreed),
+			result.Removed-result.SudoRemoved, scan.FormatSize(result.BytesFreed-result.SudoBytesFreed))
+	}
 	if result.Failed > 0 {
 		yellow := color.New(color.FgYellow)
 		fmt.Fprintln(w)
@@ -447,6 +1218,135 @@ func printCleanupSummary(w io.Writer, result cleanup.CleanupResult) {
 	fmt.Fprintln(w)
 }
 
+// cleanupJSON is the machine-readable cleanup outcome printed in --json
+// mode. Skipped counts entries withheld from deletion by
+// --exclude-newer-than.
+type cleanupJSON struct {
+	Removed    int             `json:"removed"`
+	Failed     int             `json:"failed"`
+	Skipped    int             `json:"skipped"`
+	BytesFreed int64           `json:"bytes_freed"`
+	Errors     []string        `json:"errors"`
+	Verify     *freeSpaceCheck `json:"verify,omitempty"`
+	// SudoRemoved and SudoBytesFreed are the part of Removed and
+	// BytesFreed deleted with --sudo.
+	SudoRemoved    int   `json:"sudo_removed,omitempty"`
+	SudoBytesFreed int64 `json:"sudo_bytes_freed,omitempty"`
+	// PerCategory maps category IDs to the bytes freed from each.
+	PerCategory map[string]int64 `json:"per_category,omitempty"`
+}
+
+// printCleanupJSON writes the cleanup outcome to w as a single JSON object.
+// check is included when --verify measured free space, and may be nil.
+func printCleanupJSON(w io.Writer, result cleanup.CleanupResult, skipped int, check *freeSpaceCheck) {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(newCleanupJSON(result, skipped, check)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+	}
+}
+
+// newCleanupJSON builds the --json cleanup object.
+func newCleanupJSON(result cleanup.CleanupResult, skipped int, check *freeSpaceCheck) cleanupJSON {
+	out := cleanupJSON{
+		Removed:    result.Removed,
+		Failed:     result.Failed,
+		Skipped:    skipped,
+		BytesFreed: result.BytesFreed,
+		Errors:     make([]string, 0, len(result.Errors)),
+		Verify:     check,
+
+		SudoRemoved:    result.SudoRemoved,
+		SudoBytesFreed: result.SudoBytesFreed,
+		PerCategory:    result.PerCategory,
+	}
+	for _, err := range result.Errors {
+		out.Errors = append(out.Errors, err.Error())
+	}
+	return out
+}
+
+// cleanupReport is the --json document of a scan that goes on to delete:
+// the scan results and the cleanup outcome in one object.
+type cleanupReport struct {
+	Results scan.ScanSummary `json:"results"`
+	// Cleanup is omitted when nothing was deleted: the user aborted, a
+	// gate refused, or every category was withheld.
+	Cleanup *cleanupJSON `json:"cleanup,omitempty"`
+}
+
+// runJSONCleanup is the --json deletion flow without --dry-run. It writes
+// results and the outcome of cleaning them to out as a single JSON
+// document; prompts and notices go to stderr so stdout stays parseable.
+func runJSONCleanup(in io.Reader, out io.Writer, sp *spinner.Spinner, results []scan.CategoryResult) {
+	report := cleanupReport{Results: scanSummary(results)}
+	if toClean := withholdReportOnly(os.Stderr, results); len(toClean) > 0 {
+		if o, ok := executeCleanup(in, os.Stderr, sp, toClean); ok {
+			c := newCleanupJSON(o.result, o.skipped, o.check)
+			report.Cleanup = &c
+		}
+	}
+	enc := json.NewEncoder(out)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// freeSpaceCheck compares the bytes a cleanup reported as freed with the
+// change in free disk space measured around it (--verify).
+type freeSpaceCheck struct {
+	Reported  int64 `json:"reported_bytes"`
+	DiskFreed int64 `json:"disk_freed_bytes"`
+	// Discrepancy is true when the disk freed much less than reported.
+	Discrepancy bool `json:"discrepancy"`
+}
+
+// verifyMinGap is the smallest shortfall between reported and measured
+// bytes freed that is flagged, so small cleanups and background disk
+// activity do not raise false alarms.
+const verifyMinGap = 100 * 1000 * 1000
+
+// checkFreeSpace builds a freeSpaceCheck from the reported bytes freed and
+// the free space sampled before and after cleanup. A drop in free space
+// counts as zero freed. A discrepancy is flagged when the disk freed less
+// than half of the reported total and the gap is at least verifyMinGap.
+func checkFreeSpace(reported, before, after int64) freeSpaceCheck {
+	freed := after - before
+	if freed < 0 {
+		freed = 0
+	}
+	gap := reported - freed
+	return freeSpaceCheck{
+		Reported:    reported,
+		DiskFreed:   freed,
+		Discrepancy: gap >= verifyMinGap && freed < reported/2,
+	}
+}
+
+// printFreeSpaceCheck prints the measured change in free space and, for a
+// discrepancy, the likely causes.
+func printFreeSpaceCheck(w io.Writer, c freeSpaceCheck) {
+	fmt.Fprintf(w, "Disk free space increased by %s (reported %s).\n",
+		scan.FormatSize(c.DiskFreed), scan.FormatSize(c.Reported))
+	if c.Discrepancy {
+		yellow := color.New(color.FgYellow)
+		_, _ = yellow.Fprintf(w, "Reported %s, disk freed %s — likely sparse or cloned data, files still held open, or local snapshots.\n",
+			scan.FormatSize(c.Reported), scan.FormatSize(c.DiskFreed))
+	}
+}
+
+// countWithheld returns how many entries across results were withheld
+// from deletion as recently modified.
+func countWithheld(results []scan.CategoryResult) int {
+	n := 0
+	for _, cat := range results {
+		n += len(cat.RecentlyModified)
+	}
+	return n
+}
+
 // cleanupProgress returns a ProgressFunc that drives the spinner (normal mode)
 // or prints per-entry detail (verbose mode). It returns nil for JSON mode.
 func cleanupProgress(sp *spinner.Spinner, w io.Writer) cleanup.ProgressFunc {
@@ -458,8 +1358,8 @@ func cleanupProgress(sp *spinner.Spinner, w io.Writer) cleanup.ProgressFunc {
 			if entryPath == "" {
 				fmt.Fprintf(w, "Cleaning %s (%d/%d)\n", categoryDesc, current, total)
 			} else {
-				home, _ := os.UserHomeDir()
-				fmt.Fprintf(w, "  removing %s\n", shortenHome(entryPath, home))
+				home, _ := safety.Home()
+				fmt.Fprintf(w, "  removing %s\n", displayPath(entryPath, home))
 			}
 		}
 	}
@@ -480,9 +1380,65 @@ func flagForCategory(categoryID string) string {
 	return ""
 }
 
+// sizeValue is a pflag.Value holding a byte count parsed by scan.ParseSize.
+type sizeValue int64
+
+func (v *sizeValue) String() string {
+	if *v == 0 {
+		return "0"
+	}
+	return scan.FormatSize(int64(*v))
+}
+
+func (v *sizeValue) Set(s string) error {
+	n, err := scan.ParseSize(s)
+	if err != nil {
+		return err
+	}
+	*v = sizeValue(n)
+	return nil
+}
+
+func (v *sizeValue) Type() string { return "size" }
+
+// summaryRow is one line of the dry-run summary table.
+type summaryRow struct {
+	label string
+	size  int64
+	hint  string
+}
+
+// summaryRows turns size-sorted categories into summary rows. With
+// threshold > 0, two or more categories smaller than threshold are merged
+// into a final "Other (N categories)" row carrying their combined size.
+func summaryRows(sorted []scan.CategoryResult, threshold int64) []summaryRow {
+	var rows []summaryRow
+	var small []scan.CategoryResult
+	for _, cat := range sorted {
+		if threshold > 0 && cat.TotalSize < threshold {
+			small = append(small, cat)
+			continue
+		}
+		rows = append(rows, summaryRow{label: cat.Description, size: cat.TotalSize, hint: flagForCategory(cat.Category)})
+	}
+	if len(small) == 1 {
+		cat := small[0]
+		return append(rows, summaryRow{label: cat.Description, size: cat.TotalSize, hint: flagForCategory(cat.Category)})
+	}
+	if len(small) > 1 {
+		other := summaryRow{label: fmt.Sprintf("Other (%d categories)", len(small))}
+		for _, cat := range small {
+			other.size += cat.TotalSize
+		}
+		rows = append(rows, other)
+	}
+	return rows
+}
+
 // printDryRunSummary prints a compact size-sorted summary table when at least
 // two categories have data. It is intended for dry-run output so the user can
-// quickly see where disk space is reclaimable.
+// quickly see where disk space is reclaimable. With --coalesce-under, small
+// categories share one "Other" row; JSON output always keeps full detail.
 func printDryRunSummary(w io.Writer, results []scan.CategoryResult) {
 	var nonEmpty []scan.CategoryResult
 	for _, cat := range results {
@@ -513,15 +1469,15 @@ func printDryRunSummary(w io.Writer, results []scan.CategoryResult) {
 	fmt.Fprintln(w)
 
 	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
-	for _, cat := range nonEmpty {
-		pct := float64(cat.TotalSize) / float64(total) * 100
+	for _, row := range summaryRows(nonEmpty, int64(flagCoalesceUnder)) {
+		pct := float64(row.size) / float64(total) * 100
 		hint := ""
-		if flag := flagForCategory(cat.Category); flag != "" {
-			hint = faint.Sprintf("(%s)", flag)
+		if row.hint != "" {
+			hint = faint.Sprintf("(%s)", row.hint)
 		}
 		fmt.Fprintf(tw, "  %s\t  %s\t  (%4.1f%%)\t  %s\t\n",
-			cat.Description,
-			cyan.Sprint(scan.FormatSize(cat.TotalSize)),
+			row.label,
+			cyan.Sprint(scan.FormatSize(row.size)),
 			pct,
 			hint)
 	}
@@ -529,11 +1485,41 @@ func printDryRunSummary(w io.Writer, results []scan.CategoryResult) {
 
 	fmt.Fprintln(w)
 	_, _ = greenBold.Fprintf(w, "  Total: %s reclaimable\n", scan.FormatSize(total))
+	printRiskTotals(w, scan.TotalsByRisk(nonEmpty))
+	printSizeCaveat(w, total)
 	fmt.Fprintln(w)
 }
 
-// printJSON outputs scan results as formatted JSON to stdout.
+// printRiskTotals prints the reclaimable bytes per risk level, e.g.
+// "By risk: Safe 8.0 GB, Moderate 3.0 GB, Risky 40.0 GB".
+func printRiskTotals(w io.Writer, t scan.RiskTotals) {
+	fmt.Fprintf(w, "  By risk: %s %s, %s %s, %s %s\n",
+		color.New(color.FgGreen).Sprint("Safe"), scan.FormatSize(t.Safe),
+		color.New(color.FgYellow).Sprint("Moderate"), scan.FormatSize(t.Moderate),
+		color.New(color.FgRed).Sprint("Risky"), scan.FormatSize(t.Risky))
+}
+
+// printJSON outputs scan results as formatted JSON to stdout. Every entry
+// carries an explicit risk_level, filled from its category when unset.
 func printJSON(results []scan.CategoryResult) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(scanSummary(results)); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// scanSummary builds the --json document for results, filling each
+// entry's unset risk_level from its category.
+func scanSummary(results []scan.CategoryResult) scan.ScanSummary {
+	for i := range results {
+		for j := range results[i].Entries {
+			if results[i].Entries[j].RiskLevel == "" {
+				results[i].Entries[j].RiskLevel = safety.RiskForCategory(results[i].Category)
+			}
+		}
+	}
 	var totalSize int64
 	for _, cat := range results {
 		totalSize += cat.TotalSize
@@ -542,16 +1528,14 @@ func printJSON(results []scan.CategoryResult) {
 	for _, cat := range results {
 		permIssues = append(permIssues, cat.PermissionIssues...)
 	}
-	summary := scan.ScanSummary{
-		Categories:       results,
-		TotalSize:        totalSize,
-		PermissionIssues: permIssues,
-	}
-	enc := json.NewEncoder(os.Stdout)
-	enc.SetIndent("", "  ")
-	if err := enc.Encode(summary); err != nil {
-		fmt.Fprintf(os.Stderr, "Error encoding JSON: %v\n", err)
-		os.Exit(1)
+	return scan.ScanSummary{
+		GeneratedAt:            time.Now().Truncate(time.Second),
+		ToolVersion:            version,
+		Categories:             results,
+		TotalSize:              totalSize,
+		PermissionIssues:       scan.DedupePermissionIssues(permIssues),
+		RiskTotals:             scan.TotalsByRisk(results),
+		SizeEstimateUnreliable: sizeEstimateUnreliable(totalSize),
 	}
 }
 
@@ -562,13 +1546,14 @@ func printResults(results []scan.CategoryResult, dryRun bool, title string) {
 		return
 	}
 
-	home, _ := os.UserHomeDir()
+	home, _ := safety.Home()
 
 	bold := color.New(color.Bold)
 	cyan := color.New(color.FgCyan)
 	greenBold := color.New(color.FgGreen, color.Bold)
 	red := color.New(color.FgRed)
 	yellow := color.New(color.FgYellow)
+	faint := color.New(color.Faint)
 
 	// Header
 	header := title
@@ -579,9 +1564,22 @@ func printResults(results []scan.CategoryResult, dryRun bool, title string) {
 	_, _ = bold.Println(header)
 
 	var grandTotal int64
+	totalTruncated := false
+
+	compact := useCompactLayout()
+	if compact {
+		fmt.Println()
+	}
 
 	for _, cat := range results {
-		if len(cat.Entries) == 0 {
+		if len(cat.Entries) == 0 && len(cat.RecentlyModified) == 0 {
+			continue
+		}
+
+		totalTruncated = totalTruncated || cat.Truncated
+		if compact {
+			printCompactCategory(cat, home)
+			grandTotal += cat.TotalSize
 			continue
 		}
 
@@ -590,7 +1588,7 @@ func printResults(results []scan.CategoryResult, dryRun bool, title string) {
 		// Category header with base directory path.
 		catHeader := "  " + cat.Description
 		if len(cat.Entries) > 0 {
-			baseDir := shortenHome(baseDirectory(cat.Entries[0].Path), home)
+			baseDir := displayPath(baseDirectory(cat.Entries[0].Path), home)
 			catHeader += "    " + baseDir
 		}
 		_, _ = bold.Println(catHeader)
@@ -599,6 +1597,9 @@ func printResults(results []scan.CategoryResult, dryRun bool, title string) {
 		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
 		for _, entry := range cat.Entries {
 			sizeStr := scan.FormatSize(entry.Size)
+			if entry.Truncated {
+				sizeStr = "≥ " + sizeStr
+			}
 			riskTag := ""
 			switch entry.RiskLevel {
 			case safety.RiskRisky:
@@ -606,41 +1607,143 @@ func printResults(results []scan.CategoryResult, dryRun bool, title string) {
 			case safety.RiskModerate:
 				riskTag = yellow.Sprint("  [moderate]")
 			}
-			fmt.Fprintf(w, "    %s%s\t  %s\t\n", entry.Description, riskTag, cyan.Sprint(sizeStr))
+			fmt.Fprintf(w, "    %s%s\t  %s\t\n", scan.ValidUTF8(entry.Description), riskTag, cyan.Sprint(sizeStr))
 			if flagVerbose {
-				path := shortenHome(entry.Path, home)
+				path := displayPath(entry.Path, home)
 				fmt.Fprintf(w, "      %s\t\t\n", path)
 			}
 		}
 		_ = w.Flush()
+		if cat.Truncated {
+			fmt.Printf("    %s\n", faint.Sprintf("(sizes marked ≥ stop at --max-depth %d and are lower bounds)", flagMaxDepth))
+		}
+
+		if n := len(cat.RecentlyModified); n > 0 {
+			fmt.Printf("    %s\n", faint.Sprintf("(%d recently modified item(s) kept)", n))
+			if flagVerbose {
+				for _, entry := range cat.RecentlyModified {
+					fmt.Printf("      %s\n", faint.Sprint(displayPath(entry.Path, home)))
+				}
+			}
+		}
 
 		grandTotal += cat.TotalSize
 	}
 
 	// Summary line.
 	fmt.Println()
-	_, _ = greenBold.Printf("  Total: %s reclaimable\n", scan.FormatSize(grandTotal))
+	totalStr := scan.FormatSize(grandTotal)
+	if totalTruncated {
+		totalStr = "≥ " + totalStr
+	}
+	_, _ = greenBold.Printf("  Total: %s reclaimable\n", totalStr)
 	fmt.Println()
 }
 
+// compactWidth is the terminal width below which the compact layout is
+// chosen automatically.
+const compactWidth = 80
+
+// useCompactLayout reports whether results should be printed one line per
+// category: when --compact is set, or when stdout is a terminal narrower
+// than compactWidth.
+func useCompactLayout() bool {
+	if flagCompact {
+		return true
+	}
+	fd := int(os.Stdout.Fd())
+	if !term.IsTerminal(fd) {
+		return false
+	}
+	width, _, err := term.GetSize(fd)
+	return err == nil && width < compactWidth
+}
+
+// printCompactCategory prints cat on a single line: description, total
+// size, and the flag that selects it. Entry paths follow only with
+// --verbose.
+func printCompactCategory(cat scan.CategoryResult, home string) {
+	cyan := color.New(color.FgCyan)
+	faint := color.New(color.Faint)
+
+	sizeStr := scan.FormatSize(cat.TotalSize)
+	if cat.Truncated {
+		sizeStr = "≥ " + sizeStr
+	}
+	line := "  " + cat.Description + "  " + cyan.Sprint(sizeStr)
+	if hint := flagForCategory(cat.Category); hint != "" {
+		line += "  " + faint.Sprint(hint)
+	}
+	if n := len(cat.RecentlyModified); n > 0 {
+		line += "  " + faint.Sprintf("(%d kept)", n)
+	}
+	fmt.Println(line)
+
+	if flagVerbose {
+		for _, entry := range cat.Entries {
+			fmt.Printf("    %s\n", displayPath(entry.Path, home))
+		}
+	}
+}
+
+// printScanPaths lists the candidate paths of each scanner in ids under a
+// header with the scanner name. Paths that do not exist are marked. No
+// sizes are computed and nothing is modified.
+func printScanPaths(w io.Writer, ids []string, home string) {
+	bold := color.New(color.Bold)
+	faint := color.New(color.Faint)
+	for i, id := range ids {
+		paths, err := eng.Paths(id, home)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			continue
+		}
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		_, _ = bold.Fprintf(w, "%s:\n", findScannerInfo(id).Name)
+		for _, p := range paths {
+			line := "  " + displayPath(p, home)
+			if _, err := os.Lstat(p); err != nil {
+				line += faint.Sprint("  (missing)")
+			}
+			fmt.Fprintln(w, line)
+		}
+	}
+}
+
 // printPermissionIssues collects permission issues from all categories
-// and prints them to stderr as a warning.
+// and prints them to stderr as a warning, collapsing issues inside an
+// unreadable ancestor into one line.
 func printPermissionIssues(results []scan.CategoryResult) {
 	var issues []scan.PermissionIssue
 	for _, cat := range results {
 		issues = append(issues, cat.PermissionIssues...)
 	}
+	issues = scan.DedupePermissionIssues(issues)
 	if len(issues) == 0 {
 		return
 	}
-	home, _ := os.UserHomeDir()
+	home, _ := safety.Home()
 	yellow := color.New(color.FgYellow)
 	fmt.Fprintln(os.Stderr)
 	_, _ = yellow.Fprintf(os.Stderr, "Note: %d path(s) could not be accessed (permission denied):\n", len(issues))
 	for _, issue := range issues {
-		path := shortenHome(issue.Path, home)
-		fmt.Fprintf(os.Stderr, "  %s — %s\n", path, issue.Description)
+		path := displayPath(issue.Path, home)
+		fmt.Fprintf(os.Stderr, "  %s — %s\n", path, scan.ValidUTF8(issue.Description))
+		if issue.Hint != "" {
+			fmt.Fprintf(os.Stderr, "    hint: %s\n", issue.Hint)
+		}
+	}
+}
+
+// displayPath formats a path for table output. The home directory prefix
+// is shortened to ~ unless --absolute-paths is set.
+func displayPath(path, home string) string {
+	if flagAbsolutePaths {
+		return scan.ValidUTF8(path)
 	}
+	return scan.ValidUTF8(shortenHome(path, home))
 }
 
 // shortenHome replaces the home directory prefix with ~ for display.
@@ -2,16 +2,21 @@ package cmd
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/fatih/color"
 	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/confirm"
 	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 	"github.com/sp3esu/mac-cleaner/internal/spinner"
 )
@@ -28,26 +33,42 @@ func TestFlagForCategory(t *testing.T) {
 		{"system-logs", "--system-caches"},
 		// quicklook special case
 		{"quicklook", "--system-caches"},
+		// diagnostic archives
+		{"system-sysdiagnose", "--system-caches"},
+		{"system-broken-symlinks", "--system-caches"},
+		{"system-installer-leftovers", "--system-caches"},
+		{"system-tmp-caches", "--system-caches"},
+		{"system-iclouddrive-cache", "--system-caches"},
+		{"dev-xcode-index", "--dev-caches"},
 		// browser
 		{"browser-safari", "--browser-data"},
+		{"browser-safari-favicons", "--browser-data"},
+		{"browser-safari-website-data", "--browser-data"},
 		{"browser-chrome", "--browser-data"},
+		{"browser-chrome-storage", "--browser-data"},
 		{"browser-firefox", "--browser-data"},
 		// developer
 		{"dev-xcode", "--dev-caches"},
 		{"dev-npm", "--dev-caches"},
 		{"dev-yarn", "--dev-caches"},
 		{"dev-homebrew", "--dev-caches"},
+		{"dev-brew-autoremove", "--dev-caches"},
+		{"dev-node-modules", "--dev-caches"},
+		{"dev-pyenvs", "--dev-caches"},
 		{"dev-docker", "--dev-caches"},
 		{"dev-simulator-caches", "--dev-caches"},
 		{"dev-simulator-logs", "--dev-caches"},
 		{"dev-xcode-device-support", "--dev-caches"},
 		{"dev-xcode-archives", "--dev-caches"},
+		{"dev-mobiledevice", "--dev-caches"},
 		{"dev-pnpm", "--dev-caches"},
 		{"dev-cocoapods", "--dev-caches"},
 		{"dev-gradle", "--dev-caches"},
 		{"dev-pip", "--dev-caches"},
+		{"dev-docker-vm", "--dev-caches"},
 		// app leftovers
 		{"app-orphaned-prefs", "--app-leftovers"},
+		{"app-orphaned-group-containers", "--app-leftovers"},
 		{"app-ios-backups", "--app-leftovers"},
 		{"app-old-downloads", "--app-leftovers"},
 		// creative
@@ -55,11 +76,15 @@ func TestFlagForCategory(t *testing.T) {
 		{"creative-adobe-media", "--creative-caches"},
 		{"creative-sketch", "--creative-caches"},
 		{"creative-figma", "--creative-caches"},
+		{"creative-adobe-logs", "--creative-caches"},
+		{"creative-figma-profile", "--creative-caches"},
 		// messaging
 		{"msg-slack", "--messaging-caches"},
 		{"msg-discord", "--messaging-caches"},
 		{"msg-teams", "--messaging-caches"},
 		{"msg-zoom", "--messaging-caches"},
+		{"msg-zoom-recordings", "--messaging-caches"},
+		{"msg-slack-downloads", "--messaging-caches"},
 		// unknown / empty
 		{"unknown-thing", ""},
 		{"", ""},
@@ -113,6 +138,27 @@ func TestPrintDryRunSummary_MultipleCategoriesSortedBySize(t *testing.T) {
 	}
 }
 
+func TestPrintDryRunSummary_RiskTotals(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	results := []scan.CategoryResult{
+		{Category: "system-caches", Description: "Caches", TotalSize: 8_000_000_000, Entries: []scan.ScanEntry{
+			{Path: "/a", Size: 8_000_000_000, RiskLevel: safety.RiskSafe, Deletable: true},
+		}},
+		{Category: "dev-docker-vm", Description: "VMs", TotalSize: 43_000_000_000, Entries: []scan.ScanEntry{
+			{Path: "/b", Size: 40_000_000_000, RiskLevel: safety.RiskRisky, Deletable: true},
+			{Path: "/c", Size: 3_000_000_000, RiskLevel: safety.RiskModerate, Deletable: true},
+		}},
+	}
+	printDryRunSummary(&buf, results)
+
+	if !strings.Contains(buf.String(), "By risk: Safe 8.0 GB, Moderate 3.0 GB, Risky 40.0 GB") {
+		t.Errorf("expected risk breakdown, got: %s", buf.String())
+	}
+}
+
 func TestPrintDryRunSummary_SingleCategory_NoOutput(t *testing.T) {
 	color.NoColor = true
 	defer func() { color.NoColor = false }()
@@ -165,6 +211,83 @@ func TestPrintDryRunSummary_ZeroSizeCategoriesFiltered(t *testing.T) {
 	}
 }
 
+func TestPrintDryRunSummary_CoalesceUnder(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	flagCoalesceUnder = 100_000_000
+	defer func() { flagCoalesceUnder = 0 }()
+
+	var buf bytes.Buffer
+	results := []scan.CategoryResult{
+		{Category: "a", Description: "Big Cat", TotalSize: 2_000_000_000},
+		{Category: "b", Description: "Tiny One", TotalSize: 40_000_000},
+		{Category: "c", Description: "Tiny Two", TotalSize: 60_000_000},
+		{Category: "d", Description: "Medium Cat", TotalSize: 500_000_000},
+	}
+	printDryRunSummary(&buf, results)
+	out := buf.String()
+
+	if strings.Contains(out, "Tiny One") || strings.Contains(out, "Tiny Two") {
+		t.Errorf("small categories should be coalesced, got: %s", out)
+	}
+	otherIdx := strings.Index(out, "Other (2 categories)")
+	if otherIdx < 0 {
+		t.Fatalf("expected Other row, got: %s", out)
+	}
+	if !strings.Contains(out, "100.0 MB") {
+		t.Errorf("expected combined size 100.0 MB, got: %s", out)
+	}
+	if strings.Index(out, "Medium Cat") > otherIdx {
+		t.Errorf("Other row should come last, got: %s", out)
+	}
+	if !strings.Contains(out, "Total: 2.6 GB") {
+		t.Errorf("total should include coalesced categories, got: %s", out)
+	}
+}
+
+func TestSummaryRows(t *testing.T) {
+	sorted := []scan.CategoryResult{
+		{Category: "a", Description: "Big", TotalSize: 1000},
+		{Category: "b", Description: "Small", TotalSize: 10},
+		{Category: "c", Description: "Smaller", TotalSize: 5},
+	}
+
+	rows := summaryRows(sorted, 0)
+	if len(rows) != 3 {
+		t.Fatalf("threshold 0: got %d rows, want 3", len(rows))
+	}
+
+	rows = summaryRows(sorted, 100)
+	if len(rows) != 2 {
+		t.Fatalf("threshold 100: got %d rows, want 2", len(rows))
+	}
+	if rows[1].label != "Other (2 categories)" || rows[1].size != 15 || rows[1].hint != "" {
+		t.Errorf("Other row = %+v", rows[1])
+	}
+
+	// A single small category keeps its own row.
+	rows = summaryRows(sorted, 8)
+	if len(rows) != 3 || rows[2].label != "Smaller" {
+		t.Errorf("threshold 8: got %+v, want single small category kept", rows)
+	}
+}
+
+func TestSizeValue(t *testing.T) {
+	var v sizeValue
+	if err := v.Set("250MB"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	if int64(v) != 250_000_000 {
+		t.Errorf("value = %d, want 250000000", int64(v))
+	}
+	if v.Type() != "size" {
+		t.Errorf("Type = %q", v.Type())
+	}
+	if err := v.Set("lots"); err == nil {
+		t.Error("expected error for invalid size")
+	}
+}
+
 func TestPrintDryRunSummary_ExactlyTwoCategories(t *testing.T) {
 	color.NoColor = true
 	defer func() { color.NoColor = false }()
@@ -304,6 +427,26 @@ func TestPrintCleanupSummary_NoFailures(t *testing.T) {
 	}
 }
 
+func TestPrintCleanupSummary_WithSudo(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	var buf bytes.Buffer
+	result := cleanup.CleanupResult{Removed: 5, BytesFreed: 3000, SudoRemoved: 2, SudoBytesFreed: 1000}
+	printCleanupSummary(&buf, result)
+
+	out := buf.String()
+	if !strings.Contains(out, "2 of them (1.0 kB) removed with sudo, 3 (2.0 kB) without") {
+		t.Errorf("expected sudo breakdown, got: %s", out)
+	}
+
+	buf.Reset()
+	printCleanupSummary(&buf, cleanup.CleanupResult{Removed: 5, BytesFreed: 3000})
+	if strings.Contains(buf.String(), "sudo") {
+		t.Errorf("should not mention sudo when nothing was removed with it, got: %s", buf.String())
+	}
+}
+
 func TestPrintCleanupSummary_WithFailures(t *testing.T) {
 	color.NoColor = true
 	defer func() { color.NoColor = false }()
@@ -338,6 +481,86 @@ func TestPrintCleanupSummary_WithFailures(t *testing.T) {
 	}
 }
 
+func TestPrintScanDuration(t *testing.T) {
+	var buf bytes.Buffer
+	printScanDuration(&buf, "Developer Caches", 4200*time.Millisecond)
+
+	want := "Developer Caches scanned in 4.2s.\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+}
+
+func TestSizeProgressMessage(t *testing.T) {
+	tests := []struct {
+		name string
+		p    scan.SizeProgress
+		want string
+	}{
+		{
+			name: "known total",
+			p:    scan.SizeProgress{Bytes: 3_200_000_000, Elapsed: 8 * time.Second, Estimate: 5_200_000_000},
+			want: "Scanning mail... 3.2 GB, ~5s remaining",
+		},
+		{
+			name: "unknown total",
+			p:    scan.SizeProgress{Bytes: 3_200_000_000, Elapsed: 8 * time.Second},
+			want: "Scanning mail... 3.2 GB",
+		},
+		{
+			name: "nearly done rounds up to one second",
+			p:    scan.SizeProgress{Bytes: 999, Elapsed: time.Second, Estimate: 1000},
+			want: "Scanning mail... 999 B, ~1s remaining",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sizeProgressMessage("Scanning mail...", tt.p); got != tt.want {
+				t.Errorf("sizeProgressMessage = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSpinner_PlainWhenNotTerminal(t *testing.T) {
+	// Under go test, stdout and stderr are not terminals.
+	stderr := captureStderr(t, func() {
+		sp := newSpinner("Scanning...")
+		stop := startScanSpinner(sp, "System Caches")
+		stop()
+	})
+	if stderr != "Scanning system caches...\n" {
+		t.Errorf("expected a plain status line, got %q", stderr)
+	}
+}
+
+func TestNewSpinner_NoSpinnerFlag(t *testing.T) {
+	flagNoSpinner = true
+	defer func() { flagNoSpinner = false }()
+
+	stderr := captureStderr(t, func() {
+		sp := newSpinner("Cleaning up...")
+		sp.Start()
+		sp.Stop()
+	})
+	if stderr != "Cleaning up...\n" {
+		t.Errorf("expected a plain status line with --no-spinner, got %q", stderr)
+	}
+}
+
+func TestNewSpinner_SilentWithJSON(t *testing.T) {
+	flagJSON = true
+	defer func() { flagJSON = false }()
+
+	stderr := captureStderr(t, func() {
+		stop := startScanSpinner(newSpinner("Scanning..."), "System Caches")
+		stop()
+	})
+	if stderr != "" {
+		t.Errorf("expected no spinner output with --json, got %q", stderr)
+	}
+}
+
 // captureStdout redirects os.Stdout and color.Output to a pipe and returns
 // the captured output. Both must be redirected because the color package
 // caches its own output writer at init time.
@@ -495,6 +718,60 @@ func TestBaseDirectory(t *testing.T) {
 	}
 }
 
+func TestDisplayPath_ShortensByDefault(t *testing.T) {
+	flagAbsolutePaths = false
+	got := displayPath("/Users/test/Library/Caches", "/Users/test")
+	if got != "~/Library/Caches" {
+		t.Errorf("expected ~/Library/Caches, got %q", got)
+	}
+}
+
+func TestDisplayPath_AbsolutePathsKeepsHome(t *testing.T) {
+	flagAbsolutePaths = true
+	defer func() { flagAbsolutePaths = false }()
+
+	got := displayPath("/Users/test/Library/Caches", "/Users/test")
+	if got != "/Users/test/Library/Caches" {
+		t.Errorf("expected home prefix intact, got %q", got)
+	}
+}
+
+func TestPrintResults_AbsolutePaths(t *testing.T) {
+	color.NoColor = true
+	flagVerbose = true
+	flagAbsolutePaths = true
+	defer func() {
+		color.NoColor = false
+		flagVerbose = false
+		flagAbsolutePaths = false
+	}()
+
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		t.Skip("no home directory")
+	}
+	path := filepath.Join(home, "Library", "Caches", "com.example.app")
+	results := []scan.CategoryResult{
+		{
+			Category:    "system-caches",
+			Description: "User App Caches",
+			Entries:     []scan.ScanEntry{{Path: path, Description: "com.example.app", Size: 1024}},
+			TotalSize:   1024,
+		},
+	}
+
+	out := captureStdout(t, func() {
+		printResults(results, false, "Test Title")
+	})
+
+	if !strings.Contains(out, path) {
+		t.Errorf("expected absolute path %q in output, got: %s", path, out)
+	}
+	if strings.Contains(out, "~/Library") {
+		t.Errorf("expected no ~ shortening, got: %s", out)
+	}
+}
+
 // --- printJSON tests ---
 
 func TestPrintJSON(t *testing.T) {
@@ -534,6 +811,36 @@ func TestPrintJSON(t *testing.T) {
 	}
 }
 
+func TestPrintJSON_Provenance(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	old := version
+	version = "1.2.3"
+	defer func() { version = old }()
+
+	out := captureStdout(t, func() {
+		printJSON(nil)
+	})
+
+	var raw struct {
+		GeneratedAt string `json:"generated_at"`
+		ToolVersion string `json:"tool_version"`
+	}
+	if err := json.Unmarshal([]byte(out), &raw); err != nil {
+		t.Fatalf("invalid JSON: %v\noutput: %s", err, out)
+	}
+	generated, err := time.Parse(time.RFC3339, raw.GeneratedAt)
+	if err != nil {
+		t.Fatalf("expected RFC 3339 generated_at, got %q: %v", raw.GeneratedAt, err)
+	}
+	if time.Since(generated) > time.Minute {
+		t.Errorf("expected generated_at close to now, got %s", generated)
+	}
+	if raw.ToolVersion != "1.2.3" {
+		t.Errorf("expected tool_version 1.2.3, got %q", raw.ToolVersion)
+	}
+}
+
 func TestPrintJSON_EmptyResults(t *testing.T) {
 	color.NoColor = true
 	defer func() { color.NoColor = false }()
@@ -551,6 +858,49 @@ func TestPrintJSON_EmptyResults(t *testing.T) {
 	}
 }
 
+func TestPrintJSON_EntriesIncludeRisk(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	results := []scan.CategoryResult{
+		{
+			Category:    "dev-xcode",
+			Description: "Xcode DerivedData",
+			Entries: []scan.ScanEntry{
+				{Path: "/tmp/a", Description: "a", Size: 100, RiskLevel: safety.RiskRisky},
+				{Path: "/tmp/b", Description: "b", Size: 200},
+			},
+			TotalSize: 300,
+		},
+	}
+
+	out := captureStdout(t, func() {
+		printJSON(results)
+	})
+
+	var raw struct {
+		Categories []struct {
+			Entries []map[string]interface{} `json:"entries"`
+		} `json:"categories"`
+	}
+	if err := json.Unmarshal([]byte(out), &raw); err != nil {
+		t.Fatalf("invalid JSON: %v\noutput: %s", err, out)
+	}
+	entries := raw.Categories[0].Entries
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(entries))
+	}
+	for i, e := range entries {
+		risk, ok := e["risk_level"].(string)
+		if !ok || risk == "" {
+			t.Errorf("entry %d: expected non-empty risk_level, got %v", i, e["risk_level"])
+		}
+	}
+	if got := entries[1]["risk_level"]; got != safety.RiskForCategory("dev-xcode") {
+		t.Errorf("expected unset risk filled from category, got %v", got)
+	}
+}
+
 // --- printResults tests ---
 
 func TestPrintResults_Empty(t *testing.T) {
@@ -617,6 +967,85 @@ func TestPrintResults_DryRun(t *testing.T) {
 	}
 }
 
+func TestPrintResults_Compact(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	flagCompact = true
+	defer func() { flagCompact = false }()
+
+	results := []scan.CategoryResult{
+		{
+			Category:    "dev-npm",
+			Description: "npm Cache",
+			Entries: []scan.ScanEntry{
+				{Path: "/tmp/npm/a", Description: "npm-entry-a", Size: 1000},
+				{Path: "/tmp/npm/b", Description: "npm-entry-b", Size: 500},
+			},
+			TotalSize: 1500,
+		},
+		{
+			Category:    "browser-safari",
+			Description: "Safari Cache",
+			Entries: []scan.ScanEntry{
+				{Path: "/tmp/safari/c", Description: "safari-entry-c", Size: 2000},
+			},
+			TotalSize: 2000,
+		},
+	}
+
+	out := captureStdout(t, func() {
+		printResults(results, false, "Test Title")
+	})
+
+	for _, want := range []string{"npm Cache  1.5 kB  --dev-caches", "Safari Cache  2.0 kB  --browser-data"} {
+		count := 0
+		for _, line := range strings.Split(out, "\n") {
+			if strings.Contains(line, want) {
+				count++
+			}
+		}
+		if count != 1 {
+			t.Errorf("expected exactly one line containing %q, got %d in: %s", want, count, out)
+		}
+	}
+	for _, entry := range []string{"npm-entry-a", "npm-entry-b", "safari-entry-c"} {
+		if strings.Contains(out, entry) {
+			t.Errorf("expected no per-entry row %q in compact output, got: %s", entry, out)
+		}
+	}
+	if !strings.Contains(out, "Total: 3.5 kB reclaimable") {
+		t.Errorf("expected total line, got: %s", out)
+	}
+}
+
+func TestPrintResults_CompactVerboseListsPaths(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	flagCompact = true
+	flagVerbose = true
+	defer func() {
+		flagCompact = false
+		flagVerbose = false
+	}()
+
+	results := []scan.CategoryResult{
+		{
+			Category:    "dev-npm",
+			Description: "npm Cache",
+			Entries:     []scan.ScanEntry{{Path: "/tmp/npm/a", Description: "npm-entry-a", Size: 1000}},
+			TotalSize:   1000,
+		},
+	}
+
+	out := captureStdout(t, func() {
+		printResults(results, false, "Test Title")
+	})
+
+	if !strings.Contains(out, "    /tmp/npm/a") {
+		t.Errorf("expected entry path with --verbose, got: %s", out)
+	}
+}
+
 // --- printPermissionIssues tests ---
 
 func TestPrintPermissionIssues_NoIssues(t *testing.T) {
@@ -661,6 +1090,28 @@ func TestPrintPermissionIssues_WithIssues(t *testing.T) {
 	}
 }
 
+func TestPrintPermissionIssues_ShowsHint(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	results := []scan.CategoryResult{
+		{
+			Category: "sysdata-mail",
+			PermissionIssues: []scan.PermissionIssue{
+				{Path: "/Users/x/Library/Mail", Description: "Mail (permission denied)", Hint: "grant Full Disk Access"},
+			},
+		},
+	}
+
+	out := captureStderr(t, func() {
+		printPermissionIssues(results)
+	})
+
+	if !strings.Contains(out, "hint: grant Full Disk Access") {
+		t.Errorf("expected hint in output, got: %s", out)
+	}
+}
+
 // --- cleanupProgress tests ---
 
 func TestCleanupProgress_JSON(t *testing.T) {
@@ -783,3 +1234,1080 @@ func TestEngineCategories(t *testing.T) {
 		}
 	}
 }
+
+// --- runCleanup / --report-only tests ---
+
+func TestRunCleanup_ReportOnlyRefuses(t *testing.T) {
+	flagReportOnly = true
+	flagForce = true
+	defer func() {
+		flagReportOnly = false
+		flagForce = false
+	}()
+
+	dir := t.TempDir()
+	target := filepath.Join(dir, "cache")
+	if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := []scan.CategoryResult{{
+		Category:    "system-caches",
+		Description: "User App Caches",
+		Entries:     []scan.ScanEntry{{Path: target, Description: "cache", Size: 4}},
+		TotalSize:   4,
+	}}
+
+	var out bytes.Buffer
+	var ran bool
+	stderr := captureStderr(t, func() {
+		ran = runCleanup(strings.NewReader("yes\n"), &out, spinner.New("", false), results)
+	})
+
+	if ran {
+		t.Error("expected runCleanup to refuse in report-only mode")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected file to survive report-only cleanup: %v", err)
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no prompt or summary output, got: %s", out.String())
+	}
+	if !strings.Contains(stderr, "Report-only mode") {
+		t.Errorf("expected report-only notice on stderr, got: %q", stderr)
+	}
+}
+
+func TestRunCleanup_ForceDeletes(t *testing.T) {
+	flagForce = true
+	defer func() { flagForce = false }()
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	dir := acknowledgedHome(t)
+	target := filepath.Join(dir, "cache")
+	if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := []scan.CategoryResult{{
+		Category:    "system-caches",
+		Description: "User App Caches",
+		Entries:     []scan.ScanEntry{{Path: target, Description: "cache", Size: 4}},
+		TotalSize:   4,
+	}}
+
+	var out bytes.Buffer
+	if !runCleanup(strings.NewReader(""), &out, spinner.New("", false), results) {
+		t.Fatal("expected runCleanup to proceed with --force")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("expected file to be removed, stat err: %v", err)
+	}
+	if !strings.Contains(out.String(), "1 items removed") {
+		t.Errorf("expected cleanup summary, got: %s", out.String())
+	}
+}
+
+func TestRunCleanup_OperatorStateStaysOutOfAuditedHome(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	origClock := clock
+	defer func() { clock = origClock }()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock = func() time.Time { return now }
+
+	operator := acknowledgedHome(t)
+	audited := t.TempDir()
+	safety.SetHome(audited)
+	defer safety.SetHome("")
+	// An acknowledgement planted in the audited home does not count.
+	if err := confirm.RecordAcknowledgement(audited); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(confirm.AckPath(operator))
+	makeResults := func(name string) []scan.CategoryResult {
+		target := filepath.Join(audited, name)
+		if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return []scan.CategoryResult{{
+			Category:    "system-caches",
+			Description: "User App Caches",
+			Entries:     []scan.ScanEntry{{Path: target, Description: name, Size: 4}},
+			TotalSize:   4,
+		}}
+	}
+
+	var out bytes.Buffer
+	if !runCleanup(strings.NewReader("I understand\nyes\n"), &out, spinner.New("", false), makeResults("first")) {
+		t.Fatalf("expected the cleanup to proceed, output: %s", out.String())
+	}
+	if !strings.Contains(out.String(), "First run") {
+		t.Errorf("expected the operator to be asked for the acknowledgement, got: %s", out.String())
+	}
+	if !confirm.HasAcknowledged(operator) {
+		t.Error("expected the acknowledgement in the operator's home")
+	}
+	if _, err := os.Stat(confirm.LastCleanupPath(operator)); err != nil {
+		t.Errorf("expected the cleanup time in the operator's home: %v", err)
+	}
+	if _, err := os.Stat(confirm.LastCleanupPath(audited)); !os.IsNotExist(err) {
+		t.Errorf("expected no cleanup record in the audited home, stat err: %v", err)
+	}
+
+	// The operator's cooldown applies whatever home is audited.
+	now = now.Add(10 * time.Second)
+	if runCleanup(strings.NewReader("yes\n"), &out, spinner.New("", false), makeResults("second")) {
+		t.Error("expected the operator's cooldown to refuse a quick rerun")
+	}
+}
+
+func TestRunCleanup_CooldownBlocksQuickRerun(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	origClock := clock
+	defer func() { clock = origClock }()
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	clock = func() time.Time { return now }
+
+	dir := acknowledgedHome(t)
+	makeResults := func(name string) []scan.CategoryResult {
+		target := filepath.Join(dir, name)
+		if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return []scan.CategoryResult{{
+			Category:    "system-caches",
+			Description: "User App Caches",
+			Entries:     []scan.ScanEntry{{Path: target, Description: name, Size: 4}},
+			TotalSize:   4,
+		}}
+	}
+
+	var out bytes.Buffer
+	if !runCleanup(strings.NewReader("yes\n"), &out, spinner.New("", false), makeResults("first")) {
+		t.Fatal("expected first cleanup to proceed")
+	}
+
+	// A second cleanup 10s later is refused before the prompt.
+	now = now.Add(10 * time.Second)
+	second := makeResults("second")
+	out.Reset()
+	if runCleanup(strings.NewReader("yes\n"), &out, spinner.New("", false), second) {
+		t.Fatal("expected cleanup within the cooldown to be refused")
+	}
+	if _, err := os.Stat(second[0].Entries[0].Path); err != nil {
+		t.Errorf("expected file kept during cooldown, stat err: %v", err)
+	}
+
+	// --force overrides the cooldown.
+	flagForce = true
+	if !runCleanup(strings.NewReader(""), &out, spinner.New("", false), second) {
+		t.Error("expected --force to bypass the cooldown")
+	}
+	flagForce = false
+
+	// Once the window has elapsed a normal cleanup proceeds.
+	now = now.Add(confirm.CleanupCooldown)
+	if !runCleanup(strings.NewReader("yes\n"), &out, spinner.New("", false), makeResults("third")) {
+		t.Error("expected cleanup after the cooldown to proceed")
+	}
+}
+
+func TestRunCleanup_JSONPrintsCleanupResult(t *testing.T) {
+	flagForce = true
+	flagJSON = true
+	defer func() {
+		flagForce = false
+		flagJSON = false
+	}()
+
+	dir := acknowledgedHome(t)
+	first := filepath.Join(dir, "cache-a")
+	second := filepath.Join(dir, "cache-b")
+	os.WriteFile(first, []byte("data"), 0644)
+	os.WriteFile(second, []byte("more data"), 0644)
+	results := []scan.CategoryResult{{
+		Category:    "system-caches",
+		Description: "User App Caches",
+		Entries: []scan.ScanEntry{
+			{Path: first, Description: "cache-a", Size: 4},
+			{Path: second, Description: "cache-b", Size: 9},
+			{Path: "docker:BuildCache", Description: "Build Cache", Size: 100},
+		},
+		TotalSize:        113,
+		RecentlyModified: []scan.ScanEntry{{Path: filepath.Join(dir, "live"), Size: 1}},
+	}}
+
+	var out bytes.Buffer
+	if !runCleanup(strings.NewReader(""), &out, spinner.New("", false), results) {
+		t.Fatal("expected runCleanup to proceed with --force")
+	}
+
+	var got struct {
+		Removed    int      `json:"removed"`
+		Failed     int      `json:"failed"`
+		Skipped    int      `json:"skipped"`
+		BytesFreed int64    `json:"bytes_freed"`
+		Errors     []string `json:"errors"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("cleanup output is not a JSON object: %v\n%s", err, out.String())
+	}
+	if got.Removed != 2 || got.Failed != 1 || got.Skipped != 1 {
+		t.Errorf("removed/failed/skipped = %d/%d/%d, want 2/1/1", got.Removed, got.Failed, got.Skipped)
+	}
+	if got.BytesFreed != 13 {
+		t.Errorf("bytes_freed = %d, want 13", got.BytesFreed)
+	}
+	if len(got.Errors) != 1 || !strings.Contains(got.Errors[0], "docker:BuildCache") {
+		t.Errorf("unexpected errors: %v", got.Errors)
+	}
+}
+
+func TestRootJSONCleanup_StdoutIsOneDocument(t *testing.T) {
+	for _, force := range []bool{true, false} {
+		flagSystemCaches = true
+		flagJSON = true
+		flagForce = force
+		dir := acknowledgedHome(t)
+		target := filepath.Join(dir, "cache-a")
+		if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		eng = engine.New()
+		eng.Register(engine.NewScanner(engine.ScannerInfo{ID: "system", Name: "System Caches"}, func() ([]scan.CategoryResult, error) {
+			return []scan.CategoryResult{{
+				Category:    "system-caches",
+				Description: "User App Caches",
+				Entries:     []scan.ScanEntry{{Path: target, Description: "cache-a", Size: 4}},
+				TotalSize:   4,
+			}}, nil
+		}))
+
+		// Without --force the answer to the prompt comes from stdin.
+		oldStdin := os.Stdin
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.WriteString("yes\n")
+		w.Close()
+		os.Stdin = r
+
+		var stderr string
+		stdout := captureStdout(t, func() {
+			stderr = captureStderr(t, func() { rootCmd.Run(rootCmd, nil) })
+		})
+		os.Stdin = oldStdin
+		flagSystemCaches, flagJSON, flagForce = false, false, false
+		eng = nil
+
+		var got struct {
+			Results struct {
+				TotalSize int64 `json:"total_size"`
+			} `json:"results"`
+			Cleanup *struct {
+				Removed    int   `json:"removed"`
+				BytesFreed int64 `json:"bytes_freed"`
+			} `json:"cleanup"`
+		}
+		dec := json.NewDecoder(strings.NewReader(stdout))
+		if err := dec.Decode(&got); err != nil {
+			t.Fatalf("force=%v: stdout is not JSON: %v\n%s", force, err, stdout)
+		}
+		if dec.More() {
+			t.Errorf("force=%v: stdout holds more than one JSON document:\n%s", force, stdout)
+		}
+		if got.Results.TotalSize != 4 {
+			t.Errorf("force=%v: results total_size = %d, want 4", force, got.Results.TotalSize)
+		}
+		if got.Cleanup == nil || got.Cleanup.Removed != 1 || got.Cleanup.BytesFreed != 4 {
+			t.Errorf("force=%v: cleanup = %+v, want 1 removed, 4 bytes", force, got.Cleanup)
+		}
+		if !force && !strings.Contains(stderr, "Type 'yes' to proceed") {
+			t.Errorf("expected the confirmation prompt on stderr, got %q", stderr)
+		}
+	}
+}
+
+func TestPrintCleanupJSON_EmptyErrorsIsArray(t *testing.T) {
+	var out bytes.Buffer
+	printCleanupJSON(&out, cleanup.CleanupResult{Removed: 1, BytesFreed: 10}, 0, nil)
+	if !strings.Contains(out.String(), `"errors": []`) {
+		t.Errorf("expected empty errors array, got: %s", out.String())
+	}
+}
+
+func TestPrintCleanupJSON_PerCategory(t *testing.T) {
+	var out bytes.Buffer
+	result := cleanup.CleanupResult{
+		Removed:     3,
+		BytesFreed:  30,
+		PerCategory: map[string]int64{"system-caches": 10, "dev-docker": 20},
+	}
+	printCleanupJSON(&out, result, 0, nil)
+
+	var got cleanupJSON
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, out.String())
+	}
+	if got.PerCategory["system-caches"] != 10 || got.PerCategory["dev-docker"] != 20 {
+		t.Errorf("per_category = %v, want system-caches 10, dev-docker 20", got.PerCategory)
+	}
+}
+
+func TestPrintCleanupJSON_IncludesVerify(t *testing.T) {
+	var out bytes.Buffer
+	check := checkFreeSpace(10, 0, 10)
+	printCleanupJSON(&out, cleanup.CleanupResult{Removed: 1, BytesFreed: 10}, 0, &check)
+	if !strings.Contains(out.String(), `"disk_freed_bytes": 10`) {
+		t.Errorf("expected verify block, got: %s", out.String())
+	}
+
+	out.Reset()
+	printCleanupJSON(&out, cleanup.CleanupResult{Removed: 1, BytesFreed: 10}, 0, nil)
+	if strings.Contains(out.String(), "verify") {
+		t.Errorf("expected no verify block without --verify, got: %s", out.String())
+	}
+}
+
+func TestCheckFreeSpace(t *testing.T) {
+	const gb = 1000 * 1000 * 1000
+	tests := []struct {
+		name                    string
+		reported, before, after int64
+		wantFreed               int64
+		wantDiscrepancy         bool
+	}{
+		{"matches", 10 * gb, 50 * gb, 60 * gb, 10 * gb, false},
+		{"more freed than reported", 1 * gb, 50 * gb, 52 * gb, 2 * gb, false},
+		{"cloned data", 10 * gb, 50 * gb, 52 * gb, 2 * gb, true},
+		{"nothing freed", 10 * gb, 50 * gb, 50 * gb, 0, true},
+		{"free space dropped", 10 * gb, 50 * gb, 49 * gb, 0, true},
+		{"over half freed", 10 * gb, 50 * gb, 56 * gb, 6 * gb, false},
+		{"small gap ignored", 50 * 1000 * 1000, 50 * gb, 50 * gb, 0, false},
+		{"nothing reported", 0, 50 * gb, 50 * gb, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := checkFreeSpace(tt.reported, tt.before, tt.after)
+			if got.Reported != tt.reported {
+				t.Errorf("Reported = %d, want %d", got.Reported, tt.reported)
+			}
+			if got.DiskFreed != tt.wantFreed {
+				t.Errorf("DiskFreed = %d, want %d", got.DiskFreed, tt.wantFreed)
+			}
+			if got.Discrepancy != tt.wantDiscrepancy {
+				t.Errorf("Discrepancy = %v, want %v", got.Discrepancy, tt.wantDiscrepancy)
+			}
+		})
+	}
+}
+
+func TestPrintFreeSpaceCheck(t *testing.T) {
+	var out bytes.Buffer
+	printFreeSpaceCheck(&out, checkFreeSpace(10*1000*1000*1000, 0, 2*1000*1000*1000))
+	got := out.String()
+	if !strings.Contains(got, "Disk free space increased by 2.0 GB (reported 10.0 GB)") {
+		t.Errorf("expected measured delta line, got: %s", got)
+	}
+	if !strings.Contains(got, "Reported 10.0 GB, disk freed 2.0 GB") {
+		t.Errorf("expected discrepancy warning, got: %s", got)
+	}
+
+	out.Reset()
+	printFreeSpaceCheck(&out, checkFreeSpace(100, 0, 100))
+	if strings.Contains(out.String(), "disk freed") {
+		t.Errorf("expected no warning when sizes agree, got: %s", out.String())
+	}
+}
+
+func TestRunCleanup_AbortedAtPrompt(t *testing.T) {
+	dir := acknowledgedHome(t)
+	target := filepath.Join(dir, "cache")
+	if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := []scan.CategoryResult{{
+		Category:    "system-caches",
+		Description: "User App Caches",
+		Entries:     []scan.ScanEntry{{Path: target, Description: "cache", Size: 4}},
+		TotalSize:   4,
+	}}
+
+	var out bytes.Buffer
+	if runCleanup(strings.NewReader("no\n"), &out, spinner.New("", false), results) {
+		t.Fatal("expected runCleanup to abort when the user declines")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected file to survive aborted cleanup: %v", err)
+	}
+	if !strings.Contains(out.String(), "Aborted.") {
+		t.Errorf("expected Aborted message, got: %s", out.String())
+	}
+}
+
+// acknowledgedHome points HOME at a fresh temp directory that already holds
+// the first-run acknowledgement, and returns it.
+func acknowledgedHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := confirm.RecordAcknowledgement(home); err != nil {
+		t.Fatal(err)
+	}
+	return home
+}
+
+// --- first-run acknowledgement gate tests ---
+
+func TestEnsureAcknowledged_AbsentAckBlocksForce(t *testing.T) {
+	flagForce = true
+	defer func() { flagForce = false }()
+
+	home := t.TempDir()
+	var out bytes.Buffer
+	var ok bool
+	stderr := captureStderr(t, func() {
+		ok = ensureAcknowledged(strings.NewReader("I understand\n"), &out, home)
+	})
+
+	if ok {
+		t.Error("expected --force alone to be refused on first run")
+	}
+	if confirm.HasAcknowledged(home) {
+		t.Error("expected no acknowledgement to be recorded")
+	}
+	if !strings.Contains(stderr, "--accept-risk") {
+		t.Errorf("expected --accept-risk hint on stderr, got: %q", stderr)
+	}
+}
+
+func TestEnsureAcknowledged_PresentAckAllowsForce(t *testing.T) {
+	flagForce = true
+	defer func() { flagForce = false }()
+
+	home := t.TempDir()
+	if err := confirm.RecordAcknowledgement(home); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if !ensureAcknowledged(strings.NewReader(""), &out, home) {
+		t.Error("expected existing acknowledgement to allow --force")
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no prompt, got: %s", out.String())
+	}
+}
+
+func TestEnsureAcknowledged_AcceptRiskWritesAck(t *testing.T) {
+	flagForce = true
+	flagAcceptRisk = true
+	defer func() {
+		flagForce = false
+		flagAcceptRisk = false
+	}()
+
+	home := t.TempDir()
+	var out bytes.Buffer
+	if !ensureAcknowledged(strings.NewReader(""), &out, home) {
+		t.Error("expected --accept-risk to proceed")
+	}
+	if !confirm.HasAcknowledged(home) {
+		t.Error("expected --accept-risk to record the acknowledgement")
+	}
+}
+
+func TestEnsureAcknowledged_InteractivePrompt(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	home := t.TempDir()
+	var out bytes.Buffer
+	if ensureAcknowledged(strings.NewReader("no\n"), &out, home) {
+		t.Error("expected declined prompt to block")
+	}
+	if confirm.HasAcknowledged(home) {
+		t.Error("expected no acknowledgement after declining")
+	}
+
+	out.Reset()
+	if !ensureAcknowledged(strings.NewReader("I understand\n"), &out, home) {
+		t.Error("expected accepted prompt to proceed")
+	}
+	if !confirm.HasAcknowledged(home) {
+		t.Error("expected acknowledgement after accepting")
+	}
+}
+
+func TestRunCleanup_FirstRunForceRefused(t *testing.T) {
+	flagForce = true
+	flagJSON = true
+	defer func() {
+		flagForce = false
+		flagJSON = false
+	}()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	target := filepath.Join(home, "cache")
+	if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := []scan.CategoryResult{{
+		Category:    "system-caches",
+		Description: "User App Caches",
+		Entries:     []scan.ScanEntry{{Path: target, Description: "cache", Size: 4}},
+		TotalSize:   4,
+	}}
+
+	var out bytes.Buffer
+	var ran bool
+	captureStderr(t, func() {
+		ran = runCleanup(strings.NewReader(""), &out, spinner.New("", false), results)
+	})
+	if ran {
+		t.Fatal("expected first-run --force cleanup to be refused")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Errorf("expected file to survive refused cleanup: %v", err)
+	}
+}
+
+func TestRunCleanup_FirstRunPromptsThenConfirms(t *testing.T) {
+	flagJSON = true
+	defer func() { flagJSON = false }()
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	target := filepath.Join(home, "cache")
+	if err := os.WriteFile(target, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	results := []scan.CategoryResult{{
+		Category:    "system-caches",
+		Description: "User App Caches",
+		Entries:     []scan.ScanEntry{{Path: target, Description: "cache", Size: 4}},
+		TotalSize:   4,
+	}}
+
+	var out bytes.Buffer
+	if !runCleanup(strings.NewReader("I understand\nyes\n"), &out, spinner.New("", false), results) {
+		t.Fatalf("expected cleanup to proceed, output: %s", out.String())
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("expected file to be removed, stat err: %v", err)
+	}
+	if !confirm.HasAcknowledged(home) {
+		t.Error("expected acknowledgement to be recorded")
+	}
+}
+
+// --- withholdReportOnly tests ---
+
+func TestWithholdReportOnly(t *testing.T) {
+	results := []scan.CategoryResult{
+		{Category: "system-caches", Description: "User App Caches"},
+		{Category: "app-orphaned-group-containers", Description: "Orphaned Group Containers", ReportOnly: true},
+	}
+
+	var buf bytes.Buffer
+	got := withholdReportOnly(&buf, results)
+	if len(got) != 1 || got[0].Category != "system-caches" {
+		t.Errorf("expected report-only category withheld, got %+v", got)
+	}
+	if !strings.Contains(buf.String(), "--orphaned-group-containers") {
+		t.Errorf("expected note naming the targeting flag, got %q", buf.String())
+	}
+
+	flagScanOrphanedGroupContainers = true
+	defer func() { flagScanOrphanedGroupContainers = false }()
+	buf.Reset()
+	if got := withholdReportOnly(&buf, results); len(got) != 2 {
+		t.Errorf("expected targeted report-only category kept, got %+v", got)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no note when targeted, got %q", buf.String())
+	}
+}
+
+// --- applyKeepRecent tests ---
+
+func TestApplyKeepRecent_OnlyTimeBasedCategories(t *testing.T) {
+	now := time.Now()
+	results := []scan.CategoryResult{
+		{
+			Category: "app-ios-backups",
+			Entries: []scan.ScanEntry{
+				{Path: "/old", Size: 100, ModTime: now.Add(-90 * 24 * time.Hour)},
+				{Path: "/new", Size: 200, ModTime: now.Add(-24 * time.Hour)},
+			},
+			TotalSize: 300,
+		},
+		{
+			Category:  "app-old-downloads",
+			Entries:   []scan.ScanEntry{{Path: "/dl", Size: 50, ModTime: now}},
+			TotalSize: 50,
+		},
+		{
+			Category:  "system-caches",
+			Entries:   []scan.ScanEntry{{Path: "/cache", Size: 10}},
+			TotalSize: 10,
+		},
+	}
+
+	got := applyKeepRecent(results, 1)
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 categories (emptied downloads dropped), got %d", len(got))
+	}
+	if got[0].Category != "app-ios-backups" || len(got[0].Entries) != 1 || got[0].Entries[0].Path != "/old" {
+		t.Errorf("expected only /old left in iOS backups, got %+v", got[0])
+	}
+	if got[0].TotalSize != 100 {
+		t.Errorf("expected iOS backups total 100, got %d", got[0].TotalSize)
+	}
+	if got[1].Category != "system-caches" || len(got[1].Entries) != 1 {
+		t.Errorf("expected system-caches untouched, got %+v", got[1])
+	}
+	// Input must not be modified.
+	if len(results[0].Entries) != 2 {
+		t.Error("applyKeepRecent modified its input")
+	}
+}
+
+func TestApplyKeepRecent_ZeroIsNoOp(t *testing.T) {
+	results := []scan.CategoryResult{{Category: "app-ios-backups", Entries: []scan.ScanEntry{{Path: "/a"}}}}
+	got := applyKeepRecent(results, 0)
+	if len(got) != 1 || len(got[0].Entries) != 1 {
+		t.Errorf("expected no change, got %+v", got)
+	}
+}
+
+func TestApplyExcludeNewerThan_WithholdsRecentChildren(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := now.Add(-48 * time.Hour)
+
+	oldPath := filepath.Join(dir, "old")
+	livePath := filepath.Join(dir, "live")
+	os.WriteFile(oldPath, []byte("old"), 0644)
+	os.Chtimes(oldPath, old, old)
+	os.WriteFile(livePath, []byte("live"), 0644)
+
+	results := []scan.CategoryResult{
+		{
+			Category: "system-caches",
+			Entries: []scan.ScanEntry{
+				{Path: oldPath, Size: 3},
+				{Path: livePath, Size: 4},
+			},
+			TotalSize: 7,
+		},
+		{
+			Category:  "dev-docker",
+			Entries:   []scan.ScanEntry{{Path: "docker:Images", Size: 10}},
+			TotalSize: 10,
+		},
+	}
+
+	got := applyExcludeNewerThan(results, time.Hour, now)
+
+	if len(got[0].Entries) != 1 || got[0].Entries[0].Path != oldPath {
+		t.Errorf("expected only old entry deletable, got %+v", got[0].Entries)
+	}
+	if got[0].TotalSize != 3 {
+		t.Errorf("expected total 3, got %d", got[0].TotalSize)
+	}
+	if len(got[0].RecentlyModified) != 1 || got[0].RecentlyModified[0].Path != livePath {
+		t.Errorf("expected live entry reported as recently modified, got %+v", got[0].RecentlyModified)
+	}
+	if len(got[1].Entries) != 1 {
+		t.Errorf("expected pseudo-path entry kept, got %+v", got[1].Entries)
+	}
+	// Input must not be modified.
+	if len(results[0].Entries) != 2 || len(results[0].RecentlyModified) != 0 {
+		t.Error("applyExcludeNewerThan modified its input")
+	}
+}
+
+func TestApplyExcludeNewerThan_ZeroIsNoOp(t *testing.T) {
+	results := []scan.CategoryResult{{Category: "system-caches", Entries: []scan.ScanEntry{{Path: t.TempDir()}}}}
+	got := applyExcludeNewerThan(results, 0, time.Now())
+	if len(got) != 1 || len(got[0].Entries) != 1 {
+		t.Errorf("expected no change, got %+v", got)
+	}
+}
+
+// fakeHome installs a home-info provider returning info for the test.
+func fakeHome(t *testing.T, info safety.HomeInfo) {
+	t.Helper()
+	orig := homeInfo
+	homeInfo = func() (safety.HomeInfo, error) { return info, nil }
+	t.Cleanup(func() {
+		homeInfo = orig
+		networkRoots = nil
+		flagSkipNetwork = false
+		scan.SetSkippedRoots(nil)
+	})
+}
+
+func TestPrepareHome_NetworkHomeWarnsAndSkips(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	fakeHome(t, safety.HomeInfo{Path: "/Network/Users/alex", Network: true, NetworkRoots: []string{"/Network/Users/alex"}})
+	flagSkipNetwork = true
+
+	var out bytes.Buffer
+	prepareHome(&out)
+	if !strings.Contains(out.String(), "/Network/Users/alex is on a network volume; skipping it") {
+		t.Errorf("expected network home warning, got: %s", out.String())
+	}
+
+	results := []scan.CategoryResult{
+		{
+			Category: "system-caches",
+			Entries: []scan.ScanEntry{
+				{Path: "/Network/Users/alex/Library/Caches/a", Size: 10},
+				{Path: "/private/var/folders/xy/C/com.apple.QuickLook.thumbnailcache", Size: 5},
+			},
+			TotalSize: 15,
+		},
+		{
+			Category:  "dev-npm",
+			Entries:   []scan.ScanEntry{{Path: "/Network/Users/alex/.npm/_cacache", Size: 20}},
+			TotalSize: 20,
+		},
+	}
+	got := applyEntryFilters(results)
+	if len(got) != 1 {
+		t.Fatalf("expected the all-network category dropped, got %+v", got)
+	}
+	if len(got[0].Entries) != 1 || got[0].TotalSize != 5 {
+		t.Errorf("expected only the local entry deletable, got %+v", got[0])
+	}
+	if len(results[0].Entries) != 2 {
+		t.Error("excludeNetworkPaths modified its input")
+	}
+}
+
+func TestPrepareHome_NetworkHomeWarnsWithoutSkip(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	fakeHome(t, safety.HomeInfo{Path: "/Network/Users/alex", Network: true, NetworkRoots: []string{"/Network/Users/alex"}})
+
+	var out bytes.Buffer
+	prepareHome(&out)
+	if !strings.Contains(out.String(), "Use --skip-network-paths") {
+		t.Errorf("expected hint about --skip-network-paths, got: %s", out.String())
+	}
+	if len(networkRoots) != 0 {
+		t.Errorf("expected nothing excluded without --skip-network-paths, got %v", networkRoots)
+	}
+}
+
+func TestPrepareHome_MobileAndLocal(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	fakeHome(t, safety.HomeInfo{Path: "/Users/alex", Mobile: true})
+
+	var out bytes.Buffer
+	prepareHome(&out)
+	if !strings.Contains(out.String(), "mobile account") {
+		t.Errorf("expected mobile account warning, got: %s", out.String())
+	}
+
+	fakeHome(t, safety.HomeInfo{Path: "/Users/alex"})
+	out.Reset()
+	prepareHome(&out)
+	if out.Len() != 0 {
+		t.Errorf("expected no warning for a local home, got: %s", out.String())
+	}
+}
+
+func TestPrintScanPaths_ListsDeveloperPaths(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	flagAbsolutePaths = true
+	defer func() { flagAbsolutePaths = false }()
+
+	eng = engine.New()
+	engine.RegisterDefaults(eng)
+
+	home := t.TempDir()
+	npmDir := filepath.Join(home, ".npm")
+	os.MkdirAll(npmDir, 0755)
+
+	var out bytes.Buffer
+	printScanPaths(&out, []string{"developer"}, home)
+
+	got := out.String()
+	if !strings.HasPrefix(got, "Developer Caches:\n") {
+		t.Errorf("expected scanner header, got: %s", got)
+	}
+	if !strings.Contains(got, "  "+npmDir+"\n") {
+		t.Errorf("expected existing npm dir listed without marker, got: %s", got)
+	}
+	yarnDir := filepath.Join(home, "Library", "Caches", "yarn")
+	if !strings.Contains(got, "  "+yarnDir+"  (missing)") {
+		t.Errorf("expected missing yarn dir marked, got: %s", got)
+	}
+}
+
+// --- freshness window tests ---
+
+func TestFreshnessWindows_RejectsUnknownCategory(t *testing.T) {
+	home := t.TempDir()
+	path := engine.FreshnessPath(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"dev-gradle": "72h", "gradel": "1h"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := freshnessWindows(home)
+	if err == nil || !strings.Contains(err.Error(), `unknown category "gradel"`) {
+		t.Errorf("expected unknown category error, got %v", err)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"dev-gradle": "72h"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	windows, err := freshnessWindows(home)
+	if err != nil || windows["dev-gradle"] != 72*time.Hour {
+		t.Errorf("expected dev-gradle window, got %v, %v", windows, err)
+	}
+}
+
+func TestMustLoadFreshness_ReadsAppliedHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	home := t.TempDir()
+	path := engine.FreshnessPath(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(`{"dev-gradle": "72h"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	safety.SetHome(home)
+	t.Cleanup(func() { safety.SetHome("") })
+
+	if windows := mustLoadFreshness(); windows["dev-gradle"] != 72*time.Hour {
+		t.Errorf("expected the --home freshness windows, got %v", windows)
+	}
+}
+
+// --- --strict tests ---
+
+// strictTestEngine returns an engine whose second scanner fails, between
+// two that succeed.
+func strictTestEngine() *engine.Engine {
+	e := engine.New()
+	e.Register(engine.NewScanner(engine.ScannerInfo{ID: "ok", Name: "OK"}, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{{Category: "ok-1", TotalSize: 100}}, nil
+	}))
+	e.Register(engine.NewScanner(engine.ScannerInfo{ID: "broken", Name: "Broken"}, func() ([]scan.CategoryResult, error) {
+		return nil, errors.New("disk error")
+	}))
+	e.Register(engine.NewScanner(engine.ScannerInfo{ID: "ok2", Name: "OK2"}, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{{Category: "ok2-1", TotalSize: 50}}, nil
+	}))
+	return e
+}
+
+func TestScanAll_StrictReturnsError(t *testing.T) {
+	flagJSON = true
+	flagStrict = true
+	defer func() {
+		flagJSON = false
+		flagStrict = false
+		eng = nil
+	}()
+	eng = strictTestEngine()
+	eng.Strict = true
+
+	var results []scan.CategoryResult
+	var err error
+	stderr := captureStderr(t, func() {
+		results, err = scanAll(spinner.New("", false))
+	})
+
+	var scanErr *engine.ScanError
+	if !errors.As(err, &scanErr) || scanErr.ScannerID != "broken" {
+		t.Fatalf("expected ScanError from broken scanner, got %v", err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected no partial results in strict mode, got %+v", results)
+	}
+	if strings.Contains(stderr, "Warning") {
+		t.Errorf("expected the error to be left to the caller, got stderr: %s", stderr)
+	}
+}
+
+// exitCalled is the panic value the osExit replacement stops Run with.
+type exitCalled int
+
+func TestRoot_StrictScanFailureExitsWithScanFailed(t *testing.T) {
+	flagStrict = true
+	eng = strictTestEngine()
+	eng.Strict = true
+	oldExit := osExit
+	osExit = func(code int) { panic(exitCalled(code)) }
+	defer func() {
+		flagStrict = false
+		eng = nil
+		osExit = oldExit
+	}()
+
+	code := -1
+	stderr := captureStderr(t, func() {
+		captureStdout(t, func() {
+			defer func() {
+				if c, ok := recover().(exitCalled); ok {
+					code = int(c)
+				}
+			}()
+			rootCmd.Run(rootCmd, nil)
+		})
+	})
+	if code != 4 {
+		t.Fatalf("exit status = %d, want 4", code)
+	}
+	if !strings.Contains(stderr, "disk error") || !strings.Contains(stderr, "--strict") {
+		t.Errorf("expected the scanner error on stderr, got %q", stderr)
+	}
+}
+
+func TestScanAll_BestEffortReturnsPartialResults(t *testing.T) {
+	flagJSON = true
+	defer func() {
+		flagJSON = false
+		eng = nil
+	}()
+	eng = strictTestEngine()
+
+	var results []scan.CategoryResult
+	var err error
+	stderr := captureStderr(t, func() {
+		results, err = scanAll(spinner.New("", false))
+	})
+
+	if err != nil {
+		t.Fatalf("expected no error without --strict, got %v", err)
+	}
+	if len(results) != 2 {
+		t.Errorf("expected 2 partial results, got %+v", results)
+	}
+	if !strings.Contains(stderr, "Warning: disk error") {
+		t.Errorf("expected a warning for the broken scanner, got stderr: %s", stderr)
+	}
+}
+
+func TestRunScannerByID_Strict(t *testing.T) {
+	flagJSON = true
+	defer func() {
+		flagJSON = false
+		flagStrict = false
+		eng = nil
+	}()
+	eng = strictTestEngine()
+	sp := spinner.New("", false)
+
+	captureStderr(t, func() {
+		if _, err := runScannerByID("broken", sp); err != nil {
+			t.Errorf("expected the error to be reported, not returned, got %v", err)
+		}
+	})
+
+	flagStrict = true
+	if _, err := runScannerByID("broken", sp); err == nil {
+		t.Error("expected --strict to return the scanner error")
+	}
+}
+
+// --- --locale tests ---
+
+func TestResolveLocale(t *testing.T) {
+	if l, err := resolveLocale(""); err != nil || l.Name != "en" {
+		t.Errorf("empty --locale: got %q, %v; want en", l.Name, err)
+	}
+	t.Setenv("LC_NUMERIC", "")
+	t.Setenv("LC_ALL", "fr_FR.UTF-8")
+	if l, err := resolveLocale("auto"); err != nil || l.Name != "fr" {
+		t.Errorf("--locale auto: got %q, %v; want fr from LC_ALL", l.Name, err)
+	}
+	if l, err := resolveLocale("de"); err != nil || l.Name != "de" {
+		t.Errorf("--locale de: got %q, %v", l.Name, err)
+	}
+	if _, err := resolveLocale("xx"); err == nil {
+		t.Error("expected an error for an unsupported --locale")
+	}
+}
+
+// --- --home tests ---
+
+func TestResolveHome(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, err := resolveHome(""); err != nil || got != "" {
+		t.Errorf(`resolveHome("") = %q, %v; want "", nil`, got, err)
+	}
+	if got, err := resolveHome(dir + "/"); err != nil || got != dir {
+		t.Errorf("resolveHome(%q) = %q, %v; want %q", dir+"/", got, err, dir)
+	}
+	for _, bad := range []string{filepath.Join(dir, "missing"), file, "/", "/Users", "/tmp"} {
+		if _, err := resolveHome(bad); err == nil {
+			t.Errorf("resolveHome(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestApplyHome_ScansAndContainsAlternateHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	other := t.TempDir()
+	npm := filepath.Join(other, ".npm")
+	if err := os.MkdirAll(npm, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(npm, "index"), make([]byte, 500), 0644); err != nil {
+		t.Fatal(err)
+	}
+	flagHome = other
+	eng = engine.New()
+	engine.RegisterDefaults(eng)
+	eng.NoExec = true
+	defer func() {
+		flagHome = ""
+		eng = nil
+		safety.SetHome("")
+	}()
+
+	applyHome()
+
+	if eng.Home != other {
+		t.Errorf("eng.Home = %q, want %q", eng.Home, other)
+	}
+	results, err := eng.Run(context.Background(), "developer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, cr := range results {
+		if cr.Category == "dev-npm" && len(cr.Entries) == 1 && cr.Entries[0].Path == filepath.Join(npm, "index") {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected dev-npm entry under %s, got %+v", npm, results)
+	}
+	if blocked, reason := safety.IsPathBlocked(filepath.Join(npm, "index")); blocked {
+		t.Errorf("expected cleanup allowed inside --home, blocked: %s", reason)
+	}
+}
@@ -1,19 +1,15 @@
 package cmd
 
 import (
-	"context"
 	"fmt"
 	"os"
-	"strings"
 
 	"github.com/fatih/color"
 	"github.com/spf13/cobra"
 
 	"github.com/sp3esu/mac-cleaner/internal/cleanup"
-	"github.com/sp3esu/mac-cleaner/internal/confirm"
 	"github.com/sp3esu/mac-cleaner/internal/engine"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
-	"github.com/sp3esu/mac-cleaner/internal/spinner"
 )
 
 var scanCmd = &cobra.Command{
@@ -28,6 +24,11 @@ Combine them freely: --dev-caches --safari scans all dev plus Safari only.
 Skip flags exclude items: --dev-caches --skip-docker scans all dev except Docker.
 Use --all to scan everything, then skip what you don't want.
 
+--category-include and --category-exclude select categories by a regular
+expression matched against their IDs. Include keeps only matching
+categories and, given alone, scans every group it matches; exclude drops
+matching categories and wins over include and the scan flags.
+
 At least one scan flag is required. Without flags, this help is shown.
 
 Examples:
@@ -36,11 +37,44 @@ Examples:
   mac-cleaner scan --dev-caches --safari               all dev + Safari
   mac-cleaner scan --dev-caches --skip-docker          all dev except Docker
   mac-cleaner scan --all --skip-docker --skip-safari   everything except Docker and Safari
+  mac-cleaner scan --category-include '^dev-' --category-exclude dev-docker
+                                                       all dev categories except Docker
   mac-cleaner scan --npm --json --dry-run              npm cache as JSON (no deletion)`,
 	PreRun: func(cmd *cobra.Command, args []string) {
 		eng = engine.New()
 		engine.RegisterDefaults(eng)
-
+		eng.AppDirs = flagAppDirs
+		eng.KeepLatestDeviceSupport = flagKeepLatestDS
+		eng.ProjectRoots = flagProjectRoots
+		eng.IncludeHidden = flagIncludeHidden
+		eng.CategoryOrder = categoryOrder()
+		eng.RootDeletable = flagSudo
+		eng.NoExec = flagNoExec
+		eng.Strict = flagStrict
+		eng.ScanTimeout = flagScanTimeout
+		scan.SetExpandBlobs(flagExpandBlobs)
+		eng.MaxDepth = flagMaxDepth
+		applyHome()
+		prepareHome(os.Stderr)
+		eng.Freshness = mustLoadFreshness()
+		applyIfBelow()
+
+		if flagCategoriesFile != "" {
+			ids, err := readCategoriesFile(flagCategoriesFile)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: --categories-file: %v\n", err)
+				os.Exit(1)
+			}
+			selectCategories(ids)
+		}
+		if err := applyEnvSelection(cmd, os.Getenv); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := compileCategoryPatterns(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
 		if flagAll {
 			for _, g := range scanGroups {
 				*g.ScanFlag = true
@@ -51,6 +85,7 @@ Examples:
 				*g.ScanFlag = false
 			}
 		}
+		applyOptIns()
 		if flagJSON {
 			color.NoColor = true
 		}
@@ -70,6 +105,9 @@ Examples:
 			}
 		}
 
+		if len(groupSet) == 0 && len(itemSet) == 0 && categoryInclude != nil {
+			groupSet = patternGroups()
+		}
 		if len(groupSet) == 0 && len(itemSet) == 0 {
 			_ = cmd.Help()
 			return
@@ -84,7 +122,7 @@ Examples:
 			scannersToRun[sid] = true
 		}
 
-		sp := spinner.New("Scanning...", !flagJSON)
+		sp := newSpinner("Scanning...")
 		skipSet := buildSkipSet()
 		var allResults []scan.CategoryResult
 
@@ -111,14 +149,19 @@ Examples:
 
 			// Run the scanner.
 			info := findScannerInfo(g.ScannerID)
-			sp.UpdateMessage("Scanning " + strings.ToLower(info.Name) + "...")
-			sp.Start()
-			results, err := eng.Run(context.Background(), g.ScannerID)
-			sp.Stop()
+			stop := startScanSpinner(sp, info.Name)
+			ctx, cancel := scanContext()
+			results, err := eng.Run(ctx, g.ScannerID)
+			cancel()
+			stop()
 			if err != nil {
+				if flagStrict {
+					exitStrict(err)
+				}
 				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 				continue
 			}
+			results = applyEntryFilters(results)
 
 			// Filter to targeted items only (if not full group).
 			if !isGroup {
@@ -134,41 +177,50 @@ Examples:
 			// Apply skip filtering.
 			results = engine.FilterSkipped(results, skipSet)
 
-			if !flagJSON && len(results) > 0 {
+			if !flagJSON && !flagPreviewRisky && len(results) > 0 {
 				printResults(results, flagDryRun, info.Name)
 			}
 
 			allResults = append(allResults, results...)
 		}
+		engine.SortCategories(allResults, eng.CategoryOrder)
+
+		if flagManifest != "" {
+			mustWriteManifest(allResults)
+			return
+		}
+
+		if flagPreviewRisky {
+			if flagJSON {
+				printJSON(riskyOnly(allResults))
+			} else {
+				printRiskyPreview(os.Stdout, allResults)
+			}
+			return
+		}
 
 		if !flagJSON {
 			printPermissionIssues(allResults)
 		}
 
 		if flagJSON {
-			printJSON(allResults)
 			if flagDryRun {
-				return
+				printJSON(allResults)
+			} else {
+				runJSONCleanup(os.Stdin, os.Stdout, sp, allResults)
 			}
+			return
 		}
 
-		if flagDryRun && !flagJSON {
+		if flagDryRun {
 			printDryRunSummary(os.Stdout, allResults)
 			return
 		}
 
 		if !flagDryRun && len(allResults) > 0 {
-			if !flagForce {
-				if !confirm.PromptConfirmation(os.Stdin, os.Stdout, allResults) {
-					fmt.Println("Aborted.")
-					return
-				}
+			if toClean := withholdReportOnly(os.Stderr, allResults); len(toClean) > 0 {
+				runCleanup(os.Stdin, os.Stdout, sp, toClean)
 			}
-			sp.UpdateMessage("Cleaning up...")
-			sp.Start()
-			result := cleanup.Execute(allResults, cleanupProgress(sp, os.Stderr))
-			sp.Stop()
-			printCleanupSummary(os.Stdout, result)
 		}
 	},
 }
@@ -179,6 +231,9 @@ func init() {
 		scanCmd.Flags().BoolVar(g.ScanFlag, g.FlagName, false, "scan "+g.Description)
 	}
 	scanCmd.Flags().BoolVar(&flagAll, "all", false, "scan all categories")
+	scanCmd.Flags().StringVar(&flagCategoriesFile, "categories-file", "", "scan the category IDs listed in a file, one per line (# starts a comment)")
+	scanCmd.Flags().StringVar(&flagCategoryInclude, "category-include", "", "keep only the categories whose ID matches this regular expression")
+	scanCmd.Flags().StringVar(&flagCategoryExclude, "category-exclude", "", "drop the categories whose ID matches this regular expression (wins over --category-include)")
 
 	// Targeted item scan flags.
 	for _, g := range scanGroups {
@@ -206,6 +261,28 @@ func init() {
 	// Output flags.
 	scanCmd.Flags().BoolVar(&flagJSON, "json", false, "output results as JSON")
 	scanCmd.Flags().BoolVar(&flagVerbose, "verbose", false, "show detailed file listing")
+	scanCmd.Flags().BoolVar(&flagCompact, "compact", false, "print one line per category (automatic on narrow terminals)")
+	scanCmd.Flags().StringVar(&flagManifest, "manifest", "", "write a JSON manifest of every item found (category, path, size, mtime, risk, SHA-256 of the first 4KB) to FILE for review; nothing is deleted")
+	scanCmd.Flags().BoolVar(&flagPreviewRisky, "preview-risky", false, "list only the risky entries a cleanup would include, with their total; never deletes")
+	scanCmd.Flags().BoolVar(&flagNoSpinner, "no-spinner", false, "print plain status lines instead of the animated spinner (automatic when output is not a terminal)")
+	scanCmd.Flags().IntVar(&flagKeepRecent, "keep-recent", 0, "always keep the N newest items in time-based categories (old Downloads, iOS backups)")
+	scanCmd.Flags().BoolVar(&flagKeepLatestDS, "keep-latest-devicesupport", false, "never offer the newest Xcode iOS DeviceSupport version of each device family for deletion")
+	scanCmd.Flags().DurationVar(&flagExcludeNewer, "exclude-newer-than", 0, "withhold items containing changes newer than this age (e.g. 1h) from deletion")
+	scanCmd.Flags().BoolVar(&flagAbsolutePaths, "absolute-paths", false, "show full paths instead of shortening the home directory to ~")
+	scanCmd.Flags().StringSliceVar(&flagAppDirs, "app-dir", nil, "extra directory to search for unused applications (repeatable)")
+	scanCmd.Flags().StringVar(&flagHome, "home", "", "scan this home directory instead of your own, e.g. another account's for an audit; deletions stay inside it")
+	scanCmd.Flags().StringSliceVar(&flagProjectRoots, "project-root", nil, "directory searched for stale node_modules and Python environments instead of ~/Developer, ~/Projects and ~/Documents/code (repeatable)")
+	scanCmd.Flags().Var(&flagCoalesceUnder, "coalesce-under", "group categories smaller than this size (e.g. 100MB) into one \"Other\" row in the summary")
+	scanCmd.Flags().BoolVar(&flagIncludeHidden, "include-hidden", false, "also consider hidden (dot-prefixed) entries in ~/Downloads and hidden directories under the project roots")
+	scanCmd.Flags().BoolVar(&flagExpandBlobs, "expand-blobs", false, "list the top-level entries of caches normally shown as one blob (yarn, pnpm, Mail, Messages, iOS updates) instead; VM bundles stay whole")
+	scanCmd.Flags().IntVar(&flagMaxDepth, "max-depth", 0, "size cache entries only this many directory levels deep for a fast overview; deeper content is left out and marked (0, the default, sizes everything)")
+	scanCmd.Flags().BoolVar(&flagNoExec, "no-exec", false, "run no external commands (docker, brew, tmutil, mdls, PlistBuddy); categories that need them are skipped or sized from disk")
+	scanCmd.Flags().BoolVar(&flagStrict, "strict", false, "stop at the first scanner error and exit with status 4 instead of reporting partial results")
+	scanCmd.Flags().DurationVar(&flagRemovalTO, "removal-timeout", cleanup.DefaultEntryTimeout, "give up on an item whose removal takes longer than this and move on (0 waits)")
+	scanCmd.Flags().BoolVar(&flagSkipNetwork, "skip-network-paths", false, "do not size or delete anything on a network-backed home directory")
+	scanCmd.Flags().Var(&flagIfBelow, "if-below", "only run when free space on the home volume is below this percentage (e.g. 10%) or size (e.g. 20GB)")
+	scanCmd.Flags().BoolVar(&flagSudo, "sudo", false, "delete root-owned items with sudo, asking for the password once")
+	scanCmd.Flags().BoolVar(&flagVerify, "verify", false, "after cleanup, compare the reported bytes freed with the measured change in free disk space")
 	scanCmd.Flags().BoolVar(&flagForce, "force", false, "bypass confirmation prompt (for automation)")
 
 	scanCmd.SetUsageFunc(scanUsageFunc)
@@ -225,6 +302,9 @@ func scanUsageFunc(cmd *cobra.Command) error {
 		fmt.Fprintf(w, "  --%-24s %s\n", g.FlagName, "scan "+g.Description)
 	}
 	fmt.Fprintf(w, "  --%-24s %s\n", "all", "scan all categories")
+	fmt.Fprintf(w, "  --%-24s %s\n", "categories-file", "scan the category IDs listed in a file, one per line (# starts a comment)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "category-include RE", "keep only the categories whose ID matches this regular expression")
+	fmt.Fprintf(w, "  --%-24s %s\n", "category-exclude RE", "drop the categories whose ID matches this regular expression (wins over --category-include)")
 
 	// Targeted Scans sections (one per group with items).
 	for _, g := range scanGroups {
@@ -261,8 +341,38 @@ func scanUsageFunc(cmd *cobra.Command) error {
 	fmt.Fprintf(w, "\nOutput Options:\n")
 	fmt.Fprintf(w, "  --%-24s %s\n", "json", "output results as JSON")
 	fmt.Fprintf(w, "  --%-24s %s\n", "verbose", "show detailed file listing")
+	fmt.Fprintf(w, "  --%-24s %s\n", "compact", "print one line per category (automatic on narrow terminals)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "manifest FILE", "write a JSON manifest of every item found (category, path, size, mtime, risk, SHA-256 of the first 4KB) to FILE for review; nothing is deleted")
+	fmt.Fprintf(w, "  --%-24s %s\n", "preview-risky", "list only the risky entries a cleanup would include, with their total; never deletes")
+	fmt.Fprintf(w, "  --%-24s %s\n", "no-spinner", "print plain status lines instead of the animated spinner (automatic when output is not a terminal)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "keep-recent N", "always keep the N newest items in time-based categories (old Downloads, iOS backups)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "keep-latest-devicesupport", "never offer the newest Xcode iOS DeviceSupport version of each device family for deletion")
+	fmt.Fprintf(w, "  --%-24s %s\n", "exclude-newer-than D", "withhold items containing changes newer than this age (e.g. 1h) from deletion")
+	fmt.Fprintf(w, "  --%-24s %s\n", "absolute-paths", "show full paths instead of shortening the home directory to ~")
+	fmt.Fprintf(w, "  --%-24s %s\n", "app-dir DIR", "extra directory to search for unused applications (repeatable)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "home DIR", "scan this home directory instead of your own, e.g. another account's for an audit; deletions stay inside it")
+	fmt.Fprintf(w, "  --%-24s %s\n", "project-root DIR", "directory searched for stale node_modules and Python environments instead of ~/Developer, ~/Projects and ~/Documents/code (repeatable)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "coalesce-under", "group categories smaller than this size (e.g. 100MB) into one \"Other\" row in the summary")
+	fmt.Fprintf(w, "  --%-24s %s\n", "include-hidden", "also consider hidden (dot-prefixed) entries in ~/Downloads and hidden directories under the project roots")
+	fmt.Fprintf(w, "  --%-24s %s\n", "expand-blobs", "list the top-level entries of caches normally shown as one blob (yarn, pnpm, Mail, Messages, iOS updates) instead; VM bundles stay whole")
+	fmt.Fprintf(w, "  --%-24s %s\n", "max-depth N", "size cache entries only this many directory levels deep for a fast overview; deeper content is left out and marked (0, the default, sizes everything)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "no-exec", "run no external commands (docker, brew, tmutil, mdls, PlistBuddy); categories that need them are skipped or sized from disk")
+	fmt.Fprintf(w, "  --%-24s %s\n", "strict", "stop at the first scanner error and exit with status 4 instead of reporting partial results")
+	fmt.Fprintf(w, "  --%-24s %s\n", "removal-timeout D", "give up on an item whose removal takes longer than this and move on (0 waits)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "skip-network-paths", "do not size or delete anything on a network-backed home directory")
+	fmt.Fprintf(w, "  --%-24s %s\n", "if-below", "only run when free space on the home volume is below this percentage (e.g. 10%) or size (e.g. 20GB)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "sudo", "delete root-owned items with sudo, asking for the password once")
+	fmt.Fprintf(w, "  --%-24s %s\n", "verify", "after cleanup, compare the reported bytes freed with the measured change in free disk space")
 	fmt.Fprintf(w, "  --%-24s %s\n", "force", "bypass confirmation prompt (for automation)")
 	fmt.Fprintf(w, "  --%-24s %s\n", "dry-run", "preview what would be removed without deleting")
+	fmt.Fprintf(w, "  --%-24s %s\n", "report-only", "scan and report only; refuse all cleanup (policy control)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "accept-risk", "acknowledge that deletions are permanent (required with --force on first run)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "log-level", "diagnostic log level on stderr: debug, info, warn or error")
+	fmt.Fprintf(w, "  --%-24s %s\n", "log-json", "write diagnostic logs as JSON lines")
+	fmt.Fprintf(w, "  --%-24s %s\n", "locale LANG", "format sizes and dates for a locale: en, de, fr, pl, ru, uk, or auto to follow LANG")
+	fmt.Fprintf(w, "  --%-24s %s\n", "on-disk-size", "count the disk blocks files occupy (Finder's \"on disk\" size) instead of their logical size")
+	fmt.Fprintf(w, "  --%-24s %s\n", "scan-timeout DUR", "stop scanning after this long and report what was found so far (e.g. 2m; 0 disables)")
+	fmt.Fprintf(w, "  --%-24s %s\n", "confirm-format FMT", "how the confirmation before deletion is shown: rich (default) or plain, uncolored with one item per line")
 
 	fmt.Fprintln(w)
 	return nil
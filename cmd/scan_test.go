@@ -1,8 +1,12 @@
 package cmd
 
 import (
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/sp3esu/mac-cleaner/internal/engine"
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 )
 
@@ -87,8 +91,8 @@ func TestScanGroups_TargetedFlagsCount(t *testing.T) {
 			}
 		}
 	}
-	if count != 41 {
-		t.Errorf("expected 41 targeted scan flags, got %d", count)
+	if count != 62 {
+		t.Errorf("expected 62 targeted scan flags, got %d", count)
 	}
 }
 
@@ -103,11 +107,11 @@ func TestScanGroups_SkipFlagsCount(t *testing.T) {
 			}
 		}
 	}
-	// 41 item-level skip flags + 1 dual-purpose (unused-apps group skip == item skip)
-	// = 42 unique skip mappings, but unused-apps shares the pointer with the group skip
-	// so unique SkipFlag pointers across items = 42
-	if count != 42 {
-		t.Errorf("expected 42 unique skip flag pointers across items, got %d", count)
+	// 62 item-level skip flags + 1 dual-purpose (unused-apps group skip == item skip)
+	// = 63 unique skip mappings, but unused-apps shares the pointer with the group skip
+	// so unique SkipFlag pointers across items = 63
+	if count != 63 {
+		t.Errorf("expected 63 unique skip flag pointers across items, got %d", count)
 	}
 }
 
@@ -154,6 +158,26 @@ func TestScanGroups_NoDuplicateCategoryIDs(t *testing.T) {
 	}
 }
 
+func TestCategoryOrder_CoversRegistry(t *testing.T) {
+	order := categoryOrder()
+	if order[0] != scanGroups[0].Items[0].CategoryID {
+		t.Errorf("order starts with %q, want first scanGroups item", order[0])
+	}
+	listed := map[string]bool{}
+	for _, id := range order {
+		listed[id] = true
+	}
+	e := engine.New()
+	engine.RegisterDefaults(e)
+	for _, info := range e.Categories() {
+		for _, id := range info.CategoryIDs {
+			if !listed[id] {
+				t.Errorf("registry category %q missing from categoryOrder", id)
+			}
+		}
+	}
+}
+
 func TestScanGroups_AllCategoryIDsHaveRisk(t *testing.T) {
 	for _, g := range scanGroups {
 		for _, item := range g.Items {
@@ -218,10 +242,12 @@ func TestFlagForCategory_PhotosAndSystemData(t *testing.T) {
 		{"photos-syndication", "--photos"},
 		{"sysdata-spotlight", "--system-data"},
 		{"sysdata-mail", "--system-data"},
+		{"sysdata-mail-envelope", "--system-data"},
 		{"sysdata-mail-downloads", "--system-data"},
 		{"sysdata-messages", "--system-data"},
 		{"sysdata-ios-updates", "--system-data"},
 		{"sysdata-timemachine", "--system-data"},
+		{"sysdata-unified-logs", "--system-data"},
 		{"sysdata-vm-parallels", "--system-data"},
 		{"sysdata-vm-utm", "--system-data"},
 		{"sysdata-vm-vmware", "--system-data"},
@@ -293,6 +319,151 @@ func TestBuildSkipSet_UnusedApps(t *testing.T) {
 	}
 }
 
+// --- --category-include / --category-exclude tests ---
+
+// setCategoryPatterns compiles include and exclude as the pattern flags
+// and restores them when the test ends.
+func setCategoryPatterns(t *testing.T, include, exclude string) error {
+	t.Helper()
+	t.Cleanup(func() {
+		flagCategoryInclude, flagCategoryExclude = "", ""
+		categoryInclude, categoryExclude = nil, nil
+	})
+	flagCategoryInclude, flagCategoryExclude = include, exclude
+	return compileCategoryPatterns()
+}
+
+// keptCategories returns the category IDs buildSkipSet leaves in.
+func keptCategories() []string {
+	skip := buildSkipSet()
+	var kept []string
+	for _, id := range categoryOrder() {
+		if !skip[id] {
+			kept = append(kept, id)
+		}
+	}
+	return kept
+}
+
+func TestCategoryPatterns_IncludeAndExclude(t *testing.T) {
+	resetSkipFlags()
+	defer resetSkipFlags()
+	if err := setCategoryPatterns(t, "^dev-", "dev-docker"); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"dev-xcode", "dev-xcode-index", "dev-npm", "dev-yarn", "dev-homebrew",
+		"dev-brew-autoremove", "dev-pnpm", "dev-cocoapods", "dev-gradle", "dev-pip",
+		"dev-simulator-caches", "dev-simulator-logs", "dev-xcode-device-support",
+		"dev-xcode-archives", "dev-mobiledevice", "dev-node-modules", "dev-pyenvs",
+	}
+	if got := keptCategories(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("kept categories:\n got %v\nwant %v", got, want)
+	}
+	if groups := patternGroups(); len(groups) != 1 || !groups["developer"] {
+		t.Errorf("patternGroups() = %v, want only developer", groups)
+	}
+}
+
+func TestCategoryPatterns_ExcludeWins(t *testing.T) {
+	resetSkipFlags()
+	defer resetSkipFlags()
+	if err := setCategoryPatterns(t, "^(dev|msg)-", "^(dev|msg)-"); err != nil {
+		t.Fatal(err)
+	}
+	if got := keptCategories(); len(got) != 0 {
+		t.Errorf("expected exclude to win over include, kept %v", got)
+	}
+}
+
+func TestCategoryPatterns_ComposeWithSkipFlags(t *testing.T) {
+	resetSkipFlags()
+	defer resetSkipFlags()
+	if err := setCategoryPatterns(t, "", "^browser-"); err != nil {
+		t.Fatal(err)
+	}
+	flagSkipNpm = true
+
+	skip := buildSkipSet()
+	for _, id := range []string{"dev-npm", "browser-safari", "browser-chrome"} {
+		if !skip[id] {
+			t.Errorf("expected %q in skip set", id)
+		}
+	}
+	if skip["dev-yarn"] || skip["msg-slack"] {
+		t.Error("expected categories matched by neither pattern nor skip flag to stay")
+	}
+}
+
+func TestCompileCategoryPatterns_Errors(t *testing.T) {
+	if err := setCategoryPatterns(t, "dev-(", ""); err == nil || !strings.Contains(err.Error(), "--category-include") {
+		t.Errorf("invalid include: got %v", err)
+	}
+	if err := setCategoryPatterns(t, "", "[z-a]"); err == nil || !strings.Contains(err.Error(), "--category-exclude") {
+		t.Errorf("invalid exclude: got %v", err)
+	}
+	if err := setCategoryPatterns(t, "^nope-", ""); err == nil || !strings.Contains(err.Error(), "matches no category") {
+		t.Errorf("include matching nothing: got %v", err)
+	}
+}
+
+// --- --categories-file tests ---
+
+func TestReadCategoriesFile_SelectsValidIDs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "categories.txt")
+	content := "# weekly cleanup\n\ndev-npm\nbrowser-safari  # keep history\nunused-apps\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids, err := readCategoriesFile(path)
+	if err != nil {
+		t.Fatalf("readCategoriesFile: %v", err)
+	}
+	want := []string{"dev-npm", "browser-safari", "unused-apps"}
+	if strings.Join(ids, ",") != strings.Join(want, ",") {
+		t.Fatalf("expected %v, got %v", want, ids)
+	}
+
+	defer func() {
+		flagScanNpm, flagScanSafari, flagUnusedApps = false, false, false
+	}()
+	selectCategories(ids)
+	if !flagScanNpm || !flagScanSafari {
+		t.Error("expected --npm and --safari selected")
+	}
+	if !flagUnusedApps {
+		t.Error("expected unused-apps to select its group")
+	}
+	if flagScanYarn {
+		t.Error("expected unlisted categories to stay unselected")
+	}
+}
+
+func TestParseCategoryList_ReportsUnknownLines(t *testing.T) {
+	input := "dev-npm\ndev-nmp\n# comment\nbrowser-safari\nnot-a-category\n"
+	ids, err := parseCategoryList(strings.NewReader(input), "cats.txt")
+	if err == nil {
+		t.Fatalf("expected an error, got ids %v", ids)
+	}
+	msg := err.Error()
+	for _, want := range []string{`cats.txt:2: unknown category "dev-nmp"`, `cats.txt:5: unknown category "not-a-category"`} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected %q in error, got: %s", want, msg)
+		}
+	}
+	if strings.Contains(msg, "dev-npm\"") || strings.Contains(msg, "browser-safari") {
+		t.Errorf("valid IDs reported as errors: %s", msg)
+	}
+}
+
+func TestReadCategoriesFile_Missing(t *testing.T) {
+	if _, err := readCategoriesFile(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
 // --- scan command help (no flags) ---
 
 func TestScanCmd_NoFlags_ShowsHelp(t *testing.T) {
@@ -330,6 +501,20 @@ func TestScanCmd_InheritsRootPersistentFlags(t *testing.T) {
 	}
 }
 
+func TestApplyOptIns_SelectedByCategoriesFile(t *testing.T) {
+	eng = engine.New()
+	t.Cleanup(func() {
+		flagScanTmpCaches, flagScanNodeModules, flagScanPyEnvs = false, false, false
+	})
+
+	selectCategories([]string{"system-tmp-caches", "dev-node-modules", "dev-pyenvs"})
+	applyOptIns()
+	if !eng.ScanTmpCaches || !eng.ScanNodeModules || !eng.ScanPyEnvs {
+		t.Errorf("expected opt-ins selected by the categories file on the engine, got tmp=%v node=%v py=%v",
+			eng.ScanTmpCaches, eng.ScanNodeModules, eng.ScanPyEnvs)
+	}
+}
+
 // resetSkipFlags sets all item-level skip flags to false.
 func resetSkipFlags() {
 	for _, g := range scanGroups {
@@ -6,14 +6,21 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
 	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
 	"github.com/sp3esu/mac-cleaner/internal/server"
 )
 
-var flagSocket string
+var (
+	flagSocket   string
+	flagCacheTTL time.Duration
+)
 
 var serveCmd = &cobra.Command{
 	Use:   "serve",
@@ -27,9 +34,14 @@ var serveCmd = &cobra.Command{
 		sigCh := make(chan os.Signal, 1)
 		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
-		eng := engine.New()
-		engine.RegisterDefaults(eng)
+		eng, err := newServeEngine()
+		if err != nil {
+			return err
+		}
 		srv := server.New(flagSocket, version, eng)
+		srv.ReportOnly = flagReportOnly
+		srv.Reload = newServeEngine
+		srv.SaveDisabled = saveServeDisabled
 
 		go func() {
 			<-sigCh
@@ -43,7 +55,65 @@ var serveCmd = &cobra.Command{
 	},
 }
 
+// newServeEngine builds the server's engine from the serve flags and the
+// configuration files in the home directory: the freshness windows and the
+// disabled scanners. The reload method calls it again to pick up changes
+// to either file.
+func newServeEngine() (*engine.Engine, error) {
+	eng := engine.New()
+	engine.RegisterDefaults(eng)
+	eng.CacheTTL = flagCacheTTL
+	eng.ScanTimeout = flagScanTimeout
+	eng.AppDirs = flagAppDirs
+	eng.ScanTmpCaches = flagScanTmpCaches
+	eng.KeepLatestDeviceSupport = flagKeepLatestDS
+	eng.ScanNodeModules = flagScanNodeModules
+	eng.ScanPyEnvs = flagScanPyEnvs
+	eng.ProjectRoots = flagProjectRoots
+	eng.IncludeHidden = flagIncludeHidden
+	eng.NoExec = flagNoExec
+	eng.RemovalTimeout = flagRemovalTO
+	eng.CategoryOrder = categoryOrder()
+	scan.SetExpandBlobs(flagExpandBlobs)
+	home, err := safety.Home()
+	if err != nil {
+		return nil, err
+	}
+	windows, err := freshnessWindows(home)
+	if err != nil {
+		return nil, err
+	}
+	eng.Freshness = windows
+	disabled, err := engine.LoadDisabledScanners(home)
+	if err != nil {
+		return nil, err
+	}
+	eng.SetDisabled(disabled)
+	return eng, nil
+}
+
+// saveServeDisabled persists the scanners disabled through set_enabled
+// so newServeEngine restores them.
+func saveServeDisabled(ids []string) error {
+	home, err := safety.Home()
+	if err != nil {
+		return err
+	}
+	return engine.SaveDisabledScanners(home, ids)
+}
+
 func init() {
 	serveCmd.Flags().StringVar(&flagSocket, "socket", "/tmp/mac-cleaner.sock", "Unix domain socket path")
+	serveCmd.Flags().DurationVar(&flagCacheTTL, "cache-ttl", 0, "reuse results of identical scans for this long (0 disables)")
+	serveCmd.Flags().StringSliceVar(&flagAppDirs, "app-dir", nil, "extra directory to search for unused applications (repeatable)")
+	serveCmd.Flags().BoolVar(&flagScanTmpCaches, "tmp-caches", false, "also scan temporary app caches in /private/var/folders (opt-in)")
+	serveCmd.Flags().BoolVar(&flagScanNodeModules, "node-modules", false, "also scan stale node_modules (90+ days) under the project roots (opt-in)")
+	serveCmd.Flags().BoolVar(&flagScanPyEnvs, "pyenvs", false, "also scan stale Python virtualenvs and __pycache__ (90+ days) under the project roots (opt-in)")
+	serveCmd.Flags().BoolVar(&flagNoExec, "no-exec", false, "run no external commands (docker, brew, tmutil, mdls, PlistBuddy); categories that need them are skipped or sized from disk")
+	serveCmd.Flags().DurationVar(&flagRemovalTO, "removal-timeout", cleanup.DefaultEntryTimeout, "give up on an item whose removal takes longer than this and move on (0 waits)")
+	serveCmd.Flags().StringSliceVar(&flagProjectRoots, "project-root", nil, "directory searched for stale node_modules and Python environments instead of ~/Developer, ~/Projects and ~/Documents/code (repeatable)")
+	serveCmd.Flags().BoolVar(&flagIncludeHidden, "include-hidden", false, "also consider hidden (dot-prefixed) entries in ~/Downloads and hidden directories under the project roots")
+	serveCmd.Flags().BoolVar(&flagExpandBlobs, "expand-blobs", false, "list the top-level entries of caches normally shown as one blob (yarn, pnpm, Mail, Messages, iOS updates) instead; VM bundles stay whole")
+	serveCmd.Flags().BoolVar(&flagKeepLatestDS, "keep-latest-devicesupport", false, "never offer the newest Xcode iOS DeviceSupport version of each device family for deletion")
 	rootCmd.AddCommand(serveCmd)
 }
@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"testing"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+func TestNewServeEngine_AppliesExpandBlobs(t *testing.T) {
+	safety.SetHome(t.TempDir())
+	flagExpandBlobs = true
+	defer func() {
+		flagExpandBlobs = false
+		scan.SetExpandBlobs(false)
+		safety.SetHome("")
+	}()
+
+	if _, err := newServeEngine(); err != nil {
+		t.Fatalf("newServeEngine: %v", err)
+	}
+	if !scan.ExpandBlobs() {
+		t.Error("serve ignores --expand-blobs")
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"io"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// scannedVolume returns the path whose volume the scan measured: the
+// --home directory, or the current user's home.
+func scannedVolume() string {
+	if eng != nil && eng.Home != "" {
+		return eng.Home
+	}
+	home, _ := safety.Home()
+	return home
+}
+
+// sizeEstimateUnreliable reports whether a reclaimable total is
+// implausible for the scanned volume (see scan.ImplausibleTotal). A
+// volume whose space cannot be read gives no verdict.
+func sizeEstimateUnreliable(total int64) bool {
+	avail, capacity, err := volumeSpace(scannedVolume())
+	if err != nil {
+		return false
+	}
+	return scan.ImplausibleTotal(total, capacity-avail, capacity)
+}
+
+// printSizeCaveat warns on w when total is implausible for the scanned
+// volume, so an inflated figure is not taken at face value.
+func printSizeCaveat(w io.Writer, total int64) {
+	if !sizeEstimateUnreliable(total) {
+		return
+	}
+	_, _ = color.New(color.FgYellow).Fprintf(w,
+		"  Caveat: %s is more than this volume plausibly holds; cloned or sparse files were likely counted at full size, so less space will be freed.\n",
+		scan.FormatSize(total))
+}
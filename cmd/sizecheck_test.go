@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+func TestPrintDryRunSummary_ImplausibleTotalCaveat(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	// 500 GB volume with 100 GB in use.
+	fakeVolume(t, 400_000_000_000, 500_000_000_000, nil)
+
+	results := []scan.CategoryResult{
+		{Category: "a", Description: "Clones", TotalSize: 180_000_000_000},
+		{Category: "b", Description: "Sparse", TotalSize: 20_000_000_000},
+	}
+	var buf bytes.Buffer
+	printDryRunSummary(&buf, results)
+	if !strings.Contains(buf.String(), "Caveat: 200.0 GB is more than this volume plausibly holds") {
+		t.Errorf("expected caveat for a total above the used space, got:\n%s", buf.String())
+	}
+	if !sizeEstimateUnreliable(200_000_000_000) {
+		t.Error("expected size_estimate_unreliable for a total above the used space")
+	}
+}
+
+func TestPrintDryRunSummary_PlausibleTotalNoCaveat(t *testing.T) {
+	color.NoColor = true
+	defer func() { color.NoColor = false }()
+	fakeVolume(t, 400_000_000_000, 500_000_000_000, nil)
+
+	results := []scan.CategoryResult{
+		{Category: "a", Description: "Caches", TotalSize: 8_000_000_000},
+		{Category: "b", Description: "Logs", TotalSize: 2_000_000_000},
+	}
+	var buf bytes.Buffer
+	printDryRunSummary(&buf, results)
+	if strings.Contains(buf.String(), "Caveat") {
+		t.Errorf("expected no caveat for a plausible total, got:\n%s", buf.String())
+	}
+	if sizeEstimateUnreliable(10_000_000_000) {
+		t.Error("expected a plausible total not flagged")
+	}
+}
@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// flagHuman makes total print a formatted size instead of bytes.
+var flagHuman bool
+
+var totalCmd = &cobra.Command{
+	Use:   "total [flags]",
+	Short: "print only the number of reclaimable bytes",
+	Long: `Scan every category and print only the total number of reclaimable bytes,
+for menu-bar apps and shell prompts. With --human the size is formatted
+instead (e.g. "12.4 GB").
+
+Skip flags, --category-include and --category-exclude narrow the total
+as they do for scan; opt-in categories count only when enabled.
+Report-only categories, items cleanup cannot remove and items withheld by
+the freshness windows are not counted. Nothing else is written to stdout
+and nothing is deleted.
+
+Examples:
+  mac-cleaner total                         e.g. 13316861952
+  mac-cleaner total --human                 e.g. 12.4 GB
+  mac-cleaner total --skip-docker --human   everything except Docker`,
+	Args: cobra.NoArgs,
+	PreRun: func(cmd *cobra.Command, args []string) {
+		eng = engine.New()
+		engine.RegisterDefaults(eng)
+		eng.AppDirs = flagAppDirs
+		eng.ScanTmpCaches = flagScanTmpCaches
+		eng.ScanNodeModules = flagScanNodeModules
+		eng.ScanPyEnvs = flagScanPyEnvs
+		eng.ProjectRoots = flagProjectRoots
+		eng.IncludeHidden = flagIncludeHidden
+		eng.NoExec = flagNoExec
+		eng.ScanTimeout = flagScanTimeout
+		applyHome()
+		prepareHome(os.Stderr)
+		eng.Freshness = mustLoadFreshness()
+
+		if err := compileCategoryPatterns(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runTotal(context.Background(), os.Stdout); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	totalCmd.Flags().BoolVar(&flagHuman, "human", false, "print a formatted size (e.g. 12.4 GB) instead of bytes")
+	totalCmd.Flags().StringVar(&flagCategoryInclude, "category-include", "", "keep only the categories whose ID matches this regular expression")
+	totalCmd.Flags().StringVar(&flagCategoryExclude, "category-exclude", "", "drop the categories whose ID matches this regular expression (wins over --category-include)")
+	for _, g := range scanGroups {
+		totalCmd.Flags().BoolVar(g.SkipFlag, "skip-"+g.FlagName, false, "skip "+g.Description+" scanning")
+	}
+	for _, g := range scanGroups {
+		for _, item := range g.Items {
+			if item.FlagName != "" && item.SkipFlag != nil {
+				totalCmd.Flags().BoolVar(item.SkipFlag, "skip-"+item.FlagName, false, "skip "+item.Description)
+			}
+		}
+	}
+	totalCmd.Flags().StringSliceVar(&flagAppDirs, "app-dir", nil, "extra directory to search for unused applications (repeatable)")
+	totalCmd.Flags().BoolVar(&flagScanTmpCaches, "tmp-caches", false, "also count temporary app caches in /private/var/folders (opt-in)")
+	totalCmd.Flags().BoolVar(&flagScanNodeModules, "node-modules", false, "also count stale node_modules (90+ days) under the project roots (opt-in)")
+	totalCmd.Flags().BoolVar(&flagScanPyEnvs, "pyenvs", false, "also count stale Python virtualenvs and __pycache__ (90+ days) under the project roots (opt-in)")
+	totalCmd.Flags().StringSliceVar(&flagProjectRoots, "project-root", nil, "directory searched for stale node_modules and Python environments instead of ~/Developer, ~/Projects and ~/Documents/code (repeatable)")
+	totalCmd.Flags().BoolVar(&flagIncludeHidden, "include-hidden", false, "also consider hidden (dot-prefixed) entries in ~/Downloads and hidden directories under the project roots")
+	totalCmd.Flags().StringVar(&flagHome, "home", "", "scan this home directory instead of your own")
+	totalCmd.Flags().BoolVar(&flagNoExec, "no-exec", false, "run no external commands (docker, brew, tmutil, mdls, PlistBuddy); categories that need them are skipped or sized from disk")
+	rootCmd.AddCommand(totalCmd)
+}
+
+// totalSkipSet returns the categories total leaves out: those of
+// buildSkipSet plus every category of a group skipped as a whole.
+func totalSkipSet() map[string]bool {
+	skip := buildSkipSet()
+	for _, g := range scanGroups {
+		if g.SkipFlag == nil || !*g.SkipFlag {
+			continue
+		}
+		for _, item := range g.Items {
+			skip[item.CategoryID] = true
+		}
+	}
+	return skip
+}
+
+// runTotal prints to w the bytes a cleanup of every category not skipped
+// would free: report-only categories, entries cleanup cannot remove and
+// entries withheld by the freshness windows are left out.
+func runTotal(ctx context.Context, w io.Writer) error {
+	est, err := eng.EstimateReclaimable(ctx, totalSkipSet())
+	if err != nil {
+		return err
+	}
+	printTotal(w, est.Reclaimable(), flagHuman)
+	return nil
+}
+
+// printTotal writes bytes to w as a bare integer, or as a formatted size
+// when human is set, followed by a newline and nothing else.
+func printTotal(w io.Writer, bytes int64, human bool) {
+	if human {
+		fmt.Fprintln(w, scan.FormatSize(bytes))
+		return
+	}
+	fmt.Fprintln(w, bytes)
+}
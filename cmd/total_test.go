@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// totalEngine returns an engine with one scanner reporting two categories.
+func totalEngine() *engine.Engine {
+	e := engine.New()
+	e.Register(engine.NewScanner(engine.ScannerInfo{ID: "caches", Name: "Caches"}, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{
+			{Category: "system-caches", Description: "User App Caches", TotalSize: 12_000_000_000,
+				Entries: []scan.ScanEntry{{Path: "/a", Size: 12_000_000_000}}},
+			{Category: "system-logs", Description: "User Logs", TotalSize: 400_000_000,
+				Entries: []scan.ScanEntry{{Path: "/b", Size: 400_000_000}}},
+		}, nil
+	}))
+	return e
+}
+
+func TestPrintTotal_BareInteger(t *testing.T) {
+	est, err := totalEngine().EstimateReclaimable(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("EstimateReclaimable: %v", err)
+	}
+	var out bytes.Buffer
+	printTotal(&out, est.TotalSize, false)
+	if got := out.String(); got != "12400000000\n" {
+		t.Errorf("output = %q, want a bare integer", got)
+	}
+}
+
+func TestPrintTotal_Human(t *testing.T) {
+	est, err := totalEngine().EstimateReclaimable(context.Background(), map[string]bool{"system-logs": true})
+	if err != nil {
+		t.Fatalf("EstimateReclaimable: %v", err)
+	}
+	var out bytes.Buffer
+	printTotal(&out, est.TotalSize, true)
+	if got := out.String(); got != "12.0 GB\n" {
+		t.Errorf("output = %q, want only the formatted size", got)
+	}
+}
+
+func TestTotalSkipSet_GroupSkip(t *testing.T) {
+	g := scanGroups[0]
+	*g.SkipFlag = true
+	t.Cleanup(func() { *g.SkipFlag = false })
+
+	skip := totalSkipSet()
+	for _, item := range g.Items {
+		if !skip[item.CategoryID] {
+			t.Errorf("expected %s skipped with its group", item.CategoryID)
+		}
+	}
+}
+
+func TestRunTotal_CountsOnlyWhatCleanupFrees(t *testing.T) {
+	fresh := filepath.Join(t.TempDir(), "today.log")
+	if err := os.WriteFile(fresh, make([]byte, 300), 0644); err != nil {
+		t.Fatal(err)
+	}
+	eng = engine.New()
+	defer func() { eng = nil }()
+	eng.Freshness = map[string]time.Duration{"system-logs": time.Hour}
+	eng.Register(engine.NewScanner(engine.ScannerInfo{ID: "mixed", Name: "Mixed"}, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{
+			{Category: "system-caches", TotalSize: 150, Entries: []scan.ScanEntry{
+				{Path: "/a", Size: 100},
+				{Path: "docker:images", Size: 50},
+			}},
+			{Category: "system-logs", TotalSize: 300, Entries: []scan.ScanEntry{{Path: fresh, Size: 300}}},
+			{Category: "sysdata-mail", TotalSize: 200, ReportOnly: true, Entries: []scan.ScanEntry{{Path: "/m", Size: 200}}},
+		}, nil
+	}))
+
+	var out bytes.Buffer
+	if err := runTotal(context.Background(), &out); err != nil {
+		t.Fatalf("runTotal: %v", err)
+	}
+	if got := out.String(); got != "100\n" {
+		t.Errorf("output = %q, want only the deletable, settled, cleanable bytes (100)", got)
+	}
+}
@@ -2,15 +2,17 @@ module github.com/sp3esu/mac-cleaner
 
 go 1.25.7
 
-require github.com/spf13/cobra v1.10.2
+require (
+	github.com/briandowns/spinner v1.23.2
+	github.com/fatih/color v1.18.0
+	github.com/spf13/cobra v1.10.2
+	golang.org/x/term v0.1.0
+)
 
 require (
-	github.com/briandowns/spinner v1.23.2 // indirect
-	github.com/fatih/color v1.18.0 // indirect
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
 	github.com/spf13/pflag v1.0.9 // indirect
 	golang.org/x/sys v0.25.0 // indirect
-	golang.org/x/term v0.1.0 // indirect
 )
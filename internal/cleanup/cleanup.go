@@ -4,9 +4,15 @@
 package cleanup
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
@@ -28,20 +34,277 @@ type CleanupResult struct {
 	BytesFreed int64
 	// Errors holds individual error details for failed items.
 	Errors []error
+	// SudoRemoved and SudoBytesFreed count the part of Removed and
+	// BytesFreed that was deleted with sudo.
+	SudoRemoved    int
+	SudoBytesFreed int64
+	// PerCategory maps each category ID to the bytes freed from it. Its
+	// values sum to BytesFreed; categories that freed nothing are absent.
+	PerCategory map[string]int64
 }
 
-// Execute removes all entries from the given scan results. Each path is
-// re-checked against the safety blocklist before deletion. Pseudo-paths
-// (e.g. "docker:...") are skipped. Errors on individual items do not
-// abort the overall operation.
+// credit counts one removed entry of size bytes from category.
+func (r *CleanupResult) credit(category string, size int64) {
+	r.Removed++
+	r.BytesFreed += size
+	if size == 0 {
+		return
+	}
+	if r.PerCategory == nil {
+		r.PerCategory = make(map[string]int64)
+	}
+	r.PerCategory[category] += size
+}
+
+// CmdRunner executes an external command and returns its standard output.
+// It is injected so privileged deletion can be mocked in tests.
+type CmdRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// SudoRunner is the production CmdRunner for privileged deletion. It
+// connects the terminal so sudo can prompt for a password.
+func SudoRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204 -- name is always "sudo"; arguments are built by this package from scanned paths
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	return cmd.Output()
+}
+
+// defaultRunner is the production CmdRunner for command-backed entries
+// and image compaction.
+func defaultRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec G204 -- commands come from the fixed pseudoCommands table or CompactCommands
+	return cmd.Output()
+}
+
+// pseudoCommands maps pseudo-paths that are cleaned by running a command,
+// rather than being skipped, to that command.
+var pseudoCommands = map[string][]string{
+	"brew:autoremove": {"brew", "autoremove"},
+}
+
+// RemoveFunc deletes path and returns how many bytes the deletion freed.
+type RemoveFunc func(path string) (int64, error)
+
+// removePath is the production RemoveFunc. It measures path while
+// removing it, in a single walk: each file is sized with scan.FileSize and
+// deleted as it is visited, then the emptied directories are removed
+// deepest first. Dataless directories are removed whole without being
+// walked, so the removal never downloads them, and like dataless files
+// they count as freeing nothing. A path that is already gone frees
+// nothing.
+func removePath(path string) (int64, error) {
+	var freed int64
+	var dirs []string
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() {
+			if scan.IsDataless(info) {
+				if err := os.RemoveAll(p); err != nil && !os.IsNotExist(err) {
+					return err
+				}
+				return filepath.SkipDir
+			}
+			dirs = append(dirs, p)
+			return nil
+		}
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if info.Mode().IsRegular() && !scan.IsDataless(info) {
+			freed += scan.FileSize(info)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := os.Remove(dirs[i]); err != nil && !os.IsNotExist(err) {
+			return 0, err
+		}
+	}
+	return freed, nil
+}
+
+// WatchdogFactor is how many times the scanned total a cleanup may free
+// before the watchdog aborts it. Freeing that much more than was scanned
+// means something other than the scanned items is being deleted, e.g. a
+// path that now resolves elsewhere.
+const WatchdogFactor = 4
+
+// watchdogSlack is freed on top of the scanned total without tripping the
+// watchdog, so items that grew a little since a small scan do not abort it.
+const watchdogSlack = 256 << 20
+
+// ErrWatchdog is wrapped by the error recorded when the watchdog aborts a
+// cleanup.
+var ErrWatchdog = errors.New("cleanup watchdog tripped")
+
+// watchdogTripped reports whether freed bytes exceed what a cleanup of
+// scanned bytes may plausibly free.
+func watchdogTripped(freed, scanned int64) bool {
+	return freed > scanned*WatchdogFactor && freed > scanned+watchdogSlack
+}
+
+// ErrRemovalTimeout is wrapped by the error recorded for an entry whose
+// removal did not finish within Options.EntryTimeout.
+var ErrRemovalTimeout = errors.New("removal timed out")
+
+// DefaultEntryTimeout is the per-entry removal timeout the CLI and server
+// use unless configured otherwise. It is long enough for a large directory
+// on a local disk and short enough that a hung mount does not stall a
+// cleanup for good.
+const DefaultEntryTimeout = 10 * time.Minute
+
+// callWithTimeout runs fn and returns its error, or ErrRemovalTimeout if
+// timeout passes first; timeout <= 0 waits for fn. fn gets a context that
+// is cancelled at the timeout, which kills commands started with
+// exec.CommandContext. A removal blocked in the kernel, e.g. on a hung
+// network mount, cannot be interrupted: its goroutine is left to finish
+// in the background and its result is discarded.
+func callWithTimeout(timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(context.Background())
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- fn(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ErrRemovalTimeout
+	}
+}
+
+// Options configures ExecuteWithOptions.
+type Options struct {
+	// Runner runs the commands for pseudo-paths in pseudoCommands. Nil
+	// uses os/exec.
+	Runner CmdRunner
+	// Sudo, when set, removes entries flagged RequiresRoot by running
+	// "sudo -n rm -rf -- <path>" through it; sudo must already hold a
+	// cached credential (see ValidateSudo). All other entries, and every
+	// entry when Sudo is nil, are removed directly.
+	Sudo CmdRunner
+	// Remove deletes entries removed directly and reports the bytes each
+	// freed, which the watchdog compares against the scanned total. Nil
+	// measures each path before removing it.
+	Remove RemoveFunc
+	// EntryTimeout bounds each entry's removal, including sudo and
+	// command-backed entries. An entry that takes longer is counted as
+	// failed with an ErrRemovalTimeout error and cleanup moves on. Zero
+	// waits for every removal.
+	EntryTimeout time.Duration
+	// ExtraRoots are directories, besides those safety.CheckAllowedRoot
+	// always allows, under which entries may be removed, such as the
+	// --app-dir directories.
+	ExtraRoots []string
+	// AllowDocuments lists the entries the user confirmed deleting although
+	// they look like they hold documents (see CheckDocuments). Every other
+	// such entry is left alone and counted as failed under an
+	// ErrLooksLikeDocuments error.
+	AllowDocuments []string
+}
+
+// ValidateRoots checks every filesystem path in results against
+// safety.CheckAllowedRoot before anything is deleted and returns the
+// refusal reason of each path that escapes the allowed roots, keyed by
+// path. Pseudo-paths are not checked. An empty map means every path may
+// proceed to the per-entry safety checks.
+func ValidateRoots(results []scan.CategoryResult, extraRoots []string) map[string]string {
+	refused := map[string]string{}
+	for _, cat := range results {
+		for _, entry := range cat.Entries {
+			if isPseudoPath(entry.Path) {
+				continue
+			}
+			if ok, reason := safety.CheckAllowedRoot(entry.Path, extraRoots); !ok {
+				refused[entry.Path] = reason
+			}
+		}
+	}
+	return refused
+}
+
+// ValidateSudo runs "sudo -v" through runner so the password is asked for
+// once, before deletion starts, and cached for the privileged removals.
+func ValidateSudo(runner CmdRunner) error {
+	if _, err := runner(context.Background(), "sudo", "-v"); err != nil {
+		return fmt.Errorf("sudo: %w", err)
+	}
+	return nil
+}
+
+// NeedsRoot reports whether any entry in results is flagged RequiresRoot.
+func NeedsRoot(results []scan.CategoryResult) bool {
+	for _, cat := range results {
+		for _, entry := range cat.Entries {
+			if entry.RequiresRoot {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Execute removes all entries from the given scan results. Before anything
+// is removed, every path is validated with ValidateRoots; paths that
+// escape the allowed roots are refused. Each path is also re-checked
+// against the safety blocklist before deletion, and skipped if
+// its file/directory kind no longer matches ScanEntry.IsDir. Pseudo-paths
+// (e.g. "docker:...") are skipped, except "brew:autoremove", which runs
+// "brew autoremove". Errors on individual items do not abort the overall
+// operation, and with Options.EntryTimeout set neither does a removal that
+// hangs. Directories rated safe that look like they hold the user's
+// documents are left alone (see Options.AllowDocuments). If the bytes
+// actually freed grow past WatchdogFactor
+// times the scanned total, the remaining entries are left alone and
+// counted as failed under an ErrWatchdog error.
 func Execute(results []scan.CategoryResult, onProgress ProgressFunc) CleanupResult {
+	return ExecuteWithOptions(results, onProgress, Options{})
+}
+
+// ExecuteWithOptions is Execute with privileged deletion configured by
+// opts. Root-flagged entries pass the same safety re-checks before being
+// handed to sudo.
+func ExecuteWithOptions(results []scan.CategoryResult, onProgress ProgressFunc, opts Options) CleanupResult {
 	var res CleanupResult
 
 	var total int
+	var scanned int64
 	for _, cat := range results {
 		total += len(cat.Entries)
+		for _, entry := range cat.Entries {
+			scanned += entry.Size
+		}
+	}
+	remove := opts.Remove
+	if remove == nil {
+		remove = removePath
+	}
+	refused := ValidateRoots(results, opts.ExtraRoots)
+	allowDocs := make(map[string]bool, len(opts.AllowDocuments))
+	for _, path := range opts.AllowDocuments {
+		allowDocs[path] = true
 	}
 
+	// measured is what deletions report freeing, as opposed to BytesFreed,
+	// which counts the scanned sizes.
+	var measured int64
 	current := 0
 	for _, cat := range results {
 		if onProgress != nil {
@@ -52,6 +315,26 @@ func Execute(results []scan.CategoryResult, onProgress ProgressFunc) CleanupResu
 			if onProgress != nil {
 				onProgress(cat.Description, entry.Path, current, total)
 			}
+			// Pseudo-paths backed by a command are cleaned by running it.
+			if command, ok := pseudoCommands[entry.Path]; ok {
+				runner := opts.Runner
+				if runner == nil {
+					runner = defaultRunner
+				}
+				err := callWithTimeout(opts.EntryTimeout, func(ctx context.Context) error {
+					_, err := runner(ctx, command[0], command[1:]...)
+					return err
+				})
+				if err != nil {
+					res.Failed++
+					res.Errors = append(res.Errors, fmt.Errorf("%s: %w", strings.Join(command, " "), err))
+					continue
+				}
+				res.credit(cat.Category, entry.Size)
+				measured += entry.Size
+				continue
+			}
+
 			// Skip pseudo-paths that are informational only.
 			if isPseudoPath(entry.Path) {
 				res.Failed++
@@ -59,6 +342,12 @@ func Execute(results []scan.CategoryResult, onProgress ProgressFunc) CleanupResu
 				continue
 			}
 
+			if reason, ok := refused[entry.Path]; ok {
+				res.Failed++
+				res.Errors = append(res.Errors, fmt.Errorf("refused: %s (%s)", entry.Path, reason))
+				continue
+			}
+
 			// Re-check safety at deletion time.
 			if blocked, reason := safety.IsPathBlocked(entry.Path); blocked {
 				res.Failed++
@@ -66,21 +355,77 @@ func Execute(results []scan.CategoryResult, onProgress ProgressFunc) CleanupResu
 				continue
 			}
 
-			err := os.RemoveAll(entry.Path)
-			if err != nil && !os.IsNotExist(err) {
+			// Skip paths replaced by a different kind of entry since the
+			// scan (e.g. a file swapped for a directory).
+			if info, err := os.Lstat(entry.Path); err == nil && info.IsDir() != entry.IsDir {
+				res.Failed++
+				res.Errors = append(res.Errors, fmt.Errorf("skip %s: type changed since scan", entry.Path))
+				continue
+			}
+
+			if !allowDocs[entry.Path] {
+				if d, ok := CheckDocuments(cat.Category, entry); ok {
+					res.Failed++
+					res.Errors = append(res.Errors, documentsError(entry.Path, d))
+					continue
+				}
+			}
+
+			if entry.RequiresRoot && opts.Sudo != nil {
+				err := callWithTimeout(opts.EntryTimeout, func(ctx context.Context) error {
+					_, err := opts.Sudo(ctx, "sudo", "-n", "rm", "-rf", "--", entry.Path)
+					return err
+				})
+				if err != nil {
+					res.Failed++
+					res.Errors = append(res.Errors, fmt.Errorf("sudo rm %s: %w", entry.Path, err))
+					continue
+				}
+				res.credit(cat.Category, entry.Size)
+				measured += entry.Size
+				res.SudoRemoved++
+				res.SudoBytesFreed += entry.Size
+				continue
+			}
+
+			var freed int64
+			err := callWithTimeout(opts.EntryTimeout, func(context.Context) error {
+				var err error
+				freed, err = remove(entry.Path)
+				return err
+			})
+			if err != nil {
 				res.Failed++
 				res.Errors = append(res.Errors, fmt.Errorf("remove %s: %w", entry.Path, err))
 				continue
 			}
 
-			res.Removed++
-			res.BytesFreed += entry.Size
+			measured += freed
+			res.credit(cat.Category, entry.Size)
+			if watchdogTripped(measured, scanned) {
+				res.Failed += total - current
+				res.Errors = append(res.Errors, fmt.Errorf("%w: %s freed against %s scanned; %d remaining items left untouched",
+					ErrWatchdog, scan.FormatSize(measured), scan.FormatSize(scanned), total-current))
+				return res
+			}
 		}
 	}
 
 	return res
 }
 
+// Deletable reports whether Execute can remove entry: a filesystem path,
+// or a pseudo-path cleaned by a command such as "brew:autoremove".
+// Entries flagged RequiresRoot count only when sudo is true, since
+// removing them without elevation fails.
+func Deletable(entry scan.ScanEntry, sudo bool) bool {
+	if isPseudoPath(entry.Path) {
+		_, ok := pseudoCommands[entry.Path]
+		return ok
+	}
+	return !entry.RequiresRoot || sudo
+}
+
 // isPseudoPath returns true for paths that represent non-filesystem entries
 // (e.g. Docker resource identifiers like "docker:BuildCache").
 // Real filesystem paths on macOS always start with "/".
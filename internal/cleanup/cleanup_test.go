@@ -1,10 +1,17 @@
 package cleanup
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -47,6 +54,82 @@ func TestExecuteRemovesFiles(t *testing.T) {
 	}
 }
 
+func TestExecuteInvalidUTF8Name(t *testing.T) {
+	tmp := t.TempDir()
+	name := "cache-\xff\xfe.bin"
+	path := filepath.Join(tmp, name)
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Skipf("filesystem rejects invalid UTF-8 names: %v", err)
+	}
+	results := []scan.CategoryResult{{
+		Category:  "test",
+		Entries:   []scan.ScanEntry{{Path: path, Description: name, Size: 4}},
+		TotalSize: 4,
+	}}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if !json.Valid(data) || !utf8.Valid(data) {
+		t.Fatalf("JSON is not valid UTF-8 JSON: %q", data)
+	}
+	if !strings.Contains(string(data), "cache-\uFFFD.bin") {
+		t.Errorf("expected the invalid bytes replaced by U+FFFD, got %s", data)
+	}
+
+	res := Execute(results, nil)
+	if res.Removed != 1 || res.Failed != 0 {
+		t.Fatalf("Removed = %d, Failed = %d (%v); want the real path deleted", res.Removed, res.Failed, res.Errors)
+	}
+	if _, err := os.Lstat(path); !os.IsNotExist(err) {
+		t.Errorf("file with invalid UTF-8 name should be deleted")
+	}
+}
+
+func TestExecutePerCategory(t *testing.T) {
+	tmp := t.TempDir()
+	cache := filepath.Join(tmp, "cache.bin")
+	logA := filepath.Join(tmp, "a.log")
+	logB := filepath.Join(tmp, "b.log")
+	os.WriteFile(cache, make([]byte, 300), 0644)
+	os.WriteFile(logA, make([]byte, 20), 0644)
+	os.WriteFile(logB, make([]byte, 30), 0644)
+
+	results := []scan.CategoryResult{
+		{
+			Category: "system-caches",
+			Entries: []scan.ScanEntry{
+				{Path: cache, Size: 300},
+				{Path: "docker:BuildCache", Size: 999},
+			},
+		},
+		{
+			Category: "system-logs",
+			Entries: []scan.ScanEntry{
+				{Path: logA, Size: 20},
+				{Path: logB, Size: 30},
+			},
+		},
+	}
+
+	res := Execute(results, nil)
+
+	if res.PerCategory["system-caches"] != 300 {
+		t.Errorf("system-caches freed = %d, want 300", res.PerCategory["system-caches"])
+	}
+	if res.PerCategory["system-logs"] != 50 {
+		t.Errorf("system-logs freed = %d, want 50", res.PerCategory["system-logs"])
+	}
+	var sum int64
+	for _, freed := range res.PerCategory {
+		sum += freed
+	}
+	if sum != res.BytesFreed {
+		t.Errorf("per-category sum = %d, want BytesFreed %d", sum, res.BytesFreed)
+	}
+}
+
 func TestExecuteRemovesDirectories(t *testing.T) {
 	tmp := t.TempDir()
 	nested := filepath.Join(tmp, "dir", "subdir")
@@ -59,7 +142,7 @@ func TestExecuteRemovesDirectories(t *testing.T) {
 			Category:    "test",
 			Description: "Test",
 			Entries: []scan.ScanEntry{
-				{Path: topDir, Description: "dir", Size: 4},
+				{Path: topDir, Description: "dir", Size: 4, IsDir: true},
 			},
 			TotalSize: 4,
 		},
@@ -145,6 +228,53 @@ func TestExecuteBlockedPath(t *testing.T) {
 	}
 }
 
+func TestExecuteRefusesPathOutsideAllowedRoots(t *testing.T) {
+	home := t.TempDir()
+	safety.SetHome(home)
+	t.Cleanup(func() { safety.SetHome("") })
+
+	outside := t.TempDir()
+	victim := filepath.Join(outside, "victim")
+	os.WriteFile(victim, []byte("keep"), 0644)
+	link := filepath.Join(home, "Library", "Caches", "escape")
+	os.MkdirAll(filepath.Dir(link), 0755)
+	if err := os.Symlink(outside, link); err != nil {
+		t.Fatal(err)
+	}
+	cache := filepath.Join(home, "Library", "Caches", "stale")
+	os.WriteFile(cache, []byte("stale"), 0644)
+
+	results := []scan.CategoryResult{
+		{
+			Category:    "test",
+			Description: "Test",
+			Entries: []scan.ScanEntry{
+				{Path: filepath.Join(link, "victim"), Description: "crafted", Size: 4},
+				{Path: cache, Description: "stale", Size: 5},
+			},
+			TotalSize: 9,
+		},
+	}
+	if refused := ValidateRoots(results, nil); len(refused) != 1 {
+		t.Fatalf("ValidateRoots refused %v, want only the crafted entry", refused)
+	}
+
+	res := Execute(results, nil)
+
+	if res.Removed != 1 || res.Failed != 1 {
+		t.Errorf("Removed = %d, Failed = %d, want 1 and 1", res.Removed, res.Failed)
+	}
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Error(), "refused:") {
+		t.Errorf("Errors = %v, want one refusal", res.Errors)
+	}
+	if _, err := os.Stat(victim); err != nil {
+		t.Errorf("file outside the allowed roots should survive: %v", err)
+	}
+	if _, err := os.Stat(cache); !os.IsNotExist(err) {
+		t.Errorf("entry under home should be deleted")
+	}
+}
+
 func TestExecuteAlreadyGone(t *testing.T) {
 	// Use a path under a temp dir (which is under the home or /private/var/folders/)
 	// so it passes the home containment check. The path itself does not exist.
@@ -295,6 +425,29 @@ func TestIsPseudoPath(t *testing.T) {
 	}
 }
 
+func TestDeletable(t *testing.T) {
+	tests := []struct {
+		name  string
+		entry scan.ScanEntry
+		sudo  bool
+		want  bool
+	}{
+		{name: "filesystem path", entry: scan.ScanEntry{Path: "/Users/foo/Library/Caches/x"}, want: true},
+		{name: "docker pseudo-path", entry: scan.ScanEntry{Path: "docker:BuildCache"}, want: false},
+		{name: "command-backed pseudo-path", entry: scan.ScanEntry{Path: "brew:autoremove"}, want: true},
+		{name: "root-owned without sudo", entry: scan.ScanEntry{Path: "/Library/Caches/x", RequiresRoot: true}, want: false},
+		{name: "root-owned with sudo", entry: scan.ScanEntry{Path: "/Library/Caches/x", RequiresRoot: true}, sudo: true, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Deletable(tt.entry, tt.sudo); got != tt.want {
+				t.Errorf("Deletable(%+v, %v) = %v, want %v", tt.entry, tt.sudo, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestExecuteProgressCallbackNil(t *testing.T) {
 	tmp := t.TempDir()
 	f := filepath.Join(tmp, "file.txt")
@@ -317,3 +470,362 @@ func TestExecuteProgressCallbackNil(t *testing.T) {
 		t.Errorf("Removed = %d, want 1", res.Removed)
 	}
 }
+
+func TestExecuteSkipsFileReplacedByDirectory(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "cache.db")
+
+	// Scanned as a file, then swapped for a directory before cleanup.
+	os.MkdirAll(path, 0755)
+	os.WriteFile(filepath.Join(path, "inner.txt"), []byte("keep"), 0644)
+
+	results := []scan.CategoryResult{
+		{
+			Category:    "test",
+			Description: "Test",
+			Entries: []scan.ScanEntry{
+				{Path: path, Description: "cache.db", Size: 10, IsDir: false},
+			},
+		},
+	}
+
+	res := Execute(results, nil)
+
+	if res.Removed != 0 || res.Failed != 1 {
+		t.Errorf("Removed = %d, Failed = %d, want 0 and 1", res.Removed, res.Failed)
+	}
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Error(), "type changed since scan") {
+		t.Errorf("expected type-changed error, got %v", res.Errors)
+	}
+	if _, err := os.Stat(filepath.Join(path, "inner.txt")); err != nil {
+		t.Errorf("replacement directory should survive: %v", err)
+	}
+}
+
+func TestExecuteSkipsDirectoryReplacedByFile(t *testing.T) {
+	tmp := t.TempDir()
+	path := filepath.Join(tmp, "Cache")
+	os.WriteFile(path, []byte("now a file"), 0644)
+
+	results := []scan.CategoryResult{
+		{
+			Category:    "test",
+			Description: "Test",
+			Entries: []scan.ScanEntry{
+				{Path: path, Description: "Cache", Size: 10, IsDir: true},
+			},
+		},
+	}
+
+	res := Execute(results, nil)
+
+	if res.Removed != 0 || res.Failed != 1 {
+		t.Errorf("Removed = %d, Failed = %d, want 0 and 1", res.Removed, res.Failed)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("replacement file should survive: %v", err)
+	}
+}
+
+func TestExecuteWithOptionsSudo(t *testing.T) {
+	tmp := t.TempDir()
+	userFile := filepath.Join(tmp, "user.txt")
+	rootDir := filepath.Join(tmp, "root-owned")
+	os.WriteFile(userFile, []byte("hello"), 0644)
+	os.MkdirAll(rootDir, 0755)
+
+	var calls [][]string
+	runner := func(_ context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, append([]string{name}, args...))
+		return nil, nil
+	}
+
+	results := []scan.CategoryResult{
+		{
+			Category: "test",
+			Entries: []scan.ScanEntry{
+				{Path: userFile, Size: 5},
+				{Path: rootDir, Size: 100, IsDir: true, RequiresRoot: true},
+			},
+		},
+	}
+
+	res := ExecuteWithOptions(results, nil, Options{Sudo: runner})
+
+	if res.Removed != 2 || res.BytesFreed != 105 {
+		t.Errorf("Removed = %d, BytesFreed = %d, want 2 and 105", res.Removed, res.BytesFreed)
+	}
+	if res.SudoRemoved != 1 || res.SudoBytesFreed != 100 {
+		t.Errorf("SudoRemoved = %d, SudoBytesFreed = %d, want 1 and 100", res.SudoRemoved, res.SudoBytesFreed)
+	}
+	want := "sudo -n rm -rf -- " + rootDir
+	if len(calls) != 1 || strings.Join(calls[0], " ") != want {
+		t.Fatalf("runner calls = %v, want [%s]", calls, want)
+	}
+	if _, err := os.Stat(userFile); !os.IsNotExist(err) {
+		t.Error("unprivileged entry should be removed directly")
+	}
+	if _, err := os.Stat(rootDir); err != nil {
+		t.Error("root-flagged entry should be left to the runner")
+	}
+}
+
+func TestExecuteWithOptionsSudoFailure(t *testing.T) {
+	rootDir := filepath.Join(t.TempDir(), "root-owned")
+	os.MkdirAll(rootDir, 0755)
+
+	runner := func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("a password is required")
+	}
+	results := []scan.CategoryResult{
+		{Category: "test", Entries: []scan.ScanEntry{{Path: rootDir, Size: 100, IsDir: true, RequiresRoot: true}}},
+	}
+
+	res := ExecuteWithOptions(results, nil, Options{Sudo: runner})
+
+	if res.Removed != 0 || res.Failed != 1 || res.SudoRemoved != 0 {
+		t.Errorf("Removed = %d, Failed = %d, SudoRemoved = %d, want 0, 1, 0", res.Removed, res.Failed, res.SudoRemoved)
+	}
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Error(), "sudo rm") {
+		t.Errorf("Errors = %v, want one sudo rm error", res.Errors)
+	}
+}
+
+func TestExecuteWithoutSudoRemovesRootFlaggedDirectly(t *testing.T) {
+	rootDir := filepath.Join(t.TempDir(), "root-owned")
+	os.MkdirAll(rootDir, 0755)
+
+	results := []scan.CategoryResult{
+		{Category: "test", Entries: []scan.ScanEntry{{Path: rootDir, Size: 100, IsDir: true, RequiresRoot: true}}},
+	}
+
+	res := Execute(results, nil)
+
+	if res.Removed != 1 || res.SudoRemoved != 0 {
+		t.Errorf("Removed = %d, SudoRemoved = %d, want 1 and 0", res.Removed, res.SudoRemoved)
+	}
+}
+
+func TestRemovePathMeasuresWhileRemoving(t *testing.T) {
+	tmp := t.TempDir()
+	outside := filepath.Join(tmp, "outside.bin")
+	os.WriteFile(outside, make([]byte, 1000), 0644)
+	root := filepath.Join(tmp, "cache")
+	os.MkdirAll(filepath.Join(root, "a", "b"), 0755)
+	os.WriteFile(filepath.Join(root, "top.bin"), make([]byte, 100), 0644)
+	os.WriteFile(filepath.Join(root, "a", "b", "deep.bin"), make([]byte, 200), 0644)
+	os.Symlink(outside, filepath.Join(root, "a", "link"))
+
+	freed, err := removePath(root)
+	if err != nil {
+		t.Fatalf("removePath: %v", err)
+	}
+	if freed != 300 {
+		t.Errorf("freed = %d, want 300 (symlink targets are not counted)", freed)
+	}
+	if _, err := os.Lstat(root); !os.IsNotExist(err) {
+		t.Errorf("expected %s removed, stat err: %v", root, err)
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Errorf("symlink target must survive: %v", err)
+	}
+
+	if freed, err := removePath(root); err != nil || freed != 0 {
+		t.Errorf("removing a missing path: freed = %d, err = %v, want 0 and nil", freed, err)
+	}
+}
+
+func TestExecuteWatchdogAbortsRunawayDelete(t *testing.T) {
+	tmp := t.TempDir()
+	results := []scan.CategoryResult{
+		{
+			Category:    "test",
+			Description: "Test",
+			Entries: []scan.ScanEntry{
+				{Path: filepath.Join(tmp, "a"), Size: 100},
+				{Path: filepath.Join(tmp, "b"), Size: 100},
+				{Path: filepath.Join(tmp, "c"), Size: 100},
+			},
+		},
+	}
+	var removed []string
+	remove := func(path string) (int64, error) {
+		removed = append(removed, path)
+		// The first deletion frees far more than was scanned.
+		return 1 << 30, nil
+	}
+
+	res := ExecuteWithOptions(results, nil, Options{Remove: remove})
+
+	if len(removed) != 1 {
+		t.Fatalf("expected cleanup to stop after the first deletion, removed %v", removed)
+	}
+	if res.Removed != 1 || res.Failed != 2 {
+		t.Errorf("Removed = %d, Failed = %d, want 1 and 2", res.Removed, res.Failed)
+	}
+	if len(res.Errors) != 1 || !errors.Is(res.Errors[0], ErrWatchdog) {
+		t.Errorf("expected watchdog error, got %v", res.Errors)
+	}
+}
+
+func TestExecuteWatchdogToleratesSmallGrowth(t *testing.T) {
+	tmp := t.TempDir()
+	results := []scan.CategoryResult{
+		{
+			Category: "test",
+			Entries: []scan.ScanEntry{
+				{Path: filepath.Join(tmp, "a"), Size: 100},
+				{Path: filepath.Join(tmp, "b"), Size: 100},
+			},
+		},
+	}
+	// Items that grew since the scan free more than their scanned size,
+	// but stay within the slack.
+	remove := func(string) (int64, error) { return 10 << 20, nil }
+
+	res := ExecuteWithOptions(results, nil, Options{Remove: remove})
+
+	if res.Removed != 2 || res.Failed != 0 {
+		t.Errorf("Removed = %d, Failed = %d, want 2 and 0: %v", res.Removed, res.Failed, res.Errors)
+	}
+}
+
+func TestExecuteEntryTimeoutMovesOn(t *testing.T) {
+	tmp := t.TempDir()
+	wedged := filepath.Join(tmp, "wedged")
+	results := []scan.CategoryResult{
+		{
+			Category: "test",
+			Entries: []scan.ScanEntry{
+				{Path: wedged, Size: 100},
+				{Path: filepath.Join(tmp, "b"), Size: 50},
+			},
+		},
+	}
+	release := make(chan struct{})
+	defer close(release)
+	var removed []string
+	remove := func(path string) (int64, error) {
+		if path == wedged {
+			// Stands in for a removal stuck on a hung mount.
+			<-release
+			return 100, nil
+		}
+		removed = append(removed, path)
+		return 50, nil
+	}
+
+	start := time.Now()
+	res := ExecuteWithOptions(results, nil, Options{Remove: remove, EntryTimeout: 20 * time.Millisecond})
+
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("cleanup waited %v on the wedged removal", elapsed)
+	}
+	if res.Removed != 1 || res.Failed != 1 || res.BytesFreed != 50 {
+		t.Errorf("Removed = %d, Failed = %d, BytesFreed = %d, want 1, 1 and 50", res.Removed, res.Failed, res.BytesFreed)
+	}
+	if len(removed) != 1 || removed[0] != results[0].Entries[1].Path {
+		t.Errorf("expected cleanup to proceed to the next entry, removed %v", removed)
+	}
+	if len(res.Errors) != 1 || !errors.Is(res.Errors[0], ErrRemovalTimeout) || !strings.Contains(res.Errors[0].Error(), wedged) {
+		t.Errorf("expected timeout error for %s, got %v", wedged, res.Errors)
+	}
+}
+
+func TestExecuteEntryTimeoutCancelsCommand(t *testing.T) {
+	rootDir := filepath.Join(t.TempDir(), "root-owned")
+	results := []scan.CategoryResult{
+		{Category: "test", Entries: []scan.ScanEntry{{Path: rootDir, Size: 100, IsDir: true, RequiresRoot: true}}},
+	}
+	cancelled := make(chan struct{})
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return nil, ctx.Err()
+	}
+
+	res := ExecuteWithOptions(results, nil, Options{Sudo: runner, EntryTimeout: 20 * time.Millisecond})
+
+	if res.Failed != 1 || len(res.Errors) != 1 || !errors.Is(res.Errors[0], ErrRemovalTimeout) {
+		t.Errorf("expected one timed-out entry, got Failed = %d, Errors = %v", res.Failed, res.Errors)
+	}
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Error("expected the command's context to be cancelled")
+	}
+}
+
+func TestValidateSudo(t *testing.T) {
+	var got []string
+	ok := func(_ context.Context, name string, args ...string) ([]byte, error) {
+		got = append([]string{name}, args...)
+		return nil, nil
+	}
+	if err := ValidateSudo(ok); err != nil {
+		t.Fatalf("ValidateSudo: %v", err)
+	}
+	if strings.Join(got, " ") != "sudo -v" {
+		t.Errorf("ran %v, want sudo -v", got)
+	}
+
+	failing := func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("cancelled")
+	}
+	if err := ValidateSudo(failing); err == nil {
+		t.Error("expected error when sudo -v fails")
+	}
+}
+
+func TestNeedsRoot(t *testing.T) {
+	results := []scan.CategoryResult{{Entries: []scan.ScanEntry{{Path: "/a"}}}}
+	if NeedsRoot(results) {
+		t.Error("expected false without root-flagged entries")
+	}
+	results = append(results, scan.CategoryResult{Entries: []scan.ScanEntry{{Path: "/b", RequiresRoot: true}}})
+	if !NeedsRoot(results) {
+		t.Error("expected true with a root-flagged entry")
+	}
+}
+
+func TestExecuteRunsBrewAutoremove(t *testing.T) {
+	var calls []string
+	runner := func(_ context.Context, name string, args ...string) ([]byte, error) {
+		calls = append(calls, strings.Join(append([]string{name}, args...), " "))
+		return nil, nil
+	}
+	results := []scan.CategoryResult{
+		{Category: "dev-brew-autoremove", Entries: []scan.ScanEntry{{Path: "brew:autoremove", Size: 10000}}},
+		{Category: "dev-docker", Entries: []scan.ScanEntry{{Path: "docker:Images", Size: 500}}},
+	}
+
+	res := ExecuteWithOptions(results, nil, Options{Runner: runner})
+
+	if len(calls) != 1 || calls[0] != "brew autoremove" {
+		t.Fatalf("runner calls = %v, want [brew autoremove]", calls)
+	}
+	if res.Removed != 1 || res.BytesFreed != 10000 {
+		t.Errorf("Removed = %d, BytesFreed = %d, want 1 and 10000", res.Removed, res.BytesFreed)
+	}
+	if res.Failed != 1 {
+		t.Errorf("Failed = %d, want 1 for the informational docker entry", res.Failed)
+	}
+}
+
+func TestExecuteBrewAutoremoveFailure(t *testing.T) {
+	runner := func(context.Context, string, ...string) ([]byte, error) {
+		return nil, errors.New("exit status 1")
+	}
+	results := []scan.CategoryResult{
+		{Category: "dev-brew-autoremove", Entries: []scan.ScanEntry{{Path: "brew:autoremove", Size: 10000}}},
+	}
+
+	res := ExecuteWithOptions(results, nil, Options{Runner: runner})
+
+	if res.Removed != 0 || res.Failed != 1 {
+		t.Errorf("Removed = %d, Failed = %d, want 0 and 1", res.Removed, res.Failed)
+	}
+	if len(res.Errors) != 1 || !strings.Contains(res.Errors[0].Error(), "brew autoremove") {
+		t.Errorf("Errors = %v, want one brew autoremove error", res.Errors)
+	}
+}
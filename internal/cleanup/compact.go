@@ -0,0 +1,309 @@
+package cleanup
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// ImageKind is a type of VM or Docker disk image that Compact can shrink
+// in place.
+type ImageKind string
+
+const (
+	// ImageParallels is a Parallels VM (.pvm bundle) or disk (.hdd),
+	// compacted with prl_disk_tool.
+	ImageParallels ImageKind = "parallels"
+	// ImageQCOW2 is a qcow2 disk or a UTM VM (.utm bundle) holding
+	// qcow2 or raw .img disks, rewritten without unused clusters by
+	// qemu-img convert.
+	ImageQCOW2 ImageKind = "qcow2"
+	// ImageDocker is Docker Desktop's VM disk (Docker.raw), trimmed by
+	// Docker Desktop's reclaim-space container.
+	ImageDocker ImageKind = "docker"
+)
+
+// CompactCategories lists the categories whose entries may be images
+// Compact recognizes.
+var CompactCategories = []string{"sysdata-vm-parallels", "sysdata-vm-utm", "dev-docker-vm"}
+
+// dockerReclaimImage is the container Docker Desktop provides to release
+// the unused blocks of its VM disk back to macOS.
+const dockerReclaimImage = "docker/desktop-reclaim-space"
+
+// CompactResult is the outcome of compacting one image.
+type CompactResult struct {
+	Path string    `json:"path"`
+	Kind ImageKind `json:"kind"`
+	// Before and After are the image's allocated size in bytes around
+	// the compaction.
+	Before int64 `json:"before"`
+	After  int64 `json:"after"`
+}
+
+// Reclaimed returns the bytes the compaction freed, never negative.
+func (r CompactResult) Reclaimed() int64 {
+	if r.After >= r.Before {
+		return 0
+	}
+	return r.Before - r.After
+}
+
+// CompactOptions configures Compact.
+type CompactOptions struct {
+	// Runner runs the compaction tools. Nil uses os/exec.
+	Runner CmdRunner
+	// Measure returns an image's size before and after compaction. Nil
+	// sums the allocated blocks of every file in it, since the images
+	// are sparse and their apparent length does not shrink.
+	Measure func(path string) (int64, error)
+	// Available returns the free space on the volume holding a path,
+	// checked before a disk is copied by qemu-img. Nil uses
+	// scan.AvailableBytes.
+	Available func(path string) (int64, error)
+}
+
+// ImageKindOf reports which kind of image path is, judged by its name:
+// a .pvm bundle or .hdd disk, a .qcow2 disk or .utm bundle, or
+// Docker.raw. Anything else is not compactable.
+func ImageKindOf(path string) (ImageKind, bool) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pvm", ".hdd":
+		return ImageParallels, true
+	case ".qcow2", ".utm":
+		return ImageQCOW2, true
+	}
+	if filepath.Base(path) == "Docker.raw" {
+		return ImageDocker, true
+	}
+	return "", false
+}
+
+// CompactCommands returns the commands Compact runs for the image at
+// path, in order, so a dry run can show them. A .pvm bundle yields one
+// command per .hdd disk inside it and a .utm bundle those of each .qcow2
+// or .img disk: a qcow2 disk's snapshots are listed before it is copied.
+func CompactCommands(path string) ([][]string, error) {
+	kind, ok := ImageKindOf(path)
+	if !ok {
+		return nil, fmt.Errorf("%s: not a compactable disk image", path)
+	}
+	switch kind {
+	case ImageParallels:
+		disks, err := imageDisks(path, ".pvm", ".hdd")
+		if err != nil {
+			return nil, err
+		}
+		var cmds [][]string
+		for _, d := range disks {
+			cmds = append(cmds, []string{"prl_disk_tool", "compact", "--hdd", d})
+		}
+		return cmds, nil
+	case ImageQCOW2:
+		disks, err := imageDisks(path, ".utm", ".qcow2", ".img")
+		if err != nil {
+			return nil, err
+		}
+		var cmds [][]string
+		for _, d := range disks {
+			cmds = append(cmds, qemuCommands(d)...)
+		}
+		return cmds, nil
+	default:
+		return [][]string{{"docker", "run", "--rm", "--privileged", "--pid=host", dockerReclaimImage}}, nil
+	}
+}
+
+// Compact shrinks the disk image at path in place, releasing the space
+// its guest no longer uses, and reports its size before and after.
+// Unlike deletion the VM and its data are kept. Parallels disks are
+// compacted by prl_disk_tool and Docker.raw by Docker Desktop, which must
+// be running; qcow2 and raw disks are copied by qemu-img convert and the
+// copy replaces the original, so the VM must be shut down (qemu-img
+// refuses a disk locked by a running VM). A qcow2 disk with internal
+// snapshots, which the copy would drop, is refused, as is a disk whose
+// copy might not fit in the free space; the original is kept when the
+// copy is not smaller. On error the image is left as it was, apart from
+// disks of a bundle already compacted.
+func Compact(ctx context.Context, path string, opts CompactOptions) (CompactResult, error) {
+	kind, _ := ImageKindOf(path)
+	res := CompactResult{Path: path, Kind: kind}
+	cmds, err := CompactCommands(path)
+	if err != nil {
+		return res, err
+	}
+	if len(cmds) == 0 {
+		return res, fmt.Errorf("%s: no disk image found inside", path)
+	}
+	runner := opts.Runner
+	if runner == nil {
+		runner = defaultRunner
+	}
+	measure := opts.Measure
+	if measure == nil {
+		measure = allocatedTreeSize
+	}
+
+	available := opts.Available
+	if available == nil {
+		available = scan.AvailableBytes
+	}
+
+	if res.Before, err = measure(path); err != nil {
+		return res, fmt.Errorf("measure %s: %w", path, err)
+	}
+	if kind == ImageQCOW2 {
+		disks, err := imageDisks(path, ".utm", ".qcow2", ".img")
+		if err != nil {
+			return res, err
+		}
+		for _, d := range disks {
+			if err := compactQEMUDisk(ctx, runner, available, d); err != nil {
+				return res, err
+			}
+		}
+	} else {
+		for _, c := range cmds {
+			if _, err := runner(ctx, c[0], c[1:]...); err != nil {
+				return res, fmt.Errorf("%s: %w", strings.Join(c[:2], " "), err)
+			}
+		}
+	}
+	if res.After, err = measure(path); err != nil {
+		return res, fmt.Errorf("measure %s: %w", path, err)
+	}
+	return res, nil
+}
+
+// compactQEMUDisk copies disk without its unused clusters and replaces
+// it with the copy. It refuses a qcow2 disk that lists snapshots and one
+// whose allocated size exceeds the free space, since the copy may be as
+// large, and keeps the original when the copy is not smaller.
+func compactQEMUDisk(ctx context.Context, runner CmdRunner, available func(string) (int64, error), disk string) error {
+	cmds := qemuCommands(disk)
+	convert := cmds[len(cmds)-1]
+	for _, c := range cmds[:len(cmds)-1] {
+		out, err := runner(ctx, c[0], c[1:]...)
+		if err != nil {
+			return fmt.Errorf("%s: %w", strings.Join(c[:3], " "), err)
+		}
+		if strings.TrimSpace(string(out)) != "" {
+			return fmt.Errorf("%s: has snapshots, which qemu-img convert would drop; delete them first", disk)
+		}
+	}
+
+	size, err := allocatedTreeSize(disk)
+	if err != nil {
+		return fmt.Errorf("measure %s: %w", disk, err)
+	}
+	free, err := available(filepath.Dir(disk))
+	if err != nil {
+		return fmt.Errorf("free space for %s: %w", disk, err)
+	}
+	if free < size {
+		return fmt.Errorf("%s: copying it needs up to %s but only %s is free", disk, scan.FormatSize(size), scan.FormatSize(free))
+	}
+
+	tmp := convert[len(convert)-1]
+	if _, err := runner(ctx, convert[0], convert[1:]...); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("%s: %w", strings.Join(convert[:2], " "), err)
+	}
+	copied, err := allocatedTreeSize(tmp)
+	if err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("measure %s: %w", tmp, err)
+	}
+	if copied >= size {
+		return os.Remove(tmp)
+	}
+	if err := os.Rename(tmp, disk); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("replace %s: %w", disk, err)
+	}
+	return nil
+}
+
+// qemuCommands returns the commands that compact a qcow2 or raw .img
+// disk: for qcow2 the listing of its snapshots, which must be empty, then
+// the qemu-img convert that writes the copy.
+func qemuCommands(disk string) [][]string {
+	format := "qcow2"
+	if strings.EqualFold(filepath.Ext(disk), ".img") {
+		format = "raw"
+	}
+	convert := []string{"qemu-img", "convert", "-O", format, disk, compactTemp(disk)}
+	if format != "qcow2" {
+		return [][]string{convert}
+	}
+	return [][]string{{"qemu-img", "snapshot", "-l", disk}, convert}
+}
+
+// imageDisks returns the disks of the image at path: the entries of a
+// bundle (extension bundleExt) that have one of the extensions diskExts,
+// sorted, or path itself when it is a disk.
+func imageDisks(path, bundleExt string, diskExts ...string) ([]string, error) {
+	if !strings.EqualFold(filepath.Ext(path), bundleExt) {
+		return []string{path}, nil
+	}
+	var disks []string
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != path && hasExt(p, diskExts) {
+			disks = append(disks, p)
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(disks)
+	return disks, nil
+}
+
+// hasExt reports whether path has one of exts, ignoring case.
+func hasExt(path string, exts []string) bool {
+	for _, ext := range exts {
+		if strings.EqualFold(filepath.Ext(path), ext) {
+			return true
+		}
+	}
+	return false
+}
+
+// compactTemp is where qemu-img writes the compacted copy of disk before
+// it replaces the original.
+func compactTemp(disk string) string {
+	return disk + ".compacting"
+}
+
+// allocatedTreeSize is the default CompactOptions.Measure: the allocated
+// size of path, summed over every file when it is a bundle.
+func allocatedTreeSize(path string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			size, err := scan.AllocatedSize(p)
+			if err != nil {
+				return err
+			}
+			total += size
+		}
+		return nil
+	})
+	return total, err
+}
@@ -0,0 +1,224 @@
+package cleanup
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// originalImage is the content of the test disks: large enough that the
+// copy recordingRunner writes allocates fewer blocks.
+var originalImage = make([]byte, 1<<16)
+
+// recordingRunner returns a CmdRunner that records each command it is
+// given and, for qemu-img convert, writes the compacted copy.
+func recordingRunner(calls *[][]string) CmdRunner {
+	return func(_ context.Context, name string, args ...string) ([]byte, error) {
+		*calls = append(*calls, append([]string{name}, args...))
+		if name == "qemu-img" && args[0] == "convert" {
+			if err := os.WriteFile(args[len(args)-1], []byte("compacted"), 0644); err != nil {
+				return nil, err
+			}
+		}
+		return nil, nil
+	}
+}
+
+// shrinkingMeasure returns a Measure reporting before on its first call
+// and after on later ones.
+func shrinkingMeasure(before, after int64) func(string) (int64, error) {
+	calls := 0
+	return func(string) (int64, error) {
+		calls++
+		if calls == 1 {
+			return before, nil
+		}
+		return after, nil
+	}
+}
+
+func TestImageKindOf(t *testing.T) {
+	cases := map[string]ImageKind{
+		"/h/Parallels/Windows 11.pvm":            ImageParallels,
+		"/h/Parallels/Windows 11.pvm/disk.hdd":   ImageParallels,
+		"/h/Documents/Linux.utm":                 ImageQCOW2,
+		"/h/Documents/Linux.utm/Data/disk.qcow2": ImageQCOW2,
+		"/h/Data/vms/0/data/Docker.raw":          ImageDocker,
+	}
+	for path, want := range cases {
+		if got, ok := ImageKindOf(path); !ok || got != want {
+			t.Errorf("ImageKindOf(%s) = %q, %v; want %q", path, got, ok, want)
+		}
+	}
+	if _, ok := ImageKindOf("/h/Parallels/notes.txt"); ok {
+		t.Error("expected notes.txt not to be compactable")
+	}
+}
+
+func TestCompactCommandPerImageType(t *testing.T) {
+	dir := t.TempDir()
+	pvm := filepath.Join(dir, "Windows 11.pvm")
+	hdd := filepath.Join(pvm, "harddisk.hdd")
+	utm := filepath.Join(dir, "Linux.utm")
+	qcow := filepath.Join(utm, "Data", "disk.qcow2")
+	rawUTM := filepath.Join(dir, "Raw.utm")
+	img := filepath.Join(rawUTM, "Data", "disk.img")
+	raw := filepath.Join(dir, "Docker.raw")
+	if err := os.MkdirAll(hdd, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range []string{qcow, img} {
+		if err := os.MkdirAll(filepath.Dir(f), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, f := range []string{filepath.Join(hdd, "data.hds"), qcow, img, raw} {
+		if err := os.WriteFile(f, originalImage, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		path string
+		kind ImageKind
+		want [][]string
+	}{
+		{pvm, ImageParallels, [][]string{{"prl_disk_tool", "compact", "--hdd", hdd}}},
+		{utm, ImageQCOW2, [][]string{
+			{"qemu-img", "snapshot", "-l", qcow},
+			{"qemu-img", "convert", "-O", "qcow2", qcow, qcow + ".compacting"},
+		}},
+		{rawUTM, ImageQCOW2, [][]string{{"qemu-img", "convert", "-O", "raw", img, img + ".compacting"}}},
+		{raw, ImageDocker, [][]string{{"docker", "run", "--rm", "--privileged", "--pid=host", "docker/desktop-reclaim-space"}}},
+	}
+	for _, tc := range cases {
+		var calls [][]string
+		res, err := Compact(context.Background(), tc.path, CompactOptions{
+			Runner:  recordingRunner(&calls),
+			Measure: shrinkingMeasure(10<<30, 6<<30),
+		})
+		if err != nil {
+			t.Fatalf("Compact(%s): %v", tc.path, err)
+		}
+		if !reflect.DeepEqual(calls, tc.want) {
+			t.Errorf("Compact(%s) ran %v, want %v", tc.path, calls, tc.want)
+		}
+		if res.Kind != tc.kind || res.Before != 10<<30 || res.After != 6<<30 || res.Reclaimed() != 4<<30 {
+			t.Errorf("Compact(%s) = %+v, reclaimed %d; want kind %s and 4 GiB reclaimed", tc.path, res, res.Reclaimed(), tc.kind)
+		}
+	}
+
+	// The copies replaced the original disks.
+	for _, disk := range []string{qcow, img} {
+		if data, _ := os.ReadFile(disk); string(data) != "compacted" {
+			t.Errorf("%s = %q, want the compacted copy", disk, data)
+		}
+		if _, err := os.Stat(disk + ".compacting"); !os.IsNotExist(err) {
+			t.Errorf("temporary copy left behind: %v", err)
+		}
+	}
+}
+
+// writeQCOW2 creates a qcow2 disk holding originalImage.
+func writeQCOW2(t *testing.T) string {
+	t.Helper()
+	qcow := filepath.Join(t.TempDir(), "disk.qcow2")
+	if err := os.WriteFile(qcow, originalImage, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return qcow
+}
+
+// assertOriginalKept fails the test unless disk still holds originalImage
+// and no temporary copy is left.
+func assertOriginalKept(t *testing.T, disk string) {
+	t.Helper()
+	if data, _ := os.ReadFile(disk); len(data) != len(originalImage) {
+		t.Errorf("disk holds %d bytes, want the original kept", len(data))
+	}
+	if _, err := os.Stat(compactTemp(disk)); !os.IsNotExist(err) {
+		t.Errorf("temporary copy left behind: %v", err)
+	}
+}
+
+func TestCompactRefusesQCOW2WithSnapshots(t *testing.T) {
+	qcow := writeQCOW2(t)
+	var calls [][]string
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		if args[0] == "snapshot" {
+			calls = append(calls, append([]string{name}, args...))
+			return []byte("Snapshot list:\nID  TAG     VM SIZE  DATE\n1   before  0 B      2026-01-01\n"), nil
+		}
+		return recordingRunner(&calls)(ctx, name, args...)
+	}
+
+	_, err := Compact(context.Background(), qcow, CompactOptions{Runner: runner, Measure: shrinkingMeasure(1, 1)})
+	if err == nil || !strings.Contains(err.Error(), "snapshots") {
+		t.Fatalf("expected a snapshot error, got %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("ran %v, want only the snapshot listing", calls)
+	}
+	assertOriginalKept(t, qcow)
+}
+
+func TestCompactRefusesWithoutFreeSpace(t *testing.T) {
+	qcow := writeQCOW2(t)
+	var calls [][]string
+	_, err := Compact(context.Background(), qcow, CompactOptions{
+		Runner:    recordingRunner(&calls),
+		Measure:   shrinkingMeasure(1, 1),
+		Available: func(string) (int64, error) { return 4096, nil },
+	})
+	if err == nil || !strings.Contains(err.Error(), "free") {
+		t.Fatalf("expected a free space error, got %v", err)
+	}
+	for _, c := range calls {
+		if c[1] == "convert" {
+			t.Errorf("qemu-img convert ran without room for the copy")
+		}
+	}
+	assertOriginalKept(t, qcow)
+}
+
+func TestCompactKeepsOriginalWhenCopyNotSmaller(t *testing.T) {
+	qcow := writeQCOW2(t)
+	growing := func(_ context.Context, name string, args ...string) ([]byte, error) {
+		if args[0] == "convert" {
+			return nil, os.WriteFile(args[len(args)-1], make([]byte, 2*len(originalImage)), 0644)
+		}
+		return nil, nil
+	}
+
+	if _, err := Compact(context.Background(), qcow, CompactOptions{Runner: growing, Measure: shrinkingMeasure(1, 1)}); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	assertOriginalKept(t, qcow)
+}
+
+func TestCompactFailureKeepsQCOW2(t *testing.T) {
+	qcow := writeQCOW2(t)
+	failing := func(_ context.Context, name string, args ...string) ([]byte, error) {
+		if args[0] == "snapshot" {
+			return nil, nil
+		}
+		_ = os.WriteFile(args[len(args)-1], []byte("partial"), 0644)
+		return nil, errors.New("Failed to get shared \"write\" lock")
+	}
+
+	_, err := Compact(context.Background(), qcow, CompactOptions{Runner: failing, Measure: shrinkingMeasure(1, 1)})
+	if err == nil || !strings.Contains(err.Error(), "qemu-img convert") {
+		t.Fatalf("expected qemu-img convert error, got %v", err)
+	}
+	assertOriginalKept(t, qcow)
+}
+
+func TestCompactReclaimedNeverNegative(t *testing.T) {
+	if got := (CompactResult{Before: 100, After: 150}).Reclaimed(); got != 0 {
+		t.Errorf("Reclaimed() = %d, want 0 when the image grew", got)
+	}
+}
@@ -0,0 +1,57 @@
+package cleanup
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// ErrLooksLikeDocuments marks an entry left alone because it looks like it
+// holds the user's documents and was not confirmed through
+// Options.AllowDocuments.
+var ErrLooksLikeDocuments = errors.New("looks like it contains documents")
+
+// DocumentLike describes a directory offered for deletion that looks like
+// it holds the user's documents (see safety.LooksLikeDocuments).
+type DocumentLike struct {
+	// Docs and Sampled are the documents among the files sampled.
+	Docs, Sampled int
+}
+
+// CheckDocuments reports whether entry, offered by category, looks like
+// it holds the user's documents. Only directories rated safe are sampled;
+// moderate and risky entries, such as old Downloads or Mail attachments,
+// are expected to hold user files and keep their existing warnings.
+func CheckDocuments(category string, entry scan.ScanEntry) (DocumentLike, bool) {
+	level := entry.RiskLevel
+	if level == "" {
+		level = safety.RiskForCategory(category)
+	}
+	if !entry.IsDir || level != safety.RiskSafe {
+		return DocumentLike{}, false
+	}
+	ok, docs, sampled := safety.LooksLikeDocuments(entry.Path)
+	return DocumentLike{Docs: docs, Sampled: sampled}, ok
+}
+
+// DocumentEntries returns the entries of results that look like they hold
+// documents, keyed by path (see CheckDocuments).
+func DocumentEntries(results []scan.CategoryResult) map[string]DocumentLike {
+	found := map[string]DocumentLike{}
+	for _, cat := range results {
+		for _, e := range cat.Entries {
+			if d, ok := CheckDocuments(cat.Category, e); ok {
+				found[e.Path] = d
+			}
+		}
+	}
+	return found
+}
+
+// documentsError is the error recorded for an entry withheld because it
+// looks like it holds documents.
+func documentsError(path string, d DocumentLike) error {
+	return fmt.Errorf("%w: %s (%d of %d sampled files)", ErrLooksLikeDocuments, path, d.Docs, d.Sampled)
+}
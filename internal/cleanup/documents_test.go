@@ -0,0 +1,73 @@
+package cleanup
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// writeDocumentDir creates a directory of office documents under parent
+// and returns it.
+func writeDocumentDir(t *testing.T, parent string) string {
+	t.Helper()
+	dir := filepath.Join(parent, "com.example.notes")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"contract.pdf", "invoice.pdf", "cv.docx", "budget.xlsx", "cover.psd", "IMG_0001.HEIC"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDocumentEntries(t *testing.T) {
+	docs := writeDocumentDir(t, t.TempDir())
+	cache := t.TempDir()
+	results := []scan.CategoryResult{{
+		Category: "system-caches",
+		Entries: []scan.ScanEntry{
+			{Path: docs, Size: 24, IsDir: true},
+			{Path: cache, IsDir: true},
+		},
+	}}
+
+	found := DocumentEntries(results)
+	if d, ok := found[docs]; len(found) != 1 || !ok || d.Docs != 6 || d.Sampled != 6 {
+		t.Fatalf("expected only %s flagged with 6 of 6 documents, got %+v", docs, found)
+	}
+
+	// Entries rated moderate or risky keep their own warnings.
+	results[0].Entries[0].RiskLevel = "moderate"
+	if found := DocumentEntries(results); len(found) != 0 {
+		t.Errorf("expected moderate entries to be left alone, got %+v", found)
+	}
+}
+
+func TestExecuteWithholdsDocuments(t *testing.T) {
+	docs := writeDocumentDir(t, t.TempDir())
+	results := []scan.CategoryResult{{
+		Category: "system-caches",
+		Entries:  []scan.ScanEntry{{Path: docs, Size: 24, IsDir: true}},
+	}}
+
+	res := Execute(results, nil)
+	if res.Removed != 0 || res.Failed != 1 || len(res.Errors) != 1 || !errors.Is(res.Errors[0], ErrLooksLikeDocuments) {
+		t.Fatalf("expected the documents withheld, got %+v", res)
+	}
+	if _, err := os.Stat(docs); err != nil {
+		t.Fatalf("expected %s to survive: %v", docs, err)
+	}
+
+	res = ExecuteWithOptions(results, nil, Options{AllowDocuments: []string{docs}})
+	if res.Removed != 1 || res.Failed != 0 {
+		t.Fatalf("expected the confirmed documents removed, got %+v", res)
+	}
+	if _, err := os.Stat(docs); !os.IsNotExist(err) {
+		t.Errorf("expected %s removed, stat err: %v", docs, err)
+	}
+}
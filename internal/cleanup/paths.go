@@ -0,0 +1,104 @@
+package cleanup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// PathStatus is the outcome of one path in RemovePaths.
+type PathStatus string
+
+const (
+	// PathRemoved means the path was deleted.
+	PathRemoved PathStatus = "removed"
+	// PathFailed means the path was refused by a safety check or could
+	// not be deleted.
+	PathFailed PathStatus = "failed"
+	// PathSkipped means there was nothing to delete at the path.
+	PathSkipped PathStatus = "skipped"
+)
+
+// PathOutcome reports what happened to one path of an explicit list.
+type PathOutcome struct {
+	Path   string
+	Status PathStatus
+	// Size is the size in bytes the path had before removal.
+	Size int64
+	// Reason explains a failed or skipped path.
+	Reason string
+}
+
+// CheckPath validates an explicitly listed path for deletion and sizes
+// it. The path must be absolute, pass safety.IsPathBlocked, which denies
+// protected system paths, anything outside the home directory and the
+// home directory itself, and resolve under an allowed root (see
+// safety.CheckAllowedRoot). When the path may be deleted it is returned
+// as an entry with a nil outcome; otherwise the failed or skipped outcome
+// is returned.
+func CheckPath(path string) (scan.ScanEntry, *PathOutcome) {
+	if !filepath.IsAbs(path) {
+		return scan.ScanEntry{}, &PathOutcome{Path: path, Status: PathFailed, Reason: "not an absolute path"}
+	}
+	if blocked, reason := safety.IsPathBlocked(path); blocked {
+		return scan.ScanEntry{}, &PathOutcome{Path: path, Status: PathFailed, Reason: "blocked: " + reason}
+	}
+	if ok, reason := safety.CheckAllowedRoot(path, nil); !ok {
+		return scan.ScanEntry{}, &PathOutcome{Path: path, Status: PathFailed, Reason: "refused: " + reason}
+	}
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return scan.ScanEntry{}, &PathOutcome{Path: path, Status: PathSkipped, Reason: "does not exist"}
+	}
+	if err != nil {
+		return scan.ScanEntry{}, &PathOutcome{Path: path, Status: PathFailed, Reason: err.Error()}
+	}
+	size := scan.FileSize(info)
+	if info.IsDir() {
+		// A partial size is still worth reporting; removal decides success.
+		size, _ = scan.DirSize(path)
+	}
+	return scan.ScanEntry{Path: path, Size: size, IsDir: info.IsDir()}, nil
+}
+
+// RemovePaths deletes an explicit list of paths, bypassing the scanners
+// but not the safety checks: every path is re-validated with CheckPath
+// immediately before removal, and directories that look like they hold
+// the user's documents (see safety.LooksLikeDocuments) are refused. It
+// returns one outcome per path, in order. Errors on individual paths do
+// not stop the others.
+func RemovePaths(paths []string) []PathOutcome {
+	outcomes := make([]PathOutcome, 0, len(paths))
+	for _, path := range paths {
+		entry, outcome := CheckPath(path)
+		if outcome != nil {
+			outcomes = append(outcomes, *outcome)
+			continue
+		}
+		if entry.IsDir {
+			if ok, docs, sampled := safety.LooksLikeDocuments(entry.Path); ok {
+				outcomes = append(outcomes, PathOutcome{
+					Path:   path,
+					Status: PathFailed,
+					Size:   entry.Size,
+					Reason: documentsError(entry.Path, DocumentLike{Docs: docs, Sampled: sampled}).Error(),
+				})
+				continue
+			}
+		}
+		if err := os.RemoveAll(entry.Path); err != nil && !os.IsNotExist(err) {
+			outcomes = append(outcomes, PathOutcome{
+				Path:   path,
+				Status: PathFailed,
+				Size:   entry.Size,
+				Reason: fmt.Sprintf("remove: %v", err),
+			})
+			continue
+		}
+		outcomes = append(outcomes, PathOutcome{Path: path, Status: PathRemoved, Size: entry.Size})
+	}
+	return outcomes
+}
@@ -0,0 +1,81 @@
+package cleanup
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRemovePathsMixedOutcomes(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Fatalf("cannot get home dir: %v", err)
+	}
+	tmp := t.TempDir()
+	dir := filepath.Join(tmp, "build-output")
+	os.MkdirAll(filepath.Join(dir, "sub"), 0755)
+	os.WriteFile(filepath.Join(dir, "sub", "a.bin"), make([]byte, 300), 0644)
+	missing := filepath.Join(tmp, "never-created")
+
+	outcomes := RemovePaths([]string{dir, home, missing})
+
+	if len(outcomes) != 3 {
+		t.Fatalf("expected 3 outcomes, got %d: %+v", len(outcomes), outcomes)
+	}
+	if outcomes[0].Status != PathRemoved || outcomes[0].Size != 300 {
+		t.Errorf("expected %s removed with size 300, got %+v", dir, outcomes[0])
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("%s should be deleted", dir)
+	}
+	if outcomes[1].Status != PathFailed || !strings.Contains(outcomes[1].Reason, "home directory") {
+		t.Errorf("expected home directory refused, got %+v", outcomes[1])
+	}
+	if _, err := os.Stat(home); err != nil {
+		t.Fatalf("home directory must survive: %v", err)
+	}
+	if outcomes[2].Status != PathSkipped {
+		t.Errorf("expected missing path skipped, got %+v", outcomes[2])
+	}
+}
+
+func TestRemovePathsWithholdsDocuments(t *testing.T) {
+	docs := writeDocumentDir(t, t.TempDir())
+
+	outcomes := RemovePaths([]string{docs})
+	if len(outcomes) != 1 || outcomes[0].Status != PathFailed || !strings.Contains(outcomes[0].Reason, "looks like it contains documents") {
+		t.Fatalf("expected the documents directory refused, got %+v", outcomes)
+	}
+	if _, err := os.Stat(docs); err != nil {
+		t.Errorf("expected %s to survive: %v", docs, err)
+	}
+}
+
+func TestRemovePathsRefusesUnsafePaths(t *testing.T) {
+	outcomes := RemovePaths([]string{"/System/Library", "relative/path", "/etc/hosts"})
+	for _, o := range outcomes {
+		if o.Status != PathFailed {
+			t.Errorf("expected %q to fail, got %+v", o.Path, o)
+		}
+	}
+	if !strings.Contains(outcomes[1].Reason, "not an absolute path") {
+		t.Errorf("expected relative path reason, got %q", outcomes[1].Reason)
+	}
+}
+
+func TestCheckPathSizesFile(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "log.txt")
+	os.WriteFile(f, []byte("hello"), 0644)
+
+	entry, outcome := CheckPath(f)
+	if outcome != nil {
+		t.Fatalf("expected file to pass checks, got %+v", outcome)
+	}
+	if entry.Size != 5 || entry.IsDir {
+		t.Errorf("expected 5-byte file entry, got %+v", entry)
+	}
+	if _, err := os.Stat(f); err != nil {
+		t.Errorf("CheckPath must not delete: %v", err)
+	}
+}
@@ -0,0 +1,58 @@
+package confirm
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// AckPath returns the location of the first-run acknowledgement file,
+// ~/.config/mac-cleaner/ack.
+func AckPath(home string) string {
+	return filepath.Join(home, ".config", "mac-cleaner", "ack")
+}
+
+// HasAcknowledged reports whether the first-run acknowledgement file exists.
+func HasAcknowledged(home string) bool {
+	_, err := os.Stat(AckPath(home))
+	return err == nil
+}
+
+// RecordAcknowledgement writes the first-run acknowledgement file, creating
+// its parent directory if needed. The file holds the acknowledgement time.
+func RecordAcknowledgement(home string) error {
+	path := AckPath(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	data := []byte(time.Now().UTC().Format(time.RFC3339) + "\n")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write acknowledgement: %w", err)
+	}
+	return nil
+}
+
+// PromptAcknowledgement explains that deletions are permanent and asks the
+// user to type "I understand" to proceed. Returns true only on that exact
+// response (whitespace-trimmed). Returns false on any other input or read
+// error.
+func PromptAcknowledgement(in io.Reader, out io.Writer) bool {
+	redBold := style(color.FgRed, color.Bold)
+
+	_, _ = redBold.Fprintln(out, "\nFirst run: mac-cleaner deletes files permanently.")
+	fmt.Fprintln(out, "Deleted items do not go to the Trash and cannot be recovered.")
+	fmt.Fprint(out, "Type 'I understand' to continue: ")
+
+	reader := bufio.NewReader(in)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(response) == "I understand"
+}
@@ -0,0 +1,58 @@
+package confirm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAckPath(t *testing.T) {
+	got := AckPath("/Users/test")
+	want := filepath.Join("/Users/test", ".config", "mac-cleaner", "ack")
+	if got != want {
+		t.Errorf("AckPath = %q, want %q", got, want)
+	}
+}
+
+func TestHasAcknowledged_Absent(t *testing.T) {
+	if HasAcknowledged(t.TempDir()) {
+		t.Error("expected no acknowledgement in empty home")
+	}
+}
+
+func TestRecordAcknowledgement(t *testing.T) {
+	home := t.TempDir()
+	if err := RecordAcknowledgement(home); err != nil {
+		t.Fatalf("RecordAcknowledgement: %v", err)
+	}
+	if !HasAcknowledged(home) {
+		t.Error("expected acknowledgement after recording")
+	}
+	info, err := os.Stat(AckPath(home))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestPromptAcknowledgement_Accepted(t *testing.T) {
+	out := &bytes.Buffer{}
+	if !PromptAcknowledgement(strings.NewReader("I understand\n"), out) {
+		t.Fatal("expected true for 'I understand'")
+	}
+	if !strings.Contains(out.String(), "cannot be recovered") {
+		t.Errorf("expected irreversibility warning, got: %s", out.String())
+	}
+}
+
+func TestPromptAcknowledgement_Rejected(t *testing.T) {
+	for _, input := range []string{"yes\n", "i understand\n", "\n", ""} {
+		if PromptAcknowledgement(strings.NewReader(input), &bytes.Buffer{}) {
+			t.Errorf("expected false for input %q", input)
+		}
+	}
+}
@@ -7,8 +7,8 @@ import (
 	"bufio"
 	"fmt"
 	"io"
-	"os"
 	"strings"
+	"sync"
 
 	"github.com/fatih/color"
 
@@ -16,16 +16,83 @@ import (
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
+// largestCount is how many of the biggest entries the prompt repeats
+// before asking for confirmation.
+const largestCount = 3
+
+// Format selects how PromptConfirmation renders the pending deletions.
+type Format string
+
+const (
+	// FormatRich is the default: colored, grouped by category, with the
+	// largest items repeated at the end.
+	FormatRich Format = "rich"
+	// FormatPlain has no color and one tab-separated record per line,
+	// for screen readers and scripts (see SetFormat).
+	FormatPlain Format = "plain"
+)
+
+var (
+	formatMu sync.Mutex
+	format   = FormatRich
+)
+
+// ParseFormat returns the Format named s, "plain" or "rich".
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatRich, FormatPlain:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want plain or rich)", s)
+}
+
+// SetFormat selects how PromptConfirmation renders the pending deletions.
+// FormatPlain writes, without color, one line per category and per item
+// followed by the total:
+//
+//	category<TAB>User App Caches<TAB>1.2 GB
+//	item<TAB>~/Library/Caches/com.example<TAB>800 MB<TAB>safe
+//	total<TAB>1.5 GB<TAB>5 items
+//
+// An item's fourth field is its risk level, and a fifth field "root"
+// marks items that need elevation. FormatPlain also turns off color in
+// the package's other prompts.
+func SetFormat(f Format) {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	format = f
+}
+
+// currentFormat returns the format set by SetFormat.
+func currentFormat() Format {
+	formatMu.Lock()
+	defer formatMu.Unlock()
+	return format
+}
+
+// style returns a color with attrs, or an uncolored one in FormatPlain.
+func style(attrs ...color.Attribute) *color.Color {
+	c := color.New(attrs...)
+	if currentFormat() == FormatPlain {
+		c.DisableColor()
+	}
+	return c
+}
+
 // PromptConfirmation displays a summary of items to be deleted and asks
 // the user to type "yes" to proceed. Returns true only on exact "yes"
 // input (case-sensitive, whitespace-trimmed). Returns false on any other
-// input or read error.
+// input or read error. When more than three entries are selected, the
+// three largest across all categories are listed again below the full
+// list as a final sanity check. SetFormat selects a plain rendering.
 func PromptConfirmation(in io.Reader, out io.Writer, results []scan.CategoryResult) bool {
-	home, _ := os.UserHomeDir()
+	home, _ := safety.Home()
+	if currentFormat() == FormatPlain {
+		printPlain(out, results, home)
+		return readYes(in)
+	}
 
 	bold := color.New(color.Bold)
-	red := color.New(color.FgRed)
-	yellow := color.New(color.FgYellow)
 
 	fmt.Fprintln(out, "\nThe following items will be permanently deleted:")
 
@@ -35,25 +102,61 @@ func PromptConfirmation(in io.Reader, out io.Writer, results []scan.CategoryResu
 		_, _ = bold.Fprintln(out, "  "+cat.Description)
 		for _, entry := range cat.Entries {
 			path := shortenHome(entry.Path, home)
-			riskTag := ""
-			switch entry.RiskLevel {
-			case safety.RiskRisky:
-				riskTag = red.Sprint(" [risky]")
-			case safety.RiskModerate:
-				riskTag = yellow.Sprint(" [moderate]")
-			}
-			fmt.Fprintf(out, "    %s%s  (%s)\n", path, riskTag, scan.FormatSize(entry.Size))
+			fmt.Fprintf(out, "    %s%s  (%s)\n", path, riskTag(entry), scan.FormatSize(entry.Size))
 		}
 		totalSize += cat.TotalSize
 	}
 
+	if largest := largestEntries(results, largestCount); len(largest) > 0 {
+		fmt.Fprintln(out)
+		_, _ = bold.Fprintf(out, "  Largest %d items:\n", len(largest))
+		for i, entry := range largest {
+			path := shortenHome(entry.Path, home)
+			fmt.Fprintf(out, "    %d. %s%s  (%s)\n", i+1, path, riskTag(entry), scan.FormatSize(entry.Size))
+		}
+	}
+
 	fmt.Fprintf(out, "\nTotal: %s will be permanently deleted.\n", scan.FormatSize(totalSize))
 	if hasRiskyItems(results) {
 		redBold := color.New(color.FgRed, color.Bold)
 		_, _ = redBold.Fprintln(out, "\nWARNING: Selection includes risky items that may be difficult or impossible to recover.")
 	}
 	fmt.Fprint(out, "Type 'yes' to proceed: ")
+	return readYes(in)
+}
 
+// printPlain writes the pending deletions in FormatPlain, ending with the
+// prompt.
+func printPlain(out io.Writer, results []scan.CategoryResult, home string) {
+	fmt.Fprintln(out, "The following items will be permanently deleted:")
+	var totalSize int64
+	items := 0
+	for _, cat := range results {
+		fmt.Fprintf(out, "category\t%s\t%s\n", scan.ValidUTF8(cat.Description), scan.FormatSize(cat.TotalSize))
+		for _, entry := range cat.Entries {
+			risk := entry.RiskLevel
+			if risk == "" {
+				risk = safety.RiskSafe
+			}
+			fmt.Fprintf(out, "item\t%s\t%s\t%s", shortenHome(entry.Path, home), scan.FormatSize(entry.Size), risk)
+			if entry.RequiresRoot {
+				fmt.Fprint(out, "\troot")
+			}
+			fmt.Fprintln(out)
+			items++
+		}
+		totalSize += cat.TotalSize
+	}
+	fmt.Fprintf(out, "total\t%s\t%d items\n", scan.FormatSize(totalSize), items)
+	if hasRiskyItems(results) {
+		fmt.Fprintln(out, "WARNING: Selection includes risky items that may be difficult or impossible to recover.")
+	}
+	fmt.Fprint(out, "Type 'yes' to proceed: ")
+}
+
+// readYes reads one line from in and reports whether it is exactly
+// "yes", ignoring surrounding whitespace. A read error counts as no.
+func readYes(in io.Reader) bool {
 	reader := bufio.NewReader(in)
 	response, err := reader.ReadString('\n')
 	if err != nil {
@@ -62,6 +165,60 @@ func PromptConfirmation(in io.Reader, out io.Writer, results []scan.CategoryResu
 	return strings.TrimSpace(response) == "yes"
 }
 
+// PromptYes writes question and reports whether the user answered with
+// exactly "yes" (whitespace-trimmed). Any other input or read error is
+// no.
+func PromptYes(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprint(out, question)
+	return readYes(in)
+}
+
+// PromptDocuments warns that path, offered for deletion as a cache, looks
+// like it holds the user's documents (docs of the sampled files) and asks
+// the user to type "yes" to delete it anyway. Returns true only on exact
+// "yes" input (whitespace-trimmed); any other input or read error keeps
+// the directory.
+func PromptDocuments(in io.Reader, out io.Writer, path string, docs, sampled int) bool {
+	home, _ := safety.Home()
+	redBold := style(color.FgRed, color.Bold)
+
+	_, _ = redBold.Fprintf(out, "\nWARNING: %s looks like it contains your documents.\n", shortenHome(path, home))
+	fmt.Fprintf(out, "%d of the %d files sampled are documents or photos.\n", docs, sampled)
+	fmt.Fprint(out, "Type 'yes' to delete it anyway, or press Enter to keep it: ")
+	return readYes(in)
+}
+
+// riskTag returns the risk and privilege markers shown after an
+// entry's path.
+func riskTag(entry scan.ScanEntry) string {
+	tag := ""
+	switch entry.RiskLevel {
+	case safety.RiskRisky:
+		tag = style(color.FgRed).Sprint(" [risky]")
+	case safety.RiskModerate:
+		tag = style(color.FgYellow).Sprint(" [moderate]")
+	}
+	if entry.RequiresRoot {
+		tag += " [root]"
+	}
+	return tag
+}
+
+// largestEntries returns the n largest entries across all categories,
+// largest first. It returns nil when there are n or fewer entries, since
+// the full list above already shows them all.
+func largestEntries(results []scan.CategoryResult, n int) []scan.ScanEntry {
+	var all []scan.ScanEntry
+	for _, cat := range results {
+		all = append(all, cat.Entries...)
+	}
+	if len(all) <= n {
+		return nil
+	}
+	scan.SortBySize(all)
+	return all[:n]
+}
+
 // hasRiskyItems returns true if any entry in the results has a risky risk level.
 func hasRiskyItems(results []scan.CategoryResult) bool {
 	for _, cat := range results {
@@ -74,10 +231,11 @@ func hasRiskyItems(results []scan.CategoryResult) bool {
 	return false
 }
 
-// shortenHome replaces the home directory prefix with ~ for display.
+// shortenHome replaces the home directory prefix with ~ for display and
+// makes the result valid UTF-8.
 func shortenHome(path, home string) string {
 	if home != "" && strings.HasPrefix(path, home) {
-		return "~" + path[len(home):]
+		path = "~" + path[len(home):]
 	}
-	return path
+	return scan.ValidUTF8(path)
 }
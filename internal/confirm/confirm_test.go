@@ -5,6 +5,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/fatih/color"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -90,6 +93,19 @@ func TestConfirmationOutputContainsPath(t *testing.T) {
 	}
 }
 
+func TestConfirmationShortensPathsUnderSetHome(t *testing.T) {
+	safety.SetHome("/tmp/testdir")
+	t.Cleanup(func() { safety.SetHome("") })
+
+	out := &bytes.Buffer{}
+	PromptConfirmation(strings.NewReader("no\n"), out, sampleResults())
+
+	output := out.String()
+	if !strings.Contains(output, "~/foo") || strings.Contains(output, "/tmp/testdir/foo") {
+		t.Errorf("expected paths shortened against the --home directory, got:\n%s", output)
+	}
+}
+
 func TestConfirmationOutputContainsSize(t *testing.T) {
 	in := strings.NewReader("no\n")
 	out := &bytes.Buffer{}
@@ -119,3 +135,125 @@ func TestConfirmationEmptyResults(t *testing.T) {
 		t.Fatal("expected true for 'yes' input even with empty results")
 	}
 }
+
+func TestConfirmationListsThreeLargest(t *testing.T) {
+	in := strings.NewReader("no\n")
+	out := &bytes.Buffer{}
+	results := []scan.CategoryResult{
+		{
+			Category:    "vms",
+			Description: "Virtual Machines",
+			Entries: []scan.ScanEntry{
+				{Path: "/tmp/vm.img", Size: 40000000000, RiskLevel: "risky"},
+				{Path: "/tmp/small", Size: 1000},
+			},
+		},
+		{
+			Category:    "caches",
+			Description: "Caches",
+			Entries: []scan.ScanEntry{
+				{Path: "/tmp/cache-a", Size: 2000000},
+				{Path: "/tmp/cache-b", Size: 3000000000},
+				{Path: "/tmp/cache-c", Size: 5000},
+			},
+		},
+	}
+	PromptConfirmation(in, out, results)
+
+	output := out.String()
+	idx := strings.Index(output, "Largest 3 items:")
+	if idx < 0 {
+		t.Fatalf("output should contain the largest items section, got:\n%s", output)
+	}
+	section := output[idx:strings.Index(output, "Total:")]
+	want := []string{
+		"1. /tmp/vm.img [risky]  (40.0 GB)",
+		"2. /tmp/cache-b  (3.0 GB)",
+		"3. /tmp/cache-a  (2.0 MB)",
+	}
+	for _, line := range want {
+		if !strings.Contains(section, line) {
+			t.Errorf("largest section missing %q, got:\n%s", line, section)
+		}
+	}
+	if strings.Count(section, "/tmp/") != 3 {
+		t.Errorf("largest section should list exactly three entries, got:\n%s", section)
+	}
+}
+
+func TestConfirmationSkipsLargestForFewEntries(t *testing.T) {
+	in := strings.NewReader("no\n")
+	out := &bytes.Buffer{}
+	PromptConfirmation(in, out, sampleResults())
+
+	if strings.Contains(out.String(), "Largest") {
+		t.Errorf("largest section should be omitted when every entry is already listed, got:\n%s", out.String())
+	}
+}
+
+func TestConfirmationPlainFormat(t *testing.T) {
+	SetFormat(FormatPlain)
+	t.Cleanup(func() { SetFormat(FormatRich) })
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	in := strings.NewReader("yes\n")
+	out := &bytes.Buffer{}
+	if !PromptConfirmation(in, out, sampleResults()) {
+		t.Fatal("expected true for 'yes' input")
+	}
+	got := out.String()
+	if strings.Contains(got, "\x1b[") {
+		t.Errorf("plain output contains ANSI escape codes:\n%q", got)
+	}
+	for _, want := range []string{
+		"category\tTest Category\t" + scan.FormatSize(4500) + "\n",
+		"item\t/tmp/testdir/foo\t" + scan.FormatSize(1500),
+		"item\t/tmp/testdir/bar\t" + scan.FormatSize(3000),
+		"total\t" + scan.FormatSize(4500) + "\t2 items\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("plain output missing %q:\n%s", want, got)
+		}
+	}
+}
+
+func TestPlainFormatUncolorsEveryPrompt(t *testing.T) {
+	SetFormat(FormatPlain)
+	t.Cleanup(func() { SetFormat(FormatRich) })
+	noColor := color.NoColor
+	color.NoColor = false
+	t.Cleanup(func() { color.NoColor = noColor })
+
+	prompts := map[string]func(out *bytes.Buffer){
+		"PromptDocuments": func(out *bytes.Buffer) {
+			PromptDocuments(strings.NewReader("\n"), out, "/tmp/cache", 8, 10)
+		},
+		"PromptAcknowledgement": func(out *bytes.Buffer) {
+			PromptAcknowledgement(strings.NewReader("\n"), out)
+		},
+	}
+	for name, prompt := range prompts {
+		out := &bytes.Buffer{}
+		prompt(out)
+		if strings.Contains(out.String(), "\x1b[") {
+			t.Errorf("%s output contains ANSI escape codes:\n%q", name, out.String())
+		}
+	}
+	if tag := riskTag(scan.ScanEntry{RiskLevel: safety.RiskRisky}); tag != " [risky]" {
+		t.Errorf("riskTag = %q, want it uncolored", tag)
+	}
+}
+
+func TestParseFormat(t *testing.T) {
+	if f, err := ParseFormat("plain"); err != nil || f != FormatPlain {
+		t.Errorf("ParseFormat(plain) = %q, %v", f, err)
+	}
+	if f, err := ParseFormat("rich"); err != nil || f != FormatRich {
+		t.Errorf("ParseFormat(rich) = %q, %v", f, err)
+	}
+	if _, err := ParseFormat("fancy"); err == nil {
+		t.Error("expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,50 @@
+package confirm
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// CleanupCooldown is how long after a cleanup another one is refused
+// without --force, so a quick rerun or a retrying script does not delete
+// caches that were recreated and are in use.
+const CleanupCooldown = 60 * time.Second
+
+// LastCleanupPath returns the location of the last-cleanup lock file,
+// ~/.config/mac-cleaner/last-cleanup.
+func LastCleanupPath(home string) string {
+	return filepath.Join(home, ".config", "mac-cleaner", "last-cleanup")
+}
+
+// RecordCleanup writes the last-cleanup lock file with the time now,
+// creating its parent directory if needed.
+func RecordCleanup(home string, now time.Time) error {
+	path := LastCleanupPath(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	data := []byte(now.UTC().Format(time.RFC3339Nano) + "\n")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write last cleanup: %w", err)
+	}
+	return nil
+}
+
+// RecentCleanup returns the time of the last recorded cleanup and whether
+// it happened less than window before now. A missing or unreadable lock
+// file counts as no recent cleanup, as does a recorded time in the future.
+func RecentCleanup(home string, window time.Duration, now time.Time) (time.Time, bool) {
+	data, err := os.ReadFile(LastCleanupPath(home))
+	if err != nil {
+		return time.Time{}, false
+	}
+	last, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, false
+	}
+	age := now.Sub(last)
+	return last, age >= 0 && age < window
+}
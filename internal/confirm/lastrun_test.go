@@ -0,0 +1,63 @@
+package confirm
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestRecentCleanup_NoRecord(t *testing.T) {
+	if _, recent := RecentCleanup(t.TempDir(), time.Minute, time.Now()); recent {
+		t.Error("expected no recent cleanup in empty home")
+	}
+}
+
+func TestRecentCleanup_Window(t *testing.T) {
+	home := t.TempDir()
+	at := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := RecordCleanup(home, at); err != nil {
+		t.Fatalf("RecordCleanup: %v", err)
+	}
+	info, err := os.Stat(LastCleanupPath(home))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("expected mode 0600, got %o", info.Mode().Perm())
+	}
+
+	tests := []struct {
+		name       string
+		now        time.Time
+		wantRecent bool
+	}{
+		{"same instant", at, true},
+		{"inside window", at.Add(30 * time.Second), true},
+		{"window elapsed", at.Add(time.Minute), false},
+		{"clock moved back", at.Add(-time.Second), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			last, recent := RecentCleanup(home, time.Minute, tt.now)
+			if recent != tt.wantRecent {
+				t.Errorf("recent = %v, want %v", recent, tt.wantRecent)
+			}
+			if !last.Equal(at) {
+				t.Errorf("last = %v, want %v", last, at)
+			}
+		})
+	}
+}
+
+func TestRecentCleanup_CorruptFile(t *testing.T) {
+	home := t.TempDir()
+	if err := RecordCleanup(home, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(LastCleanupPath(home), []byte("garbage"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, recent := RecentCleanup(home, time.Minute, time.Now()); recent {
+		t.Error("expected a corrupt lock file to be ignored")
+	}
+}
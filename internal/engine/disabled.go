@@ -0,0 +1,173 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ScannersPath returns the location of the saved scanner selection,
+// ~/.config/mac-cleaner/scanners.json.
+func ScannersPath(home string) string {
+	return filepath.Join(home, ".config", "mac-cleaner", "scanners.json")
+}
+
+// scannerSelection is the JSON form of the saved scanner selection.
+type scannerSelection struct {
+	// Disabled lists the IDs of the scanners turned off.
+	Disabled []string `json:"disabled"`
+}
+
+// LoadDisabledScanners reads the IDs of the scanners the user turned off,
+// e.g. {"disabled": ["docker"]}. A missing file yields none.
+func LoadDisabledScanners(home string) ([]string, error) {
+	data, err := os.ReadFile(ScannersPath(home))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read scanner selection: %w", err)
+	}
+	var sel scannerSelection
+	if err := json.Unmarshal(data, &sel); err != nil {
+		return nil, fmt.Errorf("parse scanner selection: %w", err)
+	}
+	return sel.Disabled, nil
+}
+
+// SaveDisabledScanners writes ids as the scanners turned off, creating
+// the parent directory if needed. The file is written beside the old one
+// and renamed over it, so a crash mid-write never leaves it truncated.
+func SaveDisabledScanners(home string, ids []string) error {
+	path := ScannersPath(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	sel := scannerSelection{Disabled: ids}
+	if sel.Disabled == nil {
+		sel.Disabled = []string{}
+	}
+	data, err := json.MarshalIndent(sel, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode scanner selection: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".scanners-*.json")
+	if err != nil {
+		return fmt.Errorf("write scanner selection: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write scanner selection: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write scanner selection: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write scanner selection: %w", err)
+	}
+	return nil
+}
+
+// SetDisabled replaces the set of disabled scanners. ScanAll and
+// EstimateReclaimable do not run them, so their categories are absent
+// from results. A scan already running keeps the set it started with.
+func (e *Engine) SetDisabled(ids []string) {
+	disabled := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		disabled[id] = true
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.disabled = disabled
+}
+
+// SetScannerEnabled turns the scanners with the given IDs on or off and
+// returns the resulting disabled set, sorted. When save is non-nil it is
+// called with that set first, and if it fails nothing changes. Calls are
+// serialized, so concurrent changes from different clients are all kept.
+func (e *Engine) SetScannerEnabled(ids []string, enabled bool, save func([]string) error) ([]string, error) {
+	e.selectionMu.Lock()
+	defer e.selectionMu.Unlock()
+
+	disabled := make(map[string]bool)
+	for _, id := range e.Disabled() {
+		disabled[id] = true
+	}
+	for _, id := range ids {
+		if enabled {
+			delete(disabled, id)
+		} else {
+			disabled[id] = true
+		}
+	}
+	next := make([]string, 0, len(disabled))
+	for id := range disabled {
+		next = append(next, id)
+	}
+	sort.Strings(next)
+	if save != nil {
+		if err := save(next); err != nil {
+			return nil, err
+		}
+	}
+	e.SetDisabled(next)
+	return next, nil
+}
+
+// Disabled returns the IDs of the disabled scanners, sorted.
+func (e *Engine) Disabled() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	ids := make([]string, 0, len(e.disabled))
+	for id := range e.disabled {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// ScannerEnabled reports whether the scanner with the given ID runs in
+// ScanAll, that is whether it was not disabled with SetDisabled.
+func (e *Engine) ScannerEnabled(id string) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return !e.disabled[id]
+}
+
+// enabledScanners returns a snapshot, taken once per scan, of the
+// registered scanners not disabled and of the disabled set.
+func (e *Engine) enabledScanners() ([]Scanner, map[string]bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	disabled := make(map[string]bool, len(e.disabled))
+	for id := range e.disabled {
+		disabled[id] = true
+	}
+	scanners := make([]Scanner, 0, len(e.scanners))
+	for _, s := range e.scanners {
+		if !disabled[s.Info().ID] {
+			scanners = append(scanners, s)
+		}
+	}
+	return scanners, disabled
+}
+
+// resultKey builds the result cache key for a scan with the given skip
+// set and disabled scanners, so results are not reused once the set of
+// enabled scanners changed.
+func resultKey(skip, disabled map[string]bool) string {
+	key := cacheKey(skip)
+	if len(disabled) == 0 {
+		return key
+	}
+	ids := make([]string, 0, len(disabled))
+	for id := range disabled {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return key + ";disabled=" + strings.Join(ids, ",")
+}
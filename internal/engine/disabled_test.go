@@ -0,0 +1,111 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+func TestDisabledScanners_RoundTrip(t *testing.T) {
+	home := t.TempDir()
+	if ids, err := LoadDisabledScanners(home); err != nil || ids != nil {
+		t.Fatalf("missing file: got %v, %v; want nil, nil", ids, err)
+	}
+
+	if err := SaveDisabledScanners(home, []string{"docker", "xcode"}); err != nil {
+		t.Fatalf("SaveDisabledScanners: %v", err)
+	}
+	ids, err := LoadDisabledScanners(home)
+	if err != nil {
+		t.Fatalf("LoadDisabledScanners: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "docker" || ids[1] != "xcode" {
+		t.Errorf("loaded %v, want [docker xcode]", ids)
+	}
+}
+
+func TestScanAll_SkipsDisabledScanner(t *testing.T) {
+	eng := New()
+	eng.CacheTTL = time.Minute
+	eng.Register(mockScanner("a", "A", []scan.CategoryResult{{Category: "cat-a", TotalSize: 1}}, nil))
+	eng.Register(mockScanner("b", "B", []scan.CategoryResult{{Category: "cat-b", TotalSize: 2}}, nil))
+
+	scanIDs := func() ([]string, int) {
+		events, done := eng.ScanAll(context.Background(), nil)
+		collected := drainEvents(events)
+		count := 0
+		if len(collected) > 0 {
+			count = collected[0].ScannerCount
+		}
+		var ids []string
+		for _, cr := range (<-done).Results {
+			ids = append(ids, cr.Category)
+		}
+		return ids, count
+	}
+
+	// Prime the cache so the disabled scan must not reuse it.
+	scanIDs()
+	eng.SetDisabled([]string{"b"})
+	if eng.ScannerEnabled("b") || !eng.ScannerEnabled("a") {
+		t.Fatalf("ScannerEnabled: a=%v b=%v", eng.ScannerEnabled("a"), eng.ScannerEnabled("b"))
+	}
+	ids, count := scanIDs()
+	if len(ids) != 1 || ids[0] != "cat-a" || count != 1 {
+		t.Errorf("with b disabled: categories %v, scanner count %d; want [cat-a], 1", ids, count)
+	}
+
+	eng.SetDisabled(nil)
+	if ids, _ := scanIDs(); len(ids) != 2 {
+		t.Errorf("after re-enabling: categories %v, want both", ids)
+	}
+}
+
+func TestSetScannerEnabled_ConcurrentChangesAreKept(t *testing.T) {
+	eng := New()
+	home := t.TempDir()
+	save := func(ids []string) error { return SaveDisabledScanners(home, ids) }
+
+	ids := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	var wg sync.WaitGroup
+	for _, id := range ids {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			if _, err := eng.SetScannerEnabled([]string{id}, false, save); err != nil {
+				t.Errorf("SetScannerEnabled(%s): %v", id, err)
+			}
+		}(id)
+	}
+	wg.Wait()
+
+	if got := eng.Disabled(); len(got) != len(ids) {
+		t.Errorf("disabled %v, want all of %v", got, ids)
+	}
+	saved, err := LoadDisabledScanners(home)
+	if err != nil || len(saved) != len(ids) {
+		t.Errorf("saved %v (%v), want all of %v", saved, err, ids)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(ScannersPath(home)))
+	if len(entries) != 1 {
+		t.Errorf("config dir holds %d files, want only scanners.json", len(entries))
+	}
+}
+
+func TestSetScannerEnabled_SaveFailureChangesNothing(t *testing.T) {
+	eng := New()
+	eng.SetDisabled([]string{"a"})
+	_, err := eng.SetScannerEnabled([]string{"b"}, false, func([]string) error { return errors.New("disk full") })
+	if err == nil {
+		t.Fatal("expected the save error")
+	}
+	if got := eng.Disabled(); len(got) != 1 || got[0] != "a" {
+		t.Errorf("disabled %v after failed save, want [a]", got)
+	}
+}
@@ -0,0 +1,165 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// ScannerStatus is one scanner's outcome in a Doctor report.
+type ScannerStatus struct {
+	ScannerID string          `json:"scanner_id"`
+	Name      string          `json:"name"`
+	Status    scan.ScanStatus `json:"status"`
+	// Detail explains Status: the missing commands, the error, or empty.
+	Detail string `json:"detail,omitempty"`
+	// Categories is the number of categories with data.
+	Categories       int   `json:"categories"`
+	TotalSize        int64 `json:"total_size"`
+	PermissionIssues int   `json:"permission_issues"`
+	// Skipped lists the categories the scanner could not check.
+	Skipped []scan.CategoryStatus `json:"skipped,omitempty"`
+}
+
+// Doctor runs every registered scanner and reports, per scanner, whether
+// it found data, found nothing, could not check (a command is missing,
+// external commands are disabled, or none of its directories exist), hit
+// permission issues, or failed. It ignores the result cache, streams no
+// events and issues no cleanup token. Scanners still running at
+// ScanTimeout, and those after them, are reported as errors.
+func (e *Engine) Doctor(ctx context.Context) ([]ScannerStatus, error) {
+	home := e.Home
+	if home == "" {
+		home, _ = safety.Home()
+	}
+
+	var deadline <-chan time.Time
+	if e.ScanTimeout > 0 {
+		timer := time.NewTimer(e.ScanTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	statuses := make([]ScannerStatus, 0, len(e.scanners))
+	timedOut := false
+	for _, s := range e.scanners {
+		if ctx.Err() != nil {
+			return nil, &CancelledError{Operation: "scan"}
+		}
+		info := s.Info()
+		if timedOut {
+			err := &TimeoutError{ScannerID: info.ID, Timeout: e.ScanTimeout}
+			statuses = append(statuses, ScannerStatus{ScannerID: info.ID, Name: info.Name, Status: scan.StatusError, Detail: err.Error()})
+			continue
+		}
+
+		start := time.Now()
+		results, reported, ok, err := scanWithStatus(ctx, s, deadline)
+		if ctx.Err() != nil {
+			return nil, &CancelledError{Operation: "scan"}
+		}
+		if !ok {
+			timedOut = true
+			err = &TimeoutError{ScannerID: info.ID, Timeout: e.ScanTimeout}
+		}
+		logScan(info, results, err, time.Since(start))
+		if err == nil {
+			e.finishResults(info, results)
+		}
+		var skipped []scan.CategoryStatus
+		for _, cs := range reported {
+			if !cs.Checked() {
+				skipped = append(skipped, cs)
+			}
+		}
+		statuses = append(statuses, classifyScan(s, home, results, skipped, err))
+	}
+	return statuses, nil
+}
+
+// classifyScan builds the ScannerStatus for one scanner's results. Data
+// found wins over skips, so a scanner that found data is StatusFound even
+// when one of its categories could not be checked.
+func classifyScan(s Scanner, home string, results []scan.CategoryResult, skipped []scan.CategoryStatus, err error) ScannerStatus {
+	info := s.Info()
+	st := ScannerStatus{ScannerID: info.ID, Name: info.Name, Skipped: skipped}
+	if err != nil {
+		st.Status = scan.StatusError
+		st.Detail = err.Error()
+		return st
+	}
+	for _, cr := range results {
+		st.PermissionIssues += len(cr.PermissionIssues)
+		if len(cr.Entries) > 0 {
+			st.Categories++
+			st.TotalSize += cr.TotalSize
+		}
+	}
+
+	switch {
+	case st.Categories > 0:
+		st.Status = scan.StatusFound
+	case st.PermissionIssues > 0:
+		st.Status = scan.StatusPermission
+	case hasSkip(skipped, scan.StatusToolMissing):
+		st.Status = scan.StatusToolMissing
+		st.Detail = skipDetails(skipped, scan.StatusToolMissing)
+	case hasSkip(skipped, scan.StatusDisabled):
+		st.Status = scan.StatusDisabled
+	case hasSkip(skipped, scan.StatusError):
+		st.Status = scan.StatusError
+		st.Detail = skipDetails(skipped, scan.StatusError)
+	case !anyPathExists(s, home):
+		st.Status = scan.StatusDirAbsent
+	default:
+		st.Status = scan.StatusNoData
+	}
+	return st
+}
+
+// hasSkip reports whether any skip has the given status.
+func hasSkip(skipped []scan.CategoryStatus, status scan.ScanStatus) bool {
+	for _, sk := range skipped {
+		if sk.Status == status {
+			return true
+		}
+	}
+	return false
+}
+
+// skipDetails joins the distinct details of skips with the given status.
+func skipDetails(skipped []scan.CategoryStatus, status scan.ScanStatus) string {
+	var details []string
+	seen := map[string]bool{}
+	for _, sk := range skipped {
+		if sk.Status != status || sk.Detail == "" || seen[sk.Detail] {
+			continue
+		}
+		seen[sk.Detail] = true
+		details = append(details, sk.Detail)
+	}
+	return strings.Join(details, ", ")
+}
+
+// anyPathExists reports whether any path s examines under home exists.
+// Scanners that list no paths are assumed to have one.
+func anyPathExists(s Scanner, home string) bool {
+	pl, ok := s.(PathLister)
+	if !ok {
+		return true
+	}
+	paths := pl.Paths(home)
+	if len(paths) == 0 {
+		return true
+	}
+	for _, p := range paths {
+		if _, err := os.Lstat(p); err == nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+func TestDoctor_ClassifiesScanners(t *testing.T) {
+	dir := t.TempDir()
+	eng := New()
+	eng.Register(mockScanner("data", "Data", []scan.CategoryResult{
+		{Category: "cat-1", TotalSize: 10, Entries: []scan.ScanEntry{{Path: "/a", Size: 10}}},
+	}, nil))
+	eng.Register(NewStatusScanner(ScannerInfo{ID: "tool", Name: "Tool"}, func(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+		report.Report("cat-tool", scan.StatusToolMissing, "brew")
+		return nil, nil
+	}))
+	eng.Register(NewScannerWithPaths(ScannerInfo{ID: "empty", Name: "Empty"}, func() ([]scan.CategoryResult, error) {
+		return nil, nil
+	}, func(string) []string { return []string{dir} }))
+	eng.Register(NewScannerWithPaths(ScannerInfo{ID: "absent", Name: "Absent"}, func() ([]scan.CategoryResult, error) {
+		return nil, nil
+	}, func(string) []string { return []string{filepath.Join(dir, "missing")} }))
+	eng.Register(mockScanner("perm", "Perm", []scan.CategoryResult{
+		{Category: "cat-p", PermissionIssues: []scan.PermissionIssue{{Path: "/p"}}},
+	}, nil))
+	eng.Register(mockScanner("broken", "Broken", nil, errors.New("boom")))
+
+	statuses, err := eng.Doctor(context.Background())
+	if err != nil {
+		t.Fatalf("Doctor: %v", err)
+	}
+	want := map[string]scan.ScanStatus{
+		"data":   scan.StatusFound,
+		"tool":   scan.StatusToolMissing,
+		"empty":  scan.StatusNoData,
+		"absent": scan.StatusDirAbsent,
+		"perm":   scan.StatusPermission,
+		"broken": scan.StatusError,
+	}
+	if len(statuses) != len(want) {
+		t.Fatalf("expected %d statuses, got %+v", len(want), statuses)
+	}
+	for _, st := range statuses {
+		if st.Status != want[st.ScannerID] {
+			t.Errorf("%s: status %q, want %q", st.ScannerID, st.Status, want[st.ScannerID])
+		}
+	}
+	if statuses[0].TotalSize != 10 || statuses[0].Categories != 1 {
+		t.Errorf("unexpected data totals: %+v", statuses[0])
+	}
+	if statuses[1].Detail != "brew" || len(statuses[1].Skipped) != 1 {
+		t.Errorf("expected brew skip recorded, got %+v", statuses[1])
+	}
+}
+
+func TestDoctor_FoundWinsOverSkip(t *testing.T) {
+	eng := New()
+	eng.Register(NewStatusScanner(ScannerInfo{ID: "mixed"}, func(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+		report.Report("cat-tool", scan.StatusToolMissing, "tmutil")
+		return []scan.CategoryResult{{Category: "cat-1", TotalSize: 5, Entries: []scan.ScanEntry{{Path: "/a", Size: 5}}}}, nil
+	}))
+	statuses, err := eng.Doctor(context.Background())
+	if err != nil {
+		t.Fatalf("Doctor: %v", err)
+	}
+	if st := statuses[0]; st.Status != scan.StatusFound || len(st.Skipped) != 1 {
+		t.Errorf("expected found with one skip, got %+v", st)
+	}
+}
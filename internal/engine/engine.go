@@ -4,29 +4,53 @@ package engine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/logging"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
 // ScanEvent reports progress during a scan operation.
 type ScanEvent struct {
-	// Type is one of "scanner_start", "scanner_done", "scanner_error".
+	// Type is one of the EventScan* or EventScanner* constants.
 	Type string
-	// ScannerID identifies which scanner group emitted the event.
+	// ScannerID identifies which scanner group emitted the event. Empty on
+	// "scan_start" and "scan_complete" events.
 	ScannerID string
 	// Label is the human-readable scanner group name.
 	Label string
 	// Results is populated on "scanner_done" events.
 	Results []scan.CategoryResult
+	// Statuses is populated on "scanner_done" events with the category
+	// statuses the scanner reported, so an empty result can be told apart
+	// from a category that was not checked.
+	Statuses []scan.CategoryStatus
 	// Err is populated on "scanner_error" events.
 	Err error
+	// Duration is the scanner's wall-clock run time on "scanner_done"
+	// events, and the whole scan's run time on "scan_complete" events.
+	Duration time.Duration
+	// ScannerCount is the number of scanners that will run, populated on
+	// "scan_start" events.
+	ScannerCount int
+	// TotalSize and CategoryCount aggregate the final results, after skip
+	// filtering, on "scan_complete" events.
+	TotalSize     int64
+	CategoryCount int
 }
 
-// Scan event types.
+// Scan event types. A fresh ScanAll emits EventScanStart first and
+// EventScanComplete last, with the per-scanner events in between.
 const (
+	EventScanStart    = "scan_start"
+	EventScanComplete = "scan_complete"
 	EventScannerStart = "scanner_start"
 	EventScannerDone  = "scanner_done"
 	EventScannerError = "scanner_error"
@@ -44,8 +68,15 @@ type CleanupEvent struct {
 	Current int
 	// Total is the overall item count.
 	Total int
+	// AvailableBytes is the free space on the home volume, sampled at most
+	// every diskSampleInterval on entry events. Zero when not sampled.
+	AvailableBytes int64
 }
 
+// diskSampleInterval is the minimum time between free-space samples
+// attached to cleanup entry events.
+const diskSampleInterval = 500 * time.Millisecond
+
 // Cleanup event types.
 const (
 	EventCleanupCategoryStart = "cleanup_category_start"
@@ -58,6 +89,19 @@ const (
 type ScanResult struct {
 	Results []scan.CategoryResult
 	Token   ScanToken
+	// Cached is true when the results were served from the result cache
+	// instead of a fresh scan.
+	Cached bool
+	// ScannedAt is when the results were produced: for cached results,
+	// the time of the scan that produced them.
+	ScannedAt time.Time
+	// TimedOut is true when ScanTimeout expired before every scanner
+	// finished. Results then hold only the scanners that completed.
+	TimedOut bool
+	// Err is set when Strict stopped the scan at a failing scanner. It is
+	// a *ScanError, or a *TimeoutError when ScanTimeout expired; Results
+	// and Token are then empty.
+	Err error
 }
 
 // CleanupDone holds the final outcome of a Cleanup operation.
@@ -69,73 +113,407 @@ type CleanupDone struct {
 // Engine orchestrates scanning and cleanup operations. It holds the
 // scanner registry and token store. Safe for concurrent use.
 type Engine struct {
-	scanners  []Scanner
-	mu        sync.Mutex
-	lastToken struct {
-		token ScanToken
-		entry *tokenEntry
-	}
+	// CacheTTL is how long ScanAll results stay reusable for identical
+	// scan params. Zero (the default) disables the cache. Set it before
+	// the engine is shared between goroutines.
+	CacheTTL time.Duration
+	// ScanTimeout bounds the total run time of ScanAll. When it expires,
+	// the running scanner and all remaining ones are reported with a
+	// TimeoutError and the completed results are returned. Zero (the
+	// default) means no deadline.
+	ScanTimeout time.Duration
+	// AppDirs lists extra application directories searched by the
+	// built-in unused-apps scanner, in addition to /Applications,
+	// /Applications/Utilities and ~/Applications.
+	AppDirs []string
+	// ScanTmpCaches opts in to the system-tmp-caches category: app caches
+	// in the per-user /private/var/folders cache directory.
+	ScanTmpCaches bool
+	// KeepLatestDeviceSupport leaves the newest iOS DeviceSupport version
+	// out of the dev-xcode-device-support category.
+	KeepLatestDeviceSupport bool
+	// ScanNodeModules opts in to the dev-node-modules category: stale
+	// node_modules directories under ProjectRoots.
+	ScanNodeModules bool
+	// ScanPyEnvs opts in to the dev-pyenvs category: stale Python
+	// virtualenvs and __pycache__ directories under ProjectRoots.
+	ScanPyEnvs bool
+	// ProjectRoots lists the directories searched for node_modules and
+	// Python environments. Nil means ~/Developer, ~/Projects and
+	// ~/Documents/code.
+	ProjectRoots []string
+	// IncludeHidden makes app-old-downloads consider dot-prefixed entries
+	// in ~/Downloads, and the node_modules and Python environment searches
+	// descend into hidden directories under ProjectRoots. Off by default.
+	IncludeHidden bool
+	// CategoryOrder lists category IDs in their canonical output order.
+	// ScanAll sorts its results by it so output does not depend on the
+	// order scanners complete in. Nil keeps scanner order.
+	CategoryOrder []string
+	// Freshness maps category IDs to a "don't touch if modified within"
+	// window: entries containing anything modified more recently are
+	// moved to the category's RecentlyModified list as each scanner
+	// returns. Categories without a window (or with zero) are not guarded.
+	// See LoadFreshness.
+	Freshness map[string]time.Duration
+	// RootDeletable counts entries flagged RequiresRoot as deletable, for
+	// callers that remove them with sudo. Otherwise they are marked
+	// informational and left out of category totals, like pseudo-paths
+	// without a cleanup handler (see cleanup.Deletable).
+	RootDeletable bool
+	// NoExec keeps scanners from running external commands (docker, brew,
+	// tmutil, mdls, PlistBuddy). Categories with a filesystem fallback use
+	// it; the rest are skipped.
+	NoExec bool
+	// RemovalTimeout bounds the removal of each entry during Cleanup; an
+	// entry that takes longer is reported as failed and cleanup moves on.
+	// Zero waits for every removal (see cleanup.Options.EntryTimeout).
+	RemovalTimeout time.Duration
+	// Strict makes ScanAll stop at the first scanner error instead of
+	// returning partial results: no further scanner runs, no scan_complete
+	// event is sent and ScanResult.Err carries the error.
+	Strict bool
+	// Home is the home directory the scanners examine, e.g. another
+	// account's for an audit. Empty means the current user's. Callers
+	// should contain cleanup to the same directory (see safety.SetHome).
+	Home string
+	// MaxDepth bounds how deep the entries of the top-level caches are
+	// sized, for a fast overview; truncated entries and categories are
+	// marked (see scan.ScanTopLevelDepth). Zero sizes everything.
+	MaxDepth int
+
+	scanners []Scanner
+	// disabled holds the IDs of the scanners turned off with SetDisabled.
+	disabled map[string]bool
+	mu       sync.Mutex
+	// selectionMu serializes SetScannerEnabled, which reads, saves and
+	// replaces the disabled set.
+	selectionMu sync.Mutex
+	// tokens holds the scan results awaiting cleanup. It is a pointer so
+	// ShareTokens can hand it to a replacement engine.
+	tokens *tokenStore
 }
 
 // New creates an Engine with an empty scanner registry.
 func New() *Engine {
-	return &Engine{}
+	return &Engine{tokens: &tokenStore{}}
 }
 
-// ScanAll runs all registered scanners sequentially, streaming events
+// ScanAll runs all enabled scanners sequentially, streaming events
 // through the returned channel. The done channel receives exactly one
 // ScanResult when all scanners complete (or context is cancelled).
-// The skip set filters category IDs from the final output.
+// The skip set filters category IDs from the final output. Scanners
+// turned off with SetDisabled are not run and not counted.
+//
+// The first event is always scan_start and, unless the context is
+// cancelled, the last is scan_complete with the aggregated totals.
+//
+// When CacheTTL is set and an identical scan completed within the TTL
+// (and its token has not been consumed by a cleanup), the prior results
+// and token are returned without running any scanner. The events are
+// replayed from them: scan_start, a scanner_start and scanner_done pair
+// per scanner carrying its cached categories, and scan_complete.
+//
+// When ScanTimeout is set and expires, ScanAll stops waiting: the scanner
+// in progress and every scanner not yet started get a scanner_error event
+// carrying a *TimeoutError, and the partial results are returned with
+// TimedOut set. Partial results are never cached. Cancelling ctx likewise
+// stops waiting on the scanner in progress. A scanner that was abandoned
+// keeps running in the background until its Scan returns.
+//
+// When Strict is set, the first scanner error ends the scan instead (see
+// ScanResult.Err).
 func (e *Engine) ScanAll(ctx context.Context, skip map[string]bool) (<-chan ScanEvent, <-chan ScanResult) {
 	events := make(chan ScanEvent)
 	done := make(chan ScanResult, 1)
+	scanners, disabled := e.enabledScanners()
+	key := resultKey(skip, disabled)
 
 	go func() {
 		defer close(events)
 		defer close(done)
 
+		if results, token, scannedAt, ok := e.cachedResults(key); ok {
+			if !replayCached(ctx, events, scanners, results) {
+				return
+			}
+			done <- ScanResult{Results: results, Token: token, Cached: true, ScannedAt: scannedAt}
+			return
+		}
+
+		var deadline <-chan time.Time
+		if e.ScanTimeout > 0 {
+			timer := time.NewTimer(e.ScanTimeout)
+			defer timer.Stop()
+			deadline = timer.C
+		}
+
+		scanStart := time.Now()
+		select {
+		case events <- ScanEvent{Type: EventScanStart, ScannerCount: len(scanners)}:
+		case <-ctx.Done():
+			return
+		}
+
 		var all []scan.CategoryResult
-		for _, s := range e.scanners {
+		timedOut := false
+		for _, s := range scanners {
 			if ctx.Err() != nil {
 				return
 			}
 
 			info := s.Info()
+			if timedOut {
+				select {
+				case events <- ScanEvent{Type: EventScannerError, ScannerID: info.ID, Label: info.Name, Err: &TimeoutError{ScannerID: info.ID, Timeout: e.ScanTimeout}}:
+				case <-ctx.Done():
+					return
+				}
+				continue
+			}
+
 			select {
 			case events <- ScanEvent{Type: EventScannerStart, ScannerID: info.ID, Label: info.Name}:
 			case <-ctx.Done():
 				return
 			}
 
-			results, err := s.Scan()
+			start := time.Now()
+			results, statuses, ok, err := scanWithStatus(ctx, s, deadline)
+			elapsed := time.Since(start)
+			if ctx.Err() != nil {
+				return
+			}
+			if !ok {
+				timedOut = true
+				err = &TimeoutError{ScannerID: info.ID, Timeout: e.ScanTimeout}
+			}
+			logScan(info, results, err, elapsed)
 			if err != nil {
 				select {
 				case events <- ScanEvent{Type: EventScannerError, ScannerID: info.ID, Label: info.Name, Err: err}:
 				case <-ctx.Done():
 					return
 				}
+				if e.Strict {
+					if ok {
+						err = &ScanError{ScannerID: info.ID, Err: err}
+					}
+					done <- ScanResult{Err: err}
+					return
+				}
 				continue
 			}
 
+			e.finishResults(info, results)
 			select {
-			case events <- ScanEvent{Type: EventScannerDone, ScannerID: info.ID, Label: info.Name, Results: results}:
+			case events <- ScanEvent{Type: EventScannerDone, ScannerID: info.ID, Label: info.Name, Results: results, Statuses: statuses, Duration: elapsed}:
 			case <-ctx.Done():
 				return
 			}
 			all = append(all, results...)
 		}
 
+		SortCategories(all, e.CategoryOrder)
 		filtered := FilterSkipped(all, skip)
-		token := e.storeResults(filtered)
-		done <- ScanResult{Results: filtered, Token: token}
+		complete := ScanEvent{
+			Type:          EventScanComplete,
+			Duration:      time.Since(scanStart),
+			CategoryCount: len(filtered),
+		}
+		for _, cr := range filtered {
+			complete.TotalSize += cr.TotalSize
+		}
+		select {
+		case events <- complete:
+		case <-ctx.Done():
+			return
+		}
+
+		storeKey := key
+		if timedOut {
+			// An empty key never matches cacheKey, so partial results
+			// are not served from the cache.
+			storeKey = ""
+		}
+		token, scannedAt := e.storeResults(filtered, storeKey)
+		done <- ScanResult{Results: filtered, Token: token, TimedOut: timedOut, ScannedAt: scannedAt}
 	}()
 
 	return events, done
 }
 
+// replayCached emits the events of a scan whose results were served from
+// the cache, giving each scanner the cached categories it lists in
+// ScannerInfo.CategoryIDs. It returns false if ctx ended first.
+func replayCached(ctx context.Context, events chan<- ScanEvent, scanners []Scanner, results []scan.CategoryResult) bool {
+	send := func(ev ScanEvent) bool {
+		select {
+		case events <- ev:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	if !send(ScanEvent{Type: EventScanStart, ScannerCount: len(scanners)}) {
+		return false
+	}
+	complete := ScanEvent{Type: EventScanComplete, CategoryCount: len(results)}
+	for _, cr := range results {
+		complete.TotalSize += cr.TotalSize
+	}
+	for _, s := range scanners {
+		info := s.Info()
+		owned := make(map[string]bool, len(info.CategoryIDs))
+		for _, id := range info.CategoryIDs {
+			owned[id] = true
+		}
+		var mine []scan.CategoryResult
+		for _, cr := range results {
+			if owned[cr.Category] {
+				mine = append(mine, cr)
+			}
+		}
+		if !send(ScanEvent{Type: EventScannerStart, ScannerID: info.ID, Label: info.Name}) ||
+			!send(ScanEvent{Type: EventScannerDone, ScannerID: info.ID, Label: info.Name, Results: mine}) {
+			return false
+		}
+	}
+	return send(complete)
+}
+
+// scanWithDeadline runs s.Scan and waits for it until deadline fires or
+// ctx is done. A nil deadline waits indefinitely. ok is false if the
+// deadline fired or ctx ended first; the scanner goroutine is then
+// abandoned and its result dropped.
+func scanWithDeadline(ctx context.Context, s Scanner, deadline <-chan time.Time) (results []scan.CategoryResult, ok bool, err error) {
+	return runWithDeadline(ctx, s.Scan, deadline)
+}
+
+// runWithDeadline runs scanFn for scanWithDeadline and scanWithStatus.
+func runWithDeadline(ctx context.Context, scanFn func() ([]scan.CategoryResult, error), deadline <-chan time.Time) (results []scan.CategoryResult, ok bool, err error) {
+	if deadline == nil && ctx.Done() == nil {
+		results, err = scanFn()
+		return results, true, err
+	}
+
+	type outcome struct {
+		results []scan.CategoryResult
+		err     error
+	}
+	ch := make(chan outcome, 1)
+	go func() {
+		r, err := scanFn()
+		ch <- outcome{r, err}
+	}()
+
+	select {
+	case o := <-ch:
+		return o.results, true, o.err
+	case <-deadline:
+		return nil, false, nil
+	case <-ctx.Done():
+		return nil, false, nil
+	}
+}
+
+// scanWithStatus is scanWithDeadline that also collects the category
+// statuses s reports while it runs, if it is a StatusScanner. Each call
+// collects into its own list, and statuses an abandoned scanner reports
+// after a timeout are dropped.
+func scanWithStatus(ctx context.Context, s Scanner, deadline <-chan time.Time) (results []scan.CategoryResult, statuses []scan.CategoryStatus, ok bool, err error) {
+	ss, reports := s.(StatusScanner)
+	if !reports {
+		results, ok, err = scanWithDeadline(ctx, s, deadline)
+		return results, nil, ok, err
+	}
+
+	var mu sync.Mutex
+	var reported []scan.CategoryStatus
+	closed := false
+	report := func(cs scan.CategoryStatus) {
+		mu.Lock()
+		defer mu.Unlock()
+		if !closed {
+			reported = append(reported, cs)
+		}
+	}
+	results, ok, err = runWithDeadline(ctx, func() ([]scan.CategoryResult, error) {
+		return ss.ScanWithStatus(report)
+	}, deadline)
+
+	mu.Lock()
+	defer mu.Unlock()
+	closed = true
+	return results, reported, ok, err
+}
+
+// logScan records a scanner's outcome: an error or timeout and each
+// permission issue at warn level, completion and its duration at debug.
+func logScan(info ScannerInfo, results []scan.CategoryResult, err error, elapsed time.Duration) {
+	var timeout *TimeoutError
+	switch {
+	case errors.As(err, &timeout):
+		logging.Warn("scanner timed out", "scanner", info.ID, "timeout", timeout.Timeout)
+		return
+	case err != nil:
+		logging.Warn("scanner failed", "scanner", info.ID, "error", err)
+		return
+	}
+	for _, cr := range results {
+		for _, issue := range cr.PermissionIssues {
+			logging.Warn("permission denied", "scanner", info.ID, "category", cr.Category, "path", issue.Path)
+		}
+	}
+	logging.Debug("scanner finished", "scanner", info.ID, "categories", len(results), "duration", elapsed)
+}
+
+// setRiskLevels gives the entries in results the risk level info
+// declares for their category, or safety.RiskForCategory when it declares
+// none. A declared level also replaces the central default the scanner may
+// already have applied, but not a level the scanner chose for an entry.
+func setRiskLevels(info ScannerInfo, results []scan.CategoryResult) {
+	for i := range results {
+		cr := &results[i]
+		level, ok := info.CategoryRisks[cr.Category]
+		if !ok {
+			cr.SetRiskLevels(safety.RiskForCategory)
+			continue
+		}
+		central := safety.RiskForCategory(cr.Category)
+		for j := range cr.Entries {
+			if risk := cr.Entries[j].RiskLevel; risk == "" || risk == central {
+				cr.Entries[j].RiskLevel = level
+			}
+		}
+	}
+}
+
+// setPermissionHints attaches safety.PermissionHint remediation to the
+// permission issues in results.
+func setPermissionHints(results []scan.CategoryResult) {
+	for i := range results {
+		results[i].SetPermissionHints(safety.PermissionHint)
+	}
+}
+
+// markDeletable flags the entries in results that cleanup can remove and
+// leaves the rest out of their category totals.
+func (e *Engine) markDeletable(results []scan.CategoryResult) {
+	deletable := func(entry scan.ScanEntry) bool {
+		return cleanup.Deletable(entry, e.RootDeletable)
+	}
+	for i := range results {
+		results[i].MarkDeletable(deletable)
+	}
+}
+
 // Run executes a single scanner synchronously and returns its results.
 // Returns an error if the scanner ID is not found, the context is
-// cancelled, or the scanner itself fails.
+// cancelled, or the scanner itself fails. When ctx has a deadline that
+// passes before the scanner finishes, Run stops waiting for it and
+// returns a *TimeoutError, so callers running several scanners can share
+// one overall deadline.
 func (e *Engine) Run(ctx context.Context, scannerID string) ([]scan.CategoryResult, error) {
 	var target Scanner
 	for _, s := range e.scanners {
@@ -149,19 +527,50 @@ func (e *Engine) Run(ctx context.Context, scannerID string) ([]scan.CategoryResu
 	}
 
 	if ctx.Err() != nil {
-		return nil, &CancelledError{Operation: "scan"}
+		return nil, e.runError(ctx, scannerID)
 	}
 
-	results, err := target.Scan()
+	start := time.Now()
+	results, ok, err := scanWithDeadline(ctx, target, nil)
+	if !ok {
+		err = e.runError(ctx, scannerID)
+		logScan(target.Info(), nil, err, time.Since(start))
+		return nil, err
+	}
+	logScan(target.Info(), results, err, time.Since(start))
 	if err != nil {
 		return nil, &ScanError{ScannerID: scannerID, Err: err}
 	}
+	e.finishResults(target.Info(), results)
 	return results, nil
 }
 
+// finishResults applies to a scanner's results everything the engine
+// adds after scanning: risk levels, permission hints, freshness windows
+// and the deletable flag. Every path that scans goes through it, so the
+// results agree whichever request produced them.
+func (e *Engine) finishResults(info ScannerInfo, results []scan.CategoryResult) {
+	setRiskLevels(info, results)
+	setPermissionHints(results)
+	e.applyFreshness(results)
+	e.markDeletable(results)
+}
+
+// runError is the error Run returns once ctx is done: a *TimeoutError
+// when its deadline passed, a *CancelledError otherwise.
+func (e *Engine) runError(ctx context.Context, scannerID string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{ScannerID: scannerID, Timeout: e.ScanTimeout}
+	}
+	return &CancelledError{Operation: "scan"}
+}
+
 // Cleanup removes files for the given categories from a prior scan.
 // The token must match a prior ScanAll call and is consumed (one-time use).
-// If categoryIDs is empty, all categories from the scan are cleaned.
+// If categoryIDs is empty, all categories from the scan are cleaned except
+// ReportOnly ones, which are cleaned only when listed; for a token marked
+// with MarkFiltered that fails with a *FilteredError instead, leaving the
+// token valid.
 // Returns an events channel for progress and a done channel for the final result.
 func (e *Engine) Cleanup(ctx context.Context, token ScanToken, categoryIDs []string) (<-chan CleanupEvent, <-chan CleanupDone) {
 	events := make(chan CleanupEvent)
@@ -171,53 +580,93 @@ func (e *Engine) Cleanup(ctx context.Context, token ScanToken, categoryIDs []str
 		defer close(events)
 		defer close(done)
 
+		if len(categoryIDs) == 0 && e.tokenFiltered(token) {
+			done <- CleanupDone{Err: &FilteredError{Token: token}}
+			return
+		}
 		results, err := e.validateToken(token)
 		if err != nil {
 			done <- CleanupDone{Err: err}
 			return
 		}
 
-		// Filter by selected categories if specified.
-		toClean := results
-		if len(categoryIDs) > 0 {
-			selected := make(map[string]bool, len(categoryIDs))
-			for _, id := range categoryIDs {
-				selected[id] = true
-			}
-			var filtered []scan.CategoryResult
-			for _, cat := range results {
-				if selected[cat.Category] {
-					filtered = append(filtered, cat)
-				}
-			}
-			toClean = filtered
-		}
+		plan := BuildPlan(results, PlanOptions{Categories: categoryIDs})
+		done <- CleanupDone{Result: executeCleanup(ctx, plan.Categories, events, cleanup.Options{EntryTimeout: e.RemovalTimeout, ExtraRoots: e.AppDirs})}
+	}()
 
-		progressFn := func(categoryDesc, entryPath string, current, total int) {
-			var evtType string
-			if entryPath == "" {
-				evtType = EventCleanupCategoryStart
-			} else {
-				evtType = EventCleanupEntry
-			}
-			evt := CleanupEvent{
-				Type:      evtType,
-				Category:  categoryDesc,
-				EntryPath: entryPath,
-				Current:   current,
-				Total:     total,
-			}
-			select {
-			case events <- evt:
-			case <-ctx.Done():
+	return events, done
+}
+
+// executeCleanup removes the entries in toClean, sending progress to events.
+// Entry events carry a free-space sample at most every diskSampleInterval.
+// opts bounds each entry's removal and names the extra roots entries may
+// resolve under.
+func executeCleanup(ctx context.Context, toClean []scan.CategoryResult, events chan<- CleanupEvent, opts cleanup.Options) cleanup.CleanupResult {
+	home, _ := safety.Home()
+	var lastSample time.Time
+
+	progressFn := func(categoryDesc, entryPath string, current, total int) {
+		var evtType string
+		if entryPath == "" {
+			evtType = EventCleanupCategoryStart
+		} else {
+			evtType = EventCleanupEntry
+		}
+		evt := CleanupEvent{
+			Type:      evtType,
+			Category:  categoryDesc,
+			EntryPath: entryPath,
+			Current:   current,
+			Total:     total,
+		}
+		if evtType == EventCleanupEntry && home != "" && time.Since(lastSample) >= diskSampleInterval {
+			if avail, err := scan.AvailableBytes(home); err == nil {
+				evt.AvailableBytes = avail
+				lastSample = time.Now()
 			}
 		}
+		select {
+		case events <- evt:
+		case <-ctx.Done():
+		}
+	}
 
-		result := cleanup.Execute(toClean, progressFn)
-		done <- CleanupDone{Result: result}
-	}()
+	return cleanup.ExecuteWithOptions(toClean, progressFn, opts)
+}
 
-	return events, done
+// cacheKey builds the result cache key for a skip set from its sorted
+// category IDs. Only IDs set to true contribute to the key.
+func cacheKey(skip map[string]bool) string {
+	ids := make([]string, 0, len(skip))
+	for id, skipped := range skip {
+		if skipped {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return "skip=" + strings.Join(ids, ",")
+}
+
+// SortCategories sorts results in place by each category's position in
+// order. Categories missing from order follow the listed ones in their
+// original relative order.
+func SortCategories(results []scan.CategoryResult, order []string) {
+	if len(order) == 0 {
+		return
+	}
+	rank := make(map[string]int, len(order))
+	for i, id := range order {
+		rank[id] = i
+	}
+	pos := func(id string) int {
+		if r, ok := rank[id]; ok {
+			return r
+		}
+		return len(order)
+	}
+	sort.SliceStable(results, func(i, j int) bool {
+		return pos(results[i].Category) < pos(results[j].Category)
+	})
 }
 
 // FilterSkipped removes categories matching the skip set from results.
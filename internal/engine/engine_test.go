@@ -1,11 +1,17 @@
 package engine
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/sp3esu/mac-cleaner/internal/logging"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -95,6 +101,49 @@ func TestScanAll_AggregatesResults(t *testing.T) {
 	}
 }
 
+func TestScanAll_CanonicalCategoryOrder(t *testing.T) {
+	// delayed returns a scanner whose results arrive after d, listing its
+	// categories in reverse of the canonical order.
+	delayed := func(id string, d time.Duration, cats ...string) Scanner {
+		return NewScanner(ScannerInfo{ID: id, Name: id}, func() ([]scan.CategoryResult, error) {
+			time.Sleep(d)
+			var results []scan.CategoryResult
+			for _, c := range cats {
+				results = append(results, scan.CategoryResult{Category: c, TotalSize: 1})
+			}
+			return results, nil
+		})
+	}
+
+	eng := New()
+	eng.CategoryOrder = []string{"a-1", "a-2", "b-1", "c-1", "c-2"}
+	eng.Register(delayed("c", 5*time.Millisecond, "c-2", "c-1"))
+	eng.Register(delayed("a", 0, "a-2", "a-1"))
+	eng.Register(delayed("b", 10*time.Millisecond, "unlisted", "b-1"))
+
+	events, done := eng.ScanAll(context.Background(), nil)
+	drainEvents(events)
+	result := <-done
+
+	want := []string{"a-1", "a-2", "b-1", "c-1", "c-2", "unlisted"}
+	if len(result.Results) != len(want) {
+		t.Fatalf("expected %d results, got %d", len(want), len(result.Results))
+	}
+	for i, id := range want {
+		if result.Results[i].Category != id {
+			t.Errorf("result[%d] = %q, want %q", i, result.Results[i].Category, id)
+		}
+	}
+}
+
+func TestSortCategories_NilOrderKeepsInput(t *testing.T) {
+	results := []scan.CategoryResult{{Category: "b"}, {Category: "a"}}
+	SortCategories(results, nil)
+	if results[0].Category != "b" || results[1].Category != "a" {
+		t.Errorf("nil order changed results: %v", results)
+	}
+}
+
 func TestScanAll_SkipsErroredScanners(t *testing.T) {
 	eng := New()
 	eng.Register(mockScanner("ok", "OK", []scan.CategoryResult{
@@ -114,6 +163,59 @@ func TestScanAll_SkipsErroredScanners(t *testing.T) {
 	}
 }
 
+func TestScanAll_StrictStopsAtFirstError(t *testing.T) {
+	eng := New()
+	eng.Strict = true
+	ran := false
+	eng.Register(mockScanner("ok", "OK", []scan.CategoryResult{
+		{Category: "ok-1", TotalSize: 100},
+	}, nil))
+	eng.Register(mockScanner("fail", "Fail", nil, errors.New("boom")))
+	eng.Register(NewScanner(ScannerInfo{ID: "later", Name: "Later"}, func() ([]scan.CategoryResult, error) {
+		ran = true
+		return nil, nil
+	}))
+
+	events, done := eng.ScanAll(context.Background(), nil)
+	collected := drainEvents(events)
+	result := <-done
+
+	var scanErr *ScanError
+	if !errors.As(result.Err, &scanErr) || scanErr.ScannerID != "fail" {
+		t.Fatalf("expected ScanError from scanner fail, got %v", result.Err)
+	}
+	if len(result.Results) != 0 || result.Token != "" {
+		t.Errorf("expected no results or token, got %+v", result)
+	}
+	if ran {
+		t.Error("expected no scanner to run after the failure")
+	}
+	for _, ev := range collected {
+		if ev.Type == EventScanComplete {
+			t.Error("expected no scan_complete event in strict mode")
+		}
+	}
+}
+
+func TestScanAll_BestEffortByDefault(t *testing.T) {
+	eng := New()
+	eng.Register(mockScanner("fail", "Fail", nil, errors.New("boom")))
+	eng.Register(mockScanner("ok", "OK", []scan.CategoryResult{
+		{Category: "ok-1", TotalSize: 100},
+	}, nil))
+
+	events, done := eng.ScanAll(context.Background(), nil)
+	drainEvents(events)
+	result := <-done
+
+	if result.Err != nil {
+		t.Errorf("expected no error without Strict, got %v", result.Err)
+	}
+	if len(result.Results) != 1 || result.Token == "" {
+		t.Errorf("expected partial results with a token, got %+v", result)
+	}
+}
+
 func TestScanAll_AppliesSkipSet(t *testing.T) {
 	eng := New()
 	eng.Register(mockScanner("a", "A", []scan.CategoryResult{
@@ -148,19 +250,21 @@ func TestScanAll_ProgressEvents(t *testing.T) {
 	}
 	<-done // drain done channel
 
-	// Expect: start_a, done_a, start_b, error_b
-	if len(collected) != 4 {
-		t.Fatalf("expected 4 events, got %d", len(collected))
+	// Expect: scan_start, start_a, done_a, start_b, error_b, scan_complete
+	if len(collected) != 6 {
+		t.Fatalf("expected 6 events, got %d", len(collected))
 	}
 
 	expected := []struct {
 		typ string
 		id  string
 	}{
+		{EventScanStart, ""},
 		{EventScannerStart, "a"},
 		{EventScannerDone, "a"},
 		{EventScannerStart, "b"},
 		{EventScannerError, "b"},
+		{EventScanComplete, ""},
 	}
 
 	for i, exp := range expected {
@@ -173,16 +277,195 @@ func TestScanAll_ProgressEvents(t *testing.T) {
 	}
 
 	// Done event should carry results.
-	if len(collected[1].Results) != 1 {
-		t.Errorf("done event should have 1 result, got %d", len(collected[1].Results))
+	if len(collected[2].Results) != 1 {
+		t.Errorf("done event should have 1 result, got %d", len(collected[2].Results))
 	}
 
 	// Error event should carry error.
-	if collected[3].Err == nil {
+	if collected[4].Err == nil {
 		t.Error("error event should carry non-nil Err")
 	}
 }
 
+func TestScanAll_LifecycleEvents(t *testing.T) {
+	eng := New()
+	eng.Register(mockScanner("a", "A", []scan.CategoryResult{
+		{Category: "a-1", TotalSize: 100},
+	}, nil))
+	eng.Register(mockScanner("b", "B", []scan.CategoryResult{
+		{Category: "b-1", TotalSize: 200},
+		{Category: "skip-me", TotalSize: 400},
+	}, nil))
+	eng.Register(mockScanner("c", "C", nil, errors.New("fail")))
+
+	events, done := eng.ScanAll(context.Background(), map[string]bool{"skip-me": true})
+	collected := drainEvents(events)
+	<-done
+
+	if len(collected) < 2 {
+		t.Fatalf("expected at least 2 events, got %d", len(collected))
+	}
+	first := collected[0]
+	if first.Type != EventScanStart {
+		t.Fatalf("expected first event %q, got %q", EventScanStart, first.Type)
+	}
+	if first.ScannerCount != 3 {
+		t.Errorf("expected scanner count 3, got %d", first.ScannerCount)
+	}
+
+	last := collected[len(collected)-1]
+	if last.Type != EventScanComplete {
+		t.Fatalf("expected last event %q, got %q", EventScanComplete, last.Type)
+	}
+	if last.TotalSize != 300 {
+		t.Errorf("expected aggregated total 300 (skipped category excluded), got %d", last.TotalSize)
+	}
+	if last.CategoryCount != 2 {
+		t.Errorf("expected category count 2, got %d", last.CategoryCount)
+	}
+	if last.Duration <= 0 {
+		t.Errorf("expected positive scan duration, got %v", last.Duration)
+	}
+}
+
+func TestScanAll_MarksDeletableEntries(t *testing.T) {
+	results := func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{{
+			Category:  "mixed",
+			TotalSize: 111,
+			Entries: []scan.ScanEntry{
+				{Path: "/Users/me/Library/Caches/app", Size: 1},
+				{Path: "docker:BuildCache", Size: 10},
+				{Path: "/Library/Caches/root-owned", Size: 100, RequiresRoot: true},
+			},
+		}}, nil
+	}
+
+	for _, tc := range []struct {
+		rootDeletable bool
+		wantTotal     int64
+		wantRoot      bool
+	}{
+		{rootDeletable: false, wantTotal: 1, wantRoot: false},
+		{rootDeletable: true, wantTotal: 101, wantRoot: true},
+	} {
+		eng := New()
+		eng.RootDeletable = tc.rootDeletable
+		eng.Register(NewScanner(ScannerInfo{ID: "m", Name: "Mixed"}, results))
+
+		events, done := eng.ScanAll(context.Background(), nil)
+		var completeTotal int64
+		for _, ev := range drainEvents(events) {
+			if ev.Type == EventScanComplete {
+				completeTotal = ev.TotalSize
+			}
+		}
+		res := <-done
+
+		cat := res.Results[0]
+		if !cat.Entries[0].Deletable {
+			t.Errorf("RootDeletable=%v: expected filesystem entry deletable", tc.rootDeletable)
+		}
+		if cat.Entries[1].Deletable {
+			t.Errorf("RootDeletable=%v: expected docker pseudo-path not deletable", tc.rootDeletable)
+		}
+		if cat.Entries[2].Deletable != tc.wantRoot {
+			t.Errorf("RootDeletable=%v: root-owned entry Deletable = %v, want %v", tc.rootDeletable, cat.Entries[2].Deletable, tc.wantRoot)
+		}
+		if cat.TotalSize != tc.wantTotal || completeTotal != tc.wantTotal {
+			t.Errorf("RootDeletable=%v: totals %d/%d, want %d", tc.rootDeletable, cat.TotalSize, completeTotal, tc.wantTotal)
+		}
+	}
+}
+
+func TestScanAll_DoneEventReportsDuration(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	eng := New()
+	eng.Register(NewScanner(ScannerInfo{ID: "slow", Name: "Slow"}, func() ([]scan.CategoryResult, error) {
+		time.Sleep(delay)
+		return []scan.CategoryResult{{Category: "slow-1"}}, nil
+	}))
+
+	events, done := eng.ScanAll(context.Background(), nil)
+	collected := drainEvents(events)
+	<-done
+
+	var found bool
+	for _, e := range collected {
+		switch e.Type {
+		case EventScannerDone:
+			found = true
+			if e.Duration < delay {
+				t.Errorf("expected duration >= %v, got %v", delay, e.Duration)
+			}
+		case EventScannerStart:
+			if e.Duration != 0 {
+				t.Errorf("start event should not carry a duration, got %v", e.Duration)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected a scanner_done event")
+	}
+}
+
+func TestScanAll_DoneEventCarriesStatuses(t *testing.T) {
+	eng := New()
+	eng.Register(NewStatusScanner(ScannerInfo{ID: "tools", Name: "Tools"}, func(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+		report.Report("tools-a", scan.StatusToolMissing, "tmutil")
+		report.Report("tools-b", scan.StatusNoData, "")
+		return nil, nil
+	}))
+	eng.Register(mockScanner("quiet", "Quiet", nil, nil))
+
+	events, done := eng.ScanAll(context.Background(), nil)
+	collected := drainEvents(events)
+	<-done
+
+	statuses := map[string][]scan.CategoryStatus{}
+	for _, e := range collected {
+		if e.Type == EventScannerDone {
+			statuses[e.ScannerID] = e.Statuses
+		}
+	}
+	got := statuses["tools"]
+	if len(got) != 2 || got[0].Status != scan.StatusToolMissing || got[0].Detail != "tmutil" || got[1].Status != scan.StatusNoData {
+		t.Errorf("unexpected statuses for tools: %+v", got)
+	}
+	if got[0].Checked() || !got[1].Checked() {
+		t.Errorf("expected only the no-data category to count as checked: %+v", got)
+	}
+	if len(statuses["quiet"]) != 0 {
+		t.Errorf("statuses must not leak into the next scanner: %+v", statuses["quiet"])
+	}
+}
+
+func TestScanWithStatus_AbandonedScannerDoesNotLeak(t *testing.T) {
+	release := make(chan struct{})
+	reported := make(chan struct{})
+	slow := NewStatusScanner(ScannerInfo{ID: "slow"}, func(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+		<-release
+		report.Report("slow-cat", scan.StatusToolMissing, "brew")
+		close(reported)
+		return nil, nil
+	})
+	expired := make(chan time.Time)
+	close(expired)
+	if _, statuses, ok, _ := scanWithStatus(context.Background(), slow, expired); ok || len(statuses) != 0 {
+		t.Fatalf("expected the slow scanner abandoned without statuses, got ok=%v %+v", ok, statuses)
+	}
+
+	next := NewStatusScanner(ScannerInfo{ID: "next"}, func(scan.StatusFunc) ([]scan.CategoryResult, error) {
+		close(release)
+		<-reported
+		return nil, nil
+	})
+	_, statuses, ok, _ := scanWithStatus(context.Background(), next, nil)
+	if !ok || len(statuses) != 0 {
+		t.Errorf("the abandoned scanner's status leaked into the next scan: ok=%v %+v", ok, statuses)
+	}
+}
+
 func TestScanAll_EmptyScanners(t *testing.T) {
 	eng := New()
 	events, done := eng.ScanAll(context.Background(), nil)
@@ -254,17 +537,20 @@ func TestScanAll_ContextCancellation(t *testing.T) {
 
 	events, done := eng.ScanAll(ctx, nil)
 
-	// Wait for the start event to confirm goroutine is running.
-	select {
-	case evt, ok := <-events:
-		if !ok {
-			t.Fatal("events channel closed before start event")
-		}
-		if evt.Type != EventScannerStart {
-			t.Fatalf("expected start event, got %q", evt.Type)
+	// Wait for the scan and scanner start events to confirm the scanner
+	// is running.
+	for _, want := range []string{EventScanStart, EventScannerStart} {
+		select {
+		case evt, ok := <-events:
+			if !ok {
+				t.Fatal("events channel closed before start event")
+			}
+			if evt.Type != want {
+				t.Fatalf("expected %q event, got %q", want, evt.Type)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("timed out waiting for start event")
 		}
-	case <-time.After(2 * time.Second):
-		t.Fatal("timed out waiting for start event")
 	}
 
 	// Cancel the context while scanner is blocked.
@@ -328,6 +614,110 @@ func TestRun_SingleScanner(t *testing.T) {
 	}
 }
 
+func TestRun_SetsPermissionHints(t *testing.T) {
+	eng := New()
+	eng.Register(mockScanner("sd", "System Data", []scan.CategoryResult{
+		{Category: "sysdata-mail", PermissionIssues: []scan.PermissionIssue{{Path: "/m", Description: "Mail"}}},
+		{Category: "system-caches", PermissionIssues: []scan.PermissionIssue{{Path: "/c", Description: "Caches"}}},
+	}, nil))
+
+	results, err := eng.Run(context.Background(), "sd")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if hint := results[0].PermissionIssues[0].Hint; !strings.Contains(hint, "Full Disk Access") {
+		t.Errorf("expected Full Disk Access hint for Mail, got %q", hint)
+	}
+	if hint := results[1].PermissionIssues[0].Hint; hint != "" {
+		t.Errorf("expected no hint for generic category, got %q", hint)
+	}
+}
+
+func TestScanAll_AppliesDeclaredCategoryRisks(t *testing.T) {
+	// system-caches is safe and sysdata-mail risky in the central map;
+	// the scanner's declarations take precedence over both.
+	info := ScannerInfo{ID: "r", Name: "R", CategoryRisks: map[string]string{
+		"system-caches": safety.RiskRisky,
+		"sysdata-mail":  safety.RiskSafe,
+	}}
+	eng := New()
+	eng.Register(NewScanner(info, func() ([]scan.CategoryResult, error) {
+		caches := scan.CategoryResult{Category: "system-caches", Entries: []scan.ScanEntry{{Path: "/c"}}}
+		caches.SetRiskLevels(safety.RiskForCategory)
+		return []scan.CategoryResult{
+			caches,
+			{Category: "sysdata-mail", Entries: []scan.ScanEntry{
+				{Path: "/m"},
+				{Path: "/m/special", RiskLevel: safety.RiskModerate},
+			}},
+			{Category: "dev-npm", Entries: []scan.ScanEntry{{Path: "/n"}}},
+		}, nil
+	}))
+
+	events, done := eng.ScanAll(context.Background(), nil)
+	drainEvents(events)
+	res := <-done
+	if res.Err != nil {
+		t.Fatalf("unexpected error: %v", res.Err)
+	}
+	want := map[string]string{
+		"/c":         safety.RiskRisky,
+		"/m":         safety.RiskSafe,
+		"/m/special": safety.RiskModerate,
+		"/n":         safety.RiskForCategory("dev-npm"),
+	}
+	for _, cr := range res.Results {
+		for _, entry := range cr.Entries {
+			if entry.RiskLevel != want[entry.Path] {
+				t.Errorf("%s risk = %q, want %q", entry.Path, entry.RiskLevel, want[entry.Path])
+			}
+		}
+	}
+}
+
+func TestRun_AppliesDeclaredCategoryRisks(t *testing.T) {
+	info := ScannerInfo{ID: "r", Name: "R", CategoryRisks: map[string]string{"system-caches": safety.RiskRisky}}
+	eng := New()
+	eng.Register(NewScanner(info, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{{Category: "system-caches", Entries: []scan.ScanEntry{{Path: "/c"}}}}, nil
+	}))
+
+	results, err := eng.Run(context.Background(), "r")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := results[0].Entries[0].RiskLevel; got != safety.RiskRisky {
+		t.Errorf("risk = %q, want %q", got, safety.RiskRisky)
+	}
+}
+
+func TestEstimateAndDoctor_ApplyDeclaredCategoryRisks(t *testing.T) {
+	info := ScannerInfo{ID: "r", Name: "R", CategoryRisks: map[string]string{"system-caches": safety.RiskRisky}}
+	// returned keeps each scan's results, which the engine finishes in place.
+	var returned [][]scan.CategoryResult
+	eng := New()
+	eng.Register(NewScanner(info, func() ([]scan.CategoryResult, error) {
+		results := []scan.CategoryResult{{Category: "system-caches", TotalSize: 1, Entries: []scan.ScanEntry{{Path: "/c", Size: 1}}}}
+		returned = append(returned, results)
+		return results, nil
+	}))
+
+	if _, err := eng.EstimateReclaimable(context.Background(), nil); err != nil {
+		t.Fatalf("EstimateReclaimable: %v", err)
+	}
+	if _, err := eng.Doctor(context.Background()); err != nil {
+		t.Fatalf("Doctor: %v", err)
+	}
+	if len(returned) != 2 {
+		t.Fatalf("scanner ran %d times, want 2", len(returned))
+	}
+	for i, results := range returned {
+		if got := results[0].Entries[0].RiskLevel; got != safety.RiskRisky {
+			t.Errorf("run %d: risk = %q, want %q", i, got, safety.RiskRisky)
+		}
+	}
+}
+
 func TestRun_ScannerNotFound(t *testing.T) {
 	eng := New()
 	eng.Register(mockScanner("a", "A", nil, nil))
@@ -578,46 +968,392 @@ func TestCleanup_ContextCancellation(t *testing.T) {
 	}
 }
 
-func TestStoreResults_SingleTokenPolicy(t *testing.T) {
+func TestStoreResults_TokenPerScan(t *testing.T) {
 	eng := New()
 
-	// Store first set of results.
-	token1 := eng.storeResults([]scan.CategoryResult{{Category: "first"}})
-	if token1 == "" {
-		t.Fatal("expected non-empty token1")
+	token1, _ := eng.storeResults([]scan.CategoryResult{{Category: "first"}}, "")
+	token2, _ := eng.storeResults([]scan.CategoryResult{{Category: "second"}}, "")
+	if token1 == "" || token2 == "" || token1 == token2 {
+		t.Fatalf("expected two distinct tokens, got %q and %q", token1, token2)
 	}
 
-	// Store second set — should invalidate the first.
-	token2 := eng.storeResults([]scan.CategoryResult{{Category: "second"}})
-	if token2 == "" {
-		t.Fatal("expected non-empty token2")
+	// Another client's scan must not invalidate the first token.
+	results, err := eng.validateToken(token1)
+	if err != nil {
+		t.Fatalf("token1 invalidated by a later scan: %v", err)
 	}
-	if token1 == token2 {
-		t.Error("expected different tokens for different calls")
+	if len(results) != 1 || results[0].Category != "first" {
+		t.Errorf("unexpected results for token1: %v", results)
 	}
-
-	// First token should now be invalid.
-	_, err := eng.validateToken(token1)
-	if err == nil {
-		t.Error("expected error for invalidated token1")
+	results, err = eng.validateToken(token2)
+	if err != nil {
+		t.Fatalf("unexpected error for token2: %v", err)
+	}
+	if len(results) != 1 || results[0].Category != "second" {
+		t.Errorf("unexpected results for token2: %v", results)
 	}
+
+	// Each token is single-use.
+	_, err = eng.validateToken(token1)
 	var tokenErr *TokenError
 	if !errors.As(err, &tokenErr) {
-		t.Fatalf("expected *TokenError, got %T", err)
+		t.Errorf("expected *TokenError for a consumed token, got %v", err)
 	}
+}
 
-	// Second token should still be valid.
-	results, err := eng.validateToken(token2)
+func TestStoreResults_EvictsOldestToken(t *testing.T) {
+	eng := New()
+	oldest, _ := eng.storeResults(nil, "")
+	var newest ScanToken
+	for i := 0; i < maxTokens; i++ {
+		newest, _ = eng.storeResults(nil, "")
+	}
+	if _, err := eng.validateToken(oldest); err == nil {
+		t.Error("expected the oldest token to be evicted")
+	}
+	if _, err := eng.validateToken(newest); err != nil {
+		t.Errorf("newest token: %v", err)
+	}
+}
+
+func TestCleanup_FilteredTokenNeedsCategories(t *testing.T) {
+	eng := New()
+	token, _ := eng.storeResults([]scan.CategoryResult{{Category: "shown"}, {Category: "hidden"}}, "")
+	eng.MarkFiltered(token)
+
+	events, done := eng.Cleanup(context.Background(), token, nil)
+	for range events {
+	}
+	var fe *FilteredError
+	if err := (<-done).Err; !errors.As(err, &fe) {
+		t.Fatalf("expected *FilteredError for a filtered token without categories, got %v", err)
+	}
+
+	events, done = eng.Cleanup(context.Background(), token, []string{"shown"})
+	for range events {
+	}
+	if err := (<-done).Err; err != nil {
+		t.Errorf("expected the token to stay valid for named categories, got %v", err)
+	}
+}
+
+// --- Result cache tests ---
+
+// countingScanner returns a scanner that sleeps for delay and counts calls.
+func countingScanner(calls *int, delay time.Duration) Scanner {
+	return NewScanner(ScannerInfo{ID: "slow", Name: "Slow", CategoryIDs: []string{"slow-1", "slow-2"}}, func() ([]scan.CategoryResult, error) {
+		*calls++
+		time.Sleep(delay)
+		return []scan.CategoryResult{{Category: "slow-1", TotalSize: 10}, {Category: "slow-2", TotalSize: 20}}, nil
+	})
+}
+
+// scanOnce runs ScanAll to completion and returns the events and result.
+func scanOnce(eng *Engine, skip map[string]bool) ([]ScanEvent, ScanResult) {
+	events, done := eng.ScanAll(context.Background(), skip)
+	collected := drainEvents(events)
+	return collected, <-done
+}
+
+func TestScanAll_CacheHitWithinTTL(t *testing.T) {
+	calls := 0
+	eng := New()
+	eng.CacheTTL = time.Minute
+	eng.Register(countingScanner(&calls, 50*time.Millisecond))
+
+	_, first := scanOnce(eng, map[string]bool{"slow-2": true})
+	if first.Cached {
+		t.Error("first scan should not be cached")
+	}
+
+	start := time.Now()
+	events, second := scanOnce(eng, map[string]bool{"slow-2": true})
+	elapsed := time.Since(start)
+
+	if !second.Cached {
+		t.Error("second scan should be served from cache")
+	}
+	if first.ScannedAt.IsZero() || !second.ScannedAt.Equal(first.ScannedAt) {
+		t.Errorf("cached result should keep the original scan time %v, got %v", first.ScannedAt, second.ScannedAt)
+	}
+	if calls != 1 {
+		t.Errorf("expected scanner to run once, ran %d times", calls)
+	}
+	if elapsed >= 50*time.Millisecond {
+		t.Errorf("cached scan took %v, expected it to skip the scanner", elapsed)
+	}
+	var types []string
+	for _, ev := range events {
+		types = append(types, ev.Type)
+	}
+	wantTypes := []string{EventScanStart, EventScannerStart, EventScannerDone, EventScanComplete}
+	if strings.Join(types, ",") != strings.Join(wantTypes, ",") {
+		t.Fatalf("cached scan events = %v, want %v", types, wantTypes)
+	}
+	if res := events[2].Results; len(res) != 1 || res[0].Category != "slow-1" {
+		t.Errorf("expected scanner_done to replay slow-1, got %v", res)
+	}
+	if events[3].TotalSize != 10 || events[3].CategoryCount != 1 {
+		t.Errorf("unexpected scan_complete totals: %+v", events[3])
+	}
+	if second.Token != first.Token {
+		t.Errorf("expected same token %q, got %q", first.Token, second.Token)
+	}
+	if len(second.Results) != 1 || second.Results[0].Category != "slow-1" {
+		t.Errorf("unexpected cached results: %v", second.Results)
+	}
+}
+
+func TestScanAll_CacheBypassedForDifferentSkip(t *testing.T) {
+	calls := 0
+	eng := New()
+	eng.CacheTTL = time.Minute
+	eng.Register(countingScanner(&calls, 0))
+
+	_, first := scanOnce(eng, map[string]bool{"slow-2": true})
+	_, second := scanOnce(eng, map[string]bool{"slow-1": true})
+
+	if second.Cached {
+		t.Error("scan with a different skip set should not be cached")
+	}
+	if calls != 2 {
+		t.Errorf("expected scanner to run twice, ran %d times", calls)
+	}
+	if second.Token == first.Token {
+		t.Error("expected a new token for a different skip set")
+	}
+}
+
+func TestScanAll_CacheKeyIgnoresOrderAndFalseEntries(t *testing.T) {
+	a := cacheKey(map[string]bool{"x": true, "y": true, "z": false})
+	b := cacheKey(map[string]bool{"y": true, "x": true})
+	if a != b {
+		t.Errorf("expected equal keys, got %q and %q", a, b)
+	}
+	if cacheKey(nil) != cacheKey(map[string]bool{}) {
+		t.Error("nil and empty skip sets should share a key")
+	}
+}
+
+func TestScanAll_CacheExpiresAfterTTL(t *testing.T) {
+	calls := 0
+	eng := New()
+	eng.CacheTTL = 10 * time.Millisecond
+	eng.Register(countingScanner(&calls, 0))
+
+	scanOnce(eng, nil)
+	time.Sleep(20 * time.Millisecond)
+	_, second := scanOnce(eng, nil)
+
+	if second.Cached {
+		t.Error("scan after TTL should not be cached")
+	}
+	if calls != 2 {
+		t.Errorf("expected scanner to run twice, ran %d times", calls)
+	}
+}
+
+func TestScanAll_CacheDisabledByDefault(t *testing.T) {
+	calls := 0
+	eng := New()
+	eng.Register(countingScanner(&calls, 0))
+
+	scanOnce(eng, nil)
+	_, second := scanOnce(eng, nil)
+
+	if second.Cached || calls != 2 {
+		t.Errorf("expected no caching without CacheTTL (cached=%v, calls=%d)", second.Cached, calls)
+	}
+}
+
+func TestScanAll_CacheInvalidatedByCleanup(t *testing.T) {
+	calls := 0
+	eng := New()
+	eng.CacheTTL = time.Minute
+	eng.Register(countingScanner(&calls, 0))
+
+	_, first := scanOnce(eng, nil)
+
+	cleanEvents, cleanDone := eng.Cleanup(context.Background(), first.Token, []string{"none-selected"})
+	for range cleanEvents {
+	}
+	if res := <-cleanDone; res.Err != nil {
+		t.Fatalf("cleanup failed: %v", res.Err)
+	}
+
+	_, second := scanOnce(eng, nil)
+	if second.Cached {
+		t.Error("scan after cleanup should not be cached")
+	}
+	if calls != 2 {
+		t.Errorf("expected scanner to run twice, ran %d times", calls)
+	}
+	if second.Token == first.Token {
+		t.Error("expected a new token after cleanup")
+	}
+}
+
+// --- Paths tests ---
+
+func TestPaths_FromPathLister(t *testing.T) {
+	eng := New()
+	eng.Register(NewScannerWithPaths(ScannerInfo{ID: "p", Name: "P"},
+		func() ([]scan.CategoryResult, error) { t.Error("Paths must not scan"); return nil, nil },
+		func(home string) []string { return []string{home + "/a", home + "/b"} }))
+
+	paths, err := eng.Paths("p", "/Users/test")
 	if err != nil {
-		t.Fatalf("unexpected error for token2: %v", err)
+		t.Fatalf("Paths: %v", err)
 	}
-	if len(results) != 1 || results[0].Category != "second" {
-		t.Errorf("unexpected results for token2: %v", results)
+	if len(paths) != 2 || paths[0] != "/Users/test/a" || paths[1] != "/Users/test/b" {
+		t.Errorf("unexpected paths: %v", paths)
+	}
+}
+
+func TestPaths_ScannerWithoutPaths(t *testing.T) {
+	eng := New()
+	eng.Register(mockScanner("plain", "Plain", nil, nil))
+
+	paths, err := eng.Paths("plain", "/Users/test")
+	if err != nil || paths != nil {
+		t.Errorf("expected no paths and no error, got %v, %v", paths, err)
+	}
+}
+
+func TestPaths_ScannerNotFound(t *testing.T) {
+	if _, err := New().Paths("missing", "/Users/test"); err == nil {
+		t.Error("expected error for unknown scanner")
+	}
+}
+
+func TestRegisterDefaults_AllListPaths(t *testing.T) {
+	eng := New()
+	RegisterDefaults(eng)
+	for _, info := range eng.Categories() {
+		paths, err := eng.Paths(info.ID, "/Users/test")
+		if err != nil {
+			t.Errorf("Paths(%q): %v", info.ID, err)
+		}
+		if len(paths) == 0 {
+			t.Errorf("scanner %q lists no paths", info.ID)
+		}
+	}
+}
+
+// --- Scan timeout tests ---
+
+func TestScanAll_TimeoutReturnsPartialResults(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	eng := New()
+	eng.ScanTimeout = 100 * time.Millisecond
+	eng.Register(mockScanner("fast", "Fast", []scan.CategoryResult{{Category: "fast-1", TotalSize: 10}}, nil))
+	eng.Register(NewScanner(ScannerInfo{ID: "hung", Name: "Hung"}, func() ([]scan.CategoryResult, error) {
+		<-release
+		return []scan.CategoryResult{{Category: "hung-1"}}, nil
+	}))
+	eng.Register(mockScanner("after", "After", []scan.CategoryResult{{Category: "after-1"}}, nil))
+
+	start := time.Now()
+	events, result := scanOnce(eng, nil)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Fatalf("ScanAll blocked for %v despite the deadline", elapsed)
+	}
+
+	if !result.TimedOut {
+		t.Error("expected TimedOut to be set")
+	}
+	if len(result.Results) != 1 || result.Results[0].Category != "fast-1" {
+		t.Errorf("expected only fast-1 in partial results, got %v", result.Results)
+	}
+	if result.Token == "" {
+		t.Error("expected a token for partial results")
+	}
+
+	timedOut := map[string]bool{}
+	for _, e := range events {
+		var te *TimeoutError
+		if e.Type == EventScannerError && errors.As(e.Err, &te) {
+			timedOut[te.ScannerID] = true
+		}
+	}
+	if !timedOut["hung"] || !timedOut["after"] {
+		t.Errorf("expected hung and after reported as timed out, got %v", timedOut)
+	}
+	if timedOut["fast"] {
+		t.Error("fast scanner should not be reported as timed out")
+	}
+}
+
+func TestScanAll_TimeoutNotReachedRunsAll(t *testing.T) {
+	eng := New()
+	eng.ScanTimeout = time.Minute
+	eng.Register(mockScanner("a", "A", []scan.CategoryResult{{Category: "a-1"}}, nil))
+	eng.Register(mockScanner("b", "B", []scan.CategoryResult{{Category: "b-1"}}, nil))
+
+	_, result := scanOnce(eng, nil)
+	if result.TimedOut {
+		t.Error("expected TimedOut to be false")
+	}
+	if len(result.Results) != 2 {
+		t.Errorf("expected 2 results, got %d", len(result.Results))
+	}
+}
+
+func TestScanAll_TimedOutResultsNotCached(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	eng := New()
+	eng.CacheTTL = time.Minute
+	eng.ScanTimeout = 50 * time.Millisecond
+	eng.Register(NewScanner(ScannerInfo{ID: "hung", Name: "Hung"}, func() ([]scan.CategoryResult, error) {
+		<-release
+		return nil, nil
+	}))
+
+	scanOnce(eng, nil)
+	_, second := scanOnce(eng, nil)
+	if second.Cached {
+		t.Error("partial results from a timed-out scan must not be cached")
+	}
+}
+
+func TestRun_ContextDeadlineTimesOut(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	eng := New()
+	eng.ScanTimeout = 50 * time.Millisecond
+	eng.Register(NewScanner(ScannerInfo{ID: "hung", Name: "Hung"}, func() ([]scan.CategoryResult, error) {
+		<-release
+		return nil, nil
+	}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), eng.ScanTimeout)
+	defer cancel()
+	_, err := eng.Run(ctx, "hung")
+	var te *TimeoutError
+	if !errors.As(err, &te) || te.ScannerID != "hung" {
+		t.Fatalf("expected *TimeoutError for hung, got %v", err)
+	}
+
+	// A deadline already past fails the next scanner without running it.
+	if _, err := eng.Run(ctx, "hung"); !errors.As(err, &te) {
+		t.Errorf("expected *TimeoutError after the deadline, got %v", err)
 	}
 }
 
 // --- Error type tests ---
 
+func TestTimeoutError_String(t *testing.T) {
+	err := &TimeoutError{ScannerID: "developer", Timeout: 30 * time.Second}
+	if got := err.Error(); got != "scanner developer: timed out (scan deadline 30s exceeded)" {
+		t.Errorf("unexpected error string: %q", got)
+	}
+}
+
 func TestScanError_ErrorsAs(t *testing.T) {
 	orig := errors.New("disk failure")
 	scanErr := &ScanError{ScannerID: "test", Err: orig}
@@ -650,3 +1386,80 @@ func TestTokenError_String(t *testing.T) {
 		t.Errorf("expected %q, got %q", expected, err.Error())
 	}
 }
+
+// --- Logging tests ---
+
+// captureLogs directs log records at level to a buffer as JSON lines for
+// the rest of the test.
+func captureLogs(t *testing.T, level string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := logging.Setup(&buf, level, true); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = logging.Setup(os.Stderr, logging.DefaultLevel, false) })
+	return &buf
+}
+
+func TestRun_LogsPermissionIssueAtWarn(t *testing.T) {
+	buf := captureLogs(t, "debug")
+	eng := New()
+	eng.Register(mockScanner("locked", "Locked", []scan.CategoryResult{{
+		Category:         "cat-locked",
+		PermissionIssues: []scan.PermissionIssue{{Path: "/locked/dir", Description: "dir (permission denied)"}},
+	}}, nil))
+
+	if _, err := eng.Run(context.Background(), "locked"); err != nil {
+		t.Fatal(err)
+	}
+
+	var found bool
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var rec map[string]any
+		if err := json.Unmarshal([]byte(line), &rec); err != nil {
+			t.Fatalf("invalid log record %q: %v", line, err)
+		}
+		if rec["msg"] == "permission denied" {
+			found = true
+			if rec["level"] != "WARN" || rec["path"] != "/locked/dir" || rec["scanner"] != "locked" {
+				t.Errorf("unexpected permission record: %v", rec)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected a permission denied record, got:\n%s", buf.String())
+	}
+}
+
+func TestRun_DebugSuppressedAtDefaultLevel(t *testing.T) {
+	buf := captureLogs(t, logging.DefaultLevel)
+	eng := New()
+	eng.Register(mockScanner("ok", "OK", []scan.CategoryResult{{Category: "cat-ok", TotalSize: 1}}, nil))
+
+	if _, err := eng.Run(context.Background(), "ok"); err != nil {
+		t.Fatal(err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("expected no records at the default level, got %q", buf.String())
+	}
+}
+
+func TestScanAll_LogsTimeoutAtWarn(t *testing.T) {
+	buf := captureLogs(t, "warn")
+	release := make(chan struct{})
+	defer close(release)
+	eng := New()
+	eng.ScanTimeout = 10 * time.Millisecond
+	eng.Register(NewScanner(ScannerInfo{ID: "slow", Name: "Slow"}, func() ([]scan.CategoryResult, error) {
+		<-release
+		return nil, nil
+	}))
+
+	events, done := eng.ScanAll(context.Background(), nil)
+	drainEvents(events)
+	<-done
+
+	if !strings.Contains(buf.String(), `"msg":"scanner timed out"`) || !strings.Contains(buf.String(), `"scanner":"slow"`) {
+		t.Errorf("expected a scanner timeout record, got %q", buf.String())
+	}
+}
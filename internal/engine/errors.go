@@ -1,6 +1,9 @@
 package engine
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // ScanError wraps a scanner-level error with the scanner ID.
 // It supports errors.As() for typed error handling by the server.
@@ -19,6 +22,39 @@ type CancelledError struct {
 
 func (e *CancelledError) Error() string { return fmt.Sprintf("%s cancelled", e.Operation) }
 
+// TimeoutError indicates a scanner did not finish, or never started,
+// before the overall ScanAll deadline (Engine.ScanTimeout) or the
+// deadline of the context passed to Run expired.
+type TimeoutError struct {
+	ScannerID string
+	Timeout   time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("scanner %s: timed out (scan deadline %s exceeded)", e.ScannerID, e.Timeout)
+}
+
+// CategoryError indicates a category ID that is not in the stored scan
+// results.
+type CategoryError struct {
+	Category string
+}
+
+func (e *CategoryError) Error() string {
+	return fmt.Sprintf("category %s is not in the scan results", e.Category)
+}
+
+// FilteredError indicates a cleanup of every category under a token whose
+// scan result was shown filtered (see MarkFiltered), so the client never
+// saw some of the categories it would remove.
+type FilteredError struct {
+	Token ScanToken
+}
+
+func (e *FilteredError) Error() string {
+	return fmt.Sprintf("token %s: the scan result left categories out; list the categories to clean", e.Token)
+}
+
 // TokenError indicates an invalid or expired scan token.
 type TokenError struct {
 	Token  ScanToken
@@ -0,0 +1,152 @@
+package engine
+
+import (
+	"context"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/logging"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// CategoryEstimate is one category's reclaimable total, without entries.
+type CategoryEstimate struct {
+	Category    string `json:"category"`
+	Description string `json:"description"`
+	TotalSize   int64  `json:"total_size"`
+	EntryCount  int    `json:"entry_count"`
+	// ReportOnly marks a category cleanup leaves out unless it is
+	// selected by its ID (see scan.CategoryResult.ReportOnly).
+	ReportOnly bool `json:"report_only,omitempty"`
+}
+
+// Estimate is the outcome of EstimateReclaimable: per-category totals and
+// their sum, for a "you can free ~X" headline.
+type Estimate struct {
+	Categories []CategoryEstimate `json:"categories"`
+	TotalSize  int64              `json:"total_size"`
+	// Failed lists the scanners that returned an error or did not finish
+	// before ScanTimeout; their categories are missing from the totals.
+	Failed []string `json:"failed,omitempty"`
+}
+
+// Reclaimable returns the bytes a cleanup of every category would free:
+// TotalSize without the report-only categories.
+func (est *Estimate) Reclaimable() int64 {
+	var total int64
+	for _, c := range est.Categories {
+		if !c.ReportOnly {
+			total += c.TotalSize
+		}
+	}
+	return total
+}
+
+// EstimateReclaimable runs the enabled scanners like ScanAll but keeps
+// only per-category totals: each scanner's entries are dropped as soon as
+// it returns, no events are streamed and no cleanup token is issued, so
+// huge results never accumulate. A scanner whose categories are all in
+// skip is not run. When a cached ScanAll result is valid for skip, the
+// totals are taken from it without scanning. The grand total equals the
+// total of a ScanAll with the same skip set.
+func (e *Engine) EstimateReclaimable(ctx context.Context, skip map[string]bool) (*Estimate, error) {
+	var totals []scan.CategoryResult
+	counts := map[string]int{}
+	// add keeps each category's totals and entry count, not its entries.
+	add := func(results []scan.CategoryResult) {
+		for _, cr := range results {
+			totals = append(totals, scan.CategoryResult{
+				Category:    cr.Category,
+				Description: cr.Description,
+				TotalSize:   cr.TotalSize,
+				ReportOnly:  cr.ReportOnly,
+			})
+			counts[cr.Category] += len(cr.Entries)
+		}
+	}
+
+	scanners, disabled := e.enabledScanners()
+	if results, _, _, ok := e.cachedResults(resultKey(skip, disabled)); ok {
+		add(results)
+		return buildEstimate(totals, counts, nil), nil
+	}
+
+	var deadline <-chan time.Time
+	if e.ScanTimeout > 0 {
+		timer := time.NewTimer(e.ScanTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var failed []string
+	timedOut := false
+	for _, s := range scanners {
+		if ctx.Err() != nil {
+			return nil, &CancelledError{Operation: "scan"}
+		}
+		info := s.Info()
+		if allSkipped(info.CategoryIDs, skip) {
+			logging.Debug("scanner skipped", "scanner", info.ID, "reason", "all categories skipped")
+			continue
+		}
+		if timedOut {
+			failed = append(failed, info.ID)
+			continue
+		}
+
+		start := time.Now()
+		results, ok, err := scanWithDeadline(ctx, s, deadline)
+		if ctx.Err() != nil {
+			return nil, &CancelledError{Operation: "scan"}
+		}
+		if !ok {
+			timedOut = true
+			err = &TimeoutError{ScannerID: info.ID, Timeout: e.ScanTimeout}
+		}
+		logScan(info, results, err, time.Since(start))
+		if !ok || err != nil {
+			failed = append(failed, info.ID)
+			continue
+		}
+		e.finishResults(info, results)
+		add(results)
+	}
+	if ctx.Err() != nil {
+		return nil, &CancelledError{Operation: "scan"}
+	}
+
+	SortCategories(totals, e.CategoryOrder)
+	return buildEstimate(FilterSkipped(totals, skip), counts, failed), nil
+}
+
+// allSkipped reports whether ids is non-empty and every ID is in skip.
+func allSkipped(ids []string, skip map[string]bool) bool {
+	if len(ids) == 0 {
+		return false
+	}
+	for _, id := range ids {
+		if !skip[id] {
+			return false
+		}
+	}
+	return true
+}
+
+// buildEstimate turns entry-less category totals into an Estimate, taking
+// entry counts from counts.
+func buildEstimate(totals []scan.CategoryResult, counts map[string]int, failed []string) *Estimate {
+	est := &Estimate{
+		Categories: make([]CategoryEstimate, 0, len(totals)),
+		Failed:     failed,
+	}
+	for _, cr := range totals {
+		est.Categories = append(est.Categories, CategoryEstimate{
+			Category:    cr.Category,
+			Description: cr.Description,
+			TotalSize:   cr.TotalSize,
+			EntryCount:  counts[cr.Category],
+			ReportOnly:  cr.ReportOnly,
+		})
+		est.TotalSize += cr.TotalSize
+	}
+	return est
+}
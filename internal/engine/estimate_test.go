@@ -0,0 +1,115 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// estimateEngine returns an engine with two mock scanners holding three
+// categories, and an erroring scanner.
+func estimateEngine() *Engine {
+	eng := New()
+	eng.Register(mockScanner("a", "A", []scan.CategoryResult{
+		{Category: "cat-1", Description: "One", TotalSize: 100, Entries: []scan.ScanEntry{
+			{Path: "/a/1", Size: 60}, {Path: "/a/2", Size: 40},
+		}},
+		{Category: "cat-2", Description: "Two", TotalSize: 250, Entries: []scan.ScanEntry{{Path: "/a/3", Size: 250}}},
+	}, nil))
+	eng.Register(mockScanner("b", "B", []scan.CategoryResult{
+		{Category: "cat-3", Description: "Three", TotalSize: 1000, Entries: []scan.ScanEntry{{Path: "/b/1", Size: 1000}}},
+	}, nil))
+	eng.Register(mockScanner("broken", "Broken", nil, errors.New("boom")))
+	return eng
+}
+
+func TestEstimateReclaimable_MatchesScanAllTotal(t *testing.T) {
+	for _, skip := range []map[string]bool{nil, {"cat-2": true}} {
+		eng := estimateEngine()
+		est, err := eng.EstimateReclaimable(context.Background(), skip)
+		if err != nil {
+			t.Fatalf("EstimateReclaimable: %v", err)
+		}
+
+		events, done := eng.ScanAll(context.Background(), skip)
+		var want int64
+		for _, ev := range drainEvents(events) {
+			if ev.Type == EventScanComplete {
+				want = ev.TotalSize
+			}
+		}
+		<-done
+
+		if est.TotalSize != want {
+			t.Errorf("skip %v: estimate %d, want ScanAll total %d", skip, est.TotalSize, want)
+		}
+	}
+}
+
+func TestEstimateReclaimable_OmitsEntries(t *testing.T) {
+	est, err := estimateEngine().EstimateReclaimable(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("EstimateReclaimable: %v", err)
+	}
+	if len(est.Categories) != 3 {
+		t.Fatalf("expected 3 categories, got %+v", est.Categories)
+	}
+	if c := est.Categories[0]; c.Category != "cat-1" || c.TotalSize != 100 || c.EntryCount != 2 {
+		t.Errorf("unexpected first category: %+v", c)
+	}
+	if len(est.Failed) != 1 || est.Failed[0] != "broken" {
+		t.Errorf("expected broken scanner reported, got %v", est.Failed)
+	}
+
+	data, err := json.Marshal(est)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "entries") || strings.Contains(string(data), "/a/1") {
+		t.Errorf("estimate should carry no entries: %s", data)
+	}
+}
+
+func TestEstimateReclaimable_SkipsFullySkippedScanners(t *testing.T) {
+	calls := 0
+	eng := New()
+	eng.Register(NewScanner(ScannerInfo{ID: "x", CategoryIDs: []string{"cat-x"}}, func() ([]scan.CategoryResult, error) {
+		calls++
+		return []scan.CategoryResult{{Category: "cat-x", TotalSize: 5}}, nil
+	}))
+
+	est, err := eng.EstimateReclaimable(context.Background(), map[string]bool{"cat-x": true})
+	if err != nil {
+		t.Fatalf("EstimateReclaimable: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("expected fully skipped scanner not to run, ran %d times", calls)
+	}
+	if est.TotalSize != 0 || len(est.Categories) != 0 {
+		t.Errorf("expected empty estimate, got %+v", est)
+	}
+}
+
+func TestEstimateReclaimable_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := estimateEngine().EstimateReclaimable(ctx, nil)
+	var ce *CancelledError
+	if !errors.As(err, &ce) {
+		t.Errorf("expected CancelledError, got %v", err)
+	}
+}
+
+func TestEstimateReclaimable_IssuesNoToken(t *testing.T) {
+	eng := estimateEngine()
+	if _, err := eng.EstimateReclaimable(context.Background(), nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(eng.tokens.entries) != 0 {
+		t.Error("estimate must not store results or issue a cleanup token")
+	}
+}
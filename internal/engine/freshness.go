@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// FreshnessPath returns the location of the per-category freshness
+// windows, ~/.config/mac-cleaner/freshness.json.
+func FreshnessPath(home string) string {
+	return filepath.Join(home, ".config", "mac-cleaner", "freshness.json")
+}
+
+// LoadFreshness reads the per-category freshness windows: a JSON object
+// mapping category IDs to durations, e.g. {"dev-gradle": "72h"}. A missing
+// file yields nil windows. Negative or unparsable durations are errors.
+func LoadFreshness(home string) (map[string]time.Duration, error) {
+	data, err := os.ReadFile(FreshnessPath(home))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read freshness windows: %w", err)
+	}
+	var raw map[string]string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parse freshness windows: %w", err)
+	}
+	windows := make(map[string]time.Duration, len(raw))
+	for id, s := range raw {
+		d, err := time.ParseDuration(s)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("freshness window for %s: invalid duration %q", id, s)
+		}
+		windows[id] = d
+	}
+	return windows, nil
+}
+
+// applyFreshness withholds, in each category with a Freshness window,
+// every entry containing anything modified within the window, moving it to
+// the category's RecentlyModified list.
+func (e *Engine) applyFreshness(results []scan.CategoryResult) {
+	if len(e.Freshness) == 0 {
+		return
+	}
+	now := time.Now()
+	for i := range results {
+		if window := e.Freshness[results[i].Category]; window > 0 {
+			results[i].ExcludeNewerThan(now.Add(-window))
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// writeFreshness writes content as the freshness windows file under home.
+func writeFreshness(t *testing.T, home, content string) {
+	t.Helper()
+	path := FreshnessPath(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoadFreshness(t *testing.T) {
+	home := t.TempDir()
+	if windows, err := LoadFreshness(home); err != nil || windows != nil {
+		t.Fatalf("missing file: got %v, %v; want nil, nil", windows, err)
+	}
+
+	writeFreshness(t, home, `{"dev-gradle": "72h", "browser-chrome": "0s"}`)
+	windows, err := LoadFreshness(home)
+	if err != nil {
+		t.Fatalf("LoadFreshness: %v", err)
+	}
+	if windows["dev-gradle"] != 72*time.Hour || windows["browser-chrome"] != 0 {
+		t.Errorf("unexpected windows: %v", windows)
+	}
+
+	for _, bad := range []string{`{"dev-gradle": "soon"}`, `{"dev-gradle": "-1h"}`, `not json`} {
+		writeFreshness(t, home, bad)
+		if _, err := LoadFreshness(home); err == nil {
+			t.Errorf("expected error for %s", bad)
+		}
+	}
+}
+
+func TestFreshness_GuardsOnlyConfiguredCategory(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	paths := map[string]bool{}
+	for _, name := range []string{"gradle-fresh", "gradle-old", "chrome-fresh"} {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if name == "gradle-old" {
+			os.Chtimes(p, old, old)
+		}
+		paths[name] = true
+	}
+
+	home := t.TempDir()
+	writeFreshness(t, home, `{"dev-gradle": "48h"}`)
+	windows, err := LoadFreshness(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eng := New()
+	eng.Freshness = windows
+	eng.Register(mockScanner("mixed", "Mixed", []scan.CategoryResult{
+		{Category: "dev-gradle", TotalSize: 8, Entries: []scan.ScanEntry{
+			{Path: filepath.Join(dir, "gradle-fresh"), Size: 4},
+			{Path: filepath.Join(dir, "gradle-old"), Size: 4},
+		}},
+		{Category: "browser-chrome", TotalSize: 4, Entries: []scan.ScanEntry{
+			{Path: filepath.Join(dir, "chrome-fresh"), Size: 4},
+		}},
+	}, nil))
+
+	results, err := eng.Run(context.Background(), "mixed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	gradle, chrome := results[0], results[1]
+	if len(gradle.Entries) != 1 || gradle.Entries[0].Path != filepath.Join(dir, "gradle-old") || gradle.TotalSize != 4 {
+		t.Errorf("expected only gradle-old left in dev-gradle, got %+v", gradle)
+	}
+	if len(gradle.RecentlyModified) != 1 || gradle.RecentlyModified[0].Path != filepath.Join(dir, "gradle-fresh") {
+		t.Errorf("expected gradle-fresh withheld, got %+v", gradle.RecentlyModified)
+	}
+	if len(chrome.Entries) != 1 || len(chrome.RecentlyModified) != 0 {
+		t.Errorf("expected browser-chrome without a window to keep its fresh entry, got %+v", chrome)
+	}
+}
@@ -0,0 +1,180 @@
+package engine
+
+import (
+	"context"
+	"errors"
+
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// PlanOptions controls which scan entries BuildPlan selects for deletion.
+// The zero value selects every entry.
+type PlanOptions struct {
+	// Categories limits the plan to these category IDs. Empty selects all
+	// but the ReportOnly categories, which must be named to be included.
+	Categories []string
+	// Skip excludes whole categories by ID.
+	Skip map[string]bool
+	// ExcludePaths excludes individual entries by exact path.
+	ExcludePaths []string
+	// MaxRisk is the highest risk level included (safety.RiskSafe,
+	// RiskModerate or RiskRisky). Empty includes every level; any other
+	// value is treated as the strictest level, RiskSafe, so a mistyped
+	// limit never widens the selection.
+	MaxRisk string
+}
+
+// Plan is the inspectable outcome of selection: the entries a cleanup will
+// delete and the entries it will leave alone, with the reason for each.
+// Building a plan touches no files; ApplyPlan performs the deletion.
+type Plan struct {
+	// Categories holds the selected entries, grouped by category. TotalSize
+	// of each category covers only its selected entries.
+	Categories []scan.CategoryResult
+	// Excluded lists entries dropped from the plan.
+	Excluded []PlanExclusion
+	// TotalSize is the sum of all selected entry sizes.
+	TotalSize int64
+}
+
+// PlanExclusion records an entry left out of a plan and why.
+type PlanExclusion struct {
+	Category string
+	Path     string
+	Size     int64
+	// Reason is one of the Exclude* constants.
+	Reason string
+}
+
+// Plan exclusion reasons.
+const (
+	ExcludeCategory   = "category not selected"
+	ExcludeSkipped    = "category skipped"
+	ExcludePath       = "path excluded"
+	ExcludeRisk       = "risk above limit"
+	ExcludeRecent     = "recently modified"
+	ExcludeReportOnly = "report-only category"
+)
+
+// riskRank orders risk levels from least to most risky.
+var riskRank = map[string]int{
+	safety.RiskSafe:     0,
+	safety.RiskModerate: 1,
+	safety.RiskRisky:    2,
+}
+
+// Entries returns every selected entry across all categories.
+func (p *Plan) Entries() []scan.ScanEntry {
+	var entries []scan.ScanEntry
+	for _, cat := range p.Categories {
+		entries = append(entries, cat.Entries...)
+	}
+	return entries
+}
+
+// BuildPlan selects entries from scan results according to opts. Entries
+// already withheld as recently modified are recorded as exclusions. An
+// entry without a risk level takes its category's level. The input
+// results are not modified.
+func BuildPlan(results []scan.CategoryResult, opts PlanOptions) *Plan {
+	var selected map[string]bool
+	if len(opts.Categories) > 0 {
+		selected = make(map[string]bool, len(opts.Categories))
+		for _, id := range opts.Categories {
+			selected[id] = true
+		}
+	}
+	excludePaths := make(map[string]bool, len(opts.ExcludePaths))
+	for _, p := range opts.ExcludePaths {
+		excludePaths[p] = true
+	}
+	maxRank, limitRisk := riskRank[opts.MaxRisk]
+	if !limitRisk && opts.MaxRisk != "" {
+		maxRank, limitRisk = riskRank[safety.RiskSafe], true
+	}
+
+	plan := &Plan{}
+	for _, cat := range results {
+		reason := ""
+		switch {
+		case selected != nil && !selected[cat.Category]:
+			reason = ExcludeCategory
+		case selected == nil && cat.ReportOnly:
+			reason = ExcludeReportOnly
+		case opts.Skip[cat.Category]:
+			reason = ExcludeSkipped
+		}
+		if reason != "" {
+			plan.exclude(cat.Category, cat.Entries, reason)
+			plan.exclude(cat.Category, cat.RecentlyModified, reason)
+			continue
+		}
+		plan.exclude(cat.Category, cat.RecentlyModified, ExcludeRecent)
+
+		kept := cat
+		kept.Entries = nil
+		kept.RecentlyModified = nil
+		kept.TotalSize = 0
+		for _, entry := range cat.Entries {
+			risk := entry.RiskLevel
+			if risk == "" {
+				risk = safety.RiskForCategory(cat.Category)
+			}
+			switch {
+			case excludePaths[entry.Path]:
+				plan.exclude(cat.Category, []scan.ScanEntry{entry}, ExcludePath)
+			case limitRisk && riskRank[risk] > maxRank:
+				plan.exclude(cat.Category, []scan.ScanEntry{entry}, ExcludeRisk)
+			default:
+				kept.Entries = append(kept.Entries, entry)
+				kept.TotalSize += entry.Size
+			}
+		}
+		plan.Categories = append(plan.Categories, kept)
+		plan.TotalSize += kept.TotalSize
+	}
+	return plan
+}
+
+// exclude records entries of category as excluded for reason.
+func (p *Plan) exclude(category string, entries []scan.ScanEntry, reason string) {
+	for _, entry := range entries {
+		p.Excluded = append(p.Excluded, PlanExclusion{
+			Category: category,
+			Path:     entry.Path,
+			Size:     entry.Size,
+			Reason:   reason,
+		})
+	}
+}
+
+// ApplyPlan deletes the entries selected in plan. Each path is re-checked
+// against the safety blocklist at deletion time, as in Cleanup, but no scan
+// token is required: the caller is responsible for the plan's contents.
+// Each entry's removal is bounded by cleanup.DefaultEntryTimeout. A nil
+// plan deletes nothing and fails with an error.
+// Returns an events channel for progress and a done channel for the final
+// result.
+func ApplyPlan(ctx context.Context, plan *Plan) (<-chan CleanupEvent, <-chan CleanupDone) {
+	events := make(chan CleanupEvent)
+	done := make(chan CleanupDone, 1)
+
+	go func() {
+		defer close(events)
+		defer close(done)
+
+		if plan == nil {
+			done <- CleanupDone{Err: errors.New("apply plan: no plan given")}
+			return
+		}
+		if ctx.Err() != nil {
+			done <- CleanupDone{Err: &CancelledError{Operation: "cleanup"}}
+			return
+		}
+		done <- CleanupDone{Result: executeCleanup(ctx, plan.Categories, events, cleanup.Options{EntryTimeout: cleanup.DefaultEntryTimeout})}
+	}()
+
+	return events, done
+}
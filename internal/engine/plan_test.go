@@ -0,0 +1,203 @@
+package engine
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+// planSampleResults returns results spanning three categories with mixed
+// risk levels and one withheld entry.
+func planSampleResults() []scan.CategoryResult {
+	return []scan.CategoryResult{
+		{
+			Category: "system-caches",
+			Entries: []scan.ScanEntry{
+				{Path: "/u/Library/Caches/a", Size: 100, RiskLevel: safety.RiskSafe},
+				{Path: "/u/Library/Caches/b", Size: 200, RiskLevel: safety.RiskSafe},
+			},
+			RecentlyModified: []scan.ScanEntry{
+				{Path: "/u/Library/Caches/fresh", Size: 50},
+			},
+			TotalSize: 300,
+		},
+		{
+			Category: "dev-npm",
+			Entries: []scan.ScanEntry{
+				{Path: "/u/.npm/_cacache", Size: 400},
+			},
+			TotalSize: 400,
+		},
+		{
+			Category: "dev-xcode",
+			Entries: []scan.ScanEntry{
+				{Path: "/u/Library/Developer/Xcode/DerivedData", Size: 800, RiskLevel: safety.RiskRisky},
+			},
+			TotalSize: 800,
+		},
+	}
+}
+
+func planPaths(p *Plan) map[string]bool {
+	paths := map[string]bool{}
+	for _, e := range p.Entries() {
+		paths[e.Path] = true
+	}
+	return paths
+}
+
+func TestBuildPlan_SelectsAllByDefault(t *testing.T) {
+	plan := BuildPlan(planSampleResults(), PlanOptions{})
+
+	if len(plan.Entries()) != 4 {
+		t.Fatalf("expected 4 entries, got %d", len(plan.Entries()))
+	}
+	if plan.TotalSize != 1500 {
+		t.Errorf("expected total 1500, got %d", plan.TotalSize)
+	}
+	if len(plan.Excluded) != 1 || plan.Excluded[0].Reason != ExcludeRecent {
+		t.Errorf("expected only the recently modified entry excluded, got %+v", plan.Excluded)
+	}
+}
+
+func TestBuildPlan_ReportOnlyNeedsExplicitSelection(t *testing.T) {
+	results := []scan.CategoryResult{
+		{Category: "system-caches", Entries: []scan.ScanEntry{{Path: "/c", Size: 10}}, TotalSize: 10},
+		{Category: "app-orphaned-group-containers", ReportOnly: true, Entries: []scan.ScanEntry{{Path: "/g", Size: 20}}, TotalSize: 20},
+	}
+
+	plan := BuildPlan(results, PlanOptions{})
+	if paths := planPaths(plan); len(paths) != 1 || !paths["/c"] {
+		t.Errorf("expected only /c selected by default, got %v", paths)
+	}
+	if len(plan.Excluded) != 1 || plan.Excluded[0].Path != "/g" || plan.Excluded[0].Reason != ExcludeReportOnly {
+		t.Errorf("expected /g excluded as report-only, got %+v", plan.Excluded)
+	}
+
+	plan = BuildPlan(results, PlanOptions{Categories: []string{"app-orphaned-group-containers"}})
+	if paths := planPaths(plan); len(paths) != 1 || !paths["/g"] {
+		t.Errorf("expected /g selected when named, got %v", paths)
+	}
+}
+
+func TestBuildPlan_Exclusions(t *testing.T) {
+	results := planSampleResults()
+	plan := BuildPlan(results, PlanOptions{
+		Skip:         map[string]bool{"dev-npm": true},
+		ExcludePaths: []string{"/u/Library/Caches/b"},
+		MaxRisk:      safety.RiskModerate,
+	})
+
+	paths := planPaths(plan)
+	if len(paths) != 1 || !paths["/u/Library/Caches/a"] {
+		t.Errorf("expected only /u/Library/Caches/a selected, got %v", paths)
+	}
+	if plan.TotalSize != 100 {
+		t.Errorf("expected total 100, got %d", plan.TotalSize)
+	}
+
+	reasons := map[string]string{}
+	for _, ex := range plan.Excluded {
+		reasons[ex.Path] = ex.Reason
+	}
+	want := map[string]string{
+		"/u/Library/Caches/b":                    ExcludePath,
+		"/u/Library/Caches/fresh":                ExcludeRecent,
+		"/u/.npm/_cacache":                       ExcludeSkipped,
+		"/u/Library/Developer/Xcode/DerivedData": ExcludeRisk,
+	}
+	for path, reason := range want {
+		if reasons[path] != reason {
+			t.Errorf("exclusion for %s: got %q, want %q", path, reasons[path], reason)
+		}
+	}
+	if len(plan.Excluded) != len(want) {
+		t.Errorf("expected %d exclusions, got %d", len(want), len(plan.Excluded))
+	}
+
+	// The input results are left untouched.
+	if len(results[0].Entries) != 2 || results[0].TotalSize != 300 {
+		t.Errorf("BuildPlan modified its input: %+v", results[0])
+	}
+}
+
+func TestBuildPlan_CategoriesAndCategoryRisk(t *testing.T) {
+	// dev-npm has no entry risk level; it falls back to the category's
+	// moderate level and is dropped by a safe-only limit.
+	plan := BuildPlan(planSampleResults(), PlanOptions{
+		Categories: []string{"dev-npm"},
+		MaxRisk:    safety.RiskSafe,
+	})
+
+	if len(plan.Entries()) != 0 {
+		t.Errorf("expected no entries, got %v", planPaths(plan))
+	}
+	reasons := map[string]string{}
+	for _, ex := range plan.Excluded {
+		reasons[ex.Path] = ex.Reason
+	}
+	if reasons["/u/.npm/_cacache"] != ExcludeRisk {
+		t.Errorf("expected npm entry excluded by risk, got %q", reasons["/u/.npm/_cacache"])
+	}
+	if reasons["/u/Library/Caches/a"] != ExcludeCategory {
+		t.Errorf("expected unselected category exclusion, got %q", reasons["/u/Library/Caches/a"])
+	}
+}
+
+func TestBuildPlan_UnknownMaxRiskIsStrictest(t *testing.T) {
+	plan := BuildPlan(planSampleResults(), PlanOptions{MaxRisk: "moderat"})
+
+	paths := planPaths(plan)
+	if len(paths) != 2 || !paths["/u/Library/Caches/a"] || !paths["/u/Library/Caches/b"] {
+		t.Errorf("expected only the safe entries, got %v", paths)
+	}
+}
+
+func TestApplyPlan_NilPlan(t *testing.T) {
+	events, done := ApplyPlan(context.Background(), nil)
+	for range events {
+	}
+	if result := <-done; result.Err == nil {
+		t.Error("expected an error for a nil plan")
+	}
+}
+
+func TestApplyPlan_RemovesOnlySelected(t *testing.T) {
+	dir := t.TempDir()
+	keep := filepath.Join(dir, "keep")
+	remove := filepath.Join(dir, "remove")
+	for _, p := range []string{keep, remove} {
+		if err := os.WriteFile(p, []byte("data"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	plan := BuildPlan([]scan.CategoryResult{{
+		Category: "system-caches",
+		Entries: []scan.ScanEntry{
+			{Path: keep, Size: 4},
+			{Path: remove, Size: 4},
+		},
+	}}, PlanOptions{ExcludePaths: []string{keep}})
+
+	events, done := ApplyPlan(context.Background(), plan)
+	for range events {
+	}
+	result := <-done
+	if result.Err != nil {
+		t.Fatalf("ApplyPlan: %v", result.Err)
+	}
+	if result.Result.Removed != 1 || result.Result.Failed != 0 {
+		t.Errorf("expected 1 removed, 0 failed, got %+v", result.Result)
+	}
+	if _, err := os.Stat(remove); !os.IsNotExist(err) {
+		t.Error("expected selected entry to be removed")
+	}
+	if _, err := os.Stat(keep); err != nil {
+		t.Error("expected excluded entry to be kept")
+	}
+}
@@ -1,6 +1,10 @@
 package engine
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
 	"github.com/sp3esu/mac-cleaner/pkg/appleftovers"
 	"github.com/sp3esu/mac-cleaner/pkg/browser"
 	"github.com/sp3esu/mac-cleaner/pkg/creative"
@@ -26,78 +30,171 @@ func (e *Engine) Categories() []ScannerInfo {
 	return infos
 }
 
+// Paths returns the filesystem paths the given scanner examines under home,
+// without scanning them. Scanners that do not implement PathLister report
+// no paths. Returns an error if the scanner ID is not found.
+func (e *Engine) Paths(scannerID, home string) ([]string, error) {
+	for _, s := range e.scanners {
+		if s.Info().ID != scannerID {
+			continue
+		}
+		if pl, ok := s.(PathLister); ok {
+			return pl.Paths(home), nil
+		}
+		return nil, nil
+	}
+	return nil, fmt.Errorf("scanner %q not found", scannerID)
+}
+
 // RegisterDefaults registers all built-in scanner groups with the engine.
-// Each scanner wraps an existing pkg/*/Scan() function via the adapter pattern.
+// Each scanner wraps an existing pkg/*/Scan() and Paths() pair via the
+// adapter pattern. The unused-apps scanner reads e.AppDirs, the system
+// scanner e.ScanTmpCaches and the developer scanner
+// e.KeepLatestDeviceSupport, e.ScanNodeModules, e.ScanPyEnvs and
+// e.ProjectRoots when they run. The developer and app leftovers scanners
+// read e.IncludeHidden. Every scanner that runs external commands reads
+// e.NoExec, and every scanner scans e.Home when it is set. The scanners
+// that list the top-level entries of caches size them no deeper than
+// e.MaxDepth.
 func RegisterDefaults(e *Engine) {
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewScannerWithPaths(ScannerInfo{
 		ID:          "system",
 		Name:        "System Caches",
 		Description: "User caches, logs, and QuickLook thumbnails",
-		CategoryIDs: []string{"system-caches", "system-logs", "quicklook"},
-	}, system.Scan))
+		CategoryIDs: []string{
+			"system-caches", "system-logs", "quicklook", "system-sysdiagnose", "system-broken-symlinks",
+			"system-installer-leftovers", "system-tmp-caches", "system-iclouddrive-cache",
+		},
+	}, func() ([]scan.CategoryResult, error) {
+		return system.ScanWithOptions(system.Options{TmpCaches: e.ScanTmpCaches, Home: e.Home, MaxDepth: e.MaxDepth})
+	}, system.Paths))
 
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewScannerWithPaths(ScannerInfo{
 		ID:          "browser",
 		Name:        "Browser Data",
 		Description: "Safari, Chrome, and Firefox caches",
-		CategoryIDs: []string{"browser-safari", "browser-chrome", "browser-firefox"},
-	}, browser.Scan))
+		CategoryIDs: []string{"browser-safari", "browser-safari-favicons", "browser-safari-website-data", "browser-chrome", "browser-chrome-storage", "browser-firefox"},
+	}, e.scanHomeDepthFunc(browser.ScanHomeDepth), browser.Paths))
 
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewStatusScannerWithPaths(ScannerInfo{
 		ID:          "developer",
 		Name:        "Developer Caches",
 		Description: "Xcode, npm, yarn, Homebrew, Docker, and more",
 		CategoryIDs: []string{
-			"dev-xcode", "dev-npm", "dev-yarn", "dev-homebrew", "dev-docker",
+			"dev-xcode", "dev-xcode-index", "dev-npm", "dev-yarn", "dev-homebrew", "dev-brew-autoremove", "dev-docker",
 			"dev-pnpm", "dev-cocoapods", "dev-gradle", "dev-pip",
 			"dev-simulator-caches", "dev-simulator-logs",
-			"dev-xcode-device-support", "dev-xcode-archives",
+			"dev-xcode-device-support", "dev-xcode-archives", "dev-mobiledevice",
+			"dev-docker-vm", "dev-node-modules", "dev-pyenvs",
 		},
-	}, developer.Scan))
+	}, func(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+		return developer.ScanWithOptions(developer.Options{
+			KeepLatestDeviceSupport: e.KeepLatestDeviceSupport,
+			NodeModules:             e.ScanNodeModules,
+			PyEnvs:                  e.ScanPyEnvs,
+			ProjectRoots:            e.ProjectRoots,
+			IncludeHidden:           e.IncludeHidden,
+			NoExec:                  e.NoExec,
+			Home:                    e.Home,
+			Status:                  report,
+			MaxDepth:                e.MaxDepth,
+		})
+	}, func(home string) []string {
+		paths := developer.Paths(home)
+		if e.ScanNodeModules || e.ScanPyEnvs {
+			roots := e.ProjectRoots
+			if len(roots) == 0 {
+				roots = developer.ProjectRoots(home)
+			}
+			paths = append(paths, roots...)
+		}
+		return paths
+	}))
 
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewStatusScannerWithPaths(ScannerInfo{
 		ID:          "appleftovers",
 		Name:        "App Leftovers",
-		Description: "Orphaned preferences, iOS backups, and old Downloads",
-		CategoryIDs: []string{"app-orphaned-prefs", "app-ios-backups", "app-old-downloads"},
-	}, appleftovers.Scan))
+		Description: "Orphaned preferences and Group Containers, iOS backups, and old Downloads",
+		CategoryIDs: []string{"app-orphaned-prefs", "app-orphaned-group-containers", "app-ios-backups", "app-old-downloads"},
+	}, func(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+		return appleftovers.ScanWithOptions(appleftovers.Options{NoExec: e.NoExec, IncludeHidden: e.IncludeHidden, Home: e.Home, Status: report, MaxDepth: e.MaxDepth})
+	}, appleftovers.Paths))
 
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewScannerWithPaths(ScannerInfo{
 		ID:          "creative",
 		Name:        "Creative App Caches",
 		Description: "Adobe, Sketch, and Figma caches",
-		CategoryIDs: []string{"creative-adobe", "creative-adobe-media", "creative-sketch", "creative-figma"},
-	}, creative.Scan))
+		CategoryIDs: []string{
+			"creative-adobe", "creative-adobe-media", "creative-sketch", "creative-figma",
+			"creative-adobe-logs", "creative-figma-profile",
+		},
+	}, e.scanHomeDepthFunc(creative.ScanHomeDepth), creative.Paths))
 
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewScannerWithPaths(ScannerInfo{
 		ID:          "messaging",
 		Name:        "Messaging App Caches",
-		Description: "Slack, Discord, Teams, and Zoom caches",
-		CategoryIDs: []string{"msg-slack", "msg-discord", "msg-teams", "msg-zoom"},
-	}, messaging.Scan))
+		Description: "Slack, Discord, Teams, and Zoom caches, recordings, and downloads",
+		CategoryIDs: []string{
+			"msg-slack", "msg-discord", "msg-teams", "msg-zoom",
+			"msg-zoom-recordings", "msg-slack-downloads",
+		},
+	}, e.scanHomeDepthFunc(messaging.ScanHomeDepth), messaging.Paths))
 
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewScannerWithPaths(ScannerInfo{
 		ID:          "photos",
 		Name:        "Photos & Media Analysis Caches",
 		Description: "Photos app caches, ML analysis data, iCloud sync cache, and Messages shared photos",
 		CategoryIDs: []string{"photos-caches", "photos-analysis", "photos-icloud-cache", "photos-syndication"},
-	}, photos.Scan))
+	}, e.scanHomeFunc(photos.Scan, photos.ScanHome), photos.Paths))
 
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewScannerWithPaths(ScannerInfo{
 		ID:          "unused",
 		Name:        "Unused Applications",
 		Description: "Applications not opened in 180+ days",
 		CategoryIDs: []string{"unused-apps"},
-	}, unused.Scan))
+	}, func() ([]scan.CategoryResult, error) {
+		return unused.ScanWithOptions(unused.Options{AppDirs: e.AppDirs, NoExec: e.NoExec, Home: e.Home})
+	}, func(home string) []string {
+		return unused.AppDirs(home, e.AppDirs)
+	}))
 
-	e.Register(NewScanner(ScannerInfo{
+	e.Register(NewStatusScannerWithPaths(ScannerInfo{
 		ID:          "systemdata",
 		Name:        "System Data",
 		Description: "Spotlight metadata, Mail, Messages, iOS updates, Time Machine snapshots, VM disk images",
 		CategoryIDs: []string{
-			"sysdata-spotlight", "sysdata-mail", "sysdata-mail-downloads",
-			"sysdata-messages", "sysdata-ios-updates", "sysdata-timemachine",
+			"sysdata-spotlight", "sysdata-mail", "sysdata-mail-envelope", "sysdata-mail-downloads",
+			"sysdata-messages", "sysdata-ios-updates", "sysdata-timemachine", "sysdata-unified-logs",
 			"sysdata-vm-parallels", "sysdata-vm-utm", "sysdata-vm-vmware",
 		},
-	}, systemdata.Scan))
+	}, func(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+		return systemdata.ScanWithOptions(systemdata.Options{NoExec: e.NoExec, Home: e.Home, Status: report, MaxDepth: e.MaxDepth})
+	}, systemdata.Paths))
+}
+
+// scanHomeFunc returns a scan function that calls scanHome with e.Home
+// when it is set, and scanDefault otherwise.
+func (e *Engine) scanHomeFunc(scanDefault func() ([]scan.CategoryResult, error), scanHome func(home string) ([]scan.CategoryResult, error)) func() ([]scan.CategoryResult, error) {
+	return func() ([]scan.CategoryResult, error) {
+		if e.Home == "" {
+			return scanDefault()
+		}
+		return scanHome(e.Home)
+	}
+}
+
+// scanHomeDepthFunc returns a scan function that calls scanHome with
+// e.Home, or the current user's home directory when it is empty, and
+// e.MaxDepth.
+func (e *Engine) scanHomeDepthFunc(scanHome func(home string, depth int) ([]scan.CategoryResult, error)) func() ([]scan.CategoryResult, error) {
+	return func() ([]scan.CategoryResult, error) {
+		home := e.Home
+		if home == "" {
+			var err error
+			if home, err = os.UserHomeDir(); err != nil {
+				return nil, fmt.Errorf("cannot determine home directory: %w", err)
+			}
+		}
+		return scanHome(home, e.MaxDepth)
+	}
 }
@@ -19,6 +19,11 @@ type ScannerInfo struct {
 	// RiskLevel is the dominant risk level for the group (may be empty
 	// when risk is per-category rather than per-group).
 	RiskLevel string
+	// CategoryRisks declares the risk level of categories this scanner
+	// produces. The engine applies them to the emitted entries in place
+	// of safety.RiskForCategory, which remains the fallback for
+	// categories not listed.
+	CategoryRisks map[string]string
 }
 
 // Scanner is the interface all scanners implement. It provides both
@@ -30,18 +35,63 @@ type Scanner interface {
 	Info() ScannerInfo
 }
 
-// scannerAdapter wraps a bare Scan function into the Scanner interface.
+// StatusScanner is implemented by scanners that report category statuses
+// (see scan.CategoryStatus) while they scan.
+type StatusScanner interface {
+	// ScanWithStatus is Scan that passes each category status to report.
+	ScanWithStatus(report scan.StatusFunc) ([]scan.CategoryResult, error)
+}
+
+// PathLister is implemented by scanners that can report the filesystem
+// paths they examine without scanning them.
+type PathLister interface {
+	// Paths returns the candidate paths under home, existing or not.
+	Paths(home string) []string
+}
+
+// scannerAdapter wraps a bare Scan function into the Scanner and
+// StatusScanner interfaces.
 type scannerAdapter struct {
 	info   ScannerInfo
-	scanFn func() ([]scan.CategoryResult, error)
+	scanFn func(report scan.StatusFunc) ([]scan.CategoryResult, error)
 }
 
-func (a *scannerAdapter) Scan() ([]scan.CategoryResult, error) { return a.scanFn() }
+func (a *scannerAdapter) Scan() ([]scan.CategoryResult, error) { return a.scanFn(nil) }
 func (a *scannerAdapter) Info() ScannerInfo                     { return a.info }
 
+func (a *scannerAdapter) ScanWithStatus(report scan.StatusFunc) ([]scan.CategoryResult, error) {
+	return a.scanFn(report)
+}
+
 // NewScanner creates a Scanner from metadata and a scan function.
 // This adapter pattern wraps existing pkg/*/Scan() functions without
 // modifying their signatures.
 func NewScanner(info ScannerInfo, fn func() ([]scan.CategoryResult, error)) Scanner {
+	return NewStatusScanner(info, func(scan.StatusFunc) ([]scan.CategoryResult, error) { return fn() })
+}
+
+// NewStatusScanner is like NewScanner for scan functions that report
+// category statuses to the StatusFunc they are given.
+func NewStatusScanner(info ScannerInfo, fn func(report scan.StatusFunc) ([]scan.CategoryResult, error)) Scanner {
 	return &scannerAdapter{info: info, scanFn: fn}
 }
+
+// pathScannerAdapter extends scannerAdapter with a PathLister.
+type pathScannerAdapter struct {
+	scannerAdapter
+	pathsFn func(home string) []string
+}
+
+func (a *pathScannerAdapter) Paths(home string) []string { return a.pathsFn(home) }
+
+// NewScannerWithPaths is like NewScanner but also exposes pathsFn through
+// the PathLister interface, for pkg/* packages that provide Paths(home).
+func NewScannerWithPaths(info ScannerInfo, fn func() ([]scan.CategoryResult, error), pathsFn func(home string) []string) Scanner {
+	return NewStatusScannerWithPaths(info, func(scan.StatusFunc) ([]scan.CategoryResult, error) { return fn() }, pathsFn)
+}
+
+// NewStatusScannerWithPaths is NewScannerWithPaths for scan functions that
+// report category statuses, as with NewStatusScanner.
+func NewStatusScannerWithPaths(info ScannerInfo, fn func(report scan.StatusFunc) ([]scan.CategoryResult, error), pathsFn func(home string) []string) Scanner {
+	return &pathScannerAdapter{scannerAdapter: scannerAdapter{info: info, scanFn: fn}, pathsFn: pathsFn}
+}
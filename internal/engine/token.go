@@ -3,6 +3,7 @@ package engine
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"sync"
 	"time"
 
 	"github.com/sp3esu/mac-cleaner/internal/scan"
@@ -15,46 +16,179 @@ type ScanToken string
 type tokenEntry struct {
 	results []scan.CategoryResult
 	created time.Time
+	// key is the cache key of the scan params that produced the results.
+	key string
+	// filtered is set when the result shown for the token left categories
+	// out (see MarkFiltered).
+	filtered bool
 }
 
-// storeResults saves results under a new token, invalidating any previous
-// token (single-token store policy). Returns the new token.
-func (e *Engine) storeResults(results []scan.CategoryResult) ScanToken {
+// maxTokens bounds how many scans' results the token store keeps. Once
+// it is full, storing another evicts the oldest.
+const maxTokens = 8
+
+// tokenStore holds the results of recent scans, each under its own token,
+// so one client's scan does not invalidate the token of another's.
+type tokenStore struct {
+	mu      sync.Mutex
+	entries map[ScanToken]*tokenEntry
+	// order lists the live tokens, oldest first.
+	order []ScanToken
+	// latest is the token of the most recent scan, whose results the
+	// cache serves. It is cleared by any cleanup.
+	latest ScanToken
+}
+
+// lookup returns the entry stored under token. ts.mu must be held.
+func (ts *tokenStore) lookup(token ScanToken) (*tokenEntry, bool) {
+	entry, ok := ts.entries[token]
+	return entry, ok && token != ""
+}
+
+// remove drops token from the store. ts.mu must be held.
+func (ts *tokenStore) remove(token ScanToken) {
+	delete(ts.entries, token)
+	for i, t := range ts.order {
+		if t == token {
+			ts.order = append(ts.order[:i], ts.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// ShareTokens makes e use the token store of other, so tokens issued by
+// other, including by its scans still running, stay valid for cleanup
+// through e. The server calls it when a reload replaces its engine.
+func (e *Engine) ShareTokens(other *Engine) {
+	e.tokens = other.tokens
+}
+
+// storeResults saves results under a new token. Earlier tokens stay valid
+// until consumed or until maxTokens newer scans have been stored. The key
+// records the scan params for the result cache. Returns the new token and
+// when the results were stored.
+func (e *Engine) storeResults(results []scan.CategoryResult, key string) (ScanToken, time.Time) {
 	b := make([]byte, 16)
 	// crypto/rand.Read never returns an error for small reads on supported platforms.
 	_, _ = rand.Read(b)
 	token := ScanToken(hex.EncodeToString(b))
 
-	e.mu.Lock()
-	e.lastToken.token = token
-	e.lastToken.entry = &tokenEntry{
+	created := time.Now()
+	ts := e.tokens
+	ts.mu.Lock()
+	if ts.entries == nil {
+		ts.entries = make(map[ScanToken]*tokenEntry)
+	}
+	if len(ts.order) >= maxTokens {
+		ts.remove(ts.order[0])
+	}
+	ts.entries[token] = &tokenEntry{
 		results: results,
-		created: time.Now(),
+		created: created,
+		key:     key,
 	}
-	e.mu.Unlock()
+	ts.order = append(ts.order, token)
+	ts.latest = token
+	ts.mu.Unlock()
 
-	return token
+	return token, created
+}
+
+// MarkFiltered records that a result shown for token left some of its
+// categories out, e.g. those below a minimum size. Cleanup refuses to
+// clean every category under a filtered token; the categories must be
+// named. The mark is never cleared: a cached scan hands the same token to
+// every client, and one that saw the whole result must not lift the
+// restriction for one that did not. Unknown tokens are ignored.
+func (e *Engine) MarkFiltered(token ScanToken) {
+	ts := e.tokens
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	if entry, ok := ts.lookup(token); ok {
+		entry.filtered = true
+	}
 }
 
-// validateToken checks that the given token matches the stored token.
-// If valid, returns a copy of the stored results and clears the token
-// (one-time use / replay protection). If invalid, returns a TokenError.
+// tokenFiltered reports whether token is a live token and was marked
+// with MarkFiltered.
+func (e *Engine) tokenFiltered(token ScanToken) bool {
+	ts := e.tokens
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	entry, ok := ts.lookup(token)
+	return ok && entry.filtered
+}
+
+// validateToken checks that the given token is a live token. If valid,
+// returns a copy of its results and removes the token (one-time use /
+// replay protection), and invalidates the result cache, since the cleanup
+// about to run changes what a scan would find. If invalid, returns a
+// TokenError.
 func (e *Engine) validateToken(token ScanToken) ([]scan.CategoryResult, error) {
-	e.mu.Lock()
-	defer e.mu.Unlock()
+	ts := e.tokens
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
 
-	if e.lastToken.entry == nil || e.lastToken.token != token {
+	entry, ok := ts.lookup(token)
+	if !ok {
 		return nil, &TokenError{Token: token, Reason: "unknown or expired"}
 	}
 
 	// Copy results to prevent caller from mutating the stored slice.
-	src := e.lastToken.entry.results
+	src := entry.results
 	results := make([]scan.CategoryResult, len(src))
 	copy(results, src)
 
-	// Clear the token (consumed).
-	e.lastToken.token = ""
-	e.lastToken.entry = nil
+	// Remove the token (consumed) and invalidate the cache.
+	ts.remove(token)
+	ts.latest = ""
 
 	return results, nil
 }
+
+// CategoryDetail returns one category of the results stored under token,
+// without consuming the token, so a client can inspect a category before
+// cleaning up. Returns a TokenError if token is not a live one and a
+// CategoryError if the scan produced no such category. The entries are a
+// copy the caller may modify.
+func (e *Engine) CategoryDetail(token ScanToken, category string) (scan.CategoryResult, error) {
+	ts := e.tokens
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	entry, ok := ts.lookup(token)
+	if !ok {
+		return scan.CategoryResult{}, &TokenError{Token: token, Reason: "unknown or expired"}
+	}
+	for _, cr := range entry.results {
+		if cr.Category == category {
+			cr.Entries = append([]scan.ScanEntry(nil), cr.Entries...)
+			cr.PermissionIssues = append([]scan.PermissionIssue(nil), cr.PermissionIssues...)
+			return cr, nil
+		}
+	}
+	return scan.CategoryResult{}, &CategoryError{Category: category}
+}
+
+// cachedResults returns a copy of the latest scan's results, their token
+// and when they were stored when the cache is enabled, that scan had the
+// same params, and it is younger than CacheTTL. Any cleanup invalidates
+// the cache.
+func (e *Engine) cachedResults(key string) ([]scan.CategoryResult, ScanToken, time.Time, bool) {
+	if e.CacheTTL <= 0 {
+		return nil, "", time.Time{}, false
+	}
+
+	ts := e.tokens
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	entry, ok := ts.lookup(ts.latest)
+	if !ok || entry.key != key || time.Since(entry.created) >= e.CacheTTL {
+		return nil, "", time.Time{}, false
+	}
+
+	results := make([]scan.CategoryResult, len(entry.results))
+	copy(results, entry.results)
+	return results, ts.latest, entry.created, true
+}
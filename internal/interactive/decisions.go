@@ -0,0 +1,68 @@
+package interactive
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Decision is a remembered walkthrough choice for a category.
+type Decision string
+
+const (
+	DecisionKeep   Decision = "keep"
+	DecisionDelete Decision = "delete"
+)
+
+// Decisions maps category IDs to the choice made for their entries in a
+// previous walkthrough. Categories without an entry have no default.
+type Decisions map[string]Decision
+
+// DecisionsPath returns the location of the saved walkthrough decisions,
+// ~/.config/mac-cleaner/walkthrough.json.
+func DecisionsPath(home string) string {
+	return filepath.Join(home, ".config", "mac-cleaner", "walkthrough.json")
+}
+
+// LoadDecisions reads the saved walkthrough decisions. A missing file
+// yields empty decisions; unrecognized values are dropped.
+func LoadDecisions(home string) (Decisions, error) {
+	data, err := os.ReadFile(DecisionsPath(home))
+	if os.IsNotExist(err) {
+		return Decisions{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read walkthrough decisions: %w", err)
+	}
+	var d Decisions
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, fmt.Errorf("parse walkthrough decisions: %w", err)
+	}
+	for id, v := range d {
+		if v != DecisionKeep && v != DecisionDelete {
+			delete(d, id)
+		}
+	}
+	if d == nil {
+		d = Decisions{}
+	}
+	return d, nil
+}
+
+// SaveDecisions writes d as the saved walkthrough decisions, creating the
+// parent directory if needed.
+func SaveDecisions(home string, d Decisions) error {
+	path := DecisionsPath(home)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create config dir: %w", err)
+	}
+	data, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode walkthrough decisions: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0600); err != nil {
+		return fmt.Errorf("write walkthrough decisions: %w", err)
+	}
+	return nil
+}
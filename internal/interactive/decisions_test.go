@@ -0,0 +1,137 @@
+package interactive
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
+)
+
+func TestDecisions_SaveAndLoad(t *testing.T) {
+	home := t.TempDir()
+	saved := Decisions{"dev-npm": DecisionDelete, "browser-safari": DecisionKeep}
+	if err := SaveDecisions(home, saved); err != nil {
+		t.Fatalf("SaveDecisions: %v", err)
+	}
+
+	got, err := LoadDecisions(home)
+	if err != nil {
+		t.Fatalf("LoadDecisions: %v", err)
+	}
+	if len(got) != 2 || got["dev-npm"] != DecisionDelete || got["browser-safari"] != DecisionKeep {
+		t.Errorf("LoadDecisions = %v, want %v", got, saved)
+	}
+}
+
+func TestDecisions_LoadMissing(t *testing.T) {
+	got, err := LoadDecisions(t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadDecisions: %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("expected no decisions, got %v", got)
+	}
+}
+
+func TestDecisions_LoadDropsUnknownValues(t *testing.T) {
+	home := t.TempDir()
+	if err := SaveDecisions(home, Decisions{"dev-npm": "maybe", "dev-yarn": DecisionKeep}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := LoadDecisions(home)
+	if err != nil {
+		t.Fatalf("LoadDecisions: %v", err)
+	}
+	if _, ok := got["dev-npm"]; ok || got["dev-yarn"] != DecisionKeep {
+		t.Errorf("LoadDecisions = %v, want only dev-yarn=keep", got)
+	}
+}
+
+func TestDecisions_LoadCorrupt(t *testing.T) {
+	home := t.TempDir()
+	path := DecisionsPath(home)
+	if err := SaveDecisions(home, Decisions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("{not json"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadDecisions(home); err == nil {
+		t.Error("expected error for corrupt decisions file")
+	}
+}
+
+func TestRunWalkthroughWithDefaults_UsesSavedChoices(t *testing.T) {
+	home := t.TempDir()
+	if err := SaveDecisions(home, Decisions{"dev-npm": DecisionDelete, "dev-yarn": DecisionKeep}); err != nil {
+		t.Fatal(err)
+	}
+	defaults, err := LoadDecisions(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	results := []scan.CategoryResult{
+		{Category: "dev-npm", Description: "npm", Entries: []scan.ScanEntry{{Path: "/tmp/npm", Description: "npm-item", Size: 100}}},
+		{Category: "dev-yarn", Description: "yarn", Entries: []scan.ScanEntry{{Path: "/tmp/yarn", Description: "yarn-item", Size: 200}}},
+		{Category: "dev-pip", Description: "pip", Entries: []scan.ScanEntry{{Path: "/tmp/pip", Description: "pip-item", Size: 300}}},
+	}
+
+	// Enter accepts the saved defaults; the new pip category has no
+	// default, so Enter re-prompts and "k" answers it.
+	in := strings.NewReader("\n\n\nk\n")
+	out := &bytes.Buffer{}
+	got, decisions := RunWalkthroughWithDefaults(in, out, results, defaults)
+
+	output := out.String()
+	for _, want := range []string{"npm-item", "[k/R]", "yarn-item", "[K/r]", "pip-item", "[k/r]"} {
+		if !strings.Contains(output, want) {
+			t.Errorf("output missing %q:\n%s", want, output)
+		}
+	}
+	if !strings.Contains(output, "Please enter 'k' to keep or 'r' to remove") {
+		t.Errorf("expected a re-prompt for the category without a default:\n%s", output)
+	}
+
+	if len(got) != 1 || got[0].Category != "dev-npm" {
+		t.Fatalf("expected only dev-npm marked for removal, got %+v", got)
+	}
+	want := Decisions{"dev-npm": DecisionDelete, "dev-yarn": DecisionKeep, "dev-pip": DecisionKeep}
+	if len(decisions) != len(want) {
+		t.Fatalf("decisions = %v, want %v", decisions, want)
+	}
+	for id, d := range want {
+		if decisions[id] != d {
+			t.Errorf("decisions[%s] = %q, want %q", id, decisions[id], d)
+		}
+	}
+}
+
+func TestRunWalkthroughWithDefaults_OverrideAndMixed(t *testing.T) {
+	defaults := Decisions{"dev-npm": DecisionDelete, "dev-gradle": DecisionKeep}
+	results := []scan.CategoryResult{
+		{Category: "dev-npm", Description: "npm", Entries: []scan.ScanEntry{{Path: "/tmp/npm", Size: 100}}},
+		{Category: "dev-yarn", Description: "yarn", Entries: []scan.ScanEntry{
+			{Path: "/tmp/yarn-a", Size: 1},
+			{Path: "/tmp/yarn-b", Size: 2},
+		}},
+	}
+
+	in := strings.NewReader("k\nr\nk\n")
+	got, decisions := RunWalkthroughWithDefaults(in, &bytes.Buffer{}, results, defaults)
+
+	if len(got) != 1 || got[0].Category != "dev-yarn" {
+		t.Fatalf("expected only dev-yarn marked for removal, got %+v", got)
+	}
+	if decisions["dev-npm"] != DecisionKeep {
+		t.Errorf("expected overridden dev-npm to be saved as keep, got %q", decisions["dev-npm"])
+	}
+	if _, ok := decisions["dev-yarn"]; ok {
+		t.Errorf("expected mixed dev-yarn to have no decision, got %q", decisions["dev-yarn"])
+	}
+	if decisions["dev-gradle"] != DecisionKeep {
+		t.Errorf("expected unreviewed dev-gradle to keep its saved decision, got %q", decisions["dev-gradle"])
+	}
+}
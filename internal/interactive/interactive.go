@@ -20,6 +20,22 @@ import (
 // only categories/entries that the user marked for removal. If no items
 // exist or none are marked for removal, it returns nil.
 func RunWalkthrough(in io.Reader, out io.Writer, results []scan.CategoryResult) []scan.CategoryResult {
+	filtered, _ := RunWalkthroughWithDefaults(in, out, results, nil)
+	return filtered
+}
+
+// RunWalkthroughWithDefaults is RunWalkthrough with each category's entries
+// defaulting to its choice in defaults: pressing Enter accepts it, and the
+// user can still answer either way. Categories missing from defaults have
+// no default. It also returns defaults updated with this run's choices: a
+// category whose entries were all kept or all removed records that
+// decision, and a mixed category has its decision cleared.
+func RunWalkthroughWithDefaults(in io.Reader, out io.Writer, results []scan.CategoryResult, defaults Decisions) ([]scan.CategoryResult, Decisions) {
+	decisions := Decisions{}
+	for id, d := range defaults {
+		decisions[id] = d
+	}
+
 	// Count total items across all categories.
 	totalItems := 0
 	for _, cat := range results {
@@ -28,7 +44,7 @@ func RunWalkthrough(in io.Reader, out io.Writer, results []scan.CategoryResult)
 
 	if totalItems == 0 {
 		fmt.Fprintln(out, "Nothing to clean.")
-		return nil
+		return nil, decisions
 	}
 
 	fmt.Fprintf(out, "\nFound %d items. Review each to keep or remove:\n", totalItems)
@@ -49,6 +65,7 @@ func RunWalkthrough(in io.Reader, out io.Writer, results []scan.CategoryResult)
 
 		var removedEntries []scan.ScanEntry
 		var removedSize int64
+		def := defaults[cat.Category]
 
 		for _, entry := range cat.Entries {
 			itemNum++
@@ -62,16 +79,25 @@ func RunWalkthrough(in io.Reader, out io.Writer, results []scan.CategoryResult)
 				riskTag = yellow.Sprint("  [moderate]")
 			}
 			fmt.Fprintf(out, "  [%d/%d] %s  %s%s\n", itemNum, totalItems,
-				entry.Description, cyan.Sprint(sizeStr), riskTag)
-			fmt.Fprint(out, "  keep or remove? [k/r]: ")
+				scan.ValidUTF8(entry.Description), cyan.Sprint(sizeStr), riskTag)
+			fmt.Fprintf(out, "  keep or remove? %s: ", choicePrompt(def))
 
-			choice := readChoice(reader, out)
+			choice := readChoice(reader, out, def)
 			if choice == "remove" {
 				removedEntries = append(removedEntries, entry)
 				removedSize += entry.Size
 			}
 		}
 
+		switch len(removedEntries) {
+		case 0:
+			decisions[cat.Category] = DecisionKeep
+		case len(cat.Entries):
+			decisions[cat.Category] = DecisionDelete
+		default:
+			delete(decisions, cat.Category)
+		}
+
 		if len(removedEntries) > 0 {
 			filtered = append(filtered, scan.CategoryResult{
 				Category:    cat.Category,
@@ -84,16 +110,28 @@ func RunWalkthrough(in io.Reader, out io.Writer, results []scan.CategoryResult)
 
 	if len(filtered) == 0 {
 		fmt.Fprintln(out, "Nothing marked for removal.")
-		return nil
+		return nil, decisions
 	}
 
-	return filtered
+	return filtered, decisions
+}
+
+// choicePrompt returns the [k/r] answer hint, capitalizing the default.
+func choicePrompt(def Decision) string {
+	switch def {
+	case DecisionKeep:
+		return "[K/r]"
+	case DecisionDelete:
+		return "[k/R]"
+	}
+	return "[k/r]"
 }
 
 // readChoice reads user input and returns either "keep" or "remove".
-// On EOF or read error, it defaults to "keep" (safe default).
-// On invalid input, it re-prompts until a valid response is given.
-func readChoice(reader *bufio.Reader, out io.Writer) string {
+// On EOF or read error, it defaults to "keep" (safe default). An empty
+// line selects def when set. On invalid input, it re-prompts until a
+// valid response is given.
+func readChoice(reader *bufio.Reader, out io.Writer, def Decision) string {
 	for {
 		line, err := reader.ReadString('\n')
 		if err != nil {
@@ -107,6 +145,14 @@ func readChoice(reader *bufio.Reader, out io.Writer) string {
 			return "remove"
 		case "k", "keep":
 			return "keep"
+		case "":
+			switch def {
+			case DecisionDelete:
+				return "remove"
+			case DecisionKeep:
+				return "keep"
+			}
+			fallthrough
 		default:
 			fmt.Fprint(out, "  Please enter 'k' to keep or 'r' to remove: ")
 		}
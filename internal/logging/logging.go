@@ -0,0 +1,101 @@
+// Package logging provides the leveled diagnostic logger shared by the
+// scanners, the engine and the server. Records go to stderr as text, or as
+// JSON for machine consumption; the default level keeps normal output
+// clean, and lower levels explain why a scan reported less than expected.
+package logging
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+// DefaultLevel is the level used when --log-level is not given. Only
+// errors are logged, so normal output is unaffected.
+const DefaultLevel = "error"
+
+// Levels lists the accepted --log-level values, most verbose first.
+var Levels = []string{"debug", "info", "warn", "error"}
+
+var logger atomic.Pointer[slog.Logger]
+
+func init() {
+	logger.Store(newLogger(os.Stderr, slog.LevelError, false))
+}
+
+// ParseLevel converts a --log-level value to a slog level. The empty
+// string selects DefaultLevel.
+func ParseLevel(s string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error", "":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q (want one of %s)", s, strings.Join(Levels, ", "))
+}
+
+// Setup directs log records at or above level to w, as JSON lines when
+// jsonFormat is set and as logfmt-style text otherwise.
+func Setup(w io.Writer, level string, jsonFormat bool) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+	logger.Store(newLogger(w, lvl, jsonFormat))
+	return nil
+}
+
+// newLogger builds a logger writing to w at level.
+func newLogger(w io.Writer, level slog.Level, jsonFormat bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if jsonFormat {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Logger returns the current logger.
+func Logger() *slog.Logger {
+	return logger.Load()
+}
+
+// Debug logs msg with key-value args at debug level.
+func Debug(msg string, args ...any) {
+	Logger().Debug(msg, args...)
+}
+
+// Info logs msg with key-value args at info level.
+func Info(msg string, args ...any) {
+	Logger().Info(msg, args...)
+}
+
+// Warn logs msg with key-value args at warn level.
+func Warn(msg string, args ...any) {
+	Logger().Warn(msg, args...)
+}
+
+// Error logs msg with key-value args at error level.
+func Error(msg string, args ...any) {
+	Logger().Error(msg, args...)
+}
+
+// CommandError logs a failed external command: at warn level when ctx's
+// deadline expired, since the scan then under-reports, and at debug level
+// otherwise (e.g. a daemon that is not running).
+func CommandError(ctx context.Context, command string, err error) {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		Warn("command timed out", "command", command, "error", err)
+		return
+	}
+	Debug("command failed", "command", command, "error", err)
+}
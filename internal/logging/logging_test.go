@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+// restore resets the logger to its defaults when the test ends.
+func restore(t *testing.T) {
+	t.Cleanup(func() { _ = Setup(os.Stderr, DefaultLevel, false) })
+}
+
+func TestDefaultLevelSuppressesDebugAndWarn(t *testing.T) {
+	restore(t)
+	var buf bytes.Buffer
+	if err := Setup(&buf, "", false); err != nil {
+		t.Fatal(err)
+	}
+
+	Debug("scanner skipped", "scanner", "system")
+	Warn("permission denied", "path", "/x")
+	if buf.Len() != 0 {
+		t.Errorf("expected no records at the default level, got %q", buf.String())
+	}
+	Error("scanner failed", "scanner", "system")
+	if !strings.Contains(buf.String(), "level=ERROR") {
+		t.Errorf("expected error record, got %q", buf.String())
+	}
+}
+
+func TestSetupJSON(t *testing.T) {
+	restore(t)
+	var buf bytes.Buffer
+	if err := Setup(&buf, "debug", true); err != nil {
+		t.Fatal(err)
+	}
+
+	Debug("scanner finished", "scanner", "developer")
+
+	var rec map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("expected a JSON record, got %q: %v", buf.String(), err)
+	}
+	if rec["level"] != "DEBUG" || rec["msg"] != "scanner finished" || rec["scanner"] != "developer" {
+		t.Errorf("unexpected record: %v", rec)
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	for _, s := range []string{"debug", "INFO", "warn", "warning", "error", ""} {
+		if _, err := ParseLevel(s); err != nil {
+			t.Errorf("ParseLevel(%q): %v", s, err)
+		}
+	}
+	if _, err := ParseLevel("verbose"); err == nil {
+		t.Error("expected error for unknown level")
+	}
+}
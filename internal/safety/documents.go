@@ -0,0 +1,80 @@
+package safety
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// documentExtensions lists the file extensions of user documents: office
+// files, design files and camera photos. PNG, GIF and JPEG are left out
+// because caches, photo thumbnails included, are full of them.
+var documentExtensions = map[string]bool{
+	".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true,
+	".ppt": true, ".pptx": true, ".pages": true, ".numbers": true, ".key": true,
+	".odt": true, ".ods": true, ".rtf": true,
+	".psd": true, ".ai": true, ".sketch": true, ".fig": true, ".indd": true,
+	".heic": true, ".tif": true, ".tiff": true,
+	".raw": true, ".dng": true, ".cr2": true, ".cr3": true, ".nef": true, ".arw": true,
+}
+
+const (
+	// documentSampleSize is how many files LooksLikeDocuments examines
+	// at most.
+	documentSampleSize = 1000
+	// documentDirSample is how many files LooksLikeDocuments examines at
+	// most in any one directory.
+	documentDirSample = 20
+	// documentDirLimit is how many directories LooksLikeDocuments reads at
+	// most, bounding the walk of a tree of nearly empty directories.
+	documentDirLimit = 5000
+	// documentMinSample is the fewest files a directory must hold before
+	// it can look like documents, so a stray PDF in a tiny cache does not
+	// count.
+	documentMinSample = 5
+	// documentThreshold is the percentage of sampled files that must be
+	// documents.
+	documentThreshold = 30
+)
+
+// LooksLikeDocuments samples up to 1000 files under dir and reports
+// whether a significant share of them (30% of at least 5) are user
+// documents such as PDFs, office and design files or camera photos, along
+// with the number of documents and files sampled. Directories are visited
+// shallowest first and each contributes at most 20 files, so neither one
+// large directory nor the first directories in name order make up the
+// sample. Document packages such as Keynote or Pages bundles count as one
+// document and are not walked. Unreadable entries and symlinks are
+// skipped. It is a heuristic guard for directories offered as caches that
+// a user may have repurposed.
+func LooksLikeDocuments(dir string) (ok bool, docs, sampled int) {
+	queue := []string{dir}
+	for read := 0; len(queue) > 0 && read < documentDirLimit && sampled < documentSampleSize; read++ {
+		current := queue[0]
+		queue = queue[1:]
+		entries, err := os.ReadDir(current)
+		if err != nil {
+			continue
+		}
+		taken := 0
+		for _, d := range entries {
+			isDoc := documentExtensions[strings.ToLower(filepath.Ext(d.Name()))]
+			switch {
+			case d.IsDir() && !isDoc:
+				queue = append(queue, filepath.Join(current, d.Name()))
+				continue
+			case d.IsDir(), d.Type().IsRegular():
+				if taken >= documentDirSample || sampled >= documentSampleSize {
+					continue
+				}
+				taken++
+				sampled++
+				if isDoc {
+					docs++
+				}
+			}
+		}
+	}
+	ok = sampled >= documentMinSample && docs*100 >= sampled*documentThreshold
+	return ok, docs, sampled
+}
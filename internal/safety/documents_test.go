@@ -0,0 +1,101 @@
+package safety
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates empty files with the given names under dir.
+func writeFiles(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("x"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLooksLikeDocumentsDocumentHeavy(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"taxes/2023.pdf", "taxes/2024.PDF", "cv.docx", "budget.xlsx",
+		"design/logo.psd", "design/app.sketch", "holiday/IMG_0001.HEIC", "holiday/IMG_0002.jpg",
+		"notes.txt", "index.db",
+	)
+	// A Keynote package counts as a single document.
+	writeFiles(t, dir, "talk.key/Index.zip", "talk.key/Data/a.png", "talk.key/Data/b.png")
+
+	ok, docs, sampled := LooksLikeDocuments(dir)
+	if !ok {
+		t.Fatalf("expected documents to be detected, got %d of %d", docs, sampled)
+	}
+	if docs != 8 || sampled != 11 {
+		t.Errorf("expected 8 documents of 11 sampled, got %d of %d", docs, sampled)
+	}
+}
+
+func TestLooksLikeDocumentsPhotoCache(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < 30; i++ {
+		writeFiles(t, dir, fmt.Sprintf("thumbs/%02d.jpg", i), fmt.Sprintf("previews/%02d.jpeg", i))
+	}
+
+	if ok, docs, sampled := LooksLikeDocuments(dir); ok {
+		t.Errorf("expected a JPEG cache not to look like documents, got %d of %d", docs, sampled)
+	}
+}
+
+func TestLooksLikeDocumentsSamplesEveryDirectory(t *testing.T) {
+	dir := t.TempDir()
+	// Documents sorted after a large cache directory still make up half
+	// of the sample.
+	for i := 0; i < 300; i++ {
+		writeFiles(t, dir, fmt.Sprintf("a-cache/%03d.bin", i), fmt.Sprintf("z-papers/%03d.pdf", i))
+	}
+
+	ok, docs, sampled := LooksLikeDocuments(dir)
+	if !ok || docs != documentDirSample || sampled != 2*documentDirSample {
+		t.Errorf("expected %d documents of %d sampled, got ok=%v %d of %d", documentDirSample, 2*documentDirSample, ok, docs, sampled)
+	}
+}
+
+func TestLooksLikeDocumentsCache(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir,
+		"Cache.db", "Cache.db-wal", "fsCachedData/3F2A", "fsCachedData/9B1C",
+		"thumbs/a.png", "thumbs/b.png", "thumbs/c.png", "blob_storage/data_0",
+		"preview.pdf",
+	)
+
+	if ok, docs, sampled := LooksLikeDocuments(dir); ok {
+		t.Errorf("expected a cache not to look like documents, got %d of %d", docs, sampled)
+	}
+}
+
+func TestLooksLikeDocumentsTooFewFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, "a.pdf", "b.pdf")
+
+	if ok, _, sampled := LooksLikeDocuments(dir); ok || sampled != 2 {
+		t.Errorf("expected 2 files to be too few to judge, got ok=%v sampled=%d", ok, sampled)
+	}
+}
+
+func TestLooksLikeDocumentsSampleLimit(t *testing.T) {
+	dir := t.TempDir()
+	for i := 0; i < documentSampleSize/documentDirSample+5; i++ {
+		for j := 0; j < documentDirSample; j++ {
+			writeFiles(t, dir, filepath.Join(fmt.Sprintf("blobs%02d", i), fmt.Sprintf("f%02d", j)))
+		}
+	}
+
+	if _, _, sampled := LooksLikeDocuments(dir); sampled != documentSampleSize {
+		t.Errorf("expected sampling to stop at %d files, got %d", documentSampleSize, sampled)
+	}
+}
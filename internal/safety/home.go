@@ -0,0 +1,176 @@
+package safety
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HomeInfo describes where the user's home directory is stored.
+type HomeInfo struct {
+	// Path is the home directory.
+	Path string
+	// Network is true when the home directory lives on a network share,
+	// so sizing it is slow and deletions change the server copy.
+	Network bool
+	// Mobile is true for a mobile account: a local home that syncs back to
+	// a network home on the directory server.
+	Mobile bool
+	// NetworkRoots lists the network-backed path prefixes. It holds Path
+	// when Network is true and is empty otherwise.
+	NetworkRoots []string
+}
+
+// HomeInfoProvider reports where the current user's home directory is
+// stored. DetectHome is the production provider; tests substitute fakes.
+type HomeInfoProvider func() (HomeInfo, error)
+
+// networkHomePrefixes lists automount points that only hold network
+// homes. A home directory under one of these is network-backed.
+var networkHomePrefixes = []string{
+	"/Network",
+	"/net",
+}
+
+// dsclTimeout bounds the directory service lookup.
+const dsclTimeout = 2 * time.Second
+
+// directoryConfigDirs hold the configuration of the directory services a
+// Mac can be bound to. Network and mobile accounts exist only on a bound
+// Mac, so without any configuration there is nothing for dscl to find.
+var directoryConfigDirs = []string{
+	"/Library/Preferences/OpenDirectory/Configurations/Active Directory",
+	"/Library/Preferences/OpenDirectory/Configurations/LDAPv3",
+}
+
+// DetectHome inspects the current user's home directory via $HOME, or the
+// SetHome directory, and, on a Mac bound to a directory service, the
+// user's directory record (dscl). The lookup runs at most once per
+// process.
+func DetectHome() (HomeInfo, error) {
+	return detectCurrentHome(true)
+}
+
+// DetectHomeNoExec is DetectHome without the directory service lookup, for
+// runs that may not start external commands (--no-exec). The home is
+// classified by its path alone, so a mobile account goes unnoticed.
+func DetectHomeNoExec() (HomeInfo, error) {
+	return detectCurrentHome(false)
+}
+
+// detectCurrentHome implements DetectHome, running dscl only when
+// allowExec is true.
+func detectCurrentHome(allowExec bool) (HomeInfo, error) {
+	home, overridden, err := homeDir()
+	if err != nil {
+		return HomeInfo{}, err
+	}
+	// The directory service record looked up is the current user's, which
+	// says nothing about another account's home.
+	name := ""
+	if u, err := user.Current(); err == nil && !overridden {
+		name = u.Username
+	}
+	var run func(args ...string) ([]byte, error)
+	if allowExec && directoryBound() {
+		run = cachedDscl
+	}
+	return detectHome(home, name, run), nil
+}
+
+// directoryBound reports whether the Mac is bound to a directory service,
+// judged by its configuration in directoryConfigDirs.
+func directoryBound() bool {
+	for _, dir := range directoryConfigDirs {
+		if entries, err := os.ReadDir(dir); err == nil && len(entries) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	dsclMu    sync.Mutex
+	dsclCache = map[string][]byte{}
+)
+
+// cachedDscl is runDscl with the output of each lookup kept for the rest
+// of the process. Failed lookups are not retried either.
+func cachedDscl(args ...string) ([]byte, error) {
+	key := strings.Join(args, "\x00")
+	dsclMu.Lock()
+	defer dsclMu.Unlock()
+	if out, ok := dsclCache[key]; ok {
+		return out, nil
+	}
+	out, err := runDscl(args...)
+	dsclCache[key] = out
+	return out, err
+}
+
+// runDscl runs dscl with args and returns its standard output.
+func runDscl(args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dsclTimeout)
+	defer cancel()
+	return exec.CommandContext(ctx, "dscl", args...).Output() // #nosec G204 -- command name is a literal; arguments are the current user's record name
+}
+
+// detectHome classifies home for the account username. run executes dscl;
+// a failed lookup leaves the classification to the home path alone.
+func detectHome(home, username string, run func(args ...string) ([]byte, error)) HomeInfo {
+	info := HomeInfo{Path: home}
+	for _, prefix := range networkHomePrefixes {
+		if pathHasPrefix(filepath.Clean(home), prefix) {
+			info.Network = true
+		}
+	}
+
+	if username != "" && run != nil {
+		out, _ := run(".", "-read", "/Users/"+username, "OriginalNodeName", "NFSHomeDirectory")
+		attrs := parseDscl(out)
+		if attrs["OriginalNodeName"] != "" {
+			info.Mobile = true
+		}
+		for _, prefix := range networkHomePrefixes {
+			if nfs := attrs["NFSHomeDirectory"]; nfs != "" && pathHasPrefix(filepath.Clean(nfs), prefix) {
+				info.Network = true
+			}
+		}
+	}
+
+	if info.Network {
+		info.NetworkRoots = []string{home}
+	}
+	return info
+}
+
+// parseDscl parses "dscl -read" output into attribute values. A value may
+// follow the attribute name on the same line or on indented lines below it;
+// multi-line values are joined with spaces.
+func parseDscl(out []byte) map[string]string {
+	attrs := map[string]string{}
+	current := ""
+	sc := bufio.NewScanner(strings.NewReader(string(out)))
+	for sc.Scan() {
+		line := sc.Text()
+		if strings.HasPrefix(line, " ") && current != "" {
+			value := strings.TrimSpace(attrs[current] + " " + strings.TrimSpace(line))
+			attrs[current] = value
+			continue
+		}
+		name, value, ok := strings.Cut(line, ":")
+		if !ok {
+			current = ""
+			continue
+		}
+		current = name
+		attrs[name] = strings.TrimSpace(value)
+	}
+	return attrs
+}
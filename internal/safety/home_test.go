@@ -0,0 +1,139 @@
+package safety
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// fakeDscl returns a dscl runner that prints out.
+func fakeDscl(out string, err error) func(args ...string) ([]byte, error) {
+	return func(args ...string) ([]byte, error) {
+		return []byte(out), err
+	}
+}
+
+func TestDetectHome(t *testing.T) {
+	tests := []struct {
+		name        string
+		home        string
+		dscl        func(args ...string) ([]byte, error)
+		wantNetwork bool
+		wantMobile  bool
+	}{
+		{
+			name: "local home",
+			home: "/Users/alex",
+			dscl: fakeDscl("NFSHomeDirectory: /Users/alex\n", nil),
+		},
+		{
+			name:        "network automount prefix",
+			home:        "/Network/Servers/od.example.com/Users/alex",
+			dscl:        fakeDscl("", errors.New("dscl failed")),
+			wantNetwork: true,
+		},
+		{
+			name:        "net automount prefix",
+			home:        "/net/files/alex",
+			wantNetwork: true,
+		},
+		{
+			name:        "network home from directory service",
+			home:        "/Users/alex",
+			dscl:        fakeDscl("NFSHomeDirectory:\n /Network/Servers/od.example.com/Users/alex\n", nil),
+			wantNetwork: true,
+		},
+		{
+			name:       "mobile account",
+			home:       "/Users/alex",
+			dscl:       fakeDscl("OriginalNodeName:\n /LDAPv3/od.example.com\nNFSHomeDirectory: /Users/alex\n", nil),
+			wantMobile: true,
+		},
+		{
+			name: "prefix lookalike",
+			home: "/NetworkUsers/alex",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := detectHome(tt.home, "alex", tt.dscl)
+			if got.Path != tt.home {
+				t.Errorf("Path = %q, want %q", got.Path, tt.home)
+			}
+			if got.Network != tt.wantNetwork {
+				t.Errorf("Network = %v, want %v", got.Network, tt.wantNetwork)
+			}
+			if got.Mobile != tt.wantMobile {
+				t.Errorf("Mobile = %v, want %v", got.Mobile, tt.wantMobile)
+			}
+			if tt.wantNetwork && (len(got.NetworkRoots) != 1 || got.NetworkRoots[0] != tt.home) {
+				t.Errorf("NetworkRoots = %v, want [%s]", got.NetworkRoots, tt.home)
+			}
+			if !tt.wantNetwork && len(got.NetworkRoots) != 0 {
+				t.Errorf("NetworkRoots = %v, want none", got.NetworkRoots)
+			}
+		})
+	}
+}
+
+func TestParseDscl(t *testing.T) {
+	out := "OriginalNodeName:\n /LDAPv3/od.example.com\nNFSHomeDirectory: /Users/alex\nNo such key: Foo\n"
+	attrs := parseDscl([]byte(out))
+	if attrs["OriginalNodeName"] != "/LDAPv3/od.example.com" {
+		t.Errorf("OriginalNodeName = %q", attrs["OriginalNodeName"])
+	}
+	if attrs["NFSHomeDirectory"] != "/Users/alex" {
+		t.Errorf("NFSHomeDirectory = %q", attrs["NFSHomeDirectory"])
+	}
+}
+
+func TestDetectHomeNoExecSkipsDscl(t *testing.T) {
+	ad := filepath.Join(t.TempDir(), "Active Directory")
+	if err := os.MkdirAll(ad, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(ad, "EXAMPLE.plist"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	orig := directoryConfigDirs
+	directoryConfigDirs = []string{ad}
+	t.Cleanup(func() { directoryConfigDirs = orig })
+	dsclMu.Lock()
+	dsclCache = map[string][]byte{}
+	dsclMu.Unlock()
+	t.Setenv("HOME", t.TempDir())
+
+	if _, err := DetectHomeNoExec(); err != nil {
+		t.Fatal(err)
+	}
+	dsclMu.Lock()
+	lookups := len(dsclCache)
+	dsclMu.Unlock()
+	if lookups != 0 {
+		t.Errorf("expected no dscl lookup without exec, got %d", lookups)
+	}
+}
+
+func TestDirectoryBound(t *testing.T) {
+	ad := filepath.Join(t.TempDir(), "Active Directory")
+	orig := directoryConfigDirs
+	directoryConfigDirs = []string{ad, filepath.Join(t.TempDir(), "missing")}
+	t.Cleanup(func() { directoryConfigDirs = orig })
+
+	if directoryBound() {
+		t.Error("expected an unbound Mac without directory configuration")
+	}
+	if err := os.MkdirAll(ad, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if directoryBound() {
+		t.Error("expected an empty configuration directory not to count as bound")
+	}
+	if err := os.WriteFile(filepath.Join(ad, "EXAMPLE.plist"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !directoryBound() {
+		t.Error("expected a Mac with an Active Directory configuration to be bound")
+	}
+}
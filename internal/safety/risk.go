@@ -12,21 +12,36 @@ var categoryRisk = map[string]string{
 	"system-caches":      RiskSafe,
 	"system-logs":        RiskSafe,
 	"quicklook":          RiskSafe,
+	"system-sysdiagnose": RiskSafe,
+	"system-broken-symlinks": RiskSafe,
+	"system-installer-leftovers": RiskSafe,
+	"system-tmp-caches": RiskModerate,
+	"system-iclouddrive-cache": RiskModerate,
 	"browser-safari":     RiskModerate,
+	"browser-safari-favicons": RiskSafe,
+	"browser-safari-website-data": RiskModerate,
 	"browser-chrome":     RiskModerate,
+	"browser-chrome-storage": RiskSafe,
 	"browser-firefox":    RiskModerate,
 	"dev-xcode":          RiskRisky,
+	"dev-xcode-index":    RiskModerate,
 	"dev-npm":            RiskModerate,
 	"dev-yarn":           RiskModerate,
 	"dev-homebrew":       RiskModerate,
+	"dev-brew-autoremove": RiskModerate,
+	"dev-node-modules":    RiskModerate,
+	"dev-pyenvs":          RiskModerate,
 	"dev-docker":         RiskRisky,
+	"dev-docker-vm":      RiskRisky,
 	"app-orphaned-prefs":       RiskRisky,
+	"app-orphaned-group-containers": RiskRisky,
 	"app-ios-backups":          RiskRisky,
 	"app-old-downloads":        RiskModerate,
 	"dev-simulator-caches":     RiskSafe,
 	"dev-simulator-logs":       RiskSafe,
 	"dev-xcode-device-support": RiskModerate,
 	"dev-xcode-archives":       RiskRisky,
+	"dev-mobiledevice":         RiskModerate,
 	"dev-pnpm":                 RiskModerate,
 	"dev-cocoapods":            RiskModerate,
 	"dev-gradle":               RiskModerate,
@@ -35,10 +50,14 @@ var categoryRisk = map[string]string{
 	"creative-adobe-media":     RiskModerate,
 	"creative-sketch":          RiskSafe,
 	"creative-figma":           RiskSafe,
+	"creative-adobe-logs":      RiskSafe,
+	"creative-figma-profile":   RiskModerate,
 	"msg-slack":                RiskSafe,
 	"msg-discord":              RiskSafe,
 	"msg-teams":                RiskSafe,
 	"msg-zoom":                 RiskSafe,
+	"msg-zoom-recordings":      RiskModerate,
+	"msg-slack-downloads":      RiskModerate,
 	"unused-apps":              RiskRisky,
 	"photos-caches":            RiskSafe,
 	"photos-analysis":          RiskSafe,
@@ -46,10 +65,12 @@ var categoryRisk = map[string]string{
 	"photos-syndication":       RiskRisky,
 	"sysdata-spotlight":        RiskSafe,
 	"sysdata-mail":             RiskRisky,
+	"sysdata-mail-envelope":    RiskModerate,
 	"sysdata-mail-downloads":   RiskModerate,
 	"sysdata-messages":         RiskRisky,
 	"sysdata-ios-updates":      RiskSafe,
 	"sysdata-timemachine":      RiskRisky,
+	"sysdata-unified-logs":     RiskModerate,
 	"sysdata-vm-parallels":     RiskRisky,
 	"sysdata-vm-utm":           RiskRisky,
 	"sysdata-vm-vmware":        RiskRisky,
@@ -63,3 +84,34 @@ func RiskForCategory(categoryID string) string {
 	}
 	return RiskModerate
 }
+
+// fullDiskAccessHint is the remediation for paths protected by macOS
+// privacy controls (TCC).
+const fullDiskAccessHint = "grant Full Disk Access to your terminal in System Settings > Privacy & Security"
+
+// permissionHints maps category IDs whose permission issues have a known
+// remediation to that remediation.
+var permissionHints = map[string]string{
+	"browser-safari":         fullDiskAccessHint,
+	"browser-safari-favicons": fullDiskAccessHint,
+	"browser-safari-website-data": fullDiskAccessHint,
+	"app-ios-backups":        fullDiskAccessHint,
+	"photos-caches":          fullDiskAccessHint,
+	"photos-analysis":        fullDiskAccessHint,
+	"photos-icloud-cache":    fullDiskAccessHint,
+	"photos-syndication":     fullDiskAccessHint,
+	"sysdata-mail":           fullDiskAccessHint,
+	"sysdata-mail-envelope":  fullDiskAccessHint,
+	"sysdata-mail-downloads": fullDiskAccessHint,
+	"sysdata-messages":       fullDiskAccessHint,
+	"sysdata-timemachine":    fullDiskAccessHint,
+	"sysdata-unified-logs":   "unified logs are owned by root; clear them with sudo log erase --all instead of deleting files",
+	"system-sysdiagnose":     "archives are owned by root; remove them with sudo",
+	"system-installer-leftovers": "system receipts are owned by root; forget a package with sudo pkgutil --forget <id>",
+}
+
+// PermissionHint returns a remediation hint for permission issues in the
+// given category, or an empty string when there is no specific advice.
+func PermissionHint(categoryID string) string {
+	return permissionHints[categoryID]
+}
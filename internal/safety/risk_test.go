@@ -1,6 +1,9 @@
 package safety
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestRiskForCategory(t *testing.T) {
 	tests := []struct {
@@ -11,6 +14,13 @@ func TestRiskForCategory(t *testing.T) {
 		{"system-caches", RiskSafe},
 		{"system-logs", RiskSafe},
 		{"quicklook", RiskSafe},
+		{"system-broken-symlinks", RiskSafe},
+		{"system-installer-leftovers", RiskSafe},
+		{"system-tmp-caches", RiskModerate},
+		{"system-iclouddrive-cache", RiskModerate},
+		{"browser-chrome-storage", RiskSafe},
+		{"browser-safari-favicons", RiskSafe},
+		{"browser-safari-website-data", RiskModerate},
 
 		// Moderate categories.
 		{"browser-safari", RiskModerate},
@@ -19,22 +29,32 @@ func TestRiskForCategory(t *testing.T) {
 		{"dev-npm", RiskModerate},
 		{"dev-yarn", RiskModerate},
 		{"dev-homebrew", RiskModerate},
+		{"dev-brew-autoremove", RiskModerate},
+		{"dev-node-modules", RiskModerate},
+		{"dev-pyenvs", RiskModerate},
+		{"dev-mobiledevice", RiskModerate},
 		{"app-old-downloads", RiskModerate},
+		{"msg-zoom-recordings", RiskModerate},
+		{"msg-slack-downloads", RiskModerate},
 
 		// Risky categories.
 		{"dev-xcode", RiskRisky},
 		{"dev-docker", RiskRisky},
+		{"dev-xcode-index", RiskModerate},
 		{"app-orphaned-prefs", RiskRisky},
+		{"app-orphaned-group-containers", RiskRisky},
 		{"app-ios-backups", RiskRisky},
 		{"unused-apps", RiskRisky},
 
 		// System data categories.
 		{"sysdata-spotlight", RiskSafe},
 		{"sysdata-mail", RiskRisky},
+		{"sysdata-mail-envelope", RiskModerate},
 		{"sysdata-mail-downloads", RiskModerate},
 		{"sysdata-messages", RiskRisky},
 		{"sysdata-ios-updates", RiskSafe},
 		{"sysdata-timemachine", RiskRisky},
+		{"sysdata-unified-logs", RiskModerate},
 		{"sysdata-vm-parallels", RiskRisky},
 		{"sysdata-vm-utm", RiskRisky},
 		{"sysdata-vm-vmware", RiskRisky},
@@ -53,3 +73,21 @@ func TestRiskForCategory(t *testing.T) {
 		})
 	}
 }
+
+func TestPermissionHint(t *testing.T) {
+	// Categories behind macOS privacy controls point to Full Disk Access.
+	for _, id := range []string{"sysdata-mail", "sysdata-messages", "browser-safari", "app-ios-backups", "photos-caches"} {
+		if got := PermissionHint(id); !strings.Contains(got, "Full Disk Access") {
+			t.Errorf("PermissionHint(%q) = %q, want a Full Disk Access hint", id, got)
+		}
+	}
+	if got := PermissionHint("system-sysdiagnose"); !strings.Contains(got, "sudo") {
+		t.Errorf("PermissionHint(system-sysdiagnose) = %q, want a sudo hint", got)
+	}
+	// Generic categories have no specific advice.
+	for _, id := range []string{"system-caches", "dev-npm", "unknown-category", ""} {
+		if got := PermissionHint(id); got != "" {
+			t.Errorf("PermissionHint(%q) = %q, want empty", id, got)
+		}
+	}
+}
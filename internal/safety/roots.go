@@ -0,0 +1,51 @@
+package safety
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// allowedRoots are the directories besides the home directory under which
+// deletions may happen: applications, per-user temporary caches, the
+// diagnostic archive directory and /usr/local.
+var allowedRoots = []string{
+	"/Applications",
+	"/private/var/folders",
+	"/private/var/tmp",
+	"/usr/local",
+}
+
+// CheckAllowedRoot reports whether path, with symlinks resolved, lies
+// below the home directory, one of the fixed allowed roots (/Applications,
+// /private/var/folders, /private/var/tmp and /usr/local) or one of extra,
+// along with the reason when it does not. A root itself is never allowed.
+// It is an allowlist applied on top of IsPathBlocked, so a path that
+// escapes every root through a symlink is refused whatever produced it.
+func CheckAllowedRoot(path string, extra []string) (bool, string) {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return false, fmt.Sprintf("cannot resolve path: %v", err)
+	}
+
+	roots := append([]string(nil), allowedRoots...)
+	if home, _, err := homeDir(); err == nil {
+		roots = append(roots, home)
+	}
+	roots = append(roots, extra...)
+	for _, root := range roots {
+		if !filepath.IsAbs(root) {
+			continue
+		}
+		if r, err := filepath.EvalSymlinks(root); err == nil {
+			root = r
+		}
+		root = filepath.Clean(root)
+		if resolved != root && pathHasPrefix(resolved, root) {
+			return true, ""
+		}
+	}
+	if resolved != filepath.Clean(path) {
+		return false, "outside allowed roots (resolves to " + resolved + ")"
+	}
+	return false, "outside allowed roots"
+}
@@ -0,0 +1,49 @@
+package safety
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckAllowedRoot(t *testing.T) {
+	home := t.TempDir()
+	SetHome(home)
+	t.Cleanup(func() { SetHome("") })
+
+	cache := filepath.Join(home, "Library", "Caches", "com.example")
+	if err := os.MkdirAll(cache, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if ok, reason := CheckAllowedRoot(cache, nil); !ok {
+		t.Errorf("expected path under home allowed, refused: %s", reason)
+	}
+	if ok, _ := CheckAllowedRoot(home, nil); ok {
+		t.Error("expected the home directory itself refused")
+	}
+
+	escape := filepath.Join(home, "Library", "Caches", "escape")
+	if err := os.Symlink("/etc", escape); err != nil {
+		t.Fatal(err)
+	}
+	ok, reason := CheckAllowedRoot(escape, nil)
+	if ok {
+		t.Fatal("expected symlink to /etc refused")
+	}
+	if !strings.Contains(reason, "outside allowed roots") || !strings.Contains(reason, "/etc") {
+		t.Errorf("unexpected reason %q", reason)
+	}
+
+	outside := t.TempDir()
+	app := filepath.Join(outside, "Old.app")
+	if ok, _ := CheckAllowedRoot(app, nil); ok {
+		t.Error("expected path outside every root refused")
+	}
+	if ok, reason := CheckAllowedRoot(app, []string{outside}); !ok {
+		t.Errorf("expected path under an extra root allowed, refused: %s", reason)
+	}
+	if ok, _ := CheckAllowedRoot(app, []string{"relative"}); ok {
+		t.Error("expected relative extra roots ignored")
+	}
+}
@@ -8,8 +8,48 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+
+	"github.com/sp3esu/mac-cleaner/internal/logging"
+)
+
+var (
+	homeMu       sync.Mutex
+	homeOverride string
 )
 
+// SetHome makes IsPathBlocked contain deletions to home instead of the
+// current user's home directory, for scanning another account (--home).
+// DetectHome inspects it too. Pass "" to restore the default.
+func SetHome(home string) {
+	homeMu.Lock()
+	defer homeMu.Unlock()
+	if home != "" {
+		home = filepath.Clean(home)
+	}
+	homeOverride = home
+}
+
+// homeDir returns the SetHome directory, with overridden true, or the
+// current user's home.
+func homeDir() (home string, overridden bool, err error) {
+	homeMu.Lock()
+	override := homeOverride
+	homeMu.Unlock()
+	if override != "" {
+		return override, true, nil
+	}
+	home, err = os.UserHomeDir()
+	return home, false, err
+}
+
+// Home returns the home directory deletions are contained to: the SetHome
+// directory, or the current user's home without one.
+func Home() (string, error) {
+	home, _, err := homeDir()
+	return home, err
+}
+
 // criticalPaths lists root-level paths that must never be deleted.
 // These are blocked as exact matches for defense-in-depth.
 var criticalPaths = []string{
@@ -47,32 +87,31 @@ var swapProtectedPrefixes = []string{
 	"/private/var/vm",
 }
 
+// diagnosticArchiveDirs lists directories outside the home directory whose
+// top-level sysdiagnose and spindump archives may be scanned and removed.
+var diagnosticArchiveDirs = []string{
+	"/private/var/tmp",
+}
+
+// IsDiagnosticArchive reports whether a file name looks like a sysdiagnose
+// archive (sysdiagnose_*.tar.gz) or a spindump report (spindump*).
+func IsDiagnosticArchive(name string) bool {
+	if strings.HasPrefix(name, "sysdiagnose_") && strings.HasSuffix(name, ".tar.gz") {
+		return true
+	}
+	return strings.HasPrefix(name, "spindump")
+}
+
 // IsPathBlocked checks whether a filesystem path is protected and should
 // not be modified. It returns whether the path is blocked and the reason.
 // Paths are normalized with filepath.Clean and resolved with
 // filepath.EvalSymlinks before checking against the blocklist.
 func IsPathBlocked(path string) (bool, string) {
-	cleaned := filepath.Clean(path)
-
-	// Attempt symlink resolution for additional safety.
-	resolved, err := filepath.EvalSymlinks(cleaned)
+	resolved, err := resolvePath(path)
 	if err != nil {
-		if !os.IsNotExist(err) {
-			// Path exists but cannot be resolved — block for safety.
-			return true, fmt.Sprintf("cannot resolve path: %v", err)
-		}
-		// Path does not exist; try resolving the parent directory so that
-		// symlinks in ancestor components are still resolved (e.g. on macOS,
-		// /var -> /private/var). Fall back to the literal cleaned path if
-		// the parent also cannot be resolved.
-		resolvedDir, dirErr := filepath.EvalSymlinks(filepath.Dir(cleaned))
-		if dirErr != nil {
-			resolved = cleaned
-		} else {
-			resolved = filepath.Join(resolvedDir, filepath.Base(cleaned))
-		}
+		// Path exists but cannot be resolved — block for safety.
+		return true, fmt.Sprintf("cannot resolve path: %v", err)
 	}
-	resolved = filepath.Clean(resolved)
 
 	// Check critical root-level paths (exact match).
 	for _, cp := range criticalPaths {
@@ -101,12 +140,28 @@ func IsPathBlocked(path string) (bool, string) {
 		}
 	}
 
+	// Diagnostic archives directly inside an allowed directory are the only
+	// files permitted outside the home directory besides QuickLook caches.
+	for _, dir := range diagnosticArchiveDirs {
+		if filepath.Dir(resolved) == dir && IsDiagnosticArchive(filepath.Base(resolved)) {
+			return false, ""
+		}
+	}
+
 	// Positive containment: path must be under user's home directory
 	// or under /private/var/folders/ (for QuickLook caches).
 	// This is a defense-in-depth measure — scanners already construct
 	// paths from the home directory, but this catches any future mistakes.
-	home, err := os.UserHomeDir()
+	// The home directory itself is never a valid target.
+	home, _, err := homeDir()
 	if err == nil {
+		if resolvedHome, err := filepath.EvalSymlinks(home); err == nil {
+			home = resolvedHome
+		}
+		home = filepath.Clean(home)
+		if resolved == home {
+			return true, "home directory"
+		}
 		if !pathHasPrefix(resolved, home) && !pathHasPrefix(resolved, "/private/var/folders") {
 			return true, "outside home directory"
 		}
@@ -115,10 +170,33 @@ func IsPathBlocked(path string) (bool, string) {
 	return false, ""
 }
 
-// WarnBlocked prints a skip warning to stderr for a blocked path.
-// Format: SKIP: {path} ({reason})
+// resolvePath cleans path and resolves its symlinks. A path that does not
+// exist has its parent directory resolved instead, so that symlinks in
+// ancestor components are still resolved (e.g. on macOS, /var ->
+// /private/var); when the parent cannot be resolved either, the cleaned
+// path is returned as is. An error means the path exists but cannot be
+// resolved.
+func resolvePath(path string) (string, error) {
+	cleaned := filepath.Clean(path)
+	resolved, err := filepath.EvalSymlinks(cleaned)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		resolvedDir, dirErr := filepath.EvalSymlinks(filepath.Dir(cleaned))
+		if dirErr != nil {
+			resolved = cleaned
+		} else {
+			resolved = filepath.Join(resolvedDir, filepath.Base(cleaned))
+		}
+	}
+	return filepath.Clean(resolved), nil
+}
+
+// WarnBlocked logs at warn level that a blocked path was skipped, with
+// the path and the reason it is blocked.
 func WarnBlocked(path, reason string) {
-	fmt.Fprintf(os.Stderr, "SKIP: %s (%s)\n", path, reason)
+	logging.Warn("skipping blocked path", "path", path, "reason", reason)
 }
 
 // pathHasPrefix reports whether path is equal to prefix or is a child
@@ -4,7 +4,11 @@ import (
 	"bytes"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/sp3esu/mac-cleaner/internal/logging"
 )
 
 func TestIsPathBlocked(t *testing.T) {
@@ -43,6 +47,8 @@ func TestIsPathBlocked(t *testing.T) {
 
 		// Paths that are now blocked by home containment or critical-path check
 		{name: "user Library Caches", path: home + "/Library/Caches", wantBlocked: false, wantReason: ""},
+		{name: "home itself", path: home, wantBlocked: true, wantReason: "home directory"},
+		{name: "home trailing slash", path: home + "/", wantBlocked: true, wantReason: "home directory"},
 		{name: "Library Caches", path: "/Library/Caches", wantBlocked: true, wantReason: "outside home directory"},
 		{name: "tmp", path: "/tmp", wantBlocked: true, wantReason: "outside home directory"},
 		{name: "Applications", path: "/Applications", wantBlocked: true, wantReason: "critical system path"},
@@ -74,6 +80,13 @@ func TestIsPathBlocked(t *testing.T) {
 		{name: "outside home var log", path: "/var/log", wantBlocked: true, wantReason: "outside home directory"},
 		{name: "etc hosts", path: "/etc/hosts", wantBlocked: true, wantReason: "outside home directory"},
 
+		// Diagnostic archives — only top-level archives in /private/var/tmp
+		{name: "sysdiagnose archive in var tmp", path: "/private/var/tmp/sysdiagnose_2026.10.01_12-00-00_macOS.tar.gz", wantBlocked: false, wantReason: ""},
+		{name: "spindump in var tmp", path: "/private/var/tmp/spindump.txt", wantBlocked: false, wantReason: ""},
+		{name: "other file in var tmp", path: "/private/var/tmp/other.tar.gz", wantBlocked: true, wantReason: "outside home directory"},
+		{name: "nested sysdiagnose in var tmp", path: "/private/var/tmp/sub/sysdiagnose_x.tar.gz", wantBlocked: true, wantReason: "outside home directory"},
+		{name: "var tmp dir itself", path: "/private/var/tmp", wantBlocked: true, wantReason: "outside home directory"},
+
 		// Critical paths as prefixes — not exact match, but still outside home
 		// Note: /Applications/Safari.app is a symlink into /System on macOS,
 		// so we use a non-existent app to test the home containment path.
@@ -102,30 +115,69 @@ func TestIsPathBlocked(t *testing.T) {
 	}
 }
 
-func TestWarnBlocked(t *testing.T) {
-	// Capture stderr output
-	oldStderr := os.Stderr
-	r, w, err := os.Pipe()
-	if err != nil {
-		t.Fatalf("failed to create pipe: %v", err)
+func TestIsPathBlocked_SetHome(t *testing.T) {
+	own := t.TempDir()
+	other := t.TempDir()
+	t.Setenv("HOME", own)
+	SetHome(other)
+	t.Cleanup(func() { SetHome("") })
+
+	if blocked, reason := IsPathBlocked(filepath.Join(other, "Library", "Caches")); blocked {
+		t.Errorf("expected path in the SetHome directory allowed, blocked: %s", reason)
+	}
+	if blocked, reason := IsPathBlocked(other); !blocked || reason != "home directory" {
+		t.Errorf("expected the SetHome directory itself blocked as home directory, got %v %q", blocked, reason)
+	}
+	if blocked, reason := IsPathBlocked(filepath.Join(own, "Library", "Caches")); !blocked || reason != "outside home directory" {
+		t.Errorf("expected the current user's home outside containment, got %v %q", blocked, reason)
 	}
-	os.Stderr = w
 
-	WarnBlocked("/System", "SIP-protected")
+	SetHome("")
+	if blocked, _ := IsPathBlocked(filepath.Join(own, "Library", "Caches")); blocked {
+		t.Error("expected SetHome(\"\") to restore the current user's home")
+	}
+}
 
-	w.Close()
-	os.Stderr = oldStderr
+func TestIsDiagnosticArchive(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"sysdiagnose_2026.10.01_12-00-00_macOS_MacBook.tar.gz", true},
+		{"spindump.txt", true},
+		{"spindump_Safari_2026-10-01.txt", true},
+		{"sysdiagnose_partial", false},
+		{"sysdiagnose.tar.gz", false},
+		{"notes.txt", false},
+	}
+	for _, tt := range tests {
+		if got := IsDiagnosticArchive(tt.name); got != tt.want {
+			t.Errorf("IsDiagnosticArchive(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
 
+// captureWarnings directs warn-level logs to a buffer for the test.
+func captureWarnings(t *testing.T) *bytes.Buffer {
+	t.Helper()
 	var buf bytes.Buffer
-	_, err = buf.ReadFrom(r)
-	if err != nil {
-		t.Fatalf("failed to read from pipe: %v", err)
+	if err := logging.Setup(&buf, "warn", false); err != nil {
+		t.Fatal(err)
 	}
+	t.Cleanup(func() { _ = logging.Setup(os.Stderr, logging.DefaultLevel, false) })
+	return &buf
+}
+
+func TestWarnBlocked(t *testing.T) {
+	buf := captureWarnings(t)
+
+	WarnBlocked("/System", "SIP-protected")
 
 	got := buf.String()
-	want := "SKIP: /System (SIP-protected)\n"
-	if got != want {
-		t.Errorf("WarnBlocked output = %q, want %q", got, want)
+	for _, want := range []string{"level=WARN", "path=/System", "reason=SIP-protected"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("WarnBlocked log = %q, want it to contain %q", got, want)
+		}
 	}
 }
 
@@ -135,32 +187,18 @@ func TestWarnBlockedFormat(t *testing.T) {
 		reason string
 		want   string
 	}{
-		{"/System", "SIP-protected", "SKIP: /System (SIP-protected)\n"},
-		{"/private/var/vm", "swap/VM file", "SKIP: /private/var/vm (swap/VM file)\n"},
+		{"/System", "SIP-protected", `path=/System reason=SIP-protected`},
+		{"/private/var/vm", "swap/VM file", `path=/private/var/vm reason="swap/VM file"`},
 	}
 
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%s_%s", tt.path, tt.reason), func(t *testing.T) {
-			oldStderr := os.Stderr
-			r, w, err := os.Pipe()
-			if err != nil {
-				t.Fatalf("failed to create pipe: %v", err)
-			}
-			os.Stderr = w
+			buf := captureWarnings(t)
 
 			WarnBlocked(tt.path, tt.reason)
 
-			w.Close()
-			os.Stderr = oldStderr
-
-			var buf bytes.Buffer
-			_, err = buf.ReadFrom(r)
-			if err != nil {
-				t.Fatalf("failed to read from pipe: %v", err)
-			}
-
-			if got := buf.String(); got != tt.want {
-				t.Errorf("WarnBlocked(%q, %q) = %q, want %q", tt.path, tt.reason, got, tt.want)
+			if got := buf.String(); !strings.Contains(got, tt.want) {
+				t.Errorf("WarnBlocked(%q, %q) = %q, want it to contain %q", tt.path, tt.reason, got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,75 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SizeSession holds the checkpoints of cancelled DirSizeContext walks, so
+// that a later walk of the same root in the session resumes where the
+// cancelled one stopped. Each client (an engine, a GUI window) keeps its
+// own session; nothing is shared between sessions. The zero value is not
+// usable; create one with NewSizeSession.
+type SizeSession struct {
+	mu          sync.Mutex
+	checkpoints map[string]sizeCheckpoint
+}
+
+// NewSizeSession returns an empty SizeSession.
+func NewSizeSession() *SizeSession {
+	return &SizeSession{checkpoints: map[string]sizeCheckpoint{}}
+}
+
+// sizeCheckpoint is the progress of a cancelled DirSizeContext walk: the
+// top-level children of the root sorted before next were fully counted.
+type sizeCheckpoint struct {
+	// next is the name of the first top-level child not fully counted.
+	next string
+	// bytes is the size of the children before next.
+	bytes int64
+	// denied are the permission issues found in those children.
+	denied []PermissionIssue
+	// modTime is the root's modification time when the walk was
+	// cancelled. A root whose entries changed since then starts over.
+	modTime time.Time
+}
+
+// save remembers where a cancelled walk of root stopped. A nil session
+// keeps nothing.
+func (s *SizeSession) save(root string, cp sizeCheckpoint) {
+	if s == nil {
+		return
+	}
+	info, err := os.Lstat(root)
+	if err != nil {
+		return
+	}
+	cp.modTime = info.ModTime()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.checkpoints[filepath.Clean(root)] = cp
+}
+
+// load returns the checkpoint saved for root, if any, and removes it. A
+// checkpoint is dropped when root was modified since it was saved, since
+// children may have been added or removed before next.
+func (s *SizeSession) load(root string) (sizeCheckpoint, bool) {
+	if s == nil {
+		return sizeCheckpoint{}, false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	key := filepath.Clean(root)
+	cp, ok := s.checkpoints[key]
+	if !ok {
+		return cp, false
+	}
+	delete(s.checkpoints, key)
+	info, err := os.Lstat(root)
+	if err != nil || !info.ModTime().Equal(cp.modTime) {
+		return sizeCheckpoint{}, false
+	}
+	return cp, true
+}
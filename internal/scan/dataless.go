@@ -0,0 +1,56 @@
+package scan
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sync/atomic"
+)
+
+// datalessCheck holds the func(fs.FileInfo) bool behind IsDataless. It is
+// read on every stat of a scan, so it is loaded without locking.
+var datalessCheck atomic.Value
+
+func init() {
+	datalessCheck.Store(platformDataless)
+}
+
+// IsDataless reports whether info describes a dataless file or directory:
+// one whose contents have been offloaded to a cloud provider such as
+// iCloud Drive with "Optimize Mac Storage". It reports a logical size but
+// occupies almost nothing locally, reading it downloads it again, and
+// deleting it removes the cloud copy too. On macOS this is the
+// SF_DATALESS file flag; elsewhere nothing is dataless.
+func IsDataless(info fs.FileInfo) bool {
+	return datalessCheck.Load().(func(fs.FileInfo) bool)(info)
+}
+
+// SetDatalessCheck replaces the check behind IsDataless, so tests can
+// simulate offloaded files. Pass nil to restore the platform check.
+func SetDatalessCheck(fn func(fs.FileInfo) bool) {
+	if fn == nil {
+		fn = platformDataless
+	}
+	datalessCheck.Store(fn)
+}
+
+// ContainsDataless reports whether root, or anything beneath it, is
+// dataless. Dataless directories are not descended into, so the check
+// never triggers a download. Unreadable entries are skipped.
+func ContainsDataless(root string) bool {
+	found := false
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if IsDataless(info) {
+			found = true
+			return fs.SkipAll
+		}
+		return nil
+	})
+	return found
+}
@@ -0,0 +1,18 @@
+//go:build darwin
+
+package scan
+
+import (
+	"io/fs"
+	"syscall"
+)
+
+// sfDataless is SF_DATALESS from <sys/stat.h>: the file's contents are not
+// present locally and are fetched from a cloud provider on access.
+const sfDataless = 0x40000000
+
+// platformDataless reads SF_DATALESS from the file flags.
+func platformDataless(info fs.FileInfo) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && st.Flags&sfDataless != 0
+}
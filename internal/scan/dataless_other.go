@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package scan
+
+import "io/fs"
+
+// platformDataless reports false: only macOS marks files dataless.
+func platformDataless(fs.FileInfo) bool { return false }
@@ -0,0 +1,79 @@
+package scan
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeDataless makes names starting with "cloud-" look dataless for the
+// rest of the test.
+func fakeDataless(t *testing.T) {
+	t.Helper()
+	SetDatalessCheck(func(info fs.FileInfo) bool {
+		return strings.HasPrefix(info.Name(), "cloud-")
+	})
+	t.Cleanup(func() { SetDatalessCheck(nil) })
+}
+
+func TestDirSizeSkipsDataless(t *testing.T) {
+	fakeDataless(t)
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "cloud-folder"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]int{
+		"local.txt":               100,
+		"cloud-offloaded.mov":     5000,
+		"cloud-folder/inside.txt": 7000,
+	}
+	for name, size := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	size, err := DirSize(dir)
+	if err != nil {
+		t.Fatalf("DirSize: %v", err)
+	}
+	if size != 100 {
+		t.Errorf("DirSize = %d, want 100 (dataless files and folders excluded)", size)
+	}
+}
+
+func TestContainsDataless(t *testing.T) {
+	fakeDataless(t)
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "local.txt"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if ContainsDataless(dir) {
+		t.Error("expected no dataless files yet")
+	}
+	if err := os.WriteFile(filepath.Join(dir, "sub", "cloud-photo.jpg"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if !ContainsDataless(dir) {
+		t.Error("expected nested dataless file to be found")
+	}
+}
+
+func TestIsDatalessPlatformDefault(t *testing.T) {
+	f := filepath.Join(t.TempDir(), "cloud-regular.txt")
+	if err := os.WriteFile(f, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if IsDataless(info) {
+		t.Error("a freshly written local file must not be dataless")
+	}
+}
@@ -0,0 +1,96 @@
+package scan
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// DirUsage is one directory and the bytes of the regular files under it.
+type DirUsage struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// LargestDirs walks root and returns the n largest directories at most
+// depth levels below it, largest first (ties by path). A directory's size
+// counts every regular file under it, however deep, but never anything in
+// an excluded directory: exclude entries, and roots set with
+// SetSkippedRoots, are skipped with their whole subtree. Symlinks are not
+// followed, and dataless (cloud-only) files and unreadable entries are not
+// counted. n <= 0 returns every directory.
+func LargestDirs(root string, depth, n int, exclude []string) ([]DirUsage, error) {
+	root = filepath.Clean(root)
+	if isSkippedRoot(root) {
+		return nil, nil
+	}
+	if _, err := os.Lstat(root); err != nil {
+		return nil, err
+	}
+	excluded := make(map[string]bool, len(exclude))
+	for _, p := range exclude {
+		excluded[filepath.Clean(p)] = true
+	}
+
+	sizes := make(map[string]int64)
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Unreadable entries are skipped, as in DirSize.
+			if d != nil && d.IsDir() && path != root {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			if path == root {
+				return nil
+			}
+			if excluded[path] || isSkippedRoot(path) {
+				return filepath.SkipDir
+			}
+			if info, err := d.Info(); err == nil && IsDataless(info) {
+				return filepath.SkipDir
+			}
+			if rel, _ := filepath.Rel(root, path); strings.Count(rel, string(filepath.Separator)) < depth {
+				sizes[path] += 0
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || IsDataless(info) {
+			return nil
+		}
+		// Credit the file to each ancestor directory within depth.
+		rel, _ := filepath.Rel(root, path)
+		parts := strings.Split(rel, string(filepath.Separator))
+		dir := root
+		for i := 0; i < len(parts)-1 && i < depth; i++ {
+			dir = filepath.Join(dir, parts[i])
+			sizes[dir] += FileSize(info)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make([]DirUsage, 0, len(sizes))
+	for path, size := range sizes {
+		dirs = append(dirs, DirUsage{Path: path, Size: size})
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if dirs[i].Size != dirs[j].Size {
+			return dirs[i].Size > dirs[j].Size
+		}
+		return dirs[i].Path < dirs[j].Path
+	})
+	if n > 0 && len(dirs) > n {
+		dirs = dirs[:n]
+	}
+	return dirs, nil
+}
@@ -0,0 +1,82 @@
+package scan
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLargestDirs_DescendingAndBounded(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, "Movies", "a.mov"), 5000)
+	writeFile(t, filepath.Join(home, "Movies", "clips", "deep", "deeper", "b.mov"), 3000)
+	writeFile(t, filepath.Join(home, "Documents", "c.pdf"), 1000)
+	writeFile(t, filepath.Join(home, "notes.txt"), 9000)
+
+	dirs, err := LargestDirs(home, 2, 3, nil)
+	if err != nil {
+		t.Fatalf("LargestDirs: %v", err)
+	}
+	want := []DirUsage{
+		{Path: filepath.Join(home, "Movies"), Size: 8000},
+		{Path: filepath.Join(home, "Movies", "clips"), Size: 3000},
+		{Path: filepath.Join(home, "Documents"), Size: 1000},
+	}
+	if len(dirs) != len(want) {
+		t.Fatalf("got %+v, want %+v", dirs, want)
+	}
+	for i := range want {
+		if dirs[i] != want[i] {
+			t.Errorf("dirs[%d] = %+v, want %+v", i, dirs[i], want[i])
+		}
+	}
+	for _, d := range dirs {
+		if d.Path == filepath.Join(home, "Movies", "clips", "deep") {
+			t.Errorf("directory below depth 2 listed: %s", d.Path)
+		}
+	}
+}
+
+func TestLargestDirs_Excludes(t *testing.T) {
+	home := t.TempDir()
+	caches := filepath.Join(home, "Library", "Caches")
+	writeFile(t, filepath.Join(caches, "big.bin"), 50000)
+	writeFile(t, filepath.Join(home, "Library", "Fonts", "f.ttf"), 200)
+	writeFile(t, filepath.Join(home, "Projects", "p.bin"), 700)
+
+	dirs, err := LargestDirs(home, 3, 0, []string{caches})
+	if err != nil {
+		t.Fatalf("LargestDirs: %v", err)
+	}
+	sizes := map[string]int64{}
+	for _, d := range dirs {
+		sizes[d.Path] = d.Size
+	}
+	if _, ok := sizes[caches]; ok {
+		t.Errorf("excluded directory listed: %+v", dirs)
+	}
+	if got := sizes[filepath.Join(home, "Library")]; got != 200 {
+		t.Errorf("Library size = %d, want 200 without the excluded caches", got)
+	}
+	if dirs[0].Path != filepath.Join(home, "Projects") {
+		t.Errorf("expected Projects first, got %+v", dirs)
+	}
+}
+
+func TestLargestDirs_DoesNotFollowSymlinks(t *testing.T) {
+	home := t.TempDir()
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(outside, "huge.bin"), 10000)
+	writeFile(t, filepath.Join(home, "real", "f.bin"), 10)
+	if err := os.Symlink(outside, filepath.Join(home, "real", "link")); err != nil {
+		t.Fatal(err)
+	}
+
+	dirs, err := LargestDirs(home, 3, 0, nil)
+	if err != nil {
+		t.Fatalf("LargestDirs: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0].Size != 10 {
+		t.Errorf("expected only real/ at 10 B, got %+v", dirs)
+	}
+}
@@ -5,14 +5,51 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"sync"
 
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 )
 
+var (
+	expandMu    sync.Mutex
+	expandBlobs bool
+)
+
+// SetExpandBlobs makes the scanners that report a directory as a single
+// blob entry, such as the yarn cache or the Mail database, list its
+// top-level children with ScanTopLevel instead, so a large subdirectory
+// stands out. The category total is unchanged. Blobs are the default
+// because sizing one directory is faster than sizing its children.
+// Virtual machine bundles are not expanded: each is already its own
+// entry, and listing the disk images inside one would offer part of a
+// VM for deletion.
+func SetExpandBlobs(on bool) {
+	expandMu.Lock()
+	defer expandMu.Unlock()
+	expandBlobs = on
+}
+
+// ExpandBlobs reports whether SetExpandBlobs is on.
+func ExpandBlobs() bool {
+	expandMu.Lock()
+	defer expandMu.Unlock()
+	return expandBlobs
+}
+
 // ScanTopLevel scans the top-level entries of a directory and returns a
 // CategoryResult with sized entries sorted largest first. Blocked paths
 // are skipped with warnings. Zero-byte entries are excluded.
 func ScanTopLevel(dir, category, description string) (*CategoryResult, error) {
+	return ScanTopLevelDepth(dir, category, description, 0)
+}
+
+// ScanTopLevelDepth is ScanTopLevel with directory entries sized no
+// deeper than maxDepth: only files at most maxDepth levels below an entry
+// are counted, and entries with deeper content are marked Truncated, as
+// is the result, whose TotalSize is then a lower bound. It trades
+// accuracy for a fast overview of huge caches. Zero or less sizes
+// everything.
+func ScanTopLevelDepth(dir, category, description string, maxDepth int) (*CategoryResult, error) {
 	if blocked, reason := safety.IsPathBlocked(dir); blocked {
 		safety.WarnBlocked(dir, reason)
 		return nil, fmt.Errorf("path blocked: %s", reason)
@@ -36,6 +73,7 @@ func ScanTopLevel(dir, category, description string) (*CategoryResult, error) {
 	var scanEntries []ScanEntry
 	var permIssues []PermissionIssue
 	var totalSize int64
+	anyTruncated := false
 
 	for _, entry := range entries {
 		entryPath := filepath.Join(dir, entry.Name())
@@ -46,8 +84,13 @@ func ScanTopLevel(dir, category, description string) (*CategoryResult, error) {
 		}
 
 		var size int64
+		truncated := false
+		requiresRoot := false
+		if info, err := entry.Info(); err == nil {
+			requiresRoot = RequiresRoot(info)
+		}
 		if entry.IsDir() {
-			s, err := DirSize(entryPath)
+			s, t, err := DirSizeDepth(entryPath, maxDepth)
 			if err != nil {
 				if os.IsPermission(err) {
 					permIssues = append(permIssues, PermissionIssue{
@@ -58,6 +101,7 @@ func ScanTopLevel(dir, category, description string) (*CategoryResult, error) {
 				continue
 			}
 			size = s
+			truncated = t
 		} else {
 			info, err := entry.Info()
 			if err != nil {
@@ -69,25 +113,28 @@ func ScanTopLevel(dir, category, description string) (*CategoryResult, error) {
 				}
 				continue
 			}
-			size = info.Size()
+			size = FileSize(info)
 		}
 
-		if size == 0 {
+		// A truncated directory may hold all its data deeper down; keep
+		// it so the bound does not hide it.
+		if size == 0 && !truncated {
 			continue
 		}
 
 		scanEntries = append(scanEntries, ScanEntry{
-			Path:        entryPath,
-			Description: entry.Name(),
-			Size:        size,
+			Path:         entryPath,
+			Description:  entry.Name(),
+			Size:         size,
+			IsDir:        entry.IsDir(),
+			RequiresRoot: requiresRoot,
+			Truncated:    truncated,
 		})
 		totalSize += size
+		anyTruncated = anyTruncated || truncated
 	}
 
-	// Sort entries by size descending (largest first).
-	sort.Slice(scanEntries, func(i, j int) bool {
-		return scanEntries[i].Size > scanEntries[j].Size
-	})
+	SortBySize(scanEntries)
 
 	return &CategoryResult{
 		Category:         category,
@@ -95,5 +142,14 @@ func ScanTopLevel(dir, category, description string) (*CategoryResult, error) {
 		Entries:          scanEntries,
 		TotalSize:        totalSize,
 		PermissionIssues: permIssues,
+		Truncated:        anyTruncated,
 	}, nil
 }
+
+// SortBySize sorts entries by size, largest first. Entries of equal size
+// keep their relative order.
+func SortBySize(entries []ScanEntry) {
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Size > entries[j].Size
+	})
+}
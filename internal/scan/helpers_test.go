@@ -4,6 +4,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 // writeFile is a test helper that creates a file with the given size.
@@ -61,6 +62,52 @@ func TestScanTopLevel(t *testing.T) {
 	}
 }
 
+func TestScanTopLevelMaxDepth(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "flat", "a.dat"), 100)
+	writeFile(t, filepath.Join(dir, "nested", "b.dat"), 200)
+	writeFile(t, filepath.Join(dir, "nested", "sub", "c.dat"), 400)
+	writeFile(t, filepath.Join(dir, "only-deep", "sub", "d.dat"), 800)
+
+	result, err := ScanTopLevelDepth(dir, "test-cat", "Test Category", 1)
+	if err != nil {
+		t.Fatalf("ScanTopLevelDepth: %v", err)
+	}
+	got := map[string]ScanEntry{}
+	for _, e := range result.Entries {
+		got[e.Description] = e
+	}
+	// Depth 1 counts each entry's direct files only.
+	if e := got["flat"]; e.Size != 100 || e.Truncated {
+		t.Errorf("flat = %d, truncated %v; want 100, false", e.Size, e.Truncated)
+	}
+	if e := got["nested"]; e.Size != 200 || !e.Truncated {
+		t.Errorf("nested = %d, truncated %v; want 200, true", e.Size, e.Truncated)
+	}
+	if e, ok := got["only-deep"]; !ok || e.Size != 0 || !e.Truncated {
+		t.Errorf("only-deep = %+v, present %v; want a truncated zero-size entry", e, ok)
+	}
+	if result.TotalSize != 300 || !result.Truncated {
+		t.Errorf("depth-1 total = %d, truncated %v; want 300, true", result.TotalSize, result.Truncated)
+	}
+
+	full, err := ScanTopLevel(dir, "test-cat", "Test Category")
+	if err != nil {
+		t.Fatalf("ScanTopLevel: %v", err)
+	}
+	if full.TotalSize != 1500 {
+		t.Errorf("full total = %d, want 1500", full.TotalSize)
+	}
+	if full.Truncated {
+		t.Error("category truncated with unlimited depth")
+	}
+	for _, e := range full.Entries {
+		if e.Truncated {
+			t.Errorf("%s truncated with unlimited depth", e.Description)
+		}
+	}
+}
+
 func TestScanTopLevelSkipsZeroBytes(t *testing.T) {
 	dir := t.TempDir()
 
@@ -136,3 +183,36 @@ func TestScanTopLevelHandlesFiles(t *testing.T) {
 		t.Errorf("expected second entry size 150, got %d", result.Entries[1].Size)
 	}
 }
+
+func TestScanTopLevelExcludeNewerThan(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+
+	// old-cache: untouched for two days.
+	oldDir := filepath.Join(dir, "old-cache")
+	writeFile(t, filepath.Join(oldDir, "a.dat"), 100)
+	os.Chtimes(filepath.Join(oldDir, "a.dat"), old, old)
+	os.Chtimes(oldDir, old, old)
+
+	// live-cache: its own mtime is old, but a nested file was just written.
+	liveDir := filepath.Join(dir, "live-cache")
+	writeFile(t, filepath.Join(liveDir, "sub", "b.dat"), 200)
+	os.Chtimes(filepath.Join(liveDir, "sub"), old, old)
+	os.Chtimes(liveDir, old, old)
+
+	result, err := ScanTopLevel(dir, "test-cat", "Test Category")
+	if err != nil {
+		t.Fatalf("ScanTopLevel: %v", err)
+	}
+	result.ExcludeNewerThan(time.Now().Add(-time.Hour))
+
+	if len(result.Entries) != 1 || result.Entries[0].Description != "old-cache" {
+		t.Fatalf("expected only old-cache to be deletable, got %+v", result.Entries)
+	}
+	if result.TotalSize != 100 {
+		t.Errorf("expected total 100, got %d", result.TotalSize)
+	}
+	if len(result.RecentlyModified) != 1 || result.RecentlyModified[0].Description != "live-cache" {
+		t.Errorf("expected live-cache reported as recently modified, got %+v", result.RecentlyModified)
+	}
+}
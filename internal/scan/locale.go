@@ -0,0 +1,101 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Locale holds the conventions used to render sizes and dates.
+type Locale struct {
+	// Name is the language code, e.g. "de".
+	Name string
+	// Decimal separates the integer and fractional parts of a size.
+	Decimal string
+	// Months are the abbreviated month names, January first.
+	Months [12]string
+}
+
+// DefaultLocale is English, the output format used when no locale is set.
+var DefaultLocale = Locale{
+	Name:    "en",
+	Decimal: ".",
+	Months:  [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+}
+
+// locales lists the supported locales by language code. They match the
+// languages the documentation is translated into.
+var locales = map[string]Locale{
+	"en": DefaultLocale,
+	"de": {Name: "de", Decimal: ",", Months: [12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"}},
+	"fr": {Name: "fr", Decimal: ",", Months: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."}},
+	"pl": {Name: "pl", Decimal: ",", Months: [12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"}},
+	"ru": {Name: "ru", Decimal: ",", Months: [12]string{"янв.", "февр.", "март", "апр.", "май", "июнь", "июль", "авг.", "сент.", "окт.", "нояб.", "дек."}},
+	"uk": {Name: "uk", Decimal: ",", Months: [12]string{"січ.", "лют.", "бер.", "квіт.", "трав.", "черв.", "лип.", "серп.", "вер.", "жовт.", "лист.", "груд."}},
+}
+
+var (
+	localeMu sync.Mutex
+	locale   = DefaultLocale
+)
+
+// LookupLocale returns the locale for tag, which may be a bare language
+// code ("de") or a POSIX or BCP 47 name ("de_DE.UTF-8", "de-AT"). "ua" is
+// accepted for Ukrainian, and "C" and "POSIX" mean English.
+func LookupLocale(tag string) (Locale, error) {
+	lang := strings.ToLower(tag)
+	if i := strings.IndexAny(lang, "_-.@"); i >= 0 {
+		lang = lang[:i]
+	}
+	switch lang {
+	case "c", "posix":
+		lang = "en"
+	case "ua":
+		lang = "uk"
+	}
+	l, ok := locales[lang]
+	if !ok {
+		return Locale{}, fmt.Errorf("unsupported locale %q (want en, de, fr, pl, ru or uk)", tag)
+	}
+	return l, nil
+}
+
+// DetectLocale returns the locale named by the LC_NUMERIC, LC_ALL or LANG
+// environment variable, in that order of precedence, or DefaultLocale when
+// none is set or supported. LC_NUMERIC comes first since sizes are what
+// the locale formats.
+func DetectLocale() Locale {
+	for _, name := range []string{"LC_NUMERIC", "LC_ALL", "LANG"} {
+		tag := os.Getenv(name)
+		if tag == "" {
+			continue
+		}
+		if l, err := LookupLocale(tag); err == nil {
+			return l
+		}
+		return DefaultLocale
+	}
+	return DefaultLocale
+}
+
+// SetLocale makes FormatSize and FormatMonth use l.
+func SetLocale(l Locale) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	locale = l
+}
+
+// currentLocale returns the locale set by SetLocale.
+func currentLocale() Locale {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	return locale
+}
+
+// FormatMonth renders t as an abbreviated month and year in the current
+// locale, e.g. "Mar 2024" or "Mär 2024".
+func FormatMonth(t time.Time) string {
+	return fmt.Sprintf("%s %d", currentLocale().Months[t.Month()-1], t.Year())
+}
@@ -0,0 +1,69 @@
+package scan
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatSizeAndMonth_Locales(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+	march := time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		tag, size, month string
+	}{
+		{"en", "1.5 GB", "Mar 2024"},
+		{"de_DE.UTF-8", "1,5 GB", "Mär 2024"},
+		{"fr-FR", "1,5 GB", "mars 2024"},
+	}
+	for _, tt := range tests {
+		l, err := LookupLocale(tt.tag)
+		if err != nil {
+			t.Fatalf("LookupLocale(%q): %v", tt.tag, err)
+		}
+		SetLocale(l)
+		if got := FormatSize(1_500_000_000); got != tt.size {
+			t.Errorf("%s: FormatSize = %q, want %q", tt.tag, got, tt.size)
+		}
+		if got := FormatMonth(march); got != tt.month {
+			t.Errorf("%s: FormatMonth = %q, want %q", tt.tag, got, tt.month)
+		}
+		if got := FormatSize(999); got != "999 B" {
+			t.Errorf("%s: FormatSize(999) = %q, want plain bytes", tt.tag, got)
+		}
+	}
+}
+
+func TestLookupLocale(t *testing.T) {
+	for tag, want := range map[string]string{"C": "en", "POSIX": "en", "ua": "uk", "uk_UA.UTF-8": "uk", "PL": "pl"} {
+		l, err := LookupLocale(tag)
+		if err != nil || l.Name != want {
+			t.Errorf("LookupLocale(%q) = %q, %v; want %q", tag, l.Name, err, want)
+		}
+	}
+	if _, err := LookupLocale("ja_JP"); err == nil {
+		t.Error("expected an error for an unsupported locale")
+	}
+}
+
+func TestDetectLocale(t *testing.T) {
+	t.Setenv("LC_NUMERIC", "")
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "pl_PL.UTF-8")
+	t.Setenv("LANG", "ru_RU.UTF-8")
+	if got := DetectLocale().Name; got != "ru" {
+		t.Errorf("LANG=ru_RU: got %q, want ru (LC_MESSAGES does not format sizes)", got)
+	}
+	t.Setenv("LC_ALL", "de_DE.UTF-8")
+	if got := DetectLocale().Name; got != "de" {
+		t.Errorf("LC_ALL should win over LANG: got %q", got)
+	}
+	t.Setenv("LC_NUMERIC", "fr_FR.UTF-8")
+	if got := DetectLocale().Name; got != "fr" {
+		t.Errorf("LC_NUMERIC should win over LC_ALL: got %q", got)
+	}
+	t.Setenv("LC_NUMERIC", "ja_JP.UTF-8")
+	if got := DetectLocale().Name; got != "en" {
+		t.Errorf("unsupported locale should fall back to en, got %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+package scan
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+)
+
+// ManifestHeadSize is how many leading bytes of a file ManifestEntry.HeadSHA256
+// covers.
+const ManifestHeadSize = 4096
+
+// Manifest lists every item a scan offered for deletion, so the target
+// set can be reviewed, archived or compared with the disk before anything
+// is removed.
+type Manifest struct {
+	// GeneratedAt is when the manifest was produced.
+	GeneratedAt time.Time `json:"generated_at"`
+	// ToolVersion is the version of mac-cleaner that produced it.
+	ToolVersion string `json:"tool_version"`
+	// Entries holds one record per item, in scan order.
+	Entries []ManifestEntry `json:"entries"`
+	// TotalSize is the sum of the entry sizes in bytes.
+	TotalSize int64 `json:"total_size"`
+}
+
+// ManifestEntry records one item of a Manifest as found on disk.
+type ManifestEntry struct {
+	Category  string `json:"category"`
+	Path      string `json:"path"`
+	Size      int64  `json:"size"`
+	RiskLevel string `json:"risk_level"`
+	IsDir     bool   `json:"is_dir"`
+	// ModTime is the item's modification time, zero if it could not be
+	// read.
+	ModTime time.Time `json:"mod_time,omitzero"`
+	// HeadSHA256 is the hex SHA-256 of the first ManifestHeadSize bytes
+	// of a regular file, enough to tell whether it was replaced without
+	// reading it whole. Directories, symlinks and files offloaded to the
+	// cloud (see IsDataless), which reading would download, have none.
+	HeadSHA256 string `json:"head_sha256,omitempty"`
+	// Error describes why the item could not be examined, e.g. because it
+	// no longer exists.
+	Error string `json:"error,omitempty"`
+}
+
+// MarshalJSON encodes the entry with Path made valid UTF-8, as
+// ScanEntry does.
+func (e ManifestEntry) MarshalJSON() ([]byte, error) {
+	type plain ManifestEntry
+	p := plain(e)
+	p.Path = ValidUTF8(p.Path)
+	return json.Marshal(p)
+}
+
+// NewManifest builds a manifest of the entries in results. It only reads
+// the filesystem; nothing is modified.
+func NewManifest(results []CategoryResult) Manifest {
+	m := Manifest{Entries: []ManifestEntry{}}
+	for _, cat := range results {
+		for _, entry := range cat.Entries {
+			m.Entries = append(m.Entries, manifestEntry(cat.Category, entry))
+			m.TotalSize += entry.Size
+		}
+	}
+	return m
+}
+
+// manifestEntry records entry of category as it is on disk now.
+func manifestEntry(category string, entry ScanEntry) ManifestEntry {
+	me := ManifestEntry{
+		Category:  category,
+		Path:      entry.Path,
+		Size:      entry.Size,
+		RiskLevel: entry.RiskLevel,
+		IsDir:     entry.IsDir,
+		ModTime:   entry.ModTime,
+	}
+	if me.RiskLevel == "" {
+		me.RiskLevel = safety.RiskForCategory(category)
+	}
+	info, err := os.Lstat(entry.Path)
+	if err != nil {
+		me.Error = err.Error()
+		return me
+	}
+	if me.ModTime.IsZero() {
+		me.ModTime = info.ModTime()
+	}
+	if !info.Mode().IsRegular() || IsDataless(info) {
+		return me
+	}
+	sum, err := headSHA256(entry.Path)
+	if err != nil {
+		me.Error = err.Error()
+		return me
+	}
+	me.HeadSHA256 = sum
+	return me
+}
+
+// headSHA256 returns the hex SHA-256 of the first ManifestHeadSize bytes
+// of the file at path.
+func headSHA256(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.CopyN(h, f, ManifestHeadSize); err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteManifest writes m to w as indented JSON.
+func WriteManifest(w io.Writer, m Manifest) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
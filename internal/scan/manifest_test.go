@@ -0,0 +1,124 @@
+package scan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+)
+
+func TestNewManifest(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.log")
+	large := filepath.Join(dir, "large.bin")
+	sub := filepath.Join(dir, "cache")
+	smallData := []byte("hello manifest")
+	largeData := bytes.Repeat([]byte("0123456789abcdef"), 1024) // 16 KB
+	if err := os.WriteFile(small, smallData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(large, largeData, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	mtime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(small, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+
+	results := []CategoryResult{
+		{Category: "system-logs", Entries: []ScanEntry{
+			{Path: small, Size: int64(len(smallData)), RiskLevel: safety.RiskSafe},
+			{Path: large, Size: int64(len(largeData)), RiskLevel: safety.RiskModerate},
+		}},
+		{Category: "system-caches", Entries: []ScanEntry{
+			{Path: sub, Size: 0, IsDir: true},
+			{Path: filepath.Join(dir, "gone"), Size: 10},
+		}},
+	}
+	m := NewManifest(results)
+
+	if len(m.Entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(m.Entries))
+	}
+	if want := int64(len(smallData) + len(largeData) + 10); m.TotalSize != want {
+		t.Errorf("TotalSize = %d, want %d", m.TotalSize, want)
+	}
+	sum := func(b []byte) string {
+		h := sha256.Sum256(b)
+		return hex.EncodeToString(h[:])
+	}
+
+	s := m.Entries[0]
+	if s.Category != "system-logs" || s.Size != int64(len(smallData)) || s.RiskLevel != safety.RiskSafe {
+		t.Errorf("small entry = %+v", s)
+	}
+	if s.HeadSHA256 != sum(smallData) {
+		t.Errorf("small hash = %s, want %s", s.HeadSHA256, sum(smallData))
+	}
+	if !s.ModTime.Equal(mtime) {
+		t.Errorf("small mtime = %v, want %v", s.ModTime, mtime)
+	}
+
+	if l := m.Entries[1]; l.HeadSHA256 != sum(largeData[:ManifestHeadSize]) {
+		t.Errorf("large hash = %s, want the hash of its first %d bytes", l.HeadSHA256, ManifestHeadSize)
+	}
+
+	d := m.Entries[2]
+	if d.HeadSHA256 != "" || !d.IsDir || d.ModTime.IsZero() {
+		t.Errorf("directory entry = %+v, want no hash and a mtime", d)
+	}
+	if d.RiskLevel != safety.RiskForCategory("system-caches") {
+		t.Errorf("directory risk = %q, want the category default", d.RiskLevel)
+	}
+
+	if g := m.Entries[3]; g.Error == "" || g.HeadSHA256 != "" {
+		t.Errorf("missing entry = %+v, want an error and no hash", g)
+	}
+}
+
+func TestNewManifestSkipsDataless(t *testing.T) {
+	SetDatalessCheck(func(os.FileInfo) bool { return true })
+	t.Cleanup(func() { SetDatalessCheck(nil) })
+
+	path := filepath.Join(t.TempDir(), "offloaded.pdf")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManifest([]CategoryResult{{Category: "app-old-downloads", Entries: []ScanEntry{{Path: path, Size: 4}}}})
+	if got := m.Entries[0].HeadSHA256; got != "" {
+		t.Errorf("dataless file hashed: %s", got)
+	}
+}
+
+func TestWriteManifestParses(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	if err := os.WriteFile(path, []byte("abc"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m := NewManifest([]CategoryResult{{Category: "system-logs", Entries: []ScanEntry{{Path: path, Size: 3}}}})
+	m.ToolVersion = "test"
+
+	var buf bytes.Buffer
+	if err := WriteManifest(&buf, m); err != nil {
+		t.Fatalf("WriteManifest: %v", err)
+	}
+	var got Manifest
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("manifest does not parse: %v\n%s", err, buf.String())
+	}
+	if got.ToolVersion != "test" || len(got.Entries) != 1 {
+		t.Fatalf("parsed manifest = %+v", got)
+	}
+	if e := got.Entries[0]; e.Path != path || e.Size != 3 || e.HeadSHA256 != m.Entries[0].HeadSHA256 {
+		t.Errorf("parsed entry = %+v, want %+v", e, m.Entries[0])
+	}
+}
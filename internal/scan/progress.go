@@ -0,0 +1,152 @@
+package scan
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SizeProgress describes a DirSize walk that is still running.
+type SizeProgress struct {
+	// Root is the directory being sized.
+	Root string
+	// Bytes is the size counted so far.
+	Bytes int64
+	// Elapsed is the time since the walk started.
+	Elapsed time.Duration
+	// Estimate is the size Root had when last walked, in this process or
+	// a run whose estimates were loaded with LoadSizeEstimates, or zero
+	// when unknown.
+	Estimate int64
+}
+
+// SizeProgressFunc receives progress for long DirSize walks.
+type SizeProgressFunc func(SizeProgress)
+
+// sizeProgressDelay is how long a walk runs before progress is reported,
+// so quick walks stay silent.
+const sizeProgressDelay = time.Second
+
+// sizeProgressInterval is the minimum time between progress reports.
+const sizeProgressInterval = 250 * time.Millisecond
+
+// maxSizeEstimates bounds how many roots keep a size estimate. The
+// largest are kept, since their walks are the ones long enough to show
+// an ETA.
+const maxSizeEstimates = 256
+
+var (
+	progressMu    sync.Mutex
+	progressFn    SizeProgressFunc
+	sizeEstimates = map[string]int64{}
+)
+
+// SetSizeProgress installs fn to receive progress from every DirSize walk
+// that runs longer than a second. Pass nil to stop reporting.
+func SetSizeProgress(fn SizeProgressFunc) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	progressFn = fn
+}
+
+// sizeProgressFunc returns the installed progress hook, or nil.
+func sizeProgressFunc() SizeProgressFunc {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	return progressFn
+}
+
+// sizeEstimate returns the size recorded for root by its last completed
+// walk, or zero.
+func sizeEstimate(root string) int64 {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	return sizeEstimates[root]
+}
+
+// recordSize remembers the size of root for later estimates, dropping
+// the smallest estimate when more than maxSizeEstimates are held.
+func recordSize(root string, size int64) {
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	sizeEstimates[root] = size
+	trimSizeEstimates()
+}
+
+// trimSizeEstimates drops the smallest estimates until at most
+// maxSizeEstimates remain. The caller holds progressMu.
+func trimSizeEstimates() {
+	for len(sizeEstimates) > maxSizeEstimates {
+		smallest := ""
+		for root, size := range sizeEstimates {
+			if smallest == "" || size < sizeEstimates[smallest] {
+				smallest = root
+			}
+		}
+		delete(sizeEstimates, smallest)
+	}
+}
+
+// LoadSizeEstimates adds the estimates saved at path by SaveSizeEstimates,
+// so the first walk of a root in this process can already show an ETA.
+// Estimates recorded in this process take precedence. A missing file
+// loads nothing.
+func LoadSizeEstimates(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read size estimates: %w", err)
+	}
+	var saved map[string]int64
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return fmt.Errorf("parse size estimates: %w", err)
+	}
+	progressMu.Lock()
+	defer progressMu.Unlock()
+	for root, size := range saved {
+		if _, ok := sizeEstimates[root]; !ok && size > 0 {
+			sizeEstimates[root] = size
+		}
+	}
+	trimSizeEstimates()
+	return nil
+}
+
+// SaveSizeEstimates writes the current estimates to path, creating its
+// directory if needed.
+func SaveSizeEstimates(path string) error {
+	progressMu.Lock()
+	data, err := json.Marshal(sizeEstimates)
+	progressMu.Unlock()
+	if err != nil {
+		return fmt.Errorf("encode size estimates: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return fmt.Errorf("create cache dir: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("write size estimates: %w", err)
+	}
+	return nil
+}
+
+// ETA estimates the time left for a walk that has processed bytes of total
+// after elapsed, assuming constant throughput. The boolean is false when
+// no estimate is possible: total is unknown (zero or negative), or nothing
+// has been processed yet. Once processed reaches total the estimate is
+// zero.
+func ETA(elapsed time.Duration, processed, total int64) (time.Duration, bool) {
+	if total <= 0 || processed <= 0 || elapsed <= 0 {
+		return 0, false
+	}
+	if processed >= total {
+		return 0, true
+	}
+	remaining := float64(elapsed) * float64(total-processed) / float64(processed)
+	return time.Duration(remaining), true
+}
@@ -0,0 +1,126 @@
+package scan
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestETA(t *testing.T) {
+	tests := []struct {
+		name      string
+		elapsed   time.Duration
+		processed int64
+		total     int64
+		want      time.Duration
+		wantOK    bool
+	}{
+		{name: "halfway", elapsed: 5 * time.Second, processed: 500, total: 1000, want: 5 * time.Second, wantOK: true},
+		{name: "quarter", elapsed: 2 * time.Second, processed: 250, total: 1000, want: 6 * time.Second, wantOK: true},
+		{name: "done", elapsed: 3 * time.Second, processed: 1000, total: 1000, want: 0, wantOK: true},
+		{name: "grew past estimate", elapsed: 3 * time.Second, processed: 1500, total: 1000, want: 0, wantOK: true},
+		{name: "unknown total", elapsed: 3 * time.Second, processed: 500, total: 0, wantOK: false},
+		{name: "nothing processed", elapsed: 3 * time.Second, processed: 0, total: 1000, wantOK: false},
+		{name: "no elapsed time", elapsed: 0, processed: 500, total: 1000, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ETA(tt.elapsed, tt.processed, tt.total)
+			if ok != tt.wantOK {
+				t.Fatalf("ETA(%v, %d, %d) ok = %v, want %v", tt.elapsed, tt.processed, tt.total, ok, tt.wantOK)
+			}
+			if ok && got != tt.want {
+				t.Errorf("ETA(%v, %d, %d) = %v, want %v", tt.elapsed, tt.processed, tt.total, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDirSizeRecordsEstimate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a"), make([]byte, 300), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := sizeEstimate(dir); got != 0 {
+		t.Fatalf("expected no estimate before walking, got %d", got)
+	}
+	if _, err := DirSize(dir); err != nil {
+		t.Fatal(err)
+	}
+	if got := sizeEstimate(dir); got != 300 {
+		t.Errorf("expected estimate 300 after walking, got %d", got)
+	}
+}
+
+func TestDirSizeProgressQuietForQuickWalks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a"), make([]byte, 100), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	size, err := DirSizeProgress(dir, func(SizeProgress) { calls++ })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size != 100 {
+		t.Errorf("expected size 100, got %d", size)
+	}
+	if calls != 0 {
+		t.Errorf("expected no progress for a walk under %v, got %d calls", sizeProgressDelay, calls)
+	}
+}
+
+func TestSizeEstimatesBounded(t *testing.T) {
+	t.Cleanup(func() {
+		progressMu.Lock()
+		sizeEstimates = map[string]int64{}
+		progressMu.Unlock()
+	})
+	for i := 0; i <= maxSizeEstimates; i++ {
+		recordSize(fmt.Sprintf("/root-%d", i), int64(i+1))
+	}
+	progressMu.Lock()
+	n := len(sizeEstimates)
+	progressMu.Unlock()
+	if n != maxSizeEstimates {
+		t.Errorf("got %d estimates, want %d", n, maxSizeEstimates)
+	}
+	if got := sizeEstimate("/root-0"); got != 0 {
+		t.Errorf("expected the smallest estimate dropped, got %d", got)
+	}
+	if got := sizeEstimate(fmt.Sprintf("/root-%d", maxSizeEstimates)); got == 0 {
+		t.Error("expected the largest estimate kept")
+	}
+}
+
+func TestSizeEstimatesPersist(t *testing.T) {
+	t.Cleanup(func() {
+		progressMu.Lock()
+		sizeEstimates = map[string]int64{}
+		progressMu.Unlock()
+	})
+	path := filepath.Join(t.TempDir(), "cache", "size-estimates.json")
+	if err := LoadSizeEstimates(path); err != nil {
+		t.Fatalf("LoadSizeEstimates on a missing file: %v", err)
+	}
+	recordSize("/big", 5000)
+	if err := SaveSizeEstimates(path); err != nil {
+		t.Fatalf("SaveSizeEstimates: %v", err)
+	}
+
+	// A new run starts with no estimates and loads the saved ones.
+	progressMu.Lock()
+	sizeEstimates = map[string]int64{}
+	progressMu.Unlock()
+	if err := LoadSizeEstimates(path); err != nil {
+		t.Fatalf("LoadSizeEstimates: %v", err)
+	}
+	if got := sizeEstimate("/big"); got != 5000 {
+		t.Errorf("estimate after reload = %d, want 5000", got)
+	}
+}
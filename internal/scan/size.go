@@ -1,50 +1,388 @@
 package scan
 
 import (
+	"context"
 	"fmt"
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
-// DirSize returns the total size in bytes of all regular files under root.
-// Symlinks are not followed or counted. Permission-denied entries are
-// skipped silently. Returns 0 and an error if root does not exist.
+var (
+	skipMu       sync.Mutex
+	skippedRoots []string
+
+	onDiskMu sync.Mutex
+	onDisk   bool
+)
+
+// SetOnDiskSize makes DirSize and FileSize count the blocks files occupy
+// on disk instead of their logical length. On-disk sizes match Finder's
+// "on disk" figure and the free space a deletion actually recovers: sparse
+// files count only their allocated blocks and small files are rounded up
+// to the block size. Logical sizing is the default.
+func SetOnDiskSize(on bool) {
+	onDiskMu.Lock()
+	defer onDiskMu.Unlock()
+	onDisk = on
+}
+
+// onDiskSize reports whether SetOnDiskSize enabled on-disk sizing.
+func onDiskSize() bool {
+	onDiskMu.Lock()
+	defer onDiskMu.Unlock()
+	return onDisk
+}
+
+// FileSize returns the size of the file described by info: its allocated
+// 512-byte blocks when on-disk sizing is enabled, its logical length
+// otherwise. It falls back to the logical length when block counts are
+// unavailable.
+func FileSize(info os.FileInfo) int64 {
+	if onDiskSize() {
+		return allocatedSize(info)
+	}
+	return info.Size()
+}
+
+// SetSkippedRoots makes DirSize report zero, without walking, for any root
+// equal to or under one of roots. It keeps network-backed directories from
+// being walked. Pass nil to size everything again.
+func SetSkippedRoots(roots []string) {
+	skipMu.Lock()
+	defer skipMu.Unlock()
+	skippedRoots = nil
+	for _, r := range roots {
+		skippedRoots = append(skippedRoots, filepath.Clean(r))
+	}
+}
+
+// isSkippedRoot reports whether root falls under a SetSkippedRoots prefix.
+func isSkippedRoot(root string) bool {
+	skipMu.Lock()
+	defer skipMu.Unlock()
+	root = filepath.Clean(root)
+	for _, r := range skippedRoots {
+		if root == r || strings.HasPrefix(root, r+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// DirSize returns the total size in bytes of all regular files under root,
+// as measured by FileSize. The walk is iterative (filepath.WalkDir), so
+// arbitrarily deep trees do not grow the stack. Symlinks are not followed
+// or counted. Dataless (cloud-only) files are not counted and dataless
+// directories are not walked, since they take no local space.
+// Permission-denied entries are skipped silently, along with everything
+// below them; DirSizeIssues reports them. Returns 0 and an error if root
+// does not exist. Walks longer than a second report to the hook set by
+// SetSizeProgress. Roots under a SetSkippedRoots prefix are reported as 0
+// without walking.
 func DirSize(root string) (int64, error) {
+	return DirSizeProgress(root, sizeProgressFunc())
+}
+
+// DirSizeProgress is DirSize with an explicit progress callback. After the
+// walk has run for a second, fn receives the bytes counted so far at most
+// every 250ms, along with the size root had on its previous walk. fn may
+// be nil.
+func DirSizeProgress(root string, fn SizeProgressFunc) (int64, error) {
+	total, _, err := dirSize(context.Background(), nil, root, fn)
+	if err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// DirSizeContext is DirSizeProgress that stops when ctx is done, returning
+// the bytes counted so far with ctx's error. The walk is checkpointed in
+// session at the last top-level child of root it finished, so the next
+// DirSizeContext call for root in the same session resumes from there
+// instead of starting over, and the total it returns includes the bytes
+// counted before the cancellation. The checkpoint is dropped when root
+// itself was modified in between; changes deeper inside the children
+// already counted are not noticed. A nil session keeps no checkpoints.
+func DirSizeContext(ctx context.Context, session *SizeSession, root string, fn SizeProgressFunc) (int64, error) {
+	total, _, err := dirSize(ctx, session, root, fn)
+	return total, err
+}
+
+// DirSizeIssues is DirSize that also returns a PermissionIssue for every
+// file or directory skipped because it could not be read. The size then
+// leaves out everything below those paths.
+func DirSizeIssues(root string) (int64, []PermissionIssue, error) {
+	total, denied, err := dirSize(context.Background(), nil, root, sizeProgressFunc())
+	if err != nil {
+		return 0, nil, err
+	}
+	return total, denied, nil
+}
+
+// dirSize walks root for DirSizeProgress, DirSizeContext and DirSizeIssues,
+// returning the size and the paths it was denied access to. It resumes
+// from root's checkpoint in session, if any, and saves a new one there
+// when ctx is done.
+func dirSize(ctx context.Context, session *SizeSession, root string, fn SizeProgressFunc) (int64, []PermissionIssue, error) {
 	// Check that the root exists before walking.
 	if _, err := os.Lstat(root); err != nil {
-		return 0, err
+		return 0, nil, err
+	}
+	if isSkippedRoot(root) {
+		return 0, nil, nil
 	}
 
 	var total int64
+	var denied []PermissionIssue
+	cp, resumed := session.load(root)
+	if resumed {
+		total = cp.bytes
+		denied = append(denied, cp.denied...)
+	}
+	// done is the checkpoint to save if ctx ends the walk: everything
+	// before the top-level child being walked.
+	done := cp
+	start := time.Now()
+	nextReport := start.Add(sizeProgressDelay)
+	estimate := sizeEstimate(root)
+	cleanRoot := filepath.Clean(root)
 
 	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if path != root && filepath.Dir(path) == cleanRoot && err == nil {
+			if resumed && d.Name() < cp.next {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			done = sizeCheckpoint{next: d.Name(), bytes: total, denied: denied}
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
 		if err != nil {
 			// Skip entries we cannot access. This covers permission-denied
 			// errors and also I/O errors on damaged filesystems. Propagating
 			// errors here would abort the entire scan for a single bad entry,
 			// which is undesirable for a cleanup tool.
+			if os.IsPermission(err) {
+				denied = append(denied, PermissionIssue{Path: path, Description: filepath.Base(path) + " (permission denied)"})
+			}
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
 			return nil
 		}
 		if d.Type().IsRegular() {
 			info, err := d.Info()
 			if err != nil {
 				// Skip files whose info we cannot read.
+				if os.IsPermission(err) {
+					denied = append(denied, PermissionIssue{Path: path, Description: filepath.Base(path) + " (permission denied)"})
+				}
 				return nil
 			}
-			total += info.Size()
+			if !IsDataless(info) {
+				total += FileSize(info)
+			}
+		} else if d.IsDir() && path != root {
+			// Walking a dataless directory would download its contents.
+			if info, err := d.Info(); err == nil && IsDataless(info) {
+				return filepath.SkipDir
+			}
+		}
+		if fn != nil {
+			if now := time.Now(); now.After(nextReport) {
+				nextReport = now.Add(sizeProgressInterval)
+				fn(SizeProgress{Root: root, Bytes: total, Elapsed: now.Sub(start), Estimate: estimate})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if ctx.Err() != nil && err == ctx.Err() {
+			if done.next != "" {
+				session.save(root, done)
+			}
+			return total, denied, err
+		}
+		return 0, nil, err
+	}
+
+	recordSize(root, total)
+	return total, denied, nil
+}
+
+// DirSizeDepth is DirSize that counts only files at most maxDepth levels
+// below root: with maxDepth 1, only root's own files. Deeper directories
+// are not walked, and truncated reports whether any was left out, in
+// which case the size is a lower bound. A maxDepth of zero or less walks
+// everything, as DirSize does. Size progress is not reported, since a
+// bounded walk is meant to be quick.
+func DirSizeDepth(root string, maxDepth int) (size int64, truncated bool, err error) {
+	if maxDepth <= 0 {
+		size, err = DirSize(root)
+		return size, false, err
+	}
+	if _, err := os.Lstat(root); err != nil {
+		return 0, false, err
+	}
+	if isSkippedRoot(root) {
+		return 0, false, nil
+	}
+
+	cleanRoot := filepath.Clean(root)
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries, as DirSize does.
+			if d != nil && d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil && !IsDataless(info) {
+				size += FileSize(info)
+			}
+			return nil
+		}
+		if !d.IsDir() || path == root {
+			return nil
+		}
+		if info, err := d.Info(); err == nil && IsDataless(info) {
+			return filepath.SkipDir
+		}
+		rel, _ := filepath.Rel(cleanRoot, filepath.Clean(path))
+		if strings.Count(rel, string(filepath.Separator))+1 >= maxDepth {
+			truncated = true
+			return filepath.SkipDir
 		}
 		return nil
 	})
+	if err != nil {
+		return 0, false, err
+	}
+	return size, truncated, nil
+}
+
+// LatestModTime returns the newest modification time of root and everything
+// beneath it. Symlinks are not followed; their own mtime is used. Entries
+// that cannot be read are skipped. Returns an error if root does not exist.
+func LatestModTime(root string) (time.Time, error) {
+	info, err := os.Lstat(root)
+	if err != nil {
+		return time.Time{}, err
+	}
+	latest := info.ModTime()
+	if !info.IsDir() {
+		return latest, nil
+	}
+
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Skip unreadable entries, as DirSize does.
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+
+	return latest, nil
+}
+
+// AllocatedSize returns the on-disk size of a single file in bytes, based on
+// the number of allocated 512-byte blocks rather than the apparent length.
+// For sparse files such as VM disk images this reflects the space actually
+// consumed. Falls back to the apparent size when block counts are unavailable.
+func AllocatedSize(path string) (int64, error) {
+	info, err := os.Lstat(path)
 	if err != nil {
 		return 0, err
 	}
+	return allocatedSize(info), nil
+}
 
-	return total, nil
+// allocatedSize is AllocatedSize for the file described by info, and
+// FileSize's on-disk measure.
+func allocatedSize(info os.FileInfo) int64 {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		return st.Blocks * 512
+	}
+	return info.Size()
+}
+
+// RequiresRoot reports whether info is owned by root while the current
+// process is not root, so removing the item needs elevation.
+func RequiresRoot(info os.FileInfo) bool {
+	return ownedByRootFor(info, os.Geteuid())
+}
+
+// ownedByRootFor reports whether info is owned by root and euid is not.
+func ownedByRootFor(info os.FileInfo, euid int) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return ok && st.Uid == 0 && euid != 0
+}
+
+// IsDir reports whether path is a directory, without following symlinks.
+// It returns false if the path cannot be stat'ed.
+func IsDir(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.IsDir()
+}
+
+// AvailableBytes returns the space available to unprivileged users on the
+// volume containing path, in bytes.
+func AvailableBytes(path string) (int64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil // #nosec G115 -- block counts and sizes fit in int64
+}
+
+// VolumeSpace returns the space available to unprivileged users and the
+// total size of the volume containing path, in bytes.
+func VolumeSpace(path string) (available, total int64, err error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, 0, fmt.Errorf("statfs %s: %w", path, err)
+	}
+	bsize := int64(st.Bsize)                                       // #nosec G115 -- block sizes fit in int64
+	return int64(st.Bavail) * bsize, int64(st.Blocks) * bsize, nil // #nosec G115 -- block counts fit in int64
+}
+
+// implausibleShare is the share of a volume's capacity above which a
+// reclaimable total is considered implausible.
+const implausibleShare = 0.9
+
+// ImplausibleTotal reports whether a reclaimable total cannot be right
+// for a volume of capacity bytes of which used bytes are in use: it
+// exceeds the used space or 90% of the capacity. Such totals are
+// inflated by APFS clones or sparse files, which are counted in full for
+// every copy although their blocks are shared or never allocated. A
+// volume of unknown capacity (zero) never makes a total implausible.
+func ImplausibleTotal(total, used, capacity int64) bool {
+	if capacity <= 0 {
+		return false
+	}
+	return total > used || float64(total) > float64(capacity)*implausibleShare
 }
 
 // FormatSize formats a byte count as a human-readable string using SI units
-// (base 1000) to match macOS Finder convention.
+// (base 1000) to match macOS Finder convention, with the decimal separator
+// of the current locale.
 // Examples: 0 -> "0 B", 1500 -> "1.5 kB", 1000000 -> "1.0 MB".
 func FormatSize(b int64) string {
 	const unit = 1000
@@ -59,5 +397,44 @@ func FormatSize(b int64) string {
 	}
 
 	units := []string{"kB", "MB", "GB", "TB", "PB", "EB"}
-	return fmt.Sprintf("%.1f %s", float64(b)/float64(div), units[exp])
+	s := fmt.Sprintf("%.1f %s", float64(b)/float64(div), units[exp])
+	if dec := currentLocale().Decimal; dec != "." {
+		s = strings.Replace(s, ".", dec, 1)
+	}
+	return s
+}
+
+// sizeUnits maps the unit suffixes accepted by ParseSize to their byte
+// multipliers, using SI units (base 1000) like FormatSize.
+var sizeUnits = map[string]float64{
+	"":   1,
+	"b":  1,
+	"k":  1e3,
+	"kb": 1e3,
+	"m":  1e6,
+	"mb": 1e6,
+	"g":  1e9,
+	"gb": 1e9,
+	"t":  1e12,
+	"tb": 1e12,
+}
+
+// ParseSize parses a human-readable size such as "500MB", "1.5 GB" or
+// "4096" into bytes. Units are case-insensitive SI units, matching
+// FormatSize. Negative sizes are rejected.
+func ParseSize(s string) (int64, error) {
+	trimmed := strings.TrimSpace(s)
+	i := 0
+	for i < len(trimmed) && (trimmed[i] == '.' || (trimmed[i] >= '0' && trimmed[i] <= '9')) {
+		i++
+	}
+	n, err := strconv.ParseFloat(trimmed[:i], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	mult, ok := sizeUnits[strings.ToLower(strings.TrimSpace(trimmed[i:]))]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit", s)
+	}
+	return int64(n * mult), nil
 }
@@ -1,9 +1,12 @@
 package scan
 
 import (
+	"context"
 	"os"
 	"path/filepath"
+	"syscall"
 	"testing"
+	"time"
 )
 
 func TestFormatSize(t *testing.T) {
@@ -36,6 +39,39 @@ func TestFormatSize(t *testing.T) {
 	}
 }
 
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"0", 0},
+		{"4096", 4096},
+		{"512B", 512},
+		{"1kB", 1000},
+		{"500MB", 500_000_000},
+		{"500mb", 500_000_000},
+		{"1.5 GB", 1_500_000_000},
+		{"2G", 2_000_000_000},
+		{" 1TB ", 1_000_000_000_000},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil {
+			t.Errorf("ParseSize(%q): unexpected error %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "MB", "-5MB", "10XB", "1.2.3GB"} {
+		if _, err := ParseSize(bad); err == nil {
+			t.Errorf("ParseSize(%q): expected an error", bad)
+		}
+	}
+}
+
 func TestDirSizeEmptyDir(t *testing.T) {
 	dir := t.TempDir()
 	size, err := DirSize(dir)
@@ -167,3 +203,475 @@ func TestDirSizePermissionDenied(t *testing.T) {
 		t.Errorf("DirSize(with permission-denied subdir) = %d, want 100", size)
 	}
 }
+
+func TestDirSizeDeepTree(t *testing.T) {
+	const depth = 1000
+	root := t.TempDir()
+	rel := root
+	for i := 0; i < depth; i++ {
+		rel = filepath.Join(rel, "d")
+	}
+	if err := os.MkdirAll(rel, 0755); err != nil {
+		t.Fatalf("create deep tree: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(rel, "leaf.bin"), make([]byte, 300), 0644); err != nil {
+		t.Fatalf("write leaf: %v", err)
+	}
+	// A symlink back to the top would loop forever if it were followed.
+	if err := os.Symlink(root, filepath.Join(rel, "loop")); err != nil {
+		t.Fatalf("create symlink: %v", err)
+	}
+
+	size, issues, err := DirSizeIssues(root)
+	if err != nil {
+		t.Fatalf("DirSizeIssues: %v", err)
+	}
+	if size != 300 {
+		t.Errorf("DirSizeIssues(deep tree) = %d, want 300", size)
+	}
+	if len(issues) != 0 {
+		t.Errorf("expected no permission issues, got %+v", issues)
+	}
+}
+
+func TestDirSizeIssuesPermissionDenied(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("test requires non-root user")
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "ok.txt"), make([]byte, 100), 0644); err != nil {
+		t.Fatalf("failed to write ok.txt: %v", err)
+	}
+	denied := filepath.Join(dir, "a", "denied")
+	if err := os.MkdirAll(filepath.Join(denied, "deeper"), 0755); err != nil {
+		t.Fatalf("failed to create denied dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(denied, "deeper", "secret.txt"), make([]byte, 200), 0644); err != nil {
+		t.Fatalf("failed to write secret.txt: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a", "after.txt"), make([]byte, 50), 0644); err != nil {
+		t.Fatalf("failed to write after.txt: %v", err)
+	}
+	if err := os.Chmod(denied, 0000); err != nil {
+		t.Fatalf("failed to chmod denied dir: %v", err)
+	}
+	t.Cleanup(func() { os.Chmod(denied, 0755) })
+
+	size, issues, err := DirSizeIssues(dir)
+	if err != nil {
+		t.Fatalf("DirSizeIssues should not fail on a denied subtree, got: %v", err)
+	}
+	if size != 150 {
+		t.Errorf("DirSizeIssues = %d, want 150 (denied subtree skipped, siblings counted)", size)
+	}
+	if len(issues) != 1 || issues[0].Path != denied {
+		t.Fatalf("expected one issue for %s, got %+v", denied, issues)
+	}
+	if issues[0].Description != "denied (permission denied)" {
+		t.Errorf("unexpected description %q", issues[0].Description)
+	}
+}
+
+// countdownCtx is a context that reports itself cancelled once Err has
+// been called more than n times, to stop a walk at a fixed point.
+type countdownCtx struct {
+	context.Context
+	n int
+}
+
+func (c *countdownCtx) Err() error {
+	c.n--
+	if c.n < 0 {
+		return context.Canceled
+	}
+	return nil
+}
+
+// writeSizeTree creates top-level directories a to e under root, each
+// holding two files, plus a top-level file, and returns root's size.
+func writeSizeTree(t *testing.T, root string) int64 {
+	t.Helper()
+	var total int64
+	for i, name := range []string{"a", "b", "c", "d", "e"} {
+		dir := filepath.Join(root, name)
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		for j, file := range []string{"one", "two"} {
+			size := 100*(i+1) + j
+			if err := os.WriteFile(filepath.Join(dir, file), make([]byte, size), 0644); err != nil {
+				t.Fatal(err)
+			}
+			total += int64(size)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(root, "top.bin"), make([]byte, 7), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return total + 7
+}
+
+func TestDirSizeContextResumes(t *testing.T) {
+	root := t.TempDir()
+	want := writeSizeTree(t, root)
+	session := NewSizeSession()
+	if got, err := DirSize(root); err != nil || got != want {
+		t.Fatalf("DirSize = %d, %v; want %d", got, err, want)
+	}
+
+	// Each attempt gets through the root and a couple of children before
+	// it is cancelled, so the walk only finishes by resuming.
+	var got int64
+	var err error
+	attempts := 0
+	for attempts = 1; attempts <= 10; attempts++ {
+		got, err = DirSizeContext(&countdownCtx{Context: context.Background(), n: 5}, session, root, nil)
+		if err == nil {
+			break
+		}
+		if err != context.Canceled {
+			t.Fatalf("attempt %d: unexpected error %v", attempts, err)
+		}
+		if got >= want {
+			t.Fatalf("attempt %d: cancelled walk counted %d of %d", attempts, got, want)
+		}
+	}
+	if err != nil {
+		t.Fatalf("walk did not finish after %d attempts", attempts-1)
+	}
+	if attempts < 2 {
+		t.Fatalf("expected the walk to be cancelled at least once, finished on attempt %d", attempts)
+	}
+	if got != want {
+		t.Errorf("resumed DirSizeContext = %d, want %d (uninterrupted DirSize)", got, want)
+	}
+
+	// The finished walk leaves no checkpoint behind.
+	if _, ok := session.load(root); ok {
+		t.Error("expected no checkpoint after a completed walk")
+	}
+	if got, err := DirSize(root); err != nil || got != want {
+		t.Errorf("DirSize after resume = %d, %v; want %d", got, err, want)
+	}
+}
+
+func TestDirSizeContextCheckpointDroppedOnChange(t *testing.T) {
+	root := t.TempDir()
+	want := writeSizeTree(t, root)
+	session := NewSizeSession()
+
+	if _, err := DirSizeContext(&countdownCtx{Context: context.Background(), n: 5}, session, root, nil); err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	// A file added at the top level changes the root's modification time.
+	if err := os.WriteFile(filepath.Join(root, "0-new.bin"), make([]byte, 1000), 0644); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(root, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := DirSizeContext(context.Background(), session, root, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want+1000 {
+		t.Errorf("DirSizeContext after change = %d, want %d (walked from scratch)", got, want+1000)
+	}
+}
+
+func TestDirSizeContextCheckpointsStayInTheirSession(t *testing.T) {
+	root := t.TempDir()
+	want := writeSizeTree(t, root)
+	session := NewSizeSession()
+
+	partial, err := DirSizeContext(&countdownCtx{Context: context.Background(), n: 5}, session, root, nil)
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	// Another session, and DirSize, walk from scratch.
+	if got, err := DirSizeContext(context.Background(), NewSizeSession(), root, nil); err != nil || got != want {
+		t.Errorf("DirSizeContext in another session = %d, %v; want %d", got, err, want)
+	}
+	if got, err := DirSize(root); err != nil || got != want {
+		t.Errorf("DirSize = %d, %v; want %d", got, err, want)
+	}
+	if _, ok := session.load(root); !ok {
+		t.Errorf("checkpoint at %d bytes was lost to walks outside its session", partial)
+	}
+}
+
+func TestDirSizeSkippedRoots(t *testing.T) {
+	dir := t.TempDir()
+	share := filepath.Join(dir, "share")
+	local := filepath.Join(dir, "local")
+	for _, d := range []string{share, local} {
+		if err := os.MkdirAll(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(d, "f"), make([]byte, 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	SetSkippedRoots([]string{share})
+	t.Cleanup(func() { SetSkippedRoots(nil) })
+
+	if got, err := DirSize(share); err != nil || got != 0 {
+		t.Errorf("DirSize(skipped) = %d, %v; want 0, nil", got, err)
+	}
+	if got, err := DirSize(local); err != nil || got != 100 {
+		t.Errorf("DirSize(local) = %d, %v; want 100, nil", got, err)
+	}
+
+	SetSkippedRoots(nil)
+	if got, _ := DirSize(share); got != 100 {
+		t.Errorf("DirSize after reset = %d, want 100", got)
+	}
+}
+
+func TestDirSizeDepth(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "top.dat"), 100)
+	writeFile(t, filepath.Join(root, "a", "mid.dat"), 200)
+	writeFile(t, filepath.Join(root, "a", "b", "deep.dat"), 400)
+
+	cases := []struct {
+		depth     int
+		want      int64
+		truncated bool
+	}{
+		{1, 100, true},
+		{2, 300, true},
+		{3, 700, false},
+		{0, 700, false},
+	}
+	for _, tc := range cases {
+		got, truncated, err := DirSizeDepth(root, tc.depth)
+		if err != nil {
+			t.Fatalf("DirSizeDepth(%d): %v", tc.depth, err)
+		}
+		if got != tc.want || truncated != tc.truncated {
+			t.Errorf("DirSizeDepth(%d) = %d, truncated %v; want %d, %v", tc.depth, got, truncated, tc.want, tc.truncated)
+		}
+	}
+}
+
+func TestAllocatedSizeSparseFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sparse.img")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 64 MB apparent size with only 4 kB of real data.
+	if err := f.Truncate(64 * 1000 * 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(make([]byte, 4096), 0); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	size, err := AllocatedSize(path)
+	if err != nil {
+		t.Fatalf("AllocatedSize: %v", err)
+	}
+	if size <= 0 {
+		t.Errorf("expected positive allocated size, got %d", size)
+	}
+	if size >= 64*1000*1000 {
+		t.Errorf("expected allocated size below apparent size, got %d", size)
+	}
+}
+
+func TestDirSizeOnDisk(t *testing.T) {
+	root := t.TempDir()
+	small := filepath.Join(root, "small")
+	if err := os.MkdirAll(small, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := os.WriteFile(filepath.Join(small, "f"+string(rune('0'+i))), make([]byte, 10), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f, err := os.Create(filepath.Join(root, "sparse.img"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 64 MB apparent size with only 4 kB of real data.
+	if err := f.Truncate(64 * 1000 * 1000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteAt(make([]byte, 4096), 0); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	info, err := os.Lstat(filepath.Join(small, "f0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st, ok := info.Sys().(*syscall.Stat_t); !ok || st.Blocks == 0 {
+		t.Skip("filesystem does not allocate blocks for small files")
+	}
+
+	logicalSmall, _ := DirSize(small)
+	logical, _ := DirSize(root)
+	if logicalSmall != 100 || logical != 100+64*1000*1000 {
+		t.Fatalf("logical sizes = %d, %d; want 100, %d", logicalSmall, logical, 100+64*1000*1000)
+	}
+
+	SetOnDiskSize(true)
+	t.Cleanup(func() { SetOnDiskSize(false) })
+
+	diskSmall, _ := DirSize(small)
+	disk, _ := DirSize(root)
+	// Each small file takes at least one whole block.
+	if diskSmall < 10*512 {
+		t.Errorf("on-disk size of small files = %d, want at least %d", diskSmall, 10*512)
+	}
+	// The sparse file counts only the blocks it allocated.
+	if disk >= logical {
+		t.Errorf("on-disk size = %d, want below logical size %d", disk, logical)
+	}
+	if sparse := disk - diskSmall; sparse < 4096 || sparse >= 64*1000*1000 {
+		t.Errorf("on-disk size of sparse file = %d, want between 4096 and %d", sparse, 64*1000*1000)
+	}
+	if got := FileSize(info); got%512 != 0 || got < 512 {
+		t.Errorf("FileSize(small file) = %d, want whole blocks", got)
+	}
+
+	SetOnDiskSize(false)
+	if got, _ := DirSize(root); got != logical {
+		t.Errorf("DirSize after reset = %d, want %d", got, logical)
+	}
+}
+
+func TestAllocatedSizeMissing(t *testing.T) {
+	_, err := AllocatedSize(filepath.Join(t.TempDir(), "missing"))
+	if !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestAvailableBytes(t *testing.T) {
+	avail, err := AvailableBytes(t.TempDir())
+	if err != nil {
+		t.Fatalf("AvailableBytes: %v", err)
+	}
+	if avail <= 0 {
+		t.Errorf("expected positive available bytes, got %d", avail)
+	}
+}
+
+func TestAvailableBytesMissing(t *testing.T) {
+	if _, err := AvailableBytes(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
+
+func TestImplausibleTotal(t *testing.T) {
+	tests := []struct {
+		name                  string
+		total, used, capacity int64
+		want                  bool
+	}{
+		{"plausible", 10_000, 60_000, 100_000, false},
+		{"above used space", 70_000, 60_000, 100_000, true},
+		{"above 90% of capacity", 92_000, 95_000, 100_000, true},
+		{"unknown volume", 70_000, 0, 0, false},
+	}
+	for _, tt := range tests {
+		if got := ImplausibleTotal(tt.total, tt.used, tt.capacity); got != tt.want {
+			t.Errorf("%s: ImplausibleTotal(%d, %d, %d) = %v, want %v", tt.name, tt.total, tt.used, tt.capacity, got, tt.want)
+		}
+	}
+}
+
+func TestVolumeSpace(t *testing.T) {
+	avail, total, err := VolumeSpace(t.TempDir())
+	if err != nil {
+		t.Fatalf("VolumeSpace: %v", err)
+	}
+	if total <= 0 || avail < 0 || avail > total {
+		t.Errorf("expected 0 <= available (%d) <= total (%d)", avail, total)
+	}
+	if _, _, err := VolumeSpace(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
+
+func TestIsDir(t *testing.T) {
+	tmp := t.TempDir()
+	file := filepath.Join(tmp, "file.txt")
+	if err := os.WriteFile(file, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	link := filepath.Join(tmp, "link")
+	if err := os.Symlink(tmp, link); err != nil {
+		t.Fatal(err)
+	}
+
+	if !IsDir(tmp) {
+		t.Error("expected directory to report true")
+	}
+	if IsDir(file) {
+		t.Error("expected regular file to report false")
+	}
+	if IsDir(link) {
+		t.Error("expected symlink to a directory to report false")
+	}
+	if IsDir(filepath.Join(tmp, "missing")) {
+		t.Error("expected missing path to report false")
+	}
+}
+
+func TestOwnedByRootFor(t *testing.T) {
+	info, err := os.Stat(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := info.Sys().(*syscall.Stat_t)
+
+	st.Uid = 0
+	if !ownedByRootFor(info, 501) {
+		t.Error("expected root-owned item to require root for a regular user")
+	}
+	if ownedByRootFor(info, 0) {
+		t.Error("expected root-owned item not to require elevation when already root")
+	}
+	st.Uid = 501
+	if ownedByRootFor(info, 501) {
+		t.Error("expected user-owned item not to require root")
+	}
+}
+
+func TestLatestModTime(t *testing.T) {
+	tmp := t.TempDir()
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Minute).Truncate(time.Second)
+
+	nested := filepath.Join(tmp, "a", "b.txt")
+	os.MkdirAll(filepath.Dir(nested), 0755)
+	os.WriteFile(nested, []byte("x"), 0644)
+	os.Chtimes(nested, recent, recent)
+	os.Chtimes(filepath.Dir(nested), old, old)
+	os.Chtimes(tmp, old, old)
+
+	got, err := LatestModTime(tmp)
+	if err != nil {
+		t.Fatalf("LatestModTime: %v", err)
+	}
+	if !got.Equal(recent) {
+		t.Errorf("LatestModTime = %v, want %v", got, recent)
+	}
+}
+
+func TestLatestModTimeMissing(t *testing.T) {
+	if _, err := LatestModTime(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected error for missing path")
+	}
+}
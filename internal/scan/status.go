@@ -0,0 +1,53 @@
+package scan
+
+// ScanStatus says what a scan found, or why it found nothing. It tells
+// "checked and found nothing" apart from "could not check".
+type ScanStatus string
+
+const (
+	// StatusFound means the scan found reclaimable data.
+	StatusFound ScanStatus = "found"
+	// StatusNoData means the scan checked and found nothing.
+	StatusNoData ScanStatus = "no_data"
+	// StatusToolMissing means a command the scan needs is not installed.
+	StatusToolMissing ScanStatus = "tool_missing"
+	// StatusDirAbsent means none of the directories the scan examines
+	// exist.
+	StatusDirAbsent ScanStatus = "dir_absent"
+	// StatusDisabled means the scan was not run because external commands
+	// are disabled.
+	StatusDisabled ScanStatus = "disabled"
+	// StatusPermission means the scan found nothing it could read because
+	// of insufficient permissions.
+	StatusPermission ScanStatus = "permission_denied"
+	// StatusError means the scan failed or timed out.
+	StatusError ScanStatus = "error"
+)
+
+// CategoryStatus is the ScanStatus a scanner reports for one category.
+type CategoryStatus struct {
+	Category string     `json:"category"`
+	Status   ScanStatus `json:"status"`
+	// Detail names the command or directory the status concerns, e.g. the
+	// command that is not installed. Empty when there is none.
+	Detail string `json:"detail,omitempty"`
+}
+
+// Checked reports whether the category was actually examined, that is
+// whether its status is StatusFound or StatusNoData.
+func (cs CategoryStatus) Checked() bool {
+	return cs.Status == StatusFound || cs.Status == StatusNoData
+}
+
+// StatusFunc receives the category statuses a scan reports. The caller
+// of the scan passes one in, so statuses reach only that scan's caller.
+type StatusFunc func(CategoryStatus)
+
+// Report passes the status of category to fn. Scanners call it so an
+// empty result can be told apart from a category that was not checked. A
+// nil fn drops the status.
+func (fn StatusFunc) Report(category string, status ScanStatus, detail string) {
+	if fn != nil {
+		fn(CategoryStatus{Category: category, Status: status, Detail: detail})
+	}
+}
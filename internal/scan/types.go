@@ -1,6 +1,16 @@
 // Package scan provides shared types and utilities for filesystem scanning.
 package scan
 
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/safety"
+)
+
 // ScanEntry represents a single scannable item on the filesystem.
 type ScanEntry struct {
 	// Path is the absolute filesystem path to the item.
@@ -11,6 +21,24 @@ type ScanEntry struct {
 	Size int64 `json:"size"`
 	// RiskLevel indicates the deletion risk (safe, moderate, risky).
 	RiskLevel string `json:"risk_level"`
+	// IsDir records whether the path was a directory (not following
+	// symlinks) at scan time. Cleanup skips the entry if this no longer
+	// matches the filesystem.
+	IsDir bool `json:"is_dir"`
+	// ModTime is the item's modification time. It is set only by
+	// time-based categories (e.g. old Downloads, iOS backups).
+	ModTime time.Time `json:"mod_time,omitzero"`
+	// RequiresRoot is set when the item is owned by root while the scan
+	// ran unprivileged, so removing it needs elevation (see --sudo).
+	RequiresRoot bool `json:"requires_root,omitempty"`
+	// Truncated is set when Size counts only the files within the depth
+	// given to ScanTopLevelDepth, so the item is larger than reported.
+	Truncated bool `json:"truncated,omitempty"`
+	// Deletable reports whether cleanup can actually remove the item.
+	// Informational entries, such as pseudo-paths without a cleanup
+	// handler or root-owned items when sudo is unavailable, are false and
+	// left out of their category's TotalSize. Set by MarkDeletable.
+	Deletable bool `json:"deletable"`
 }
 
 // PermissionIssue records a path that could not be scanned due to
@@ -18,6 +46,104 @@ type ScanEntry struct {
 type PermissionIssue struct {
 	Path        string `json:"path"`
 	Description string `json:"description"`
+	// Hint is a remediation step for the issue, e.g. granting Full Disk
+	// Access. Empty when there is no specific advice.
+	Hint string `json:"hint,omitempty"`
+}
+
+// ValidUTF8 returns s with each run of bytes that is not valid UTF-8
+// replaced by U+FFFD. macOS allows such bytes in file names; output uses
+// this form while the original string is kept for filesystem access.
+func ValidUTF8(s string) string {
+	return strings.ToValidUTF8(s, "\uFFFD")
+}
+
+// MarshalJSON encodes the entry with Path and Description made valid
+// UTF-8 (see ValidUTF8). The entry itself keeps the original bytes, so
+// cleanup removes the real path.
+func (e ScanEntry) MarshalJSON() ([]byte, error) {
+	type plain ScanEntry
+	p := plain(e)
+	p.Path = ValidUTF8(p.Path)
+	p.Description = ValidUTF8(p.Description)
+	return json.Marshal(p)
+}
+
+// MarshalJSON encodes the issue with Path and Description made valid
+// UTF-8, as ScanEntry does.
+func (p PermissionIssue) MarshalJSON() ([]byte, error) {
+	type plain PermissionIssue
+	q := plain(p)
+	q.Path = ValidUTF8(q.Path)
+	q.Description = ValidUTF8(q.Description)
+	return json.Marshal(q)
+}
+
+// DedupePermissionIssues collapses permission issues whose path is the same
+// as, or inside, another issue's path into that outermost inaccessible
+// ancestor; exact duplicates are merged too, so an unreadable ~/Library
+// reported by several scanners shows up once. An ancestor that absorbed
+// other issues is described as covering its contents and keeps the first
+// non-empty hint among them. Issues are returned in the order their
+// surviving paths first appeared.
+func DedupePermissionIssues(issues []PermissionIssue) []PermissionIssue {
+	if len(issues) == 0 {
+		return issues
+	}
+
+	// Shorter paths first, so every ancestor is settled before the paths
+	// inside it are checked against it.
+	byDepth := make([]string, 0, len(issues))
+	seen := make(map[string]bool, len(issues))
+	for _, issue := range issues {
+		p := filepath.Clean(issue.Path)
+		if !seen[p] {
+			seen[p] = true
+			byDepth = append(byDepth, p)
+		}
+	}
+	sort.SliceStable(byDepth, func(a, b int) bool { return len(byDepth[a]) < len(byDepth[b]) })
+
+	owner := make(map[string]string, len(byDepth))
+	var roots []string
+	for _, p := range byDepth {
+		owner[p] = p
+		for _, root := range roots {
+			if strings.HasPrefix(p, root+string(filepath.Separator)) || root == string(filepath.Separator) {
+				owner[p] = root
+				break
+			}
+		}
+		if owner[p] == p {
+			roots = append(roots, p)
+		}
+	}
+
+	index := make(map[string]int, len(roots))
+	absorbed := make(map[string]bool, len(roots))
+	var out []PermissionIssue
+	for _, issue := range issues {
+		p := filepath.Clean(issue.Path)
+		root := owner[p]
+		if p != root {
+			absorbed[root] = true
+		}
+		i, ok := index[root]
+		if !ok {
+			index[root] = len(out)
+			out = append(out, PermissionIssue{Path: root, Description: issue.Description, Hint: issue.Hint})
+			continue
+		}
+		if out[i].Hint == "" {
+			out[i].Hint = issue.Hint
+		}
+	}
+	for i := range out {
+		if absorbed[out[i].Path] {
+			out[i].Description = "this folder and its contents could not be accessed"
+		}
+	}
+	return out
 }
 
 // CategoryResult groups scan entries under a named category.
@@ -32,23 +158,164 @@ type CategoryResult struct {
 	TotalSize int64 `json:"total_size"`
 	// PermissionIssues records paths that could not be scanned.
 	PermissionIssues []PermissionIssue `json:"permission_issues,omitempty"`
+	// RecentlyModified lists entries withheld from deletion because
+	// something inside them changed recently (see ExcludeNewerThan).
+	// They are informational and not counted in TotalSize.
+	RecentlyModified []ScanEntry `json:"recently_modified,omitempty"`
+	// ReportOnly marks a category listed for information only: cleanup
+	// leaves it out unless it was selected explicitly by its ID.
+	ReportOnly bool `json:"report_only,omitempty"`
+	// Truncated is set when an entry is Truncated, so TotalSize is a
+	// lower bound.
+	Truncated bool `json:"truncated,omitempty"`
 }
 
 // SetRiskLevels applies a risk level to all entries in this category
-// by calling riskFn with the category ID.
+// by calling riskFn with the category ID. Entries that a scanner already
+// gave a level of their own are left alone.
 func (cr *CategoryResult) SetRiskLevels(riskFn func(string) string) {
 	level := riskFn(cr.Category)
 	for i := range cr.Entries {
-		cr.Entries[i].RiskLevel = level
+		if cr.Entries[i].RiskLevel == "" {
+			cr.Entries[i].RiskLevel = level
+		}
+	}
+}
+
+// SetPermissionHints sets Hint on every permission issue in this category
+// to hintFn(categoryID). Issues that already carry a hint are left alone.
+func (cr *CategoryResult) SetPermissionHints(hintFn func(string) string) {
+	hint := hintFn(cr.Category)
+	for i := range cr.PermissionIssues {
+		if cr.PermissionIssues[i].Hint == "" {
+			cr.PermissionIssues[i].Hint = hint
+		}
+	}
+}
+
+// MarkDeletable sets Deletable on every entry to deletable(entry) and
+// subtracts the sizes of the entries that are not from TotalSize, so the
+// total only counts what cleanup can reclaim. Call it once per scan
+// result.
+func (cr *CategoryResult) MarkDeletable(deletable func(ScanEntry) bool) {
+	for i := range cr.Entries {
+		cr.Entries[i].Deletable = deletable(cr.Entries[i])
+		if !cr.Entries[i].Deletable {
+			cr.TotalSize -= cr.Entries[i].Size
+		}
+	}
+}
+
+// KeepRecent removes the n most recently modified entries from this
+// category so they are never offered for deletion, and subtracts their
+// sizes from TotalSize. Entries without a ModTime count as oldest. The
+// order of the remaining entries is preserved. n <= 0 is a no-op.
+func (cr *CategoryResult) KeepRecent(n int) {
+	if n <= 0 || len(cr.Entries) == 0 {
+		return
+	}
+
+	byAge := make([]int, len(cr.Entries))
+	for i := range byAge {
+		byAge[i] = i
+	}
+	sort.SliceStable(byAge, func(a, b int) bool {
+		return cr.Entries[byAge[a]].ModTime.After(cr.Entries[byAge[b]].ModTime)
+	})
+	if n > len(byAge) {
+		n = len(byAge)
+	}
+	keep := make(map[int]bool, n)
+	for _, i := range byAge[:n] {
+		keep[i] = true
+	}
+
+	var remaining []ScanEntry
+	for i, e := range cr.Entries {
+		if keep[i] {
+			cr.TotalSize -= e.Size
+			continue
+		}
+		remaining = append(remaining, e)
+	}
+	cr.Entries = remaining
+}
+
+// ExcludeNewerThan moves entries containing anything modified after cutoff
+// from Entries to RecentlyModified, so caches that may belong to a running
+// app are not offered for deletion. Their sizes are subtracted from
+// TotalSize. Entries whose path cannot be stat'ed (e.g. "docker:..."
+// pseudo-paths) are left in place.
+func (cr *CategoryResult) ExcludeNewerThan(cutoff time.Time) {
+	var remaining []ScanEntry
+	for _, e := range cr.Entries {
+		latest, err := LatestModTime(e.Path)
+		if err == nil && latest.After(cutoff) {
+			cr.TotalSize -= e.Size
+			cr.RecentlyModified = append(cr.RecentlyModified, e)
+			continue
+		}
+		remaining = append(remaining, e)
 	}
+	cr.Entries = remaining
 }
 
 // ScanSummary aggregates results from all scanned categories.
 type ScanSummary struct {
+	// GeneratedAt is when the summary was produced.
+	GeneratedAt time.Time `json:"generated_at"`
+	// ToolVersion is the version of mac-cleaner that produced it.
+	ToolVersion string `json:"tool_version"`
 	// Categories holds results for each scanned category.
 	Categories []CategoryResult `json:"categories"`
 	// TotalSize is the sum of all category sizes in bytes.
 	TotalSize int64 `json:"total_size"`
 	// PermissionIssues records paths that could not be scanned.
 	PermissionIssues []PermissionIssue `json:"permission_issues,omitempty"`
+	// RiskTotals breaks the reclaimable bytes down by risk level.
+	RiskTotals RiskTotals `json:"risk_totals"`
+	// SizeEstimateUnreliable is set when TotalSize is implausible for the
+	// volume (see ImplausibleTotal), most likely because clones or sparse
+	// files were counted at their full size.
+	SizeEstimateUnreliable bool `json:"size_estimate_unreliable,omitempty"`
+}
+
+// RiskTotals holds reclaimable bytes per risk level.
+type RiskTotals struct {
+	Safe     int64 `json:"safe"`
+	Moderate int64 `json:"moderate"`
+	Risky    int64 `json:"risky"`
+}
+
+// Total returns the sum of all risk levels.
+func (t RiskTotals) Total() int64 {
+	return t.Safe + t.Moderate + t.Risky
+}
+
+// TotalsByRisk sums entry sizes across results by each entry's risk level.
+// Entries without a risk level take their category's; unknown levels
+// count as moderate, matching safety.RiskForCategory. Entries that are not
+// Deletable are left out, as they are from TotalSize (see MarkDeletable).
+func TotalsByRisk(results []CategoryResult) RiskTotals {
+	var t RiskTotals
+	for _, cat := range results {
+		for _, e := range cat.Entries {
+			if !e.Deletable {
+				continue
+			}
+			level := e.RiskLevel
+			if level == "" {
+				level = safety.RiskForCategory(cat.Category)
+			}
+			switch level {
+			case safety.RiskSafe:
+				t.Safe += e.Size
+			case safety.RiskRisky:
+				t.Risky += e.Size
+			default:
+				t.Moderate += e.Size
+			}
+		}
+	}
+	return t
 }
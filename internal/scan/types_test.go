@@ -1,6 +1,11 @@
 package scan
 
-import "testing"
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
 
 func TestSetRiskLevels_AppliesRiskToAllEntries(t *testing.T) {
 	cr := CategoryResult{
@@ -31,6 +36,70 @@ func TestSetRiskLevels_AppliesRiskToAllEntries(t *testing.T) {
 	}
 }
 
+func TestTotalsByRisk_MixedRisk(t *testing.T) {
+	results := []CategoryResult{
+		{
+			Category: "system-caches",
+			Entries: []ScanEntry{
+				{Path: "/a", Size: 8000, RiskLevel: "safe", Deletable: true},
+				{Path: "/b", Size: 500, RiskLevel: "risky", Deletable: true},
+			},
+		},
+		{
+			Category: "app-ios-backups",
+			Entries: []ScanEntry{
+				{Path: "/c", Size: 40000, RiskLevel: "risky", Deletable: true},
+				{Path: "/d", Size: 3000, RiskLevel: "moderate", Deletable: true},
+			},
+		},
+		{
+			// No per-entry risk: falls back to the category's level.
+			Category: "system-caches",
+			Entries:  []ScanEntry{{Path: "/e", Size: 100, Deletable: true}},
+		},
+		{
+			// Not deletable: informational only, like in TotalSize.
+			Category: "dev-docker",
+			Entries:  []ScanEntry{{Path: "docker:buildx-cache", Size: 7000, RiskLevel: "risky"}},
+		},
+	}
+
+	got := TotalsByRisk(results)
+	want := RiskTotals{Safe: 8100, Moderate: 3000, Risky: 40500}
+	if got != want {
+		t.Errorf("TotalsByRisk = %+v, want %+v", got, want)
+	}
+
+	var grand int64
+	for _, cat := range results {
+		for _, e := range cat.Entries {
+			if e.Deletable {
+				grand += e.Size
+			}
+		}
+	}
+	if got.Total() != grand {
+		t.Errorf("subtotals sum to %d, want deletable total %d", got.Total(), grand)
+	}
+}
+
+func TestSetRiskLevels_KeepsEntryLevels(t *testing.T) {
+	cr := CategoryResult{
+		Category: "app-old-downloads",
+		Entries: []ScanEntry{
+			{Path: "/a", Size: 1},
+			{Path: "/b", Size: 2, RiskLevel: "risky"},
+		},
+	}
+	cr.SetRiskLevels(func(string) string { return "moderate" })
+	if cr.Entries[0].RiskLevel != "moderate" {
+		t.Errorf("expected category level for unset entry, got %q", cr.Entries[0].RiskLevel)
+	}
+	if cr.Entries[1].RiskLevel != "risky" {
+		t.Errorf("expected scanner-set level kept, got %q", cr.Entries[1].RiskLevel)
+	}
+}
+
 func TestSetRiskLevels_EmptyEntries(t *testing.T) {
 	cr := CategoryResult{Category: "empty"}
 	cr.SetRiskLevels(func(string) string { return "risky" })
@@ -39,6 +108,84 @@ func TestSetRiskLevels_EmptyEntries(t *testing.T) {
 	}
 }
 
+func TestSetPermissionHints(t *testing.T) {
+	cr := CategoryResult{
+		Category: "sysdata-mail",
+		PermissionIssues: []PermissionIssue{
+			{Path: "/a", Description: "a (permission denied)"},
+			{Path: "/b", Description: "b (permission denied)", Hint: "custom"},
+		},
+	}
+
+	cr.SetPermissionHints(func(catID string) string {
+		if catID != "sysdata-mail" {
+			t.Errorf("expected category 'sysdata-mail', got %q", catID)
+		}
+		return "grant access"
+	})
+
+	if cr.PermissionIssues[0].Hint != "grant access" {
+		t.Errorf("expected hint 'grant access', got %q", cr.PermissionIssues[0].Hint)
+	}
+	if cr.PermissionIssues[1].Hint != "custom" {
+		t.Errorf("expected existing hint kept, got %q", cr.PermissionIssues[1].Hint)
+	}
+}
+
+func TestDedupePermissionIssues_CollapsesToAncestor(t *testing.T) {
+	issues := []PermissionIssue{
+		{Path: "/Users/me/Library/Caches", Description: "User App Caches (permission denied)"},
+		{Path: "/Users/me/Library", Description: "Library (permission denied)", Hint: "grant Full Disk Access"},
+		{Path: "/Users/me/Library/Logs", Description: "User Logs (permission denied)"},
+		{Path: "/Users/me/Library/Caches/com.apple.Safari", Description: "Safari (permission denied)"},
+		{Path: "/Users/me/Downloads", Description: "Downloads (permission denied)"},
+		{Path: "/Users/me/Library2", Description: "sibling with a shared prefix"},
+	}
+
+	got := DedupePermissionIssues(issues)
+
+	if len(got) != 3 {
+		t.Fatalf("expected 3 issues, got %d: %+v", len(got), got)
+	}
+	if got[0].Path != "/Users/me/Library" {
+		t.Errorf("expected ~/Library first, got %q", got[0].Path)
+	}
+	if got[0].Description != "this folder and its contents could not be accessed" {
+		t.Errorf("expected collapsed description, got %q", got[0].Description)
+	}
+	if got[0].Hint != "grant Full Disk Access" {
+		t.Errorf("expected hint from absorbed issue, got %q", got[0].Hint)
+	}
+	if got[1].Path != "/Users/me/Downloads" || got[1].Description != "Downloads (permission denied)" {
+		t.Errorf("expected unrelated issue kept as is, got %+v", got[1])
+	}
+	if got[2].Path != "/Users/me/Library2" {
+		t.Errorf("expected shared-prefix sibling kept, got %+v", got[2])
+	}
+}
+
+func TestDedupePermissionIssues_ExactDuplicates(t *testing.T) {
+	issues := []PermissionIssue{
+		{Path: "/Users/me/Library/Mail", Description: "Mail (permission denied)"},
+		{Path: "/Users/me/Library/Mail/", Description: "Mail data (permission denied)", Hint: "grant access"},
+	}
+
+	got := DedupePermissionIssues(issues)
+
+	if len(got) != 1 {
+		t.Fatalf("expected 1 issue, got %+v", got)
+	}
+	if got[0].Description != "Mail (permission denied)" || got[0].Hint != "grant access" {
+		t.Errorf("expected first description and merged hint, got %+v", got[0])
+	}
+}
+
+func TestDedupePermissionIssues_Empty(t *testing.T) {
+	if got := DedupePermissionIssues(nil); len(got) != 0 {
+		t.Errorf("expected no issues, got %+v", got)
+	}
+}
+
 func TestSetRiskLevels_UsesCategory(t *testing.T) {
 	cr := CategoryResult{
 		Category: "dev-xcode",
@@ -60,3 +207,57 @@ func TestSetRiskLevels_UsesCategory(t *testing.T) {
 		t.Errorf("expected risk 'risky', got %q", cr.Entries[0].RiskLevel)
 	}
 }
+
+func TestKeepRecent_RemovesNewestEntries(t *testing.T) {
+	now := time.Now()
+	cr := CategoryResult{
+		Entries: []ScanEntry{
+			{Path: "/big-old", Size: 400, ModTime: now.Add(-300 * time.Hour)},
+			{Path: "/newest", Size: 300, ModTime: now.Add(-1 * time.Hour)},
+			{Path: "/middle", Size: 200, ModTime: now.Add(-100 * time.Hour)},
+			{Path: "/second", Size: 100, ModTime: now.Add(-10 * time.Hour)},
+		},
+		TotalSize: 1000,
+	}
+
+	cr.KeepRecent(2)
+
+	if len(cr.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(cr.Entries))
+	}
+	if cr.Entries[0].Path != "/big-old" || cr.Entries[1].Path != "/middle" {
+		t.Errorf("unexpected remaining entries: %+v", cr.Entries)
+	}
+	if cr.TotalSize != 600 {
+		t.Errorf("expected total 600, got %d", cr.TotalSize)
+	}
+}
+
+func TestKeepRecent_NoOpAndOverflow(t *testing.T) {
+	cr := CategoryResult{Entries: []ScanEntry{{Path: "/a", Size: 10}}, TotalSize: 10}
+	cr.KeepRecent(0)
+	if len(cr.Entries) != 1 || cr.TotalSize != 10 {
+		t.Errorf("expected no change for n=0, got %+v", cr)
+	}
+	cr.KeepRecent(5)
+	if len(cr.Entries) != 0 || cr.TotalSize != 0 {
+		t.Errorf("expected all entries kept back, got %+v", cr)
+	}
+}
+
+func TestScanEntry_ModTimeOmittedWhenZero(t *testing.T) {
+	data, err := json.Marshal(ScanEntry{Path: "/a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "mod_time") {
+		t.Errorf("expected mod_time omitted, got %s", data)
+	}
+	data, err = json.Marshal(ScanEntry{Path: "/a", ModTime: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"mod_time":"2026-01-02T03:04:05Z"`) {
+		t.Errorf("expected mod_time in JSON, got %s", data)
+	}
+}
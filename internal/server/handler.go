@@ -2,7 +2,10 @@ package server
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+
+	"github.com/sp3esu/mac-cleaner/internal/logging"
 )
 
 // Handler dispatches NDJSON requests to method-specific handlers.
@@ -26,7 +29,18 @@ func (h *Handler) Dispatch(ctx context.Context, req Request, w *NDJSONWriter) {
 		h.handleCleanup(ctx, req, w)
 	case MethodCategories:
 		h.handleCategories(req, w)
+	case MethodCategoryDetail:
+		h.handleCategoryDetail(req, w)
+	case MethodSetEnabled:
+		h.handleSetEnabled(req, w)
+	case MethodAttach:
+		h.handleAttach(ctx, req, w)
+	case MethodSubscribe:
+		h.handleSubscribe(req, w)
+	case MethodReload:
+		h.handleReload(req, w)
 	default:
+		logging.Warn("unknown method", "id", req.ID, "method", req.Method)
 		_ = w.WriteErrorMsg(req.ID, fmt.Sprintf("unknown method: %s", req.Method))
 	}
 }
@@ -38,3 +52,50 @@ func (h *Handler) handlePing(req Request, w *NDJSONWriter) {
 		Version: h.server.version,
 	})
 }
+
+// handleReload replaces the server's engine with one built from the
+// current configuration. Operations already running finish with the old
+// engine. The new engine shares the old one's token store, so scan tokens
+// issued before the reload, or by scans still running, stay valid for
+// cleanup. On error the old engine stays in place.
+func (h *Handler) handleReload(req Request, w *NDJSONWriter) {
+	if h.server.Reload == nil {
+		_ = w.WriteErrorMsg(req.ID, "reload is not supported by this server")
+		return
+	}
+	eng, err := h.server.Reload()
+	if err != nil {
+		logging.Warn("reload failed", "error", err)
+		_ = w.WriteErrorMsg(req.ID, fmt.Sprintf("reload failed: %v", err))
+		return
+	}
+	eng.ShareTokens(h.server.currentEngine())
+	h.server.engine.Store(eng)
+	logging.Info("configuration reloaded")
+	_ = w.WriteResult(req.ID, map[string]string{"status": "reloaded"})
+}
+
+// handleAttach streams the remaining progress and the final response of a
+// running or recently finished operation to this connection. Responses
+// carry the attach request's ID.
+func (h *Handler) handleAttach(ctx context.Context, req Request, w *NDJSONWriter) {
+	var params AttachParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			_ = w.WriteErrorMsg(req.ID, fmt.Sprintf("invalid params: %v", err))
+			return
+		}
+	}
+	if params.OperationID == "" {
+		_ = w.WriteErrorMsg(req.ID, "operation_id is required")
+		return
+	}
+
+	op, ok := h.server.lookupOperation(params.OperationID)
+	if !ok {
+		_ = w.WriteErrorCode(req.ID, ErrCodeUnknownOperation, fmt.Sprintf("unknown operation: %s", params.OperationID))
+		return
+	}
+	op.attach(req.ID, w)
+	op.follow(ctx, w)
+}
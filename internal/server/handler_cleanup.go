@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/sp3esu/mac-cleaner/internal/engine"
 )
@@ -15,6 +16,9 @@ type CleanupProgress struct {
 	EntryPath string `json:"entry_path,omitempty"`
 	Current   int    `json:"current"`
 	Total     int    `json:"total"`
+	// AvailableBytes is the free space on the home volume, sampled about
+	// every 500ms on "cleanup_entry" events.
+	AvailableBytes int64 `json:"available_bytes,omitempty"`
 }
 
 // CleanupResult is the final result of a cleanup operation.
@@ -23,23 +27,73 @@ type CleanupResult struct {
 	Failed     int      `json:"failed"`
 	BytesFreed int64    `json:"bytes_freed"`
 	Errors     []string `json:"errors,omitempty"`
+	// PerCategory maps category IDs to the bytes freed from each; the
+	// values sum to BytesFreed.
+	PerCategory map[string]int64 `json:"per_category,omitempty"`
+}
+
+// defaultProgressEvery is the cleanup_entry batch size used when the client
+// does not set CleanupParams.ProgressEvery.
+const defaultProgressEvery = 25
+
+// progressInterval is the longest gap between streamed cleanup_entry events
+// while entries keep being removed.
+const progressInterval = 250 * time.Millisecond
+
+// progressThrottle decides which cleanup events are streamed to the client.
+// Category starts are always sent. Entry events are sent for the first
+// entry, every N entries, after progressInterval has passed, when they
+// carry a free-space sample, and for the final entry, so the client still
+// sees an exact end state.
+type progressThrottle struct {
+	every    int
+	pending  int
+	lastSent time.Time
+}
+
+func newProgressThrottle(every int) *progressThrottle {
+	if every <= 0 {
+		every = defaultProgressEvery
+	}
+	return &progressThrottle{every: every}
+}
+
+// shouldSend reports whether event should be streamed at time now.
+func (p *progressThrottle) shouldSend(event engine.CleanupEvent, now time.Time) bool {
+	if event.Type != engine.EventCleanupEntry {
+		return true
+	}
+	p.pending++
+	if p.pending < p.every && event.Current < event.Total && event.AvailableBytes == 0 &&
+		now.Sub(p.lastSent) < progressInterval {
+		return false
+	}
+	p.pending = 0
+	p.lastSent = now
+	return true
 }
 
 func (h *Handler) handleCleanup(ctx context.Context, req Request, w *NDJSONWriter) {
+	if h.server.ReportOnly {
+		_ = w.WriteErrorCode(req.ID, ErrCodeCleanupDisabled, "cleanup is disabled (report-only mode)")
+		return
+	}
+
 	if !h.server.busy.CompareAndSwap(false, true) {
 		_ = w.WriteErrorMsg(req.ID, "another operation is in progress")
 		return
 	}
-	defer h.server.busy.Store(false)
 
 	// Check for client disconnect before starting.
 	if ctx.Err() != nil {
+		h.server.busy.Store(false)
 		return
 	}
 
 	var params CleanupParams
 	if len(req.Params) > 0 {
 		if err := json.Unmarshal(req.Params, &params); err != nil {
+			h.server.busy.Store(false)
 			_ = w.WriteErrorMsg(req.ID, fmt.Sprintf("invalid params: %v", err))
 			return
 		}
@@ -47,35 +101,43 @@ func (h *Handler) handleCleanup(ctx context.Context, req Request, w *NDJSONWrite
 
 	// Token is required for cleanup (must come from a prior scan).
 	if params.Token == "" {
+		h.server.busy.Store(false)
 		_ = w.WriteErrorMsg(req.ID, "token is required; run scan first")
 		return
 	}
 
-	events, done := h.server.engine.Cleanup(ctx, engine.ScanToken(params.Token), params.Categories)
+	op := h.server.startOperation(req.ID, w, 0)
+	go h.runCleanup(op, params)
+	op.follow(ctx, w)
+}
+
+// runCleanup runs the cleanup for op, streaming throttled progress to
+// whichever client is attached. File deletion continues to completion if
+// the client disconnects.
+func (h *Handler) runCleanup(op *operation, params CleanupParams) {
+	events, done := h.server.currentEngine().Cleanup(op.ctx, engine.ScanToken(params.Token), params.Categories)
 
-	// Drain events channel, streaming progress to client.
+	// Drain events channel, streaming throttled progress to client.
+	throttle := newProgressThrottle(params.ProgressEvery)
 	for event := range events {
-		if ctx.Err() != nil {
-			break
+		if !throttle.shouldSend(event, time.Now()) {
+			continue
 		}
-		_ = w.WriteProgress(req.ID, CleanupProgress{
-			Event:     event.Type,
-			Category:  event.Category,
-			EntryPath: event.EntryPath,
-			Current:   event.Current,
-			Total:     event.Total,
+		op.progress(CleanupProgress{
+			Event:          event.Type,
+			Category:       event.Category,
+			EntryPath:      event.EntryPath,
+			Current:        event.Current,
+			Total:          event.Total,
+			AvailableBytes: event.AvailableBytes,
 		})
 	}
 
 	result := <-done
-
-	// If client disconnected during cleanup, skip final result.
-	if ctx.Err() != nil {
-		return
-	}
+	h.server.busy.Store(false)
 
 	if result.Err != nil {
-		_ = w.WriteErrorMsg(req.ID, result.Err.Error())
+		op.finish(Response{Type: ResponseError, Error: result.Err.Error()})
 		return
 	}
 
@@ -84,10 +146,12 @@ func (h *Handler) handleCleanup(ctx context.Context, req Request, w *NDJSONWrite
 		errs = append(errs, e.Error())
 	}
 
-	_ = w.WriteResult(req.ID, CleanupResult{
+	op.finish(Response{Type: ResponseResult, Result: CleanupResult{
 		Removed:    result.Result.Removed,
 		Failed:     result.Result.Failed,
 		BytesFreed: result.Result.BytesFreed,
 		Errors:     errs,
-	})
+
+		PerCategory: result.Result.PerCategory,
+	}})
 }
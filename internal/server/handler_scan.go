@@ -3,17 +3,35 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"time"
 
 	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/logging"
+	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
 // ScanProgress is a progress event streamed during scanning.
 type ScanProgress struct {
-	Event     string `json:"event"` // "scanner_start", "scanner_done", "scanner_error"
+	// Event is "scan_start", "scanner_start", "scanner_done",
+	// "scanner_error" or "scan_complete".
+	Event     string `json:"event"`
 	ScannerID string `json:"scanner_id"`
 	Label     string `json:"label"`
 	Error     string `json:"error,omitempty"`
+	// Duration is the wall-clock run time in nanoseconds: the scanner's on
+	// "scanner_done" events, the whole scan's on "scan_complete".
+	Duration time.Duration `json:"duration_ns,omitempty"`
+	// ScannerCount is the number of scanners that will run, present on
+	// "scan_start".
+	ScannerCount int `json:"scanner_count,omitempty"`
+	// TotalSize and CategoryCount summarize the results on "scan_complete".
+	TotalSize     int64 `json:"total_size,omitempty"`
+	CategoryCount int   `json:"category_count,omitempty"`
+	// Statuses lists the category statuses the scanner reported, present
+	// on "scanner_done".
+	Statuses []scan.CategoryStatus `json:"statuses,omitempty"`
 }
 
 // ScanResult is the final result of a scan operation.
@@ -21,6 +39,13 @@ type ScanResult struct {
 	Categories []scanResultCategory `json:"categories"`
 	TotalSize  int64                `json:"total_size"`
 	Token      string               `json:"token"`
+	Cached     bool                 `json:"cached,omitempty"`
+	TimedOut   bool                 `json:"timed_out,omitempty"`
+	// GeneratedAt is when the result was produced, the original scan's
+	// time for a cached one, and ToolVersion the server version, so saved
+	// results can be traced.
+	GeneratedAt time.Time `json:"generated_at"`
+	ToolVersion string    `json:"tool_version"`
 }
 
 // scanResultCategory mirrors scan.CategoryResult for JSON serialization.
@@ -31,6 +56,9 @@ type scanResultCategory = interface{}
 type CategoryInfo struct {
 	ID    string `json:"id"`
 	Label string `json:"label"`
+	// Enabled is false for scanners turned off with set_enabled; scans
+	// skip them.
+	Enabled bool `json:"enabled"`
 }
 
 // CategoriesResult is the result of a categories request.
@@ -38,21 +66,28 @@ type CategoriesResult struct {
 	Scanners []CategoryInfo `json:"scanners"`
 }
 
+// CategoryDetailResult is the result of a category_detail request: one
+// category of the stored scan with all its entries.
+type CategoryDetailResult struct {
+	Category scan.CategoryResult `json:"category"`
+}
+
 func (h *Handler) handleScan(ctx context.Context, req Request, w *NDJSONWriter) {
 	if !h.server.busy.CompareAndSwap(false, true) {
 		_ = w.WriteErrorMsg(req.ID, "another operation is in progress")
 		return
 	}
-	defer h.server.busy.Store(false)
 
 	// Check for client disconnect before starting.
 	if ctx.Err() != nil {
+		h.server.busy.Store(false)
 		return
 	}
 
 	var params ScanParams
 	if len(req.Params) > 0 {
 		if err := json.Unmarshal(req.Params, &params); err != nil {
+			h.server.busy.Store(false)
 			_ = w.WriteErrorMsg(req.ID, fmt.Sprintf("invalid params: %v", err))
 			return
 		}
@@ -63,56 +98,182 @@ func (h *Handler) handleScan(ctx context.Context, req Request, w *NDJSONWriter)
 		skip[id] = true
 	}
 
-	events, done := h.server.engine.ScanAll(ctx, skip)
+	op := h.server.startOperation(req.ID, w, h.server.orphanTimeout())
+	go h.runScan(op, skip, params.MinSizeBytes)
+	op.follow(ctx, w)
+}
+
+// runScan runs the scan for op, streaming progress to whichever client is
+// attached. It keeps running if the client disconnects, until no client
+// has attached for the server's OrphanTimeout. Categories smaller than
+// minSize are left out of the result; the token covers them anyway, but
+// only for a cleanup that names its categories (see engine.MarkFiltered).
+func (h *Handler) runScan(op *operation, skip map[string]bool, minSize int64) {
+	eng := h.server.currentEngine()
+	disabled := eng.Disabled()
+	events, done := eng.ScanAll(op.ctx, skip)
 
 	// Drain events channel, streaming progress to client.
 	for event := range events {
-		if ctx.Err() != nil {
-			break
-		}
 		progress := ScanProgress{ScannerID: event.ScannerID, Label: event.Label}
 		switch event.Type {
+		case engine.EventScanStart:
+			progress.Event = "scan_start"
+			progress.ScannerCount = event.ScannerCount
+		case engine.EventScanComplete:
+			progress.Event = "scan_complete"
+			progress.Duration = event.Duration
+			progress.TotalSize = event.TotalSize
+			progress.CategoryCount = event.CategoryCount
 		case engine.EventScannerStart:
 			progress.Event = "scanner_start"
 		case engine.EventScannerDone:
 			progress.Event = "scanner_done"
+			progress.Duration = event.Duration
+			progress.Statuses = event.Statuses
 		case engine.EventScannerError:
 			progress.Event = "scanner_error"
 			if event.Err != nil {
 				progress.Error = event.Err.Error()
 			}
 		}
-		_ = w.WriteProgress(req.ID, progress)
+		op.progress(progress)
 	}
 
 	result := <-done
-
-	// If client disconnected during scan, don't bother with final result.
-	if ctx.Err() != nil {
+	if op.ctx.Err() != nil {
+		h.server.busy.Store(false)
+		op.finish(Response{Type: ResponseError, Error: "scan cancelled"})
 		return
 	}
 
+	categories := result.Results
+	if minSize > 0 {
+		categories = make([]scan.CategoryResult, 0, len(result.Results))
+		for _, cat := range result.Results {
+			if cat.TotalSize >= minSize {
+				categories = append(categories, cat)
+			}
+		}
+	}
+
+	if len(categories) < len(result.Results) {
+		eng.MarkFiltered(result.Token)
+	}
+
 	var totalSize int64
-	for _, cat := range result.Results {
+	for _, cat := range categories {
 		totalSize += cat.TotalSize
 	}
 
-	_ = w.WriteResult(req.ID, struct {
-		Categories interface{} `json:"categories"`
-		TotalSize  int64       `json:"total_size"`
-		Token      string      `json:"token"`
+	// Release busy before the result is sent so a client can follow up
+	// with cleanup as soon as it has the token.
+	h.server.busy.Store(false)
+	op.finish(Response{Type: ResponseResult, Result: struct {
+		Categories  interface{} `json:"categories"`
+		TotalSize   int64       `json:"total_size"`
+		Token       string      `json:"token"`
+		Cached      bool        `json:"cached,omitempty"`
+		TimedOut    bool        `json:"timed_out,omitempty"`
+		GeneratedAt time.Time   `json:"generated_at"`
+		ToolVersion string      `json:"tool_version"`
+		// DisabledScanners lists the scanners turned off with
+		// set_enabled, whose categories are absent.
+		DisabledScanners []string `json:"disabled_scanners,omitempty"`
 	}{
-		Categories: result.Results,
-		TotalSize:  totalSize,
-		Token:      string(result.Token),
-	})
+		Categories:       categories,
+		TotalSize:        totalSize,
+		Token:            string(result.Token),
+		Cached:           result.Cached,
+		TimedOut:         result.TimedOut,
+		GeneratedAt:      result.ScannedAt.Truncate(time.Second),
+		ToolVersion:      h.server.version,
+		DisabledScanners: disabled,
+	}})
 }
 
 func (h *Handler) handleCategories(req Request, w *NDJSONWriter) {
-	infos := h.server.engine.Categories()
+	_ = w.WriteResult(req.ID, categoriesResult(h.server.currentEngine()))
+}
+
+// categoriesResult lists eng's scanners with whether each is enabled.
+func categoriesResult(eng *engine.Engine) CategoriesResult {
+	infos := eng.Categories()
 	cats := make([]CategoryInfo, len(infos))
 	for i, info := range infos {
-		cats[i] = CategoryInfo{ID: info.ID, Label: info.Name}
+		cats[i] = CategoryInfo{ID: info.ID, Label: info.Name, Enabled: eng.ScannerEnabled(info.ID)}
+	}
+	return CategoriesResult{Scanners: cats}
+}
+
+// handleSetEnabled turns the named scanners on or off for later scans and
+// returns the updated categories. The selection is saved through
+// SaveDisabled before it takes effect; if saving fails nothing changes.
+// A scan already running keeps the selection it started with.
+func (h *Handler) handleSetEnabled(req Request, w *NDJSONWriter) {
+	var params SetEnabledParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			_ = w.WriteErrorMsg(req.ID, fmt.Sprintf("invalid params: %v", err))
+			return
+		}
+	}
+	if len(params.ScannerIDs) == 0 {
+		_ = w.WriteErrorMsg(req.ID, "scanner_ids is required")
+		return
+	}
+
+	eng := h.server.currentEngine()
+	known := make(map[string]bool)
+	for _, info := range eng.Categories() {
+		known[info.ID] = true
+	}
+	for _, id := range params.ScannerIDs {
+		if !known[id] {
+			_ = w.WriteErrorCode(req.ID, ErrCodeUnknownScanner, fmt.Sprintf("unknown scanner: %s", id))
+			return
+		}
+	}
+
+	ids, err := eng.SetScannerEnabled(params.ScannerIDs, params.Enabled, h.server.SaveDisabled)
+	if err != nil {
+		logging.Warn("saving scanner selection failed", "error", err)
+		_ = w.WriteErrorMsg(req.ID, fmt.Sprintf("save scanner selection: %v", err))
+		return
+	}
+	logging.Info("scanner selection changed", "disabled", ids)
+	_ = w.WriteResult(req.ID, categoriesResult(eng))
+}
+
+// handleCategoryDetail returns one category of the scan stored under the
+// request's token. It does not run a scan or consume the token, so it may
+// be called while another operation is in progress.
+func (h *Handler) handleCategoryDetail(req Request, w *NDJSONWriter) {
+	var params CategoryDetailParams
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			_ = w.WriteErrorMsg(req.ID, fmt.Sprintf("invalid params: %v", err))
+			return
+		}
+	}
+	if params.Token == "" || params.CategoryID == "" {
+		_ = w.WriteErrorMsg(req.ID, "token and category_id are required")
+		return
+	}
+
+	cat, err := h.server.currentEngine().CategoryDetail(engine.ScanToken(params.Token), params.CategoryID)
+	var tokenErr *engine.TokenError
+	var catErr *engine.CategoryError
+	switch {
+	case errors.As(err, &tokenErr):
+		_ = w.WriteErrorCode(req.ID, ErrCodeInvalidToken, err.Error())
+		return
+	case errors.As(err, &catErr):
+		_ = w.WriteErrorCode(req.ID, ErrCodeUnknownCategory, err.Error())
+		return
+	case err != nil:
+		_ = w.WriteError(req.ID, err)
+		return
 	}
-	_ = w.WriteResult(req.ID, CategoriesResult{Scanners: cats})
+	_ = w.WriteResult(req.ID, CategoryDetailResult{Category: cat})
 }
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/logging"
+)
+
+// AttachRetention is how long the final response of a finished operation
+// stays available to attach requests.
+const AttachRetention = 30 * time.Second
+
+// DefaultOrphanTimeout is how long a scan keeps running without a client
+// before it is cancelled.
+const DefaultOrphanTimeout = 10 * time.Second
+
+// OperationStart is the first progress event of a scan or cleanup. Its
+// OperationID lets a client that reconnects resume the stream with attach.
+type OperationStart struct {
+	// Event is always "operation_start".
+	Event       string `json:"event"`
+	OperationID string `json:"operation_id"`
+}
+
+// operation is a scan or cleanup running independently of the connection
+// that started it. Progress is streamed to at most one subscriber, the
+// latest connection to start or attach to it, and broadcast to the
+// connections that sent subscribe; the final response is kept so a client
+// that attaches after completion still receives it.
+type operation struct {
+	id   string
+	done chan struct{}
+
+	// ctx bounds the operation. It is cancelled when the operation is left
+	// without a subscriber for orphanTimeout, or when it finishes.
+	ctx    context.Context
+	cancel context.CancelFunc
+	// orphanTimeout is zero for operations that run to completion without
+	// a client.
+	orphanTimeout time.Duration
+	// broadcast sends progress to the subscribed connections other than
+	// the one given. Nil broadcasts nothing.
+	broadcast func(v any, skip *NDJSONWriter)
+
+	mu         sync.Mutex
+	subID      string
+	sub        *NDJSONWriter
+	final      *Response
+	finishedAt time.Time
+	orphan     *time.Timer
+}
+
+func newOperation(parent context.Context, orphanTimeout time.Duration) *operation {
+	b := make([]byte, 16)
+	// crypto/rand.Read never returns an error for small reads on supported platforms.
+	_, _ = rand.Read(b)
+	op := &operation{id: hex.EncodeToString(b), done: make(chan struct{}), orphanTimeout: orphanTimeout}
+	op.ctx, op.cancel = context.WithCancel(parent)
+	return op
+}
+
+// progress streams a progress event to the current subscriber, if any. A
+// subscriber whose write fails is dropped, so a dead client is noticed
+// even before its connection is. Other subscribers are sent the event
+// after op.mu is released.
+func (op *operation) progress(v any) {
+	op.mu.Lock()
+	if op.sub != nil {
+		if err := op.sub.WriteProgress(op.subID, v); err != nil {
+			logging.Debug("operation subscriber lost", "operation", op.id, "error", err)
+			op.sub = nil
+			op.orphaned()
+		}
+	}
+	sub := op.sub
+	op.mu.Unlock()
+	if op.broadcast != nil {
+		op.broadcast(v, sub)
+	}
+}
+
+// orphaned starts the timer that cancels the operation unless a client
+// attaches within orphanTimeout. op.mu must be held.
+func (op *operation) orphaned() {
+	if op.orphanTimeout <= 0 || op.final != nil || op.orphan != nil {
+		return
+	}
+	op.orphan = time.AfterFunc(op.orphanTimeout, func() {
+		op.mu.Lock()
+		abandoned := op.sub == nil && op.final == nil
+		op.mu.Unlock()
+		if abandoned {
+			logging.Debug("cancelling orphaned operation", "operation", op.id, "timeout", op.orphanTimeout)
+			op.cancel()
+		}
+	})
+}
+
+// adopted stops the orphan timer. op.mu must be held.
+func (op *operation) adopted() {
+	if op.orphan != nil {
+		op.orphan.Stop()
+		op.orphan = nil
+	}
+}
+
+// finish records the final response, sends it to the current subscriber
+// and marks the operation done.
+func (op *operation) finish(resp Response) {
+	op.mu.Lock()
+	op.final = &resp
+	op.finishedAt = time.Now()
+	op.adopted()
+	sub := op.sub
+	if op.sub != nil {
+		resp.ID = op.subID
+		_ = op.sub.Write(resp)
+		op.sub = nil
+	}
+	op.mu.Unlock()
+	if op.broadcast != nil {
+		op.broadcast(OperationDone{Event: "operation_done", OperationID: op.id, Error: resp.Error}, sub)
+	}
+	close(op.done)
+	op.cancel()
+}
+
+// attach makes w the subscriber, replacing any previous one. Responses
+// carry id. If the operation has already finished, the final response is
+// written immediately.
+func (op *operation) attach(id string, w *NDJSONWriter) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	if op.final != nil {
+		resp := *op.final
+		resp.ID = id
+		_ = w.Write(resp)
+		return
+	}
+	op.subID = id
+	op.sub = w
+	op.adopted()
+}
+
+// detach drops w as the subscriber. It is a no-op if another connection
+// has attached since.
+func (op *operation) detach(w *NDJSONWriter) {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	if op.sub == w {
+		op.sub = nil
+		op.orphaned()
+	}
+}
+
+// expired reports whether the operation finished more than AttachRetention
+// before now.
+func (op *operation) expired(now time.Time) bool {
+	op.mu.Lock()
+	defer op.mu.Unlock()
+	return op.final != nil && now.Sub(op.finishedAt) > AttachRetention
+}
+
+// follow blocks until op finishes or the client disconnects, in which case
+// the connection stops receiving the operation's progress.
+func (op *operation) follow(ctx context.Context, w *NDJSONWriter) {
+	select {
+	case <-op.done:
+	case <-ctx.Done():
+		op.detach(w)
+	}
+}
+
+// startOperation registers a new operation as the server's current one and
+// streams its operation_start event to the requesting client. An
+// orphanTimeout above zero cancels the operation once it has run that long
+// without a client; zero lets it run to completion.
+func (s *Server) startOperation(id string, w *NDJSONWriter, orphanTimeout time.Duration) *operation {
+	op := newOperation(s.opCtx, orphanTimeout)
+	op.broadcast = s.broadcast
+	s.mu.Lock()
+	s.op = op
+	s.mu.Unlock()
+	op.attach(id, w)
+	op.progress(OperationStart{Event: "operation_start", OperationID: op.id})
+	return op
+}
+
+// lookupOperation returns the running or recently finished operation with
+// the given ID.
+func (s *Server) lookupOperation(id string) (*operation, bool) {
+	s.mu.Lock()
+	op := s.op
+	s.mu.Unlock()
+	if op == nil || op.id != id || op.expired(time.Now()) {
+		return nil, false
+	}
+	return op, true
+}
@@ -18,13 +18,23 @@ const (
 	MethodScan       = "scan"
 	MethodCleanup    = "cleanup"
 	MethodCategories = "categories"
+	MethodAttach     = "attach"
+	MethodReload     = "reload"
+	// MethodCategoryDetail returns one category of a prior scan.
+	MethodCategoryDetail = "category_detail"
+	// MethodSetEnabled turns scanners on or off for later scans.
+	MethodSetEnabled = "set_enabled"
+	// MethodSubscribe streams the progress of every operation, whichever
+	// client started it, to the connection.
+	MethodSubscribe = "subscribe"
 )
 
 // Request is the client-to-server NDJSON message.
 type Request struct {
 	// ID is a client-assigned identifier echoed in all responses.
 	ID string `json:"id"`
-	// Method is the RPC method name (ping, scan, cleanup, categories, shutdown).
+	// Method is the RPC method name (ping, scan, cleanup, categories,
+	// category_detail, set_enabled, attach, subscribe, reload, shutdown).
 	Method string `json:"method"`
 	// Params holds method-specific parameters.
 	Params json.RawMessage `json:"params,omitempty"`
@@ -40,6 +50,9 @@ type Response struct {
 	Result any `json:"result,omitempty"`
 	// Error holds an error message (for "error" type).
 	Error string `json:"error,omitempty"`
+	// Code is a machine-readable error code (for "error" type). Empty for
+	// errors that have no dedicated code.
+	Code string `json:"code,omitempty"`
 }
 
 // Response types.
@@ -49,10 +62,34 @@ const (
 	ResponseError    = "error"
 )
 
+// Error codes carried in Response.Code.
+const (
+	// ErrCodeCleanupDisabled is returned for cleanup requests when the
+	// server runs in report-only mode.
+	ErrCodeCleanupDisabled = "cleanup_disabled"
+	// ErrCodeUnknownOperation is returned for attach requests whose
+	// operation ID matches no running or recently finished operation.
+	ErrCodeUnknownOperation = "unknown_operation"
+	// ErrCodeInvalidToken is returned for category_detail requests whose
+	// token was already used or has been evicted by newer scans.
+	ErrCodeInvalidToken = "invalid_token"
+	// ErrCodeUnknownCategory is returned for category_detail requests
+	// naming a category the scan did not produce.
+	ErrCodeUnknownCategory = "unknown_category"
+	// ErrCodeUnknownScanner is returned for set_enabled requests naming
+	// a scanner that is not registered.
+	ErrCodeUnknownScanner = "unknown_scanner"
+)
+
 // ScanParams holds parameters for the scan method.
 type ScanParams struct {
 	// Skip lists category IDs to exclude from results.
 	Skip []string `json:"skip,omitempty"`
+	// MinSizeBytes drops categories smaller than this many bytes from the
+	// result and its total_size. The token still covers every scanned
+	// category, so a cleanup may name one that was left out. Zero or
+	// negative returns every category.
+	MinSizeBytes int64 `json:"min_size_bytes,omitempty"`
 }
 
 // CleanupParams holds parameters for the cleanup method.
@@ -62,6 +99,34 @@ type CleanupParams struct {
 	Token string `json:"token"`
 	// Categories lists the category IDs to clean up. Must match a prior scan.
 	Categories []string `json:"categories,omitempty"`
+	// ProgressEvery batches cleanup_entry progress events: one is streamed
+	// for every N entries removed. Zero or negative uses the default batch.
+	// Use 1 to stream every entry.
+	ProgressEvery int `json:"progress_every,omitempty"`
+}
+
+// CategoryDetailParams holds parameters for the category_detail method.
+type CategoryDetailParams struct {
+	// Token is the scan token returned by a prior scan. It is not
+	// consumed, so it stays valid for cleanup.
+	Token string `json:"token"`
+	// CategoryID is the category to return, e.g. "system-caches".
+	CategoryID string `json:"category_id"`
+}
+
+// SetEnabledParams holds parameters for the set_enabled method.
+type SetEnabledParams struct {
+	// ScannerIDs lists the scanners to change, as returned by categories.
+	ScannerIDs []string `json:"scanner_ids"`
+	// Enabled turns the scanners on (true) or off (false).
+	Enabled bool `json:"enabled"`
+}
+
+// AttachParams holds parameters for the attach method.
+type AttachParams struct {
+	// OperationID is the ID from the operation_start event of the scan or
+	// cleanup to reattach to.
+	OperationID string `json:"operation_id"`
 }
 
 // PingResult is the result of a ping request.
@@ -71,22 +136,43 @@ type PingResult struct {
 }
 
 // NDJSONWriter writes NDJSON responses to a writer. It is safe for
-// concurrent use.
+// concurrent use: each response is encoded in full and handed to the
+// underlying writer in a single Write under a lock, so concurrent callers
+// never interleave within a line.
 type NDJSONWriter struct {
 	mu  sync.Mutex
-	enc *json.Encoder
+	w   io.Writer
+	err error
 }
 
 // NewNDJSONWriter creates a new NDJSON writer.
 func NewNDJSONWriter(w io.Writer) *NDJSONWriter {
-	return &NDJSONWriter{enc: json.NewEncoder(w)}
+	return &NDJSONWriter{w: w}
 }
 
-// Write sends a single NDJSON response.
+// Write sends a single NDJSON response as one line. After a failed write
+// the stream may end in a partial line, so every later Write returns the
+// same error instead of appending to it.
 func (w *NDJSONWriter) Write(resp Response) error {
+	line, err := json.Marshal(resp)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
 	w.mu.Lock()
 	defer w.mu.Unlock()
-	return w.enc.Encode(resp)
+	if w.err != nil {
+		return w.err
+	}
+	n, err := w.w.Write(line)
+	if err == nil && n < len(line) {
+		err = io.ErrShortWrite
+	}
+	if err != nil {
+		w.err = err
+	}
+	return err
 }
 
 // WriteResult sends a result response.
@@ -109,6 +195,11 @@ func (w *NDJSONWriter) WriteErrorMsg(id, msg string) error {
 	return w.Write(Response{ID: id, Type: ResponseError, Error: msg})
 }
 
+// WriteErrorCode sends an error response with a machine-readable code.
+func (w *NDJSONWriter) WriteErrorCode(id, code, msg string) error {
+	return w.Write(Response{ID: id, Type: ResponseError, Error: msg, Code: code})
+}
+
 // NDJSONReader reads NDJSON requests from a reader.
 type NDJSONReader struct {
 	scanner *bufio.Scanner
@@ -3,8 +3,12 @@ package server
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
 )
 
@@ -71,6 +75,40 @@ func TestNDJSONWriter_WriteError(t *testing.T) {
 	}
 }
 
+func TestNDJSONWriter_WriteErrorCode(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+
+	err := w.WriteErrorCode("req-4", ErrCodeCleanupDisabled, "cleanup is disabled")
+	if err != nil {
+		t.Fatalf("WriteErrorCode: %v", err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(buf.Bytes(), &resp); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if resp.Type != ResponseError {
+		t.Errorf("expected type error, got %q", resp.Type)
+	}
+	if resp.Code != ErrCodeCleanupDisabled {
+		t.Errorf("expected code %q, got %q", ErrCodeCleanupDisabled, resp.Code)
+	}
+	if resp.Error != "cleanup is disabled" {
+		t.Errorf("expected error message, got %q", resp.Error)
+	}
+}
+
+func TestNDJSONWriter_WriteErrorOmitsEmptyCode(t *testing.T) {
+	var buf bytes.Buffer
+	w := NewNDJSONWriter(&buf)
+
+	_ = w.WriteErrorMsg("req-5", "plain error")
+	if strings.Contains(buf.String(), `"code"`) {
+		t.Errorf("expected no code field, got %s", buf.String())
+	}
+}
+
 func TestNDJSONWriter_MultipleMessages(t *testing.T) {
 	var buf bytes.Buffer
 	w := NewNDJSONWriter(&buf)
@@ -92,6 +130,89 @@ func TestNDJSONWriter_MultipleMessages(t *testing.T) {
 	}
 }
 
+// chunkedWriter passes each Write to buf a few bytes at a time, yielding
+// between chunks, the way a socket may accept a large write in pieces. It
+// does no locking of its own.
+type chunkedWriter struct {
+	buf bytes.Buffer
+}
+
+func (c *chunkedWriter) Write(p []byte) (int, error) {
+	for i := 0; i < len(p); i += 7 {
+		end := min(i+7, len(p))
+		c.buf.Write(p[i:end])
+		runtime.Gosched()
+	}
+	return len(p), nil
+}
+
+func TestNDJSONWriter_ConcurrentWritesStayOnOneLine(t *testing.T) {
+	const goroutines, perGoroutine = 32, 50
+	var out chunkedWriter
+	w := NewNDJSONWriter(&out)
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < perGoroutine; i++ {
+				id := fmt.Sprintf("g%d-%d", g, i)
+				_ = w.WriteProgress(id, map[string]string{"payload": strings.Repeat("x", 64+g)})
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(out.buf.String(), "\n"), "\n")
+	if len(lines) != goroutines*perGoroutine {
+		t.Fatalf("expected %d lines, got %d", goroutines*perGoroutine, len(lines))
+	}
+	seen := map[string]bool{}
+	for i, line := range lines {
+		var resp Response
+		if err := json.Unmarshal([]byte(line), &resp); err != nil {
+			t.Fatalf("line %d is not a complete JSON object: %v\n%s", i, err, line)
+		}
+		seen[resp.ID] = true
+	}
+	if len(seen) != goroutines*perGoroutine {
+		t.Errorf("expected %d distinct responses, got %d", goroutines*perGoroutine, len(seen))
+	}
+}
+
+// failingWriter accepts the first n bytes and then fails.
+type failingWriter struct {
+	n   int
+	buf bytes.Buffer
+}
+
+func (f *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > f.n {
+		f.buf.Write(p[:f.n])
+		return f.n, errors.New("broken pipe")
+	}
+	f.n -= len(p)
+	return f.buf.Write(p)
+}
+
+func TestNDJSONWriter_StopsAfterFailedWrite(t *testing.T) {
+	out := &failingWriter{n: 10}
+	w := NewNDJSONWriter(out)
+
+	if err := w.WriteResult("1", "first"); err == nil {
+		t.Fatal("expected the partial write to fail")
+	}
+	written := out.buf.String()
+	out.n = 1 << 20
+	if err := w.WriteResult("2", "second"); err == nil {
+		t.Error("expected later writes to keep failing")
+	}
+	if out.buf.String() != written {
+		t.Errorf("nothing may be appended after a partial line, got %q", out.buf.String())
+	}
+}
+
 func TestNDJSONReader_Read(t *testing.T) {
 	input := `{"id":"1","method":"ping"}` + "\n" +
 		`{"id":"2","method":"scan","params":{"skip":["dev-docker"]}}` + "\n"
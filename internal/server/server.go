@@ -2,20 +2,28 @@ package server
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net"
 	"os"
 	"sync"
 	"sync/atomic"
+	"syscall"
 	"time"
 
 	"github.com/sp3esu/mac-cleaner/internal/engine"
+	"github.com/sp3esu/mac-cleaner/internal/logging"
 )
 
 // DefaultIdleTimeout is the maximum time a connection can be idle before
-// being closed. Reset on each received message.
+// being closed. Reset on each received message; subscribed connections
+// never time out.
 const DefaultIdleTimeout = 5 * time.Minute
 
+// DefaultWriteTimeout is the longest a single write to a client may block
+// before the client is treated as gone.
+const DefaultWriteTimeout = 10 * time.Second
+
 // Server is a Unix domain socket IPC server for mac-cleaner.
 type Server struct {
 	socketPath string
@@ -26,8 +34,34 @@ type Server struct {
 	// being closed. Defaults to DefaultIdleTimeout if zero.
 	IdleTimeout time.Duration
 
-	// engine is the scan/cleanup engine instance.
-	engine *engine.Engine
+	// WriteTimeout bounds each write to a client. A client that stops
+	// reading is disconnected once a write blocks this long. Defaults to
+	// DefaultWriteTimeout if zero.
+	WriteTimeout time.Duration
+
+	// OrphanTimeout is how long a scan keeps running after its client
+	// disconnected without another attaching. The scan is then cancelled
+	// so the server is free for the next one. Cleanups always run to
+	// completion. Defaults to DefaultOrphanTimeout if zero.
+	OrphanTimeout time.Duration
+
+	// ReportOnly disables cleanup entirely. Scans work normally, but every
+	// cleanup request is refused with ErrCodeCleanupDisabled.
+	ReportOnly bool
+
+	// Reload builds a new engine from the current configuration for the
+	// reload method. Nil disables reloading.
+	Reload func() (*engine.Engine, error)
+
+	// SaveDisabled persists the IDs of the disabled scanners after a
+	// set_enabled request, so Reload and restarts keep them. Nil keeps
+	// the selection in memory only.
+	SaveDisabled func(ids []string) error
+
+	// engine is the scan/cleanup engine instance. Each operation uses the
+	// engine current when it starts, so a reload never affects one in
+	// flight.
+	engine atomic.Pointer[engine.Engine]
 
 	// handler is the method dispatch table.
 	handler *Handler
@@ -35,43 +69,64 @@ type Server struct {
 	// busy tracks whether a scan or cleanup operation is in progress.
 	busy atomic.Bool
 
-	// mu guards active connection state.
-	mu     sync.Mutex
-	active net.Conn
-
-	// connCancel cancels the current connection's context when the client
-	// disconnects, allowing long-running handlers to abort cleanly.
-	connCancel context.CancelFunc
-
-	// done is closed when the server shuts down.
-	done chan struct{}
+	// opCtx bounds scan and cleanup operations. It outlives connections so
+	// an operation keeps running when its client disconnects, and is
+	// cancelled on shutdown.
+	opCtx    context.Context
+	opCancel context.CancelFunc
+
+	// mu guards conns and op.
+	mu sync.Mutex
+	// conns maps each open connection to the function cancelling its
+	// context, so Shutdown can end them all.
+	conns map[net.Conn]context.CancelFunc
+
+	// op is the running or most recently finished operation, the target
+	// of attach requests.
+	op *operation
+
+	// subMu guards subs, the writers of the connections that sent
+	// subscribe, each mapped to its subscription.
+	subMu sync.Mutex
+	subs  map[*NDJSONWriter]*subscriber
+
+	// done is closed when the server shuts down, once: connections may
+	// request shutdown concurrently.
+	done     chan struct{}
+	doneOnce sync.Once
 }
 
 // New creates a new server that will listen on the given socket path.
 // The engine is used for all scan and cleanup operations.
 func New(socketPath, version string, eng *engine.Engine) *Server {
 	s := &Server{
-		socketPath:  socketPath,
-		version:     version,
-		engine:      eng,
-		IdleTimeout: DefaultIdleTimeout,
-		done:        make(chan struct{}),
+		socketPath:    socketPath,
+		version:       version,
+		IdleTimeout:   DefaultIdleTimeout,
+		WriteTimeout:  DefaultWriteTimeout,
+		OrphanTimeout: DefaultOrphanTimeout,
+		conns:         make(map[net.Conn]context.CancelFunc),
+		subs:          make(map[*NDJSONWriter]*subscriber),
+		done:          make(chan struct{}),
 	}
+	s.engine.Store(eng)
+	s.opCtx, s.opCancel = context.WithCancel(context.Background())
 	s.handler = NewHandler(s)
 	return s
 }
 
+// currentEngine returns the engine new operations should use.
+func (s *Server) currentEngine() *engine.Engine {
+	return s.engine.Load()
+}
+
 // Serve starts the server, listening for connections until the context is
 // cancelled or Shutdown is called. It removes stale socket files on startup
 // and cleans up the socket file on shutdown.
 func (s *Server) Serve(ctx context.Context) error {
-	if err := s.cleanStaleSocket(); err != nil {
-		return fmt.Errorf("stale socket: %w", err)
-	}
-
-	ln, err := net.Listen("unix", s.socketPath)
+	ln, err := s.listen()
 	if err != nil {
-		return fmt.Errorf("listen: %w", err)
+		return err
 	}
 	s.listener = ln
 
@@ -83,6 +138,7 @@ func (s *Server) Serve(ctx context.Context) error {
 		select {
 		case <-ctx.Done():
 			ln.Close() // #nosec G104 -- best-effort listener close during shutdown
+			s.opCancel()
 		case <-s.done:
 		}
 	}()
@@ -96,80 +152,117 @@ func (s *Server) Serve(ctx context.Context) error {
 			case <-ctx.Done():
 				return nil
 			default:
+				logging.Error("accept failed", "socket", s.socketPath, "error", err)
 				return fmt.Errorf("accept: %w", err)
 			}
 		}
 
-		// Handle one connection at a time.
-		s.handleConnection(ctx, conn)
+		go s.handleConnection(ctx, conn)
 	}
 }
 
 // Shutdown gracefully shuts down the server.
 func (s *Server) Shutdown() {
-	select {
-	case <-s.done:
+	first := false
+	s.doneOnce.Do(func() {
+		close(s.done)
+		first = true
+	})
+	if !first {
 		return // already shut down
-	default:
 	}
-	close(s.done)
+	s.opCancel()
 	if s.listener != nil {
 		s.listener.Close() // #nosec G104 -- best-effort listener close during shutdown
 	}
 	s.mu.Lock()
-	if s.connCancel != nil {
-		s.connCancel()
-	}
-	if s.active != nil {
-		s.active.Close() // #nosec G104 -- best-effort connection close during shutdown
+	for conn, cancel := range s.conns {
+		cancel()
+		conn.Close() // #nosec G104 -- best-effort connection close during shutdown
 	}
 	s.mu.Unlock()
 }
 
-// handleConnection processes a single client connection. It creates a
-// per-connection context that is cancelled when the client disconnects,
-// allowing long-running handlers (scan, cleanup) to abort cleanly.
+// handleConnection processes a single client connection. Connections are
+// served concurrently; requests on one connection are handled in order.
+// It creates a per-connection context that is cancelled when the client
+// disconnects, so a handler waiting on a long-running operation (scan,
+// cleanup) stops streaming, and drops the connection's subscription.
 func (s *Server) handleConnection(ctx context.Context, conn net.Conn) {
 	connCtx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
 	s.mu.Lock()
-	s.active = conn
-	s.connCancel = cancel
+	s.conns[conn] = cancel
 	s.mu.Unlock()
 
 	defer func() {
 		conn.Close() // #nosec G104 -- best-effort connection close on handler exit
 		s.mu.Lock()
-		s.active = nil
-		s.connCancel = nil
+		delete(s.conns, conn)
 		s.mu.Unlock()
 	}()
 
-	reader := NewNDJSONReader(conn)
-	writer := NewNDJSONWriter(conn)
+	writeTimeout := s.WriteTimeout
+	if writeTimeout <= 0 {
+		writeTimeout = DefaultWriteTimeout
+	}
+	writer := NewNDJSONWriter(&deadlineWriter{conn: conn, timeout: writeTimeout, failed: cancel})
+	defer s.unsubscribe(writer)
+	logging.Debug("client connected", "socket", s.socketPath)
+
+	// Read requests in the background so a disconnect is noticed while a
+	// request is being handled. A read error cancels connCtx.
+	requests := make(chan Request)
+	go func() {
+		defer cancel()
+		reader := NewNDJSONReader(conn)
+		for {
+			req, err := reader.Read()
+			if err != nil {
+				logging.Debug("connection closed", "error", err)
+				return // connection closed or read error
+			}
+			select {
+			case requests <- req:
+			case <-connCtx.Done():
+				return
+			}
+		}
+	}()
 
 	for {
+		// Idle timeout — if no message arrives within IdleTimeout, the
+		// connection is closed. Time spent handling a request does not
+		// count, and a subscribed connection, waiting for events, never
+		// times out until its subscription is dropped.
+		var idleC <-chan time.Time
+		var droppedC <-chan struct{}
+		stopIdle := func() bool { return false }
+		if sub := s.subscription(writer); sub != nil {
+			droppedC = sub.dropped
+		} else {
+			idle := time.NewTimer(s.IdleTimeout)
+			idleC, stopIdle = idle.C, idle.Stop
+		}
+		var req Request
 		select {
+		case req = <-requests:
+			stopIdle()
+		case <-droppedC:
+			continue // re-arm the idle timer
+		case <-idleC:
+			logging.Debug("connection idle timeout", "timeout", s.IdleTimeout)
+			return
 		case <-connCtx.Done():
+			stopIdle()
 			return
 		case <-s.done:
+			stopIdle()
 			return
-		default:
 		}
 
-		// Set idle timeout — if no message arrives within IdleTimeout,
-		// the connection is closed.
-		_ = conn.SetReadDeadline(time.Now().Add(s.IdleTimeout))
-
-		req, err := reader.Read()
-		if err != nil {
-			return // connection closed, timeout, or read error
-		}
-
-		// Reset deadline for next read.
-		_ = conn.SetReadDeadline(time.Time{})
-
+		logging.Debug("request", "id", req.ID, "method", req.Method)
 		if req.Method == MethodShutdown {
 			_ = writer.WriteResult(req.ID, map[string]string{"status": "shutting_down"})
 			s.Shutdown()
@@ -180,6 +273,63 @@ func (s *Server) handleConnection(ctx context.Context, conn net.Conn) {
 	}
 }
 
+// deadlineWriter bounds each write to a connection by timeout. A failed
+// write, whether the client closed the socket or stopped reading, calls
+// failed, which ends the connection as a disconnect would.
+type deadlineWriter struct {
+	conn    net.Conn
+	timeout time.Duration
+	failed  context.CancelFunc
+}
+
+func (d *deadlineWriter) Write(p []byte) (int, error) {
+	_ = d.conn.SetWriteDeadline(time.Now().Add(d.timeout))
+	n, err := d.conn.Write(p)
+	if err != nil {
+		logging.Debug("write to client failed", "error", err)
+		d.failed()
+	}
+	return n, err
+}
+
+// orphanTimeout returns the OrphanTimeout scans run with.
+func (s *Server) orphanTimeout() time.Duration {
+	if s.OrphanTimeout <= 0 {
+		return DefaultOrphanTimeout
+	}
+	return s.OrphanTimeout
+}
+
+// bindAttempts bounds how often listen binds the socket before giving up.
+const bindAttempts = 3
+
+// listenUnix binds a Unix domain socket. Tests replace it to simulate a
+// socket file appearing between the stale check and the bind.
+var listenUnix = func(path string) (net.Listener, error) {
+	return net.Listen("unix", path)
+}
+
+// listen removes a stale socket file and binds the socket. Another
+// instance can create the file between the stale check and the bind; the
+// bind then fails with EADDRINUSE and the file is probed again. A live
+// listener fails with "already listening"; a stale file is removed and the
+// bind retried, up to bindAttempts times.
+func (s *Server) listen() (net.Listener, error) {
+	for attempt := 1; ; attempt++ {
+		if err := s.cleanStaleSocket(); err != nil {
+			return nil, fmt.Errorf("stale socket: %w", err)
+		}
+
+		ln, err := listenUnix(s.socketPath)
+		if err == nil {
+			return ln, nil
+		}
+		if !errors.Is(err, syscall.EADDRINUSE) || attempt >= bindAttempts {
+			return nil, fmt.Errorf("listen: %w", err)
+		}
+	}
+}
+
 // cleanStaleSocket removes a leftover socket file if no process is listening
 // on it. This handles the case where a previous server crashed without cleanup.
 func (s *Server) cleanStaleSocket() error {
@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -163,27 +165,45 @@ func TestServer_ScanStreaming(t *testing.T) {
 		}
 	}
 
-	// 2 scanners x (scanner_start + scanner_done) = 4 progress events minimum.
-	if progressCount < 4 {
-		t.Errorf("expected at least 4 progress responses, got %d", progressCount)
+	// operation_start + scan_start + 2 scanners x (scanner_start +
+	// scanner_done) + scan_complete = 7 progress events minimum.
+	if progressCount < 7 {
+		t.Errorf("expected at least 7 progress responses, got %d", progressCount)
 	}
 	if resultCount != 1 {
 		t.Errorf("expected exactly 1 result response, got %d", resultCount)
 	}
 
 	// Verify progress events contain expected fields.
+	var progresses []ScanProgress
+	var opID string
 	for _, resp := range responses {
 		if resp.Type != ResponseProgress {
 			continue
 		}
 		resultBytes, _ := json.Marshal(resp.Result)
+		if len(progresses) == 0 && opID == "" {
+			var start OperationStart
+			if err := json.Unmarshal(resultBytes, &start); err != nil {
+				t.Fatalf("unmarshal operation_start: %v", err)
+			}
+			if start.Event != "operation_start" || start.OperationID == "" {
+				t.Fatalf("expected operation_start with an operation_id first, got %+v", start)
+			}
+			opID = start.OperationID
+			continue
+		}
 		var progress ScanProgress
 		if err := json.Unmarshal(resultBytes, &progress); err != nil {
 			t.Fatalf("unmarshal progress: %v", err)
 		}
+		progresses = append(progresses, progress)
 		if progress.Event == "" {
 			t.Error("progress event field is empty")
 		}
+		if progress.Event == "scan_start" || progress.Event == "scan_complete" {
+			continue
+		}
 		if progress.ScannerID == "" {
 			t.Error("progress scanner_id field is empty")
 		}
@@ -192,13 +212,23 @@ func TestServer_ScanStreaming(t *testing.T) {
 		}
 	}
 
+	// The scan is bracketed by scan_start and scan_complete.
+	if first := progresses[0]; first.Event != "scan_start" || first.ScannerCount != 2 {
+		t.Errorf("expected first progress scan_start with scanner_count 2, got %+v", first)
+	}
+	if last := progresses[len(progresses)-1]; last.Event != "scan_complete" || last.TotalSize != 3072 {
+		t.Errorf("expected last progress scan_complete with total_size 3072, got %+v", last)
+	}
+
 	// Verify final result.
 	final := responses[len(responses)-1]
 	resultBytes, _ := json.Marshal(final.Result)
 	var scanResult struct {
-		Categories []json.RawMessage `json:"categories"`
-		TotalSize  int64             `json:"total_size"`
-		Token      string            `json:"token"`
+		Categories  []json.RawMessage `json:"categories"`
+		TotalSize   int64             `json:"total_size"`
+		Token       string            `json:"token"`
+		GeneratedAt string            `json:"generated_at"`
+		ToolVersion string            `json:"tool_version"`
 	}
 	if err := json.Unmarshal(resultBytes, &scanResult); err != nil {
 		t.Fatalf("unmarshal scan result: %v", err)
@@ -212,6 +242,12 @@ func TestServer_ScanStreaming(t *testing.T) {
 	if scanResult.Token == "" {
 		t.Error("expected non-empty token")
 	}
+	if _, err := time.Parse(time.RFC3339, scanResult.GeneratedAt); err != nil {
+		t.Errorf("expected RFC 3339 generated_at, got %q: %v", scanResult.GeneratedAt, err)
+	}
+	if scanResult.ToolVersion != "test-1.0.0" {
+		t.Errorf("expected tool_version test-1.0.0, got %q", scanResult.ToolVersion)
+	}
 }
 
 func TestServer_ScanThenCleanup(t *testing.T) {
@@ -283,13 +319,21 @@ func TestServer_ScanThenCleanup(t *testing.T) {
 	cleanFinal := cleanupResponses[len(cleanupResponses)-1]
 	cleanResultBytes, _ := json.Marshal(cleanFinal.Result)
 	var cleanupResult struct {
-		Removed    int   `json:"removed"`
-		Failed     int   `json:"failed"`
-		BytesFreed int64 `json:"bytes_freed"`
+		Removed     int              `json:"removed"`
+		Failed      int              `json:"failed"`
+		BytesFreed  int64            `json:"bytes_freed"`
+		PerCategory map[string]int64 `json:"per_category"`
 	}
 	if err := json.Unmarshal(cleanResultBytes, &cleanupResult); err != nil {
 		t.Fatalf("unmarshal cleanup result: %v", err)
 	}
+	var perCategoryTotal int64
+	for _, freed := range cleanupResult.PerCategory {
+		perCategoryTotal += freed
+	}
+	if perCategoryTotal != cleanupResult.BytesFreed {
+		t.Errorf("per_category sums to %d, want bytes_freed %d", perCategoryTotal, cleanupResult.BytesFreed)
+	}
 
 	// Mock paths don't exist on disk, so all entries should be reported as
 	// failed. The key assertion is that the fields are present and the handler
@@ -300,6 +344,76 @@ func TestServer_ScanThenCleanup(t *testing.T) {
 	}
 }
 
+func TestServer_CleanupReportsPerCategory(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	cachePath := filepath.Join(home, "cache.bin")
+	logPath := filepath.Join(home, "old.log")
+	if err := os.WriteFile(cachePath, make([]byte, 512), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(logPath, make([]byte, 256), 0644); err != nil {
+		t.Fatal(err)
+	}
+	eng := engine.New()
+	eng.Register(engine.NewScanner(engine.ScannerInfo{ID: "files", Name: "Files"}, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{
+			{Category: "real-caches", TotalSize: 512, Entries: []scan.ScanEntry{{Path: cachePath, Size: 512}}},
+			{Category: "real-logs", TotalSize: 256, Entries: []scan.ScanEntry{{Path: logPath, Size: 256}}},
+		}, nil
+	}))
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", eng)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "s1", Method: MethodScan})
+	scanResponses := readAllResponses(t, conn, 5*time.Second)
+	resultBytes, _ := json.Marshal(scanResponses[len(scanResponses)-1].Result)
+	var scanResult struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(resultBytes, &scanResult); err != nil || scanResult.Token == "" {
+		t.Fatalf("expected a scan token, got %s (%v)", resultBytes, err)
+	}
+
+	params, _ := json.Marshal(CleanupParams{Token: scanResult.Token})
+	sendRequest(t, conn, Request{ID: "c1", Method: MethodCleanup, Params: params})
+	cleanupResponses := readAllResponses(t, conn, 5*time.Second)
+	cleanResultBytes, _ := json.Marshal(cleanupResponses[len(cleanupResponses)-1].Result)
+	var cleanupResult struct {
+		Removed     int              `json:"removed"`
+		BytesFreed  int64            `json:"bytes_freed"`
+		PerCategory map[string]int64 `json:"per_category"`
+	}
+	if err := json.Unmarshal(cleanResultBytes, &cleanupResult); err != nil {
+		t.Fatalf("unmarshal cleanup result: %v", err)
+	}
+	if cleanupResult.Removed != 2 || cleanupResult.BytesFreed != 768 {
+		t.Fatalf("expected both files removed, got %s", cleanResultBytes)
+	}
+	want := map[string]int64{"real-caches": 512, "real-logs": 256}
+	if len(cleanupResult.PerCategory) != len(want) {
+		t.Fatalf("per_category = %v, want %v", cleanupResult.PerCategory, want)
+	}
+	for id, freed := range want {
+		if cleanupResult.PerCategory[id] != freed {
+			t.Errorf("per_category[%s] = %d, want %d", id, cleanupResult.PerCategory[id], freed)
+		}
+	}
+}
+
 func TestServer_ConcurrentScanRejected(t *testing.T) {
 	// The server processes requests sequentially per connection, so true
 	// socket-level concurrent scans can't happen on one connection. Instead,
@@ -450,6 +564,250 @@ func TestServer_ScanWithSkipParam(t *testing.T) {
 	}
 }
 
+func TestServer_ScanWithMinSizeBytes(t *testing.T) {
+	socketPath := filepath.Join(os.TempDir(), "mc-test-scan-minsize.sock")
+	os.Remove(socketPath)
+	defer os.Remove(socketPath)
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// "mock-caches" holds 1024 bytes, below the threshold.
+	params, _ := json.Marshal(ScanParams{MinSizeBytes: 1500})
+	sendRequest(t, conn, Request{ID: "ms1", Method: MethodScan, Params: params})
+
+	responses := readAllResponses(t, conn, 5*time.Second)
+	final := responses[len(responses)-1]
+	if final.Type != ResponseResult {
+		t.Fatalf("expected final result, got %s", final.Type)
+	}
+
+	resultBytes, _ := json.Marshal(final.Result)
+	var scanResult struct {
+		Categories []struct {
+			Category string `json:"category"`
+		} `json:"categories"`
+		TotalSize int64  `json:"total_size"`
+		Token     string `json:"token"`
+	}
+	if err := json.Unmarshal(resultBytes, &scanResult); err != nil {
+		t.Fatalf("unmarshal scan result: %v", err)
+	}
+	if len(scanResult.Categories) != 1 || scanResult.Categories[0].Category != "mock-browser-data" {
+		t.Fatalf("expected only mock-browser-data, got %+v", scanResult.Categories)
+	}
+	if scanResult.TotalSize != 2048 {
+		t.Errorf("expected total_size 2048, got %d", scanResult.TotalSize)
+	}
+
+	// A cleanup without categories would remove mock-caches, which the
+	// client never saw, so it is refused and the token stays valid.
+	cleanupParams, _ := json.Marshal(CleanupParams{Token: scanResult.Token})
+	sendRequest(t, conn, Request{ID: "ms2", Method: MethodCleanup, Params: cleanupParams})
+	cleanupResponses := readAllResponses(t, conn, 5*time.Second)
+	if resp := cleanupResponses[len(cleanupResponses)-1]; resp.Type != ResponseError || !strings.Contains(resp.Error, "list the categories") {
+		t.Fatalf("expected cleanup of all categories to be refused, got %+v", resp)
+	}
+
+	// The token still covers the category that was left out.
+	cleanupParams, _ = json.Marshal(CleanupParams{Token: scanResult.Token, Categories: []string{"mock-caches"}})
+	sendRequest(t, conn, Request{ID: "ms3", Method: MethodCleanup, Params: cleanupParams})
+	for _, resp := range readAllResponses(t, conn, 5*time.Second) {
+		if resp.Type == ResponseError {
+			t.Fatalf("cleanup of filtered category rejected: %s", resp.Error)
+		}
+	}
+}
+
+func TestServer_CachedUnfilteredScanKeepsTokenFiltered(t *testing.T) {
+	eng := newMockTestEngine()
+	eng.CacheTTL = time.Minute
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", eng)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	scanToken := func(id string, params ScanParams) string {
+		t.Helper()
+		raw, _ := json.Marshal(params)
+		sendRequest(t, conn, Request{ID: id, Method: MethodScan, Params: raw})
+		responses := readAllResponses(t, conn, 5*time.Second)
+		final := responses[len(responses)-1]
+		if final.Type != ResponseResult {
+			t.Fatalf("%s: expected final result, got %+v", id, final)
+		}
+		resultBytes, _ := json.Marshal(final.Result)
+		var result struct {
+			Token string `json:"token"`
+		}
+		if err := json.Unmarshal(resultBytes, &result); err != nil {
+			t.Fatalf("unmarshal scan result: %v", err)
+		}
+		return result.Token
+	}
+
+	// A filtered scan, then the same scan unfiltered from the cache: both
+	// carry the same token.
+	filtered := scanToken("f1", ScanParams{MinSizeBytes: 1500})
+	if whole := scanToken("f2", ScanParams{}); whole != filtered {
+		t.Fatalf("expected the cached scan to reuse token %q, got %q", filtered, whole)
+	}
+
+	// The client that saw the filtered result still cannot clean every
+	// category with it.
+	cleanupParams, _ := json.Marshal(CleanupParams{Token: filtered})
+	sendRequest(t, conn, Request{ID: "f3", Method: MethodCleanup, Params: cleanupParams})
+	responses := readAllResponses(t, conn, 5*time.Second)
+	if resp := responses[len(responses)-1]; resp.Type != ResponseError || !strings.Contains(resp.Error, "list the categories") {
+		t.Fatalf("expected cleanup of all categories to be refused, got %+v", resp)
+	}
+}
+
+func TestServer_ScanDoneProgressIncludesDuration(t *testing.T) {
+	socketPath := filepath.Join(os.TempDir(), "mc-test-scan-duration.sock")
+	os.Remove(socketPath)
+	defer os.Remove(socketPath)
+
+	const delay = 20 * time.Millisecond
+	eng := engine.New()
+	eng.Register(engine.NewScanner(engine.ScannerInfo{
+		ID:   "mock-slow",
+		Name: "Mock Slow",
+	}, func() ([]scan.CategoryResult, error) {
+		time.Sleep(delay)
+		return nil, nil
+	}))
+
+	srv := New(socketPath, "test-1.0.0", eng)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "d1", Method: MethodScan})
+	responses := readAllResponses(t, conn, 5*time.Second)
+
+	var found bool
+	for _, resp := range responses {
+		if resp.Type != ResponseProgress {
+			continue
+		}
+		resultBytes, _ := json.Marshal(resp.Result)
+		var progress ScanProgress
+		if err := json.Unmarshal(resultBytes, &progress); err != nil {
+			t.Fatalf("unmarshal progress: %v", err)
+		}
+		if progress.Event != "scanner_done" {
+			continue
+		}
+		found = true
+		if progress.Duration < delay {
+			t.Errorf("expected duration_ns >= %v, got %v", delay, progress.Duration)
+		}
+	}
+	if !found {
+		t.Fatal("no scanner_done progress received")
+	}
+}
+
+func TestServer_ScanTimeoutReturnsPartialResult(t *testing.T) {
+	socketPath := filepath.Join(os.TempDir(), "mc-test-scan-timeout.sock")
+	os.Remove(socketPath)
+	defer os.Remove(socketPath)
+
+	release := make(chan struct{})
+	defer close(release)
+
+	eng := newMockTestEngine()
+	eng.ScanTimeout = 100 * time.Millisecond
+	eng.Register(engine.NewScanner(engine.ScannerInfo{
+		ID:   "mock-hung",
+		Name: "Mock Hung",
+	}, func() ([]scan.CategoryResult, error) {
+		<-release
+		return nil, nil
+	}))
+
+	srv := New(socketPath, "test-1.0.0", eng)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "t1", Method: MethodScan})
+	responses := readAllResponses(t, conn, 5*time.Second)
+
+	var hungErr string
+	for _, resp := range responses {
+		if resp.Type != ResponseProgress {
+			continue
+		}
+		resultBytes, _ := json.Marshal(resp.Result)
+		var progress ScanProgress
+		if err := json.Unmarshal(resultBytes, &progress); err != nil {
+			t.Fatalf("unmarshal progress: %v", err)
+		}
+		if progress.Event == "scanner_error" && progress.ScannerID == "mock-hung" {
+			hungErr = progress.Error
+		}
+	}
+	if !strings.Contains(hungErr, "timed out") {
+		t.Errorf("expected timed-out scanner_error for mock-hung, got %q", hungErr)
+	}
+
+	final := responses[len(responses)-1]
+	if final.Type != ResponseResult {
+		t.Fatalf("expected final result, got %s", final.Type)
+	}
+	resultBytes, _ := json.Marshal(final.Result)
+	var result ScanResult
+	if err := json.Unmarshal(resultBytes, &result); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if !result.TimedOut {
+		t.Error("expected timed_out in final result")
+	}
+	if len(result.Categories) != 2 {
+		t.Errorf("expected 2 completed categories, got %d", len(result.Categories))
+	}
+}
+
 func TestServer_PingIntegration(t *testing.T) {
 	socketPath := filepath.Join(t.TempDir(), "test.sock")
 	srv := New(socketPath, "test-1.0.0", newTestEngine())
@@ -800,6 +1158,95 @@ func TestServer_NonSocketFileBlocks(t *testing.T) {
 	}
 }
 
+func TestServer_CategoryDetail(t *testing.T) {
+	socketPath := filepath.Join(os.TempDir(), "mc-test-detail.sock")
+	os.Remove(socketPath)
+	defer os.Remove(socketPath)
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "s1", Method: MethodScan})
+	scanResponses := readAllResponses(t, conn, 5*time.Second)
+	resultBytes, _ := json.Marshal(scanResponses[len(scanResponses)-1].Result)
+	var scanResult struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(resultBytes, &scanResult); err != nil || scanResult.Token == "" {
+		t.Fatalf("scan returned no token: %v %s", err, resultBytes)
+	}
+
+	params, _ := json.Marshal(CategoryDetailParams{Token: scanResult.Token, CategoryID: "mock-caches"})
+	sendRequest(t, conn, Request{ID: "d1", Method: MethodCategoryDetail, Params: params})
+	resp := readAllResponses(t, conn, 2*time.Second)[0]
+	if resp.Type != ResponseResult || resp.ID != "d1" {
+		t.Fatalf("expected result for d1, got %+v", resp)
+	}
+	detailBytes, _ := json.Marshal(resp.Result)
+	var detail struct {
+		Category struct {
+			Category  string `json:"category"`
+			TotalSize int64  `json:"total_size"`
+			Entries   []struct {
+				Path      string  `json:"path"`
+				Size      int64   `json:"size"`
+				RiskLevel *string `json:"risk_level"`
+				Deletable *bool   `json:"deletable"`
+			} `json:"entries"`
+		} `json:"category"`
+	}
+	if err := json.Unmarshal(detailBytes, &detail); err != nil {
+		t.Fatalf("unmarshal detail: %v", err)
+	}
+	cat := detail.Category
+	if cat.Category != "mock-caches" || cat.TotalSize != 1024 || len(cat.Entries) != 2 {
+		t.Fatalf("expected only mock-caches with its 2 entries, got %s", detailBytes)
+	}
+	for i, want := range []string{"/tmp/mock-test/cache1", "/tmp/mock-test/cache2"} {
+		e := cat.Entries[i]
+		if e.Path != want || e.Size != 512 || e.RiskLevel == nil || e.Deletable == nil {
+			t.Errorf("entry %d: unexpected fields %+v", i, e)
+		}
+	}
+	if strings.Contains(string(detailBytes), "mock-browser") {
+		t.Errorf("detail must not include other categories: %s", detailBytes)
+	}
+
+	// Unknown category and wrong token are refused with their codes.
+	params, _ = json.Marshal(CategoryDetailParams{Token: scanResult.Token, CategoryID: "nope"})
+	sendRequest(t, conn, Request{ID: "d2", Method: MethodCategoryDetail, Params: params})
+	if resp := readAllResponses(t, conn, 2*time.Second)[0]; resp.Type != ResponseError || resp.Code != ErrCodeUnknownCategory {
+		t.Errorf("expected unknown_category error, got %+v", resp)
+	}
+	params, _ = json.Marshal(CategoryDetailParams{Token: "bogus", CategoryID: "mock-caches"})
+	sendRequest(t, conn, Request{ID: "d3", Method: MethodCategoryDetail, Params: params})
+	if resp := readAllResponses(t, conn, 2*time.Second)[0]; resp.Type != ResponseError || resp.Code != ErrCodeInvalidToken {
+		t.Errorf("expected invalid_token error, got %+v", resp)
+	}
+	sendRequest(t, conn, Request{ID: "d4", Method: MethodCategoryDetail})
+	if resp := readAllResponses(t, conn, 2*time.Second)[0]; resp.Type != ResponseError || !strings.Contains(resp.Error, "required") {
+		t.Errorf("expected missing params error, got %+v", resp)
+	}
+
+	// The token was not consumed: cleanup still accepts it.
+	params, _ = json.Marshal(CleanupParams{Token: scanResult.Token, Categories: []string{"mock-caches"}})
+	sendRequest(t, conn, Request{ID: "c1", Method: MethodCleanup, Params: params})
+	cleanupResponses := readAllResponses(t, conn, 5*time.Second)
+	if final := cleanupResponses[len(cleanupResponses)-1]; final.Type != ResponseResult {
+		t.Errorf("expected cleanup to accept the token after category_detail, got %+v", final)
+	}
+}
+
 func TestServer_CleanupWithoutScan(t *testing.T) {
 	socketPath := filepath.Join(t.TempDir(), "test.sock")
 	srv := New(socketPath, "test-1.0.0", newTestEngine())
@@ -872,15 +1319,95 @@ func TestServer_ActiveServerBlocks(t *testing.T) {
 	}
 }
 
-func TestServer_DisconnectDuringScan(t *testing.T) {
-	blocker := make(chan struct{})
-	eng := engine.New()
-	eng.Register(engine.NewScanner(engine.ScannerInfo{
-		ID:   "blocking",
-		Name: "Blocking Scanner",
-	}, func() ([]scan.CategoryResult, error) {
-		<-blocker // block until released
-		return []scan.CategoryResult{{
+// makeStaleSocket leaves a socket file at path with nothing listening.
+func makeStaleSocket(t *testing.T, path string) {
+	t.Helper()
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatalf("create socket: %v", err)
+	}
+	ln.(*net.UnixListener).SetUnlinkOnClose(false)
+	ln.Close()
+}
+
+func TestServer_BindRetriesAfterStaleSocketRace(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "race.sock")
+
+	// The first bind finds a stale socket file that appeared after the
+	// stale check, as if another instance bound and died in between.
+	orig := listenUnix
+	defer func() { listenUnix = orig }()
+	calls := 0
+	listenUnix = func(path string) (net.Listener, error) {
+		calls++
+		if calls == 1 {
+			makeStaleSocket(t, path)
+		}
+		return orig(path)
+	}
+
+	srv := New(socketPath, "test", newTestEngine())
+	ln, err := srv.listen()
+	if err != nil {
+		t.Fatalf("expected bind to succeed after retry, got: %v", err)
+	}
+	defer ln.Close()
+	if calls != 2 {
+		t.Errorf("expected 2 bind attempts, got %d", calls)
+	}
+}
+
+func TestServer_StaleSocketReplaced(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "stale.sock")
+	makeStaleSocket(t, socketPath)
+
+	srv := New(socketPath, "test", newTestEngine())
+	ln, err := srv.listen()
+	if err != nil {
+		t.Fatalf("expected stale socket to be replaced, got: %v", err)
+	}
+	defer ln.Close()
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial new listener: %v", err)
+	}
+	conn.Close()
+}
+
+func TestServer_LiveListenerKept(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "live.sock")
+	ln, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatalf("create listener: %v", err)
+	}
+	defer ln.Close()
+
+	srv := New(socketPath, "test", newTestEngine())
+	if _, err := srv.listen(); err == nil || !strings.Contains(err.Error(), "already listening") {
+		t.Fatalf("expected 'already listening' error, got: %v", err)
+	}
+
+	// The live socket is left in place and still accepts connections.
+	if _, err := os.Lstat(socketPath); err != nil {
+		t.Fatalf("expected socket file to remain: %v", err)
+	}
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("expected existing listener to still accept: %v", err)
+	}
+	conn.Close()
+}
+
+func TestServer_DisconnectDuringScan(t *testing.T) {
+	blocker := make(chan struct{})
+	eng := engine.New()
+	eng.Register(engine.NewScanner(engine.ScannerInfo{
+		ID:   "blocking",
+		Name: "Blocking Scanner",
+	}, func() ([]scan.CategoryResult, error) {
+		<-blocker // block until released
+		return []scan.CategoryResult{{
 			Category:    "blocking-cat",
 			Description: "Blocking Category",
 			TotalSize:   100,
@@ -944,6 +1471,87 @@ func TestServer_DisconnectDuringScan(t *testing.T) {
 	}
 }
 
+func TestServer_DeadClientReleasesBusy(t *testing.T) {
+	blocker := make(chan struct{})
+	eng := engine.New()
+	eng.Register(engine.NewScanner(engine.ScannerInfo{
+		ID:   "blocking",
+		Name: "Blocking Scanner",
+	}, func() ([]scan.CategoryResult, error) {
+		<-blocker // block until released
+		return []scan.CategoryResult{{
+			Category:    "blocking-cat",
+			Description: "Blocking Category",
+			TotalSize:   100,
+			Entries:     []scan.ScanEntry{{Path: "/tmp/blocking-test/f1", Description: "File 1", Size: 100}},
+		}}, nil
+	}))
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", eng)
+	srv.OrphanTimeout = 100 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	sendRequest(t, conn, Request{ID: "s1", Method: MethodScan})
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	sc := bufio.NewScanner(conn)
+	if !sc.Scan() {
+		t.Fatalf("failed to read operation_start: %v", sc.Err())
+	}
+
+	// Kill the client while its scanner is stuck.
+	conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for srv.busy.Load() {
+		if time.Now().After(deadline) {
+			t.Fatal("busy flag still set 2s after the client died")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// A fresh connection can start a new scan.
+	close(blocker)
+	conn2, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	defer conn2.Close()
+	sendRequest(t, conn2, Request{ID: "s2", Method: MethodScan})
+	responses := readAllResponses(t, conn2, 5*time.Second)
+	if len(responses) == 0 {
+		t.Fatal("no responses to the second scan")
+	}
+	if final := responses[len(responses)-1]; final.Type != ResponseResult {
+		t.Errorf("second scan ended with %+v, want a result", final)
+	}
+}
+
+func TestDeadlineWriterCancelsOnFailure(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	cancelled := false
+	w := NewNDJSONWriter(&deadlineWriter{conn: server, timeout: 50 * time.Millisecond, failed: func() { cancelled = true }})
+
+	// The client never reads, so the write times out.
+	if err := w.WriteResult("x", "hello"); err == nil {
+		t.Fatal("expected a write timeout")
+	}
+	if !cancelled {
+		t.Error("a failed write should cancel the connection")
+	}
+	client.Close()
+}
+
 func TestServer_DisconnectDuringCleanup(t *testing.T) {
 	// Create temp files that cleanup can actually remove.
 	tmpDir := t.TempDir()
@@ -1114,7 +1722,9 @@ func TestServer_CleanupWithInvalidToken(t *testing.T) {
 	params, _ := json.Marshal(CleanupParams{Token: "bogus-token"})
 	sendRequest(t, conn, Request{ID: "cl1", Method: MethodCleanup, Params: params})
 
-	resp := readResponse(t, conn)
+	// The error follows the operation_start progress event.
+	responses := readAllResponses(t, conn, 2*time.Second)
+	resp := responses[len(responses)-1]
 	if resp.Type != ResponseError {
 		t.Errorf("expected error type, got %q", resp.Type)
 	}
@@ -1122,3 +1732,843 @@ func TestServer_CleanupWithInvalidToken(t *testing.T) {
 		t.Errorf("expected 'invalid token' error, got: %q", resp.Error)
 	}
 }
+
+func TestServer_ReportOnlyRefusesCleanup(t *testing.T) {
+	socketPath := filepath.Join(os.TempDir(), "mc-test-report-only.sock")
+	os.Remove(socketPath)
+	defer os.Remove(socketPath)
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	srv.ReportOnly = true
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Scans work normally in report-only mode.
+	sendRequest(t, conn, Request{ID: "s1", Method: MethodScan})
+	responses := readAllResponses(t, conn, 5*time.Second)
+	final := responses[len(responses)-1]
+	if final.Type != ResponseResult {
+		t.Fatalf("expected scan result, got %q (%s)", final.Type, final.Error)
+	}
+	resultBytes, _ := json.Marshal(final.Result)
+	var scanResult struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(resultBytes, &scanResult); err != nil {
+		t.Fatalf("unmarshal scan result: %v", err)
+	}
+	if scanResult.Token == "" {
+		t.Fatal("expected non-empty token")
+	}
+
+	// Cleanup is refused even with a valid token.
+	params, _ := json.Marshal(CleanupParams{Token: scanResult.Token})
+	sendRequest(t, conn, Request{ID: "c1", Method: MethodCleanup, Params: params})
+	responses = readAllResponses(t, conn, 5*time.Second)
+	if len(responses) != 1 {
+		t.Fatalf("expected a single response, got %d", len(responses))
+	}
+	resp := responses[0]
+	if resp.Type != ResponseError {
+		t.Errorf("expected error type, got %q", resp.Type)
+	}
+	if resp.Code != ErrCodeCleanupDisabled {
+		t.Errorf("expected code %q, got %q", ErrCodeCleanupDisabled, resp.Code)
+	}
+}
+
+func TestServer_CleanupProgressIncludesAvailableBytes(t *testing.T) {
+	dir := t.TempDir()
+	var entries []scan.ScanEntry
+	for i := 0; i < 3; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("cache%d", i))
+		if err := os.WriteFile(path, make([]byte, 4096), 0644); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, scan.ScanEntry{Path: path, Description: filepath.Base(path), Size: 4096})
+	}
+	eng := engine.New()
+	eng.Register(engine.NewScanner(engine.ScannerInfo{ID: "real", Name: "Real Files"}, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{{
+			Category:    "real-caches",
+			Description: "Real Caches",
+			Entries:     entries,
+			TotalSize:   3 * 4096,
+		}}, nil
+	}))
+
+	socketPath := filepath.Join(os.TempDir(), "mc-test-disk-free.sock")
+	os.Remove(socketPath)
+	defer os.Remove(socketPath)
+	srv := New(socketPath, "test-1.0.0", eng)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "s1", Method: MethodScan})
+	scanResponses := readAllResponses(t, conn, 5*time.Second)
+	resultBytes, _ := json.Marshal(scanResponses[len(scanResponses)-1].Result)
+	var scanResult struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(resultBytes, &scanResult); err != nil || scanResult.Token == "" {
+		t.Fatalf("scan returned no token: %v", err)
+	}
+
+	params, _ := json.Marshal(CleanupParams{Token: scanResult.Token})
+	sendRequest(t, conn, Request{ID: "c1", Method: MethodCleanup, Params: params})
+	responses := readAllResponses(t, conn, 5*time.Second)
+
+	var sampled int
+	for _, resp := range responses {
+		if resp.Type != ResponseProgress {
+			continue
+		}
+		raw, _ := json.Marshal(resp.Result)
+		var fields map[string]interface{}
+		if err := json.Unmarshal(raw, &fields); err != nil {
+			t.Fatalf("unmarshal progress: %v", err)
+		}
+		v, ok := fields["available_bytes"]
+		if !ok {
+			continue
+		}
+		avail, ok := v.(float64)
+		if !ok || avail <= 0 {
+			t.Errorf("implausible available_bytes: %v", v)
+		}
+		sampled++
+	}
+	if sampled == 0 {
+		t.Error("expected at least one cleanup progress event with available_bytes")
+	}
+
+	for _, e := range entries {
+		if _, err := os.Stat(e.Path); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed", e.Path)
+		}
+	}
+}
+
+func TestServer_CleanupProgressEveryBatchesEvents(t *testing.T) {
+	const total = 100
+	dir := t.TempDir()
+	var entries []scan.ScanEntry
+	for i := 0; i < total; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("cache%03d", i))
+		if err := os.WriteFile(path, make([]byte, 10), 0644); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, scan.ScanEntry{Path: path, Description: filepath.Base(path), Size: 10})
+	}
+	eng := engine.New()
+	eng.Register(engine.NewScanner(engine.ScannerInfo{ID: "many", Name: "Many Files"}, func() ([]scan.CategoryResult, error) {
+		return []scan.CategoryResult{{
+			Category:    "many-caches",
+			Description: "Many Caches",
+			Entries:     entries,
+			TotalSize:   total * 10,
+		}}, nil
+	}))
+
+	socketPath := filepath.Join(os.TempDir(), "mc-test-progress-every.sock")
+	os.Remove(socketPath)
+	defer os.Remove(socketPath)
+	srv := New(socketPath, "test-1.0.0", eng)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "s1", Method: MethodScan})
+	scanResponses := readAllResponses(t, conn, 5*time.Second)
+	resultBytes, _ := json.Marshal(scanResponses[len(scanResponses)-1].Result)
+	var scanResult struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(resultBytes, &scanResult); err != nil || scanResult.Token == "" {
+		t.Fatalf("scan returned no token: %v", err)
+	}
+
+	params, _ := json.Marshal(CleanupParams{Token: scanResult.Token, ProgressEvery: 10})
+	sendRequest(t, conn, Request{ID: "c1", Method: MethodCleanup, Params: params})
+	responses := readAllResponses(t, conn, 5*time.Second)
+
+	var entryEvents, lastCurrent int
+	for _, resp := range responses {
+		if resp.Type != ResponseProgress {
+			continue
+		}
+		raw, _ := json.Marshal(resp.Result)
+		var p CleanupProgress
+		if err := json.Unmarshal(raw, &p); err != nil {
+			t.Fatalf("unmarshal progress: %v", err)
+		}
+		if p.Event == "cleanup_entry" {
+			entryEvents++
+			lastCurrent = p.Current
+		}
+	}
+	// One per batch of 10, plus the first entry and a few time or
+	// free-space samples.
+	if entryEvents < total/10 || entryEvents > total/10+5 {
+		t.Errorf("expected about %d cleanup_entry events, got %d", total/10, entryEvents)
+	}
+	if lastCurrent != total {
+		t.Errorf("expected last progress current %d, got %d", total, lastCurrent)
+	}
+
+	final := responses[len(responses)-1]
+	if final.Type != ResponseResult {
+		t.Fatalf("expected result type, got %q", final.Type)
+	}
+	raw, _ := json.Marshal(final.Result)
+	var result CleanupResult
+	if err := json.Unmarshal(raw, &result); err != nil {
+		t.Fatalf("unmarshal cleanup result: %v", err)
+	}
+	if result.Removed != total || result.Failed != 0 || result.BytesFreed != total*10 {
+		t.Errorf("expected %d removed, 0 failed, %d bytes freed, got %+v", total, total*10, result)
+	}
+}
+
+func TestServer_AttachAfterReconnect(t *testing.T) {
+	blocker := make(chan struct{})
+	eng := engine.New()
+	eng.Register(engine.NewScanner(engine.ScannerInfo{
+		ID:   "blocking",
+		Name: "Blocking Scanner",
+	}, func() ([]scan.CategoryResult, error) {
+		<-blocker // block until released
+		return []scan.CategoryResult{{
+			Category:    "blocking-cat",
+			Description: "Blocking Category",
+			TotalSize:   100,
+			Entries:     []scan.ScanEntry{{Path: "/tmp/blocking-test/f1", Description: "File 1", Size: 100}},
+		}}, nil
+	}))
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", eng)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	sendRequest(t, conn, Request{ID: "s1", Method: MethodScan})
+
+	// The first progress event carries the operation ID.
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	sc := bufio.NewScanner(conn)
+	if !sc.Scan() {
+		t.Fatalf("failed to read operation_start: %v", sc.Err())
+	}
+	var first struct {
+		Type   string         `json:"type"`
+		Result OperationStart `json:"result"`
+	}
+	if err := json.Unmarshal(sc.Bytes(), &first); err != nil {
+		t.Fatalf("unmarshal operation_start: %v", err)
+	}
+	if first.Type != ResponseProgress || first.Result.Event != "operation_start" || first.Result.OperationID == "" {
+		t.Fatalf("expected operation_start progress, got %s", sc.Text())
+	}
+
+	// Disconnect while the scan is still blocked, then reattach.
+	conn.Close()
+
+	conn2, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("reconnect: %v", err)
+	}
+	defer conn2.Close()
+
+	params, _ := json.Marshal(AttachParams{OperationID: first.Result.OperationID})
+	sendRequest(t, conn2, Request{ID: "a1", Method: MethodAttach, Params: params})
+
+	// Give the server time to accept the attach before the scan finishes.
+	time.Sleep(100 * time.Millisecond)
+	close(blocker)
+
+	responses := readAllResponses(t, conn2, 5*time.Second)
+	if len(responses) == 0 {
+		t.Fatal("no responses after attach")
+	}
+	var sawDone bool
+	for _, resp := range responses {
+		if resp.ID != "a1" {
+			t.Errorf("response ID = %q, want a1", resp.ID)
+		}
+		if resp.Type == ResponseProgress {
+			b, _ := json.Marshal(resp.Result)
+			var p ScanProgress
+			_ = json.Unmarshal(b, &p)
+			if p.Event == "scanner_done" {
+				sawDone = true
+			}
+		}
+	}
+	if !sawDone {
+		t.Error("expected the remaining scanner_done progress after attach")
+	}
+
+	final := responses[len(responses)-1]
+	if final.Type != ResponseResult {
+		t.Fatalf("expected final result, got %+v", final)
+	}
+	b, _ := json.Marshal(final.Result)
+	var result struct {
+		TotalSize int64  `json:"total_size"`
+		Token     string `json:"token"`
+	}
+	if err := json.Unmarshal(b, &result); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if result.TotalSize != 100 || result.Token == "" {
+		t.Errorf("result = %+v, want total_size 100 and a token", result)
+	}
+
+	// Attaching again after completion replays the final result.
+	sendRequest(t, conn2, Request{ID: "a2", Method: MethodAttach, Params: params})
+	again := readAllResponses(t, conn2, 2*time.Second)
+	if len(again) != 1 || again[0].Type != ResponseResult || again[0].ID != "a2" {
+		t.Errorf("expected buffered result for a2, got %+v", again)
+	}
+}
+
+func TestServer_AttachUnknownOperation(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	params, _ := json.Marshal(AttachParams{OperationID: "nope"})
+	sendRequest(t, conn, Request{ID: "a1", Method: MethodAttach, Params: params})
+	resp := readResponse(t, conn)
+	if resp.Type != ResponseError || resp.Code != ErrCodeUnknownOperation {
+		t.Errorf("expected %s error, got %+v", ErrCodeUnknownOperation, resp)
+	}
+}
+
+// categoryIDs sends a categories request and returns the scanner IDs.
+func categoryIDs(t *testing.T, conn net.Conn, id string) []string {
+	t.Helper()
+	sendRequest(t, conn, Request{ID: id, Method: MethodCategories})
+	resp := readResponse(t, conn)
+	if resp.Type != ResponseResult {
+		t.Fatalf("expected categories result, got %+v", resp)
+	}
+	data, _ := json.Marshal(resp.Result)
+	var cats CategoriesResult
+	if err := json.Unmarshal(data, &cats); err != nil {
+		t.Fatalf("unmarshal categories: %v", err)
+	}
+	ids := make([]string, len(cats.Scanners))
+	for i, c := range cats.Scanners {
+		ids[i] = c.ID
+	}
+	return ids
+}
+
+func TestServer_ReloadAppliesNewConfig(t *testing.T) {
+	// extraCache stands in for the configuration file: reloading picks up an
+	// extra cache scanner once it is enabled.
+	extraCache := false
+	build := func() (*engine.Engine, error) {
+		eng := newMockTestEngine()
+		if extraCache {
+			eng.Register(engine.NewScanner(engine.ScannerInfo{ID: "extra-cache", Name: "Extra Cache"}, func() ([]scan.CategoryResult, error) {
+				return nil, nil
+			}))
+		}
+		return eng, nil
+	}
+	eng, _ := build()
+
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", eng)
+	srv.Reload = build
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	if ids := categoryIDs(t, conn, "c1"); len(ids) != 2 {
+		t.Fatalf("expected 2 scanners before reload, got %v", ids)
+	}
+
+	extraCache = true
+	sendRequest(t, conn, Request{ID: "r1", Method: MethodReload})
+	if resp := readResponse(t, conn); resp.Type != ResponseResult {
+		t.Fatalf("expected reload result, got %+v", resp)
+	}
+
+	ids := categoryIDs(t, conn, "c2")
+	if len(ids) != 3 || ids[2] != "extra-cache" {
+		t.Errorf("expected extra-cache after reload, got %v", ids)
+	}
+}
+
+func TestServer_ReloadKeepsScanTokens(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	srv.Reload = func() (*engine.Engine, error) {
+		return newMockTestEngine(), nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "s1", Method: MethodScan})
+	scanResponses := readAllResponses(t, conn, 5*time.Second)
+	resultBytes, _ := json.Marshal(scanResponses[len(scanResponses)-1].Result)
+	var scanResult struct {
+		Token string `json:"token"`
+	}
+	if err := json.Unmarshal(resultBytes, &scanResult); err != nil || scanResult.Token == "" {
+		t.Fatalf("expected a scan token, got %s (%v)", resultBytes, err)
+	}
+
+	sendRequest(t, conn, Request{ID: "r1", Method: MethodReload})
+	if resp := readResponse(t, conn); resp.Type != ResponseResult {
+		t.Fatalf("expected reload result, got %+v", resp)
+	}
+
+	params, _ := json.Marshal(CleanupParams{Token: scanResult.Token})
+	sendRequest(t, conn, Request{ID: "c1", Method: MethodCleanup, Params: params})
+	cleanupResponses := readAllResponses(t, conn, 5*time.Second)
+	if final := cleanupResponses[len(cleanupResponses)-1]; final.Type != ResponseResult {
+		t.Errorf("expected the pre-reload token to be accepted, got %+v", final)
+	}
+}
+
+func TestServer_ReloadFailureKeepsEngine(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	srv.Reload = func() (*engine.Engine, error) {
+		return nil, fmt.Errorf("freshness.json: invalid duration")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "r1", Method: MethodReload})
+	resp := readResponse(t, conn)
+	if resp.Type != ResponseError || !strings.Contains(resp.Error, "invalid duration") {
+		t.Errorf("expected reload error, got %+v", resp)
+	}
+	if ids := categoryIDs(t, conn, "c1"); len(ids) != 2 {
+		t.Errorf("expected the old engine kept after a failed reload, got %v", ids)
+	}
+}
+
+func TestServer_ReloadUnsupported(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	sendRequest(t, conn, Request{ID: "r1", Method: MethodReload})
+	if resp := readResponse(t, conn); resp.Type != ResponseError {
+		t.Errorf("expected error without a Reload function, got %+v", resp)
+	}
+}
+
+// scanCategories runs a scan and returns the category IDs of its result.
+func scanCategories(t *testing.T, conn net.Conn, id string) []string {
+	t.Helper()
+	sendRequest(t, conn, Request{ID: id, Method: MethodScan})
+	responses := readAllResponses(t, conn, 5*time.Second)
+	final := responses[len(responses)-1]
+	if final.Type != ResponseResult {
+		t.Fatalf("expected scan result, got %+v", final)
+	}
+	data, _ := json.Marshal(final.Result)
+	var result struct {
+		Categories []struct {
+			Category string `json:"category"`
+		} `json:"categories"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		t.Fatalf("unmarshal scan result: %v", err)
+	}
+	ids := make([]string, len(result.Categories))
+	for i, c := range result.Categories {
+		ids[i] = c.Category
+	}
+	return ids
+}
+
+// setEnabled sends a set_enabled request and returns the updated
+// categories.
+func setEnabled(t *testing.T, conn net.Conn, id string, params SetEnabledParams) CategoriesResult {
+	t.Helper()
+	data, _ := json.Marshal(params)
+	sendRequest(t, conn, Request{ID: id, Method: MethodSetEnabled, Params: data})
+	resp := readResponse(t, conn)
+	if resp.Type != ResponseResult {
+		t.Fatalf("expected set_enabled result, got %+v", resp)
+	}
+	data, _ = json.Marshal(resp.Result)
+	var cats CategoriesResult
+	if err := json.Unmarshal(data, &cats); err != nil {
+		t.Fatalf("unmarshal categories: %v", err)
+	}
+	return cats
+}
+
+func TestServer_SetEnabledSkipsDisabledScanner(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	var saved [][]string
+	srv.SaveDisabled = func(ids []string) error {
+		saved = append(saved, ids)
+		return nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	cats := setEnabled(t, conn, "d1", SetEnabledParams{ScannerIDs: []string{"mock-browser"}, Enabled: false})
+	for _, c := range cats.Scanners {
+		if c.Enabled != (c.ID != "mock-browser") {
+			t.Errorf("scanner %s enabled = %v after disabling mock-browser", c.ID, c.Enabled)
+		}
+	}
+	if ids := scanCategories(t, conn, "s1"); len(ids) != 1 || ids[0] != "mock-caches" {
+		t.Errorf("expected only mock-caches with mock-browser disabled, got %v", ids)
+	}
+
+	setEnabled(t, conn, "e1", SetEnabledParams{ScannerIDs: []string{"mock-browser"}, Enabled: true})
+	if ids := scanCategories(t, conn, "s2"); len(ids) != 2 {
+		t.Errorf("expected mock-browser-data back after re-enabling, got %v", ids)
+	}
+
+	if len(saved) != 2 || len(saved[0]) != 1 || saved[0][0] != "mock-browser" || len(saved[1]) != 0 {
+		t.Errorf("saved selections = %v, want [[mock-browser] []]", saved)
+	}
+}
+
+func TestServer_SetEnabledUnknownScanner(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	params, _ := json.Marshal(SetEnabledParams{ScannerIDs: []string{"nope"}})
+	sendRequest(t, conn, Request{ID: "u1", Method: MethodSetEnabled, Params: params})
+	if resp := readResponse(t, conn); resp.Type != ResponseError || resp.Code != ErrCodeUnknownScanner {
+		t.Errorf("expected unknown_scanner error, got %+v", resp)
+	}
+}
+
+func TestServer_SubscribeReceivesOtherClientsScan(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("unix", socketPath)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	scanner, watcher := dial(), dial()
+	for _, c := range []net.Conn{scanner, watcher} {
+		sendRequest(t, c, Request{ID: "sub", Method: MethodSubscribe})
+		if resp := readResponse(t, c); resp.Type != ResponseResult {
+			t.Fatalf("expected subscribe result, got %+v", resp)
+		}
+	}
+
+	sendRequest(t, scanner, Request{ID: "s1", Method: MethodScan})
+	own := readAllResponses(t, scanner, 5*time.Second)
+	if final := own[len(own)-1]; final.Type != ResponseResult || final.ID != "s1" {
+		t.Fatalf("scanning client ended with %+v, want its result", final)
+	}
+	for _, resp := range own {
+		if resp.ID != "s1" {
+			t.Errorf("scanning client got a duplicate broadcast: %+v", resp)
+		}
+	}
+
+	// The other subscriber sees every progress event of the scan under
+	// its subscribe ID, ending with operation_done.
+	_ = watcher.SetReadDeadline(time.Now().Add(5 * time.Second))
+	sc := bufio.NewScanner(watcher)
+	var events []string
+	for sc.Scan() {
+		var resp Response
+		if err := json.Unmarshal(sc.Bytes(), &resp); err != nil {
+			t.Fatalf("unmarshal: %v", err)
+		}
+		if resp.Type != ResponseProgress || resp.ID != "sub" {
+			t.Fatalf("subscriber got %+v, want progress under its subscribe ID", resp)
+		}
+		event, _ := resp.Result.(map[string]any)["event"].(string)
+		events = append(events, event)
+		if event == "operation_done" {
+			break
+		}
+	}
+	if len(events) != len(own) {
+		// own holds the scan's progress events and its result; the
+		// subscriber gets operation_done in place of the result.
+		t.Errorf("subscriber got %d events %v, want %d", len(events), events, len(own))
+	}
+	want := []string{"operation_start", "scan_start", "scanner_start"}
+	for i, w := range want {
+		if i >= len(events) || events[i] != w {
+			t.Fatalf("subscriber events = %v, want them to start with %v", events, want)
+		}
+	}
+	if !slices.Contains(events, "scan_complete") {
+		t.Errorf("subscriber events %v lack scan_complete", events)
+	}
+}
+
+func TestServer_UnsubscribeOnDisconnect(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	sendRequest(t, conn, Request{ID: "sub", Method: MethodSubscribe})
+	readResponse(t, conn)
+	conn.Close()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		srv.subMu.Lock()
+		n := len(srv.subs)
+		srv.subMu.Unlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("subscription kept after the client disconnected")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestServer_SubscribedConnectionOutlivesIdleTimeout(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	srv.IdleTimeout = 100 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	dial := func() net.Conn {
+		conn, err := net.Dial("unix", socketPath)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		t.Cleanup(func() { conn.Close() })
+		return conn
+	}
+	watcher, idle := dial(), dial()
+	sendRequest(t, watcher, Request{ID: "sub", Method: MethodSubscribe})
+	if resp := readResponse(t, watcher); resp.Type != ResponseResult {
+		t.Fatalf("expected subscribe result, got %+v", resp)
+	}
+
+	time.Sleep(3 * srv.IdleTimeout)
+
+	// The idle connection is closed, the subscribed one still answers.
+	_ = idle.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := idle.Read(make([]byte, 1)); err == nil {
+		t.Error("idle connection was not closed")
+	}
+	sendRequest(t, watcher, Request{ID: "p1", Method: MethodPing})
+	if resp := readResponse(t, watcher); resp.Type != ResponseResult || resp.ID != "p1" {
+		t.Errorf("subscribed connection got %+v, want the ping result", resp)
+	}
+}
+
+func TestServer_SlowSubscriberIsDropped(t *testing.T) {
+	srv := New(filepath.Join(t.TempDir(), "test.sock"), "test-1.0.0", newMockTestEngine())
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	w := NewNDJSONWriter(server)
+	h := NewHandler(srv)
+	// The client never reads, so the subscriber's writer blocks.
+	h.handleSubscribe(Request{ID: "sub"}, w)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i <= subscriberQueue+1; i++ {
+			srv.broadcast(map[string]string{"event": "tick"}, nil)
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("broadcast blocked on a subscriber that does not read")
+	}
+	if srv.subscribed(w) {
+		t.Error("subscriber that fell behind was kept")
+	}
+}
+
+func TestServer_DroppedSubscriberIdlesOut(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "test.sock")
+	srv := New(socketPath, "test-1.0.0", newMockTestEngine())
+	srv.IdleTimeout = 100 * time.Millisecond
+	srv.WriteTimeout = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer srv.Shutdown()
+
+	go srv.Serve(ctx)
+	waitForSocket(t, socketPath)
+
+	conn, err := net.Dial("unix", socketPath)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	sendRequest(t, conn, Request{ID: "sub", Method: MethodSubscribe})
+	if resp := readResponse(t, conn); resp.Type != ResponseResult {
+		t.Fatalf("expected subscribe result, got %+v", resp)
+	}
+
+	subscribers := func() int {
+		srv.subMu.Lock()
+		defer srv.subMu.Unlock()
+		return len(srv.subs)
+	}
+	// The client stops reading; events large enough to fill the socket
+	// buffer back up the queue until the subscriber is dropped.
+	event := map[string]string{"event": strings.Repeat("x", 64<<10)}
+	for i := 0; i <= subscriberQueue+64 && subscribers() > 0; i++ {
+		srv.broadcast(event, nil)
+	}
+	if subscribers() != 0 {
+		t.Fatal("subscriber that fell behind was kept")
+	}
+
+	// Once the backlog is read, the connection closes after IdleTimeout.
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	if _, err := io.Copy(io.Discard, conn); err != nil {
+		t.Errorf("dropped subscriber was not closed after the idle timeout: %v", err)
+	}
+}
@@ -0,0 +1,112 @@
+package server
+
+import "github.com/sp3esu/mac-cleaner/internal/logging"
+
+// subscriberQueue bounds the events waiting to be written to one
+// subscriber. A subscriber that falls this far behind is unsubscribed
+// rather than slowing down the operation that broadcasts them.
+const subscriberQueue = 256
+
+// subscriber is a connection that sent subscribe. Its events are queued
+// and written by its own goroutine, so a slow client never blocks the
+// broadcast.
+type subscriber struct {
+	// id is the ID of the subscribe request, carried by every event.
+	id    string
+	queue chan Response
+	// dropped is closed when the subscription ends, so the connection
+	// goes back to closing when idle.
+	dropped chan struct{}
+}
+
+// run writes the queued responses to w until the queue is closed.
+func (sub *subscriber) run(w *NDJSONWriter) {
+	for resp := range sub.queue {
+		_ = w.Write(resp)
+	}
+}
+
+// OperationDone is the state-change event subscribers receive when a scan
+// or cleanup finishes. The final result is not included; send attach with
+// OperationID within AttachRetention to fetch it.
+type OperationDone struct {
+	// Event is always "operation_done".
+	Event       string `json:"event"`
+	OperationID string `json:"operation_id"`
+	// Error is the operation's error, empty when it produced a result.
+	Error string `json:"error,omitempty"`
+}
+
+// handleSubscribe registers the connection to receive the progress events
+// of every scan and cleanup, whichever client started it, as progress
+// responses carrying the subscribe request's ID. The subscription lasts
+// until the connection closes or falls subscriberQueue events behind;
+// subscribing again only changes the ID. A subscribed connection is not
+// closed for being idle.
+func (h *Handler) handleSubscribe(req Request, w *NDJSONWriter) {
+	s := h.server
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	sub, ok := s.subs[w]
+	if !ok {
+		sub = &subscriber{queue: make(chan Response, subscriberQueue), dropped: make(chan struct{})}
+		s.subs[w] = sub
+		go sub.run(w)
+	}
+	sub.id = req.ID
+	// The confirmation is queued so no event overtakes it.
+	s.enqueue(w, sub, Response{ID: req.ID, Type: ResponseResult, Result: map[string]string{"status": "subscribed"}})
+}
+
+// subscribed reports whether w has a subscription.
+func (s *Server) subscribed(w *NDJSONWriter) bool {
+	return s.subscription(w) != nil
+}
+
+// subscription returns w's subscriber, or nil if w is not subscribed.
+func (s *Server) subscription(w *NDJSONWriter) *subscriber {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	return s.subs[w]
+}
+
+// drop ends sub, the subscription of w. s.subMu must be held.
+func (s *Server) drop(w *NDJSONWriter, sub *subscriber) {
+	delete(s.subs, w)
+	close(sub.queue)
+	close(sub.dropped)
+}
+
+// unsubscribe drops w's subscription, if any.
+func (s *Server) unsubscribe(w *NDJSONWriter) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	if sub, ok := s.subs[w]; ok {
+		s.drop(w, sub)
+	}
+}
+
+// enqueue queues resp for sub, the subscriber on w, without blocking. A
+// subscriber whose queue is full is unsubscribed. s.subMu must be held.
+func (s *Server) enqueue(w *NDJSONWriter, sub *subscriber, resp Response) {
+	select {
+	case sub.queue <- resp:
+	default:
+		logging.Warn("dropping subscriber that fell behind", "queued", subscriberQueue)
+		s.drop(w, sub)
+	}
+}
+
+// broadcast queues a progress event for every subscriber except skip, the
+// connection already following the operation. It never blocks on a
+// client.
+func (s *Server) broadcast(v any, skip *NDJSONWriter) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+	for w, sub := range s.subs {
+		if w == skip {
+			continue
+		}
+		s.enqueue(w, sub, Response{ID: sub.id, Type: ResponseProgress, Result: v})
+	}
+}
@@ -2,20 +2,29 @@
 package spinner
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"golang.org/x/term"
 )
 
 // frames is a braille-dot spinner animation (fixed-width characters).
 var frames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 
 // Spinner wraps briandowns/spinner with an enable/disable toggle.
-// When disabled, all methods are safe no-ops.
+// When disabled, all methods are safe no-ops. In plain mode it never
+// animates and prints status lines instead.
 type Spinner struct {
 	inner   *spinner.Spinner
 	enabled bool
+
+	// plain receives status lines in plain mode; nil otherwise.
+	plain   io.Writer
+	message string
+	active  bool
 }
 
 // New creates a spinner writing to stderr. When enabled is false, all methods
@@ -29,11 +38,32 @@ func New(message string, enabled bool) *Spinner {
 	return &Spinner{inner: s, enabled: true}
 }
 
+// NewPlain creates a spinner for CI logs and piped output: it writes no
+// control sequences, and each Start prints the current message to w as a
+// plain line. Message updates while it runs are not printed.
+func NewPlain(w io.Writer, message string) *Spinner {
+	return &Spinner{enabled: true, plain: w, message: message}
+}
+
+// IsTerminal reports whether w is a terminal. Writers without a file
+// descriptor, such as buffers, are not.
+func IsTerminal(w io.Writer) bool {
+	f, ok := w.(interface{ Fd() uintptr })
+	return ok && term.IsTerminal(int(f.Fd()))
+}
+
 // Start begins the spinner animation.
 func (s *Spinner) Start() {
 	if !s.enabled {
 		return
 	}
+	if s.plain != nil {
+		if !s.active {
+			fmt.Fprintln(s.plain, s.message)
+			s.active = true
+		}
+		return
+	}
 	s.inner.Start()
 }
 
@@ -42,6 +72,10 @@ func (s *Spinner) Stop() {
 	if !s.enabled {
 		return
 	}
+	if s.plain != nil {
+		s.active = false
+		return
+	}
 	s.inner.Stop()
 }
 
@@ -50,6 +84,10 @@ func (s *Spinner) UpdateMessage(msg string) {
 	if !s.enabled {
 		return
 	}
+	if s.plain != nil {
+		s.message = msg
+		return
+	}
 	s.inner.Suffix = " " + msg
 }
 
@@ -58,5 +96,8 @@ func (s *Spinner) Active() bool {
 	if !s.enabled {
 		return false
 	}
+	if s.plain != nil {
+		return s.active
+	}
 	return s.inner.Active()
 }
@@ -1,6 +1,8 @@
 package spinner
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -66,3 +68,37 @@ func TestDisabled(t *testing.T) {
 		t.Fatal("disabled spinner should never be active after Start")
 	}
 }
+
+func TestIsTerminalBuffer(t *testing.T) {
+	if IsTerminal(&bytes.Buffer{}) {
+		t.Fatal("a buffer is not a terminal")
+	}
+}
+
+func TestPlainPrintsStatusLines(t *testing.T) {
+	var buf bytes.Buffer
+	s := NewPlain(&buf, "Scanning...")
+	if s.inner != nil {
+		t.Fatal("plain spinner must not create an animated spinner")
+	}
+
+	s.UpdateMessage("Scanning system caches...")
+	s.Start()
+	if !s.Active() {
+		t.Fatal("plain spinner should be active after Start")
+	}
+	// Updates while running, such as size progress, are not printed.
+	s.UpdateMessage("Scanning system caches... 1.2 GB")
+	s.Stop()
+	s.UpdateMessage("Scanning browser data...")
+	s.Start()
+	s.Stop()
+
+	want := "Scanning system caches...\nScanning browser data...\n"
+	if buf.String() != want {
+		t.Fatalf("expected %q, got %q", want, buf.String())
+	}
+	if strings.ContainsRune(buf.String(), '\r') || strings.Contains(buf.String(), "\x1b") {
+		t.Fatalf("plain output must not contain control sequences: %q", buf.String())
+	}
+}
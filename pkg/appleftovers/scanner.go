@@ -1,5 +1,5 @@
-// Package appleftovers provides scanners for orphaned app preferences,
-// iOS device backups, and old Downloads files on macOS.
+// Package appleftovers provides scanners for orphaned app preferences and
+// Group Containers, iOS device backups, and old Downloads files on macOS.
 package appleftovers
 
 import (
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sp3esu/mac-cleaner/internal/logging"
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
@@ -26,26 +27,68 @@ func defaultRunner(ctx context.Context, name string, args ...string) ([]byte, er
 	return cmd.Output()
 }
 
-// Scan discovers orphaned app preferences, iOS device backups, and old
-// Downloads files. Missing directories are silently skipped. No files are
-// modified.
+// Scan discovers orphaned app preferences and Group Containers, iOS device
+// backups, and old Downloads files. Missing directories are silently
+// skipped. No files are modified.
 func Scan() ([]scan.CategoryResult, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+	return ScanWithOptions(Options{})
+}
+
+// Options configures ScanWithOptions.
+type Options struct {
+	// NoExec runs no external commands. Installed apps are then unknown,
+	// so the orphaned preferences and Group Containers are skipped.
+	NoExec bool
+	// IncludeHidden considers dot-prefixed entries in ~/Downloads for
+	// app-old-downloads. They are left out by default.
+	IncludeHidden bool
+	// Runner runs PlistBuddy. Nil uses os/exec.
+	Runner CmdRunner
+	// Status receives the status of the categories that need a command,
+	// e.g. when it is not installed. Nil drops them.
+	Status scan.StatusFunc
+	// Home is the home directory to scan, e.g. another account's for an
+	// audit. Empty means the current user's.
+	Home string
+	// MaxDepth bounds how deep the entries of the top-level caches are
+	// sized (see scan.ScanTopLevelDepth). Zero sizes everything.
+	MaxDepth int
+}
+
+// ScanWithOptions is Scan with command execution configured by opts.
+func ScanWithOptions(opts Options) ([]scan.CategoryResult, error) {
+	home := opts.Home
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return nil, fmt.Errorf("cannot determine home directory: %w", err)
+		}
 	}
+	depth := opts.MaxDepth
 
 	var results []scan.CategoryResult
 
-	if cr := scanOrphanedPrefs(home, "/usr/libexec/PlistBuddy", defaultRunner); cr != nil {
+	runner := opts.Runner
+	if runner == nil {
+		runner = defaultRunner
+	}
+	if opts.NoExec {
+		runner = nil
+	}
+	installedIDs := installedBundleIDs(home, "/usr/libexec/PlistBuddy", runner, opts.Status)
+	if cr := scanOrphanedPrefs(home, installedIDs); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanIOSBackups(home); cr != nil {
+	if cr := scanOrphanedGroupContainers(home, installedIDs); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanOldDownloads(home, 90*24*time.Hour); cr != nil {
+	if cr := scanIOSBackups(home, depth); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
+	if cr := scanOldDownloads(home, 90*24*time.Hour, opts.IncludeHidden); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
@@ -53,32 +96,41 @@ func Scan() ([]scan.CategoryResult, error) {
 	return results, nil
 }
 
-// scanOrphanedPrefs finds preference .plist files in ~/Library/Preferences
-// that do not match any installed application's bundle ID. com.apple.*
-// preferences are always skipped. Returns nil if PlistBuddy is not found
-// or the Preferences directory does not exist.
-func scanOrphanedPrefs(home, plistBuddyPath string, runner CmdRunner) *scan.CategoryResult {
-	// Guard: PlistBuddy must exist.
-	if _, err := exec.LookPath(plistBuddyPath); err != nil {
-		return nil
+// Paths returns the locations Scan examines, without checking whether
+// they exist. Application directories are read only to collect installed
+// bundle IDs.
+func Paths(home string) []string {
+	return []string{
+		filepath.Join(home, "Library", "Preferences"),
+		filepath.Join(home, "Library", "Group Containers"),
+		"/Applications",
+		"/Applications/Utilities",
+		filepath.Join(home, "Applications"),
+		"/System/Applications",
+		"/System/Applications/Utilities",
+		filepath.Join(home, "Library", "Application Support", "MobileSync", "Backup"),
+		filepath.Join(home, "Downloads"),
 	}
+}
 
-	prefsDir := filepath.Join(home, "Library", "Preferences")
-	if _, err := os.Stat(prefsDir); err != nil {
-		if os.IsPermission(err) {
-			return &scan.CategoryResult{
-				Category:    "app-orphaned-prefs",
-				Description: "Orphaned Preferences",
-				PermissionIssues: []scan.PermissionIssue{{
-					Path:        prefsDir,
-					Description: "Preferences directory (permission denied)",
-				}},
-			}
-		}
+// installedBundleIDs collects the bundle IDs of the applications in the
+// standard app directories by reading each Info.plist with PlistBuddy.
+// Returns nil if PlistBuddy is not found or runner is nil (external
+// commands disabled), so callers can tell "nothing installed" apart from
+// "cannot tell what is installed".
+func installedBundleIDs(home, plistBuddyPath string, runner CmdRunner, report scan.StatusFunc) map[string]bool {
+	if runner == nil {
+		logging.Warn("orphan detection skipped", "reason", "external commands disabled")
+		reportOrphanSkip(report, scan.StatusDisabled, "")
+		return nil
+	}
+	// Guard: PlistBuddy must exist.
+	if _, err := exec.LookPath(plistBuddyPath); err != nil {
+		logging.Debug("command not found", "command", plistBuddyPath)
+		reportOrphanSkip(report, scan.StatusToolMissing, plistBuddyPath)
 		return nil
 	}
 
-	// Build set of installed bundle IDs by scanning app directories.
 	appDirs := []string{
 		"/Applications",
 		"/Applications/Utilities",
@@ -101,6 +153,9 @@ func scanOrphanedPrefs(home, plistBuddyPath string, runner CmdRunner) *scan.Cate
 
 			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 			out, err := runner(ctx, plistBuddyPath, "-c", "Print :CFBundleIdentifier", plistPath)
+			if err != nil {
+				logging.CommandError(ctx, "PlistBuddy", err)
+			}
 			cancel()
 
 			if err != nil {
@@ -113,6 +168,39 @@ func scanOrphanedPrefs(home, plistBuddyPath string, runner CmdRunner) *scan.Cate
 			}
 		}
 	}
+	return installedIDs
+}
+
+// reportOrphanSkip reports both orphan categories to report as skipped,
+// since neither can be checked without the installed bundle IDs.
+func reportOrphanSkip(report scan.StatusFunc, status scan.ScanStatus, detail string) {
+	report.Report("app-orphaned-prefs", status, detail)
+	report.Report("app-orphaned-group-containers", status, detail)
+}
+
+// scanOrphanedPrefs finds preference .plist files in ~/Library/Preferences
+// that do not match any of installedIDs. com.apple.* preferences are
+// always skipped. Returns nil if installedIDs is nil (PlistBuddy not
+// found) or the Preferences directory does not exist.
+func scanOrphanedPrefs(home string, installedIDs map[string]bool) *scan.CategoryResult {
+	if installedIDs == nil {
+		return nil
+	}
+
+	prefsDir := filepath.Join(home, "Library", "Preferences")
+	if _, err := os.Stat(prefsDir); err != nil {
+		if os.IsPermission(err) {
+			return &scan.CategoryResult{
+				Category:    "app-orphaned-prefs",
+				Description: "Orphaned Preferences",
+				PermissionIssues: []scan.PermissionIssue{{
+					Path:        prefsDir,
+					Description: "Preferences directory (permission denied)",
+				}},
+			}
+		}
+		return nil
+	}
 
 	// Read preference files and find orphans.
 	prefEntries, err := os.ReadDir(prefsDir)
@@ -163,7 +251,7 @@ func scanOrphanedPrefs(home, plistBuddyPath string, runner CmdRunner) *scan.Cate
 			continue
 		}
 
-		size := info.Size()
+		size := scan.FileSize(info)
 		if size == 0 {
 			continue
 		}
@@ -206,10 +294,156 @@ func isMatchedByInstalledApp(domain string, installedIDs map[string]bool) bool {
 	return false
 }
 
+// scanOrphanedGroupContainers lists the directories in
+// ~/Library/Group Containers whose group ID matches none of installedIDs.
+// A group ID matches when, after dropping its "group." or team ID prefix,
+// it is an installed bundle ID, a prefix or extension of one, or shares
+// its vendor (the first two reverse-DNS components) with one. Apple's own
+// containers are always skipped. Team IDs cannot be read without codesign,
+// so a container named only by its team ID (e.g. "ABCDE12345.ms") is
+// reported; the category is therefore ReportOnly and risky. Returns nil if
+// installedIDs is nil (PlistBuddy not found) or the directory does not
+// exist.
+func scanOrphanedGroupContainers(home string, installedIDs map[string]bool) *scan.CategoryResult {
+	if installedIDs == nil {
+		return nil
+	}
+
+	const category = "app-orphaned-group-containers"
+	const description = "Orphaned Group Containers"
+	containersDir := filepath.Join(home, "Library", "Group Containers")
+	dirEntries, err := os.ReadDir(containersDir)
+	if err != nil {
+		if os.IsPermission(err) {
+			return &scan.CategoryResult{
+				Category:    category,
+				Description: description,
+				ReportOnly:  true,
+				PermissionIssues: []scan.PermissionIssue{{
+					Path:        containersDir,
+					Description: "Group Containers directory (permission denied)",
+				}},
+			}
+		}
+		return nil
+	}
+
+	var entries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, entry := range dirEntries {
+		name := entry.Name()
+		if !entry.IsDir() {
+			continue
+		}
+		groupID := groupContainerID(name)
+		if strings.HasPrefix(groupID, "com.apple.") || strings.Contains(groupID, ".com.apple.") {
+			continue
+		}
+		if isGroupMatchedByInstalledApp(groupID, installedIDs) {
+			continue
+		}
+
+		path := filepath.Join(containersDir, name)
+		size, err := scan.DirSize(path)
+		if err != nil {
+			if os.IsPermission(err) {
+				permIssues = append(permIssues, scan.PermissionIssue{
+					Path:        path,
+					Description: name + " (permission denied)",
+				})
+			}
+			continue
+		}
+		if size == 0 {
+			continue
+		}
+
+		entries = append(entries, scan.ScanEntry{
+			Path:        path,
+			Description: name,
+			Size:        size,
+			IsDir:       true,
+		})
+		totalSize += size
+	}
+
+	if len(entries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Size > entries[j].Size
+	})
+
+	return &scan.CategoryResult{
+		Category:         category,
+		Description:      description,
+		Entries:          entries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+		ReportOnly:       true,
+	}
+}
+
+// groupContainerID strips the "group." or team ID prefix from a Group
+// Containers directory name, leaving the identifier the sharing apps use.
+func groupContainerID(name string) string {
+	if id, ok := strings.CutPrefix(name, "group."); ok {
+		return id
+	}
+	if team, id, ok := strings.Cut(name, "."); ok && isTeamID(team) {
+		return id
+	}
+	return name
+}
+
+// isTeamID reports whether s looks like an Apple developer team ID: ten
+// upper-case letters and digits.
+func isTeamID(s string) bool {
+	if len(s) != 10 {
+		return false
+	}
+	for _, r := range s {
+		if (r < 'A' || r > 'Z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// isGroupMatchedByInstalledApp checks if a group ID belongs to any
+// installed bundle ID: the IDs are equal, one extends the other by a dot,
+// or both share a vendor.
+func isGroupMatchedByInstalledApp(groupID string, installedIDs map[string]bool) bool {
+	vendor := bundleVendor(groupID)
+	for id := range installedIDs {
+		if groupID == id || strings.HasPrefix(groupID, id+".") || strings.HasPrefix(id, groupID+".") {
+			return true
+		}
+		if vendor != "" && bundleVendor(id) == vendor {
+			return true
+		}
+	}
+	return false
+}
+
+// bundleVendor returns the first two reverse-DNS components of id (e.g.
+// "com.example" for "com.example.app.shared"), or "" if id has fewer than
+// three components.
+func bundleVendor(id string) string {
+	parts := strings.SplitN(id, ".", 3)
+	if len(parts) < 3 {
+		return ""
+	}
+	return parts[0] + "." + parts[1]
+}
+
 // scanIOSBackups scans ~/Library/Application Support/MobileSync/Backup for
 // iOS device backups. Returns nil if the directory does not exist or has no
 // entries.
-func scanIOSBackups(home string) *scan.CategoryResult {
+func scanIOSBackups(home string, depth int) *scan.CategoryResult {
 	backupDir := filepath.Join(home, "Library", "Application Support", "MobileSync", "Backup")
 
 	if _, err := os.Stat(backupDir); err != nil {
@@ -226,11 +460,18 @@ func scanIOSBackups(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(backupDir, "app-ios-backups", "iOS Device Backups")
+	cr, err := scan.ScanTopLevelDepth(backupDir, "app-ios-backups", "iOS Device Backups", depth)
 	if err != nil {
 		return nil
 	}
 
+	// Record each backup's modification time so the newest can be kept.
+	for i := range cr.Entries {
+		if info, err := os.Lstat(cr.Entries[i].Path); err == nil {
+			cr.Entries[i].ModTime = info.ModTime()
+		}
+	}
+
 	if len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0 {
 		return nil
 	}
@@ -239,9 +480,13 @@ func scanIOSBackups(home string) *scan.CategoryResult {
 }
 
 // scanOldDownloads scans ~/Downloads for files and directories older than
-// maxAge based on modification time. Returns nil if the directory does not
-// exist or no old entries are found.
-func scanOldDownloads(home string, maxAge time.Duration) *scan.CategoryResult {
+// maxAge based on modification time. Dataless (iCloud-only) items are left
+// out; folders holding some are sized without them and marked risky.
+// Symlinks are never followed or reported: the link frees nothing, and its
+// target may lie outside Downloads. Hidden (dot-prefixed) entries such as
+// .DS_Store or .localized are left out unless includeHidden is set.
+// Returns nil if the directory does not exist or no old entries are found.
+func scanOldDownloads(home string, maxAge time.Duration, includeHidden bool) *scan.CategoryResult {
 	downloadsDir := filepath.Join(home, "Downloads")
 
 	if _, err := os.Stat(downloadsDir); err != nil {
@@ -278,6 +523,14 @@ func scanOldDownloads(home string, maxAge time.Duration) *scan.CategoryResult {
 	var totalSize int64
 
 	for _, entry := range dirEntries {
+		if !includeHidden && strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if entry.Type()&os.ModeSymlink != 0 {
+			logging.Debug("skipping symlink", "category", "app-old-downloads", "path", filepath.Join(downloadsDir, entry.Name()))
+			continue
+		}
+
 		info, err := entry.Info()
 		if err != nil {
 			if os.IsPermission(err) {
@@ -293,8 +546,16 @@ func scanOldDownloads(home string, maxAge time.Duration) *scan.CategoryResult {
 			continue
 		}
 
+		// Cloud-only items free nothing locally, and deleting them
+		// removes the cloud copy.
+		if scan.IsDataless(info) {
+			continue
+		}
+
 		var size int64
 		entryPath := filepath.Join(downloadsDir, entry.Name())
+		description := entry.Name()
+		risk := ""
 
 		if entry.IsDir() {
 			s, err := scan.DirSize(entryPath)
@@ -308,8 +569,14 @@ func scanOldDownloads(home string, maxAge time.Duration) *scan.CategoryResult {
 				continue
 			}
 			size = s
+			// The size leaves out cloud-only files, but deleting the
+			// folder removes them from iCloud too.
+			if scan.ContainsDataless(entryPath) {
+				description += " (includes iCloud-only files; deleting removes them from iCloud too)"
+				risk = safety.RiskRisky
+			}
 		} else {
-			size = info.Size()
+			size = scan.FileSize(info)
 		}
 
 		if size == 0 {
@@ -318,8 +585,11 @@ func scanOldDownloads(home string, maxAge time.Duration) *scan.CategoryResult {
 
 		entries = append(entries, scan.ScanEntry{
 			Path:        entryPath,
-			Description: entry.Name(),
+			Description: description,
 			Size:        size,
+			RiskLevel:   risk,
+			IsDir:       entry.IsDir(),
+			ModTime:     info.ModTime(),
 		})
 		totalSize += size
 	}
@@ -3,11 +3,14 @@ package appleftovers
 import (
 	"context"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -52,7 +55,7 @@ func TestScanOrphanedPrefs(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanOrphanedPrefs(home, fakePB, runner)
+	result := scanOrphanedPrefs(home, installedBundleIDs(home, fakePB, runner, nil))
 	if result == nil {
 		t.Fatal("expected non-nil result for orphaned prefs")
 	}
@@ -89,7 +92,7 @@ func TestScanOrphanedPrefsNoPlistBuddy(t *testing.T) {
 	}
 
 	// Pass a path that does not exist.
-	result := scanOrphanedPrefs(home, "/nonexistent/PlistBuddy", runner)
+	result := scanOrphanedPrefs(home, installedBundleIDs(home, "/nonexistent/PlistBuddy", runner, nil))
 	if result != nil {
 		t.Fatal("expected nil when PlistBuddy is not found")
 	}
@@ -125,7 +128,7 @@ func TestScanOrphanedPrefsApplePrefixSkipped(t *testing.T) {
 		return nil, fmt.Errorf("no bundle ID")
 	}
 
-	result := scanOrphanedPrefs(home, fakePB, runner)
+	result := scanOrphanedPrefs(home, installedBundleIDs(home, fakePB, runner, nil))
 	if result != nil {
 		t.Fatal("expected nil -- all com.apple.* prefs should be skipped")
 	}
@@ -144,7 +147,7 @@ func TestScanOrphanedPrefsNoPrefsDir(t *testing.T) {
 		return nil, nil
 	}
 
-	result := scanOrphanedPrefs(home, fakePB, runner)
+	result := scanOrphanedPrefs(home, installedBundleIDs(home, fakePB, runner, nil))
 	if result == nil {
 		// No Preferences dir, should return nil.
 	} else {
@@ -152,6 +155,89 @@ func TestScanOrphanedPrefsNoPrefsDir(t *testing.T) {
 	}
 }
 
+// --- Orphaned Group Containers tests ---
+
+func TestScanOrphanedGroupContainers(t *testing.T) {
+	home := t.TempDir()
+	groupDir := filepath.Join(home, "Library", "Group Containers")
+	writeFile(t, filepath.Join(groupDir, "group.com.removed.app", "data.db"), 4000)
+	writeFile(t, filepath.Join(groupDir, "ABCDE12345.com.gone.sync", "Library", "cache"), 1000)
+	writeFile(t, filepath.Join(groupDir, "group.com.known.app", "data.db"), 500)
+	writeFile(t, filepath.Join(groupDir, "ABCDE12345.com.known.shared", "data.db"), 500)
+	writeFile(t, filepath.Join(groupDir, "group.com.apple.notes", "NoteStore.sqlite"), 500)
+	writeFile(t, filepath.Join(groupDir, "243LU875E5.groups.com.apple.podcasts", "db"), 500)
+	if err := os.MkdirAll(filepath.Join(groupDir, "group.com.empty.app"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	installed := map[string]bool{"com.known.app": true}
+	result := scanOrphanedGroupContainers(home, installed)
+	if result == nil {
+		t.Fatal("expected non-nil result for orphaned group containers")
+	}
+	if result.Category != "app-orphaned-group-containers" {
+		t.Errorf("expected category app-orphaned-group-containers, got %s", result.Category)
+	}
+	if !result.ReportOnly {
+		t.Error("expected category to be report-only")
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 orphaned containers, got %d: %+v", len(result.Entries), result.Entries)
+	}
+	if result.Entries[0].Description != "group.com.removed.app" || result.Entries[0].Size != 4000 {
+		t.Errorf("expected largest entry group.com.removed.app (4000), got %+v", result.Entries[0])
+	}
+	if result.Entries[1].Description != "ABCDE12345.com.gone.sync" || !result.Entries[1].IsDir {
+		t.Errorf("expected team-prefixed orphan second, got %+v", result.Entries[1])
+	}
+	if result.TotalSize != 5000 {
+		t.Errorf("expected total 5000, got %d", result.TotalSize)
+	}
+
+	result.SetRiskLevels(safety.RiskForCategory)
+	for _, e := range result.Entries {
+		if e.RiskLevel != safety.RiskRisky {
+			t.Errorf("expected risky entry, got %q for %s", e.RiskLevel, e.Path)
+		}
+	}
+}
+
+func TestScanOrphanedGroupContainersAllInstalled(t *testing.T) {
+	home := t.TempDir()
+	groupDir := filepath.Join(home, "Library", "Group Containers")
+	writeFile(t, filepath.Join(groupDir, "group.com.vendor.editor", "data"), 100)
+	writeFile(t, filepath.Join(groupDir, "group.com.vendor.shared", "data"), 100)
+
+	installed := map[string]bool{"com.vendor.editor": true}
+	if result := scanOrphanedGroupContainers(home, installed); result != nil {
+		t.Errorf("expected nil when every container belongs to an installed vendor, got %+v", result.Entries)
+	}
+}
+
+func TestScanOrphanedGroupContainersNoPlistBuddy(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, "Library", "Group Containers", "group.com.removed.app", "data"), 100)
+
+	if result := scanOrphanedGroupContainers(home, nil); result != nil {
+		t.Error("expected nil when installed apps cannot be determined")
+	}
+}
+
+func TestGroupContainerID(t *testing.T) {
+	tests := map[string]string{
+		"group.com.example.app":      "com.example.app",
+		"ABCDE12345.com.example.app": "com.example.app",
+		"UBF8T346G9.ms":              "ms",
+		"com.example.app":            "com.example.app",
+		"abcde12345.com.example":     "abcde12345.com.example",
+	}
+	for name, want := range tests {
+		if got := groupContainerID(name); got != want {
+			t.Errorf("groupContainerID(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
 // --- iOS Backups tests ---
 
 func TestScanIOSBackups(t *testing.T) {
@@ -163,7 +249,7 @@ func TestScanIOSBackups(t *testing.T) {
 	writeFile(t, filepath.Join(backupDir, "AAAA-BBBB-CCCC-DDDD", "files", "data.bin"), 2000)
 	writeFile(t, filepath.Join(backupDir, "EEEE-FFFF-1111-2222", "Manifest.db"), 1000)
 
-	result := scanIOSBackups(home)
+	result := scanIOSBackups(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for iOS backups")
 	}
@@ -193,9 +279,53 @@ func TestScanIOSBackups(t *testing.T) {
 	}
 }
 
+func TestScanIOSBackupsKeepRecent(t *testing.T) {
+	home := t.TempDir()
+	backupDir := filepath.Join(home, "Library", "Application Support", "MobileSync", "Backup")
+
+	// Four old backups, each modified a different number of days ago.
+	ages := map[string]int{"backup-a": 400, "backup-b": 30, "backup-c": 200, "backup-d": 90}
+	for name, days := range ages {
+		dir := filepath.Join(backupDir, name)
+		writeFile(t, filepath.Join(dir, "Manifest.db"), 1000+days)
+		mod := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+		if err := os.Chtimes(dir, mod, mod); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := scanIOSBackups(home, 0)
+	if result == nil {
+		t.Fatal("expected non-nil result for iOS backups")
+	}
+	for _, e := range result.Entries {
+		if e.ModTime.IsZero() {
+			t.Errorf("entry %s has no ModTime", e.Description)
+		}
+	}
+
+	result.KeepRecent(2)
+
+	// The two newest (backup-b, backup-d) are retained; the rest remain
+	// flagged for deletion.
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 flagged entries, got %d", len(result.Entries))
+	}
+	flagged := map[string]bool{}
+	for _, e := range result.Entries {
+		flagged[e.Description] = true
+	}
+	if !flagged["backup-a"] || !flagged["backup-c"] {
+		t.Errorf("expected backup-a and backup-c flagged, got %v", flagged)
+	}
+	if want := int64(1400 + 1200); result.TotalSize != want {
+		t.Errorf("expected total %d, got %d", want, result.TotalSize)
+	}
+}
+
 func TestScanIOSBackupsMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanIOSBackups(home)
+	result := scanIOSBackups(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing iOS backup directory")
 	}
@@ -206,7 +336,7 @@ func TestScanIOSBackupsEmptyDir(t *testing.T) {
 	backupDir := filepath.Join(home, "Library", "Application Support", "MobileSync", "Backup")
 	os.MkdirAll(backupDir, 0755)
 
-	result := scanIOSBackups(home)
+	result := scanIOSBackups(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty iOS backup directory")
 	}
@@ -230,7 +360,7 @@ func TestScanOldDownloads(t *testing.T) {
 	// recent.pdf keeps its current time (just created).
 
 	maxAge := 90 * 24 * time.Hour
-	result := scanOldDownloads(home, maxAge)
+	result := scanOldDownloads(home, maxAge, false)
 	if result == nil {
 		t.Fatal("expected non-nil result for old downloads")
 	}
@@ -264,6 +394,129 @@ func TestScanOldDownloads(t *testing.T) {
 	}
 }
 
+func TestScanOldDownloadsHiddenEntries(t *testing.T) {
+	home := t.TempDir()
+	downloadsDir := filepath.Join(home, "Downloads")
+	writeFile(t, filepath.Join(downloadsDir, "old.zip"), 1000)
+	writeFile(t, filepath.Join(downloadsDir, ".old-hidden.bin"), 4000)
+
+	oldTime := time.Now().Add(-120 * 24 * time.Hour)
+	os.Chtimes(filepath.Join(downloadsDir, "old.zip"), oldTime, oldTime)
+	os.Chtimes(filepath.Join(downloadsDir, ".old-hidden.bin"), oldTime, oldTime)
+
+	maxAge := 90 * 24 * time.Hour
+	result := scanOldDownloads(home, maxAge, false)
+	if result == nil {
+		t.Fatal("expected non-nil result for old downloads")
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Description != "old.zip" {
+		t.Fatalf("expected only old.zip by default, got %+v", result.Entries)
+	}
+
+	result = scanOldDownloads(home, maxAge, true)
+	if result == nil {
+		t.Fatal("expected non-nil result with hidden entries included")
+	}
+	if len(result.Entries) != 2 || result.Entries[0].Description != ".old-hidden.bin" {
+		t.Fatalf("expected .old-hidden.bin and old.zip, got %+v", result.Entries)
+	}
+	if result.TotalSize != 5000 {
+		t.Errorf("expected total size 5000, got %d", result.TotalSize)
+	}
+}
+
+func TestScanOldDownloadsSkipsSymlinks(t *testing.T) {
+	home := t.TempDir()
+	downloadsDir := filepath.Join(home, "Downloads")
+	writeFile(t, filepath.Join(downloadsDir, "old.zip"), 100)
+
+	// A large directory outside Downloads, linked from it.
+	external := filepath.Join(t.TempDir(), "external")
+	writeFile(t, filepath.Join(external, "huge.bin"), 50000)
+	link := filepath.Join(downloadsDir, "external-link")
+	if err := os.Symlink(external, link); err != nil {
+		t.Fatal(err)
+	}
+	fileLink := filepath.Join(downloadsDir, "file-link")
+	if err := os.Symlink(filepath.Join(external, "huge.bin"), fileLink); err != nil {
+		t.Fatal(err)
+	}
+
+	// A negative maxAge treats every entry as old, including the links,
+	// whose own modification time cannot portably be set.
+	result := scanOldDownloads(home, -time.Hour, false)
+	if result == nil {
+		t.Fatal("expected non-nil result for old downloads")
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Description != "old.zip" {
+		t.Fatalf("expected only old.zip, got %+v", result.Entries)
+	}
+	if result.TotalSize != 100 {
+		t.Errorf("expected the link targets not to be counted, total %d", result.TotalSize)
+	}
+	for _, e := range result.Entries {
+		if e.Path == link || e.Path == fileLink || strings.HasPrefix(e.Path, external) {
+			t.Errorf("symlink or its target must not be deletable: %+v", e)
+		}
+	}
+}
+
+// simulateDataless makes files whose name ends in ".icloud-only" look
+// dataless for the rest of the test.
+func simulateDataless(t *testing.T) {
+	t.Helper()
+	scan.SetDatalessCheck(func(info fs.FileInfo) bool {
+		return strings.HasSuffix(info.Name(), ".icloud-only")
+	})
+	t.Cleanup(func() { scan.SetDatalessCheck(nil) })
+}
+
+func TestScanOldDownloadsDataless(t *testing.T) {
+	simulateDataless(t)
+	home := t.TempDir()
+	downloadsDir := filepath.Join(home, "Downloads")
+
+	writeFile(t, filepath.Join(downloadsDir, "local.dmg"), 3000)
+	writeFile(t, filepath.Join(downloadsDir, "movie.mov.icloud-only"), 90000)
+	writeFile(t, filepath.Join(downloadsDir, "photos", "a.jpg"), 1000)
+	writeFile(t, filepath.Join(downloadsDir, "photos", "b.jpg.icloud-only"), 50000)
+
+	oldTime := time.Now().Add(-120 * 24 * time.Hour)
+	for _, name := range []string{"local.dmg", "movie.mov.icloud-only", "photos"} {
+		os.Chtimes(filepath.Join(downloadsDir, name), oldTime, oldTime)
+	}
+
+	result := scanOldDownloads(home, 90*24*time.Hour, false)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	result.SetRiskLevels(safety.RiskForCategory)
+
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected the dataless file to be excluded, got %+v", result.Entries)
+	}
+	if result.TotalSize != 4000 {
+		t.Errorf("expected reclaimable total 4000 without cloud-only bytes, got %d", result.TotalSize)
+	}
+	for _, e := range result.Entries {
+		switch filepath.Base(e.Path) {
+		case "local.dmg":
+			if e.RiskLevel != safety.RiskForCategory("app-old-downloads") {
+				t.Errorf("expected category risk for local file, got %q", e.RiskLevel)
+			}
+		case "photos":
+			if e.Size != 1000 {
+				t.Errorf("expected folder size 1000 without cloud-only file, got %d", e.Size)
+			}
+			if e.RiskLevel != safety.RiskRisky || !strings.Contains(e.Description, "iCloud") {
+				t.Errorf("expected folder with cloud-only files flagged risky, got %+v", e)
+			}
+		default:
+			t.Errorf("unexpected entry %s", e.Path)
+		}
+	}
+}
+
 func TestScanOldDownloadsSkipsRecent(t *testing.T) {
 	home := t.TempDir()
 	downloadsDir := filepath.Join(home, "Downloads")
@@ -273,7 +526,7 @@ func TestScanOldDownloadsSkipsRecent(t *testing.T) {
 	writeFile(t, filepath.Join(downloadsDir, "recent2.zip"), 2000)
 
 	maxAge := 90 * 24 * time.Hour
-	result := scanOldDownloads(home, maxAge)
+	result := scanOldDownloads(home, maxAge, false)
 	if result != nil {
 		t.Fatal("expected nil when all downloads are recent")
 	}
@@ -281,7 +534,7 @@ func TestScanOldDownloadsSkipsRecent(t *testing.T) {
 
 func TestScanOldDownloadsMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanOldDownloads(home, 90*24*time.Hour)
+	result := scanOldDownloads(home, 90*24*time.Hour, false)
 	if result != nil {
 		t.Fatal("expected nil for missing Downloads directory")
 	}
@@ -302,7 +555,7 @@ func TestScanOldDownloadsWithDirectories(t *testing.T) {
 	os.Chtimes(filepath.Join(downloadsDir, "old-project", "file2.txt"), oldTime, oldTime)
 
 	maxAge := 90 * 24 * time.Hour
-	result := scanOldDownloads(home, maxAge)
+	result := scanOldDownloads(home, maxAge, false)
 	if result == nil {
 		t.Fatal("expected non-nil result for old directory in Downloads")
 	}
@@ -332,7 +585,7 @@ func TestScanOldDownloadsSkipsZeroByte(t *testing.T) {
 	os.Chtimes(filepath.Join(downloadsDir, "empty.txt"), oldTime, oldTime)
 
 	maxAge := 90 * 24 * time.Hour
-	result := scanOldDownloads(home, maxAge)
+	result := scanOldDownloads(home, maxAge, false)
 	if result != nil {
 		t.Fatal("expected nil -- zero-byte entries should be excluded")
 	}
@@ -357,10 +610,10 @@ func TestScanIntegration(t *testing.T) {
 	var results []scan.CategoryResult
 
 	// Skip orphaned prefs (requires PlistBuddy mock setup).
-	if cr := scanIOSBackups(home); cr != nil {
+	if cr := scanIOSBackups(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanOldDownloads(home, 90*24*time.Hour); cr != nil {
+	if cr := scanOldDownloads(home, 90*24*time.Hour, false); cr != nil {
 		results = append(results, *cr)
 	}
 
@@ -12,25 +12,49 @@ import (
 )
 
 // Scan discovers and sizes browser cache directories for Safari, Chrome,
-// and Firefox. Missing browsers are silently skipped. Permission failures
+// and Firefox, plus Chrome's per-profile service worker and code caches.
+// Missing browsers are silently skipped. Permission failures
 // are collected as PermissionIssue structs. No files are modified.
 func Scan() ([]scan.CategoryResult, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("cannot determine home directory: %w", err)
 	}
+	return ScanHome(home)
+}
+
+// ScanHome is Scan for the account whose home directory is home.
+func ScanHome(home string) ([]scan.CategoryResult, error) {
+	return ScanHomeDepth(home, 0)
+}
 
+// ScanHomeDepth is ScanHome with the entries of the top-level caches
+// sized no deeper than depth (see scan.ScanTopLevelDepth). Zero sizes
+// everything.
+func ScanHomeDepth(home string, depth int) ([]scan.CategoryResult, error) {
 	var results []scan.CategoryResult
 
 	if cr := scanSafari(home); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
+	if cr := scanSafariData(home, "browser-safari-favicons", "Safari Favicon Cache", safariFavicons); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
+	if cr := scanSafariData(home, "browser-safari-website-data", "Safari Website Data", safariWebsiteData); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
 	if cr := scanChrome(home); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanFirefox(home); cr != nil {
+	if cr := scanChromeStorage(home); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
+	if cr := scanFirefox(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
@@ -38,11 +62,79 @@ func Scan() ([]scan.CategoryResult, error) {
 	return results, nil
 }
 
-// scanSafari scans the Safari cache directory. Returns nil if Safari is
-// not installed or the cache directory does not exist. Returns a
-// CategoryResult with PermissionIssue if TCC (Full Disk Access)
+// Paths returns the locations Scan examines, without checking whether
+// they exist.
+func Paths(home string) []string {
+	paths := []string{
+		filepath.Join(home, "Library", "Caches", "com.apple.Safari"),
+		filepath.Join(home, "Library", "Containers", "com.apple.Safari", "Data", "Library", "Caches"),
+		filepath.Join(home, "Library", "Caches", "Google", "Chrome"),
+		filepath.Join(home, "Library", "Application Support", "Google", "Chrome"),
+		filepath.Join(home, "Library", "Caches", "Firefox"),
+	}
+	for _, dirs := range [][]safariDataDir{safariFavicons, safariWebsiteData} {
+		for _, d := range dirs {
+			paths = append(paths, filepath.Join(home, "Library", "Safari", d.rel))
+		}
+	}
+	return paths
+}
+
+// safariDataDir is a directory under ~/Library/Safari sized as one entry.
+type safariDataDir struct {
+	rel   string
+	label string
+}
+
+// safariFavicons are Safari's site icon caches. Safari fetches icons again
+// as sites are visited.
+var safariFavicons = []safariDataDir{
+	{rel: "Favicon Cache", label: "Favicon Cache"},
+	{rel: "Touch Icons Cache", label: "Touch Icons Cache"},
+}
+
+// safariWebsiteData is the storage websites keep in Safari. Removing it
+// signs the user out of sites and resets their settings.
+var safariWebsiteData = []safariDataDir{
+	{rel: "LocalStorage", label: "Local Storage"},
+	{rel: "Databases", label: "Databases"},
+}
+
+// scanSafari scans the Safari cache directory and, when present, the cache
+// directory of Safari's sandbox container. Returns nil if neither exists.
+// Returns a CategoryResult with PermissionIssue if TCC (Full Disk Access)
 // permission prevents access.
 func scanSafari(home string) *scan.CategoryResult {
+	cr := scanSafariCache(home)
+	containerDir := filepath.Join(home, "Library", "Containers", "com.apple.Safari", "Data", "Library", "Caches")
+	size, err := scan.DirSize(containerDir)
+	switch {
+	case err != nil && os.IsPermission(err):
+		if cr == nil {
+			cr = &scan.CategoryResult{Category: "browser-safari", Description: "Safari Cache"}
+		}
+		cr.PermissionIssues = append(cr.PermissionIssues, scan.PermissionIssue{
+			Path:        containerDir,
+			Description: "Safari container cache requires Full Disk Access",
+		})
+	case err == nil && size > 0:
+		if cr == nil {
+			cr = &scan.CategoryResult{Category: "browser-safari", Description: "Safari Cache"}
+		}
+		cr.Entries = append(cr.Entries, scan.ScanEntry{
+			Path:        containerDir,
+			Description: "Safari container cache",
+			Size:        size,
+			IsDir:       scan.IsDir(containerDir),
+		})
+		cr.TotalSize += size
+	}
+	return cr
+}
+
+// scanSafariCache scans ~/Library/Caches/com.apple.Safari. Returns nil if
+// Safari is not installed or the cache directory does not exist.
+func scanSafariCache(home string) *scan.CategoryResult {
 	safariDir := filepath.Join(home, "Library", "Caches", "com.apple.Safari")
 
 	_, err := os.Stat(safariDir)
@@ -90,12 +182,64 @@ func scanSafari(home string) *scan.CategoryResult {
 				Path:        safariDir,
 				Description: "com.apple.Safari",
 				Size:        size,
+				IsDir:       scan.IsDir(safariDir),
 			},
 		},
 		TotalSize: size,
 	}
 }
 
+// scanSafariData sizes each of dirs under ~/Library/Safari as a separate
+// entry of category. Missing directories are skipped; ~/Library/Safari is
+// protected by TCC, so denied ones are reported as needing Full Disk
+// Access. Returns nil if there is nothing to report.
+func scanSafariData(home, category, description string, dirs []safariDataDir) *scan.CategoryResult {
+	var entries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, d := range dirs {
+		entryPath := filepath.Join(home, "Library", "Safari", d.rel)
+		if blocked, reason := safety.IsPathBlocked(entryPath); blocked {
+			safety.WarnBlocked(entryPath, reason)
+			continue
+		}
+
+		size, denied, err := scan.DirSizeIssues(entryPath)
+		if (err != nil && os.IsPermission(err)) || len(denied) > 0 {
+			permIssues = append(permIssues, scan.PermissionIssue{
+				Path:        entryPath,
+				Description: "Safari " + d.label + " requires Full Disk Access",
+			})
+		}
+		if err != nil || size == 0 {
+			continue
+		}
+
+		entries = append(entries, scan.ScanEntry{
+			Path:        entryPath,
+			Description: d.label,
+			Size:        size,
+			IsDir:       scan.IsDir(entryPath),
+		})
+		totalSize += size
+	}
+
+	if len(entries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	scan.SortBySize(entries)
+
+	return &scan.CategoryResult{
+		Category:         category,
+		Description:      description,
+		Entries:          entries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}
+}
+
 // scanChrome scans Chrome cache directories including all user profiles
 // (Default, Profile 1, Profile 2, etc.). Returns nil if Chrome cache
 // directory does not exist.
@@ -160,6 +304,7 @@ func scanChrome(home string) *scan.CategoryResult {
 			Path:        entryPath,
 			Description: fmt.Sprintf("Chrome (%s)", entry.Name()),
 			Size:        size,
+			IsDir:       entry.IsDir(),
 		})
 		totalSize += size
 	}
@@ -181,10 +326,102 @@ func scanChrome(home string) *scan.CategoryResult {
 	}
 }
 
+// chromeProfileCaches lists the cache subdirectories inside a Chrome profile
+// that Chrome rebuilds on demand, with their display labels.
+var chromeProfileCaches = []struct {
+	rel   string
+	label string
+}{
+	{filepath.Join("Service Worker", "CacheStorage"), "Service Worker CacheStorage"},
+	{"Code Cache", "Code Cache"},
+}
+
+// scanChromeStorage scans the service worker CacheStorage and compiled
+// JavaScript Code Cache of every Chrome profile under
+// ~/Library/Application Support/Google/Chrome/. These live outside the HTTP
+// cache and are often larger than it. Each profile cache is a separate
+// entry. Returns nil if Chrome has no profile data.
+func scanChromeStorage(home string) *scan.CategoryResult {
+	chromeDir := filepath.Join(home, "Library", "Application Support", "Google", "Chrome")
+
+	profiles, err := os.ReadDir(chromeDir)
+	if err != nil {
+		if os.IsPermission(err) {
+			return &scan.CategoryResult{
+				Category:    "browser-chrome-storage",
+				Description: "Chrome Service Worker & Code Cache",
+				PermissionIssues: []scan.PermissionIssue{{
+					Path:        chromeDir,
+					Description: "Chrome profile data (permission denied)",
+				}},
+			}
+		}
+		return nil
+	}
+
+	var scanEntries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, profile := range profiles {
+		if !profile.IsDir() {
+			continue
+		}
+		for _, c := range chromeProfileCaches {
+			entryPath := filepath.Join(chromeDir, profile.Name(), c.rel)
+			desc := fmt.Sprintf("%s (%s)", c.label, profile.Name())
+
+			if blocked, reason := safety.IsPathBlocked(entryPath); blocked {
+				safety.WarnBlocked(entryPath, reason)
+				continue
+			}
+
+			size, err := scan.DirSize(entryPath)
+			if err != nil {
+				if os.IsPermission(err) {
+					permIssues = append(permIssues, scan.PermissionIssue{
+						Path:        entryPath,
+						Description: desc + " (permission denied)",
+					})
+				}
+				continue
+			}
+
+			if size == 0 {
+				continue
+			}
+
+			scanEntries = append(scanEntries, scan.ScanEntry{
+				Path:        entryPath,
+				Description: desc,
+				Size:        size,
+				IsDir:       scan.IsDir(entryPath),
+			})
+			totalSize += size
+		}
+	}
+
+	if len(scanEntries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	sort.Slice(scanEntries, func(i, j int) bool {
+		return scanEntries[i].Size > scanEntries[j].Size
+	})
+
+	return &scan.CategoryResult{
+		Category:         "browser-chrome-storage",
+		Description:      "Chrome Service Worker & Code Cache",
+		Entries:          scanEntries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}
+}
+
 // scanFirefox scans the Firefox cache directory. Returns nil if Firefox
 // cache directory does not exist. Uses the shared ScanTopLevel helper
 // since Firefox caches follow the standard directory-of-subdirectories pattern.
-func scanFirefox(home string) *scan.CategoryResult {
+func scanFirefox(home string, depth int) *scan.CategoryResult {
 	firefoxDir := filepath.Join(home, "Library", "Caches", "Firefox")
 
 	if _, err := os.Stat(firefoxDir); err != nil {
@@ -201,7 +438,7 @@ func scanFirefox(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(firefoxDir, "browser-firefox", "Firefox Cache")
+	cr, err := scan.ScanTopLevelDepth(firefoxDir, "browser-firefox", "Firefox Cache", depth)
 	if err != nil {
 		return nil
 	}
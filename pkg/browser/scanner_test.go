@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -71,6 +72,100 @@ func TestScanSafariEmptyDir(t *testing.T) {
 	}
 }
 
+func TestScanSafariContainerCache(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, "Library", "Caches", "com.apple.Safari", "cache.db"), 1000)
+	writeFile(t, filepath.Join(home, "Library", "Containers", "com.apple.Safari", "Data", "Library", "Caches", "com.apple.Safari", "fsCachedData", "a"), 700)
+
+	result := scanSafari(home)
+	if result == nil {
+		t.Fatal("expected a result")
+	}
+	if len(result.Entries) != 2 || result.TotalSize != 1700 {
+		t.Fatalf("expected 2 entries totalling 1700, got %+v", result)
+	}
+	if result.Entries[1].Description != "Safari container cache" {
+		t.Errorf("expected the container cache as second entry, got %q", result.Entries[1].Description)
+	}
+}
+
+func TestScanHomeSafariWebsiteDataAndFavicons(t *testing.T) {
+	home := t.TempDir()
+	safariDir := filepath.Join(home, "Library", "Safari")
+	writeFile(t, filepath.Join(home, "Library", "Caches", "com.apple.Safari", "cache.db"), 1000)
+	writeFile(t, filepath.Join(safariDir, "Favicon Cache", "favicons.db"), 800)
+	writeFile(t, filepath.Join(safariDir, "Touch Icons Cache", "Images", "a.png"), 200)
+	writeFile(t, filepath.Join(safariDir, "LocalStorage", "https_example.com_0.localstorage"), 300)
+	writeFile(t, filepath.Join(safariDir, "Databases", "___IndexedDB", "db.sqlite3"), 600)
+	// Bookmarks and history must never be offered.
+	writeFile(t, filepath.Join(safariDir, "Bookmarks.plist"), 5000)
+	writeFile(t, filepath.Join(safariDir, "History.db"), 5000)
+
+	results, err := ScanHome(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byID := map[string]scan.CategoryResult{}
+	for _, cr := range results {
+		byID[cr.Category] = cr
+	}
+
+	favicons, ok := byID["browser-safari-favicons"]
+	if !ok {
+		t.Fatal("expected a browser-safari-favicons category")
+	}
+	if favicons.TotalSize != 1000 || len(favicons.Entries) != 2 {
+		t.Errorf("favicons: expected 2 entries totalling 1000, got %+v", favicons)
+	}
+	if favicons.Entries[0].Description != "Favicon Cache" {
+		t.Errorf("favicons: expected the largest entry first, got %q", favicons.Entries[0].Description)
+	}
+
+	website, ok := byID["browser-safari-website-data"]
+	if !ok {
+		t.Fatal("expected a browser-safari-website-data category")
+	}
+	if website.TotalSize != 900 || len(website.Entries) != 2 {
+		t.Errorf("website data: expected 2 entries totalling 900, got %+v", website)
+	}
+
+	if byID["browser-safari"].TotalSize != 1000 {
+		t.Errorf("expected the Safari cache to stay separate, got %+v", byID["browser-safari"])
+	}
+
+	for id, want := range map[string]string{
+		"browser-safari-favicons":     safety.RiskSafe,
+		"browser-safari-website-data": safety.RiskModerate,
+	} {
+		for _, e := range byID[id].Entries {
+			if e.RiskLevel != want {
+				t.Errorf("%s entry %q: risk %q, want %q", id, e.Description, e.RiskLevel, want)
+			}
+			if filepath.Dir(e.Path) != safariDir {
+				t.Errorf("%s entry path %q is not a direct child of %s", id, e.Path, safariDir)
+			}
+		}
+	}
+}
+
+func TestScanSafariDataPermissionDenied(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root ignores directory permissions")
+	}
+	home := t.TempDir()
+	local := filepath.Join(home, "Library", "Safari", "LocalStorage")
+	writeFile(t, filepath.Join(local, "x.localstorage"), 100)
+	if err := os.Chmod(local, 0); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(local, 0755) })
+
+	result := scanSafariData(home, "browser-safari-website-data", "Safari Website Data", safariWebsiteData)
+	if result == nil || len(result.PermissionIssues) == 0 {
+		t.Fatalf("expected a permission issue, got %+v", result)
+	}
+}
+
 func TestScanChromeMissing(t *testing.T) {
 	home := t.TempDir()
 	result := scanChrome(home)
@@ -149,9 +244,75 @@ func TestScanChromeSkipsZeroByte(t *testing.T) {
 	}
 }
 
+func TestScanChromeStorageMissing(t *testing.T) {
+	home := t.TempDir()
+	if result := scanChromeStorage(home); result != nil {
+		t.Fatal("expected nil for missing Chrome profile data")
+	}
+}
+
+func TestScanChromeStorageServiceWorker(t *testing.T) {
+	home := t.TempDir()
+	profileDir := filepath.Join(home, "Library", "Application Support", "Google", "Chrome", "Default")
+	writeFile(t, filepath.Join(profileDir, "Service Worker", "CacheStorage", "abc123", "index.txt"), 900)
+	writeFile(t, filepath.Join(profileDir, "Service Worker", "CacheStorage", "def456", "data"), 100)
+	// Profile data outside the cache directories must not be reported.
+	writeFile(t, filepath.Join(profileDir, "Service Worker", "Database", "LOG"), 50)
+	writeFile(t, filepath.Join(profileDir, "Bookmarks"), 50)
+
+	result := scanChromeStorage(home)
+	if result == nil {
+		t.Fatal("expected non-nil result for Chrome profile with CacheStorage")
+	}
+	result.SetRiskLevels(safety.RiskForCategory)
+
+	if result.Category != "browser-chrome-storage" {
+		t.Errorf("expected category 'browser-chrome-storage', got %q", result.Category)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(result.Entries))
+	}
+	entry := result.Entries[0]
+	if entry.Path != filepath.Join(profileDir, "Service Worker", "CacheStorage") {
+		t.Errorf("unexpected entry path %q", entry.Path)
+	}
+	if entry.Description != "Service Worker CacheStorage (Default)" {
+		t.Errorf("expected 'Service Worker CacheStorage (Default)', got %q", entry.Description)
+	}
+	if entry.Size != 1000 || result.TotalSize != 1000 {
+		t.Errorf("expected size 1000, got entry %d total %d", entry.Size, result.TotalSize)
+	}
+	if entry.RiskLevel != safety.RiskSafe {
+		t.Errorf("expected risk %q, got %q", safety.RiskSafe, entry.RiskLevel)
+	}
+}
+
+func TestScanChromeStorageCodeCachePerProfile(t *testing.T) {
+	home := t.TempDir()
+	chromeDir := filepath.Join(home, "Library", "Application Support", "Google", "Chrome")
+	writeFile(t, filepath.Join(chromeDir, "Default", "Code Cache", "js", "a"), 300)
+	writeFile(t, filepath.Join(chromeDir, "Profile 1", "Code Cache", "wasm", "b"), 200)
+	writeFile(t, filepath.Join(chromeDir, "Profile 1", "Service Worker", "CacheStorage", "c"), 600)
+	writeFile(t, filepath.Join(chromeDir, "Local State"), 10)
+
+	result := scanChromeStorage(home)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if len(result.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(result.Entries))
+	}
+	if result.TotalSize != 1100 {
+		t.Errorf("expected total 1100, got %d", result.TotalSize)
+	}
+	if result.Entries[0].Description != "Service Worker CacheStorage (Profile 1)" {
+		t.Errorf("expected largest entry first, got %q", result.Entries[0].Description)
+	}
+}
+
 func TestScanFirefoxMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanFirefox(home)
+	result := scanFirefox(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Firefox cache")
 	}
@@ -162,7 +323,7 @@ func TestScanFirefoxWithData(t *testing.T) {
 	firefoxDir := filepath.Join(home, "Library", "Caches", "Firefox")
 	writeFile(t, filepath.Join(firefoxDir, "Profiles", "abc123.default", "cache2", "entries", "data.bin"), 700)
 
-	result := scanFirefox(home)
+	result := scanFirefox(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Firefox with data")
 	}
@@ -187,7 +348,7 @@ func TestScanFirefoxEmptyDir(t *testing.T) {
 	firefoxDir := filepath.Join(home, "Library", "Caches", "Firefox")
 	os.MkdirAll(firefoxDir, 0755)
 
-	result := scanFirefox(home)
+	result := scanFirefox(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty Firefox cache directory")
 	}
@@ -213,7 +374,7 @@ func TestScanIntegration(t *testing.T) {
 	if cr := scanChrome(home); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanFirefox(home); cr != nil {
+	if cr := scanFirefox(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
 
@@ -241,7 +402,7 @@ func TestScanEmptyHome(t *testing.T) {
 	if cr := scanChrome(home); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanFirefox(home); cr != nil {
+	if cr := scanFirefox(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
 
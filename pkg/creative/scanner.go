@@ -11,17 +11,28 @@ import (
 )
 
 // Scan discovers and sizes creative application cache directories for Adobe,
-// Sketch, and Figma. Missing applications are silently skipped. No files are
-// modified.
+// Sketch, and Figma, plus Adobe installer logs and Figma desktop profile data.
+// Missing applications are silently skipped. No files are modified.
 func Scan() ([]scan.CategoryResult, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("cannot determine home directory: %w", err)
 	}
+	return ScanHome(home)
+}
+
+// ScanHome is Scan for the account whose home directory is home.
+func ScanHome(home string) ([]scan.CategoryResult, error) {
+	return ScanHomeDepth(home, 0)
+}
 
+// ScanHomeDepth is ScanHome with the entries of the top-level caches
+// sized no deeper than depth (see scan.ScanTopLevelDepth). Zero sizes
+// everything.
+func ScanHomeDepth(home string, depth int) ([]scan.CategoryResult, error) {
 	var results []scan.CategoryResult
 
-	if cr := scanAdobeCaches(home); cr != nil {
+	if cr := scanAdobeCaches(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
@@ -37,13 +48,35 @@ func Scan() ([]scan.CategoryResult, error) {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
+	if cr := scanAdobeLogs(home); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
+	if cr := scanFigmaProfile(home); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
 
 	return results, nil
 }
 
+// Paths returns the locations Scan examines, without checking whether
+// they exist.
+func Paths(home string) []string {
+	return []string{
+		filepath.Join(home, "Library", "Caches", "Adobe"),
+		filepath.Join(home, "Library", "Application Support", "Adobe", "Common", "Media Cache Files"),
+		filepath.Join(home, "Library", "Application Support", "Adobe", "Common", "Media Cache"),
+		filepath.Join(home, "Library", "Caches", "com.bohemiancoding.sketch3"),
+		filepath.Join(home, "Library", "Application Support", "Figma", "Desktop"),
+		filepath.Join(home, "Library", "Application Support", "Adobe", "Installers"),
+		filepath.Join(home, "Library", "Application Support", "Figma", "DesktopProfile"),
+	}
+}
+
 // scanAdobeCaches scans ~/Library/Caches/Adobe/.
 // Returns nil if the directory does not exist.
-func scanAdobeCaches(home string) *scan.CategoryResult {
+func scanAdobeCaches(home string, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, "Library", "Caches", "Adobe")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -60,7 +93,7 @@ func scanAdobeCaches(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "creative-adobe", "Adobe Caches")
+	cr, err := scan.ScanTopLevelDepth(dir, "creative-adobe", "Adobe Caches", depth)
 	if err != nil {
 		return nil
 	}
@@ -133,27 +166,47 @@ func scanSketchCache(home string) *scan.CategoryResult {
 				Path:        dir,
 				Description: "Sketch",
 				Size:        size,
+				IsDir:       scan.IsDir(dir),
 			},
 		},
 		TotalSize: size,
 	}
 }
 
-// scanFigmaCache scans Figma cache directories:
-//   - ~/Library/Application Support/Figma/DesktopProfile/
-//   - ~/Library/Application Support/Figma/Desktop/
-//
-// Results from both paths are combined into a single CategoryResult.
-// Returns nil if neither directory exists.
+// scanFigmaCache scans ~/Library/Application Support/Figma/Desktop/.
+// The DesktopProfile directory is reported separately by scanFigmaProfile.
+// Returns nil if the directory does not exist.
 func scanFigmaCache(home string) *scan.CategoryResult {
 	paths := []string{
-		filepath.Join(home, "Library", "Application Support", "Figma", "DesktopProfile"),
 		filepath.Join(home, "Library", "Application Support", "Figma", "Desktop"),
 	}
 
 	return scanMultiDir(paths, "creative-figma", "Figma Cache")
 }
 
+// scanAdobeLogs scans ~/Library/Application Support/Adobe/Installers/,
+// where Adobe installers leave per-product install logs behind.
+// Returns nil if the directory does not exist.
+func scanAdobeLogs(home string) *scan.CategoryResult {
+	paths := []string{
+		filepath.Join(home, "Library", "Application Support", "Adobe", "Installers"),
+	}
+
+	return scanMultiDir(paths, "creative-adobe-logs", "Adobe Installer Logs")
+}
+
+// scanFigmaProfile scans ~/Library/Application Support/Figma/DesktopProfile/.
+// The profile holds signed-in session state alongside cached data, so it is
+// kept separate from the Figma cache.
+// Returns nil if the directory does not exist.
+func scanFigmaProfile(home string) *scan.CategoryResult {
+	paths := []string{
+		filepath.Join(home, "Library", "Application Support", "Figma", "DesktopProfile"),
+	}
+
+	return scanMultiDir(paths, "creative-figma-profile", "Figma Desktop Profile")
+}
+
 // scanMultiDir scans multiple directories and combines them into a single
 // CategoryResult. Each existing directory becomes a single blob entry with
 // its total size. Returns nil if no directories exist or all are empty.
@@ -192,6 +245,7 @@ func scanMultiDir(paths []string, category, description string) *scan.CategoryRe
 			Path:        dir,
 			Description: filepath.Base(dir),
 			Size:        size,
+			IsDir:       scan.IsDir(dir),
 		})
 		totalSize += size
 	}
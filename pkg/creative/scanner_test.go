@@ -25,7 +25,7 @@ func writeFile(t *testing.T, path string, size int) {
 
 func TestScanAdobeCachesMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanAdobeCaches(home)
+	result := scanAdobeCaches(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Adobe Caches")
 	}
@@ -37,7 +37,7 @@ func TestScanAdobeCachesWithData(t *testing.T) {
 	writeFile(t, filepath.Join(dir, "Photoshop", "cache.db"), 3000)
 	writeFile(t, filepath.Join(dir, "Premiere Pro", "cache.db"), 5000)
 
-	result := scanAdobeCaches(home)
+	result := scanAdobeCaches(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Adobe Caches with data")
 	}
@@ -57,7 +57,7 @@ func TestScanAdobeCachesEmptyDir(t *testing.T) {
 	dir := filepath.Join(home, "Library", "Caches", "Adobe")
 	os.MkdirAll(dir, 0755)
 
-	result := scanAdobeCaches(home)
+	result := scanAdobeCaches(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty Adobe Caches directory")
 	}
@@ -169,11 +169,81 @@ func TestScanFigmaCacheWithData(t *testing.T) {
 	if result.Category != "creative-figma" {
 		t.Errorf("expected category 'creative-figma', got %q", result.Category)
 	}
-	if len(result.Entries) != 2 {
-		t.Fatalf("expected 2 entries, got %d", len(result.Entries))
+	// DesktopProfile is reported by scanFigmaProfile, not here.
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(result.Entries))
 	}
-	if result.TotalSize != 3000 {
-		t.Errorf("expected total size 3000, got %d", result.TotalSize)
+	if result.TotalSize != 1000 {
+		t.Errorf("expected total size 1000, got %d", result.TotalSize)
+	}
+}
+
+// --- Adobe Installer Logs tests ---
+
+func TestScanAdobeLogsMissing(t *testing.T) {
+	home := t.TempDir()
+	result := scanAdobeLogs(home)
+	if result != nil {
+		t.Fatal("expected nil for missing Adobe installer logs")
+	}
+}
+
+func TestScanAdobeLogsWithData(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "Library", "Application Support", "Adobe", "Installers")
+	writeFile(t, filepath.Join(dir, "Photoshop 2024", "install.log"), 1500)
+	writeFile(t, filepath.Join(dir, "Illustrator 2024", "install.log"), 500)
+
+	result := scanAdobeLogs(home)
+	if result == nil {
+		t.Fatal("expected non-nil result for Adobe installer logs with data")
+	}
+	if result.Category != "creative-adobe-logs" {
+		t.Errorf("expected category 'creative-adobe-logs', got %q", result.Category)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected 1 entry (single blob), got %d", len(result.Entries))
+	}
+	if result.TotalSize != 2000 {
+		t.Errorf("expected total size 2000, got %d", result.TotalSize)
+	}
+}
+
+func TestScanAdobeLogsEmptyDir(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "Library", "Application Support", "Adobe", "Installers")
+	os.MkdirAll(dir, 0755)
+
+	result := scanAdobeLogs(home)
+	if result != nil {
+		t.Fatal("expected nil for empty Adobe installer logs directory")
+	}
+}
+
+// --- Figma Desktop Profile tests ---
+
+func TestScanFigmaProfileMissing(t *testing.T) {
+	home := t.TempDir()
+	result := scanFigmaProfile(home)
+	if result != nil {
+		t.Fatal("expected nil for missing Figma desktop profile")
+	}
+}
+
+func TestScanFigmaProfileWithData(t *testing.T) {
+	home := t.TempDir()
+	profile := filepath.Join(home, "Library", "Application Support", "Figma", "DesktopProfile")
+	writeFile(t, filepath.Join(profile, "Cache", "data_0"), 2000)
+
+	result := scanFigmaProfile(home)
+	if result == nil {
+		t.Fatal("expected non-nil result for Figma desktop profile with data")
+	}
+	if result.Category != "creative-figma-profile" {
+		t.Errorf("expected category 'creative-figma-profile', got %q", result.Category)
+	}
+	if result.TotalSize != 2000 {
+		t.Errorf("expected total size 2000, got %d", result.TotalSize)
 	}
 }
 
@@ -193,7 +263,7 @@ func TestScanIntegration(t *testing.T) {
 	// No Figma, no Adobe Media Cache -- should be silently skipped.
 
 	var results []scan.CategoryResult
-	if cr := scanAdobeCaches(home); cr != nil {
+	if cr := scanAdobeCaches(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
 	if cr := scanAdobeMediaCache(home); cr != nil {
@@ -5,14 +5,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/sp3esu/mac-cleaner/internal/logging"
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
@@ -31,72 +34,192 @@ func defaultRunner(ctx context.Context, name string, args ...string) ([]byte, er
 // npm cache, yarn cache, Homebrew cache, and Docker artifacts. Missing tools
 // are silently skipped. No files are modified.
 func Scan() ([]scan.CategoryResult, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+	return ScanWithOptions(Options{})
+}
+
+// Options selects the optional parts of ScanWithOptions.
+type Options struct {
+	// KeepLatestDeviceSupport leaves the newest iOS DeviceSupport version
+	// out of dev-xcode-device-support, so the files for the current OS are
+	// never offered for deletion.
+	KeepLatestDeviceSupport bool
+	// NodeModules opts in to dev-node-modules: node_modules directories
+	// under the project roots that have not changed in 90 days.
+	NodeModules bool
+	// PyEnvs opts in to dev-pyenvs: Python virtualenvs and __pycache__
+	// directories under the project roots that have not changed in 90 days.
+	PyEnvs bool
+	// ProjectRoots are the directories searched for node_modules and
+	// Python environments. Empty means ProjectRoots(home).
+	ProjectRoots []string
+	// IncludeHidden descends into hidden directories under the project
+	// roots when searching for node_modules and Python environments.
+	IncludeHidden bool
+	// NoExec runs no external commands: dev-brew-autoremove is skipped
+	// and dev-docker falls back to sizing Docker's data directories.
+	NoExec bool
+	// Runner runs the brew and docker commands. Nil uses os/exec.
+	Runner CmdRunner
+	// Status receives the status of the categories that need a command,
+	// e.g. when it is not installed. Nil drops them.
+	Status scan.StatusFunc
+	// Home is the home directory to scan, e.g. another account's for an
+	// audit. Empty means the current user's.
+	Home string
+	// MaxDepth bounds how deep the entries of the top-level caches are
+	// sized (see scan.ScanTopLevelDepth). Zero sizes everything.
+	MaxDepth int
+}
+
+// ScanWithOptions is Scan with the optional behavior selected by opts.
+func ScanWithOptions(opts Options) ([]scan.CategoryResult, error) {
+	home := opts.Home
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return nil, fmt.Errorf("cannot determine home directory: %w", err)
+		}
 	}
+	depth := opts.MaxDepth
 
 	var results []scan.CategoryResult
 
-	if cr := scanXcodeDerivedData(home); cr != nil {
+	derived := scanXcodeDerivedData(home, depth)
+	index := scanXcodeIndex(home)
+	if derived != nil && index != nil {
+		splitIndexedProjects(derived, index, depth)
+	}
+	if derived != nil {
+		derived.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *derived)
+	}
+	if index != nil {
+		index.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *index)
+	}
+	if cr := scanNpmCache(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanNpmCache(home); cr != nil {
+	if cr := scanYarnCache(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanYarnCache(home); cr != nil {
+	if cr := scanHomebrew(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanHomebrew(home); cr != nil {
+	runner := opts.Runner
+	if runner == nil {
+		runner = defaultRunner
+	}
+	if opts.NoExec {
+		runner = nil
+	}
+	if cr := scanBrewAutoremove(runner, opts.Status); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanDocker(defaultRunner); cr != nil {
+	cr, status := scanDockerCaches(home, runner)
+	opts.Status.Report("dev-docker", status, "docker")
+	if cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanSimulatorCaches(home); cr != nil {
+	if cr := scanSimulatorCaches(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanSimulatorLogs(home); cr != nil {
+	if cr := scanSimulatorLogs(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanXcodeDeviceSupport(home); cr != nil {
+	if cr := scanXcodeDeviceSupport(home, opts.KeepLatestDeviceSupport, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanXcodeArchives(home); cr != nil {
+	if cr := scanXcodeArchives(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanPnpmStore(home); cr != nil {
+	if cr := scanMobileDevice(home, time.Now(), depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanCocoaPods(home); cr != nil {
+	if cr := scanPnpmStore(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanGradle(home); cr != nil {
+	if cr := scanCocoaPods(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanPip(home); cr != nil {
+	if cr := scanGradle(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
+	if cr := scanPip(home, depth); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
+	if cr := scanDockerVMDisk(home); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
+	roots := opts.ProjectRoots
+	if len(roots) == 0 {
+		roots = ProjectRoots(home)
+	}
+	if opts.NodeModules {
+		if cr := scanNodeModules(home, roots, projectMaxAge, projectMaxDepth, opts.IncludeHidden); cr != nil {
+			cr.SetRiskLevels(safety.RiskForCategory)
+			results = append(results, *cr)
+		}
+	}
+	if opts.PyEnvs {
+		if cr := scanPyEnvs(home, roots, projectMaxAge, projectMaxDepth, opts.IncludeHidden); cr != nil {
+			cr.SetRiskLevels(safety.RiskForCategory)
+			results = append(results, *cr)
+		}
+	}
 
 	return results, nil
 }
 
+// Paths returns the locations Scan examines, without checking whether
+// they exist. Docker reclaimable space and unneeded Homebrew dependencies
+// are queried through the docker and brew CLIs and have no path; the
+// on-disk Docker data directories used when the CLI is unavailable are
+// included.
+func Paths(home string) []string {
+	vmDir := filepath.Join(home, "Library", "Containers", "com.docker.docker", "Data", "vms", "0")
+	paths := []string{
+		filepath.Join(home, "Library", "Developer", "Xcode", "DerivedData"),
+		filepath.Join(home, ".npm"),
+		filepath.Join(home, "Library", "Caches", "yarn"),
+		filepath.Join(home, "Library", "Caches", "Homebrew"),
+		filepath.Join(home, "Library", "Developer", "CoreSimulator", "Caches"),
+		filepath.Join(home, "Library", "Logs", "CoreSimulator"),
+		filepath.Join(home, "Library", "Developer", "Xcode", "iOS DeviceSupport"),
+		filepath.Join(home, "Library", "Developer", "Xcode", "Archives"),
+		filepath.Join(home, "Library", "Developer", "Xcode", "Products"),
+		filepath.Join(home, "Library", "MobileDevice", "Provisioning Profiles"),
+		filepath.Join(home, "Library", "pnpm", "store"),
+		filepath.Join(home, "Library", "Caches", "CocoaPods"),
+		filepath.Join(home, ".gradle", "caches"),
+		filepath.Join(home, "Library", "Caches", "pip"),
+		filepath.Join(vmDir, "data", "Docker.raw"),
+		filepath.Join(vmDir, "Docker.raw"),
+	}
+	for _, d := range dockerDataDirs(home) {
+		paths = append(paths, d.path)
+	}
+	return paths
+}
+
 // scanXcodeDerivedData scans ~/Library/Developer/Xcode/DerivedData/.
 // Returns nil if the directory does not exist.
-func scanXcodeDerivedData(home string) *scan.CategoryResult {
+func scanXcodeDerivedData(home string, depth int) *scan.CategoryResult {
 	derivedData := filepath.Join(home, "Library", "Developer", "Xcode", "DerivedData")
 
 	if _, err := os.Stat(derivedData); err != nil {
@@ -113,7 +236,7 @@ func scanXcodeDerivedData(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(derivedData, "dev-xcode", "Xcode DerivedData")
+	cr, err := scan.ScanTopLevelDepth(derivedData, "dev-xcode", "Xcode DerivedData", depth)
 	if err != nil {
 		return nil
 	}
@@ -125,9 +248,127 @@ func scanXcodeDerivedData(home string) *scan.CategoryResult {
 	return cr
 }
 
+// splitIndexedProjects replaces the dev-xcode entry of each project folder
+// holding an index store in index with entries for the folder's other
+// items. Cleaning dev-xcode then leaves the index to dev-xcode-index, the
+// bytes are counted once, and each entry's size is what deleting it frees.
+// A project folder that cannot be read is left out of dev-xcode.
+func splitIndexedProjects(derived, index *scan.CategoryResult, depth int) {
+	indexed := make(map[string]bool, len(index.Entries))
+	for _, e := range index.Entries {
+		indexed[filepath.Dir(e.Path)] = true
+	}
+	var entries []scan.ScanEntry
+	var total int64
+	for _, e := range derived.Entries {
+		if !indexed[e.Path] {
+			entries = append(entries, e)
+			total += e.Size
+			continue
+		}
+		cr, err := scan.ScanTopLevelDepth(e.Path, derived.Category, derived.Description, depth)
+		if err != nil {
+			continue
+		}
+		for _, item := range cr.Entries {
+			if filepath.Base(item.Path) == "Index.noindex" {
+				continue
+			}
+			item.Description = e.Description + "/" + item.Description
+			entries = append(entries, item)
+			total += item.Size
+		}
+		derived.PermissionIssues = append(derived.PermissionIssues, cr.PermissionIssues...)
+		derived.Truncated = derived.Truncated || cr.Truncated
+	}
+	scan.SortBySize(entries)
+	derived.Entries = entries
+	derived.TotalSize = total
+}
+
+// scanXcodeIndex scans the Index.noindex store inside each project folder
+// of ~/Library/Developer/Xcode/DerivedData/. Clearing an index forces Xcode
+// to reindex the project while keeping its build products. The stores lie
+// inside the dev-xcode project folders; see splitIndexedProjects. Returns nil
+// if no project has an index.
+func scanXcodeIndex(home string) *scan.CategoryResult {
+	derivedData := filepath.Join(home, "Library", "Developer", "Xcode", "DerivedData")
+
+	projects, err := os.ReadDir(derivedData)
+	if err != nil {
+		if os.IsPermission(err) {
+			return &scan.CategoryResult{
+				Category:    "dev-xcode-index",
+				Description: "Xcode Index Stores",
+				PermissionIssues: []scan.PermissionIssue{{
+					Path:        derivedData,
+					Description: "Xcode DerivedData (permission denied)",
+				}},
+			}
+		}
+		return nil
+	}
+
+	var scanEntries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, project := range projects {
+		if !project.IsDir() {
+			continue
+		}
+		entryPath := filepath.Join(derivedData, project.Name(), "Index.noindex")
+		desc := project.Name() + " index"
+
+		if blocked, reason := safety.IsPathBlocked(entryPath); blocked {
+			safety.WarnBlocked(entryPath, reason)
+			continue
+		}
+
+		size, err := scan.DirSize(entryPath)
+		if err != nil {
+			if os.IsPermission(err) {
+				permIssues = append(permIssues, scan.PermissionIssue{
+					Path:        entryPath,
+					Description: desc + " (permission denied)",
+				})
+			}
+			continue
+		}
+
+		if size == 0 {
+			continue
+		}
+
+		scanEntries = append(scanEntries, scan.ScanEntry{
+			Path:        entryPath,
+			Description: desc,
+			Size:        size,
+			IsDir:       scan.IsDir(entryPath),
+		})
+		totalSize += size
+	}
+
+	if len(scanEntries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	sort.Slice(scanEntries, func(i, j int) bool {
+		return scanEntries[i].Size > scanEntries[j].Size
+	})
+
+	return &scan.CategoryResult{
+		Category:         "dev-xcode-index",
+		Description:      "Xcode Index Stores",
+		Entries:          scanEntries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}
+}
+
 // scanNpmCache scans ~/.npm/ (the npm cache directory).
 // Returns nil if the directory does not exist.
-func scanNpmCache(home string) *scan.CategoryResult {
+func scanNpmCache(home string, depth int) *scan.CategoryResult {
 	npmDir := filepath.Join(home, ".npm")
 
 	if _, err := os.Stat(npmDir); err != nil {
@@ -144,7 +385,7 @@ func scanNpmCache(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(npmDir, "dev-npm", "npm Cache")
+	cr, err := scan.ScanTopLevelDepth(npmDir, "dev-npm", "npm Cache", depth)
 	if err != nil {
 		return nil
 	}
@@ -158,8 +399,9 @@ func scanNpmCache(home string) *scan.CategoryResult {
 
 // scanYarnCache scans ~/Library/Caches/yarn/.
 // Returns nil if the directory does not exist. Uses DirSize since
-// yarn cache is treated as a single blob rather than individual entries.
-func scanYarnCache(home string) *scan.CategoryResult {
+// yarn cache is treated as a single blob rather than individual entries,
+// unless scan.ExpandBlobs is on.
+func scanYarnCache(home string, depth int) *scan.CategoryResult {
 	yarnDir := filepath.Join(home, "Library", "Caches", "yarn")
 
 	if _, err := os.Stat(yarnDir); err != nil {
@@ -176,6 +418,10 @@ func scanYarnCache(home string) *scan.CategoryResult {
 		return nil
 	}
 
+	if scan.ExpandBlobs() {
+		return scanExpandedBlob(yarnDir, "dev-yarn", "Yarn Cache", depth)
+	}
+
 	size, err := scan.DirSize(yarnDir)
 	if err != nil {
 		if os.IsPermission(err) {
@@ -203,6 +449,7 @@ func scanYarnCache(home string) *scan.CategoryResult {
 				Path:        yarnDir,
 				Description: "yarn",
 				Size:        size,
+				IsDir:       scan.IsDir(yarnDir),
 			},
 		},
 		TotalSize: size,
@@ -211,7 +458,7 @@ func scanYarnCache(home string) *scan.CategoryResult {
 
 // scanHomebrew scans ~/Library/Caches/Homebrew/.
 // Returns nil if the directory does not exist.
-func scanHomebrew(home string) *scan.CategoryResult {
+func scanHomebrew(home string, depth int) *scan.CategoryResult {
 	brewDir := filepath.Join(home, "Library", "Caches", "Homebrew")
 
 	if _, err := os.Stat(brewDir); err != nil {
@@ -228,7 +475,7 @@ func scanHomebrew(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(brewDir, "dev-homebrew", "Homebrew Cache")
+	cr, err := scan.ScanTopLevelDepth(brewDir, "dev-homebrew", "Homebrew Cache", depth)
 	if err != nil {
 		return nil
 	}
@@ -240,6 +487,83 @@ func scanHomebrew(home string) *scan.CategoryResult {
 	return cr
 }
 
+// brewAutoremovePath is the pseudo-path of the dev-brew-autoremove entry.
+// The cleanup package runs "brew autoremove" for it instead of deleting a
+// path.
+const brewAutoremovePath = "brew:autoremove"
+
+// scanBrewAutoremove reports the formulae "brew autoremove" would remove
+// because nothing installed depends on them any more, sized from their
+// Cellar directories. They are reported as a single entry since brew
+// removes them all at once. Returns nil if brew is not installed, runner
+// is nil (external commands disabled) or nothing would be removed; the
+// first two are reported to report.
+func scanBrewAutoremove(runner CmdRunner, report scan.StatusFunc) *scan.CategoryResult {
+	if runner == nil {
+		logging.Warn("category skipped", "category", "dev-brew-autoremove", "reason", "external commands disabled")
+		report.Report("dev-brew-autoremove", scan.StatusDisabled, "")
+		return nil
+	}
+	if _, err := exec.LookPath("brew"); err != nil {
+		logging.Debug("command not found", "command", "brew")
+		report.Report("dev-brew-autoremove", scan.StatusToolMissing, "brew")
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	out, err := runner(ctx, "brew", "autoremove", "--dry-run")
+	if err != nil {
+		logging.CommandError(ctx, "brew autoremove", err)
+		return nil
+	}
+	formulae := parseBrewAutoremove(string(out))
+	if len(formulae) == 0 {
+		return nil
+	}
+
+	var size int64
+	if prefix, err := runner(ctx, "brew", "--prefix"); err == nil {
+		cellar := filepath.Join(strings.TrimSpace(string(prefix)), "Cellar")
+		for _, name := range formulae {
+			if n, err := scan.DirSize(filepath.Join(cellar, name)); err == nil {
+				size += n
+			}
+		}
+	}
+
+	noun := "dependencies"
+	if len(formulae) == 1 {
+		noun = "dependency"
+	}
+	return &scan.CategoryResult{
+		Category:    "dev-brew-autoremove",
+		Description: "Homebrew Unneeded Dependencies",
+		Entries: []scan.ScanEntry{{
+			Path:        brewAutoremovePath,
+			Description: fmt.Sprintf("%d unneeded Homebrew %s (%s)", len(formulae), noun, strings.Join(formulae, ", ")),
+			Size:        size,
+		}},
+		TotalSize: size,
+	}
+}
+
+// parseBrewAutoremove returns the formula names listed by
+// "brew autoremove --dry-run", which prints a "==> Would autoremove ..."
+// header followed by one formula per line.
+func parseBrewAutoremove(out string) []string {
+	var formulae []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "==>") {
+			continue
+		}
+		formulae = append(formulae, line)
+	}
+	return formulae
+}
+
 // dockerDFRow represents one row from docker system df --format '{{json .}}'.
 type dockerDFRow struct {
 	Type        string `json:"Type"`
@@ -247,12 +571,20 @@ type dockerDFRow struct {
 }
 
 // scanDocker queries Docker for reclaimable space using docker system df.
-// Returns nil if Docker is not installed or not running. Uses a 10-second
-// timeout to prevent hangs when the Docker daemon is unresponsive.
-func scanDocker(runner CmdRunner) *scan.CategoryResult {
+// Returns nil with StatusDisabled if runner is nil (external commands
+// disabled), StatusToolMissing if Docker is not installed, StatusError if
+// it is not running and StatusNoData if nothing is reclaimable. Uses a
+// 10-second timeout to prevent hangs when the Docker daemon is
+// unresponsive.
+func scanDocker(runner CmdRunner) (*scan.CategoryResult, scan.ScanStatus) {
+	if runner == nil {
+		logging.Warn("docker CLI skipped", "reason", "external commands disabled")
+		return nil, scan.StatusDisabled
+	}
 	// Check if docker binary is available.
 	if _, err := exec.LookPath("docker"); err != nil {
-		return nil
+		logging.Debug("command not found", "command", "docker")
+		return nil, scan.StatusToolMissing
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -260,7 +592,8 @@ func scanDocker(runner CmdRunner) *scan.CategoryResult {
 
 	out, err := runner(ctx, "docker", "system", "df", "--format", "{{json .}}")
 	if err != nil {
-		return nil
+		logging.CommandError(ctx, "docker system df", err)
+		return nil, scan.StatusError
 	}
 
 	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
@@ -292,7 +625,7 @@ func scanDocker(runner CmdRunner) *scan.CategoryResult {
 	}
 
 	if len(entries) == 0 {
-		return nil
+		return nil, scan.StatusNoData
 	}
 
 	sort.Slice(entries, func(i, j int) bool {
@@ -304,9 +637,111 @@ func scanDocker(runner CmdRunner) *scan.CategoryResult {
 		Description: "Docker Reclaimable",
 		Entries:     entries,
 		TotalSize:   totalSize,
+	}, scan.StatusFound
+}
+
+// dockerDataDir is a Docker data directory that can be sized on disk.
+// name identifies its informational "docker:" pseudo-entry.
+type dockerDataDir struct {
+	name        string
+	path        string
+	description string
+}
+
+// dockerDataDirs lists Docker data kept on disk outside the VM disk image,
+// which is reported separately as dev-docker-vm.
+func dockerDataDirs(home string) []dockerDataDir {
+	return []dockerDataDir{
+		{"buildx-cache", filepath.Join(home, ".docker", "buildx"), "Docker buildx cache"},
+		{"desktop-logs", filepath.Join(home, "Library", "Containers", "com.docker.docker", "Data", "log"), "Docker Desktop logs"},
 	}
 }
 
+// scanDockerCaches reports Docker reclaimable space through the docker CLI.
+// When the CLI is not installed, cannot reach the daemon or may not be run
+// (nil runner), it falls back
+// to sizing Docker's on-disk data directories so the space is still
+// surfaced. The status is the fallback's when it found data or examined
+// existing directories, and the CLI's otherwise, so a missing docker
+// command is reported rather than the absent directories.
+func scanDockerCaches(home string, runner CmdRunner) (*scan.CategoryResult, scan.ScanStatus) {
+	cr, cliStatus := scanDocker(runner)
+	if cr != nil || cliStatus == scan.StatusNoData {
+		return cr, cliStatus
+	}
+	cr, dirStatus := scanDockerDataDirs(home)
+	if dirStatus == scan.StatusDirAbsent {
+		return cr, cliStatus
+	}
+	return cr, dirStatus
+}
+
+// scanDockerDataDirs sizes the directories in dockerDataDirs without
+// running docker. The sizes are informational only: each directory is
+// reported as a "docker:" pseudo-entry, which cleanup never removes, since
+// Docker owns these files and deleting them behind its back can break it.
+// Returns nil with StatusDirAbsent if none exist, or StatusNoData if they
+// hold nothing.
+func scanDockerDataDirs(home string) (*scan.CategoryResult, scan.ScanStatus) {
+	var entries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	found := false
+	for _, d := range dockerDataDirs(home) {
+		if _, err := os.Stat(d.path); err != nil {
+			continue
+		}
+		found = true
+		if blocked, reason := safety.IsPathBlocked(d.path); blocked {
+			safety.WarnBlocked(d.path, reason)
+			continue
+		}
+		size, err := scan.DirSize(d.path)
+		if err != nil {
+			if os.IsPermission(err) {
+				permIssues = append(permIssues, scan.PermissionIssue{
+					Path:        d.path,
+					Description: d.description + " (permission denied)",
+				})
+			}
+			continue
+		}
+		if size == 0 {
+			continue
+		}
+		entries = append(entries, scan.ScanEntry{
+			Path:        "docker:" + d.name,
+			Description: d.description + " in " + d.path + " (size only; docker CLI unavailable)",
+			Size:        size,
+		})
+		totalSize += size
+	}
+
+	switch {
+	case !found:
+		return nil, scan.StatusDirAbsent
+	case len(entries) == 0 && len(permIssues) == 0:
+		return nil, scan.StatusNoData
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Size > entries[j].Size
+	})
+
+	status := scan.StatusFound
+	if len(entries) == 0 {
+		status = scan.StatusPermission
+	}
+	return &scan.CategoryResult{
+		Category:         "dev-docker",
+		Description:      "Docker Data on Disk",
+		Entries:          entries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}, status
+}
+
 // parseDockerSize parses Docker's human-readable size strings like "16.43MB",
 // "2.3GB", "1.5kB", "0B". The Reclaimable field may include a percentage
 // suffix like "1.2GB (52%)" which is stripped before parsing.
@@ -352,7 +787,7 @@ func parseDockerSize(s string) int64 {
 
 // scanSimulatorCaches scans ~/Library/Developer/CoreSimulator/Caches/.
 // Returns nil if the directory does not exist.
-func scanSimulatorCaches(home string) *scan.CategoryResult {
+func scanSimulatorCaches(home string, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, "Library", "Developer", "CoreSimulator", "Caches")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -369,7 +804,7 @@ func scanSimulatorCaches(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "dev-simulator-caches", "Simulator Caches")
+	cr, err := scan.ScanTopLevelDepth(dir, "dev-simulator-caches", "Simulator Caches", depth)
 	if err != nil {
 		return nil
 	}
@@ -383,7 +818,7 @@ func scanSimulatorCaches(home string) *scan.CategoryResult {
 
 // scanSimulatorLogs scans ~/Library/Logs/CoreSimulator/.
 // Returns nil if the directory does not exist.
-func scanSimulatorLogs(home string) *scan.CategoryResult {
+func scanSimulatorLogs(home string, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, "Library", "Logs", "CoreSimulator")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -400,7 +835,7 @@ func scanSimulatorLogs(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "dev-simulator-logs", "Simulator Logs")
+	cr, err := scan.ScanTopLevelDepth(dir, "dev-simulator-logs", "Simulator Logs", depth)
 	if err != nil {
 		return nil
 	}
@@ -412,9 +847,11 @@ func scanSimulatorLogs(home string) *scan.CategoryResult {
 	return cr
 }
 
-// scanXcodeDeviceSupport scans ~/Library/Developer/Xcode/iOS DeviceSupport/.
-// Returns nil if the directory does not exist.
-func scanXcodeDeviceSupport(home string) *scan.CategoryResult {
+// scanXcodeDeviceSupport scans ~/Library/Developer/Xcode/iOS DeviceSupport/
+// with one entry per OS version, newest first. With keepLatest the newest
+// version of each device family is left out. Returns nil if the directory
+// does not exist.
+func scanXcodeDeviceSupport(home string, keepLatest bool, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, "Library", "Developer", "Xcode", "iOS DeviceSupport")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -431,10 +868,14 @@ func scanXcodeDeviceSupport(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "dev-xcode-device-support", "Xcode Device Support")
+	cr, err := scan.ScanTopLevelDepth(dir, "dev-xcode-device-support", "Xcode Device Support", depth)
 	if err != nil {
 		return nil
 	}
+	sortDeviceSupport(cr.Entries)
+	if keepLatest {
+		keepNewestDeviceSupport(cr)
+	}
 
 	if len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0 {
 		return nil
@@ -443,9 +884,130 @@ func scanXcodeDeviceSupport(home string) *scan.CategoryResult {
 	return cr
 }
 
+// deviceSupportVersionRe matches the OS version in a DeviceSupport
+// directory name such as "17.2 (21C62)" or "iPhone15,2 17.2 (21C62)".
+var deviceSupportVersionRe = regexp.MustCompile(`(?:^|\s)(\d+(?:\.\d+)*)(?:\s|$)`)
+
+// deviceSupportVersion returns the numeric components of the OS version in
+// a DeviceSupport directory name, or nil if it has none.
+func deviceSupportVersion(name string) []int {
+	m := deviceSupportVersionRe.FindStringSubmatch(name)
+	if m == nil {
+		return nil
+	}
+	var version []int
+	for _, part := range strings.Split(m[1], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return nil
+		}
+		version = append(version, n)
+	}
+	return version
+}
+
+// deviceSupportFamily returns the device family of a DeviceSupport
+// directory: its model prefix without the identifier numbers ("iPhone" for
+// "iPhone15,2 17.0 (21A329)"), or "" for the generic per-OS directories.
+func deviceSupportFamily(name string) string {
+	loc := deviceSupportVersionRe.FindStringIndex(name)
+	if loc == nil {
+		return ""
+	}
+	return strings.TrimRight(strings.TrimSpace(name[:loc[0]]), "0123456789,")
+}
+
+// keepNewestDeviceSupport removes the entry with the highest OS version of
+// each device family from cr, whatever the order of its entries, and
+// subtracts their sizes from TotalSize. Entries without a version are kept
+// in the result.
+func keepNewestDeviceSupport(cr *scan.CategoryResult) {
+	newest := make(map[string]int)
+	newestVersion := make(map[string][]int)
+	for i, e := range cr.Entries {
+		name := filepath.Base(e.Path)
+		v := deviceSupportVersion(name)
+		if v == nil {
+			continue
+		}
+		family := deviceSupportFamily(name)
+		if _, ok := newest[family]; !ok || compareVersions(v, newestVersion[family]) > 0 {
+			newest[family] = i
+			newestVersion[family] = v
+		}
+	}
+	retained := make(map[int]bool, len(newest))
+	for _, i := range newest {
+		retained[i] = true
+	}
+	entries := cr.Entries[:0]
+	for i, e := range cr.Entries {
+		if retained[i] {
+			cr.TotalSize -= e.Size
+			continue
+		}
+		entries = append(entries, e)
+	}
+	cr.Entries = entries
+}
+
+// compareVersions compares two versions component by component; missing
+// components count as zero. A nil version sorts before any other.
+func compareVersions(a, b []int) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+	for i := 0; i < len(a) || i < len(b); i++ {
+		var x, y int
+		if i < len(a) {
+			x = a[i]
+		}
+		if i < len(b) {
+			y = b[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// sortDeviceSupport labels DeviceSupport entries with their iOS version
+// (e.g. "iOS 17.2 (21C62)") and sorts them newest version first. Entries
+// without a recognizable version keep their name and sort last.
+func sortDeviceSupport(entries []scan.ScanEntry) {
+	for i := range entries {
+		name := filepath.Base(entries[i].Path)
+		loc := deviceSupportVersionRe.FindStringSubmatchIndex(name)
+		if loc == nil {
+			continue
+		}
+		label := "iOS " + name[loc[2]:]
+		if prefix := strings.TrimSpace(name[:loc[2]]); prefix != "" {
+			label += " (" + prefix + ")"
+		}
+		entries[i].Description = label
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		vi := deviceSupportVersion(filepath.Base(entries[i].Path))
+		vj := deviceSupportVersion(filepath.Base(entries[j].Path))
+		return compareVersions(vi, vj) > 0
+	})
+}
+
 // scanXcodeArchives scans ~/Library/Developer/Xcode/Archives/.
 // Returns nil if the directory does not exist.
-func scanXcodeArchives(home string) *scan.CategoryResult {
+func scanXcodeArchives(home string, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, "Library", "Developer", "Xcode", "Archives")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -462,7 +1024,7 @@ func scanXcodeArchives(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "dev-xcode-archives", "Xcode Archives")
+	cr, err := scan.ScanTopLevelDepth(dir, "dev-xcode-archives", "Xcode Archives", depth)
 	if err != nil {
 		return nil
 	}
@@ -474,9 +1036,110 @@ func scanXcodeArchives(home string) *scan.CategoryResult {
 	return cr
 }
 
-// scanPnpmStore scans ~/Library/pnpm/store/.
+// scanExpandedBlob lists the top-level entries of a directory normally
+// reported as a single blob. Returns nil if it holds nothing.
+func scanExpandedBlob(dir, category, description string, depth int) *scan.CategoryResult {
+	cr, err := scan.ScanTopLevelDepth(dir, category, description, depth)
+	if err != nil {
+		return nil
+	}
+	if len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0 {
+		return nil
+	}
+	return cr
+}
+
+// profileExpiration and profileName extract the expiration date and name
+// from the XML plist embedded in a signed provisioning profile.
+var (
+	profileExpiration = regexp.MustCompile(`<key>ExpirationDate</key>\s*<date>([^<]+)</date>`)
+	profileName       = regexp.MustCompile(`<key>Name</key>\s*<string>([^<]*)</string>`)
+)
+
+// scanMobileDevice scans the exported app products in
+// ~/Library/Developer/Xcode/Products/ and the provisioning profiles in
+// ~/Library/MobileDevice/Provisioning Profiles/ that expired before now.
+// Profiles without a readable expiration date are left alone. Returns nil
+// if neither holds anything.
+func scanMobileDevice(home string, now time.Time, depth int) *scan.CategoryResult {
+	const category, description = "dev-mobiledevice", "Xcode Products & Expired Provisioning Profiles"
+	cr := &scan.CategoryResult{Category: category, Description: description}
+
+	products := filepath.Join(home, "Library", "Developer", "Xcode", "Products")
+	if _, err := os.Stat(products); err == nil {
+		if pr, err := scan.ScanTopLevelDepth(products, category, description, depth); err == nil {
+			cr.Entries = append(cr.Entries, pr.Entries...)
+			cr.PermissionIssues = append(cr.PermissionIssues, pr.PermissionIssues...)
+			cr.Truncated = pr.Truncated
+		}
+	} else if os.IsPermission(err) {
+		cr.PermissionIssues = append(cr.PermissionIssues, scan.PermissionIssue{
+			Path:        products,
+			Description: "Xcode Products (permission denied)",
+		})
+	}
+
+	profiles := filepath.Join(home, "Library", "MobileDevice", "Provisioning Profiles")
+	entries, err := os.ReadDir(profiles)
+	if err != nil && os.IsPermission(err) {
+		cr.PermissionIssues = append(cr.PermissionIssues, scan.PermissionIssue{
+			Path:        profiles,
+			Description: "Provisioning Profiles (permission denied)",
+		})
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if !entry.Type().IsRegular() || (ext != ".mobileprovision" && ext != ".provisionprofile") {
+			continue
+		}
+		path := filepath.Join(profiles, entry.Name())
+		if blocked, reason := safety.IsPathBlocked(path); blocked {
+			safety.WarnBlocked(path, reason)
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		m := profileExpiration.FindSubmatch(data)
+		if m == nil {
+			continue
+		}
+		expires, err := time.Parse(time.RFC3339, string(m[1]))
+		if err != nil || !expires.Before(now) {
+			continue
+		}
+		name := entry.Name()
+		if n := profileName.FindSubmatch(data); n != nil && len(n[1]) > 0 {
+			name = string(n[1])
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		cr.Entries = append(cr.Entries, scan.ScanEntry{
+			Path:        path,
+			Description: fmt.Sprintf("%s (expired %s)", name, scan.FormatMonth(expires)),
+			Size:        scan.FileSize(info),
+		})
+	}
+
+	if len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0 {
+		return nil
+	}
+	sort.SliceStable(cr.Entries, func(i, j int) bool {
+		return cr.Entries[i].Size > cr.Entries[j].Size
+	})
+	for _, e := range cr.Entries {
+		cr.TotalSize += e.Size
+	}
+	return cr
+}
+
+// scanPnpmStore scans ~/Library/pnpm/store/ as a single blob, or per
+// top-level entry when scan.ExpandBlobs is on.
 // Returns nil if the directory does not exist.
-func scanPnpmStore(home string) *scan.CategoryResult {
+func scanPnpmStore(home string, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, "Library", "pnpm", "store")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -493,6 +1156,10 @@ func scanPnpmStore(home string) *scan.CategoryResult {
 		return nil
 	}
 
+	if scan.ExpandBlobs() {
+		return scanExpandedBlob(dir, "dev-pnpm", "pnpm Store", depth)
+	}
+
 	size, err := scan.DirSize(dir)
 	if err != nil {
 		if os.IsPermission(err) {
@@ -520,6 +1187,7 @@ func scanPnpmStore(home string) *scan.CategoryResult {
 				Path:        dir,
 				Description: "pnpm",
 				Size:        size,
+				IsDir:       scan.IsDir(dir),
 			},
 		},
 		TotalSize: size,
@@ -528,7 +1196,7 @@ func scanPnpmStore(home string) *scan.CategoryResult {
 
 // scanCocoaPods scans ~/Library/Caches/CocoaPods/.
 // Returns nil if the directory does not exist.
-func scanCocoaPods(home string) *scan.CategoryResult {
+func scanCocoaPods(home string, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, "Library", "Caches", "CocoaPods")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -545,7 +1213,7 @@ func scanCocoaPods(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "dev-cocoapods", "CocoaPods Cache")
+	cr, err := scan.ScanTopLevelDepth(dir, "dev-cocoapods", "CocoaPods Cache", depth)
 	if err != nil {
 		return nil
 	}
@@ -559,7 +1227,7 @@ func scanCocoaPods(home string) *scan.CategoryResult {
 
 // scanGradle scans ~/.gradle/caches/.
 // Returns nil if the directory does not exist.
-func scanGradle(home string) *scan.CategoryResult {
+func scanGradle(home string, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, ".gradle", "caches")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -576,7 +1244,7 @@ func scanGradle(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "dev-gradle", "Gradle Cache")
+	cr, err := scan.ScanTopLevelDepth(dir, "dev-gradle", "Gradle Cache", depth)
 	if err != nil {
 		return nil
 	}
@@ -590,7 +1258,7 @@ func scanGradle(home string) *scan.CategoryResult {
 
 // scanPip scans ~/Library/Caches/pip/.
 // Returns nil if the directory does not exist.
-func scanPip(home string) *scan.CategoryResult {
+func scanPip(home string, depth int) *scan.CategoryResult {
 	dir := filepath.Join(home, "Library", "Caches", "pip")
 
 	if _, err := os.Stat(dir); err != nil {
@@ -607,7 +1275,7 @@ func scanPip(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "dev-pip", "pip Cache")
+	cr, err := scan.ScanTopLevelDepth(dir, "dev-pip", "pip Cache", depth)
 	if err != nil {
 		return nil
 	}
@@ -618,3 +1286,249 @@ func scanPip(home string) *scan.CategoryResult {
 
 	return cr
 }
+
+// scanDockerVMDisk reports Docker Desktop's VM disk image (Docker.raw).
+// The image is a sparse file that grows but never shrinks, so its size is
+// measured in allocated blocks rather than apparent length. Both the current
+// (vms/0/data/) and legacy (vms/0/) locations are checked.
+//
+// Deleting Docker.raw resets Docker Desktop: all images, containers, and
+// volumes are lost and the image is recreated empty on next launch. Docker
+// Desktop must be quit first. To reclaim space without losing data, use
+// Docker Desktop's "Clean / Purge data" or compact the image instead.
+// Returns nil if no disk image exists.
+func scanDockerVMDisk(home string) *scan.CategoryResult {
+	vmDir := filepath.Join(home, "Library", "Containers", "com.docker.docker", "Data", "vms", "0")
+	paths := []string{
+		filepath.Join(vmDir, "data", "Docker.raw"),
+		filepath.Join(vmDir, "Docker.raw"),
+	}
+
+	var entries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, p := range paths {
+		size, err := scan.AllocatedSize(p)
+		if err != nil {
+			if os.IsPermission(err) {
+				permIssues = append(permIssues, scan.PermissionIssue{
+					Path:        p,
+					Description: "Docker VM disk image (permission denied)",
+				})
+			}
+			continue
+		}
+		if size == 0 {
+			continue
+		}
+		entries = append(entries, scan.ScanEntry{
+			Path:        p,
+			Description: "Docker.raw (quit Docker Desktop first; deletes all images and volumes)",
+			Size:        size,
+			IsDir:       scan.IsDir(p),
+		})
+		totalSize += size
+	}
+
+	if len(entries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	return &scan.CategoryResult{
+		Category:         "dev-docker-vm",
+		Description:      "Docker Desktop VM Disk",
+		Entries:          entries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}
+}
+
+// projectMaxAge is how long a node_modules directory, virtualenv or
+// __pycache__ must have been unchanged to be reported as stale.
+const projectMaxAge = 90 * 24 * time.Hour
+
+// projectMaxDepth bounds how deep below a project root directories are
+// looked for, e.g. 4 finds ~/Projects/a/b/c/node_modules.
+const projectMaxDepth = 4
+
+// ProjectRoots returns the default directories searched for stale
+// node_modules and Python environments: ~/Developer, ~/Projects and
+// ~/Documents/code.
+func ProjectRoots(home string) []string {
+	return []string{
+		filepath.Join(home, "Developer"),
+		filepath.Join(home, "Projects"),
+		filepath.Join(home, "Documents", "code"),
+	}
+}
+
+// scanNodeModules reports node_modules directories under roots, at most
+// maxDepth levels down, whose newest file or directory is older than
+// maxAge. Each entry is described by its project path. Hidden directories
+// are descended into only when includeHidden is set, and nested
+// node_modules are covered by their outermost one. Returns nil if nothing
+// stale is found.
+func scanNodeModules(home string, roots []string, maxAge time.Duration, maxDepth int, includeHidden bool) *scan.CategoryResult {
+	var entries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, root := range roots {
+		found := findProjectDirs(root, maxDepth, includeHidden, func(_ string, name string) bool {
+			return name == "node_modules"
+		})
+		for _, dir := range found {
+			entry, issue, ok := staleProjectEntry(dir, maxAge, "node_modules")
+			if issue != nil {
+				permIssues = append(permIssues, *issue)
+			}
+			if !ok {
+				continue
+			}
+			entry.Description = tildePath(home, filepath.Dir(dir))
+			entries = append(entries, entry)
+			totalSize += entry.Size
+		}
+	}
+
+	if len(entries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	scan.SortBySize(entries)
+
+	return &scan.CategoryResult{
+		Category:         "dev-node-modules",
+		Description:      "Stale node_modules (90+ days)",
+		Entries:          entries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}
+}
+
+// scanPyEnvs reports virtualenvs (.venv or venv directories containing
+// pyvenv.cfg) and __pycache__ directories under roots, at most maxDepth
+// levels down, whose newest file or directory is older than maxAge. Each
+// entry is described by its project, the directory directly below the
+// root, followed by the path inside it. Hidden directories other than
+// .venv are descended into only when includeHidden is set. Returns nil if
+// nothing stale is found.
+func scanPyEnvs(home string, roots []string, maxAge time.Duration, maxDepth int, includeHidden bool) *scan.CategoryResult {
+	var entries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, root := range roots {
+		found := findProjectDirs(root, maxDepth, includeHidden, func(path, name string) bool {
+			return name == "__pycache__" || isVirtualenv(path, name)
+		})
+		for _, dir := range found {
+			entry, issue, ok := staleProjectEntry(dir, maxAge, filepath.Base(dir))
+			if issue != nil {
+				permIssues = append(permIssues, *issue)
+			}
+			if !ok {
+				continue
+			}
+			rel, _ := filepath.Rel(root, dir)
+			project, inner, _ := strings.Cut(rel, string(filepath.Separator))
+			entry.Description = fmt.Sprintf("%s (%s)", tildePath(home, filepath.Join(root, project)), inner)
+			entries = append(entries, entry)
+			totalSize += entry.Size
+		}
+	}
+
+	if len(entries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	scan.SortBySize(entries)
+
+	return &scan.CategoryResult{
+		Category:         "dev-pyenvs",
+		Description:      "Stale Python virtualenvs and __pycache__ (90+ days)",
+		Entries:          entries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}
+}
+
+// isVirtualenv reports whether the directory at path, named name, is a
+// Python virtualenv: a .venv or venv directory containing pyvenv.cfg.
+func isVirtualenv(path, name string) bool {
+	if name != ".venv" && name != "venv" {
+		return false
+	}
+	info, err := os.Stat(filepath.Join(path, "pyvenv.cfg"))
+	return err == nil && info.Mode().IsRegular()
+}
+
+// staleProjectEntry sizes dir and returns it as an entry when its newest
+// file or directory is older than maxAge. ok is false for blocked, recent,
+// empty or unreadable directories; a permission error is returned as an
+// issue labelled with label.
+func staleProjectEntry(dir string, maxAge time.Duration, label string) (entry scan.ScanEntry, issue *scan.PermissionIssue, ok bool) {
+	if blocked, reason := safety.IsPathBlocked(dir); blocked {
+		safety.WarnBlocked(dir, reason)
+		return entry, nil, false
+	}
+	latest, err := scan.LatestModTime(dir)
+	if err != nil || time.Since(latest) <= maxAge {
+		return entry, nil, false
+	}
+	size, err := scan.DirSize(dir)
+	if err != nil {
+		if os.IsPermission(err) {
+			issue = &scan.PermissionIssue{
+				Path:        dir,
+				Description: label + " (permission denied)",
+			}
+		}
+		return entry, issue, false
+	}
+	if size == 0 {
+		return entry, nil, false
+	}
+	return scan.ScanEntry{
+		Path:    dir,
+		Size:    size,
+		IsDir:   true,
+		ModTime: latest,
+	}, nil, true
+}
+
+// tildePath abbreviates path with "~" when it is inside home.
+func tildePath(home, path string) string {
+	if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
+
+// findProjectDirs returns the directories under root, at most maxDepth
+// levels down, for which match returns true; matched directories are not
+// descended into. Symlinks and node_modules are not followed, nor are
+// other hidden directories unless includeHidden is set. A missing root
+// yields nothing.
+func findProjectDirs(root string, maxDepth int, includeHidden bool, match func(path, name string) bool) []string {
+	var found []string
+	_ = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.IsDir() || path == root {
+			return nil
+		}
+		if match(path, d.Name()) {
+			found = append(found, path)
+			return filepath.SkipDir
+		}
+		if (!includeHidden && strings.HasPrefix(d.Name(), ".")) || d.Name() == "node_modules" {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil || strings.Count(rel, string(filepath.Separator))+1 >= maxDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	return found
+}
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -27,7 +30,7 @@ func writeFile(t *testing.T, path string, size int) {
 
 func TestScanXcodeMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanXcodeDerivedData(home)
+	result := scanXcodeDerivedData(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Xcode DerivedData")
 	}
@@ -39,7 +42,7 @@ func TestScanXcodeWithData(t *testing.T) {
 	writeFile(t, filepath.Join(derivedData, "MyApp-abc123", "Build", "Products", "app.o"), 1000)
 	writeFile(t, filepath.Join(derivedData, "OtherApp-def456", "Build", "Products", "lib.o"), 500)
 
-	result := scanXcodeDerivedData(home)
+	result := scanXcodeDerivedData(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Xcode with data")
 	}
@@ -70,17 +73,95 @@ func TestScanXcodeEmptyDir(t *testing.T) {
 	derivedData := filepath.Join(home, "Library", "Developer", "Xcode", "DerivedData")
 	os.MkdirAll(derivedData, 0755)
 
-	result := scanXcodeDerivedData(home)
+	result := scanXcodeDerivedData(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty DerivedData directory")
 	}
 }
 
+// --- Xcode index store tests ---
+
+func TestScanXcodeIndexMissing(t *testing.T) {
+	home := t.TempDir()
+	if result := scanXcodeIndex(home); result != nil {
+		t.Fatal("expected nil for missing Xcode DerivedData")
+	}
+}
+
+func TestScanXcodeIndexReportedDistinctly(t *testing.T) {
+	home := t.TempDir()
+	derivedData := filepath.Join(home, "Library", "Developer", "Xcode", "DerivedData")
+	writeFile(t, filepath.Join(derivedData, "MyApp-abc123", "Build", "Products", "app.o"), 1000)
+	writeFile(t, filepath.Join(derivedData, "MyApp-abc123", "Index.noindex", "DataStore", "v5", "units", "u1"), 700)
+	writeFile(t, filepath.Join(derivedData, "MyApp-abc123", "Index.noindex", "PrecompiledHeaders", "h1"), 100)
+	// A project without an index store is not reported.
+	writeFile(t, filepath.Join(derivedData, "OtherApp-def456", "Build", "Products", "lib.o"), 500)
+
+	result := scanXcodeIndex(home)
+	if result == nil {
+		t.Fatal("expected non-nil result for project with an index store")
+	}
+	if result.Category != "dev-xcode-index" {
+		t.Errorf("expected category 'dev-xcode-index', got %q", result.Category)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(result.Entries))
+	}
+	entry := result.Entries[0]
+	wantPath := filepath.Join(derivedData, "MyApp-abc123", "Index.noindex")
+	if entry.Path != wantPath {
+		t.Errorf("expected path %q, got %q", wantPath, entry.Path)
+	}
+	if entry.Description != "MyApp-abc123 index" {
+		t.Errorf("expected description 'MyApp-abc123 index', got %q", entry.Description)
+	}
+	if entry.Size != 800 || result.TotalSize != 800 {
+		t.Errorf("expected size 800, got entry %d total %d", entry.Size, result.TotalSize)
+	}
+	if !entry.IsDir {
+		t.Error("expected index store entry to be a directory")
+	}
+}
+
+func TestScanWithOptionsCountsIndexOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	home := t.TempDir()
+	safety.SetHome(home)
+	t.Cleanup(func() { safety.SetHome("") })
+	derivedData := filepath.Join(home, "Library", "Developer", "Xcode", "DerivedData")
+	writeFile(t, filepath.Join(derivedData, "MyApp-abc123", "Build", "Products", "app.o"), 1000)
+	writeFile(t, filepath.Join(derivedData, "MyApp-abc123", "Index.noindex", "DataStore", "u1"), 800)
+
+	results, err := ScanWithOptions(Options{NoExec: true, Home: home})
+	if err != nil {
+		t.Fatal(err)
+	}
+	totals := map[string]int64{}
+	for _, cr := range results {
+		totals[cr.Category] = cr.TotalSize
+	}
+	if totals["dev-xcode"] != 1000 || totals["dev-xcode-index"] != 800 {
+		t.Errorf("expected DerivedData 1000 and index 800, got %v", totals)
+	}
+
+	// dev-xcode deletes the project's other items, not the folder holding
+	// the index, and each size is what its deletion frees.
+	for _, cr := range results {
+		if cr.Category != "dev-xcode" {
+			continue
+		}
+		want := filepath.Join(derivedData, "MyApp-abc123", "Build")
+		if len(cr.Entries) != 1 || cr.Entries[0].Path != want || cr.Entries[0].Size != 1000 {
+			t.Errorf("expected one 1000-byte entry for %s, got %+v", want, cr.Entries)
+		}
+	}
+}
+
 // --- npm cache tests ---
 
 func TestScanNpmMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanNpmCache(home)
+	result := scanNpmCache(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing npm cache")
 	}
@@ -92,7 +173,7 @@ func TestScanNpmWithData(t *testing.T) {
 	writeFile(t, filepath.Join(npmDir, "_cacache", "content-v2", "sha512", "pkg.tgz"), 2000)
 	writeFile(t, filepath.Join(npmDir, "_logs", "debug.log"), 100)
 
-	result := scanNpmCache(home)
+	result := scanNpmCache(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for npm with data")
 	}
@@ -110,11 +191,33 @@ func TestScanNpmWithData(t *testing.T) {
 	}
 }
 
+func TestScanWithOptionsMaxDepth(t *testing.T) {
+	home := t.TempDir()
+	npmDir := filepath.Join(home, ".npm")
+	writeFile(t, filepath.Join(npmDir, "_cacache", "content-v2", "sha512", "pkg.tgz"), 2000)
+	writeFile(t, filepath.Join(npmDir, "_logs", "debug.log"), 100)
+
+	results, err := ScanWithOptions(Options{NoExec: true, Home: home, MaxDepth: 1})
+	if err != nil {
+		t.Fatalf("ScanWithOptions: %v", err)
+	}
+	for _, cr := range results {
+		if cr.Category != "dev-npm" {
+			continue
+		}
+		if cr.TotalSize != 100 || !cr.Truncated {
+			t.Errorf("dev-npm at depth 1: total %d, truncated %v; want 100, true", cr.TotalSize, cr.Truncated)
+		}
+		return
+	}
+	t.Fatal("dev-npm missing from results")
+}
+
 // --- yarn cache tests ---
 
 func TestScanYarnMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanYarnCache(home)
+	result := scanYarnCache(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing yarn cache")
 	}
@@ -126,7 +229,7 @@ func TestScanYarnWithData(t *testing.T) {
 	writeFile(t, filepath.Join(yarnDir, "v6", ".tmp", "pkg1.tgz"), 3000)
 	writeFile(t, filepath.Join(yarnDir, "v6", ".tmp", "pkg2.tgz"), 1500)
 
-	result := scanYarnCache(home)
+	result := scanYarnCache(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for yarn with data")
 	}
@@ -149,11 +252,44 @@ func TestScanYarnWithData(t *testing.T) {
 	}
 }
 
+func TestScanYarnExpandBlobs(t *testing.T) {
+	home := t.TempDir()
+	yarnDir := filepath.Join(home, "Library", "Caches", "yarn")
+	writeFile(t, filepath.Join(yarnDir, "v6", "npm-lodash", "pkg.tgz"), 3000)
+	writeFile(t, filepath.Join(yarnDir, "v4", "npm-react", "pkg.tgz"), 1500)
+	writeFile(t, filepath.Join(yarnDir, ".yarn-metadata.json"), 200)
+
+	blob := scanYarnCache(home, 0)
+	if blob == nil || len(blob.Entries) != 1 {
+		t.Fatalf("expected a single blob entry by default, got %+v", blob)
+	}
+
+	scan.SetExpandBlobs(true)
+	t.Cleanup(func() { scan.SetExpandBlobs(false) })
+
+	result := scanYarnCache(home, 0)
+	if result == nil {
+		t.Fatal("expected non-nil result for yarn with data")
+	}
+	if result.Category != "dev-yarn" {
+		t.Errorf("expected category 'dev-yarn', got %q", result.Category)
+	}
+	if len(result.Entries) != 3 {
+		t.Fatalf("expected 3 top-level entries, got %d", len(result.Entries))
+	}
+	if got := filepath.Base(result.Entries[0].Path); got != "v6" {
+		t.Errorf("expected the largest version first, got %s", got)
+	}
+	if result.TotalSize != blob.TotalSize {
+		t.Errorf("expanded total %d differs from blob total %d", result.TotalSize, blob.TotalSize)
+	}
+}
+
 // --- Homebrew cache tests ---
 
 func TestScanHomebrewMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanHomebrew(home)
+	result := scanHomebrew(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Homebrew cache")
 	}
@@ -165,7 +301,7 @@ func TestScanHomebrewWithData(t *testing.T) {
 	writeFile(t, filepath.Join(brewDir, "downloads", "pkg1.bottle.tar.gz"), 5000)
 	writeFile(t, filepath.Join(brewDir, "Cask", "firefox.dmg"), 8000)
 
-	result := scanHomebrew(home)
+	result := scanHomebrew(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Homebrew with data")
 	}
@@ -183,6 +319,81 @@ func TestScanHomebrewWithData(t *testing.T) {
 	}
 }
 
+// --- brew autoremove tests ---
+
+// fakeBrewPath creates a temporary directory with a fake brew executable
+// and prepends it to PATH so exec.LookPath("brew") succeeds.
+func fakeBrewPath(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "brew"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("create fake brew: %v", err)
+	}
+	t.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+}
+
+func TestScanBrewAutoremoveNotInstalled(t *testing.T) {
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		t.Fatal("runner should not be called when brew is not installed")
+		return nil, nil
+	}
+	t.Setenv("PATH", t.TempDir())
+
+	if result := scanBrewAutoremove(runner, nil); result != nil {
+		t.Fatal("expected nil when brew is not installed")
+	}
+}
+
+func TestScanBrewAutoremoveWithCandidates(t *testing.T) {
+	fakeBrewPath(t)
+	prefix := t.TempDir()
+	writeFile(t, filepath.Join(prefix, "Cellar", "libfoo", "1.0", "lib", "libfoo.dylib"), 4000)
+	writeFile(t, filepath.Join(prefix, "Cellar", "python@3.10", "3.10.13", "bin", "python3"), 6000)
+	writeFile(t, filepath.Join(prefix, "Cellar", "git", "2.43.0", "bin", "git"), 9000)
+
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		switch strings.Join(args, " ") {
+		case "autoremove --dry-run":
+			return []byte("==> Would autoremove 3 unneeded formulae:\nlibfoo\npython@3.10\nlibgone\n"), nil
+		case "--prefix":
+			return []byte(prefix + "\n"), nil
+		}
+		return nil, fmt.Errorf("unexpected command: %s %v", name, args)
+	}
+
+	result := scanBrewAutoremove(runner, nil)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Category != "dev-brew-autoremove" {
+		t.Errorf("expected category 'dev-brew-autoremove', got %q", result.Category)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(result.Entries))
+	}
+	entry := result.Entries[0]
+	if entry.Path != "brew:autoremove" {
+		t.Errorf("expected pseudo-path 'brew:autoremove', got %q", entry.Path)
+	}
+	if !strings.HasPrefix(entry.Description, "3 unneeded Homebrew dependencies") {
+		t.Errorf("expected 3 candidates in description, got %q", entry.Description)
+	}
+	// libgone has no Cellar directory and contributes nothing.
+	if entry.Size != 10000 || result.TotalSize != 10000 {
+		t.Errorf("expected size 10000, got entry %d total %d", entry.Size, result.TotalSize)
+	}
+}
+
+func TestScanBrewAutoremoveNothingToRemove(t *testing.T) {
+	fakeBrewPath(t)
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return nil, nil
+	}
+	if result := scanBrewAutoremove(runner, nil); result != nil {
+		t.Fatal("expected nil when brew would remove nothing")
+	}
+}
+
 // --- Docker tests ---
 
 func TestScanDockerNotInstalled(t *testing.T) {
@@ -197,10 +408,13 @@ func TestScanDockerNotInstalled(t *testing.T) {
 	t.Setenv("PATH", t.TempDir())
 	defer os.Setenv("PATH", origPath)
 
-	result := scanDocker(runner)
+	result, status := scanDocker(runner)
 	if result != nil {
 		t.Fatal("expected nil when docker is not installed")
 	}
+	if status != scan.StatusToolMissing {
+		t.Errorf("expected status %q, got %q", scan.StatusToolMissing, status)
+	}
 }
 
 func TestScanDockerDaemonStopped(t *testing.T) {
@@ -210,10 +424,13 @@ func TestScanDockerDaemonStopped(t *testing.T) {
 		return nil, fmt.Errorf("Cannot connect to the Docker daemon")
 	}
 
-	result := scanDocker(runner)
+	result, status := scanDocker(runner)
 	if result != nil {
 		t.Fatal("expected nil when Docker daemon is not running")
 	}
+	if status != scan.StatusError {
+		t.Errorf("expected status %q, got %q", scan.StatusError, status)
+	}
 }
 
 // fakeDockerPath creates a temporary directory with a fake docker executable
@@ -239,10 +456,13 @@ func TestScanDockerWithData(t *testing.T) {
 		return []byte(output), nil
 	}
 
-	result := scanDocker(runner)
+	result, status := scanDocker(runner)
 	if result == nil {
 		t.Fatal("expected non-nil result for Docker with data")
 	}
+	if status != scan.StatusFound {
+		t.Errorf("expected status %q, got %q", scan.StatusFound, status)
+	}
 
 	if result.Category != "dev-docker" {
 		t.Errorf("expected category 'dev-docker', got %q", result.Category)
@@ -275,10 +495,13 @@ func TestScanDockerEmptyOutput(t *testing.T) {
 		return []byte(""), nil
 	}
 
-	result := scanDocker(runner)
+	result, status := scanDocker(runner)
 	if result != nil {
 		t.Fatal("expected nil for empty Docker output")
 	}
+	if status != scan.StatusNoData {
+		t.Errorf("expected status %q, got %q", scan.StatusNoData, status)
+	}
 }
 
 func TestScanDockerAllZero(t *testing.T) {
@@ -290,10 +513,104 @@ func TestScanDockerAllZero(t *testing.T) {
 		return []byte(output), nil
 	}
 
-	result := scanDocker(runner)
+	result, status := scanDocker(runner)
 	if result != nil {
 		t.Fatal("expected nil when all Docker reclaimable sizes are 0B")
 	}
+	if status != scan.StatusNoData {
+		t.Errorf("expected status %q, got %q", scan.StatusNoData, status)
+	}
+}
+
+func TestScanDockerCachesWithoutCLI(t *testing.T) {
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		t.Fatal("runner should not be called when docker is not installed")
+		return nil, nil
+	}
+	t.Setenv("PATH", t.TempDir())
+
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".docker", "buildx", "refs", "default", "ref"), 4000)
+	writeFile(t, filepath.Join(home, "Library", "Containers", "com.docker.docker", "Data", "log", "vm", "console.log"), 1000)
+
+	result, status := scanDockerCaches(home, runner)
+	if result == nil {
+		t.Fatal("expected on-disk Docker data to be reported without the docker CLI")
+	}
+	if status != scan.StatusFound {
+		t.Errorf("expected status %q, got %q", scan.StatusFound, status)
+	}
+	if result.Category != "dev-docker" {
+		t.Errorf("expected category 'dev-docker', got %q", result.Category)
+	}
+	if result.Description != "Docker Data on Disk" {
+		t.Errorf("expected description 'Docker Data on Disk', got %q", result.Description)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(result.Entries))
+	}
+	buildx := result.Entries[0]
+	if buildx.Path != "docker:buildx-cache" || buildx.Size != 4000 {
+		t.Errorf("expected an informational pseudo-entry for the buildx cache, got %+v", buildx)
+	}
+	if !strings.Contains(buildx.Description, filepath.Join(home, ".docker", "buildx")) {
+		t.Errorf("entry should name the directory it sized, got %q", buildx.Description)
+	}
+	if !strings.Contains(buildx.Description, "docker CLI unavailable") {
+		t.Errorf("entry should say the CLI is unavailable, got %q", buildx.Description)
+	}
+	if result.TotalSize != 5000 {
+		t.Errorf("expected total size 5000, got %d", result.TotalSize)
+	}
+}
+
+func TestScanDockerCachesPrefersCLI(t *testing.T) {
+	fakeDockerPath(t)
+	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		return []byte(`{"Type":"Build Cache","Reclaimable":"3.5GB"}`), nil
+	}
+
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".docker", "buildx", "ref"), 4000)
+
+	result, _ := scanDockerCaches(home, runner)
+	if result == nil || result.Description != "Docker Reclaimable" {
+		t.Fatalf("expected CLI-based result, got %+v", result)
+	}
+}
+
+func TestScanDockerCachesNothingOnDisk(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	result, status := scanDockerCaches(t.TempDir(), nil)
+	if result != nil {
+		t.Errorf("expected nil without docker CLI or data, got %+v", result)
+	}
+	if status != scan.StatusDisabled {
+		t.Errorf("expected the CLI's status %q when no data dirs exist, got %q", scan.StatusDisabled, status)
+	}
+}
+
+func TestScanDockerCachesStatus(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	home := t.TempDir()
+	if _, status := scanDockerCaches(home, defaultRunner); status != scan.StatusToolMissing {
+		t.Errorf("no docker and no data dirs: expected %q, got %q", scan.StatusToolMissing, status)
+	}
+
+	if err := os.MkdirAll(filepath.Join(home, ".docker", "buildx"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, status := scanDockerCaches(home, defaultRunner); status != scan.StatusNoData {
+		t.Errorf("empty data dir: expected %q, got %q", scan.StatusNoData, status)
+	}
+}
+
+func TestScanDockerDataDirsMissingDir(t *testing.T) {
+	result, status := scanDockerDataDirs(t.TempDir())
+	if result != nil || status != scan.StatusDirAbsent {
+		t.Errorf("expected nil with %q, got %+v, %q", scan.StatusDirAbsent, result, status)
+	}
 }
 
 // --- parseDockerSize tests ---
@@ -333,7 +650,7 @@ func TestParseDockerSize(t *testing.T) {
 
 func TestScanSimulatorCachesMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanSimulatorCaches(home)
+	result := scanSimulatorCaches(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Simulator Caches")
 	}
@@ -345,7 +662,7 @@ func TestScanSimulatorCachesWithData(t *testing.T) {
 	writeFile(t, filepath.Join(dir, "com.apple.CoreSimulator.SimDevice.abc", "data.bin"), 4000)
 	writeFile(t, filepath.Join(dir, "com.apple.CoreSimulator.SimDevice.def", "data.bin"), 2000)
 
-	result := scanSimulatorCaches(home)
+	result := scanSimulatorCaches(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Simulator Caches with data")
 	}
@@ -364,7 +681,7 @@ func TestScanSimulatorCachesWithData(t *testing.T) {
 
 func TestScanSimulatorLogsMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanSimulatorLogs(home)
+	result := scanSimulatorLogs(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Simulator Logs")
 	}
@@ -375,7 +692,7 @@ func TestScanSimulatorLogsWithData(t *testing.T) {
 	dir := filepath.Join(home, "Library", "Logs", "CoreSimulator")
 	writeFile(t, filepath.Join(dir, "device-abc", "system.log"), 1500)
 
-	result := scanSimulatorLogs(home)
+	result := scanSimulatorLogs(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Simulator Logs with data")
 	}
@@ -391,7 +708,7 @@ func TestScanSimulatorLogsWithData(t *testing.T) {
 
 func TestScanXcodeDeviceSupportMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanXcodeDeviceSupport(home)
+	result := scanXcodeDeviceSupport(home, false, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Xcode Device Support")
 	}
@@ -403,7 +720,7 @@ func TestScanXcodeDeviceSupportWithData(t *testing.T) {
 	writeFile(t, filepath.Join(dir, "16.0 (20A362)", "Symbols", "sym.db"), 5000)
 	writeFile(t, filepath.Join(dir, "15.0 (19A346)", "Symbols", "sym.db"), 3000)
 
-	result := scanXcodeDeviceSupport(home)
+	result := scanXcodeDeviceSupport(home, false, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Xcode Device Support with data")
 	}
@@ -418,11 +735,107 @@ func TestScanXcodeDeviceSupportWithData(t *testing.T) {
 	}
 }
 
+func TestScanXcodeDeviceSupportPerVersion(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "Library", "Developer", "Xcode", "iOS DeviceSupport")
+	writeFile(t, filepath.Join(dir, "16.4 (20E247)", "Symbols", "sym.db"), 4000)
+	writeFile(t, filepath.Join(dir, "17.2 (21C62)", "Symbols", "sym.db"), 1000)
+	writeFile(t, filepath.Join(dir, "16.10 (20H10)", "Symbols", "sym.db"), 2000)
+	writeFile(t, filepath.Join(dir, "iPhone15,2 17.0 (21A329)", "Symbols", "sym.db"), 3000)
+
+	result := scanXcodeDeviceSupport(home, false, 0)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	want := []string{"iOS 17.2 (21C62)", "iOS 17.0 (21A329) (iPhone15,2)", "iOS 16.10 (20H10)", "iOS 16.4 (20E247)"}
+	if len(result.Entries) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(result.Entries))
+	}
+	for i, w := range want {
+		if result.Entries[i].Description != w {
+			t.Errorf("entry %d: expected %q, got %q", i, w, result.Entries[i].Description)
+		}
+	}
+	if result.TotalSize != 10000 {
+		t.Errorf("expected total size 10000, got %d", result.TotalSize)
+	}
+}
+
+func TestScanXcodeDeviceSupportKeepLatest(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "Library", "Developer", "Xcode", "iOS DeviceSupport")
+	writeFile(t, filepath.Join(dir, "16.0 (20A362)", "Symbols", "sym.db"), 5000)
+	writeFile(t, filepath.Join(dir, "17.2 (21C62)", "Symbols", "sym.db"), 1000)
+	writeFile(t, filepath.Join(dir, "15.0 (19A346)", "Symbols", "sym.db"), 3000)
+
+	result := scanXcodeDeviceSupport(home, true, 0)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(result.Entries))
+	}
+	for _, e := range result.Entries {
+		if strings.Contains(e.Path, "17.2") {
+			t.Errorf("latest version should be retained, got entry %s", e.Path)
+		}
+	}
+	if result.TotalSize != 8000 {
+		t.Errorf("expected total size 8000, got %d", result.TotalSize)
+	}
+}
+
+func TestKeepNewestDeviceSupportPerFamily(t *testing.T) {
+	// Largest first, as ScanTopLevel orders them: the newest version
+	// must be kept regardless of its size.
+	cr := &scan.CategoryResult{
+		Entries: []scan.ScanEntry{
+			{Path: "/ds/16.0 (20A362)", Size: 5000},
+			{Path: "/ds/iPhone15,2 17.0 (21A329)", Size: 4000},
+			{Path: "/ds/iPhone14,5 16.4 (20E247)", Size: 3000},
+			{Path: "/ds/17.2 (21C62)", Size: 1000},
+			{Path: "/ds/Symbols", Size: 500},
+		},
+		TotalSize: 13500,
+	}
+	keepNewestDeviceSupport(cr)
+
+	var got []string
+	for _, e := range cr.Entries {
+		got = append(got, filepath.Base(e.Path))
+	}
+	want := []string{"16.0 (20A362)", "iPhone14,5 16.4 (20E247)", "Symbols"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("expected %v offered for deletion, got %v", want, got)
+	}
+	if cr.TotalSize != 8500 {
+		t.Errorf("expected total size 8500, got %d", cr.TotalSize)
+	}
+}
+
+func TestDeviceSupportVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		want []int
+	}{
+		{"17.2 (21C62)", []int{17, 2}},
+		{"16.4.1 (20E252)", []int{16, 4, 1}},
+		{"iPhone15,2 17.0 (21A329)", []int{17, 0}},
+		{"Symbols", nil},
+	}
+	for _, tt := range tests {
+		got := deviceSupportVersion(tt.name)
+		if compareVersions(got, tt.want) != 0 || (got == nil) != (tt.want == nil) {
+			t.Errorf("deviceSupportVersion(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 // --- Xcode Archives tests ---
 
 func TestScanXcodeArchivesMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanXcodeArchives(home)
+	result := scanXcodeArchives(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Xcode Archives")
 	}
@@ -433,7 +846,7 @@ func TestScanXcodeArchivesWithData(t *testing.T) {
 	dir := filepath.Join(home, "Library", "Developer", "Xcode", "Archives")
 	writeFile(t, filepath.Join(dir, "2024-01-15", "MyApp.xcarchive", "Products", "app"), 7000)
 
-	result := scanXcodeArchives(home)
+	result := scanXcodeArchives(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Xcode Archives with data")
 	}
@@ -445,11 +858,86 @@ func TestScanXcodeArchivesWithData(t *testing.T) {
 	}
 }
 
+// --- Xcode Products and provisioning profile tests ---
+
+// writeProfile creates a provisioning profile named file whose embedded
+// plist carries name and the expiration date expires.
+func writeProfile(t *testing.T, home, file, name, expires string) string {
+	t.Helper()
+	path := filepath.Join(home, "Library", "MobileDevice", "Provisioning Profiles", file)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	body := "0\x82signed<?xml version=\"1.0\"?>\n<plist version=\"1.0\"><dict>\n" +
+		"\t<key>ExpirationDate</key>\n\t<date>" + expires + "</date>\n" +
+		"\t<key>Name</key>\n\t<string>" + name + "</string>\n" +
+		"</dict></plist>\x00trailer"
+	if err := os.WriteFile(path, []byte(body), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestScanMobileDeviceMissing(t *testing.T) {
+	home := t.TempDir()
+	if result := scanMobileDevice(home, time.Now(), 0); result != nil {
+		t.Fatal("expected nil without Products or provisioning profiles")
+	}
+}
+
+func TestScanMobileDeviceProducts(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "Library", "Developer", "Xcode", "Products")
+	writeFile(t, filepath.Join(dir, "com.example.app", "1.2 (42)", "MyApp.ipa"), 9000)
+	writeFile(t, filepath.Join(dir, "com.example.widget", "1.0 (1)", "Widget.ipa"), 1000)
+
+	result := scanMobileDevice(home, time.Now(), 0)
+	if result == nil {
+		t.Fatal("expected non-nil result for Xcode Products with data")
+	}
+	if result.Category != "dev-mobiledevice" {
+		t.Errorf("expected category 'dev-mobiledevice', got %q", result.Category)
+	}
+	if len(result.Entries) != 2 || result.TotalSize != 10000 {
+		t.Fatalf("expected 2 entries totalling 10000, got %d totalling %d", len(result.Entries), result.TotalSize)
+	}
+	if got := filepath.Base(result.Entries[0].Path); got != "com.example.app" {
+		t.Errorf("expected the largest product first, got %s", got)
+	}
+}
+
+func TestScanMobileDeviceExpiredProfiles(t *testing.T) {
+	home := t.TempDir()
+	now := time.Date(2025, time.June, 1, 0, 0, 0, 0, time.UTC)
+	expired := writeProfile(t, home, "old.mobileprovision", "iOS Team Provisioning Profile", "2024-03-10T12:00:00Z")
+	writeProfile(t, home, "current.mobileprovision", "Current Profile", "2026-03-10T12:00:00Z")
+	writeProfile(t, home, "broken.mobileprovision", "Broken", "not a date")
+	writeFile(t, filepath.Join(home, "Library", "MobileDevice", "Provisioning Profiles", "notes.txt"), 10)
+
+	result := scanMobileDevice(home, now, 0)
+	if result == nil {
+		t.Fatal("expected non-nil result with an expired profile")
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected only the expired profile, got %d entries", len(result.Entries))
+	}
+	entry := result.Entries[0]
+	if entry.Path != expired {
+		t.Errorf("expected %s, got %s", expired, entry.Path)
+	}
+	if entry.Description != "iOS Team Provisioning Profile (expired Mar 2024)" {
+		t.Errorf("unexpected description %q", entry.Description)
+	}
+	if entry.Size <= 0 || result.TotalSize != entry.Size {
+		t.Errorf("expected the profile's size counted, got entry %d total %d", entry.Size, result.TotalSize)
+	}
+}
+
 // --- pnpm Store tests ---
 
 func TestScanPnpmStoreMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanPnpmStore(home)
+	result := scanPnpmStore(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing pnpm store")
 	}
@@ -460,7 +948,7 @@ func TestScanPnpmStoreWithData(t *testing.T) {
 	dir := filepath.Join(home, "Library", "pnpm", "store")
 	writeFile(t, filepath.Join(dir, "v3", "files", "pkg.tgz"), 5000)
 
-	result := scanPnpmStore(home)
+	result := scanPnpmStore(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for pnpm store with data")
 	}
@@ -476,7 +964,7 @@ func TestScanPnpmStoreWithData(t *testing.T) {
 
 func TestScanCocoaPodsMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanCocoaPods(home)
+	result := scanCocoaPods(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing CocoaPods cache")
 	}
@@ -488,7 +976,7 @@ func TestScanCocoaPodsWithData(t *testing.T) {
 	writeFile(t, filepath.Join(dir, "Pods", "Release", "Alamofire", "pod.tar.gz"), 3000)
 	writeFile(t, filepath.Join(dir, "Pods", "Release", "SDWebImage", "pod.tar.gz"), 2000)
 
-	result := scanCocoaPods(home)
+	result := scanCocoaPods(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for CocoaPods with data")
 	}
@@ -504,7 +992,7 @@ func TestScanCocoaPodsWithData(t *testing.T) {
 
 func TestScanGradleMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanGradle(home)
+	result := scanGradle(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Gradle cache")
 	}
@@ -515,7 +1003,7 @@ func TestScanGradleWithData(t *testing.T) {
 	dir := filepath.Join(home, ".gradle", "caches")
 	writeFile(t, filepath.Join(dir, "modules-2", "files-2.1", "lib.jar"), 4000)
 
-	result := scanGradle(home)
+	result := scanGradle(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Gradle with data")
 	}
@@ -531,7 +1019,7 @@ func TestScanGradleWithData(t *testing.T) {
 
 func TestScanPipMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanPip(home)
+	result := scanPip(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing pip cache")
 	}
@@ -542,7 +1030,7 @@ func TestScanPipWithData(t *testing.T) {
 	dir := filepath.Join(home, "Library", "Caches", "pip")
 	writeFile(t, filepath.Join(dir, "wheels", "numpy.whl"), 6000)
 
-	result := scanPip(home)
+	result := scanPip(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for pip with data")
 	}
@@ -554,6 +1042,328 @@ func TestScanPipWithData(t *testing.T) {
 	}
 }
 
+// --- Docker VM disk image tests ---
+
+// writeSparseFile creates a sparse file with the given apparent size and a
+// small amount of real data at the start, creating parent directories.
+func writeSparseFile(t *testing.T, path string, apparent int64, data int) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("mkdir for %s: %v", path, err)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("create %s: %v", path, err)
+	}
+	defer f.Close()
+	if err := f.Truncate(apparent); err != nil {
+		t.Fatalf("truncate %s: %v", path, err)
+	}
+	if _, err := f.WriteAt(make([]byte, data), 0); err != nil {
+		t.Fatalf("write %s: %v", path, err)
+	}
+}
+
+func TestScanDockerVMDiskMissing(t *testing.T) {
+	home := t.TempDir()
+	result := scanDockerVMDisk(home)
+	if result != nil {
+		t.Fatal("expected nil for missing Docker.raw")
+	}
+}
+
+func TestScanDockerVMDiskWithData(t *testing.T) {
+	home := t.TempDir()
+	raw := filepath.Join(home, "Library", "Containers", "com.docker.docker", "Data", "vms", "0", "data", "Docker.raw")
+	const apparent = 256 * 1000 * 1000
+	writeSparseFile(t, raw, apparent, 64*1024)
+
+	result := scanDockerVMDisk(home)
+	if result == nil {
+		t.Fatal("expected non-nil result for Docker.raw")
+	}
+	if result.Category != "dev-docker-vm" {
+		t.Errorf("expected category 'dev-docker-vm', got %q", result.Category)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(result.Entries))
+	}
+	if result.Entries[0].Path != raw {
+		t.Errorf("expected path %q, got %q", raw, result.Entries[0].Path)
+	}
+
+	// Size must be block-based, not the sparse file's apparent length.
+	want, err := scan.AllocatedSize(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.TotalSize != want {
+		t.Errorf("expected allocated size %d, got %d", want, result.TotalSize)
+	}
+	if result.TotalSize >= apparent {
+		t.Errorf("expected size below apparent %d, got %d", apparent, result.TotalSize)
+	}
+}
+
+func TestScanDockerVMDiskLegacyPath(t *testing.T) {
+	home := t.TempDir()
+	raw := filepath.Join(home, "Library", "Containers", "com.docker.docker", "Data", "vms", "0", "Docker.raw")
+	writeFile(t, raw, 8192)
+
+	result := scanDockerVMDisk(home)
+	if result == nil {
+		t.Fatal("expected non-nil result for legacy Docker.raw path")
+	}
+	if result.Entries[0].Path != raw {
+		t.Errorf("expected path %q, got %q", raw, result.Entries[0].Path)
+	}
+}
+
+func TestScanDockerVMDiskMarkedRisky(t *testing.T) {
+	home := t.TempDir()
+	raw := filepath.Join(home, "Library", "Containers", "com.docker.docker", "Data", "vms", "0", "data", "Docker.raw")
+	writeFile(t, raw, 4096)
+
+	result := scanDockerVMDisk(home)
+	if result == nil {
+		t.Fatal("expected non-nil result for Docker.raw")
+	}
+	result.SetRiskLevels(safety.RiskForCategory)
+	if result.Entries[0].RiskLevel != safety.RiskRisky {
+		t.Errorf("expected risk %q, got %q", safety.RiskRisky, result.Entries[0].RiskLevel)
+	}
+}
+
+// --- Stale node_modules tests ---
+
+// ageTree sets the modification time of root and everything below it.
+func ageTree(t *testing.T, root string, when time.Time) {
+	t.Helper()
+	err := filepath.WalkDir(root, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		return os.Chtimes(path, when, when)
+	})
+	if err != nil {
+		t.Fatalf("ageTree %s: %v", root, err)
+	}
+}
+
+func TestScanNodeModulesMissingRoots(t *testing.T) {
+	home := t.TempDir()
+	if result := scanNodeModules(home, ProjectRoots(home), projectMaxAge, projectMaxDepth, false); result != nil {
+		t.Fatal("expected nil when no project roots exist")
+	}
+}
+
+func TestScanNodeModulesStaleOnly(t *testing.T) {
+	home := t.TempDir()
+	old := time.Now().Add(-200 * 24 * time.Hour)
+
+	stale := filepath.Join(home, "Projects", "old-app", "node_modules")
+	writeFile(t, filepath.Join(stale, "left-pad", "index.js"), 2048)
+	ageTree(t, stale, old)
+
+	nested := filepath.Join(home, "Developer", "org", "mono", "web", "node_modules")
+	writeFile(t, filepath.Join(nested, "react", "index.js"), 1024)
+	ageTree(t, nested, old)
+
+	recent := filepath.Join(home, "Projects", "new-app", "node_modules")
+	writeFile(t, filepath.Join(recent, "lodash", "index.js"), 4096)
+
+	// One recently touched file keeps the whole tree.
+	touched := filepath.Join(home, "Documents", "code", "wip", "node_modules")
+	writeFile(t, filepath.Join(touched, "a", "index.js"), 512)
+	ageTree(t, touched, old)
+	writeFile(t, filepath.Join(touched, "b", "index.js"), 512)
+
+	result := scanNodeModules(home, ProjectRoots(home), projectMaxAge, projectMaxDepth, false)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Category != "dev-node-modules" {
+		t.Errorf("expected category 'dev-node-modules', got %q", result.Category)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 stale entries, got %d: %+v", len(result.Entries), result.Entries)
+	}
+	if result.Entries[0].Path != stale || result.Entries[1].Path != nested {
+		t.Errorf("expected %s then %s (largest first), got %s, %s",
+			stale, nested, result.Entries[0].Path, result.Entries[1].Path)
+	}
+	if want := filepath.Join("~", "Projects", "old-app"); result.Entries[0].Description != want {
+		t.Errorf("expected description %q, got %q", want, result.Entries[0].Description)
+	}
+	if !result.Entries[0].IsDir || result.Entries[0].ModTime.IsZero() {
+		t.Errorf("expected directory entry with mod time, got %+v", result.Entries[0])
+	}
+	if result.TotalSize != 3072 {
+		t.Errorf("expected total size 3072, got %d", result.TotalSize)
+	}
+}
+
+func TestScanNodeModulesDepthAndHidden(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, "Projects")
+	old := time.Now().Add(-200 * 24 * time.Hour)
+
+	tooDeep := filepath.Join(root, "a", "b", "c", "d", "node_modules")
+	hidden := filepath.Join(root, ".trash", "app", "node_modules")
+	inner := filepath.Join(root, "app", "node_modules", "pkg", "node_modules")
+	for _, dir := range []string{tooDeep, hidden, inner} {
+		writeFile(t, filepath.Join(dir, "x", "index.js"), 1024)
+	}
+	ageTree(t, root, old)
+
+	result := scanNodeModules(home, []string{root}, projectMaxAge, projectMaxDepth, false)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected only the outer app/node_modules, got %+v", result.Entries)
+	}
+	if want := filepath.Join(root, "app", "node_modules"); result.Entries[0].Path != want {
+		t.Errorf("expected path %q, got %q", want, result.Entries[0].Path)
+	}
+}
+
+func TestScanNodeModulesIncludeHidden(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, "Projects")
+	hidden := filepath.Join(root, ".archive", "app", "node_modules")
+	writeFile(t, filepath.Join(hidden, "x", "index.js"), 1024)
+	ageTree(t, root, time.Now().Add(-200*24*time.Hour))
+
+	if result := scanNodeModules(home, []string{root}, projectMaxAge, projectMaxDepth, false); result != nil {
+		t.Fatalf("expected hidden directory to be skipped by default, got %+v", result.Entries)
+	}
+	result := scanNodeModules(home, []string{root}, projectMaxAge, projectMaxDepth, true)
+	if result == nil || len(result.Entries) != 1 || result.Entries[0].Path != hidden {
+		t.Fatalf("expected %s with hidden directories included, got %+v", hidden, result)
+	}
+}
+
+// --- Stale Python environment tests ---
+
+// writeVenv creates a virtualenv-shaped directory with pyvenv.cfg and a
+// site-packages file.
+func writeVenv(t *testing.T, dir string, size int) {
+	t.Helper()
+	writeFile(t, filepath.Join(dir, "pyvenv.cfg"), 64)
+	writeFile(t, filepath.Join(dir, "lib", "python3.12", "site-packages", "requests", "__init__.py"), size)
+}
+
+func TestScanPyEnvsMissingRoots(t *testing.T) {
+	home := t.TempDir()
+	if result := scanPyEnvs(home, ProjectRoots(home), projectMaxAge, projectMaxDepth, false); result != nil {
+		t.Fatal("expected nil when no project roots exist")
+	}
+}
+
+func TestScanPyEnvsStaleOnly(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, "Projects")
+	old := time.Now().Add(-200 * 24 * time.Hour)
+
+	oldVenv := filepath.Join(root, "api", ".venv")
+	writeVenv(t, oldVenv, 4096)
+	oldCache := filepath.Join(root, "api", "src", "app", "__pycache__")
+	writeFile(t, filepath.Join(oldCache, "main.cpython-312.pyc"), 1024)
+	ageTree(t, filepath.Join(root, "api"), old)
+
+	// A recent virtualenv and cache are left alone.
+	writeVenv(t, filepath.Join(root, "web", "venv"), 4096)
+	writeFile(t, filepath.Join(root, "web", "__pycache__", "app.cpython-312.pyc"), 1024)
+
+	// A "venv" without pyvenv.cfg is not a virtualenv.
+	notVenv := filepath.Join(root, "notes", "venv")
+	writeFile(t, filepath.Join(notVenv, "ideas.txt"), 2048)
+	ageTree(t, notVenv, old)
+
+	result := scanPyEnvs(home, []string{root}, projectMaxAge, projectMaxDepth, false)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Category != "dev-pyenvs" {
+		t.Errorf("expected category 'dev-pyenvs', got %q", result.Category)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 stale entries, got %d: %+v", len(result.Entries), result.Entries)
+	}
+	if result.Entries[0].Path != oldVenv || result.Entries[1].Path != oldCache {
+		t.Errorf("expected %s then %s (largest first), got %s, %s",
+			oldVenv, oldCache, result.Entries[0].Path, result.Entries[1].Path)
+	}
+	project := filepath.Join("~", "Projects", "api")
+	if want := project + " (.venv)"; result.Entries[0].Description != want {
+		t.Errorf("expected description %q, got %q", want, result.Entries[0].Description)
+	}
+	if want := project + " (" + filepath.Join("src", "app", "__pycache__") + ")"; result.Entries[1].Description != want {
+		t.Errorf("expected description %q, got %q", want, result.Entries[1].Description)
+	}
+	result.SetRiskLevels(safety.RiskForCategory)
+	if result.Entries[0].RiskLevel != safety.RiskModerate {
+		t.Errorf("expected risk %q, got %q", safety.RiskModerate, result.Entries[0].RiskLevel)
+	}
+}
+
+func TestScanPyEnvsDepthBound(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, "Developer")
+	old := time.Now().Add(-200 * 24 * time.Hour)
+
+	atLimit := filepath.Join(root, "a", "b", "c", "__pycache__")
+	tooDeep := filepath.Join(root, "a", "b", "c", "d", "__pycache__")
+	inVendored := filepath.Join(root, "site", "node_modules", "py", "__pycache__")
+	for _, dir := range []string{atLimit, tooDeep, inVendored} {
+		writeFile(t, filepath.Join(dir, "mod.cpython-312.pyc"), 512)
+	}
+	ageTree(t, root, old)
+
+	result := scanPyEnvs(home, []string{root}, projectMaxAge, projectMaxDepth, false)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Path != atLimit {
+		t.Fatalf("expected only %s, got %+v", atLimit, result.Entries)
+	}
+}
+
+func TestScanWithOptionsNodeModulesOptIn(t *testing.T) {
+	home := t.TempDir()
+	root := filepath.Join(home, "code")
+	stale := filepath.Join(root, "app", "node_modules")
+	writeFile(t, filepath.Join(stale, "x", "index.js"), 1024)
+	ageTree(t, stale, time.Now().Add(-200*24*time.Hour))
+
+	hasNodeModules := func(results []scan.CategoryResult) bool {
+		for _, r := range results {
+			if r.Category == "dev-node-modules" {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Setenv("HOME", home)
+	results, err := ScanWithOptions(Options{ProjectRoots: []string{root}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasNodeModules(results) {
+		t.Error("dev-node-modules should not be scanned unless opted in")
+	}
+
+	results, err = ScanWithOptions(Options{NodeModules: true, ProjectRoots: []string{root}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasNodeModules(results) {
+		t.Error("expected dev-node-modules with NodeModules set")
+	}
+}
+
 // --- Integration test ---
 
 func TestScanIntegration(t *testing.T) {
@@ -571,16 +1381,16 @@ func TestScanIntegration(t *testing.T) {
 
 	// Call private helpers directly (Scan() uses os.UserHomeDir()).
 	var results []scan.CategoryResult
-	if cr := scanXcodeDerivedData(home); cr != nil {
+	if cr := scanXcodeDerivedData(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanNpmCache(home); cr != nil {
+	if cr := scanNpmCache(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanYarnCache(home); cr != nil {
+	if cr := scanYarnCache(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanHomebrew(home); cr != nil {
+	if cr := scanHomebrew(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
 
@@ -596,3 +1406,98 @@ func TestScanIntegration(t *testing.T) {
 		t.Errorf("expected second result 'dev-npm', got %q", results[1].Category)
 	}
 }
+
+func TestPaths(t *testing.T) {
+	home := "/Users/test"
+	paths := Paths(home)
+
+	want := []string{
+		filepath.Join(home, "Library", "Developer", "Xcode", "DerivedData"),
+		filepath.Join(home, ".npm"),
+		filepath.Join(home, "Library", "Caches", "yarn"),
+		filepath.Join(home, "Library", "Caches", "Homebrew"),
+		filepath.Join(home, "Library", "pnpm", "store"),
+		filepath.Join(home, "Library", "Caches", "CocoaPods"),
+		filepath.Join(home, ".gradle", "caches"),
+		filepath.Join(home, "Library", "Caches", "pip"),
+		filepath.Join(home, "Library", "Containers", "com.docker.docker", "Data", "vms", "0", "data", "Docker.raw"),
+		filepath.Join(home, ".docker", "buildx"),
+	}
+	got := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		got[p] = true
+	}
+	for _, p := range want {
+		if !got[p] {
+			t.Errorf("expected %q in Paths", p)
+		}
+	}
+	for _, p := range paths {
+		if !filepath.IsAbs(p) || !strings.HasPrefix(p, home+"/") {
+			t.Errorf("path %q is not under home", p)
+		}
+	}
+}
+
+func TestScanWithOptions_NoExecUsesDockerFallback(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, ".docker", "buildx", "cache.bin"), 4096)
+	t.Setenv("HOME", home)
+
+	failRunner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		t.Errorf("runner called with %s %v despite NoExec", name, args)
+		return nil, fmt.Errorf("unexpected call")
+	}
+	results, err := ScanWithOptions(Options{NoExec: true, Runner: failRunner})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var docker *scan.CategoryResult
+	for i := range results {
+		switch results[i].Category {
+		case "dev-docker":
+			docker = &results[i]
+		case "dev-brew-autoremove":
+			t.Error("dev-brew-autoremove needs brew and should be skipped under NoExec")
+		}
+	}
+	if docker == nil {
+		t.Fatal("expected dev-docker from the on-disk fallback")
+	}
+	if docker.TotalSize != 4096 {
+		t.Errorf("expected fallback size 4096, got %d", docker.TotalSize)
+	}
+	for _, e := range docker.Entries {
+		if !strings.HasPrefix(e.Path, "docker:") {
+			t.Errorf("fallback entry %q should be an informational pseudo-entry, not a deletable path", e.Path)
+		}
+	}
+}
+
+func TestScanWithOptions_AlternateHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	other := t.TempDir()
+	safety.SetHome(other)
+	t.Cleanup(func() { safety.SetHome("") })
+	writeFile(t, filepath.Join(other, ".npm", "_cacache", "index"), 3000)
+
+	results, err := ScanWithOptions(Options{NoExec: true, Home: other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var npm *scan.CategoryResult
+	for i := range results {
+		for _, e := range results[i].Entries {
+			if !strings.HasPrefix(e.Path, other+string(filepath.Separator)) {
+				t.Errorf("%s: entry %s is outside the scanned home", results[i].Category, e.Path)
+			}
+		}
+		if results[i].Category == "dev-npm" {
+			npm = &results[i]
+		}
+	}
+	if npm == nil || npm.TotalSize != 3000 {
+		t.Fatalf("expected dev-npm sized from the alternate home, got %+v", npm)
+	}
+}
@@ -1,4 +1,5 @@
-// Package messaging provides scanners for messaging application cache directories.
+// Package messaging provides scanners for messaging application cache
+// directories and the recordings and downloads those applications store.
 package messaging
 
 import (
@@ -11,14 +12,27 @@ import (
 )
 
 // Scan discovers and sizes messaging application cache directories for Slack,
-// Discord, Microsoft Teams, and Zoom. Missing applications are silently
-// skipped. No files are modified.
+// Discord, Microsoft Teams, and Zoom, plus Zoom local recordings and Slack
+// downloads, which are reported as separate categories because they hold
+// user content. Missing applications are silently skipped. No files are
+// modified.
 func Scan() ([]scan.CategoryResult, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, fmt.Errorf("cannot determine home directory: %w", err)
 	}
+	return ScanHome(home)
+}
+
+// ScanHome is Scan for the account whose home directory is home.
+func ScanHome(home string) ([]scan.CategoryResult, error) {
+	return ScanHomeDepth(home, 0)
+}
 
+// ScanHomeDepth is ScanHome with the entries of the top-level caches
+// sized no deeper than depth (see scan.ScanTopLevelDepth). Zero sizes
+// everything.
+func ScanHomeDepth(home string, depth int) ([]scan.CategoryResult, error) {
 	var results []scan.CategoryResult
 
 	if cr := scanSlackCache(home); cr != nil {
@@ -37,10 +51,34 @@ func Scan() ([]scan.CategoryResult, error) {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
+	if cr := scanZoomRecordings(home, depth); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
+	if cr := scanSlackDownloads(home, depth); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
 
 	return results, nil
 }
 
+// Paths returns the locations Scan examines, without checking whether
+// they exist.
+func Paths(home string) []string {
+	return []string{
+		filepath.Join(home, "Library", "Application Support", "Slack", "Cache"),
+		filepath.Join(home, "Library", "Application Support", "Slack", "Service Worker", "CacheStorage"),
+		filepath.Join(home, "Library", "Application Support", "discord", "Cache"),
+		filepath.Join(home, "Library", "Application Support", "discord", "Code Cache"),
+		filepath.Join(home, "Library", "Application Support", "Microsoft", "Teams", "Cache"),
+		filepath.Join(home, "Library", "Caches", "com.microsoft.teams2"),
+		filepath.Join(home, "Library", "Application Support", "zoom.us", "data"),
+		filepath.Join(home, "Documents", "Zoom"),
+		filepath.Join(home, "Library", "Containers", "com.tinyspeck.slackmacgap", "Data", "Downloads"),
+	}
+}
+
 // scanSlackCache scans Slack cache directories:
 //   - ~/Library/Application Support/Slack/Cache/
 //   - ~/Library/Application Support/Slack/Service Worker/CacheStorage/
@@ -129,12 +167,64 @@ func scanZoomCache(home string) *scan.CategoryResult {
 				Path:        dir,
 				Description: "Zoom",
 				Size:        size,
+				IsDir:       scan.IsDir(dir),
 			},
 		},
 		TotalSize: size,
 	}
 }
 
+// scanZoomRecordings scans ~/Documents/Zoom/, where Zoom saves local
+// meeting recordings. Each meeting folder is a separate entry so that
+// individual recordings can be kept. Returns nil if the directory does not
+// exist or is empty.
+func scanZoomRecordings(home string, depth int) *scan.CategoryResult {
+	dir := filepath.Join(home, "Documents", "Zoom")
+	return scanUserContent(dir, "msg-zoom-recordings", "Zoom Recordings", depth)
+}
+
+// scanSlackDownloads scans the Downloads folder inside the Slack app
+// container, where the App Store build of Slack saves downloaded files:
+//   - ~/Library/Containers/com.tinyspeck.slackmacgap/Data/Downloads/
+//
+// Each file is a separate entry. Returns nil if the directory does not
+// exist or is empty.
+func scanSlackDownloads(home string, depth int) *scan.CategoryResult {
+	dir := filepath.Join(home, "Library", "Containers", "com.tinyspeck.slackmacgap", "Data", "Downloads")
+	return scanUserContent(dir, "msg-slack-downloads", "Slack Downloads", depth)
+}
+
+// scanUserContent lists the top-level entries of dir as individual scan
+// entries. The files are the user's own, not caches, so the category is
+// ReportOnly: it is listed, but deleted only when targeted by its ID.
+// Returns nil if dir does not exist or holds nothing.
+func scanUserContent(dir, category, description string, depth int) *scan.CategoryResult {
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsPermission(err) {
+			return &scan.CategoryResult{
+				Category:    category,
+				Description: description,
+				PermissionIssues: []scan.PermissionIssue{{
+					Path:        dir,
+					Description: description + " (permission denied)",
+				}},
+				ReportOnly: true,
+			}
+		}
+		return nil
+	}
+
+	cr, err := scan.ScanTopLevelDepth(dir, category, description, depth)
+	if err != nil {
+		return nil
+	}
+	if len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0 {
+		return nil
+	}
+	cr.ReportOnly = true
+	return cr
+}
+
 // scanMultiDir scans multiple directories and combines them into a single
 // CategoryResult. Each existing directory becomes a single blob entry with
 // its total size. Returns nil if no directories exist or all are empty.
@@ -173,6 +263,7 @@ func scanMultiDir(paths []string, category, description string) *scan.CategoryRe
 			Path:        dir,
 			Description: filepath.Base(dir),
 			Size:        size,
+			IsDir:       scan.IsDir(dir),
 		})
 		totalSize += size
 	}
@@ -3,8 +3,10 @@ package messaging
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -176,6 +178,95 @@ func TestScanZoomCacheEmptyDir(t *testing.T) {
 	}
 }
 
+// --- Recordings and downloads tests ---
+
+func TestScanZoomRecordingsMissing(t *testing.T) {
+	home := t.TempDir()
+	if result := scanZoomRecordings(home, 0); result != nil {
+		t.Fatal("expected nil for missing Zoom recordings")
+	}
+}
+
+func TestScanZoomRecordingsSeparateFromCache(t *testing.T) {
+	home := t.TempDir()
+	cacheDir := filepath.Join(home, "Library", "Application Support", "zoom.us", "data")
+	writeFile(t, filepath.Join(cacheDir, "data.bin"), 500)
+	recDir := filepath.Join(home, "Documents", "Zoom")
+	writeFile(t, filepath.Join(recDir, "2026-03-02 10.00.00 Standup", "video.mp4"), 4000)
+	writeFile(t, filepath.Join(recDir, "2026-03-03 14.30.00 Review", "video.mp4"), 6000)
+
+	result := scanZoomRecordings(home, 0)
+	if result == nil {
+		t.Fatal("expected non-nil result for Zoom recordings")
+	}
+	result.SetRiskLevels(safety.RiskForCategory)
+	if result.Category != "msg-zoom-recordings" {
+		t.Errorf("expected category 'msg-zoom-recordings', got %q", result.Category)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 entries (one per meeting), got %d", len(result.Entries))
+	}
+	if !result.ReportOnly {
+		t.Error("expected recordings to be report-only")
+	}
+	if result.TotalSize != 10000 {
+		t.Errorf("expected total size 10000, got %d", result.TotalSize)
+	}
+	for _, e := range result.Entries {
+		if e.RiskLevel != safety.RiskModerate {
+			t.Errorf("entry %s: expected risk %q, got %q", e.Path, safety.RiskModerate, e.RiskLevel)
+		}
+		if strings.HasPrefix(e.Path, cacheDir) {
+			t.Errorf("recordings category includes cache path %s", e.Path)
+		}
+	}
+
+	cache := scanZoomCache(home)
+	if cache == nil {
+		t.Fatal("expected Zoom cache result")
+	}
+	if cache.TotalSize != 500 {
+		t.Errorf("expected Zoom cache size 500 excluding recordings, got %d", cache.TotalSize)
+	}
+	if safety.RiskForCategory(cache.Category) != safety.RiskSafe {
+		t.Errorf("expected Zoom cache to stay safe, got %q", safety.RiskForCategory(cache.Category))
+	}
+}
+
+func TestScanSlackDownloadsWithData(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "Library", "Containers", "com.tinyspeck.slackmacgap", "Data", "Downloads")
+	writeFile(t, filepath.Join(dir, "report.pdf"), 3000)
+	writeFile(t, filepath.Join(dir, "screenshot.png"), 1000)
+
+	result := scanSlackDownloads(home, 0)
+	if result == nil {
+		t.Fatal("expected non-nil result for Slack downloads")
+	}
+	if result.Category != "msg-slack-downloads" {
+		t.Errorf("expected category 'msg-slack-downloads', got %q", result.Category)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(result.Entries))
+	}
+	if result.Entries[0].Description != "report.pdf" {
+		t.Errorf("expected largest entry first, got %q", result.Entries[0].Description)
+	}
+	if !result.ReportOnly {
+		t.Error("expected Slack downloads to be report-only")
+	}
+}
+
+func TestScanSlackDownloadsEmptyDir(t *testing.T) {
+	home := t.TempDir()
+	dir := filepath.Join(home, "Library", "Containers", "com.tinyspeck.slackmacgap", "Data", "Downloads")
+	os.MkdirAll(dir, 0755)
+
+	if result := scanSlackDownloads(home, 0); result != nil {
+		t.Fatal("expected nil for empty Slack downloads directory")
+	}
+}
+
 // --- Integration test ---
 
 func TestScanIntegration(t *testing.T) {
@@ -18,7 +18,11 @@ func Scan() ([]scan.CategoryResult, error) {
 	if err != nil {
 		return nil, fmt.Errorf("cannot determine home directory: %w", err)
 	}
+	return ScanHome(home)
+}
 
+// ScanHome is Scan for the account whose home directory is home.
+func ScanHome(home string) ([]scan.CategoryResult, error) {
 	var results []scan.CategoryResult
 
 	if cr := scanPhotosCaches(home); cr != nil {
@@ -41,6 +45,18 @@ func Scan() ([]scan.CategoryResult, error) {
 	return results, nil
 }
 
+// Paths returns the locations Scan examines, without checking whether
+// they exist.
+func Paths(home string) []string {
+	return []string{
+		filepath.Join(home, "Library", "Containers", "com.apple.Photos", "Data", "Library", "Caches"),
+		filepath.Join(home, "Library", "Containers", "com.apple.mediaanalysisd", "Data", "Library", "Caches"),
+		filepath.Join(home, "Library", "Containers", "com.apple.photoanalysisd", "Data", "Library", "Caches"),
+		filepath.Join(home, "Library", "Containers", "com.apple.cloudphotosd", "Data", "Library", "Caches"),
+		filepath.Join(home, "Library", "Photos", "Libraries", "Syndication.photoslibrary"),
+	}
+}
+
 // scanPhotosCaches scans ~/Library/Containers/com.apple.Photos/Data/Library/Caches/.
 // Returns nil if the directory does not exist.
 func scanPhotosCaches(home string) *scan.CategoryResult {
@@ -120,6 +136,7 @@ func scanSingleDir(dir, category, description string) *scan.CategoryResult {
 				Path:        dir,
 				Description: description,
 				Size:        size,
+				IsDir:       scan.IsDir(dir),
 			},
 		},
 		TotalSize: size,
@@ -164,6 +181,7 @@ func scanMultiDir(paths []string, category, description string) *scan.CategoryRe
 			Path:        dir,
 			Description: filepath.Base(dir),
 			Size:        size,
+			IsDir:       scan.IsDir(dir),
 		})
 		totalSize += size
 	}
@@ -7,24 +7,79 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+	"syscall"
+	"time"
 
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
 // Scan discovers and sizes system cache directories. It scans
-// ~/Library/Caches, ~/Library/Logs, and QuickLook thumbnail caches.
+// ~/Library/Caches, ~/Library/Logs, QuickLook thumbnail caches,
+// sysdiagnose/spindump archives in /var/tmp and ~/Library/Logs,
+// installer receipts and partial App Store downloads, and the iCloud Drive
+// cache.
 // Blocked paths are skipped with stderr warnings. No files are modified.
 func Scan() ([]scan.CategoryResult, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+	return ScanWithTmpCaches(false)
+}
+
+// ScanWithTmpCaches is Scan, additionally reporting the current user's
+// app caches under the per-user /private/var/folders cache directory as
+// system-tmp-caches when tmpCaches is true. That category is opt-in.
+func ScanWithTmpCaches(tmpCaches bool) ([]scan.CategoryResult, error) {
+	return ScanWithOptions(Options{TmpCaches: tmpCaches})
+}
+
+// Options configures ScanWithOptions.
+type Options struct {
+	// TmpCaches opts in to system-tmp-caches, as for ScanWithTmpCaches.
+	TmpCaches bool
+	// Home is the home directory to scan, e.g. another account's for an
+	// audit. Empty means the current user's. When set, the QuickLook and
+	// temporary app caches are not scanned: they are found through
+	// $TMPDIR and belong to the current user.
+	Home string
+	// MaxDepth bounds how deep the entries of the top-level caches are
+	// sized (see scan.ScanTopLevelDepth). Zero sizes everything.
+	MaxDepth int
+}
+
+// ScanWithOptions is Scan with the optional behavior selected by opts.
+func ScanWithOptions(opts Options) ([]scan.CategoryResult, error) {
+	home := opts.Home
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return nil, fmt.Errorf("cannot determine home directory: %w", err)
+		}
 	}
+	depth := opts.MaxDepth
+	// The per-user cache directory found through $TMPDIR is the current
+	// user's, whichever home is scanned.
+	ownCaches := opts.Home == ""
 
 	var results []scan.CategoryResult
 
+	// Diagnostic archives are scanned first so that archives sitting in
+	// ~/Library/Logs are not also counted under User Logs.
+	diag := scanDiagnosticArchives([]string{"/var/tmp", filepath.Join(home, "Library", "Logs")})
+
+	// Likewise the App Store download cache is reported as an installer
+	// leftover rather than under User App Caches.
+	installers := scanInstallerLeftovers(home, depth)
+
+	// And the iCloud Drive cache under its own category.
+	icloud := scanICloudDriveCache(home)
+
 	// User App Caches
-	if cr, err := scan.ScanTopLevel(filepath.Join(home, "Library", "Caches"), "system-caches", "User App Caches"); err == nil && cr != nil {
+	if cr, err := scan.ScanTopLevelDepth(filepath.Join(home, "Library", "Caches"), "system-caches", "User App Caches", depth); err == nil && cr != nil {
+		if installers != nil {
+			excludeEntries(cr, installers.Entries)
+		}
+		if icloud != nil {
+			excludeEntries(cr, icloud.Entries)
+		}
 		cr.SetRiskLevels(safety.RiskForCategory)
 		if len(cr.Entries) > 0 || len(cr.PermissionIssues) > 0 {
 			results = append(results, *cr)
@@ -32,7 +87,10 @@ func Scan() ([]scan.CategoryResult, error) {
 	}
 
 	// User Logs
-	if cr, err := scan.ScanTopLevel(filepath.Join(home, "Library", "Logs"), "system-logs", "User Logs"); err == nil && cr != nil {
+	if cr, err := scan.ScanTopLevelDepth(filepath.Join(home, "Library", "Logs"), "system-logs", "User Logs", depth); err == nil && cr != nil {
+		if diag != nil {
+			excludeEntries(cr, diag.Entries)
+		}
 		cr.SetRiskLevels(safety.RiskForCategory)
 		if len(cr.Entries) > 0 || len(cr.PermissionIssues) > 0 {
 			results = append(results, *cr)
@@ -40,16 +98,354 @@ func Scan() ([]scan.CategoryResult, error) {
 	}
 
 	// QuickLook Thumbnails
-	if cacheDir, err := quickLookCacheDir(); err == nil {
+	if cacheDir, err := quickLookCacheDir(); err == nil && ownCaches {
 		if cr, err := scanQuickLook(cacheDir, "quicklook", "QuickLook Thumbnails"); err == nil && cr != nil {
 			cr.SetRiskLevels(safety.RiskForCategory)
 			results = append(results, *cr)
 		}
 	}
 
+	// Per-user temporary app caches (opt-in)
+	if opts.TmpCaches && ownCaches {
+		if cacheDir, err := quickLookCacheDir(); err == nil {
+			if cr := scanTmpCaches(cacheDir, os.Getuid()); cr != nil {
+				cr.SetRiskLevels(safety.RiskForCategory)
+				results = append(results, *cr)
+			}
+		}
+	}
+
+	// Sysdiagnose and spindump archives
+	if diag != nil {
+		diag.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *diag)
+	}
+
+	// Installer receipts and partial App Store downloads
+	if installers != nil {
+		installers.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *installers)
+	}
+
+	// iCloud Drive evictable cache
+	if icloud != nil {
+		icloud.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *icloud)
+	}
+
+	// Broken symlinks in bin directories
+	if cr := scanBrokenSymlinks(symlinkDirs(home)); cr != nil {
+		cr.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *cr)
+	}
+
 	return results, nil
 }
 
+// Paths returns the locations Scan examines, without checking whether they
+// exist: user caches and logs, /var/tmp for diagnostic archives, installer
+// receipt directories, the iCloud Drive cache, the bin directories checked
+// for broken symlinks, and the QuickLook cache directory when it can be
+// derived from $TMPDIR.
+func Paths(home string) []string {
+	paths := []string{
+		filepath.Join(home, "Library", "Caches"),
+		filepath.Join(home, "Library", "Logs"),
+		"/var/tmp",
+		filepath.Join(home, "Library", "Receipts"),
+		appStoreCacheDir(home),
+	}
+	for _, d := range iCloudDriveCacheDirs(home) {
+		paths = append(paths, d.path)
+	}
+	paths = append(paths, symlinkDirs(home)...)
+	if cacheDir, err := quickLookCacheDir(); err == nil {
+		paths = append(paths, cacheDir)
+	}
+	return paths
+}
+
+// symlinkDirs returns the bin directories checked for broken symlinks.
+// Package managers link executables into these and leave the links behind
+// when a package is removed outside the manager.
+func symlinkDirs(home string) []string {
+	return []string{
+		filepath.Join(home, "bin"),
+		filepath.Join(home, ".local", "bin"),
+		"/usr/local/bin",
+	}
+}
+
+// scanBrokenSymlinks lists symlinks directly inside the given directories
+// whose targets do not exist. Each becomes a zero-byte entry described as
+// "name -> target"; removing it deletes only the link. Returns nil if
+// nothing is found.
+func scanBrokenSymlinks(dirs []string) *scan.CategoryResult {
+	var entries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+
+	for _, dir := range dirs {
+		dirEntries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsPermission(err) {
+				permIssues = append(permIssues, scan.PermissionIssue{
+					Path:        dir,
+					Description: "Broken symlinks (permission denied)",
+				})
+			}
+			continue
+		}
+
+		for _, entry := range dirEntries {
+			if entry.Type()&os.ModeSymlink == 0 {
+				continue
+			}
+
+			entryPath := filepath.Join(dir, entry.Name())
+			if _, err := os.Stat(entryPath); !os.IsNotExist(err) {
+				continue
+			}
+
+			if blocked, reason := safety.IsPathBlocked(entryPath); blocked {
+				safety.WarnBlocked(entryPath, reason)
+				continue
+			}
+
+			target, err := os.Readlink(entryPath)
+			if err != nil {
+				continue
+			}
+
+			entries = append(entries, scan.ScanEntry{
+				Path:        entryPath,
+				Description: entry.Name() + " -> " + target,
+			})
+		}
+	}
+
+	if len(entries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	return &scan.CategoryResult{
+		Category:         "system-broken-symlinks",
+		Description:      "Broken Symlinks",
+		Entries:          entries,
+		PermissionIssues: permIssues,
+	}
+}
+
+// scanDiagnosticArchives lists sysdiagnose_*.tar.gz and spindump files
+// found directly inside the given directories. Each archive becomes one
+// entry described by its name and modification date. Archives that cannot
+// be read (typically root-owned ones in /var/tmp) are reported as
+// permission issues. Returns nil if nothing is found.
+func scanDiagnosticArchives(dirs []string) *scan.CategoryResult {
+	var entries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, dir := range dirs {
+		dirEntries, err := os.ReadDir(dir)
+		if err != nil {
+			if os.IsPermission(err) {
+				permIssues = append(permIssues, scan.PermissionIssue{
+					Path:        dir,
+					Description: "Diagnostic archives (permission denied)",
+				})
+			}
+			continue
+		}
+
+		for _, entry := range dirEntries {
+			if !entry.Type().IsRegular() || !safety.IsDiagnosticArchive(entry.Name()) {
+				continue
+			}
+
+			entryPath := filepath.Join(dir, entry.Name())
+
+			if blocked, reason := safety.IsPathBlocked(entryPath); blocked {
+				safety.WarnBlocked(entryPath, reason)
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			f, err := os.Open(entryPath) // #nosec G304 -- entryPath is a diagnostic archive verified by safety.IsPathBlocked()
+			if err != nil {
+				if os.IsPermission(err) {
+					permIssues = append(permIssues, scan.PermissionIssue{
+						Path:        entryPath,
+						Description: entry.Name() + " (permission denied)",
+					})
+				}
+				continue
+			}
+			f.Close() // #nosec G104 -- read-only probe
+
+			size := scan.FileSize(info)
+			if size == 0 {
+				continue
+			}
+
+			entries = append(entries, scan.ScanEntry{
+				Path:        entryPath,
+				Description: entry.Name() + " (" + info.ModTime().Format(time.DateOnly) + ")",
+				Size:        size,
+			})
+			totalSize += size
+		}
+	}
+
+	if len(entries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Size > entries[j].Size
+	})
+
+	return &scan.CategoryResult{
+		Category:         "system-sysdiagnose",
+		Description:      "Sysdiagnose & Spindump Archives",
+		Entries:          entries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}
+}
+
+// appStoreCacheDir returns the App Store cache holding partial downloads.
+func appStoreCacheDir(home string) string {
+	return filepath.Join(home, "Library", "Caches", "com.apple.appstore")
+}
+
+// scanInstallerLeftovers lists installer receipts in ~/Library/Receipts,
+// one entry each, and the App Store cache of partial downloads as a single
+// entry. The root-owned system receipts (/Library/Receipts,
+// /private/var/db/receipts) are not examined: they are never cleaned, and
+// reporting them would raise a permission issue on every Mac. Returns nil
+// if nothing is found.
+func scanInstallerLeftovers(home string, depth int) *scan.CategoryResult {
+	cr := &scan.CategoryResult{
+		Category:    "system-installer-leftovers",
+		Description: "Installer Leftovers",
+	}
+
+	receipts := filepath.Join(home, "Library", "Receipts")
+	if _, err := os.Stat(receipts); err == nil {
+		if r, err := scan.ScanTopLevelDepth(receipts, cr.Category, cr.Description, depth); err == nil {
+			cr.Entries = append(cr.Entries, r.Entries...)
+			cr.TotalSize += r.TotalSize
+			cr.PermissionIssues = append(cr.PermissionIssues, r.PermissionIssues...)
+			cr.Truncated = cr.Truncated || r.Truncated
+		}
+	}
+
+	appStore := appStoreCacheDir(home)
+	if blocked, reason := safety.IsPathBlocked(appStore); blocked {
+		safety.WarnBlocked(appStore, reason)
+	} else if size, err := scan.DirSize(appStore); err == nil && size > 0 {
+		cr.Entries = append(cr.Entries, scan.ScanEntry{
+			Path:        appStore,
+			Description: "App Store partial downloads",
+			Size:        size,
+			IsDir:       true,
+		})
+		cr.TotalSize += size
+	} else if os.IsPermission(err) {
+		cr.PermissionIssues = append(cr.PermissionIssues, scan.PermissionIssue{
+			Path:        appStore,
+			Description: "App Store partial downloads (permission denied)",
+		})
+	}
+
+	if len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0 {
+		return nil
+	}
+	sort.Slice(cr.Entries, func(i, j int) bool {
+		return cr.Entries[i].Size > cr.Entries[j].Size
+	})
+	return cr
+}
+
+// iCloudDriveCacheDir is one directory of evictable iCloud Drive data.
+type iCloudDriveCacheDir struct {
+	path        string
+	description string
+}
+
+// iCloudDriveCacheDirs returns the directories where the CloudDocs (bird)
+// daemon keeps evictable iCloud Drive content.
+func iCloudDriveCacheDirs(home string) []iCloudDriveCacheDir {
+	return []iCloudDriveCacheDir{
+		{filepath.Join(home, "Library", "Caches", "com.apple.bird"), "iCloud Drive cache (bird)"},
+		{filepath.Join(home, "Library", "Application Support", "CloudDocs", "session", "db"), "iCloud Drive session database"},
+	}
+}
+
+// scanICloudDriveCache sizes the iCloud Drive cache directories, one entry
+// each. Their content is downloaded again on demand. A directory that
+// exists but cannot be read is reported as a permission issue. Returns nil
+// if nothing is found.
+func scanICloudDriveCache(home string) *scan.CategoryResult {
+	cr := &scan.CategoryResult{
+		Category:    "system-iclouddrive-cache",
+		Description: "iCloud Drive Cache",
+	}
+	for _, d := range iCloudDriveCacheDirs(home) {
+		f, err := os.Open(d.path)
+		if err != nil {
+			if os.IsPermission(err) {
+				cr.PermissionIssues = append(cr.PermissionIssues, scan.PermissionIssue{
+					Path:        d.path,
+					Description: d.description + " (permission denied)",
+				})
+			}
+			continue
+		}
+		f.Close()
+		size, err := scan.DirSize(d.path)
+		if err != nil || size == 0 {
+			continue
+		}
+		cr.Entries = append(cr.Entries, scan.ScanEntry{
+			Path:        d.path,
+			Description: d.description,
+			Size:        size,
+			IsDir:       true,
+		})
+		cr.TotalSize += size
+	}
+	if len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0 {
+		return nil
+	}
+	sort.Slice(cr.Entries, func(i, j int) bool {
+		return cr.Entries[i].Size > cr.Entries[j].Size
+	})
+	return cr
+}
+
+// excludeEntries removes entries whose paths appear in exclude from cr and
+// adjusts its total size accordingly.
+func excludeEntries(cr *scan.CategoryResult, exclude []scan.ScanEntry) {
+	skip := make(map[string]bool, len(exclude))
+	for _, e := range exclude {
+		skip[e.Path] = true
+	}
+	kept := cr.Entries[:0]
+	for _, e := range cr.Entries {
+		if skip[e.Path] {
+			cr.TotalSize -= e.Size
+			continue
+		}
+		kept = append(kept, e)
+	}
+	cr.Entries = kept
+}
+
 // quickLookCacheDir derives the per-user QuickLook cache directory from
 // $TMPDIR. On macOS, TMPDIR is typically /var/folders/XX/YY/T/, and the
 // cache directory is the sibling "C" directory.
@@ -76,6 +472,116 @@ func quickLookCacheDir() (string, error) {
 	return cacheDir, nil
 }
 
+// tmpCacheDenylist lists name prefixes of entries in the per-user cache
+// directory that macOS services depend on. Removing them forces slow
+// rebuilds of launch, icon and font databases, so they are never reported.
+var tmpCacheDenylist = []string{
+	"com.apple.dyld",
+	"com.apple.LaunchServices",
+	"com.apple.iconservices",
+	"com.apple.FontRegistry",
+	"com.apple.nsurlsessiond",
+}
+
+// isDenylistedTmpCache reports whether name matches tmpCacheDenylist.
+func isDenylistedTmpCache(name string) bool {
+	for _, prefix := range tmpCacheDenylist {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// scanTmpCaches lists the app cache directories ("com.*") in the per-user
+// cache directory that are owned by uid. QuickLook caches, which have their
+// own category, and denylisted system caches are left out. Returns nil if
+// nothing is found.
+func scanTmpCaches(cacheParent string, uid int) *scan.CategoryResult {
+	const description = "Temporary App Caches"
+	entries, err := os.ReadDir(cacheParent)
+	if err != nil {
+		if os.IsPermission(err) {
+			return &scan.CategoryResult{
+				Category:    "system-tmp-caches",
+				Description: description,
+				PermissionIssues: []scan.PermissionIssue{{
+					Path:        cacheParent,
+					Description: description + " (permission denied)",
+				}},
+			}
+		}
+		return nil
+	}
+
+	var scanEntries []scan.ScanEntry
+	var permIssues []scan.PermissionIssue
+	var totalSize int64
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() || !strings.HasPrefix(name, "com.") ||
+			strings.HasPrefix(name, "com.apple.quicklook.") || isDenylistedTmpCache(name) {
+			continue
+		}
+
+		entryPath := filepath.Join(cacheParent, name)
+		if info, err := entry.Info(); err != nil || !ownedBy(info, uid) {
+			continue
+		}
+
+		if blocked, reason := safety.IsPathBlocked(entryPath); blocked {
+			safety.WarnBlocked(entryPath, reason)
+			continue
+		}
+
+		size, err := scan.DirSize(entryPath)
+		if err != nil {
+			if os.IsPermission(err) {
+				permIssues = append(permIssues, scan.PermissionIssue{
+					Path:        entryPath,
+					Description: name + " (permission denied)",
+				})
+			}
+			continue
+		}
+		if size == 0 {
+			continue
+		}
+
+		scanEntries = append(scanEntries, scan.ScanEntry{
+			Path:        entryPath,
+			Description: name,
+			Size:        size,
+			IsDir:       true,
+		})
+		totalSize += size
+	}
+
+	if len(scanEntries) == 0 && len(permIssues) == 0 {
+		return nil
+	}
+
+	sort.Slice(scanEntries, func(i, j int) bool {
+		return scanEntries[i].Size > scanEntries[j].Size
+	})
+
+	return &scan.CategoryResult{
+		Category:         "system-tmp-caches",
+		Description:      description,
+		Entries:          scanEntries,
+		TotalSize:        totalSize,
+		PermissionIssues: permIssues,
+	}
+}
+
+// ownedBy reports whether info belongs to uid. Ownership is assumed when
+// the platform does not expose it.
+func ownedBy(info os.FileInfo, uid int) bool {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	return !ok || int(st.Uid) == uid
+}
+
 // scanQuickLook scans a per-user cache directory for QuickLook-related
 // entries (directories matching "com.apple.quicklook.*") and aggregates
 // them into a single CategoryResult.
@@ -135,7 +641,7 @@ func scanQuickLook(cacheParent, category, description string) (*scan.CategoryRes
 				}
 				continue
 			}
-			size = info.Size()
+			size = scan.FileSize(info)
 		}
 
 		if size == 0 {
@@ -146,6 +652,7 @@ func scanQuickLook(cacheParent, category, description string) (*scan.CategoryRes
 			Path:        entryPath,
 			Description: entry.Name(),
 			Size:        size,
+			IsDir:       entry.IsDir(),
 		})
 		totalSize += size
 	}
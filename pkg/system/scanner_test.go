@@ -4,7 +4,10 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -422,3 +425,357 @@ func TestQuickLookCacheDir_PassesSafetyCheck(t *testing.T) {
 		t.Errorf("expected %q, got %q", cDir, got)
 	}
 }
+
+// --- Diagnostic archive tests ---
+
+func TestScanDiagnosticArchives_ListsAndSums(t *testing.T) {
+	varTmp := t.TempDir()
+	logs := t.TempDir()
+
+	writeFile(t, filepath.Join(varTmp, "sysdiagnose_2026.10.01_12-00-00_macOS.tar.gz"), 4000)
+	writeFile(t, filepath.Join(logs, "spindump.txt"), 1000)
+	writeFile(t, filepath.Join(logs, "sysdiagnose_2026.09.15_08-30-00_macOS.tar.gz"), 2000)
+	// Unrelated files and directories are ignored.
+	writeFile(t, filepath.Join(varTmp, "other.tar.gz"), 500)
+	os.MkdirAll(filepath.Join(logs, "sysdiagnose_dir.tar.gz"), 0755)
+
+	mod := time.Date(2026, 9, 15, 8, 30, 0, 0, time.Local)
+	os.Chtimes(filepath.Join(logs, "sysdiagnose_2026.09.15_08-30-00_macOS.tar.gz"), mod, mod)
+
+	result := scanDiagnosticArchives([]string{varTmp, logs})
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Category != "system-sysdiagnose" {
+		t.Errorf("expected category 'system-sysdiagnose', got %q", result.Category)
+	}
+	if len(result.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(result.Entries))
+	}
+	if result.TotalSize != 7000 {
+		t.Errorf("expected total 7000, got %d", result.TotalSize)
+	}
+	// Sorted by size descending; description carries name and date.
+	if result.Entries[0].Path != filepath.Join(varTmp, "sysdiagnose_2026.10.01_12-00-00_macOS.tar.gz") {
+		t.Errorf("unexpected first entry %q", result.Entries[0].Path)
+	}
+	want := "sysdiagnose_2026.09.15_08-30-00_macOS.tar.gz (2026-09-15)"
+	if result.Entries[1].Description != want {
+		t.Errorf("expected description %q, got %q", want, result.Entries[1].Description)
+	}
+}
+
+func TestScanDiagnosticArchives_Empty(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "notes.txt"), 100)
+
+	if result := scanDiagnosticArchives([]string{dir, filepath.Join(dir, "missing")}); result != nil {
+		t.Errorf("expected nil result, got %+v", result)
+	}
+}
+
+func TestScanDiagnosticArchives_UnreadableReportedAsPermissionIssue(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can read any file")
+	}
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sysdiagnose_root.tar.gz")
+	writeFile(t, path, 100)
+	os.Chmod(path, 0000)
+	t.Cleanup(func() { os.Chmod(path, 0644) })
+
+	result := scanDiagnosticArchives([]string{dir})
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if len(result.Entries) != 0 {
+		t.Errorf("expected 0 entries, got %d", len(result.Entries))
+	}
+	if len(result.PermissionIssues) != 1 || result.PermissionIssues[0].Path != path {
+		t.Errorf("expected permission issue for %s, got %+v", path, result.PermissionIssues)
+	}
+}
+
+// --- Temporary app cache tests ---
+
+func TestScanTmpCaches_ExcludesDenylistedAndQuickLook(t *testing.T) {
+	dir := t.TempDir()
+	for name, size := range map[string]int{
+		"com.example.editor":                  400,
+		"com.example.browser":                 200,
+		"com.apple.dyld":                      900,
+		"com.apple.quicklook.ThumbnailsAgent": 300,
+		"org.example.other":                   100,
+	} {
+		os.MkdirAll(filepath.Join(dir, name), 0755)
+		writeFile(t, filepath.Join(dir, name, "data.bin"), size)
+	}
+	writeFile(t, filepath.Join(dir, "com.example.file"), 50)
+
+	result := scanTmpCaches(dir, os.Getuid())
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Category != "system-tmp-caches" {
+		t.Errorf("category = %q, want system-tmp-caches", result.Category)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %+v", result.Entries)
+	}
+	if result.Entries[0].Description != "com.example.editor" || result.Entries[1].Description != "com.example.browser" {
+		t.Errorf("unexpected entries: %+v", result.Entries)
+	}
+	for _, e := range result.Entries {
+		if e.Description == "com.apple.dyld" {
+			t.Error("denylisted com.apple.dyld should be excluded")
+		}
+	}
+	if result.TotalSize != 600 {
+		t.Errorf("total size = %d, want 600", result.TotalSize)
+	}
+}
+
+func TestScanTmpCaches_SkipsOtherUsers(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "com.example.editor"), 0755)
+	writeFile(t, filepath.Join(dir, "com.example.editor", "data.bin"), 100)
+
+	if result := scanTmpCaches(dir, os.Getuid()+1); result != nil {
+		t.Errorf("expected nil result for caches owned by another user, got %+v", result)
+	}
+}
+
+func TestScanTmpCaches_MissingDir(t *testing.T) {
+	if result := scanTmpCaches(filepath.Join(t.TempDir(), "missing"), os.Getuid()); result != nil {
+		t.Errorf("expected nil result, got %+v", result)
+	}
+}
+
+// --- Broken symlink tests ---
+
+func TestScanBrokenSymlinks_FlagsOnlyBroken(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "real-tool")
+	writeFile(t, target, 100)
+	if err := os.Symlink(target, filepath.Join(dir, "valid")); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(dir, "removed-tool")
+	broken := filepath.Join(dir, "broken")
+	if err := os.Symlink(missing, broken); err != nil {
+		t.Fatal(err)
+	}
+
+	result := scanBrokenSymlinks([]string{dir, filepath.Join(dir, "missing")})
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	if result.Category != "system-broken-symlinks" {
+		t.Errorf("expected category 'system-broken-symlinks', got %q", result.Category)
+	}
+	if len(result.Entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(result.Entries))
+	}
+	entry := result.Entries[0]
+	if entry.Path != broken {
+		t.Errorf("expected path %q, got %q", broken, entry.Path)
+	}
+	if entry.Size != 0 || result.TotalSize != 0 {
+		t.Errorf("expected zero size, got entry %d total %d", entry.Size, result.TotalSize)
+	}
+	if want := "broken -> " + missing; entry.Description != want {
+		t.Errorf("expected description %q, got %q", want, entry.Description)
+	}
+
+	res := cleanup.Execute([]scan.CategoryResult{*result}, nil)
+	if res.Removed != 1 || res.Failed != 0 {
+		t.Fatalf("expected 1 removed, 0 failed, got %+v", res)
+	}
+	if _, err := os.Lstat(broken); !os.IsNotExist(err) {
+		t.Error("expected broken symlink to be removed")
+	}
+	if _, err := os.Lstat(filepath.Join(dir, "valid")); err != nil {
+		t.Error("expected valid symlink to be preserved")
+	}
+	if _, err := os.Stat(target); err != nil {
+		t.Error("expected symlink target to be preserved")
+	}
+}
+
+func TestScanBrokenSymlinks_Empty(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "tool"), 100)
+
+	if result := scanBrokenSymlinks([]string{dir}); result != nil {
+		t.Errorf("expected nil result, got %+v", result)
+	}
+}
+
+// --- Installer leftover tests ---
+
+func TestScanInstallerLeftovers_ListsReceiptsAndAppStore(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	receipts := filepath.Join(home, "Library", "Receipts")
+	appStore := filepath.Join(home, "Library", "Caches", "com.apple.appstore")
+	os.MkdirAll(receipts, 0755)
+	os.MkdirAll(filepath.Join(appStore, "1234567"), 0755)
+	writeFile(t, filepath.Join(receipts, "com.example.tool.bom"), 1500)
+	writeFile(t, filepath.Join(receipts, "com.example.tool.plist"), 500)
+	writeFile(t, filepath.Join(appStore, "1234567", "partial.pkg"), 8000)
+
+	result := scanInstallerLeftovers(home, 0)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	result.SetRiskLevels(safety.RiskForCategory)
+	if result.Category != "system-installer-leftovers" {
+		t.Errorf("expected category 'system-installer-leftovers', got %q", result.Category)
+	}
+	if len(result.Entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d: %+v", len(result.Entries), result.Entries)
+	}
+	if result.TotalSize != 10000 {
+		t.Errorf("expected total 10000, got %d", result.TotalSize)
+	}
+	if result.Entries[0].Path != appStore || result.Entries[0].Description != "App Store partial downloads" {
+		t.Errorf("expected App Store cache first, got %+v", result.Entries[0])
+	}
+	for _, e := range result.Entries {
+		if e.RiskLevel != safety.RiskSafe {
+			t.Errorf("entry %s: expected risk %q, got %q", e.Path, safety.RiskSafe, e.RiskLevel)
+		}
+	}
+	if len(result.PermissionIssues) != 0 {
+		t.Errorf("expected no permission issues, got %+v", result.PermissionIssues)
+	}
+}
+
+func TestScanInstallerLeftovers_BlockedAppStoreSkipped(t *testing.T) {
+	home := t.TempDir()
+	// Deletions are contained to another home, so the App Store cache
+	// of this one is blocked.
+	safety.SetHome(t.TempDir())
+	t.Cleanup(func() { safety.SetHome("") })
+	appStore := filepath.Join(home, "Library", "Caches", "com.apple.appstore")
+	os.MkdirAll(filepath.Join(appStore, "1234567"), 0755)
+	writeFile(t, filepath.Join(appStore, "1234567", "partial.pkg"), 8000)
+
+	if result := scanInstallerLeftovers(home, 0); result != nil {
+		t.Errorf("expected the blocked App Store cache not to be listed, got %+v", result)
+	}
+}
+
+func TestScanInstallerLeftovers_Empty(t *testing.T) {
+	if result := scanInstallerLeftovers(t.TempDir(), 0); result != nil {
+		t.Errorf("expected nil, got %+v", result)
+	}
+}
+
+func TestScanICloudDriveCache_Missing(t *testing.T) {
+	if result := scanICloudDriveCache(t.TempDir()); result != nil {
+		t.Errorf("expected nil, got %+v", result)
+	}
+}
+
+func TestScanICloudDriveCache_WithData(t *testing.T) {
+	home := t.TempDir()
+	bird := filepath.Join(home, "Library", "Caches", "com.apple.bird")
+	session := filepath.Join(home, "Library", "Application Support", "CloudDocs", "session", "db")
+	os.MkdirAll(filepath.Join(bird, "sub"), 0755)
+	os.MkdirAll(session, 0755)
+	writeFile(t, filepath.Join(bird, "sub", "chunk.bin"), 7000)
+	writeFile(t, filepath.Join(session, "client.db"), 3000)
+
+	result := scanICloudDriveCache(home)
+	if result == nil {
+		t.Fatal("expected non-nil result")
+	}
+	result.SetRiskLevels(safety.RiskForCategory)
+	if result.Category != "system-iclouddrive-cache" {
+		t.Errorf("expected category 'system-iclouddrive-cache', got %q", result.Category)
+	}
+	if len(result.Entries) != 2 || result.TotalSize != 10000 {
+		t.Fatalf("expected 2 entries totalling 10000, got %d: %+v", result.TotalSize, result.Entries)
+	}
+	if result.Entries[0].Path != bird || !result.Entries[0].IsDir {
+		t.Errorf("expected bird cache first, got %+v", result.Entries[0])
+	}
+	for _, e := range result.Entries {
+		if e.RiskLevel != safety.RiskModerate {
+			t.Errorf("entry %s: expected risk %q, got %q", e.Path, safety.RiskModerate, e.RiskLevel)
+		}
+	}
+}
+
+func TestScanICloudDriveCache_UnreadableReportedAsPermissionIssue(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can read any directory")
+	}
+	home := t.TempDir()
+	bird := filepath.Join(home, "Library", "Caches", "com.apple.bird")
+	os.MkdirAll(bird, 0755)
+	writeFile(t, filepath.Join(bird, "chunk.bin"), 100)
+	os.Chmod(bird, 0000)
+	t.Cleanup(func() { os.Chmod(bird, 0755) })
+
+	result := scanICloudDriveCache(home)
+	if result == nil {
+		t.Fatal("expected a result reporting the permission issue")
+	}
+	if len(result.Entries) != 0 {
+		t.Errorf("expected no entries, got %+v", result.Entries)
+	}
+	if len(result.PermissionIssues) != 1 || result.PermissionIssues[0].Path != bird {
+		t.Errorf("expected one permission issue for %s, got %+v", bird, result.PermissionIssues)
+	}
+}
+
+func TestScan_ICloudDriveCacheNotCountedAsUserCache(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	caches := filepath.Join(home, "Library", "Caches")
+	os.MkdirAll(filepath.Join(caches, "com.apple.bird"), 0755)
+	os.MkdirAll(filepath.Join(caches, "com.example.app"), 0755)
+	writeFile(t, filepath.Join(caches, "com.apple.bird", "chunk.bin"), 5000)
+	writeFile(t, filepath.Join(caches, "com.example.app", "data"), 200)
+
+	results, err := Scan()
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	found := false
+	for _, cr := range results {
+		for _, e := range cr.Entries {
+			if e.Path != filepath.Join(caches, "com.apple.bird") {
+				continue
+			}
+			if cr.Category != "system-iclouddrive-cache" {
+				t.Errorf("bird cache listed under %s", cr.Category)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected bird cache under system-iclouddrive-cache")
+	}
+}
+
+func TestExcludeEntries(t *testing.T) {
+	cr := &scan.CategoryResult{
+		Entries: []scan.ScanEntry{
+			{Path: "/a", Size: 100},
+			{Path: "/b", Size: 200},
+			{Path: "/c", Size: 300},
+		},
+		TotalSize: 600,
+	}
+	excludeEntries(cr, []scan.ScanEntry{{Path: "/b"}})
+
+	if len(cr.Entries) != 2 || cr.Entries[0].Path != "/a" || cr.Entries[1].Path != "/c" {
+		t.Errorf("unexpected entries after exclude: %+v", cr.Entries)
+	}
+	if cr.TotalSize != 400 {
+		t.Errorf("expected total 400, got %d", cr.TotalSize)
+	}
+}
@@ -1,6 +1,7 @@
 // Package systemdata provides scanners for macOS "System Data" contributors
 // including Spotlight metadata, Mail, Messages, iOS software updates,
-// Time Machine local snapshots, and virtual machine disk images.
+// Time Machine local snapshots, unified logs, and virtual machine disk
+// images.
 package systemdata
 
 import (
@@ -12,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sp3esu/mac-cleaner/internal/logging"
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
@@ -28,49 +30,97 @@ func defaultRunner(ctx context.Context, name string, args ...string) ([]byte, er
 
 // Scan discovers and sizes System Data contributors including Spotlight metadata,
 // Mail data, Messages attachments, iOS software updates, Time Machine snapshots,
-// and virtual machine disk images. Missing directories are silently skipped.
+// unified logs, and virtual machine disk images. Missing directories are silently skipped.
 // No files are modified.
 func Scan() ([]scan.CategoryResult, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+	return ScanWithOptions(Options{})
+}
+
+// Options configures ScanWithOptions.
+type Options struct {
+	// NoExec runs no external commands, so sysdata-timemachine, which
+	// needs tmutil, is skipped.
+	NoExec bool
+	// Runner runs tmutil. Nil uses os/exec.
+	Runner CmdRunner
+	// Status receives the status of the categories that need a command,
+	// e.g. when it is not installed. Nil drops them.
+	Status scan.StatusFunc
+	// Home is the home directory to scan, e.g. another account's for an
+	// audit. Empty means the current user's.
+	Home string
+	// MaxDepth bounds how deep the entries of the top-level caches are
+	// sized (see scan.ScanTopLevelDepth). Zero sizes everything.
+	MaxDepth int
+}
+
+// ScanWithOptions is Scan with command execution configured by opts.
+func ScanWithOptions(opts Options) ([]scan.CategoryResult, error) {
+	home := opts.Home
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return nil, fmt.Errorf("cannot determine home directory: %w", err)
+		}
 	}
+	depth := opts.MaxDepth
 
 	var results []scan.CategoryResult
 
-	if cr := scanSpotlight(home); cr != nil {
+	if cr := scanSpotlight(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanMail(home); cr != nil {
+	mail, envelope := scanMail(home, depth), scanMailEnvelope(home)
+	if mail != nil && envelope != nil {
+		excludeEnvelopeSizes(mail, envelope)
+	}
+	if mail != nil {
+		mail.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *mail)
+	}
+	if envelope != nil {
+		envelope.SetRiskLevels(safety.RiskForCategory)
+		results = append(results, *envelope)
+	}
+	if cr := scanMailDownloads(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanMailDownloads(home); cr != nil {
+	if cr := scanMessages(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanMessages(home); cr != nil {
+	if cr := scanIOSUpdates(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanIOSUpdates(home); cr != nil {
+	runner := opts.Runner
+	if runner == nil {
+		runner = defaultRunner
+	}
+	if opts.NoExec {
+		runner = nil
+	}
+	cr, status := scanTimeMachine(runner)
+	opts.Status.Report("sysdata-timemachine", status, "tmutil")
+	if cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanTimeMachine(defaultRunner); cr != nil {
+	if cr := scanUnifiedLogs(unifiedLogDirs); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanVMParallels(home); cr != nil {
+	if cr := scanVMParallels(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanVMUTM(home); cr != nil {
+	if cr := scanVMUTM(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
-	if cr := scanVMVMware(home); cr != nil {
+	if cr := scanVMVMware(home, depth); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
@@ -78,10 +128,59 @@ func Scan() ([]scan.CategoryResult, error) {
 	return results, nil
 }
 
+// categoryDirs lists, for each category scanned from fixed directories,
+// those directories relative to the home directory. Scan and Paths both
+// read it, so the paths listed are the paths scanned.
+var categoryDirs = []struct {
+	category string
+	dirs     [][]string
+}{
+	{"sysdata-spotlight", [][]string{{"Library", "Metadata", "CoreSpotlight"}}},
+	{"sysdata-mail", [][]string{{"Library", "Mail"}}},
+	{"sysdata-mail-downloads", [][]string{{"Library", "Containers", "com.apple.mail", "Data", "Library", "Mail Downloads"}}},
+	{"sysdata-messages", [][]string{{"Library", "Messages", "Attachments"}}},
+	{"sysdata-ios-updates", [][]string{
+		{"Library", "iTunes", "iPhone Software Updates"},
+		{"Library", "iTunes", "iPad Software Updates"},
+	}},
+	{"sysdata-vm-parallels", [][]string{{"Parallels"}}},
+	{"sysdata-vm-utm", [][]string{{"Library", "Containers", "com.utmapp.UTM", "Data", "Documents"}}},
+	{"sysdata-vm-vmware", [][]string{{"Virtual Machines.localized"}}},
+}
+
+// dirsFor returns the directories category is scanned from under home.
+func dirsFor(home, category string) []string {
+	for _, c := range categoryDirs {
+		if c.category != category {
+			continue
+		}
+		paths := make([]string, 0, len(c.dirs))
+		for _, rel := range c.dirs {
+			paths = append(paths, filepath.Join(append([]string{home}, rel...)...))
+		}
+		return paths
+	}
+	return nil
+}
+
+// Paths returns the locations Scan examines, without checking whether
+// they exist. Time Machine snapshots are queried through tmutil and have
+// no path. The Envelope Index files are listed for the Mail versions
+// present, and the root-owned unified log stores are included.
+func Paths(home string) []string {
+	var paths []string
+	for _, c := range categoryDirs {
+		paths = append(paths, dirsFor(home, c.category)...)
+	}
+	envelope, _ := mailEnvelopePaths(home)
+	paths = append(paths, envelope...)
+	return append(paths, unifiedLogDirs...)
+}
+
 // scanSpotlight scans ~/Library/Metadata/CoreSpotlight/.
 // Returns nil if the directory does not exist.
-func scanSpotlight(home string) *scan.CategoryResult {
-	dir := filepath.Join(home, "Library", "Metadata", "CoreSpotlight")
+func scanSpotlight(home string, depth int) *scan.CategoryResult {
+	dir := dirsFor(home, "sysdata-spotlight")[0]
 
 	if _, err := os.Stat(dir); err != nil {
 		if os.IsPermission(err) {
@@ -97,7 +196,7 @@ func scanSpotlight(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "sysdata-spotlight", "CoreSpotlight Metadata")
+	cr, err := scan.ScanTopLevelDepth(dir, "sysdata-spotlight", "CoreSpotlight Metadata", depth)
 	if err != nil {
 		return nil
 	}
@@ -111,23 +210,115 @@ func scanSpotlight(home string) *scan.CategoryResult {
 
 // scanMail scans ~/Library/Mail/.
 // Returns nil if the directory does not exist.
-func scanMail(home string) *scan.CategoryResult {
-	dir := filepath.Join(home, "Library", "Mail")
-	return scanSingleDir(dir, "sysdata-mail", "Mail Database")
+func scanMail(home string, depth int) *scan.CategoryResult {
+	dir := dirsFor(home, "sysdata-mail")[0]
+	return scanSingleDir(dir, "sysdata-mail", "Mail Database", depth)
+}
+
+// mailEnvelopeFiles are the Envelope Index database and its SQLite journal
+// files, found in ~/Library/Mail/V*/MailData/.
+var mailEnvelopeFiles = []string{"Envelope Index", "Envelope Index-wal", "Envelope Index-shm"}
+
+// mailEnvelopePaths returns the Envelope Index files scanMailEnvelope
+// examines, existing or not, in the MailData folder of each Mail version
+// directory (V2, V10, ...) under ~/Library/Mail. The error is that of
+// reading ~/Library/Mail.
+func mailEnvelopePaths(home string) ([]string, error) {
+	mailDir := dirsFor(home, "sysdata-mail")[0]
+	versions, err := os.ReadDir(mailDir)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, v := range versions {
+		if !v.IsDir() || !strings.HasPrefix(v.Name(), "V") {
+			continue
+		}
+		for _, name := range mailEnvelopeFiles {
+			paths = append(paths, filepath.Join(mailDir, v.Name(), "MailData", name))
+		}
+	}
+	return paths, nil
+}
+
+// scanMailEnvelope sizes Mail's Envelope Index (and its -wal/-shm files)
+// under ~/Library/Mail/V*/MailData/, one entry per file. Mail rebuilds the
+// index on its next launch, so this is a targeted subset of the full Mail
+// store; see excludeEnvelopeSizes. Returns nil if no index files exist.
+func scanMailEnvelope(home string) *scan.CategoryResult {
+	const category, description = "sysdata-mail-envelope", "Mail Envelope Index"
+	paths, err := mailEnvelopePaths(home)
+	if err != nil {
+		if os.IsPermission(err) {
+			return &scan.CategoryResult{
+				Category:    category,
+				Description: description,
+				PermissionIssues: []scan.PermissionIssue{{
+					Path:        dirsFor(home, "sysdata-mail")[0],
+					Description: description + " (permission denied)",
+				}},
+			}
+		}
+		return nil
+	}
+
+	var entries []scan.ScanEntry
+	var totalSize int64
+	for _, path := range paths {
+		info, err := os.Lstat(path)
+		if err != nil || !info.Mode().IsRegular() || info.Size() == 0 {
+			continue
+		}
+		size := scan.FileSize(info)
+		version := filepath.Base(filepath.Dir(filepath.Dir(path)))
+		entries = append(entries, scan.ScanEntry{
+			Path:        path,
+			Description: version + "/" + filepath.Base(path),
+			Size:        size,
+		})
+		totalSize += size
+	}
+
+	if len(entries) == 0 {
+		return nil
+	}
+	return &scan.CategoryResult{
+		Category:    category,
+		Description: description,
+		Entries:     entries,
+		TotalSize:   totalSize,
+	}
+}
+
+// excludeEnvelopeSizes takes the size of each index file in envelope out of
+// the sysdata-mail entry holding it, so the bytes are counted once, under
+// sysdata-mail-envelope.
+func excludeEnvelopeSizes(mail, envelope *scan.CategoryResult) {
+	for _, env := range envelope.Entries {
+		for i := range mail.Entries {
+			if !strings.HasPrefix(env.Path, mail.Entries[i].Path+string(filepath.Separator)) {
+				continue
+			}
+			size := min(env.Size, mail.Entries[i].Size)
+			mail.Entries[i].Size -= size
+			mail.TotalSize -= size
+			break
+		}
+	}
 }
 
 // scanMailDownloads scans ~/Library/Containers/com.apple.mail/Data/Library/Mail Downloads/.
 // Returns nil if the directory does not exist.
-func scanMailDownloads(home string) *scan.CategoryResult {
-	dir := filepath.Join(home, "Library", "Containers", "com.apple.mail", "Data", "Library", "Mail Downloads")
-	return scanSingleDir(dir, "sysdata-mail-downloads", "Mail Attachment Cache")
+func scanMailDownloads(home string, depth int) *scan.CategoryResult {
+	dir := dirsFor(home, "sysdata-mail-downloads")[0]
+	return scanSingleDir(dir, "sysdata-mail-downloads", "Mail Attachment Cache", depth)
 }
 
 // scanMessages scans ~/Library/Messages/Attachments/.
 // Returns nil if the directory does not exist.
-func scanMessages(home string) *scan.CategoryResult {
-	dir := filepath.Join(home, "Library", "Messages", "Attachments")
-	return scanSingleDir(dir, "sysdata-messages", "Messages Attachments")
+func scanMessages(home string, depth int) *scan.CategoryResult {
+	dir := dirsFor(home, "sysdata-messages")[0]
+	return scanSingleDir(dir, "sysdata-messages", "Messages Attachments", depth)
 }
 
 // scanIOSUpdates scans iOS/iPad software update directories:
@@ -135,22 +326,25 @@ func scanMessages(home string) *scan.CategoryResult {
 //   - ~/Library/iTunes/iPad Software Updates/
 //
 // Returns nil if neither directory exists.
-func scanIOSUpdates(home string) *scan.CategoryResult {
-	paths := []string{
-		filepath.Join(home, "Library", "iTunes", "iPhone Software Updates"),
-		filepath.Join(home, "Library", "iTunes", "iPad Software Updates"),
-	}
-	return scanMultiDir(paths, "sysdata-ios-updates", "iOS Software Updates")
+func scanIOSUpdates(home string, depth int) *scan.CategoryResult {
+	return scanMultiDir(dirsFor(home, "sysdata-ios-updates"), "sysdata-ios-updates", "iOS Software Updates", depth)
 }
 
 // scanTimeMachine queries tmutil for local APFS snapshots.
 // Snapshots use pseudo-paths (tmutil:snapshot:<name>) since they are not
 // regular filesystem entries. Size is reported as 0 because per-snapshot
 // size is unavailable without root privileges.
-// Returns nil if tmutil is not installed or no snapshots exist.
-func scanTimeMachine(runner CmdRunner) *scan.CategoryResult {
+// Returns nil with StatusDisabled if runner is nil (external commands
+// disabled), StatusToolMissing if tmutil is not installed, StatusError if
+// it fails and StatusNoData if no snapshots exist.
+func scanTimeMachine(runner CmdRunner) (*scan.CategoryResult, scan.ScanStatus) {
+	if runner == nil {
+		logging.Warn("category skipped", "category", "sysdata-timemachine", "reason", "external commands disabled")
+		return nil, scan.StatusDisabled
+	}
 	if _, err := exec.LookPath("tmutil"); err != nil {
-		return nil
+		logging.Debug("command not found", "command", "tmutil")
+		return nil, scan.StatusToolMissing
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -158,12 +352,13 @@ func scanTimeMachine(runner CmdRunner) *scan.CategoryResult {
 
 	out, err := runner(ctx, "tmutil", "listlocalsnapshots", "/")
 	if err != nil {
-		return nil
+		logging.CommandError(ctx, "tmutil listlocalsnapshots", err)
+		return nil, scan.StatusError
 	}
 
 	snapshots := parseTmutilSnapshots(string(out))
 	if len(snapshots) == 0 {
-		return nil
+		return nil, scan.StatusNoData
 	}
 
 	var entries []scan.ScanEntry
@@ -180,7 +375,75 @@ func scanTimeMachine(runner CmdRunner) *scan.CategoryResult {
 		Description: fmt.Sprintf("Time Machine Local Snapshots (%d snapshots)", len(snapshots)),
 		Entries:     entries,
 		TotalSize:   0,
+	}, scan.StatusFound
+}
+
+// unifiedLogDirs lists the root-owned stores of the unified logging system:
+// the log archive and the format strings it references. Removing files
+// from them corrupts the log database, so they are only reported.
+var unifiedLogDirs = []string{
+	"/private/var/db/diagnostics",
+	"/private/var/db/uuidtext",
+}
+
+// scanUnifiedLogs sizes the unified log stores in dirs, one entry each,
+// counting what the current user can read. Entries use pseudo-paths
+// (log:<dir>) and are flagged RequiresRoot so cleanup never removes them;
+// the category is ReportOnly and points to "sudo log erase --all", which
+// clears the logs safely. A store that cannot be read at all, or only in
+// part, is reported as a permission issue. Returns nil if none of dirs
+// exist.
+func scanUnifiedLogs(dirs []string) *scan.CategoryResult {
+	cr := &scan.CategoryResult{
+		Category:    "sysdata-unified-logs",
+		Description: "Unified Logs (clear with sudo log erase --all)",
+		ReportOnly:  true,
+	}
+	for _, dir := range dirs {
+		name := filepath.Base(dir)
+		if _, err := os.Stat(dir); err != nil {
+			if os.IsPermission(err) {
+				cr.PermissionIssues = append(cr.PermissionIssues, scan.PermissionIssue{
+					Path:        dir,
+					Description: name + " (root only)",
+				})
+			}
+			continue
+		}
+		size, issues, err := scan.DirSizeIssues(dir)
+		if err != nil {
+			if os.IsPermission(err) {
+				cr.PermissionIssues = append(cr.PermissionIssues, scan.PermissionIssue{
+					Path:        dir,
+					Description: name + " (root only)",
+				})
+			}
+			continue
+		}
+		if len(issues) > 0 {
+			cr.PermissionIssues = append(cr.PermissionIssues, scan.PermissionIssue{
+				Path:        dir,
+				Description: name + " (partly root only, size is a lower bound)",
+			})
+		}
+		if size == 0 {
+			continue
+		}
+		cr.Entries = append(cr.Entries, scan.ScanEntry{
+			Path:         "log:" + dir,
+			Description:  dir,
+			Size:         size,
+			IsDir:        true,
+			RequiresRoot: true,
+		})
+		cr.TotalSize += size
 	}
+
+	if len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0 {
+		return nil
+	}
+	scan.SortBySize(cr.Entries)
+	return cr
 }
 
 // parseTmutilSnapshots extracts snapshot names from tmutil listlocalsnapshots output.
@@ -208,8 +471,8 @@ func parseTmutilSnapshots(output string) []string {
 
 // scanVMParallels scans ~/Parallels/.
 // Returns nil if the directory does not exist.
-func scanVMParallels(home string) *scan.CategoryResult {
-	dir := filepath.Join(home, "Parallels")
+func scanVMParallels(home string, depth int) *scan.CategoryResult {
+	dir := dirsFor(home, "sysdata-vm-parallels")[0]
 
 	if _, err := os.Stat(dir); err != nil {
 		if os.IsPermission(err) {
@@ -225,7 +488,7 @@ func scanVMParallels(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "sysdata-vm-parallels", "Parallels VMs")
+	cr, err := scan.ScanTopLevelDepth(dir, "sysdata-vm-parallels", "Parallels VMs", depth)
 	if err != nil {
 		return nil
 	}
@@ -239,8 +502,8 @@ func scanVMParallels(home string) *scan.CategoryResult {
 
 // scanVMUTM scans ~/Library/Containers/com.utmapp.UTM/Data/Documents/.
 // Returns nil if the directory does not exist.
-func scanVMUTM(home string) *scan.CategoryResult {
-	dir := filepath.Join(home, "Library", "Containers", "com.utmapp.UTM", "Data", "Documents")
+func scanVMUTM(home string, depth int) *scan.CategoryResult {
+	dir := dirsFor(home, "sysdata-vm-utm")[0]
 
 	if _, err := os.Stat(dir); err != nil {
 		if os.IsPermission(err) {
@@ -256,7 +519,7 @@ func scanVMUTM(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "sysdata-vm-utm", "UTM VMs")
+	cr, err := scan.ScanTopLevelDepth(dir, "sysdata-vm-utm", "UTM VMs", depth)
 	if err != nil {
 		return nil
 	}
@@ -270,8 +533,8 @@ func scanVMUTM(home string) *scan.CategoryResult {
 
 // scanVMVMware scans ~/Virtual Machines.localized/.
 // Returns nil if the directory does not exist.
-func scanVMVMware(home string) *scan.CategoryResult {
-	dir := filepath.Join(home, "Virtual Machines.localized")
+func scanVMVMware(home string, depth int) *scan.CategoryResult {
+	dir := dirsFor(home, "sysdata-vm-vmware")[0]
 
 	if _, err := os.Stat(dir); err != nil {
 		if os.IsPermission(err) {
@@ -287,7 +550,7 @@ func scanVMVMware(home string) *scan.CategoryResult {
 		return nil
 	}
 
-	cr, err := scan.ScanTopLevel(dir, "sysdata-vm-vmware", "VMware Fusion VMs")
+	cr, err := scan.ScanTopLevelDepth(dir, "sysdata-vm-vmware", "VMware Fusion VMs", depth)
 	if err != nil {
 		return nil
 	}
@@ -299,9 +562,10 @@ func scanVMVMware(home string) *scan.CategoryResult {
 	return cr
 }
 
-// scanSingleDir scans a single directory and returns it as a blob entry.
+// scanSingleDir scans a single directory and returns it as a blob entry,
+// or as its top-level entries when scan.ExpandBlobs is on.
 // Returns nil if the directory does not exist or is empty.
-func scanSingleDir(dir, category, description string) *scan.CategoryResult {
+func scanSingleDir(dir, category, description string, depth int) *scan.CategoryResult {
 	if _, err := os.Stat(dir); err != nil {
 		if os.IsPermission(err) {
 			return &scan.CategoryResult{
@@ -316,6 +580,14 @@ func scanSingleDir(dir, category, description string) *scan.CategoryResult {
 		return nil
 	}
 
+	if scan.ExpandBlobs() {
+		cr, err := scan.ScanTopLevelDepth(dir, category, description, depth)
+		if err != nil || (len(cr.Entries) == 0 && len(cr.PermissionIssues) == 0) {
+			return nil
+		}
+		return cr
+	}
+
 	size, err := scan.DirSize(dir)
 	if err != nil {
 		if os.IsPermission(err) {
@@ -343,6 +615,7 @@ func scanSingleDir(dir, category, description string) *scan.CategoryResult {
 				Path:        dir,
 				Description: description,
 				Size:        size,
+				IsDir:       scan.IsDir(dir),
 			},
 		},
 		TotalSize: size,
@@ -351,11 +624,14 @@ func scanSingleDir(dir, category, description string) *scan.CategoryResult {
 
 // scanMultiDir scans multiple directories and combines them into a single
 // CategoryResult. Each existing directory becomes a single blob entry with
-// its total size. Returns nil if no directories exist or all are empty.
-func scanMultiDir(paths []string, category, description string) *scan.CategoryResult {
+// its total size, or contributes its top-level entries when
+// scan.ExpandBlobs is on. Returns nil if no directories exist or all are
+// empty.
+func scanMultiDir(paths []string, category, description string, depth int) *scan.CategoryResult {
 	var entries []scan.ScanEntry
 	var permIssues []scan.PermissionIssue
 	var totalSize int64
+	truncated := false
 
 	for _, dir := range paths {
 		if _, err := os.Stat(dir); err != nil {
@@ -368,6 +644,16 @@ func scanMultiDir(paths []string, category, description string) *scan.CategoryRe
 			continue
 		}
 
+		if scan.ExpandBlobs() {
+			if cr, err := scan.ScanTopLevelDepth(dir, category, description, depth); err == nil {
+				entries = append(entries, cr.Entries...)
+				permIssues = append(permIssues, cr.PermissionIssues...)
+				totalSize += cr.TotalSize
+				truncated = truncated || cr.Truncated
+			}
+			continue
+		}
+
 		size, err := scan.DirSize(dir)
 		if err != nil {
 			if os.IsPermission(err) {
@@ -387,6 +673,7 @@ func scanMultiDir(paths []string, category, description string) *scan.CategoryRe
 			Path:        dir,
 			Description: filepath.Base(dir),
 			Size:        size,
+			IsDir:       scan.IsDir(dir),
 		})
 		totalSize += size
 	}
@@ -401,5 +688,6 @@ func scanMultiDir(paths []string, category, description string) *scan.CategoryRe
 		Entries:          entries,
 		TotalSize:        totalSize,
 		PermissionIssues: permIssues,
+		Truncated:        truncated,
 	}
 }
@@ -5,8 +5,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/sp3esu/mac-cleaner/internal/cleanup"
+	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
@@ -27,7 +30,7 @@ func writeFile(t *testing.T, path string, size int) {
 
 func TestScanSpotlightMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanSpotlight(home)
+	result := scanSpotlight(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Spotlight metadata")
 	}
@@ -40,7 +43,7 @@ func TestScanSpotlightEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanSpotlight(home)
+	result := scanSpotlight(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty Spotlight directory")
 	}
@@ -52,7 +55,7 @@ func TestScanSpotlightWithData(t *testing.T) {
 	writeFile(t, filepath.Join(dir, "index-1", "store.db"), 5000)
 	writeFile(t, filepath.Join(dir, "index-2", "store.db"), 3000)
 
-	result := scanSpotlight(home)
+	result := scanSpotlight(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Spotlight with data")
 	}
@@ -79,7 +82,7 @@ func TestScanSpotlightPermission(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(parent, 0755) })
 
-	result := scanSpotlight(home)
+	result := scanSpotlight(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for permission denied")
 	}
@@ -92,7 +95,7 @@ func TestScanSpotlightPermission(t *testing.T) {
 
 func TestScanMailMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanMail(home)
+	result := scanMail(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Mail directory")
 	}
@@ -105,7 +108,7 @@ func TestScanMailEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanMail(home)
+	result := scanMail(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty Mail directory")
 	}
@@ -116,7 +119,7 @@ func TestScanMailWithData(t *testing.T) {
 	dir := filepath.Join(home, "Library", "Mail")
 	writeFile(t, filepath.Join(dir, "V10", "Mailboxes", "INBOX.mbox", "messages.db"), 10000)
 
-	result := scanMail(home)
+	result := scanMail(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Mail with data")
 	}
@@ -131,6 +134,41 @@ func TestScanMailWithData(t *testing.T) {
 	}
 }
 
+func TestScanMailEnvelopeWithIndexAndWAL(t *testing.T) {
+	home := t.TempDir()
+	mailData := filepath.Join(home, "Library", "Mail", "V10", "MailData")
+	writeFile(t, filepath.Join(mailData, "Envelope Index"), 6000)
+	writeFile(t, filepath.Join(mailData, "Envelope Index-wal"), 2500)
+	writeFile(t, filepath.Join(mailData, "Signatures", "AllSignatures.plist"), 100)
+	writeFile(t, filepath.Join(home, "Library", "Mail", "V10", "Mailboxes", "INBOX.mbox", "messages.db"), 10000)
+
+	result := scanMailEnvelope(home)
+	if result == nil {
+		t.Fatal("expected non-nil result for Envelope Index")
+	}
+	if result.Category != "sysdata-mail-envelope" {
+		t.Errorf("expected category 'sysdata-mail-envelope', got %q", result.Category)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected index and WAL entries, got %+v", result.Entries)
+	}
+	if result.TotalSize != 8500 {
+		t.Errorf("expected combined size 8500, got %d", result.TotalSize)
+	}
+	if result.Entries[1].Path != filepath.Join(mailData, "Envelope Index-wal") {
+		t.Errorf("expected WAL entry, got %q", result.Entries[1].Path)
+	}
+}
+
+func TestScanMailEnvelopeMissing(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, "Library", "Mail", "V10", "Mailboxes", "INBOX.mbox", "messages.db"), 10000)
+
+	if result := scanMailEnvelope(home); result != nil {
+		t.Fatalf("expected nil without an Envelope Index, got %+v", result)
+	}
+}
+
 func TestScanMailPermission(t *testing.T) {
 	home := t.TempDir()
 	dir := filepath.Join(home, "Library", "Mail")
@@ -143,7 +181,7 @@ func TestScanMailPermission(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(parent, 0755) })
 
-	result := scanMail(home)
+	result := scanMail(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for permission denied")
 	}
@@ -156,7 +194,7 @@ func TestScanMailPermission(t *testing.T) {
 
 func TestScanMailDownloadsMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanMailDownloads(home)
+	result := scanMailDownloads(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Mail Downloads")
 	}
@@ -169,7 +207,7 @@ func TestScanMailDownloadsEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanMailDownloads(home)
+	result := scanMailDownloads(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty Mail Downloads directory")
 	}
@@ -180,7 +218,7 @@ func TestScanMailDownloadsWithData(t *testing.T) {
 	dir := filepath.Join(home, "Library", "Containers", "com.apple.mail", "Data", "Library", "Mail Downloads")
 	writeFile(t, filepath.Join(dir, "attachment.pdf"), 7000)
 
-	result := scanMailDownloads(home)
+	result := scanMailDownloads(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Mail Downloads with data")
 	}
@@ -207,7 +245,7 @@ func TestScanMailDownloadsPermission(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(parent, 0755) })
 
-	result := scanMailDownloads(home)
+	result := scanMailDownloads(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for permission denied")
 	}
@@ -220,7 +258,7 @@ func TestScanMailDownloadsPermission(t *testing.T) {
 
 func TestScanMessagesMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanMessages(home)
+	result := scanMessages(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Messages Attachments")
 	}
@@ -233,7 +271,7 @@ func TestScanMessagesEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanMessages(home)
+	result := scanMessages(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty Messages Attachments directory")
 	}
@@ -245,7 +283,7 @@ func TestScanMessagesWithData(t *testing.T) {
 	writeFile(t, filepath.Join(dir, "ab", "photo.heic"), 4000)
 	writeFile(t, filepath.Join(dir, "cd", "video.mov"), 6000)
 
-	result := scanMessages(home)
+	result := scanMessages(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Messages with data")
 	}
@@ -272,7 +310,7 @@ func TestScanMessagesPermission(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(parent, 0755) })
 
-	result := scanMessages(home)
+	result := scanMessages(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for permission denied")
 	}
@@ -285,7 +323,7 @@ func TestScanMessagesPermission(t *testing.T) {
 
 func TestScanIOSUpdatesMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanIOSUpdates(home)
+	result := scanIOSUpdates(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing iOS update directories")
 	}
@@ -302,7 +340,7 @@ func TestScanIOSUpdatesEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanIOSUpdates(home)
+	result := scanIOSUpdates(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty iOS update directories")
 	}
@@ -315,7 +353,7 @@ func TestScanIOSUpdatesWithData(t *testing.T) {
 	writeFile(t, filepath.Join(dir1, "iOS17.ipsw"), 8000)
 	writeFile(t, filepath.Join(dir2, "iPadOS17.ipsw"), 4000)
 
-	result := scanIOSUpdates(home)
+	result := scanIOSUpdates(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for iOS updates with data")
 	}
@@ -335,7 +373,7 @@ func TestScanIOSUpdatesPartial(t *testing.T) {
 	dir := filepath.Join(home, "Library", "iTunes", "iPhone Software Updates")
 	writeFile(t, filepath.Join(dir, "iOS17.ipsw"), 6000)
 
-	result := scanIOSUpdates(home)
+	result := scanIOSUpdates(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for partial iOS updates")
 	}
@@ -359,7 +397,7 @@ func TestScanIOSUpdatesPermission(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(parent, 0755) })
 
-	result := scanIOSUpdates(home)
+	result := scanIOSUpdates(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for permission denied")
 	}
@@ -370,36 +408,58 @@ func TestScanIOSUpdatesPermission(t *testing.T) {
 
 // --- Time Machine tests ---
 
+// fakeTmutilPath creates a temporary directory with a fake tmutil
+// executable and prepends it to PATH so exec.LookPath("tmutil") succeeds.
+func fakeTmutilPath(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "tmutil"), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("create fake tmutil: %v", err)
+	}
+	t.Setenv("PATH", dir+":"+os.Getenv("PATH"))
+}
+
 func TestScanTimeMachineNotInstalled(t *testing.T) {
-	// Use a runner that returns an error (simulating tmutil not found).
-	// The actual LookPath check happens first, so we test via runner error path.
 	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
-		return nil, fmt.Errorf("exit status 1")
+		t.Fatal("runner should not be called when tmutil is not installed")
+		return nil, nil
 	}
-	result := scanTimeMachine(runner)
+	t.Setenv("PATH", t.TempDir())
+	result, status := scanTimeMachine(runner)
 	if result != nil {
-		t.Fatal("expected nil when tmutil returns error")
+		t.Fatal("expected nil when tmutil is not installed")
+	}
+	if status != scan.StatusToolMissing {
+		t.Errorf("expected status %q, got %q", scan.StatusToolMissing, status)
 	}
 }
 
 func TestScanTimeMachineNoSnapshots(t *testing.T) {
+	fakeTmutilPath(t)
 	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
 		return []byte(""), nil
 	}
-	result := scanTimeMachine(runner)
+	result, status := scanTimeMachine(runner)
 	if result != nil {
 		t.Fatal("expected nil for no snapshots")
 	}
+	if status != scan.StatusNoData {
+		t.Errorf("expected status %q, got %q", scan.StatusNoData, status)
+	}
 }
 
 func TestScanTimeMachineWithSnapshots(t *testing.T) {
+	fakeTmutilPath(t)
 	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
 		return []byte("com.apple.TimeMachine.2024-01-15-120000.local\ncom.apple.TimeMachine.2024-01-16-120000.local\n"), nil
 	}
-	result := scanTimeMachine(runner)
+	result, status := scanTimeMachine(runner)
 	if result == nil {
 		t.Fatal("expected non-nil result for snapshots")
 	}
+	if status != scan.StatusFound {
+		t.Errorf("expected status %q, got %q", scan.StatusFound, status)
+	}
 	if result.Category != "sysdata-timemachine" {
 		t.Errorf("expected category 'sysdata-timemachine', got %q", result.Category)
 	}
@@ -418,13 +478,17 @@ func TestScanTimeMachineWithSnapshots(t *testing.T) {
 }
 
 func TestScanTimeMachineError(t *testing.T) {
+	fakeTmutilPath(t)
 	runner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
 		return nil, fmt.Errorf("tmutil: Operation not permitted")
 	}
-	result := scanTimeMachine(runner)
+	result, status := scanTimeMachine(runner)
 	if result != nil {
 		t.Fatal("expected nil when tmutil returns error")
 	}
+	if status != scan.StatusError {
+		t.Errorf("expected status %q, got %q", scan.StatusError, status)
+	}
 }
 
 // --- parseTmutilSnapshots tests ---
@@ -486,7 +550,7 @@ func TestParseTmutilSnapshots(t *testing.T) {
 
 func TestScanVMParallelsMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanVMParallels(home)
+	result := scanVMParallels(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing Parallels directory")
 	}
@@ -499,7 +563,7 @@ func TestScanVMParallelsEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanVMParallels(home)
+	result := scanVMParallels(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty Parallels directory")
 	}
@@ -510,7 +574,7 @@ func TestScanVMParallelsWithData(t *testing.T) {
 	dir := filepath.Join(home, "Parallels")
 	writeFile(t, filepath.Join(dir, "Windows 11.pvm", "disk.hdd"), 50000)
 
-	result := scanVMParallels(home)
+	result := scanVMParallels(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for Parallels with data")
 	}
@@ -536,7 +600,7 @@ func TestScanVMParallelsPermission(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(dir, 0755) })
 
-	result := scanVMParallels(home)
+	result := scanVMParallels(home, 0)
 	// ScanTopLevel should return permission issues.
 	if result == nil {
 		t.Fatal("expected non-nil result for permission denied")
@@ -550,7 +614,7 @@ func TestScanVMParallelsPermission(t *testing.T) {
 
 func TestScanVMUTMMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanVMUTM(home)
+	result := scanVMUTM(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing UTM directory")
 	}
@@ -563,7 +627,7 @@ func TestScanVMUTMEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanVMUTM(home)
+	result := scanVMUTM(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty UTM directory")
 	}
@@ -574,7 +638,7 @@ func TestScanVMUTMWithData(t *testing.T) {
 	dir := filepath.Join(home, "Library", "Containers", "com.utmapp.UTM", "Data", "Documents")
 	writeFile(t, filepath.Join(dir, "Ubuntu.utm", "disk.qcow2"), 30000)
 
-	result := scanVMUTM(home)
+	result := scanVMUTM(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for UTM with data")
 	}
@@ -601,7 +665,7 @@ func TestScanVMUTMPermission(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(parent, 0755) })
 
-	result := scanVMUTM(home)
+	result := scanVMUTM(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for permission denied")
 	}
@@ -614,7 +678,7 @@ func TestScanVMUTMPermission(t *testing.T) {
 
 func TestScanVMVMwareMissing(t *testing.T) {
 	home := t.TempDir()
-	result := scanVMVMware(home)
+	result := scanVMVMware(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for missing VMware directory")
 	}
@@ -627,7 +691,7 @@ func TestScanVMVMwareEmpty(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	result := scanVMVMware(home)
+	result := scanVMVMware(home, 0)
 	if result != nil {
 		t.Fatal("expected nil for empty VMware directory")
 	}
@@ -638,7 +702,7 @@ func TestScanVMVMwareWithData(t *testing.T) {
 	dir := filepath.Join(home, "Virtual Machines.localized")
 	writeFile(t, filepath.Join(dir, "Windows.vmwarevm", "disk.vmdk"), 40000)
 
-	result := scanVMVMware(home)
+	result := scanVMVMware(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for VMware with data")
 	}
@@ -664,7 +728,7 @@ func TestScanVMVMwarePermission(t *testing.T) {
 	}
 	t.Cleanup(func() { os.Chmod(dir, 0755) })
 
-	result := scanVMVMware(home)
+	result := scanVMVMware(home, 0)
 	if result == nil {
 		t.Fatal("expected non-nil result for permission denied")
 	}
@@ -673,6 +737,87 @@ func TestScanVMVMwarePermission(t *testing.T) {
 	}
 }
 
+// --- Unified log tests ---
+
+func TestScanUnifiedLogsMissing(t *testing.T) {
+	base := t.TempDir()
+	if result := scanUnifiedLogs([]string{filepath.Join(base, "diagnostics")}); result != nil {
+		t.Fatal("expected nil for missing unified log stores")
+	}
+}
+
+func TestScanUnifiedLogsReportOnly(t *testing.T) {
+	base := t.TempDir()
+	diagnostics := filepath.Join(base, "diagnostics")
+	uuidtext := filepath.Join(base, "uuidtext")
+	writeFile(t, filepath.Join(diagnostics, "Persist", "0000000000000001.tracev3"), 8000)
+	writeFile(t, filepath.Join(diagnostics, "Special", "0000000000000002.tracev3"), 2000)
+	writeFile(t, filepath.Join(uuidtext, "3F", "A1B2C3"), 500)
+
+	result := scanUnifiedLogs([]string{diagnostics, uuidtext})
+	if result == nil {
+		t.Fatal("expected non-nil result for unified logs")
+	}
+	if result.Category != "sysdata-unified-logs" {
+		t.Errorf("expected category 'sysdata-unified-logs', got %q", result.Category)
+	}
+	if !result.ReportOnly {
+		t.Error("expected unified logs to be report-only")
+	}
+	if !strings.Contains(result.Description, "log erase") {
+		t.Errorf("expected description to suggest log erase, got %q", result.Description)
+	}
+	if len(result.Entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(result.Entries))
+	}
+	if result.Entries[0].Description != diagnostics || result.Entries[0].Size != 10000 {
+		t.Errorf("expected %s with 10000 bytes first, got %+v", diagnostics, result.Entries[0])
+	}
+	if result.TotalSize != 10500 {
+		t.Errorf("expected total size 10500, got %d", result.TotalSize)
+	}
+	for _, e := range result.Entries {
+		if !e.RequiresRoot {
+			t.Errorf("expected %s to require root", e.Description)
+		}
+		if cleanup.Deletable(e, true) {
+			t.Errorf("expected %s to be non-deletable even with sudo", e.Description)
+		}
+	}
+}
+
+func TestScanUnifiedLogsPermission(t *testing.T) {
+	if os.Getuid() == 0 {
+		t.Skip("root can read directories without permissions")
+	}
+	base := t.TempDir()
+	diagnostics := filepath.Join(base, "diagnostics")
+	writeFile(t, filepath.Join(diagnostics, "Persist", "0000000000000001.tracev3"), 4000)
+	private := filepath.Join(diagnostics, "Special")
+	writeFile(t, filepath.Join(private, "0000000000000002.tracev3"), 2000)
+	if err := os.Chmod(private, 0000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(private, 0755) })
+
+	uuidtext := filepath.Join(base, "uuidtext")
+	if err := os.MkdirAll(uuidtext, 0000); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chmod(uuidtext, 0755) })
+
+	result := scanUnifiedLogs([]string{diagnostics, uuidtext})
+	if result == nil {
+		t.Fatal("expected non-nil result for readable unified logs")
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Size != 4000 {
+		t.Fatalf("expected the readable 4000 bytes of diagnostics, got %+v", result.Entries)
+	}
+	if len(result.PermissionIssues) != 2 {
+		t.Fatalf("expected 2 permission issues, got %+v", result.PermissionIssues)
+	}
+}
+
 // --- Integration test ---
 
 func TestScanIntegration(t *testing.T) {
@@ -689,19 +834,19 @@ func TestScanIntegration(t *testing.T) {
 	// No Mail, no iOS updates, no VMs -- should be silently skipped.
 
 	var results []scan.CategoryResult
-	if cr := scanSpotlight(home); cr != nil {
+	if cr := scanSpotlight(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanMail(home); cr != nil {
+	if cr := scanMail(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanMailDownloads(home); cr != nil {
+	if cr := scanMailDownloads(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanMessages(home); cr != nil {
+	if cr := scanMessages(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
-	if cr := scanIOSUpdates(home); cr != nil {
+	if cr := scanIOSUpdates(home, 0); cr != nil {
 		results = append(results, *cr)
 	}
 
@@ -715,3 +860,139 @@ func TestScanIntegration(t *testing.T) {
 		t.Errorf("expected second result 'sysdata-messages', got %q", results[1].Category)
 	}
 }
+
+func TestScanWithOptions_NoExecSkipsTimeMachine(t *testing.T) {
+	home := t.TempDir()
+	writeFile(t, filepath.Join(home, "Library", "Messages", "Attachments", "a.jpg"), 2048)
+	t.Setenv("HOME", home)
+
+	failRunner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		t.Errorf("runner called with %s %v despite NoExec", name, args)
+		return nil, fmt.Errorf("unexpected call")
+	}
+	results, err := ScanWithOptions(Options{NoExec: true, Runner: failRunner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range results {
+		if r.Category == "sysdata-timemachine" {
+			t.Error("sysdata-timemachine needs tmutil and should be skipped under NoExec")
+		}
+	}
+	if len(results) != 1 || results[0].Category != "sysdata-messages" {
+		t.Errorf("expected filesystem categories to still be scanned, got %+v", results)
+	}
+	if result, status := scanTimeMachine(nil); result != nil || status != scan.StatusDisabled {
+		t.Errorf("expected nil Time Machine result with %q without a runner, got %+v, %q", scan.StatusDisabled, result, status)
+	}
+}
+
+func TestScanWithOptions_CountsEnvelopeOnce(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	home := t.TempDir()
+	safety.SetHome(home)
+	t.Cleanup(func() { safety.SetHome("") })
+	mailData := filepath.Join(home, "Library", "Mail", "V10", "MailData")
+	writeFile(t, filepath.Join(home, "Library", "Mail", "V10", "INBOX.mbox", "1.emlx"), 1000)
+	writeFile(t, filepath.Join(mailData, "Envelope Index"), 600)
+	writeFile(t, filepath.Join(mailData, "Envelope Index-wal"), 200)
+
+	results, err := ScanWithOptions(Options{NoExec: true, Home: home})
+	if err != nil {
+		t.Fatal(err)
+	}
+	totals := map[string]int64{}
+	for _, cr := range results {
+		totals[cr.Category] = cr.TotalSize
+	}
+	if totals["sysdata-mail"] != 1000 || totals["sysdata-mail-envelope"] != 800 {
+		t.Errorf("expected Mail 1000 and Envelope Index 800, got %v", totals)
+	}
+}
+
+func TestPathsCoverScannedEntries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	home := t.TempDir()
+	safety.SetHome(home)
+	t.Cleanup(func() { safety.SetHome("") })
+	mailData := filepath.Join(home, "Library", "Mail", "V10", "MailData")
+	for _, p := range []string{
+		filepath.Join(home, "Library", "Metadata", "CoreSpotlight", "index.db"),
+		filepath.Join(home, "Library", "Mail", "V10", "INBOX.mbox", "1.emlx"),
+		filepath.Join(mailData, "Envelope Index"),
+		filepath.Join(mailData, "Envelope Index-wal"),
+		filepath.Join(home, "Library", "Containers", "com.apple.mail", "Data", "Library", "Mail Downloads", "a.pdf"),
+		filepath.Join(home, "Library", "Messages", "Attachments", "a.jpg"),
+		filepath.Join(home, "Library", "iTunes", "iPhone Software Updates", "iOS.ipsw"),
+		filepath.Join(home, "Parallels", "Win.pvm", "disk.hdd"),
+		filepath.Join(home, "Library", "Containers", "com.utmapp.UTM", "Data", "Documents", "Linux.utm", "disk.img"),
+		filepath.Join(home, "Virtual Machines.localized", "Win.vmwarevm", "disk.vmdk"),
+	} {
+		writeFile(t, p, 100)
+	}
+
+	results, err := ScanWithOptions(Options{NoExec: true, Home: home})
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths := Paths(home)
+	covered := func(p string) bool {
+		for _, root := range paths {
+			if p == root || strings.HasPrefix(p, root+string(filepath.Separator)) {
+				return true
+			}
+		}
+		return false
+	}
+	seen := map[string]bool{}
+	for _, cr := range results {
+		for _, e := range cr.Entries {
+			if !strings.HasPrefix(e.Path, home+string(filepath.Separator)) {
+				continue // unified log pseudo-paths
+			}
+			seen[cr.Category] = true
+			if !covered(e.Path) {
+				t.Errorf("%s: entry %s is not under any of Paths", cr.Category, e.Path)
+			}
+		}
+	}
+	if len(seen) != 9 {
+		t.Errorf("expected entries from 9 home categories, got %v", seen)
+	}
+	// The Envelope Index is its own category inside ~/Library/Mail, so it
+	// is listed by itself rather than only through its parent.
+	listed := false
+	for _, p := range paths {
+		listed = listed || p == filepath.Join(mailData, "Envelope Index")
+	}
+	if !listed {
+		t.Errorf("expected Paths to list the Envelope Index, got %v", paths)
+	}
+}
+
+func TestScanWithOptions_AlternateHome(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	other := t.TempDir()
+	safety.SetHome(other)
+	t.Cleanup(func() { safety.SetHome("") })
+	writeFile(t, filepath.Join(other, "Library", "Messages", "Attachments", "a.jpg"), 2048)
+	writeFile(t, filepath.Join(other, "Library", "Metadata", "CoreSpotlight", "index.db"), 1024)
+
+	results, err := ScanWithOptions(Options{NoExec: true, Home: other})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("expected Spotlight and Messages from the alternate home, got %+v", results)
+	}
+	for _, r := range results {
+		if len(r.Entries) == 0 {
+			t.Errorf("%s: expected entries", r.Category)
+		}
+		for _, e := range r.Entries {
+			if !strings.HasPrefix(e.Path, other+string(filepath.Separator)) {
+				t.Errorf("%s: entry %s is outside the scanned home", r.Category, e.Path)
+			}
+		}
+	}
+}
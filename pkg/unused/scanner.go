@@ -13,6 +13,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/sp3esu/mac-cleaner/internal/logging"
 	"github.com/sp3esu/mac-cleaner/internal/safety"
 	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
@@ -37,21 +38,75 @@ const defaultThreshold = 180 * 24 * time.Hour
 // (blocked by the safety system) and require system-level procedures to remove.
 const appleBundleIDPrefix = "com.apple."
 
-// mdlsDateLayout is the time layout returned by mdls -raw for kMDItemLastUsedDate.
+// systemAppDirs lists directories holding apps bundled with macOS. Apps
+// located there, directly or through a symlink, are never flagged.
+var systemAppDirs = []string{"/System/Applications"}
+
+// mdlsDateLayout is the time layout usually returned by mdls -raw for
+// kMDItemLastUsedDate.
 const mdlsDateLayout = "2006-01-02 15:04:05 +0000"
 
+// mdlsDateLayouts lists the layouts parseMdlsDate accepts, in order. Some
+// OS versions and locales print a non-UTC offset, a colon in the offset,
+// an ISO 8601 "T" separator, or no offset at all (taken as UTC).
+var mdlsDateLayouts = []string{
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 -07:00",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02T15:04:05-0700",
+	"2006-01-02 15:04:05",
+}
+
 // Scan discovers applications not opened in 180+ days and returns their
 // total disk footprint (bundle + ~/Library/ data). Missing directories
 // are silently skipped. No files are modified.
 func Scan() ([]scan.CategoryResult, error) {
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("cannot determine home directory: %w", err)
+	return ScanAppDirs(nil)
+}
+
+// ScanAppDirs is Scan with extra application directories searched in
+// addition to the defaults, e.g. ~/Developer/Apps. Missing extra
+// directories are skipped silently; unreadable ones are reported as
+// permission issues.
+func ScanAppDirs(extra []string) ([]scan.CategoryResult, error) {
+	return ScanWithOptions(Options{AppDirs: extra})
+}
+
+// Options configures ScanWithOptions.
+type Options struct {
+	// AppDirs lists extra application directories, as for ScanAppDirs.
+	AppDirs []string
+	// NoExec runs no external commands. Last-used dates come from mdls,
+	// with no filesystem equivalent, so unused-apps is skipped.
+	NoExec bool
+	// Runner runs mdls and PlistBuddy. Nil uses os/exec.
+	Runner CmdRunner
+	// Home is the home directory to scan, e.g. another account's for an
+	// audit. Empty means the current user's.
+	Home string
+}
+
+// ScanWithOptions is ScanAppDirs with command execution configured by
+// opts.
+func ScanWithOptions(opts Options) ([]scan.CategoryResult, error) {
+	home := opts.Home
+	if home == "" {
+		var err error
+		if home, err = os.UserHomeDir(); err != nil {
+			return nil, fmt.Errorf("cannot determine home directory: %w", err)
+		}
 	}
 
 	var results []scan.CategoryResult
 
-	if cr := scanUnusedApps(home, defaultThreshold, defaultRunner); cr != nil {
+	runner := opts.Runner
+	if runner == nil {
+		runner = defaultRunner
+	}
+	if opts.NoExec {
+		runner = nil
+	}
+	if cr := scanUnusedApps(home, AppDirs(home, opts.AppDirs), defaultThreshold, runner); cr != nil {
 		cr.SetRiskLevels(safety.RiskForCategory)
 		results = append(results, *cr)
 	}
@@ -59,16 +114,52 @@ func Scan() ([]scan.CategoryResult, error) {
 	return results, nil
 }
 
-// scanUnusedApps scans application directories for .app bundles that have
-// not been opened within the given threshold. Each entry includes the total
-// footprint: bundle size + associated ~/Library/ directories.
-func scanUnusedApps(home string, threshold time.Duration, runner CmdRunner) *scan.CategoryResult {
-	appDirs := []string{
+// Paths returns the application directories Scan examines, without
+// checking whether they exist. Per-app ~/Library data is located from
+// each discovered bundle and is not listed.
+func Paths(home string) []string {
+	return AppDirs(home, nil)
+}
+
+// AppDirs returns the default application directories followed by extra.
+// A leading "~/" in an extra directory is expanded to home; empty and
+// duplicate entries are dropped.
+func AppDirs(home string, extra []string) []string {
+	dirs := []string{
 		"/Applications",
 		"/Applications/Utilities",
 		filepath.Join(home, "Applications"),
 	}
+	seen := make(map[string]bool, len(dirs)+len(extra))
+	for _, d := range dirs {
+		seen[d] = true
+	}
+	for _, d := range extra {
+		if d == "" {
+			continue
+		}
+		if d == "~" || strings.HasPrefix(d, "~/") {
+			d = filepath.Join(home, d[1:])
+		}
+		d = filepath.Clean(d)
+		if seen[d] {
+			continue
+		}
+		seen[d] = true
+		dirs = append(dirs, d)
+	}
+	return dirs
+}
 
+// scanUnusedApps scans appDirs for .app bundles that have not been opened
+// within the given threshold. Each entry includes the total footprint:
+// bundle size + associated ~/Library/ directories. Returns nil if runner
+// is nil (external commands disabled), since last use cannot be told.
+func scanUnusedApps(home string, appDirs []string, threshold time.Duration, runner CmdRunner) *scan.CategoryResult {
+	if runner == nil {
+		logging.Warn("category skipped", "category", "unused-apps", "reason", "external commands disabled")
+		return nil
+	}
 	cutoff := time.Now().Add(-threshold)
 	plistBuddyPath := "/usr/libexec/PlistBuddy"
 
@@ -95,6 +186,11 @@ func scanUnusedApps(home string, threshold time.Duration, runner CmdRunner) *sca
 
 			appPath := filepath.Join(appDir, entry.Name())
 
+			// Never offer apps bundled with macOS, whatever their usage.
+			if isSystemAppPath(appPath) {
+				continue
+			}
+
 			// Query last-used date via Spotlight metadata.
 			lastUsed, err := queryLastUsedDate(appPath, runner)
 			if err != nil {
@@ -148,6 +244,7 @@ func scanUnusedApps(home string, threshold time.Duration, runner CmdRunner) *sca
 				Path:        appPath,
 				Description: desc,
 				Size:        size,
+				IsDir:       scan.IsDir(appPath),
 			})
 			totalSize += size
 		}
@@ -180,6 +277,7 @@ func queryLastUsedDate(appPath string, runner CmdRunner) (*time.Time, error) {
 
 	out, err := runner(ctx, "mdls", "-name", "kMDItemLastUsedDate", "-raw", appPath)
 	if err != nil {
+		logging.CommandError(ctx, "mdls", err)
 		return nil, fmt.Errorf("mdls failed for %s: %w", appPath, err)
 	}
 
@@ -190,14 +288,25 @@ func queryLastUsedDate(appPath string, runner CmdRunner) (*time.Time, error) {
 		return nil, nil
 	}
 
-	t, err := time.Parse(mdlsDateLayout, raw)
+	t, err := parseMdlsDate(raw)
 	if err != nil {
-		return nil, fmt.Errorf("cannot parse date %q: %w", raw, err)
+		return nil, err
 	}
 
 	return &t, nil
 }
 
+// parseMdlsDate parses an mdls date using the first matching layout in
+// mdlsDateLayouts and returns it in UTC.
+func parseMdlsDate(raw string) (time.Time, error) {
+	for _, layout := range mdlsDateLayouts {
+		if t, err := time.Parse(layout, raw); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("cannot parse date %q", raw)
+}
+
 // extractBundleID reads CFBundleIdentifier from an app's Info.plist.
 // Returns empty string on any error.
 func extractBundleID(appPath, plistBuddyPath string, runner CmdRunner) string {
@@ -208,6 +317,7 @@ func extractBundleID(appPath, plistBuddyPath string, runner CmdRunner) string {
 
 	out, err := runner(ctx, plistBuddyPath, "-c", "Print :CFBundleIdentifier", plistPath)
 	if err != nil {
+		logging.CommandError(ctx, "PlistBuddy", err)
 		return ""
 	}
 
@@ -320,7 +430,7 @@ func pathSize(path string) int64 {
 	}
 
 	if !info.IsDir() {
-		return info.Size()
+		return scan.FileSize(info)
 	}
 
 	size, err := scan.DirSize(path)
@@ -337,10 +447,27 @@ func isAppleApp(bundleID string) bool {
 	return bundleID != "" && strings.HasPrefix(bundleID, appleBundleIDPrefix)
 }
 
+// isSystemAppPath reports whether appPath, or the path it resolves to
+// through symlinks, lies under one of systemAppDirs.
+func isSystemAppPath(appPath string) bool {
+	paths := []string{filepath.Clean(appPath)}
+	if resolved, err := filepath.EvalSymlinks(appPath); err == nil {
+		paths = append(paths, resolved)
+	}
+	for _, p := range paths {
+		for _, dir := range systemAppDirs {
+			if p == dir || strings.HasPrefix(p, dir+string(filepath.Separator)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 // formatDescription formats the app name with its last-used date.
 func formatDescription(appName string, lastUsed *time.Time) string {
 	if lastUsed == nil {
 		return appName + " (no usage history)"
 	}
-	return appName + " (last used " + lastUsed.Format("Jan 2006") + ")"
+	return appName + " (last used " + scan.FormatMonth(*lastUsed) + ")"
 }
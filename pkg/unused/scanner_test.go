@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 	"testing"
 	"time"
+
+	"github.com/sp3esu/mac-cleaner/internal/scan"
 )
 
 // writeFile is a test helper that creates a file with the given size,
@@ -73,7 +75,7 @@ func TestScanUnusedApps_UnusedDetected(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result for unused app")
 	}
@@ -117,7 +119,7 @@ func TestScanUnusedApps_RecentAppSkipped(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result != nil {
 		t.Fatal("expected nil result when all apps are recent")
 	}
@@ -139,7 +141,7 @@ func TestScanUnusedApps_NeverOpened(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result for never-opened app")
 	}
@@ -187,7 +189,7 @@ func TestScanUnusedApps_LibraryFootprintIncluded(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result")
 	}
@@ -211,7 +213,7 @@ func TestScanUnusedApps_MdlsErrorSkipsApp(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result != nil {
 		t.Fatal("expected nil when mdls fails for all apps")
 	}
@@ -236,7 +238,7 @@ func TestScanUnusedApps_PlistBuddyErrorStillScans(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result even when PlistBuddy fails")
 	}
@@ -261,7 +263,7 @@ func TestScanUnusedApps_EmptyAppDirReturnsNil(t *testing.T) {
 
 	runner := newMockRunner(map[string]mockResponse{})
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result != nil {
 		t.Fatal("expected nil for empty app directory")
 	}
@@ -273,7 +275,7 @@ func TestScanUnusedApps_MissingAppDirReturnsNil(t *testing.T) {
 
 	runner := newMockRunner(map[string]mockResponse{})
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result != nil {
 		t.Fatal("expected nil when app directory doesn't exist")
 	}
@@ -301,7 +303,7 @@ func TestScanUnusedApps_SortedBySizeDescending(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result")
 	}
@@ -330,7 +332,7 @@ func TestScanUnusedApps_PermissionErrorCollected(t *testing.T) {
 
 	runner := newMockRunner(map[string]mockResponse{})
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result with permission issues")
 	}
@@ -379,7 +381,7 @@ func TestScanUnusedApps_DateParsingEdgeCases(t *testing.T) {
 			responses[plistKey] = mockResponse{err: fmt.Errorf("no plist")}
 
 			runner := newMockRunner(responses)
-			result := scanUnusedApps(home, defaultThreshold, runner)
+			result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 
 			if tt.wantNil {
 				if result != nil {
@@ -404,7 +406,7 @@ func TestScanUnusedApps_NonAppEntriesSkipped(t *testing.T) {
 
 	runner := newMockRunner(map[string]mockResponse{})
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result != nil {
 		t.Fatal("expected nil when no .app bundles exist")
 	}
@@ -425,6 +427,20 @@ func TestFormatDescription(t *testing.T) {
 			t.Errorf("unexpected description: %q", desc)
 		}
 	})
+
+	t.Run("localized month", func(t *testing.T) {
+		pl, err := scan.LookupLocale("pl")
+		if err != nil {
+			t.Fatal(err)
+		}
+		scan.SetLocale(pl)
+		defer scan.SetLocale(scan.DefaultLocale)
+
+		date := time.Date(2024, 10, 15, 10, 0, 0, 0, time.UTC)
+		if desc := formatDescription("SomeApp", &date); desc != "SomeApp (last used paź 2024)" {
+			t.Errorf("unexpected description: %q", desc)
+		}
+	})
 }
 
 func TestQueryLastUsedDate(t *testing.T) {
@@ -472,6 +488,44 @@ func TestQueryLastUsedDate(t *testing.T) {
 	})
 }
 
+func TestParseMdlsDate(t *testing.T) {
+	want := time.Date(2024, 5, 14, 9, 23, 41, 0, time.UTC)
+	for _, raw := range []string{
+		"2024-05-14 09:23:41 +0000",
+		"2024-05-14 11:23:41 +0200",
+		"2024-05-14 02:23:41 -0700",
+		"2024-05-14 11:23:41 +02:00",
+		"2024-05-14T09:23:41Z",
+		"2024-05-14T04:23:41-0500",
+		"2024-05-14 09:23:41",
+	} {
+		got, err := parseMdlsDate(raw)
+		if err != nil {
+			t.Errorf("parseMdlsDate(%q): unexpected error: %v", raw, err)
+			continue
+		}
+		if !got.Equal(want) {
+			t.Errorf("parseMdlsDate(%q) = %v, want %v", raw, got, want)
+		}
+		if got.Location() != time.UTC {
+			t.Errorf("parseMdlsDate(%q) location = %v, want UTC", raw, got.Location())
+		}
+	}
+}
+
+func TestParseMdlsDate_Malformed(t *testing.T) {
+	for _, raw := range []string{
+		"yesterday",
+		"14/05/2024 09:23",
+		"2024-05-14",
+		"2024-13-45 99:99:99 +0000",
+	} {
+		if _, err := parseMdlsDate(raw); err == nil {
+			t.Errorf("parseMdlsDate(%q): expected error", raw)
+		}
+	}
+}
+
 func TestLibraryFootprint(t *testing.T) {
 	home := t.TempDir()
 
@@ -613,7 +667,7 @@ func TestScanUnusedApps_AppleAppSkipped(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result != nil {
 		t.Fatal("expected nil result: Apple apps should be skipped")
 	}
@@ -647,7 +701,7 @@ func TestScanUnusedApps_AppleAppSkippedMixed(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result: third-party app should be detected")
 	}
@@ -661,6 +715,55 @@ func TestScanUnusedApps_AppleAppSkippedMixed(t *testing.T) {
 	}
 }
 
+func TestScanUnusedApps_SystemAppDirSkipped(t *testing.T) {
+	home := t.TempDir()
+	appDir := filepath.Join(home, "Applications")
+	sysDir := filepath.Join(t.TempDir(), "System", "Applications")
+
+	orig := systemAppDirs
+	systemAppDirs = []string{sysDir}
+	defer func() { systemAppDirs = orig }()
+
+	// A bundled app, reached both directly and through a symlink, and a
+	// third-party app. None has a readable bundle ID.
+	writeFile(t, filepath.Join(sysDir, "Chess.app", "Contents", "MacOS", "Chess"), 4000)
+	writeFile(t, filepath.Join(appDir, "OldApp.app", "Contents", "MacOS", "OldApp"), 3000)
+	if err := os.Symlink(filepath.Join(sysDir, "Chess.app"), filepath.Join(appDir, "Chess.app")); err != nil {
+		t.Fatal(err)
+	}
+
+	oldDate := time.Now().Add(-365 * 24 * time.Hour).Format(mdlsDateLayout)
+	runner := newMockRunner(map[string]mockResponse{
+		"mdls":                    {output: []byte(oldDate)},
+		"/usr/libexec/PlistBuddy": {err: fmt.Errorf("plist not found")},
+	})
+
+	result := scanUnusedApps(home, AppDirs(home, []string{sysDir}), defaultThreshold, runner)
+	if result == nil {
+		t.Fatal("expected non-nil result: third-party app should be detected")
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Path != filepath.Join(appDir, "OldApp.app") {
+		t.Errorf("expected only OldApp.app, got %+v", result.Entries)
+	}
+}
+
+func TestIsSystemAppPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/System/Applications/Chess.app", true},
+		{"/System/Applications/Utilities/Terminal.app", true},
+		{"/System/ApplicationsExtra/Foo.app", false},
+		{"/Applications/Foo.app", false},
+	}
+	for _, tt := range tests {
+		if got := isSystemAppPath(tt.path); got != tt.want {
+			t.Errorf("isSystemAppPath(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
+
 func TestScanUnusedApps_UnknownBundleIDNotSkipped(t *testing.T) {
 	home := t.TempDir()
 	appDir := filepath.Join(home, "Applications")
@@ -680,7 +783,7 @@ func TestScanUnusedApps_UnknownBundleIDNotSkipped(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result: app with unknown bundleID should not be skipped")
 	}
@@ -715,7 +818,7 @@ func TestScanUnusedApps_RecentLibraryDataSkipsApp(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result != nil {
 		t.Fatal("expected nil result: app with recent Library data should be skipped")
 	}
@@ -747,7 +850,7 @@ func TestScanUnusedApps_OldLibraryDataStillDetected(t *testing.T) {
 
 	runner := newMockRunner(responses)
 
-	result := scanUnusedApps(home, defaultThreshold, runner)
+	result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner)
 	if result == nil {
 		t.Fatal("expected non-nil result for app with old Library data")
 	}
@@ -760,3 +863,71 @@ func TestScanUnusedApps_OldLibraryDataStillDetected(t *testing.T) {
 		t.Errorf("expected TrulyOld.app, got %q", result.Entries[0].Path)
 	}
 }
+
+func TestScanUnusedApps_ExtraAppDir(t *testing.T) {
+	home := t.TempDir()
+	appDir := filepath.Join(home, "Developer", "Apps")
+	appPath := filepath.Join(appDir, "OldTool.app")
+	writeFile(t, filepath.Join(appPath, "Contents", "MacOS", "OldTool"), 3000)
+
+	oldDate := time.Now().Add(-365 * 24 * time.Hour).Format(mdlsDateLayout)
+	runner := newMockRunner(map[string]mockResponse{
+		"mdls -name kMDItemLastUsedDate -raw " + appPath: {output: []byte(oldDate)},
+	})
+
+	// Without the extra directory the app is not found.
+	if result := scanUnusedApps(home, AppDirs(home, nil), defaultThreshold, runner); result != nil {
+		t.Fatalf("expected nil result without extra dir, got %+v", result)
+	}
+
+	dirs := AppDirs(home, []string{"~/Developer/Apps", filepath.Join(home, "missing")})
+	result := scanUnusedApps(home, dirs, defaultThreshold, runner)
+	if result == nil {
+		t.Fatal("expected non-nil result with extra dir")
+	}
+	if len(result.Entries) != 1 || result.Entries[0].Path != appPath {
+		t.Fatalf("expected %s detected as unused, got %+v", appPath, result.Entries)
+	}
+	if len(result.PermissionIssues) != 0 {
+		t.Errorf("expected missing extra dir to be skipped silently, got %+v", result.PermissionIssues)
+	}
+}
+
+func TestAppDirs(t *testing.T) {
+	home := "/Users/test"
+	got := AppDirs(home, []string{"~/Developer/Apps", "", "/Applications", "/opt/apps/"})
+	want := []string{
+		"/Applications",
+		"/Applications/Utilities",
+		"/Users/test/Applications",
+		"/Users/test/Developer/Apps",
+		"/opt/apps",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("AppDirs = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("AppDirs[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+func TestScanWithOptions_NoExecSkipsUnusedApps(t *testing.T) {
+	home := t.TempDir()
+	appDir := filepath.Join(home, "Apps")
+	writeFile(t, filepath.Join(appDir, "Old.app", "Contents", "MacOS", "Old"), 5000)
+	t.Setenv("HOME", home)
+
+	failRunner := func(ctx context.Context, name string, args ...string) ([]byte, error) {
+		t.Errorf("runner called with %s %v despite NoExec", name, args)
+		return nil, fmt.Errorf("unexpected call")
+	}
+	results, err := ScanWithOptions(Options{AppDirs: []string{appDir}, NoExec: true, Runner: failRunner})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 0 {
+		t.Errorf("expected unused-apps skipped under NoExec, got %+v", results)
+	}
+}